@@ -7,16 +7,30 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"hl-carry-bot/internal/accounting"
 	"hl-carry-bot/internal/app"
 	"hl-carry-bot/internal/config"
 	"hl-carry-bot/internal/logging"
+	"hl-carry-bot/internal/tracing"
 
 	"go.uber.org/zap"
 )
 
+// runner is a single-account App or the multi-account orchestrator.
+type runner interface {
+	Run(ctx context.Context) error
+	RunBackfill(ctx context.Context, start time.Time) (accounting.Result, error)
+	Reload(cfg *config.Config) error
+	Preflight(ctx context.Context) app.PreflightReport
+}
+
 func main() {
 	configPath := flag.String("config", "internal/config/config.yaml", "path to config file")
+	backfill := flag.Bool("backfill", false, "backfill fill/funding history into the accounting journal and exit")
+	backfillStart := flag.String("backfill-start", "", "backfill start date (YYYY-MM-DD or RFC3339); defaults to accounting.backfill_start")
+	preflight := flag.Bool("preflight", false, "run the startup checks, print a pass/fail report and exit (non-zero on failure)")
 	flag.Parse()
 
 	if err := config.LoadEnv(".env"); err != nil {
@@ -30,7 +44,25 @@ func main() {
 	log := logging.New(cfg.Log)
 	log.Info("config loaded", zap.String("path", *configPath))
 
-	application, err := app.New(cfg, log)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Error("failed to initialize tracing", zap.Error(err))
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Warn("tracing shutdown failed", zap.Error(err))
+		}
+	}()
+
+	var application runner
+	if len(cfg.Accounts) > 0 {
+		application, err = app.NewMulti(cfg, log)
+	} else {
+		application, err = app.New(cfg, log)
+	}
 	if err != nil {
 		log.Error("failed to initialize app", zap.Error(err))
 		os.Exit(1)
@@ -40,8 +72,60 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	if *preflight {
+		report := application.Preflight(ctx)
+		fmt.Print(report.String())
+		if !report.Passed() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *backfill {
+		raw := cfg.Accounting.BackfillStart
+		if *backfillStart != "" {
+			raw = *backfillStart
+		}
+		start, err := config.ParseBackfillStart(raw, time.Now())
+		if err != nil {
+			log.Error("invalid backfill start", zap.Error(err))
+			os.Exit(1)
+		}
+		if _, err := application.RunBackfill(ctx, start); err != nil {
+			log.Error("accounting backfill failed", zap.Error(err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	go reloadOnSIGHUP(ctx, *configPath, application, log)
+
 	if err := application.Run(ctx); err != nil && err != context.Canceled {
 		log.Error("app terminated", zap.Error(err))
 		os.Exit(1)
 	}
 }
+
+// reloadOnSIGHUP re-reads the config file on every SIGHUP and hands it to the
+// running bot, which applies the fields that can change without a restart.
+func reloadOnSIGHUP(ctx context.Context, path string, application runner, log *zap.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+		log.Info("SIGHUP received, reloading config", zap.String("path", path))
+		cfg, err := config.Load(path)
+		if err != nil {
+			log.Warn("config reload failed", zap.Error(err))
+			continue
+		}
+		if err := application.Reload(cfg); err != nil {
+			log.Warn("config reload rejected", zap.Error(err))
+		}
+	}
+}
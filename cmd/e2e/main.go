@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/e2e"
+	"hl-carry-bot/internal/logging"
+)
+
+const defaultE2EEnvFile = ".env"
+
+func main() {
+	envFile := flag.String("env", defaultE2EEnvFile, "env file with HL_E2E_* credentials")
+	timeout := flag.Duration("timeout", 3*time.Minute, "overall run timeout")
+	flag.Parse()
+
+	if err := config.LoadEnv(*envFile); err != nil {
+		fatal(err)
+	}
+	cfg, err := e2e.ConfigFromEnv()
+	if err != nil {
+		fatal(err)
+	}
+	log := logging.New(config.LoggingConfig{Level: "info"})
+	defer func() { _ = log.Sync() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	report, runErr := e2e.Run(ctx, cfg, log)
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Println(string(out))
+	if runErr != nil {
+		fatal(runErr)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
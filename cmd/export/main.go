@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/export"
+	"hl-carry-bot/internal/state/backend"
+	"hl-carry-bot/internal/state/sqlite"
+)
+
+func main() {
+	configPath := flag.String("config", "internal/config/config.yaml", "path to config file (for the state store)")
+	dbPath := flag.String("db", "", "SQLite state file to export; defaults to the configured state store")
+	start := flag.String("start", "", "first day or time to export (YYYY-MM-DD or RFC3339); defaults to the first record")
+	end := flag.String("end", "", "last day or time to export, inclusive (YYYY-MM-DD or RFC3339); defaults to the latest record")
+	outDir := flag.String("out", "export", "directory to write the CSV files to")
+	byAsset := flag.Bool("by-asset", false, "write fills and funding to one file per asset")
+	flag.Parse()
+
+	opts := export.Options{ByAsset: *byAsset}
+	if *start != "" {
+		ts, err := parseDate(*start, false)
+		if err != nil {
+			fatal(fmt.Errorf("-start: %w", err))
+		}
+		opts.StartMS = ts.UnixMilli()
+	}
+	if *end != "" {
+		ts, err := parseDate(*end, true)
+		if err != nil {
+			fatal(fmt.Errorf("-end: %w", err))
+		}
+		opts.EndMS = ts.UnixMilli()
+	}
+	if opts.EndMS > 0 && opts.EndMS < opts.StartMS {
+		fatal(errors.New("-end is before -start"))
+	}
+	src, err := openSource(*configPath, *dbPath)
+	if err != nil {
+		fatal(err)
+	}
+	defer src.Close()
+	written, err := export.Write(context.Background(), src, *outDir, opts)
+	for _, file := range written {
+		fmt.Println(file)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+type source interface {
+	export.Source
+	Close() error
+}
+
+// openSource opens the SQLite file at dbPath, or the state store configured
+// in configPath when dbPath is empty.
+func openSource(configPath, dbPath string) (source, error) {
+	if dbPath == "" {
+		if err := config.LoadEnv(".env"); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load .env: %v\n", err)
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.State.Backend == "postgres" {
+			store, err := backend.Open(cfg)
+			if err != nil {
+				return nil, err
+			}
+			src, ok := store.(source)
+			if !ok {
+				_ = store.Close()
+				return nil, errors.New("state store does not support the accounting journal")
+			}
+			return src, nil
+		}
+		dbPath = cfg.State.SQLitePath
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, err
+	}
+	return sqlite.New(dbPath)
+}
+
+// parseDate reads YYYY-MM-DD (UTC) or RFC3339. A date given as an end bound
+// covers the whole day.
+func parseDate(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if ts, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			ts = ts.Add(24*time.Hour - time.Millisecond)
+		}
+		return ts, nil
+	}
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("must be YYYY-MM-DD or RFC3339")
+	}
+	return ts, nil
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
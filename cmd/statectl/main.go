@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	persist "hl-carry-bot/internal/state"
+	"hl-carry-bot/internal/state/backend"
+	"hl-carry-bot/internal/state/sqlite"
+)
+
+const usage = `usage:
+  statectl export [-config path] [-db state.db] -out bundle.json
+  statectl import [-config path] [-db state.db] -in bundle.json [-source-stopped]`
+
+func main() {
+	if len(os.Args) < 2 {
+		fatal(errors.New(usage))
+	}
+	switch os.Args[1] {
+	case "export":
+		runExport(os.Args[2:])
+	case "import":
+		runImport(os.Args[2:])
+	default:
+		fatal(errors.New(usage))
+	}
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	configPath := fs.String("config", "internal/config/config.yaml", "path to config file (for the state store)")
+	dbPath := fs.String("db", "", "SQLite state file to export; defaults to the configured state store")
+	out := fs.String("out", "", "bundle file to write")
+	_ = fs.Parse(args)
+	if *out == "" {
+		fatal(errors.New("-out is required"))
+	}
+	store, err := openStore(*configPath, *dbPath, true)
+	if err != nil {
+		fatal(err)
+	}
+	defer store.Close()
+	host, _ := os.Hostname()
+	bundle, err := persist.ExportBundle(context.Background(), store, host, time.Now())
+	if err != nil {
+		fatal(err)
+	}
+	payload, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		fatal(err)
+	}
+	if err := os.WriteFile(*out, payload, 0o600); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("exported %d keys, %d fills, %d funding payments, %d foreign activity, %d lifecycle events, %d transfers to %s\n",
+		len(bundle.KV), len(bundle.Fills), len(bundle.Funding), len(bundle.ForeignActivity), len(bundle.Lifecycle), len(bundle.Transfers), *out)
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	configPath := fs.String("config", "internal/config/config.yaml", "path to config file (for the state store)")
+	dbPath := fs.String("db", "", "SQLite state file to import into; defaults to the configured state store")
+	in := fs.String("in", "", "bundle file to read")
+	sourceStopped := fs.Bool("source-stopped", false, "the bot on the source host was stopped before the export")
+	_ = fs.Parse(args)
+	if *in == "" {
+		fatal(errors.New("-in is required"))
+	}
+	payload, err := os.ReadFile(*in)
+	if err != nil {
+		fatal(err)
+	}
+	var bundle persist.Bundle
+	if err := json.Unmarshal(payload, &bundle); err != nil {
+		fatal(fmt.Errorf("%s: %w", *in, err))
+	}
+	store, err := openStore(*configPath, *dbPath, false)
+	if err != nil {
+		fatal(err)
+	}
+	defer store.Close()
+	if err := persist.ImportBundle(context.Background(), store, bundle, *sourceStopped, time.Now()); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("imported %d keys, %d fills, %d funding payments, %d foreign activity, %d lifecycle events, %d transfers from %s (exported %s on %s)\n",
+		len(bundle.KV), len(bundle.Fills), len(bundle.Funding), len(bundle.ForeignActivity), len(bundle.Lifecycle), len(bundle.Transfers),
+		*in, time.UnixMilli(bundle.ExportedAtMS).UTC().Format(time.RFC3339), bundle.Host)
+}
+
+// openStore opens the SQLite file at dbPath, or the state store configured
+// in configPath when dbPath is empty. An export requires the file to exist.
+func openStore(configPath, dbPath string, mustExist bool) (persist.Store, error) {
+	if dbPath == "" {
+		if err := config.LoadEnv(".env"); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load .env: %v\n", err)
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.State.Backend == "sqlite" && mustExist {
+			if _, err := os.Stat(cfg.State.SQLitePath); err != nil {
+				return nil, err
+			}
+		}
+		return backend.Open(cfg)
+	}
+	if mustExist {
+		if _, err := os.Stat(dbPath); err != nil {
+			return nil, err
+		}
+	}
+	return sqlite.New(dbPath)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"hl-carry-bot/internal/alerts"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/market"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLoopInterval  = 5 * time.Minute
+	defaultLoopOffsetBps = 500
+	loopCheckTimeout     = 30 * time.Second
+)
+
+// Keep-alive check results, the label values of hl_carry_bot_verify_checks_total.
+const (
+	checkOK       = "ok"
+	checkSigned   = "signed"
+	checkMarket   = "market"
+	checkSign     = "sign"
+	checkPlace    = "place"
+	checkRejected = "rejected"
+	checkCancel   = "cancel"
+)
+
+var checkResults = []string{checkOK, checkSigned, checkMarket, checkSign, checkPlace, checkRejected, checkCancel}
+
+// keepAlive periodically places a post-only order far from the mid on the
+// passive side and cancels it straight away, so signing, nonces and exchange
+// connectivity are exercised from this host without trading. With dryRun the
+// order is only signed locally and the mid fetched: nothing is sent to
+// /exchange, so a passing check (checkSigned) says nothing about the key's
+// standing on the exchange and never counts as a last success.
+type keepAlive struct {
+	log       *zap.Logger
+	md        *market.MarketData
+	ex        *exchange.Client
+	signer    *exchange.Signer
+	target    target
+	isBuy     bool
+	notional  float64
+	offsetBps int
+	dryRun    bool
+	alerts    *alerts.Telegram
+
+	checks      *prometheus.CounterVec
+	latency     prometheus.Histogram
+	lastSuccess prometheus.Gauge
+	registry    *prometheus.Registry
+
+	failing bool
+}
+
+func newKeepAlive(log *zap.Logger, md *market.MarketData, ex *exchange.Client, signer *exchange.Signer, tgt target, isBuy bool, notional float64, offsetBps int, dryRun bool, alertsClient *alerts.Telegram) *keepAlive {
+	k := &keepAlive{
+		log:       log,
+		md:        md,
+		ex:        ex,
+		signer:    signer,
+		target:    tgt,
+		isBuy:     isBuy,
+		notional:  notional,
+		offsetBps: offsetBps,
+		dryRun:    dryRun,
+		alerts:    alertsClient,
+		checks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hl_carry_bot_verify_checks_total",
+			Help: "Keep-alive verification checks by result (ok, or the step that failed).",
+		}, []string{"result"}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "hl_carry_bot_verify_check_seconds",
+			Help:    "Duration of a keep-alive verification check, place through cancel.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "hl_carry_bot_verify_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful keep-alive verification check.",
+		}),
+		registry: prometheus.NewRegistry(),
+	}
+	k.registry.MustRegister(k.checks, k.latency, k.lastSuccess)
+	for _, result := range checkResults {
+		k.checks.WithLabelValues(result)
+	}
+	return k
+}
+
+// Run checks once immediately and then every interval until ctx is done.
+func (k *keepAlive) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		k.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (k *keepAlive) tick(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, loopCheckTimeout)
+	defer cancel()
+	start := time.Now()
+	result, err := k.check(checkCtx)
+	elapsed := time.Since(start)
+	if ctx.Err() != nil {
+		return
+	}
+	k.checks.WithLabelValues(result).Inc()
+	k.latency.Observe(elapsed.Seconds())
+	if err != nil {
+		k.log.Warn("keep-alive check failed", zap.String("step", result), zap.Duration("elapsed", elapsed), zap.Error(err))
+		fmt.Printf("keep-alive: %s failed after %s: %v\n", result, elapsed.Round(time.Millisecond), err)
+		if !k.failing {
+			k.failing = true
+			k.alert(ctx, fmt.Sprintf("keep-alive verification failed at %s: %v", result, err))
+		}
+		return
+	}
+	if result == checkSigned {
+		fmt.Printf("keep-alive: signed locally in %s (dry run: nothing sent, not validated by the exchange)\n", elapsed.Round(time.Millisecond))
+	} else {
+		k.lastSuccess.Set(float64(time.Now().Unix()))
+		fmt.Printf("keep-alive: ok in %s\n", elapsed.Round(time.Millisecond))
+	}
+	if k.failing {
+		k.failing = false
+		k.alert(ctx, "keep-alive verification recovered")
+	}
+}
+
+// check runs one place/cancel round and returns the step that failed, or
+// checkOK (checkSigned for a dry run).
+func (k *keepAlive) check(ctx context.Context) (string, error) {
+	order, err := k.order(ctx)
+	if err != nil {
+		return checkMarket, err
+	}
+	if k.dryRun {
+		action := exchange.OrderAction{Type: "order", Orders: []exchange.OrderWire{order}, Grouping: "na"}
+		if _, err := k.signer.SignOrderAction(action, uint64(time.Now().UnixMilli()), nil, nil); err != nil {
+			return checkSign, err
+		}
+		return checkSigned, nil
+	}
+	resp, err := k.ex.PlaceOrder(ctx, order)
+	if err != nil {
+		return checkPlace, err
+	}
+	results, err := exchange.OrderResults(resp)
+	if err != nil {
+		return checkPlace, err
+	}
+	if len(results) == 0 {
+		return checkPlace, errors.New("order response has no statuses")
+	}
+	result := results[0]
+	switch result.Status {
+	case exchange.OrderStatusResting:
+	case exchange.OrderStatusRejected:
+		return checkRejected, result.Err
+	default:
+		// A fill means the offset did not keep the order off the book.
+		return checkRejected, fmt.Errorf("order %s instead of resting (order_id=%s)", result.Status, result.OrderID)
+	}
+	// The order rests until cancelled, so the cancel must not inherit a
+	// context that is about to expire.
+	cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loopCheckTimeout)
+	defer cancel()
+	if err := cancelByID(cancelCtx, k.ex, k.target.AssetID, result.OrderID); err != nil {
+		return checkCancel, err
+	}
+	return checkOK, nil
+}
+
+// order builds the post-only order offsetBps from the current mid on the
+// passive side, sized up to at least notional at that price.
+func (k *keepAlive) order(ctx context.Context) (exchange.OrderWire, error) {
+	mid, err := k.target.mid(ctx, k.md)
+	if err != nil {
+		return exchange.OrderWire{}, err
+	}
+	price := k.target.Increments.RoundPrice(limitPrice(mid, k.isBuy, exchange.TifAlo, k.offsetBps))
+	if price <= 0 {
+		return exchange.OrderWire{}, errors.New("keep-alive price <= 0 after tick rounding")
+	}
+	size := k.target.Increments.RoundSizeUp(k.notional / price)
+	if size <= 0 {
+		return exchange.OrderWire{}, errors.New("keep-alive size <= 0 after rounding")
+	}
+	return exchange.LimitOrderWire(k.target.AssetID, k.isBuy, size, price, false, exchange.TifAlo, "")
+}
+
+func (k *keepAlive) alert(ctx context.Context, message string) {
+	if k.alerts == nil {
+		return
+	}
+	if err := k.alerts.Send(ctx, message); err != nil {
+		k.log.Warn("keep-alive alert failed", zap.Error(err))
+	}
+}
+
+// serveMetrics exposes the keep-alive metrics on addr until ctx is done.
+func (k *keepAlive) serveMetrics(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(k.registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		k.log.Warn("keep-alive metrics server failed", zap.Error(err))
+	}
+}
+
+// loopPrefix labels keep-alive alerts with the host they ran from.
+func loopPrefix() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "[verify] "
+	}
+	return "[verify " + host + "] "
+}
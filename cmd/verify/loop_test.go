@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hltest"
+
+	"go.uber.org/zap"
+)
+
+func newTestKeepAlive(t *testing.T, server *hltest.Server, dryRun bool) *keepAlive {
+	t.Helper()
+	md := newTestMarket(t, server)
+	tgt, err := resolveTarget(md, marketPerp, "ETH")
+	if err != nil {
+		t.Fatalf("resolve target: %v", err)
+	}
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	return newKeepAlive(zap.NewNop(), md, client, signer, tgt, true, defaultVerifyNotional, defaultLoopOffsetBps, dryRun, nil)
+}
+
+func TestKeepAliveCheckPlacesAndCancels(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	checker := newTestKeepAlive(t, server, false)
+	ctx := context.Background()
+
+	if result, err := checker.check(ctx); result != checkOK || err != nil {
+		t.Fatalf("expected a passing check, got %s (err=%v)", result, err)
+	}
+	orders := server.Orders()
+	if len(orders) != 1 {
+		t.Fatalf("expected one keep-alive order, got %+v", orders)
+	}
+	order := orders[0]
+	if order.Asset != hltest.PerpAsset || !order.IsBuy || order.OrderType.Limit == nil || order.OrderType.Limit.Tif != exchange.TifAlo {
+		t.Fatalf("expected a post-only perp buy, got %+v", order)
+	}
+	price, _ := strconv.ParseFloat(order.Price, 64)
+	size, _ := strconv.ParseFloat(order.Size, 64)
+	if price >= 3000 || size*price < config.MinOrderValueUSD {
+		t.Fatalf("expected a passive order of at least the minimum value, got %s @ %s", order.Size, order.Price)
+	}
+	if cancels := server.Cancels(); len(cancels) != 1 || cancels[0].Asset != hltest.PerpAsset {
+		t.Fatalf("expected the resting order cancelled, got %+v", cancels)
+	}
+	if open := server.OpenOrderIDs(); len(open) != 0 {
+		t.Fatalf("expected nothing left resting, got %v", open)
+	}
+
+	server.RejectNextOrder("Insufficient margin to place order.")
+	if result, err := checker.check(ctx); result != checkRejected || err == nil {
+		t.Fatalf("expected a rejected check, got %s (err=%v)", result, err)
+	}
+	if cancels := server.Cancels(); len(cancels) != 1 {
+		t.Fatalf("expected no cancel after a rejection, got %+v", cancels)
+	}
+}
+
+func TestKeepAliveDryRunOnlySigns(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	checker := newTestKeepAlive(t, server, true)
+
+	if result, err := checker.check(context.Background()); result != checkSigned || err != nil {
+		t.Fatalf("expected a local signing pass, got %s (err=%v)", result, err)
+	}
+	if orders := server.Orders(); len(orders) != 0 {
+		t.Fatalf("expected nothing sent on a dry run, got %+v", orders)
+	}
+}
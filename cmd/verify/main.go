@@ -6,26 +6,30 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"math"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/alerts"
 	"hl-carry-bot/internal/config"
 	"hl-carry-bot/internal/hl/exchange"
 	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/keystore"
 	"hl-carry-bot/internal/logging"
 	"hl-carry-bot/internal/market"
-	"hl-carry-bot/internal/state/sqlite"
+	"hl-carry-bot/internal/state/backend"
 
 	"go.uber.org/zap"
 )
 
 const (
-	defaultVerifyNotional = 5.0
+	// defaultVerifyNotional is the smallest order the exchange accepts; sizes
+	// round up so the order never lands below it.
+	defaultVerifyNotional = config.MinOrderValueUSD
 	defaultSlippageBps    = 20
 	defaultRESTTimeout    = 10 * time.Second
 	defaultRESTBaseURL    = "https://api.hyperliquid.xyz"
@@ -34,63 +38,65 @@ const (
 )
 
 func main() {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		runSubcommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	configPath := flag.String("config", "", "optional config path for REST settings")
 	dryRun := flag.Bool("dry-run", false, "print the derived order and exit")
 	userFunding := flag.Bool("user-funding", false, "fetch and print /info userFunding and exit")
 	fundingStartMS := flag.Int64("funding-start-ms", 0, "startTime (ms since epoch) for userFunding query")
 	fundingHours := flag.Int("funding-hours", 0, "lookback hours for userFunding query (used if funding-start-ms is 0)")
+	marketKind := flag.String("market", marketSpot, "market to place the verification order on: spot or perp")
+	side := flag.String("side", "buy", "order side: buy or sell")
+	reduceOnly := flag.Bool("reduce-only", false, "mark the verification order reduce-only")
+	tifFlag := flag.String("tif", string(defaultLimitTif), "time in force: Ioc (crosses by the slippage), Alo or Gtc (rest by the slippage away from the mid)")
+	cloidFlag := flag.String("cloid", "", "client order id: 0x + 32 hex digits, or auto for a random one")
+	cancelResting := flag.Bool("cancel", false, "cancel the order by oid if it rests")
+	loop := flag.Bool("loop", false, "keep placing and cancelling a resting post-only order to verify signing, nonces and connectivity (with -dry-run: a local signing check only, nothing is sent to the exchange)")
+	loopInterval := flag.Duration("loop-interval", defaultLoopInterval, "interval between -loop checks")
+	loopOffsetBps := flag.Int("loop-offset-bps", defaultLoopOffsetBps, "how far from the mid the -loop order rests, in bps")
+	metricsAddr := flag.String("metrics-addr", "", "serve -loop metrics on this address (e.g. 127.0.0.1:9002); empty disables")
 	flag.Parse()
 
-	if err := config.LoadEnv(defaultVerifyEnvFile); err != nil {
-		fatal(err)
-	}
-
-	logCfg := config.LoggingConfig{Level: "info"}
-	baseURL := defaultRESTBaseURL
-	timeout := defaultRESTTimeout
-	var cfg *config.Config
-	if *configPath != "" {
-		loaded, err := config.Load(*configPath)
-		if err != nil {
-			fatal(err)
-		}
-		cfg = loaded
-		logCfg = cfg.Log
-		if cfg.REST.BaseURL != "" {
-			baseURL = cfg.REST.BaseURL
-		}
-		if cfg.REST.Timeout > 0 {
-			timeout = cfg.REST.Timeout
-		}
-	}
-
-	log := logging.New(logCfg)
-	defer func() { _ = log.Sync() }()
+	env := loadSettings(*configPath)
+	defer env.close()
+	cfg, log, baseURL, timeout := env.cfg, env.log, env.baseURL, env.timeout
 
 	if *userFunding {
 		runUserFunding(log, baseURL, timeout, *fundingStartMS, *fundingHours)
 		return
 	}
 
+	isBuy, err := parseSide(*side)
+	if err != nil {
+		fatal(err)
+	}
+	tif, err := parseTif(*tifFlag)
+	if err != nil {
+		fatal(err)
+	}
+	cloid, err := parseCloid(*cloidFlag)
+	if err != nil {
+		fatal(err)
+	}
+
 	asset := strings.TrimSpace(os.Getenv("HL_VERIFY_ASSET"))
 	if asset == "" && cfg != nil {
-		if cfg.Strategy.SpotAsset != "" {
+		switch {
+		case *marketKind == marketPerp && cfg.Strategy.PerpAsset != "":
+			asset = cfg.Strategy.PerpAsset
+		case *marketKind == marketSpot && cfg.Strategy.SpotAsset != "":
 			asset = cfg.Strategy.SpotAsset
-		} else {
+		default:
 			asset = cfg.Strategy.Asset
 		}
 	}
 	if asset == "" {
 		fatal(errors.New("HL_VERIFY_ASSET is required"))
 	}
-	wallet := strings.TrimSpace(os.Getenv("HL_WALLET_ADDRESS"))
-	if wallet == "" {
-		fatal(errors.New("HL_WALLET_ADDRESS is required"))
-	}
-	privateKey := strings.TrimSpace(os.Getenv("HL_PRIVATE_KEY"))
-	if privateKey == "" {
-		fatal(errors.New("HL_PRIVATE_KEY is required"))
-	}
+	signer := env.signer()
 
 	notional := defaultVerifyNotional
 	if envVal, ok, err := floatEnv("HL_VERIFY_NOTIONAL"); err != nil {
@@ -101,11 +107,11 @@ func main() {
 		notional = cfg.Strategy.NotionalUSD
 	}
 
-	limitPrice := 0.0
+	fixedPrice := 0.0
 	if envVal, ok, err := floatEnv("HL_VERIFY_LIMIT_PRICE"); err != nil {
 		fatal(err)
 	} else if ok {
-		limitPrice = envVal
+		fixedPrice = envVal
 	}
 
 	slippageBps := defaultSlippageBps
@@ -115,15 +121,6 @@ func main() {
 		slippageBps = envVal
 	}
 
-	isMainnet := !strings.Contains(strings.ToLower(baseURL), "testnet")
-	signer, err := exchange.NewSigner(privateKey, isMainnet)
-	if err != nil {
-		fatal(err)
-	}
-	if !strings.EqualFold(wallet, signer.Address().Hex()) {
-		fatal(fmt.Errorf("wallet address does not match private key: got %s expected %s", wallet, signer.Address().Hex()))
-	}
-
 	restClient := rest.New(baseURL, timeout, log)
 	md := market.New(restClient, nil, log)
 	ctx := context.Background()
@@ -131,84 +128,228 @@ func main() {
 		fatal(err)
 	}
 
-	spotCtx, ok := md.SpotContext(asset)
-	if !ok {
-		spotCtx, ok = md.SpotContext(asset + "/USDC")
-	}
-	if !ok {
-		fatal(fmt.Errorf("spot asset not found for %s", asset))
-	}
-	spotID, ok := md.SpotAssetID(spotCtx.Symbol)
-	if !ok {
-		spotID, ok = md.SpotAssetID(asset)
+	tgt, err := resolveTarget(md, *marketKind, asset)
+	if err != nil {
+		fatal(err)
 	}
-	if !ok {
-		fatal(fmt.Errorf("spot asset id not found for %s", asset))
+
+	if *loop {
+		runLoop(log, cfg, md, signer, tgt, isBuy, notional, *loopInterval, *loopOffsetBps, *metricsAddr, *dryRun, baseURL, timeout)
+		return
 	}
 
-	if limitPrice <= 0 {
-		mid, err := midWithFallback(ctx, md, spotCtx, asset)
+	price := fixedPrice
+	if price <= 0 {
+		mid, err := tgt.mid(ctx, md)
 		if err != nil {
 			fatal(err)
 		}
-		limitPrice = mid * (1 + float64(slippageBps)/10000.0)
+		price = limitPrice(mid, isBuy, tif, slippageBps)
 	}
-	if limitPrice <= 0 {
+	if price <= 0 {
 		fatal(errors.New("limit price must be > 0"))
 	}
-	limitPrice = normalizeLimitPrice(limitPrice, true, spotCtx.BaseSzDecimals)
-	if limitPrice <= 0 {
+	price = tgt.Increments.RoundPrice(price)
+	if price <= 0 {
 		fatal(errors.New("limit price <= 0 after tick rounding"))
 	}
 
-	size := notional / limitPrice
-	if spotCtx.BaseSzDecimals >= 0 {
-		size = roundDown(size, spotCtx.BaseSzDecimals)
-	}
+	size := tgt.Increments.RoundSizeUp(notional / price)
 	if size <= 0 {
 		fatal(errors.New("calculated size <= 0 after rounding"))
 	}
 
-	order, err := exchange.LimitOrderWire(spotID, true, size, limitPrice, false, defaultLimitTif, "")
+	order, err := exchange.LimitOrderWire(tgt.AssetID, isBuy, size, price, *reduceOnly, tif, cloid)
 	if err != nil {
 		fatal(err)
 	}
 
-	fmt.Printf("verify order: asset=%s spot_id=%d size=%s limit_price=%s notional=%.6f\n", spotCtx.Symbol, spotID, order.Size, order.Price, size*limitPrice)
+	fmt.Printf("verify order: market=%s asset=%s asset_id=%d side=%s size=%s limit_price=%s notional=%.6f tif=%s reduce_only=%t cloid=%s\n",
+		tgt.Market, tgt.Name, tgt.AssetID, sideName(isBuy), order.Size, order.Price, size*price, tif, *reduceOnly, cloid)
 	if *dryRun {
 		return
 	}
 
-	exClient, err := exchange.NewClient(baseURL, timeout, signer, "")
+	exClient, closeStore := newExchangeClient(ctx, log, cfg, baseURL, timeout, signer)
+	defer closeStore()
+	resp, err := exClient.PlaceOrder(ctx, order)
 	if err != nil {
 		fatal(err)
 	}
-	statePath := "data/hl-carry-bot.db"
-	if cfg != nil && cfg.State.SQLitePath != "" {
-		statePath = cfg.State.SQLitePath
-	}
-	if statePath != "" {
-		if err := os.MkdirAll(filepath.Dir(statePath), 0o755); err != nil {
-			log.Warn("nonce store init failed: " + err.Error())
-		} else if store, err := sqlite.New(statePath); err != nil {
-			log.Warn("nonce store init failed: " + err.Error())
-		} else {
-			defer store.Close()
-			if err := exClient.InitNonceStore(ctx, store); err != nil {
-				log.Warn("nonce store init failed: " + err.Error())
-			}
+	results, err := exchange.OrderResults(resp)
+	if err != nil || len(results) == 0 {
+		fmt.Printf("exchange response: %v\n", resp)
+		return
+	}
+	result := results[0]
+	switch result.Status {
+	case exchange.OrderStatusFilled:
+		fmt.Printf("exchange response: filled order_id=%s total_sz=%g avg_px=%g\n", result.OrderID, result.FilledSize, result.AvgPrice)
+	case exchange.OrderStatusRejected:
+		fmt.Printf("exchange response: rejected: %v\n", result.Err)
+	default:
+		fmt.Printf("exchange response: %s order_id=%s\n", result.Status, result.OrderID)
+	}
+	if *cancelResting && result.Status == exchange.OrderStatusResting {
+		if err := cancelByID(ctx, exClient, tgt.AssetID, result.OrderID); err != nil {
+			fatal(err)
 		}
+		fmt.Printf("cancel response: cancelled order_id=%s\n", result.OrderID)
 	}
-	resp, err := exClient.PlaceOrder(ctx, order)
+}
+
+// cancelByID cancels one order by oid and checks both the response and the
+// per-order status.
+func cancelByID(ctx context.Context, exClient *exchange.Client, assetID int, orderID string) error {
+	oid, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid order id %q: %w", orderID, err)
+	}
+	resp, err := exClient.CancelOrder(ctx, assetID, oid)
+	if err != nil {
+		return fmt.Errorf("cancel order %d: %w", oid, err)
+	}
+	if err := exchange.ResponseError(resp); err != nil {
+		return fmt.Errorf("cancel order %d: %w", oid, err)
+	}
+	if err := exchange.OrderStatusError(resp); err != nil {
+		return fmt.Errorf("cancel order %d: %w", oid, err)
+	}
+	return nil
+}
+
+func sideName(isBuy bool) string {
+	if isBuy {
+		return "buy"
+	}
+	return "sell"
+}
+
+// settings is the REST endpoint, config and logger every verify mode
+// starts from.
+type settings struct {
+	cfg     *config.Config
+	log     *zap.Logger
+	baseURL string
+	timeout time.Duration
+}
+
+// loadSettings loads .env and, when configPath is set, the bot config for
+// REST, logging, key and state settings.
+func loadSettings(configPath string) settings {
+	if err := config.LoadEnv(defaultVerifyEnvFile); err != nil {
+		fatal(err)
+	}
+	logCfg := config.LoggingConfig{Level: "info"}
+	env := settings{baseURL: defaultRESTBaseURL, timeout: defaultRESTTimeout}
+	if configPath != "" {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fatal(err)
+		}
+		env.cfg = cfg
+		logCfg = cfg.Log
+		if cfg.REST.BaseURL != "" {
+			env.baseURL = cfg.REST.BaseURL
+		}
+		if cfg.REST.Timeout > 0 {
+			env.timeout = cfg.REST.Timeout
+		}
+	}
+	env.log = logging.New(logCfg)
+	return env
+}
+
+func (env settings) close() {
+	_ = env.log.Sync()
+}
+
+// signer loads the HL_WALLET_ADDRESS key (from keys.source when a config is
+// given) and checks it belongs to the wallet.
+func (env settings) signer() *exchange.Signer {
+	wallet := strings.TrimSpace(os.Getenv("HL_WALLET_ADDRESS"))
+	if wallet == "" {
+		fatal(errors.New("HL_WALLET_ADDRESS is required"))
+	}
+	keySource := keystore.Source{Kind: keystore.SourceEnv, Env: "HL_PRIVATE_KEY"}
+	if env.cfg != nil {
+		keySource = keystore.Source{
+			Kind:          env.cfg.Keys.Source,
+			Env:           "HL_PRIVATE_KEY",
+			Path:          env.cfg.Keys.KeystorePath,
+			PassphraseEnv: env.cfg.Keys.PassphraseEnv,
+			Service:       env.cfg.Keys.KeychainService,
+			Account:       wallet,
+		}
+	}
+	privateKey, err := keystore.Load(context.Background(), keySource)
 	if err != nil {
 		fatal(err)
 	}
-	orderID := exchange.OrderIDFromResponse(resp)
-	if orderID != "" {
-		fmt.Printf("exchange response: order_id=%s\n", orderID)
-		return
+	isMainnet := !strings.Contains(strings.ToLower(env.baseURL), "testnet")
+	signer, err := exchange.NewSignerFromKey(privateKey, isMainnet)
+	if err != nil {
+		fatal(err)
+	}
+	if !strings.EqualFold(wallet, signer.Address().Hex()) {
+		fatal(fmt.Errorf("wallet address does not match private key: got %s expected %s", wallet, signer.Address().Hex()))
+	}
+	return signer
+}
+
+func runLoop(log *zap.Logger, cfg *config.Config, md *market.MarketData, signer *exchange.Signer, tgt target, isBuy bool, notional float64, interval time.Duration, offsetBps int, metricsAddr string, dryRun bool, baseURL string, timeout time.Duration) {
+	if interval <= 0 {
+		fatal(errors.New("loop-interval must be > 0"))
+	}
+	if offsetBps <= 0 || offsetBps >= 10000 {
+		fatal(errors.New("loop-offset-bps must be between 1 and 9999"))
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var exClient *exchange.Client
+	if !dryRun {
+		client, closeStore := newExchangeClient(ctx, log, cfg, baseURL, timeout, signer)
+		defer closeStore()
+		exClient = client
+	}
+	var alertsClient *alerts.Telegram
+	if cfg != nil && cfg.Telegram.Enabled {
+		alertsClient = alerts.NewTelegram(cfg.Telegram, log).WithPrefix(loopPrefix())
+	}
+	checker := newKeepAlive(log, md, exClient, signer, tgt, isBuy, notional, offsetBps, dryRun, alertsClient)
+	if metricsAddr != "" {
+		go checker.serveMetrics(ctx, metricsAddr)
+	}
+	fmt.Printf("keep-alive: market=%s asset=%s asset_id=%d side=%s notional=%.2f offset_bps=%d interval=%s dry_run=%t\n", tgt.Market, tgt.Name, tgt.AssetID, sideName(isBuy), notional, offsetBps, interval, dryRun)
+	if dryRun {
+		fmt.Println("keep-alive: dry run, checking local signing only; the key, nonces and connectivity are not validated by the exchange")
+	}
+	checker.Run(ctx, interval)
+}
+
+// newExchangeClient builds the signed /exchange client, acting for
+// HL_VAULT_ADDRESS when set, with nonces seeded from the bot's state store so
+// verify never reuses a nonce the bot has already spent. The returned func
+// closes the store.
+func newExchangeClient(ctx context.Context, log *zap.Logger, cfg *config.Config, baseURL string, timeout time.Duration, signer *exchange.Signer) (*exchange.Client, func()) {
+	exClient, err := exchange.NewClient(baseURL, timeout, signer, strings.TrimSpace(os.Getenv("HL_VAULT_ADDRESS")))
+	if err != nil {
+		fatal(err)
+	}
+	stateCfg := &config.Config{State: config.StateConfig{SQLitePath: "data/hl-carry-bot.db"}}
+	if cfg != nil && cfg.State.SQLitePath != "" {
+		stateCfg = cfg
+	}
+	store, err := backend.Open(stateCfg)
+	if err != nil {
+		log.Warn("nonce store init failed: " + err.Error())
+		return exClient, func() {}
+	}
+	if err := exClient.InitNonceStore(ctx, store); err != nil {
+		log.Warn("nonce store init failed: " + err.Error())
 	}
-	fmt.Printf("exchange response: %v\n", resp)
+	return exClient, func() { _ = store.Close() }
 }
 
 func runUserFunding(log *zap.Logger, baseURL string, timeout time.Duration, startTimeMS int64, lookbackHours int) {
@@ -252,7 +393,7 @@ func runUserFunding(log *zap.Logger, baseURL string, timeout time.Duration, star
 	fmt.Printf("userFunding response:\n%s\n", string(pretty))
 }
 
-func midWithFallback(ctx context.Context, md *market.MarketData, spotCtx market.SpotContext, asset string) (float64, error) {
+func spotMid(ctx context.Context, md *market.MarketData, spotCtx market.SpotContext) (float64, error) {
 	if spotCtx.MidKey != "" {
 		if mid, err := md.Mid(ctx, spotCtx.MidKey); err == nil {
 			return mid, nil
@@ -263,11 +404,6 @@ func midWithFallback(ctx context.Context, md *market.MarketData, spotCtx market.
 			return mid, nil
 		}
 	}
-	if asset != "" {
-		if mid, err := md.Mid(ctx, asset); err == nil {
-			return mid, nil
-		}
-	}
 	return 0, errors.New("mid price not found")
 }
 
@@ -295,44 +431,6 @@ func intEnv(key string) (int, bool, error) {
 	return parsed, true, nil
 }
 
-func roundDown(value float64, decimals int) float64 {
-	if decimals <= 0 {
-		return math.Floor(value)
-	}
-	factor := math.Pow10(decimals)
-	return math.Floor(value*factor) / factor
-}
-
-func roundTo(value float64, decimals int) float64 {
-	if decimals <= 0 {
-		return math.Round(value)
-	}
-	factor := math.Pow10(decimals)
-	return math.Round(value*factor) / factor
-}
-
-func normalizeLimitPrice(price float64, isSpot bool, szDecimals int) float64 {
-	if price == 0 {
-		return 0
-	}
-	// Hyperliquid enforces a tick size that is effectively a combination of
-	// (a) 5 significant figures and (b) a decimal precision derived from szDecimals.
-	if sig, err := strconv.ParseFloat(strconv.FormatFloat(price, 'g', 5, 64), 64); err == nil {
-		price = sig
-	}
-	decimals := 6
-	if isSpot {
-		decimals = 8
-	}
-	if szDecimals >= 0 {
-		decimals -= szDecimals
-		if decimals < 0 {
-			decimals = 0
-		}
-	}
-	return roundTo(price, decimals)
-}
-
 func fatal(err error) {
 	fmt.Fprintln(os.Stderr, err)
 	os.Exit(1)
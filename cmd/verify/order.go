@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/precision"
+	"hl-carry-bot/internal/market"
+)
+
+const (
+	marketSpot = "spot"
+	marketPerp = "perp"
+)
+
+// target is the instrument a verification order is placed on.
+type target struct {
+	Market     string
+	Name       string
+	AssetID    int
+	Increments precision.Increments
+	spot       market.SpotContext
+}
+
+func resolveTarget(md *market.MarketData, kind, asset string) (target, error) {
+	switch kind {
+	case marketSpot:
+		spotCtx, ok := md.ResolveSpot(asset)
+		if !ok {
+			return target{}, fmt.Errorf("spot asset not found for %s", asset)
+		}
+		spotID, ok := md.SpotAssetID(spotCtx.Symbol)
+		if !ok {
+			return target{}, fmt.Errorf("spot asset id not found for %s", asset)
+		}
+		return target{Market: marketSpot, Name: spotCtx.Symbol, AssetID: spotID, Increments: spotCtx.Increments, spot: spotCtx}, nil
+	case marketPerp:
+		perpCtx, ok := md.PerpContext(asset)
+		if !ok {
+			return target{}, fmt.Errorf("perp asset not found for %s", asset)
+		}
+		return target{Market: marketPerp, Name: asset, AssetID: perpCtx.Index, Increments: perpCtx.Increments}, nil
+	default:
+		return target{}, fmt.Errorf("unknown market %q (want spot or perp)", kind)
+	}
+}
+
+func (t target) mid(ctx context.Context, md *market.MarketData) (float64, error) {
+	if t.Market == marketSpot {
+		return spotMid(ctx, md, t.spot)
+	}
+	return md.Mid(ctx, t.Name)
+}
+
+// parseSide maps -side onto isBuy.
+func parseSide(raw string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "buy", "b", "long":
+		return true, nil
+	case "sell", "s", "short":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown side %q (want buy or sell)", raw)
+	}
+}
+
+func parseTif(raw string) (exchange.Tif, error) {
+	for _, tif := range []exchange.Tif{exchange.TifIoc, exchange.TifAlo, exchange.TifGtc} {
+		if strings.EqualFold(strings.TrimSpace(raw), string(tif)) {
+			return tif, nil
+		}
+	}
+	return "", fmt.Errorf("unknown tif %q (want Ioc, Alo or Gtc)", raw)
+}
+
+// parseCloid accepts an empty value (no cloid), "auto" (a random one) or a
+// 16-byte hex cloid with its 0x prefix.
+func parseCloid(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case raw == "":
+		return "", nil
+	case strings.EqualFold(raw, "auto"):
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return "", err
+		}
+		return "0x" + hex.EncodeToString(b[:]), nil
+	}
+	if !strings.HasPrefix(raw, "0x") {
+		return "", errors.New("cloid must start with 0x")
+	}
+	decoded, err := hex.DecodeString(raw[2:])
+	if err != nil || len(decoded) != 16 {
+		return "", errors.New("cloid must be 16 bytes of hex (0x + 32 hex digits)")
+	}
+	return strings.ToLower(raw), nil
+}
+
+// limitPrice offsets mid by bps: through the book for an IOC so it can
+// match, away from it for Alo/Gtc so the order rests.
+func limitPrice(mid float64, isBuy bool, tif exchange.Tif, bps int) float64 {
+	offset := float64(bps) / 10000.0
+	crosses := tif == exchange.TifIoc
+	if isBuy == crosses {
+		return mid * (1 + offset)
+	}
+	return mid * (1 - offset)
+}
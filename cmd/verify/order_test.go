@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/market"
+
+	"go.uber.org/zap"
+)
+
+func newTestMarket(t *testing.T, server *hltest.Server) *market.MarketData {
+	t.Helper()
+	md := market.New(rest.New(server.URL(), 2*time.Second, zap.NewNop()), nil, zap.NewNop())
+	if err := md.RefreshContexts(context.Background()); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+	return md
+}
+
+func TestParseSide(t *testing.T) {
+	cases := []struct {
+		raw     string
+		want    bool
+		wantErr bool
+	}{
+		{raw: "buy", want: true},
+		{raw: " B ", want: true},
+		{raw: "long", want: true},
+		{raw: "SELL", want: false},
+		{raw: "s", want: false},
+		{raw: "short", want: false},
+		{raw: "", wantErr: true},
+		{raw: "hold", wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := parseSide(tc.raw)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("%q: unexpected error %v", tc.raw, err)
+		}
+		if err == nil && got != tc.want {
+			t.Fatalf("%q: expected isBuy %t, got %t", tc.raw, tc.want, got)
+		}
+	}
+}
+
+func TestParseTif(t *testing.T) {
+	cases := []struct {
+		raw     string
+		want    exchange.Tif
+		wantErr bool
+	}{
+		{raw: "Ioc", want: exchange.TifIoc},
+		{raw: "alo", want: exchange.TifAlo},
+		{raw: " GTC ", want: exchange.TifGtc},
+		{raw: "", wantErr: true},
+		{raw: "fok", wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := parseTif(tc.raw)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("%q: unexpected error %v", tc.raw, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: expected %q, got %q", tc.raw, tc.want, got)
+		}
+	}
+}
+
+func TestParseCloid(t *testing.T) {
+	const cloid = "0x0123456789abcdef0123456789abcdef"
+	cases := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "", want: ""},
+		{raw: "  ", want: ""},
+		{raw: cloid, want: cloid},
+		{raw: " 0x0123456789ABCDEF0123456789ABCDEF ", want: cloid},
+		{raw: "0123456789abcdef0123456789abcdef", wantErr: true},
+		{raw: "0x0123", wantErr: true},
+		{raw: "0x0123456789abcdef0123456789abcdeg", wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := parseCloid(tc.raw)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("%q: unexpected error %v", tc.raw, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: expected %q, got %q", tc.raw, tc.want, got)
+		}
+	}
+
+	auto, err := parseCloid("AUTO")
+	if err != nil {
+		t.Fatalf("auto cloid: %v", err)
+	}
+	if !strings.HasPrefix(auto, "0x") || len(auto) != 34 {
+		t.Fatalf("expected a random 16-byte cloid, got %q", auto)
+	}
+	if again, _ := parseCloid("auto"); again == auto {
+		t.Fatalf("expected a fresh cloid each time, got %q twice", auto)
+	}
+}
+
+func TestLimitPrice(t *testing.T) {
+	cases := []struct {
+		isBuy bool
+		tif   exchange.Tif
+		want  float64
+	}{
+		{isBuy: true, tif: exchange.TifIoc, want: 101},
+		{isBuy: false, tif: exchange.TifIoc, want: 99},
+		{isBuy: true, tif: exchange.TifAlo, want: 99},
+		{isBuy: false, tif: exchange.TifAlo, want: 101},
+		{isBuy: true, tif: exchange.TifGtc, want: 99},
+		{isBuy: false, tif: exchange.TifGtc, want: 101},
+	}
+	for _, tc := range cases {
+		if got := limitPrice(100, tc.isBuy, tc.tif, 100); math.Abs(got-tc.want) > 1e-9 {
+			t.Fatalf("buy=%t tif=%s: expected %f, got %f", tc.isBuy, tc.tif, tc.want, got)
+		}
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	md := newTestMarket(t, server)
+	cases := []struct {
+		kind    string
+		asset   string
+		wantID  int
+		wantErr bool
+	}{
+		{kind: marketPerp, asset: "ETH", wantID: hltest.PerpAsset},
+		{kind: marketSpot, asset: "UETH", wantID: hltest.SpotAsset},
+		{kind: marketPerp, asset: "DOGE", wantErr: true},
+		{kind: marketSpot, asset: "NOPE", wantErr: true},
+		{kind: "option", asset: "ETH", wantErr: true},
+	}
+	for _, tc := range cases {
+		tgt, err := resolveTarget(md, tc.kind, tc.asset)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("%s %s: unexpected error %v", tc.kind, tc.asset, err)
+		}
+		if err != nil {
+			continue
+		}
+		if tgt.Market != tc.kind || tgt.AssetID != tc.wantID {
+			t.Fatalf("%s %s: expected asset id %d, got %+v", tc.kind, tc.asset, tc.wantID, tgt)
+		}
+		if mid, err := tgt.mid(context.Background(), md); err != nil || mid != 3000 {
+			t.Fatalf("%s %s: expected mid 3000, got %f (err=%v)", tc.kind, tc.asset, mid, err)
+		}
+	}
+}
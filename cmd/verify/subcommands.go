@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/market"
+)
+
+// runSubcommand dispatches `verify <name> [flags]`. Subcommands are CLI
+// escape hatches for single exchange actions; each takes its own -config.
+func runSubcommand(name string, args []string) {
+	switch name {
+	case "transfer":
+		runTransfer(args)
+	case "orders":
+		runOrders(args)
+	case "cancel-all":
+		runCancelAll(args)
+	default:
+		fatal(fmt.Errorf("unknown subcommand %q (want transfer, orders or cancel-all)", name))
+	}
+}
+
+// runTransfer moves USDC between the spot and perp wallets with a signed
+// usdClassTransfer.
+func runTransfer(args []string) {
+	fs := flag.NewFlagSet("transfer", flag.ExitOnError)
+	configPath := fs.String("config", "", "optional config path for REST, key and state settings")
+	amount := fs.Float64("amount", 0, "USDC to move")
+	toPerp := fs.Bool("to-perp", false, "move spot -> perp (default perp -> spot)")
+	dryRun := fs.Bool("dry-run", false, "print the transfer and exit")
+	_ = fs.Parse(args)
+	if *amount <= 0 {
+		fatal(errors.New("-amount must be > 0"))
+	}
+
+	env := loadSettings(*configPath)
+	defer env.close()
+	signer := env.signer()
+	direction := "perp->spot"
+	if *toPerp {
+		direction = "spot->perp"
+	}
+	fmt.Printf("verify transfer: amount=%g direction=%s\n", *amount, direction)
+	if *dryRun {
+		return
+	}
+
+	ctx := context.Background()
+	exClient, closeStore := newExchangeClient(ctx, env.log, env.cfg, env.baseURL, env.timeout, signer)
+	defer closeStore()
+	resp, err := exClient.USDClassTransfer(ctx, *amount, *toPerp)
+	if err != nil {
+		fatal(err)
+	}
+	if err := exchange.ResponseError(resp); err != nil {
+		fatal(fmt.Errorf("transfer rejected: %w", err))
+	}
+	fmt.Printf("exchange response: transferred %g USDC %s\n", *amount, direction)
+}
+
+// runOrders lists the account's open orders as the bot parses them.
+func runOrders(args []string) {
+	fs := flag.NewFlagSet("orders", flag.ExitOnError)
+	configPath := fs.String("config", "", "optional config path for REST settings")
+	_ = fs.Parse(args)
+
+	env := loadSettings(*configPath)
+	defer env.close()
+	ctx := context.Background()
+	md, refs := openOrderRefs(ctx, env)
+	fmt.Printf("open orders: %d\n", len(refs))
+	for _, ref := range refs {
+		assetID, _ := resolveAssetID(md, ref)
+		placed := "-"
+		if !ref.PlacedAt.IsZero() {
+			placed = ref.PlacedAt.UTC().Format("2006-01-02T15:04:05Z")
+		}
+		fmt.Printf("order_id=%s cloid=%s asset=%s asset_id=%d placed_at=%s\n", ref.OrderID, orDash(ref.Cloid), ref.AssetSymbol, assetID, placed)
+	}
+}
+
+// runCancelAll cancels every open order, optionally only those on one coin,
+// in a single signed cancel action.
+func runCancelAll(args []string) {
+	fs := flag.NewFlagSet("cancel-all", flag.ExitOnError)
+	configPath := fs.String("config", "", "optional config path for REST, key and state settings")
+	asset := fs.String("asset", "", "only cancel orders on this coin (as listed by orders)")
+	dryRun := fs.Bool("dry-run", false, "list the orders that would be cancelled and exit")
+	_ = fs.Parse(args)
+
+	env := loadSettings(*configPath)
+	defer env.close()
+	signer := env.signer()
+	ctx := context.Background()
+	md, refs := openOrderRefs(ctx, env)
+	if *asset != "" {
+		filtered := refs[:0]
+		for _, ref := range refs {
+			if strings.EqualFold(ref.AssetSymbol, *asset) {
+				filtered = append(filtered, ref)
+			}
+		}
+		refs = filtered
+	}
+	fmt.Printf("cancel-all: %d open orders\n", len(refs))
+	if *dryRun || len(refs) == 0 {
+		return
+	}
+
+	failed := 0
+	var sent []account.OrderRef
+	var cancels []exchange.CancelWire
+	for _, ref := range refs {
+		wire, err := cancelWire(md, ref)
+		if err != nil {
+			failed++
+			fmt.Printf("order_id=%s asset=%s: cancel failed: %v\n", orDash(ref.OrderID), ref.AssetSymbol, err)
+			continue
+		}
+		sent = append(sent, ref)
+		cancels = append(cancels, wire)
+	}
+	if len(cancels) > 0 {
+		exClient, closeStore := newExchangeClient(ctx, env.log, env.cfg, env.baseURL, env.timeout, signer)
+		defer closeStore()
+		resp, err := exClient.CancelOrders(ctx, cancels)
+		if err != nil {
+			fatal(err)
+		}
+		results, err := exchange.CancelResults(resp)
+		if err != nil {
+			fatal(fmt.Errorf("cancel rejected: %w", err))
+		}
+		for i, ref := range sent {
+			if i < len(results) && results[i] != nil {
+				failed++
+				fmt.Printf("order_id=%s asset=%s: cancel failed: %v\n", ref.OrderID, ref.AssetSymbol, results[i])
+				continue
+			}
+			fmt.Printf("order_id=%s asset=%s: cancelled\n", ref.OrderID, ref.AssetSymbol)
+		}
+	}
+	if failed > 0 {
+		fatal(fmt.Errorf("%d of %d cancels failed", failed, len(refs)))
+	}
+}
+
+// openOrderRefs reconciles the account (HL_ACCOUNT_ADDRESS, else
+// HL_WALLET_ADDRESS) and returns its open orders with the market data used
+// to resolve their asset ids.
+func openOrderRefs(ctx context.Context, env settings) (*market.MarketData, []account.OrderRef) {
+	user := strings.TrimSpace(os.Getenv("HL_ACCOUNT_ADDRESS"))
+	if user == "" {
+		user = strings.TrimSpace(os.Getenv("HL_WALLET_ADDRESS"))
+	}
+	if user == "" {
+		fatal(errors.New("HL_WALLET_ADDRESS is required"))
+	}
+	restClient := rest.New(env.baseURL, env.timeout, env.log)
+	md := market.New(restClient, nil, env.log)
+	if err := md.RefreshContexts(ctx); err != nil {
+		fatal(err)
+	}
+	state, err := account.New(restClient, nil, env.log, user).Reconcile(ctx)
+	if err != nil {
+		fatal(err)
+	}
+	return md, account.OpenOrderRefs(state.OpenOrders)
+}
+
+// resolveAssetID returns the order's asset id, looking the coin up as a perp
+// and then a spot name when the payload carries none.
+func resolveAssetID(md *market.MarketData, ref account.OrderRef) (int, bool) {
+	if ref.AssetID != 0 {
+		return ref.AssetID, true
+	}
+	if ref.AssetSymbol == "" {
+		return 0, false
+	}
+	if id, ok := md.PerpAssetID(ref.AssetSymbol); ok {
+		return id, true
+	}
+	return md.SpotAssetID(ref.AssetSymbol)
+}
+
+func cancelWire(md *market.MarketData, ref account.OrderRef) (exchange.CancelWire, error) {
+	if ref.OrderID == "" {
+		return exchange.CancelWire{}, errors.New("order id missing")
+	}
+	oid, err := strconv.ParseInt(ref.OrderID, 10, 64)
+	if err != nil {
+		return exchange.CancelWire{}, fmt.Errorf("invalid order id %q: %w", ref.OrderID, err)
+	}
+	assetID, ok := resolveAssetID(md, ref)
+	if !ok {
+		return exchange.CancelWire{}, errors.New("asset id not found")
+	}
+	return exchange.CancelWire{Asset: assetID, OrderID: oid}, nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
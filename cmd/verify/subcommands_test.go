@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hltest"
+)
+
+func TestResolveAssetID(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	md := newTestMarket(t, server)
+	cases := []struct {
+		ref    account.OrderRef
+		want   int
+		wantOK bool
+	}{
+		{ref: account.OrderRef{AssetID: 42, AssetSymbol: "ETH"}, want: 42, wantOK: true},
+		{ref: account.OrderRef{AssetSymbol: "ETH"}, want: hltest.PerpAsset, wantOK: true},
+		{ref: account.OrderRef{AssetSymbol: "UETH/USDC"}, want: hltest.SpotAsset, wantOK: true},
+		{ref: account.OrderRef{AssetSymbol: "NOPE"}},
+		{ref: account.OrderRef{}},
+	}
+	for _, tc := range cases {
+		got, ok := resolveAssetID(md, tc.ref)
+		if ok != tc.wantOK || (ok && got != tc.want) {
+			t.Fatalf("%+v: expected %d %t, got %d %t", tc.ref, tc.want, tc.wantOK, got, ok)
+		}
+	}
+}
+
+func TestCancelWire(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	md := newTestMarket(t, server)
+	cases := []struct {
+		ref     account.OrderRef
+		want    exchange.CancelWire
+		wantErr bool
+	}{
+		{ref: account.OrderRef{OrderID: "77", AssetSymbol: "ETH"}, want: exchange.CancelWire{Asset: hltest.PerpAsset, OrderID: 77}},
+		{ref: account.OrderRef{OrderID: "78", AssetSymbol: "UETH/USDC"}, want: exchange.CancelWire{Asset: hltest.SpotAsset, OrderID: 78}},
+		{ref: account.OrderRef{AssetSymbol: "ETH"}, wantErr: true},
+		{ref: account.OrderRef{OrderID: "abc", AssetSymbol: "ETH"}, wantErr: true},
+		{ref: account.OrderRef{OrderID: "79", AssetSymbol: "NOPE"}, wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := cancelWire(md, tc.ref)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("%+v: unexpected error %v", tc.ref, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%+v: expected %+v, got %+v", tc.ref, tc.want, got)
+		}
+	}
+}
@@ -13,33 +13,45 @@ import (
 	"sync/atomic"
 	"time"
 
+	"hl-carry-bot/internal/events"
 	"hl-carry-bot/internal/hl/rest"
 	"hl-carry-bot/internal/hl/ws"
+	"hl-carry-bot/internal/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+var tracer = tracing.Tracer("hl-carry-bot/internal/account")
+
 type Account struct {
-	rest *rest.Client
-	ws   *ws.Client
-	log  *zap.Logger
-	user string
-
-	mu                     sync.RWMutex
-	state                  State
-	openOrders             map[string]map[string]any
-	fillsEnabled           bool
-	fillsByOrderID         map[string]float64
-	fillOrderList          *list.List
-	fillOrderElem          map[string]*list.Element
-	seenFillKeys           map[string]struct{}
-	seenFillOrder          []string
-	hasOpenOrdersSnapshot  bool
-	hasPerpStateSnapshot   bool
-	hasSpotStateSnapshot   bool
-	lastClearinghouseState map[string]any
-	spotPostID             atomic.Uint64
-	lastUpdate             time.Time
+	rest   *rest.Client
+	ws     *ws.Client
+	log    *zap.Logger
+	user   string
+	events *events.Bus
+
+	mu                    sync.RWMutex
+	state                 State
+	openOrders            map[string]map[string]any
+	fillsEnabled          bool
+	fillsByOrderID        map[string]OrderFill
+	fillOrderList         *list.List
+	fillOrderElem         map[string]*list.Element
+	seenFillKeys          map[string]struct{}
+	seenFillOrder         []string
+	hasOpenOrdersSnapshot bool
+	hasPerpStateSnapshot  bool
+	hasSpotStateSnapshot  bool
+	spotPostID            atomic.Uint64
+	lastUpdate            time.Time
+	orderUpdatesEnabled   bool
+	orderStatuses         map[string]OrderUpdate
+	orderStatusOrder      []string
+	orderWaiters          map[string][]chan OrderUpdate
+	activityHandler       func(Activity)
+	driftHandler          func(Drift)
 }
 
 const (
@@ -55,16 +67,54 @@ type State struct {
 	LastRawUpdate    map[string]any
 	MarginSummary    MarginSummary
 	HasMarginSummary bool
+	// IsolatedMargin holds the perp positions on isolated margin, by coin.
+	IsolatedMargin map[string]IsolatedMargin
+}
+
+// IsolatedMargin is an isolated perp position's margin from
+// clearinghouseState. MarginUsed is the margin backing the position,
+// unrealized PnL included.
+type IsolatedMargin struct {
+	MarginUsed    float64
+	PositionValue float64
+	Leverage      int
 }
 
+// MarginSummary is the perp wallet's margin state from clearinghouseState.
+// The totals come from marginSummary (all positions, cross and isolated);
+// Cross holds crossMarginSummary on its own.
 type MarginSummary struct {
 	AccountValue      float64
 	TotalMarginUsed   float64
+	TotalNtlPos       float64
+	TotalRawUSD       float64
 	MaintenanceMargin float64
 	MarginRatio       float64
 	HealthRatio       float64
-	HasMarginRatio    bool
-	HasHealthRatio    bool
+	// Withdrawable is the USDC the perp wallet can transfer out now.
+	Withdrawable    float64
+	Cross           MarginTotals
+	HasMarginRatio  bool
+	HasHealthRatio  bool
+	HasWithdrawable bool
+	HasCross        bool
+}
+
+// MarginTotals is one clearinghouseState margin summary block.
+type MarginTotals struct {
+	AccountValue    float64
+	TotalMarginUsed float64
+	TotalNtlPos     float64
+	TotalRawUSD     float64
+}
+
+// FreeUSD is the perp USDC not backing positions: withdrawable when the
+// exchange reports it, else account value less margin used.
+func (m MarginSummary) FreeUSD() float64 {
+	if m.HasWithdrawable {
+		return m.Withdrawable
+	}
+	return math.Max(m.AccountValue-m.TotalMarginUsed, 0)
 }
 
 func New(restClient *rest.Client, wsClient *ws.Client, log *zap.Logger, user string) *Account {
@@ -72,39 +122,61 @@ func New(restClient *rest.Client, wsClient *ws.Client, log *zap.Logger, user str
 }
 
 func (a *Account) Reconcile(ctx context.Context) (*State, error) {
+	return a.reconcile(ctx, DriftReasonReconcile, nil)
+}
+
+// reconcile replaces the state with a REST snapshot and reports any drift
+// from the WS-maintained state, or from before when it is set.
+func (a *Account) reconcile(ctx context.Context, reason string, before *State) (_ *State, err error) {
 	if a.rest == nil {
 		return nil, errors.New("rest client is required")
 	}
-	spot, err := a.rest.Info(ctx, rest.InfoRequest{Type: "spotClearinghouseState", User: a.user})
+	ctx, span := tracer.Start(ctx, "reconcile", trace.WithAttributes(attribute.String("reason", reason)))
+	defer func() { tracing.End(span, err) }()
+	balances, spot, err := a.fetchSpotBalances(ctx)
 	if err != nil {
 		return nil, err
 	}
-	perp, err := a.rest.Info(ctx, rest.InfoRequest{Type: "clearinghouseState", User: a.user})
+	perp, err := a.fetchPerpState(ctx)
 	if err != nil {
 		return nil, err
 	}
-	orders, err := a.rest.InfoAny(ctx, rest.InfoRequest{Type: "openOrders", User: a.user})
+	positions, marginSummary, hasMargin := perp.positions, perp.summary, perp.hasMargin
+	openOrders, orders, err := a.fetchOpenOrders(ctx)
 	if err != nil {
 		return nil, err
 	}
-	marginSummary, hasMargin := parseMarginSummary(perp)
 	state := State{
-		SpotBalances:     parseBalances(spot),
-		PerpPosition:     parsePositions(perp),
-		OpenOrders:       parseOpenOrders(orders),
-		LastRawUpdate:    map[string]any{"spot": spot, "perp": perp, "orders": orders},
+		SpotBalances:     balances,
+		PerpPosition:     positions,
+		OpenOrders:       openOrders,
+		LastRawUpdate:    map[string]any{"spot": spot, "perp": perp.raw, "orders": orders},
 		MarginSummary:    marginSummary,
 		HasMarginSummary: hasMargin,
+		IsolatedMargin:   perp.isolated,
 	}
+	var drift Drift
 	a.mu.Lock()
+	if a.hasPerpStateSnapshot {
+		baseline := a.state
+		if before != nil {
+			baseline = *before
+		}
+		drift = compareStates(reason, baseline, state)
+		now := time.Now().UTC()
+		a.publishPositionChanges(a.state.PerpPosition, state.PerpPosition, now)
+		if hasMargin {
+			a.publishMarginChange(marginSummary, now)
+		}
+	}
 	a.state = state
 	a.openOrders = openOrdersMap(state.OpenOrders)
 	a.hasOpenOrdersSnapshot = true
 	a.hasPerpStateSnapshot = true
 	a.hasSpotStateSnapshot = true
-	a.lastClearinghouseState = perp
 	a.lastUpdate = time.Now().UTC()
 	a.mu.Unlock()
+	a.reportDrift(drift)
 	return &state, nil
 }
 
@@ -158,9 +230,24 @@ func (a *Account) Start(ctx context.Context) error {
 	if err := a.ws.Subscribe(ctx, ledgerSub); err != nil {
 		return err
 	}
+	orderUpdatesSub := map[string]any{
+		"method": "subscribe",
+		"subscription": map[string]any{
+			"type": "orderUpdates",
+			"user": a.user,
+		},
+	}
+	if err := a.ws.Subscribe(ctx, orderUpdatesSub); err != nil {
+		return err
+	}
 	a.mu.Lock()
 	a.fillsEnabled = true
+	a.orderUpdatesEnabled = true
 	a.mu.Unlock()
+	a.ws.SetReconnectHandler(func() {
+		before := a.Snapshot()
+		go a.reconcileAfterReconnect(ctx, before)
+	})
 	go func() {
 		_ = a.ws.Run(ctx, a.handleMessage)
 	}()
@@ -179,6 +266,23 @@ func (a *Account) FillsEnabled() bool {
 	return a.fillsEnabled
 }
 
+// SubscriptionsHealthy reports whether the account WS acknowledged every
+// subscription; see ws.Client.SubscriptionsHealthy.
+func (a *Account) SubscriptionsHealthy() bool {
+	if a.ws == nil {
+		return true
+	}
+	return a.ws.SubscriptionsHealthy()
+}
+
+// Connected reports whether the account WS is connected.
+func (a *Account) Connected() bool {
+	if a.ws == nil {
+		return true
+	}
+	return a.ws.Connected()
+}
+
 func (a *Account) LastUpdate() time.Time {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -186,8 +290,13 @@ func (a *Account) LastUpdate() time.Time {
 }
 
 func (a *Account) FillSize(orderID string) float64 {
+	return a.OrderFill(orderID).Size
+}
+
+// OrderFill is what the fill stream has reported for orderID so far.
+func (a *Account) OrderFill(orderID string) OrderFill {
 	if orderID == "" {
-		return 0
+		return OrderFill{}
 	}
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -212,6 +321,8 @@ func (a *Account) handleMessage(msg json.RawMessage) {
 		a.applyUserFillsUpdate(payload["data"])
 	case "userNonFundingLedgerUpdates":
 		a.applyLedgerUpdates(payload["data"])
+	case "orderUpdates":
+		a.applyOrderUpdates(payload["data"])
 	}
 }
 
@@ -258,9 +369,11 @@ func (a *Account) applyClearinghouseUpdate(data any) {
 	}
 	isSnapshot, hasSnapshot := snapshotFlag(payload)
 	positions := parsePositions(payload)
+	isolated := parseIsolatedMargins(payload)
 	if len(positions) == 0 {
 		if nested, ok := payload["data"].(map[string]any); ok {
 			positions = parsePositions(nested)
+			isolated = parseIsolatedMargins(nested)
 		}
 	}
 	marginSummary, hasMargin := parseMarginSummary(payload)
@@ -269,15 +382,33 @@ func (a *Account) applyClearinghouseUpdate(data any) {
 	}
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	a.lastUpdate = time.Now().UTC()
+	now := time.Now().UTC()
+	a.lastUpdate = now
+	previous := copyPositions(a.state.PerpPosition)
+	hadSnapshot := a.hasPerpStateSnapshot
+	defer func() {
+		if hadSnapshot {
+			a.publishPositionChanges(previous, a.state.PerpPosition, now)
+		}
+	}()
 	if isSnapshot || !a.hasPerpStateSnapshot {
 		a.state.PerpPosition = positions
+		a.state.IsolatedMargin = isolated
 		a.hasPerpStateSnapshot = true
 	} else {
 		if a.state.PerpPosition == nil {
 			a.state.PerpPosition = make(map[string]float64)
 		}
 		for asset, size := range positions {
+			margin, isIsolated := isolated[asset]
+			if size == 0 || !isIsolated {
+				delete(a.state.IsolatedMargin, asset)
+			} else {
+				if a.state.IsolatedMargin == nil {
+					a.state.IsolatedMargin = make(map[string]IsolatedMargin)
+				}
+				a.state.IsolatedMargin[asset] = margin
+			}
 			if size == 0 {
 				delete(a.state.PerpPosition, asset)
 				continue
@@ -285,27 +416,121 @@ func (a *Account) applyClearinghouseUpdate(data any) {
 			a.state.PerpPosition[asset] = size
 		}
 	}
-	a.lastClearinghouseState = payload
 	if a.state.LastRawUpdate == nil {
 		a.state.LastRawUpdate = make(map[string]any)
 	}
 	a.state.LastRawUpdate["ws_clearinghouse"] = data
 	if hasMargin {
+		a.publishMarginChange(marginSummary, now)
 		a.state.MarginSummary = marginSummary
 		a.state.HasMarginSummary = true
 	}
 }
 
+// SetEventBus publishes FillReceived for fills seen after the stream
+// snapshot, PositionChanged and MarginChanged whenever a
+// clearinghouseState update or a reconcile moves a perp position or the
+// margin summary, and ExternalTransfer for deposits and withdrawals on the
+// ledger stream.
+func (a *Account) SetEventBus(bus *events.Bus) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = bus
+}
+
+// publishPositionChanges compares perp positions before and after an update.
+// Callers hold a.mu; Publish never blocks.
+func (a *Account) publishPositionChanges(before, after map[string]float64, now time.Time) {
+	if a.events == nil {
+		return
+	}
+	for asset, size := range after {
+		if prev := before[asset]; math.Abs(prev-size) > balanceEpsilon {
+			a.events.Publish(events.PositionChanged{Asset: asset, Previous: prev, Size: size, Time: now})
+		}
+	}
+	for asset, prev := range before {
+		if _, ok := after[asset]; !ok && math.Abs(prev) > balanceEpsilon {
+			a.events.Publish(events.PositionChanged{Asset: asset, Previous: prev, Time: now})
+		}
+	}
+}
+
+// publishMarginChange publishes summary when it differs from the current
+// one. Callers hold a.mu.
+func (a *Account) publishMarginChange(summary MarginSummary, now time.Time) {
+	if a.events == nil || (a.state.HasMarginSummary && a.state.MarginSummary == summary) {
+		return
+	}
+	a.events.Publish(events.MarginChanged{
+		AccountValue:   summary.AccountValue,
+		MarginRatio:    summary.MarginRatio,
+		HealthRatio:    summary.HealthRatio,
+		HasMarginRatio: summary.HasMarginRatio,
+		HasHealthRatio: summary.HasHealthRatio,
+		Time:           now,
+	})
+}
+
+func copyPositions(positions map[string]float64) map[string]float64 {
+	out := make(map[string]float64, len(positions))
+	for asset, size := range positions {
+		out[asset] = size
+	}
+	return out
+}
+
 func (a *Account) applyUserFillsUpdate(data any) {
 	fills := parseFills(data)
 	if len(fills) == 0 {
 		return
 	}
+	isSnapshot, _ := snapshotFlag(data)
+	var fresh []Fill
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	defer func() {
+		handler := a.activityHandler
+		bus := a.events
+		a.mu.Unlock()
+		if isSnapshot {
+			return
+		}
+		for _, fill := range fresh {
+			bus.Publish(events.FillReceived{
+				OrderID:   fill.OrderID,
+				Cloid:     fill.Cloid,
+				TradeID:   fill.TradeID,
+				Hash:      fill.Hash,
+				Asset:     fill.Asset,
+				Side:      fill.Side,
+				Size:      fill.Size,
+				Price:     fill.Price,
+				Fee:       fill.Fee,
+				FeeToken:  fill.FeeToken,
+				ClosedPnL: fill.ClosedPnL,
+				Time:      time.UnixMilli(fill.TimeMS).UTC(),
+			})
+		}
+		if handler == nil {
+			return
+		}
+		for _, fill := range fresh {
+			handler(Activity{
+				Kind:    ActivityFill,
+				OrderID: fill.OrderID,
+				Cloid:   fill.Cloid,
+				Asset:   fill.Asset,
+				Side:    fill.Side,
+				Size:    fill.Size,
+				Price:   fill.Price,
+				TimeMS:  fill.TimeMS,
+				Hash:    fill.Hash,
+			})
+		}
+	}()
 	a.lastUpdate = time.Now().UTC()
 	if a.fillsByOrderID == nil {
-		a.fillsByOrderID = make(map[string]float64)
+		a.fillsByOrderID = make(map[string]OrderFill)
 	}
 	if a.fillOrderList == nil {
 		a.fillOrderList = list.New()
@@ -332,13 +557,16 @@ func (a *Account) applyUserFillsUpdate(data any) {
 		}
 		a.seenFillKeys[key] = struct{}{}
 		a.seenFillOrder = append(a.seenFillOrder, key)
+		fresh = append(fresh, fill)
 		if elem, ok := a.fillOrderElem[fill.OrderID]; ok {
 			a.fillOrderList.MoveToBack(elem)
 		} else {
 			elem := a.fillOrderList.PushBack(fill.OrderID)
 			a.fillOrderElem[fill.OrderID] = elem
 		}
-		a.fillsByOrderID[fill.OrderID] += math.Abs(fill.Size)
+		agg := a.fillsByOrderID[fill.OrderID]
+		agg.Add(fill.Size, fill.Price)
+		a.fillsByOrderID[fill.OrderID] = agg
 	}
 	if len(a.seenFillOrder) > maxSeenFillKeys {
 		evict := a.seenFillOrder[0 : len(a.seenFillOrder)-maxSeenFillKeys]
@@ -430,62 +658,74 @@ func parseSpotBalances(data any) map[string]float64 {
 }
 
 func parseMarginSummary(data any) (MarginSummary, bool) {
-	if data == nil {
-		return MarginSummary{}, false
-	}
 	payload, ok := data.(map[string]any)
 	if !ok {
 		return MarginSummary{}, false
 	}
-	if summary, ok := payload["marginSummary"].(map[string]any); ok {
-		return parseMarginSummaryMap(summary)
+	summary, hasSummary := payload["marginSummary"].(map[string]any)
+	cross, hasCross := payload["crossMarginSummary"].(map[string]any)
+	if !hasSummary && !hasCross {
+		if nested, ok := payload["data"]; ok {
+			return parseMarginSummary(nested)
+		}
+		return MarginSummary{}, false
 	}
-	if summary, ok := payload["crossMarginSummary"].(map[string]any); ok {
-		return parseMarginSummaryMap(summary)
+	if !hasSummary {
+		summary = cross
 	}
-	if nested, ok := payload["data"]; ok {
-		if summary, ok := parseMarginSummary(nested); ok {
-			return summary, ok
-		}
+	out, found := parseMarginSummaryMap(summary, payload)
+	if hasCross {
+		out.Cross, out.HasCross = parseMarginTotals(cross)
+		found = found || out.HasCross
 	}
-	return MarginSummary{}, false
+	return out, found
 }
 
-func parseMarginSummaryMap(summary map[string]any) (MarginSummary, bool) {
+// parseMarginSummaryMap reads a summary block; fields the exchange reports
+// next to it (withdrawable, crossMaintenanceMarginUsed) are read from top.
+func parseMarginSummaryMap(summary, top map[string]any) (MarginSummary, bool) {
 	var out MarginSummary
 	var (
 		found           bool
 		hasAccountValue bool
 		hasMarginUsed   bool
+		hasNtlPos       bool
+		hasRawUSD       bool
 		hasMaintenance  bool
 		hasMarginRatio  bool
 		hasHealthRatio  bool
+		hasWithdrawable bool
 	)
-	setFloat := func(dst *float64, has *bool, key string) {
+	setFloat := func(src map[string]any, dst *float64, has *bool, key string) {
 		if *has {
 			return
 		}
-		if val, ok := floatFromAny(summary[key]); ok {
+		if val, ok := floatFromAny(src[key]); ok {
 			*dst = val
 			*has = true
 			found = true
 		}
 	}
 	for _, key := range []string{"accountValue", "accountValueUsd", "accountValueUSDC"} {
-		setFloat(&out.AccountValue, &hasAccountValue, key)
+		setFloat(summary, &out.AccountValue, &hasAccountValue, key)
 	}
 	for _, key := range []string{"totalMarginUsed", "totalMarginUsedUsd", "marginUsed"} {
-		setFloat(&out.TotalMarginUsed, &hasMarginUsed, key)
+		setFloat(summary, &out.TotalMarginUsed, &hasMarginUsed, key)
 	}
+	setFloat(summary, &out.TotalNtlPos, &hasNtlPos, "totalNtlPos")
+	setFloat(summary, &out.TotalRawUSD, &hasRawUSD, "totalRawUsd")
 	for _, key := range []string{"maintenanceMargin", "maintenanceMarginUsed", "maintMargin"} {
-		setFloat(&out.MaintenanceMargin, &hasMaintenance, key)
+		setFloat(summary, &out.MaintenanceMargin, &hasMaintenance, key)
 	}
+	setFloat(top, &out.MaintenanceMargin, &hasMaintenance, "crossMaintenanceMarginUsed")
 	for _, key := range []string{"marginRatio", "margin_ratio", "marginFraction"} {
-		setFloat(&out.MarginRatio, &hasMarginRatio, key)
+		setFloat(summary, &out.MarginRatio, &hasMarginRatio, key)
 	}
 	for _, key := range []string{"health", "accountHealth"} {
-		setFloat(&out.HealthRatio, &hasHealthRatio, key)
+		setFloat(summary, &out.HealthRatio, &hasHealthRatio, key)
 	}
+	setFloat(summary, &out.Withdrawable, &hasWithdrawable, "withdrawable")
+	setFloat(top, &out.Withdrawable, &hasWithdrawable, "withdrawable")
 	if !hasHealthRatio && hasAccountValue && hasMaintenance && out.MaintenanceMargin > 0 {
 		out.HealthRatio = out.AccountValue / out.MaintenanceMargin
 		hasHealthRatio = true
@@ -493,6 +733,27 @@ func parseMarginSummaryMap(summary map[string]any) (MarginSummary, bool) {
 	}
 	out.HasMarginRatio = hasMarginRatio
 	out.HasHealthRatio = hasHealthRatio
+	out.HasWithdrawable = hasWithdrawable
+	return out, found
+}
+
+func parseMarginTotals(summary map[string]any) (MarginTotals, bool) {
+	var out MarginTotals
+	found := false
+	for _, field := range []struct {
+		dst *float64
+		key string
+	}{
+		{&out.AccountValue, "accountValue"},
+		{&out.TotalMarginUsed, "totalMarginUsed"},
+		{&out.TotalNtlPos, "totalNtlPos"},
+		{&out.TotalRawUSD, "totalRawUsd"},
+	} {
+		if val, ok := floatFromAny(summary[field.key]); ok {
+			*field.dst = val
+			found = true
+		}
+	}
 	return out, found
 }
 
@@ -648,6 +909,7 @@ func (a *Account) applyLedgerUpdates(data any) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	a.lastUpdate = time.Now().UTC()
+	a.publishExternalTransfers(updates)
 	if !a.hasSpotStateSnapshot {
 		return
 	}
@@ -786,6 +1048,42 @@ func parsePositions(payload map[string]any) map[string]float64 {
 	return positions
 }
 
+// parseIsolatedMargins reads the isolated positions of a clearinghouseState
+// payload; cross positions are left out.
+func parseIsolatedMargins(payload map[string]any) map[string]IsolatedMargin {
+	raw, ok := payload["assetPositions"].([]any)
+	if !ok || len(raw) == 0 {
+		return nil
+	}
+	var out map[string]IsolatedMargin
+	for _, item := range raw {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		pos := entry
+		if nested, ok := entry["position"].(map[string]any); ok {
+			pos = nested
+		}
+		leverage, ok := pos["leverage"].(map[string]any)
+		if !ok || stringFromAny(leverage["type"]) != "isolated" {
+			continue
+		}
+		asset := stringFromAny(pos["coin"])
+		if asset == "" {
+			continue
+		}
+		margin := IsolatedMargin{Leverage: intFromAny(leverage["value"])}
+		margin.MarginUsed, _ = floatFromAny(pos["marginUsed"])
+		margin.PositionValue, _ = floatFromAny(pos["positionValue"])
+		if out == nil {
+			out = make(map[string]IsolatedMargin)
+		}
+		out[asset] = margin
+	}
+	return out
+}
+
 func parseOpenOrders(payload any) []map[string]any {
 	if payload == nil {
 		return nil
@@ -844,6 +1142,8 @@ type OrderRef struct {
 	Cloid       string
 	AssetSymbol string
 	AssetID     int
+	// PlacedAt is the order's placement time, zero when the payload has none.
+	PlacedAt time.Time
 }
 
 func OpenOrderRefs(openOrders []map[string]any) []OrderRef {
@@ -868,11 +1168,13 @@ func OpenOrderRefs(openOrders []map[string]any) []OrderRef {
 		if orderID == "" && cloid == "" {
 			continue
 		}
+		placedAt, _ := timeFromAny(order["timestamp"])
 		refs = append(refs, OrderRef{
 			OrderID:     orderID,
 			Cloid:       cloid,
 			AssetSymbol: assetSymbol,
 			AssetID:     assetID,
+			PlacedAt:    placedAt,
 		})
 	}
 	return refs
@@ -1059,6 +1361,12 @@ func copyState(state State) State {
 		MarginSummary:    state.MarginSummary,
 		HasMarginSummary: state.HasMarginSummary,
 	}
+	if len(state.IsolatedMargin) > 0 {
+		out.IsolatedMargin = make(map[string]IsolatedMargin, len(state.IsolatedMargin))
+		for asset, margin := range state.IsolatedMargin {
+			out.IsolatedMargin[asset] = margin
+		}
+	}
 	if state.LastRawUpdate != nil {
 		out.LastRawUpdate = make(map[string]any, len(state.LastRawUpdate))
 		for k, v := range state.LastRawUpdate {
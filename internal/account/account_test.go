@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"testing"
 
+	"hl-carry-bot/internal/events"
+
 	"go.uber.org/zap"
 )
 
@@ -190,6 +192,45 @@ func TestClearinghouseSnapshotAndDelta(t *testing.T) {
 	}
 }
 
+func TestClearinghouseIsolatedMargin(t *testing.T) {
+	acct := &Account{log: zap.NewNop()}
+	snapshot := map[string]any{
+		"channel": "clearinghouseState",
+		"data": map[string]any{
+			"isSnapshot": true,
+			"assetPositions": []any{
+				map[string]any{"position": map[string]any{"coin": "BTC", "szi": "0.1", "leverage": map[string]any{"type": "cross", "value": 3}}},
+				map[string]any{"position": map[string]any{"coin": "ETH", "szi": "-0.2", "marginUsed": "300", "positionValue": "600",
+					"leverage": map[string]any{"type": "isolated", "value": 2}}},
+			},
+		},
+	}
+	raw, _ := json.Marshal(snapshot)
+	acct.handleMessage(raw)
+	state := acct.Snapshot()
+	if _, ok := state.IsolatedMargin["BTC"]; ok || len(state.IsolatedMargin) != 1 {
+		t.Fatalf("expected only ETH isolated, got %+v", state.IsolatedMargin)
+	}
+	if got := state.IsolatedMargin["ETH"]; got != (IsolatedMargin{MarginUsed: 300, PositionValue: 600, Leverage: 2}) {
+		t.Fatalf("unexpected ETH margin %+v", got)
+	}
+
+	delta := map[string]any{
+		"channel": "clearinghouseState",
+		"data": map[string]any{
+			"isSnapshot": false,
+			"assetPositions": []any{
+				map[string]any{"position": map[string]any{"coin": "ETH", "szi": "0"}},
+			},
+		},
+	}
+	raw, _ = json.Marshal(delta)
+	acct.handleMessage(raw)
+	if got := acct.Snapshot().IsolatedMargin; len(got) != 0 {
+		t.Fatalf("expected closed position dropped, got %+v", got)
+	}
+}
+
 func TestClearinghouseMarginSummary(t *testing.T) {
 	acct := &Account{log: zap.NewNop()}
 	snapshot := map[string]any{
@@ -229,6 +270,36 @@ func TestClearinghouseMarginSummary(t *testing.T) {
 	}
 }
 
+func TestParseMarginSummaryExchangeShape(t *testing.T) {
+	payload := map[string]any{
+		"marginSummary":              map[string]any{"accountValue": "1200.5", "totalNtlPos": "900", "totalRawUsd": "2100.5", "totalMarginUsed": "180"},
+		"crossMarginSummary":         map[string]any{"accountValue": "1100", "totalNtlPos": "800", "totalRawUsd": "1900", "totalMarginUsed": "160"},
+		"crossMaintenanceMarginUsed": "40",
+		"withdrawable":               "940.25",
+		"assetPositions":             []any{},
+	}
+	summary, ok := parseMarginSummary(payload)
+	if !ok {
+		t.Fatal("expected a margin summary")
+	}
+	if summary.AccountValue != 1200.5 || summary.TotalMarginUsed != 180 || summary.TotalNtlPos != 900 || summary.TotalRawUSD != 2100.5 {
+		t.Fatalf("unexpected totals %+v", summary)
+	}
+	if !summary.HasCross || summary.Cross != (MarginTotals{AccountValue: 1100, TotalMarginUsed: 160, TotalNtlPos: 800, TotalRawUSD: 1900}) {
+		t.Fatalf("unexpected cross totals %+v", summary.Cross)
+	}
+	if summary.MaintenanceMargin != 40 || !summary.HasHealthRatio || math.Abs(summary.HealthRatio-1200.5/40) > 1e-9 {
+		t.Fatalf("expected health from crossMaintenanceMarginUsed, got %+v", summary)
+	}
+	if !summary.HasWithdrawable || summary.FreeUSD() != 940.25 {
+		t.Fatalf("expected withdrawable 940.25, got %+v", summary)
+	}
+	summary.HasWithdrawable = false
+	if got := summary.FreeUSD(); math.Abs(got-1020.5) > 1e-9 {
+		t.Fatalf("expected account value less margin used without withdrawable, got %f", got)
+	}
+}
+
 func contains(items []string, target string) bool {
 	for _, item := range items {
 		if item == target {
@@ -256,7 +327,7 @@ func TestUserFillsAggregationAndDedupe(t *testing.T) {
 				"coin": "BTC",
 				"side": "B",
 				"sz":   -0.2,
-				"px":   30000.0,
+				"px":   30300.0,
 				"time": 1700000000001,
 				"hash": "h2",
 			},
@@ -278,6 +349,10 @@ func TestUserFillsAggregationAndDedupe(t *testing.T) {
 		t.Fatalf("expected aggregated fill 0.4 for order 2, got %f", got)
 	}
 
+	if got := acct.OrderFill("1").AvgPrice(); math.Abs(got-30200) > 1e-6 {
+		t.Fatalf("expected order 1 volume-weighted price 30200, got %f", got)
+	}
+
 	acct.applyUserFillsUpdate(update)
 	if got := acct.FillSize("1"); math.Abs(got-0.3) > 1e-9 {
 		t.Fatalf("expected deduped fill 0.3 for order 1, got %f", got)
@@ -441,3 +516,40 @@ func TestUserFillsLRUEvictionKeepsActiveOrder(t *testing.T) {
 		t.Fatalf("expected %d tracked orders, got %d", maxFillOrderIDs, got)
 	}
 }
+
+func TestAccountPublishesFillAndPositionEvents(t *testing.T) {
+	bus := events.New()
+	ch, unsubscribe := bus.Subscribe(8)
+	defer unsubscribe()
+	acct := &Account{log: zap.NewNop()}
+	acct.SetEventBus(bus)
+
+	acct.applyUserFillsUpdate(map[string]any{"isSnapshot": true, "fills": []any{
+		map[string]any{"oid": "1", "coin": "ETH", "side": "B", "sz": "0.1", "px": "3000", "time": 1700000000000, "hash": "h1"},
+	}})
+	acct.applyUserFillsUpdate(map[string]any{"fills": []any{
+		map[string]any{"oid": "2", "coin": "ETH", "side": "A", "sz": "0.2", "px": "3001", "fee": "0.1", "time": 1700000000001, "hash": "h2"},
+	}})
+	acct.applyClearinghouseUpdate(map[string]any{"isSnapshot": true, "assetPositions": []any{
+		map[string]any{"position": map[string]any{"coin": "ETH", "szi": "-0.1"}},
+	}})
+	acct.applyClearinghouseUpdate(map[string]any{"isSnapshot": true, "assetPositions": []any{
+		map[string]any{"position": map[string]any{"coin": "ETH", "szi": "-0.3"}},
+	}})
+
+	var got []events.Event
+	for len(ch) > 0 {
+		got = append(got, <-ch)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected one fill and one position event, got %#v", got)
+	}
+	fill, ok := got[0].(events.FillReceived)
+	if !ok || fill.OrderID != "2" || fill.Size != 0.2 || fill.Fee != 0.1 {
+		t.Fatalf("expected the non-snapshot fill, got %#v", got[0])
+	}
+	pos, ok := got[1].(events.PositionChanged)
+	if !ok || pos.Asset != "ETH" || pos.Previous != -0.1 || pos.Size != -0.3 {
+		t.Fatalf("expected ETH -0.1 -> -0.3, got %#v", got[1])
+	}
+}
@@ -0,0 +1,31 @@
+package account
+
+// Activity kinds reported to the handler set with SetActivityHandler.
+const (
+	ActivityOrder = "order"
+	ActivityFill  = "fill"
+)
+
+// Activity is one order update or newly seen fill from the account streams.
+// Fills in the initial userFills snapshot are history and are not reported.
+type Activity struct {
+	Kind    string
+	OrderID string
+	Cloid   string
+	Asset   string
+	Side    string
+	Status  string
+	Size    float64
+	Price   float64
+	TimeMS  int64
+	Hash    string
+}
+
+// SetActivityHandler registers fn to receive order and fill activity as it
+// arrives on the WS streams. fn runs on the stream goroutine without the
+// account lock held, so it must not block.
+func (a *Account) SetActivityHandler(fn func(Activity)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.activityHandler = fn
+}
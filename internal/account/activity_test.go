@@ -0,0 +1,33 @@
+package account
+
+import (
+	"testing"
+)
+
+func TestActivityHandlerSkipsSnapshotAndDuplicateFills(t *testing.T) {
+	acct := New(nil, nil, nil, "0xabc")
+	var got []Activity
+	acct.SetActivityHandler(func(activity Activity) { got = append(got, activity) })
+
+	fill := map[string]any{"oid": 7, "cloid": "0xfeed", "coin": "ETH", "side": "B", "sz": "1", "px": "2000", "time": 1000, "hash": "0x1"}
+	acct.applyUserFillsUpdate(map[string]any{"isSnapshot": true, "fills": []any{fill}})
+	if len(got) != 0 {
+		t.Fatalf("expected snapshot fills ignored, got %+v", got)
+	}
+	fill2 := map[string]any{"oid": 8, "coin": "ETH", "side": "A", "sz": "2", "px": "2001", "time": 2000, "hash": "0x2"}
+	acct.applyUserFillsUpdate(map[string]any{"fills": []any{fill, fill2}})
+	if len(got) != 1 || got[0].Kind != ActivityFill || got[0].OrderID != "8" || got[0].Hash != "0x2" {
+		t.Fatalf("expected only the new fill reported, got %+v", got)
+	}
+
+	acct.applyOrderUpdates([]any{map[string]any{
+		"order":  map[string]any{"oid": 9, "cloid": "0xbeef", "coin": "ETH", "side": "B", "sz": "1", "origSz": "1", "limitPx": "1999"},
+		"status": "open",
+	}})
+	if len(got) != 2 {
+		t.Fatalf("expected order activity, got %+v", got)
+	}
+	if order := got[1]; order.Kind != ActivityOrder || order.OrderID != "9" || order.Cloid != "0xbeef" || order.Status != "open" || order.Price != 1999 {
+		t.Fatalf("unexpected order activity: %+v", order)
+	}
+}
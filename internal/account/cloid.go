@@ -0,0 +1,62 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// CloidOrder is what the exchange shows of an order looked up by cloid.
+// Resting orders are still on the book; Fill sums the fills seen so far.
+type CloidOrder struct {
+	OrderID string
+	Resting bool
+	Fill    OrderFill
+}
+
+// cloidFillSlack widens the fill query before since, so a fill timestamped
+// by an exchange clock slightly behind ours is not missed.
+const cloidFillSlack = time.Minute
+
+// OrderByCloid finds the order placed with cloid on the book (open orders)
+// or among the fills since since. found is false when neither shows it: the
+// exchange never accepted the order, or it ended without a fill.
+func (a *Account) OrderByCloid(ctx context.Context, cloid string, since time.Time) (CloidOrder, bool, error) {
+	if cloid == "" {
+		return CloidOrder{}, false, errors.New("cloid is required")
+	}
+	var out CloidOrder
+	found := false
+	orders, err := a.OpenOrders(ctx)
+	if err != nil {
+		return CloidOrder{}, false, err
+	}
+	for _, ref := range OpenOrderRefs(orders) {
+		if strings.EqualFold(ref.Cloid, cloid) {
+			out.OrderID = ref.OrderID
+			out.Resting = true
+			found = true
+			break
+		}
+	}
+	start := since.Add(-cloidFillSlack)
+	if since.IsZero() {
+		start = time.Now().Add(-cloidFillSlack)
+	}
+	fills, err := a.UserFillsByTime(ctx, start.UnixMilli(), 0)
+	if err != nil {
+		return CloidOrder{}, false, err
+	}
+	for _, fill := range fills {
+		if !strings.EqualFold(fill.Cloid, cloid) {
+			continue
+		}
+		if out.OrderID == "" {
+			out.OrderID = fill.OrderID
+		}
+		out.Fill.Add(fill.Size, fill.Price)
+		found = true
+	}
+	return out, found, nil
+}
@@ -0,0 +1,61 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/rest"
+
+	"go.uber.org/zap"
+)
+
+func TestOrderByCloid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch payload["type"] {
+		case "openOrders":
+			_, _ = w.Write([]byte(`[{"oid":7,"coin":"BTC","side":"B","limitPx":"30000","sz":"0.5","timestamp":1700000000000,"origSz":"1","cloid":"0xaa"}]`))
+		case "userFillsByTime":
+			_, _ = w.Write([]byte(`[
+				{"oid":7,"coin":"BTC","sz":"0.5","px":"30000","time":1700000000001,"cloid":"0xaa"},
+				{"oid":9,"coin":"BTC","sz":"1","px":"29000","time":1700000000002,"cloid":"0xbb"},
+				{"oid":9,"coin":"BTC","sz":"1","px":"31000","time":1700000000003,"cloid":"0xbb"}
+			]`))
+		default:
+			t.Fatalf("unexpected info type %v", payload["type"])
+		}
+	}))
+	defer server.Close()
+
+	acct := New(rest.New(server.URL, 5*time.Second, zap.NewNop()), nil, zap.NewNop(), "0xabc")
+	ctx := context.Background()
+	since := time.UnixMilli(1700000000000)
+
+	resting, found, err := acct.OrderByCloid(ctx, "0xaa", since)
+	if err != nil || !found {
+		t.Fatalf("expected resting order found, got found=%t err=%v", found, err)
+	}
+	if resting.OrderID != "7" || !resting.Resting || resting.Fill.Size != 0.5 {
+		t.Fatalf("unexpected resting order %+v", resting)
+	}
+
+	filled, found, err := acct.OrderByCloid(ctx, "0xbb", since)
+	if err != nil || !found {
+		t.Fatalf("expected filled order found, got found=%t err=%v", found, err)
+	}
+	if filled.OrderID != "9" || filled.Resting || filled.Fill.Size != 2 || filled.Fill.AvgPrice() != 30000 {
+		t.Fatalf("unexpected filled order %+v", filled)
+	}
+
+	if _, found, err := acct.OrderByCloid(ctx, "0xcc", since); err != nil || found {
+		t.Fatalf("expected unknown cloid not found, got found=%t err=%v", found, err)
+	}
+}
@@ -0,0 +1,137 @@
+package account
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Drift reasons.
+const (
+	DriftReasonReconcile = "reconcile"
+	DriftReasonReconnect = "ws_reconnect"
+)
+
+const (
+	driftTolerance            = 1e-6
+	reconnectReconcileTimeout = 5 * time.Second
+)
+
+// Drift is where the WS-maintained state disagreed with a REST reconcile.
+// Position and balance entries are WS minus REST.
+type Drift struct {
+	Reason        string
+	Positions     map[string]float64
+	SpotBalances  map[string]float64
+	MissingOrders []string
+	ExtraOrders   []string
+}
+
+// Empty reports whether the two states agreed within tolerance.
+func (d Drift) Empty() bool {
+	return len(d.Positions) == 0 && len(d.SpotBalances) == 0 && len(d.MissingOrders) == 0 && len(d.ExtraOrders) == 0
+}
+
+// SetDriftHandler registers fn to receive every non-empty Drift found by a
+// reconcile. fn runs without the account lock held.
+func (a *Account) SetDriftHandler(fn func(Drift)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.driftHandler = fn
+}
+
+// reconcileAfterReconnect forces a REST reconcile once the WS reconnects and
+// compares it with the state held before the gap.
+func (a *Account) reconcileAfterReconnect(ctx context.Context, before State) {
+	ctx, cancel := context.WithTimeout(ctx, reconnectReconcileTimeout)
+	defer cancel()
+	if _, err := a.reconcile(ctx, DriftReasonReconnect, &before); err != nil {
+		if a.log != nil && ctx.Err() == nil {
+			a.log.Warn("account reconcile after ws reconnect failed", zap.Error(err))
+		}
+		return
+	}
+	if a.log != nil {
+		a.log.Info("account reconciled after ws reconnect")
+	}
+}
+
+func (a *Account) reportDrift(drift Drift) {
+	if drift.Empty() {
+		return
+	}
+	if a.log != nil {
+		a.log.Warn("account ws state diverged from rest",
+			zap.String("reason", drift.Reason),
+			zap.Any("positions", drift.Positions),
+			zap.Any("spot_balances", drift.SpotBalances),
+			zap.Strings("missing_orders", drift.MissingOrders),
+			zap.Strings("extra_orders", drift.ExtraOrders),
+		)
+	}
+	a.mu.RLock()
+	handler := a.driftHandler
+	a.mu.RUnlock()
+	if handler != nil {
+		handler(drift)
+	}
+}
+
+// compareStates diffs the WS-maintained state against a REST snapshot.
+// MissingOrders are open on REST but absent from WS; ExtraOrders the reverse.
+func compareStates(reason string, wsState, restState State) Drift {
+	drift := Drift{
+		Reason:       reason,
+		Positions:    diffAmounts(wsState.PerpPosition, restState.PerpPosition),
+		SpotBalances: diffAmounts(wsState.SpotBalances, restState.SpotBalances),
+	}
+	wsOrders := openOrderIDSet(wsState.OpenOrders)
+	restOrders := openOrderIDSet(restState.OpenOrders)
+	for id := range restOrders {
+		if !wsOrders[id] {
+			drift.MissingOrders = append(drift.MissingOrders, id)
+		}
+	}
+	for id := range wsOrders {
+		if !restOrders[id] {
+			drift.ExtraOrders = append(drift.ExtraOrders, id)
+		}
+	}
+	sort.Strings(drift.MissingOrders)
+	sort.Strings(drift.ExtraOrders)
+	return drift
+}
+
+func diffAmounts(wsAmounts, restAmounts map[string]float64) map[string]float64 {
+	var out map[string]float64
+	add := func(key string) {
+		delta := wsAmounts[key] - restAmounts[key]
+		if math.Abs(delta) <= driftTolerance {
+			return
+		}
+		if out == nil {
+			out = make(map[string]float64)
+		}
+		out[key] = delta
+	}
+	for key := range wsAmounts {
+		add(key)
+	}
+	for key := range restAmounts {
+		if _, ok := wsAmounts[key]; !ok {
+			add(key)
+		}
+	}
+	return out
+}
+
+func openOrderIDSet(orders []map[string]any) map[string]bool {
+	out := make(map[string]bool, len(orders))
+	for _, id := range OpenOrderIDs(orders) {
+		out[id] = true
+	}
+	return out
+}
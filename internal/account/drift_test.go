@@ -0,0 +1,87 @@
+package account
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/hltest"
+
+	"go.uber.org/zap"
+)
+
+func TestCompareStates(t *testing.T) {
+	wsState := State{
+		PerpPosition: map[string]float64{"ETH": -0.1},
+		SpotBalances: map[string]float64{"USDC": 100, "UETH": 0.1 + 1e-9},
+		OpenOrders:   []map[string]any{{"oid": 1.0}, {"oid": 2.0}},
+	}
+	restState := State{
+		PerpPosition: map[string]float64{"ETH": -0.2, "BTC": 0.01},
+		SpotBalances: map[string]float64{"USDC": 100, "UETH": 0.1},
+		OpenOrders:   []map[string]any{{"oid": 2.0}, {"oid": 3.0}},
+	}
+	drift := compareStates(DriftReasonReconnect, wsState, restState)
+	if drift.Empty() {
+		t.Fatalf("expected drift")
+	}
+	if math.Abs(drift.Positions["ETH"]-0.1) > 1e-9 || math.Abs(drift.Positions["BTC"]+0.01) > 1e-9 {
+		t.Fatalf("unexpected position drift %v", drift.Positions)
+	}
+	if len(drift.SpotBalances) != 0 {
+		t.Fatalf("expected balances within tolerance, got %v", drift.SpotBalances)
+	}
+	if len(drift.MissingOrders) != 1 || drift.MissingOrders[0] != "3" {
+		t.Fatalf("expected order 3 missing from ws, got %v", drift.MissingOrders)
+	}
+	if len(drift.ExtraOrders) != 1 || drift.ExtraOrders[0] != "1" {
+		t.Fatalf("expected order 1 extra in ws, got %v", drift.ExtraOrders)
+	}
+	if !compareStates(DriftReasonReconcile, restState, restState).Empty() {
+		t.Fatalf("expected identical states to agree")
+	}
+}
+
+func TestReconcileReportsDrift(t *testing.T) {
+	server := hltest.NewServer(t)
+	server.SetPerpPosition("ETH", -0.1)
+	acct := New(rest.New(server.URL(), time.Second, zap.NewNop()), nil, zap.NewNop(), "0xabc")
+	var drifts []Drift
+	acct.SetDriftHandler(func(drift Drift) { drifts = append(drifts, drift) })
+
+	ctx := context.Background()
+	if _, err := acct.Reconcile(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if _, err := acct.Reconcile(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(drifts) != 0 {
+		t.Fatalf("expected no drift between matching reconciles, got %v", drifts)
+	}
+
+	// A position change the WS never delivered.
+	server.SetPerpPosition("ETH", -0.3)
+	before := acct.Snapshot()
+	acct.reconcileAfterReconnect(ctx, before)
+	if len(drifts) != 1 {
+		t.Fatalf("expected one drift report, got %d", len(drifts))
+	}
+	if drifts[0].Reason != DriftReasonReconnect || math.Abs(drifts[0].Positions["ETH"]-0.2) > 1e-9 {
+		t.Fatalf("unexpected drift %+v", drifts[0])
+	}
+	if got := acct.Snapshot().PerpPosition["ETH"]; got != -0.3 {
+		t.Fatalf("expected rest position adopted, got %v", got)
+	}
+
+	server.SetIsolatedMargin("ETH", 450)
+	state, err := acct.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if got := state.IsolatedMargin["ETH"]; got.MarginUsed != 450 || got.PositionValue != 900 {
+		t.Fatalf("expected isolated margin read from rest, got %+v", got)
+	}
+}
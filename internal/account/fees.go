@@ -0,0 +1,64 @@
+package account
+
+import (
+	"context"
+	"errors"
+)
+
+// FeeSchedule is the account's effective fee rates in bps, after volume
+// tier, staking, and referral discounts.
+type FeeSchedule struct {
+	PerpTakerBps float64
+	PerpMakerBps float64
+	SpotTakerBps float64
+	SpotMakerBps float64
+}
+
+// UserFees fetches the account's current fee rates (userFees).
+func (a *Account) UserFees(ctx context.Context) (FeeSchedule, error) {
+	if a.rest == nil {
+		return FeeSchedule{}, errors.New("rest client is required")
+	}
+	if a.user == "" {
+		return FeeSchedule{}, errors.New("account user is required")
+	}
+	resp, err := a.rest.InfoAny(ctx, map[string]any{
+		"type": "userFees",
+		"user": a.user,
+	})
+	if err != nil {
+		return FeeSchedule{}, err
+	}
+	return parseUserFees(resp)
+}
+
+// parseUserFees reads the user*Rate fields, which are fractions (0.00035 is
+// 3.5 bps). Spot rates fall back to the perp rates when absent.
+func parseUserFees(payload any) (FeeSchedule, error) {
+	data, ok := payload.(map[string]any)
+	if !ok {
+		return FeeSchedule{}, errors.New("unexpected userFees response")
+	}
+	taker, ok := floatFromAny(data["userCrossRate"])
+	if !ok {
+		return FeeSchedule{}, errors.New("userFees response missing userCrossRate")
+	}
+	maker, ok := floatFromAny(data["userAddRate"])
+	if !ok {
+		return FeeSchedule{}, errors.New("userFees response missing userAddRate")
+	}
+	spotTaker, ok := floatFromAny(data["userSpotCrossRate"])
+	if !ok {
+		spotTaker = taker
+	}
+	spotMaker, ok := floatFromAny(data["userSpotAddRate"])
+	if !ok {
+		spotMaker = maker
+	}
+	return FeeSchedule{
+		PerpTakerBps: taker * 10000,
+		PerpMakerBps: maker * 10000,
+		SpotTakerBps: spotTaker * 10000,
+		SpotMakerBps: spotMaker * 10000,
+	}, nil
+}
@@ -0,0 +1,59 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/rest"
+
+	"go.uber.org/zap"
+)
+
+func TestUserFeesParsesRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		_ = json.Unmarshal(body, &payload)
+		if payload["type"] != "userFees" || payload["user"] != "0xabc" {
+			t.Fatalf("unexpected payload %v", payload)
+		}
+		_, _ = w.Write([]byte(`{"userCrossRate":"0.00035","userAddRate":"0.0001","userSpotCrossRate":"0.0007","userSpotAddRate":"0.0004"}`))
+	}))
+	defer server.Close()
+	acct := New(rest.New(server.URL, 2*time.Second, zap.NewNop()), nil, zap.NewNop(), "0xabc")
+
+	fees, err := acct.UserFees(context.Background())
+	if err != nil {
+		t.Fatalf("user fees: %v", err)
+	}
+	want := FeeSchedule{PerpTakerBps: 3.5, PerpMakerBps: 1, SpotTakerBps: 7, SpotMakerBps: 4}
+	for name, pair := range map[string][2]float64{
+		"perp taker": {fees.PerpTakerBps, want.PerpTakerBps},
+		"perp maker": {fees.PerpMakerBps, want.PerpMakerBps},
+		"spot taker": {fees.SpotTakerBps, want.SpotTakerBps},
+		"spot maker": {fees.SpotMakerBps, want.SpotMakerBps},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Fatalf("%s: expected %f bps, got %f", name, pair[1], pair[0])
+		}
+	}
+}
+
+func TestParseUserFeesFallbacks(t *testing.T) {
+	fees, err := parseUserFees(map[string]any{"userCrossRate": "0.00045", "userAddRate": "-0.00001"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if math.Abs(fees.SpotTakerBps-4.5) > 1e-9 || math.Abs(fees.SpotMakerBps+0.1) > 1e-9 {
+		t.Fatalf("expected spot rates to fall back to perp rates, got %+v", fees)
+	}
+	if _, err := parseUserFees(map[string]any{"userAddRate": "0.0001"}); err == nil {
+		t.Fatalf("expected error without userCrossRate")
+	}
+}
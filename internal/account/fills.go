@@ -3,16 +3,73 @@ package account
 import (
 	"context"
 	"errors"
+	"math"
+	"strings"
+
+	"hl-carry-bot/internal/hl/rest"
 )
 
+// Fill is one account fill. Fee is charged in FeeToken: USDC for perps and
+// spot sells, the received token for spot buys.
 type Fill struct {
-	OrderID string
-	Asset   string
-	Side    string
-	Size    float64
-	Price   float64
-	TimeMS  int64
-	Hash    string
+	OrderID   string
+	Cloid     string
+	TradeID   string
+	Asset     string
+	Side      string
+	Size      float64
+	Price     float64
+	Fee       float64
+	FeeToken  string
+	ClosedPnL float64
+	TimeMS    int64
+	Hash      string
+}
+
+// FeeUSD is the fee valued in USDC, converting a fee charged in the traded
+// token at the fill price.
+func (f Fill) FeeUSD() float64 {
+	if f.FeeInAsset() {
+		return f.Fee * f.Price
+	}
+	return f.Fee
+}
+
+// FeeInAsset reports a fee taken from the token the fill received rather
+// than from USDC; the balances already reflect it.
+func (f Fill) FeeInAsset() bool {
+	return f.FeeToken != "" && !strings.EqualFold(f.FeeToken, "USDC")
+}
+
+// OrderFill is the fills of one order so far.
+type OrderFill struct {
+	Size     float64
+	Notional float64
+}
+
+func (o *OrderFill) Add(size, price float64) {
+	size = math.Abs(size)
+	o.Size += size
+	o.Notional += size * price
+}
+
+// AvgPrice is the volume-weighted fill price, or 0 before any fill.
+func (o OrderFill) AvgPrice() float64 {
+	if o.Size <= 0 {
+		return 0
+	}
+	return o.Notional / o.Size
+}
+
+// SumOrderFills aggregates the fills of orderID in fills.
+func SumOrderFills(fills []Fill, orderID string) OrderFill {
+	var out OrderFill
+	for _, fill := range fills {
+		if fill.OrderID == orderID {
+			out.Add(fill.Size, fill.Price)
+		}
+	}
+	return out
 }
 
 func (a *Account) UserFillsByTime(ctx context.Context, startTimeMS, endTimeMS int64) ([]Fill, error) {
@@ -25,19 +82,22 @@ func (a *Account) UserFillsByTime(ctx context.Context, startTimeMS, endTimeMS in
 	if startTimeMS <= 0 {
 		return nil, errors.New("start time must be > 0")
 	}
-	req := map[string]any{
-		"type":      "userFillsByTime",
-		"user":      a.user,
-		"startTime": startTimeMS,
-	}
-	if endTimeMS > 0 {
-		req["endTime"] = endTimeMS
+	fills, err := a.rest.UserFillsByTime(ctx, a.user, startTimeMS, endTimeMS)
+	if raw, ok := rest.RawFallback(err); ok {
+		a.logDecodeFallback(err)
+		return parseFills(raw), nil
 	}
-	resp, err := a.rest.InfoAny(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	return parseFills(resp), nil
+	if len(fills) == 0 {
+		return nil, nil
+	}
+	out := make([]Fill, 0, len(fills))
+	for _, fill := range fills {
+		out = append(out, fillFromInfo(fill))
+	}
+	return out, nil
 }
 
 func (a *Account) OpenOrders(ctx context.Context) ([]map[string]any, error) {
@@ -47,14 +107,8 @@ func (a *Account) OpenOrders(ctx context.Context) ([]map[string]any, error) {
 	if a.user == "" {
 		return nil, errors.New("account user is required")
 	}
-	resp, err := a.rest.InfoAny(ctx, map[string]any{
-		"type": "openOrders",
-		"user": a.user,
-	})
-	if err != nil {
-		return nil, err
-	}
-	return parseOpenOrders(resp), nil
+	orders, _, err := a.fetchOpenOrders(ctx)
+	return orders, err
 }
 
 func parseFills(payload any) []Fill {
@@ -106,13 +160,18 @@ func parseFillListMaps(raw []map[string]any) []Fill {
 
 func parseFill(entry map[string]any) Fill {
 	return Fill{
-		OrderID: stringFromAny(entry["oid"]),
-		Asset:   stringFromAny(entry["coin"]),
-		Side:    stringFromAny(entry["side"]),
-		Size:    floatOrZero(entry["sz"]),
-		Price:   floatOrZero(entry["px"]),
-		TimeMS:  int64FromAny(entry["time"]),
-		Hash:    stringFromAny(entry["hash"]),
+		OrderID:   stringFromAny(entry["oid"]),
+		Cloid:     stringFromAny(entry["cloid"]),
+		TradeID:   stringFromAny(entry["tid"]),
+		Asset:     stringFromAny(entry["coin"]),
+		Side:      stringFromAny(entry["side"]),
+		Size:      floatOrZero(entry["sz"]),
+		Price:     floatOrZero(entry["px"]),
+		Fee:       floatOrZero(entry["fee"]),
+		FeeToken:  stringFromAny(entry["feeToken"]),
+		ClosedPnL: floatOrZero(entry["closedPnl"]),
+		TimeMS:    int64FromAny(entry["time"]),
+		Hash:      stringFromAny(entry["hash"]),
 	}
 }
 
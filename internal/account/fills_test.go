@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -62,3 +63,20 @@ func TestUserFillsByTime(t *testing.T) {
 		t.Fatalf("expected size 1.5, got %f", fills[0].Size)
 	}
 }
+
+func TestFillFeesAndOrderAverage(t *testing.T) {
+	fills := []Fill{
+		{OrderID: "7", Side: "B", Size: 0.1, Price: 3000, Fee: 0.00003, FeeToken: "UETH"},
+		{OrderID: "7", Side: "B", Size: 0.3, Price: 3004, Fee: 0.00009, FeeToken: "UETH"},
+		{OrderID: "8", Side: "S", Size: 0.4, Price: 3010, Fee: 0.5, FeeToken: "USDC"},
+	}
+	if got := SumOrderFills(fills, "7"); math.Abs(got.Size-0.4) > 1e-9 || math.Abs(got.AvgPrice()-3003) > 1e-9 {
+		t.Fatalf("expected 0.4 at a volume-weighted 3003, got %+v avg %f", got, got.AvgPrice())
+	}
+	if got := fills[0].FeeUSD(); math.Abs(got-0.09) > 1e-9 || !fills[0].FeeInAsset() {
+		t.Fatalf("expected a base-token fee valued at the fill price, got %f", got)
+	}
+	if got := fills[2].FeeUSD(); got != 0.5 || fills[2].FeeInAsset() {
+		t.Fatalf("expected a USDC fee kept as is, got %f", got)
+	}
+}
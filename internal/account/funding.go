@@ -19,6 +19,13 @@ type FundingPayment struct {
 }
 
 func (a *Account) UserFunding(ctx context.Context, startTimeMs int64) ([]FundingPayment, error) {
+	return a.UserFundingRange(ctx, startTimeMs, 0)
+}
+
+// UserFundingRange fetches funding payments between startTimeMs and
+// endTimeMs (0 for open-ended). The endpoint caps each response, so callers
+// page by advancing startTimeMs.
+func (a *Account) UserFundingRange(ctx context.Context, startTimeMs, endTimeMs int64) ([]FundingPayment, error) {
 	if a.rest == nil {
 		return nil, errors.New("rest client is required")
 	}
@@ -32,6 +39,9 @@ func (a *Account) UserFunding(ctx context.Context, startTimeMs int64) ([]Funding
 	if startTimeMs >= 0 {
 		req["startTime"] = startTimeMs
 	}
+	if endTimeMs > 0 {
+		req["endTime"] = endTimeMs
+	}
 	payload, err := a.rest.InfoAny(ctx, req)
 	if err != nil {
 		return nil, err
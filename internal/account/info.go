@@ -0,0 +1,182 @@
+package account
+
+import (
+	"context"
+
+	"hl-carry-bot/internal/hl/rest"
+
+	"go.uber.org/zap"
+)
+
+// The /info snapshots are read through the typed rest client. An answer that
+// does not fit its typed response falls back to the untyped parsers, which
+// also serve the WS channels; the value kept in LastRawUpdate is whichever
+// form was used.
+
+func (a *Account) fetchSpotBalances(ctx context.Context) (map[string]float64, any, error) {
+	spot, err := a.rest.SpotClearinghouseState(ctx, a.user)
+	if raw, ok := rest.RawFallback(err); ok {
+		a.logDecodeFallback(err)
+		payload, _ := raw.(map[string]any)
+		return parseBalances(payload), raw, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	balances := make(map[string]float64, len(spot.Balances))
+	for _, bal := range spot.Balances {
+		balances[bal.Coin] = bal.Total.Float()
+	}
+	return balances, spot, nil
+}
+
+// perpState is a clearinghouseState snapshot as the account keeps it; raw is
+// the answer kept in LastRawUpdate.
+type perpState struct {
+	positions map[string]float64
+	isolated  map[string]IsolatedMargin
+	summary   MarginSummary
+	hasMargin bool
+	raw       any
+}
+
+func (a *Account) fetchPerpState(ctx context.Context) (perpState, error) {
+	perp, err := a.rest.ClearinghouseState(ctx, a.user)
+	if raw, ok := rest.RawFallback(err); ok {
+		a.logDecodeFallback(err)
+		payload, _ := raw.(map[string]any)
+		summary, hasMargin := parseMarginSummary(payload)
+		return perpState{
+			positions: parsePositions(payload),
+			isolated:  parseIsolatedMargins(payload),
+			summary:   summary,
+			hasMargin: hasMargin,
+			raw:       raw,
+		}, nil
+	}
+	if err != nil {
+		return perpState{}, err
+	}
+	out := perpState{positions: make(map[string]float64, len(perp.AssetPositions)), raw: perp}
+	for _, pos := range perp.AssetPositions {
+		out.positions[pos.Position.Coin] = pos.Position.Szi.Float()
+		if pos.Position.Leverage.Type != "isolated" {
+			continue
+		}
+		if out.isolated == nil {
+			out.isolated = make(map[string]IsolatedMargin)
+		}
+		out.isolated[pos.Position.Coin] = IsolatedMargin{
+			MarginUsed:    pos.Position.MarginUsed.Float(),
+			PositionValue: pos.Position.PositionValue.Float(),
+			Leverage:      pos.Position.Leverage.Value,
+		}
+	}
+	out.summary, out.hasMargin = marginSummaryFromState(perp)
+	return out, nil
+}
+
+func (a *Account) fetchOpenOrders(ctx context.Context) ([]map[string]any, any, error) {
+	orders, err := a.rest.OpenOrders(ctx, a.user)
+	if raw, ok := rest.RawFallback(err); ok {
+		a.logDecodeFallback(err)
+		return parseOpenOrders(raw), raw, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return openOrderMaps(orders), orders, nil
+}
+
+func (a *Account) logDecodeFallback(err error) {
+	if a.log != nil {
+		a.log.Debug("info answer did not fit its typed response; parsing it untyped", zap.Error(err))
+	}
+}
+
+// marginSummaryFromState reads the totals from marginSummary (or
+// crossMarginSummary alone), with the maintenance margin and withdrawable
+// the exchange reports next to them.
+func marginSummaryFromState(perp rest.ClearinghouseState) (MarginSummary, bool) {
+	totals := perp.MarginSummary
+	if totals == nil {
+		totals = perp.CrossMarginSummary
+	}
+	if totals == nil {
+		return MarginSummary{}, false
+	}
+	out := MarginSummary{
+		AccountValue:    totals.AccountValue.Float(),
+		TotalMarginUsed: totals.TotalMarginUsed.Float(),
+		TotalNtlPos:     totals.TotalNtlPos.Float(),
+		TotalRawUSD:     totals.TotalRawUSD.Float(),
+	}
+	if perp.CrossMaintenanceMarginUsed != nil {
+		out.MaintenanceMargin = perp.CrossMaintenanceMarginUsed.Float()
+		if out.MaintenanceMargin > 0 {
+			out.HealthRatio = out.AccountValue / out.MaintenanceMargin
+			out.HasHealthRatio = true
+		}
+	}
+	if perp.Withdrawable != nil {
+		out.Withdrawable = perp.Withdrawable.Float()
+		out.HasWithdrawable = true
+	}
+	if cross := perp.CrossMarginSummary; cross != nil {
+		out.Cross = MarginTotals{
+			AccountValue:    cross.AccountValue.Float(),
+			TotalMarginUsed: cross.TotalMarginUsed.Float(),
+			TotalNtlPos:     cross.TotalNtlPos.Float(),
+			TotalRawUSD:     cross.TotalRawUSD.Float(),
+		}
+		out.HasCross = true
+	}
+	return out, true
+}
+
+// openOrderMaps keeps typed open orders in the map form the WS channel and
+// the order helpers share, under the exchange's own keys.
+func openOrderMaps(orders []rest.OpenOrder) []map[string]any {
+	if len(orders) == 0 {
+		return nil
+	}
+	out := make([]map[string]any, 0, len(orders))
+	for _, order := range orders {
+		entry := map[string]any{
+			"coin":      order.Coin,
+			"side":      order.Side,
+			"limitPx":   order.LimitPx.Float(),
+			"sz":        order.Sz.Float(),
+			"oid":       order.Oid,
+			"timestamp": order.Timestamp,
+			"origSz":    order.OrigSz.Float(),
+		}
+		if order.Cloid != "" {
+			entry["cloid"] = order.Cloid
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func fillFromInfo(fill rest.Fill) Fill {
+	out := Fill{
+		Cloid:     fill.Cloid,
+		Asset:     fill.Coin,
+		Side:      fill.Side,
+		Size:      fill.Sz.Float(),
+		Price:     fill.Px.Float(),
+		Fee:       fill.Fee.Float(),
+		FeeToken:  fill.FeeToken,
+		ClosedPnL: fill.ClosedPnl.Float(),
+		TimeMS:    fill.Time,
+		Hash:      fill.Hash,
+	}
+	if fill.Oid != 0 {
+		out.OrderID = stringFromAny(fill.Oid)
+	}
+	if fill.Tid != 0 {
+		out.TradeID = stringFromAny(fill.Tid)
+	}
+	return out
+}
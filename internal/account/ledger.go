@@ -0,0 +1,76 @@
+package account
+
+import (
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/events"
+)
+
+// externalTransfer classifies one userNonFundingLedgerUpdates entry as money
+// moved by someone other than the bot. Transfers between the account's own
+// spot and perp wallets and vault moves are the bot's (or at least stay in
+// the account) and are not reported.
+func externalTransfer(update map[string]any, user string) (events.ExternalTransfer, bool) {
+	delta := update
+	if nested, ok := update["delta"].(map[string]any); ok {
+		delta = nested
+	}
+	out := events.ExternalTransfer{
+		Type: stringFromAny(delta["type"]),
+		Hash: stringFromAny(update["hash"]),
+	}
+	if ts, ok := timeFromAny(update["time"]); ok {
+		out.Time = ts
+	} else {
+		out.Time = time.Now().UTC()
+	}
+	switch strings.ToLower(out.Type) {
+	case "deposit":
+		usdc, ok := floatFromAny(delta["usdc"])
+		if !ok || usdc == 0 {
+			return events.ExternalTransfer{}, false
+		}
+		out.Asset, out.Amount, out.USDValue = "USDC", usdc, usdc
+	case "withdraw":
+		usdc, ok := floatFromAny(delta["usdc"])
+		if !ok || usdc == 0 {
+			return events.ExternalTransfer{}, false
+		}
+		out.Asset, out.Amount, out.USDValue = "USDC", -usdc, usdc
+	case "internaltransfer", "subaccounttransfer":
+		usdc, ok := floatFromAny(delta["usdc"])
+		if !ok || usdc == 0 {
+			return events.ExternalTransfer{}, false
+		}
+		out.Asset, out.Amount = "USDC", signedLedgerAmount(usdc, delta, user)
+		out.USDValue = usdc
+	case "spottransfer", "send":
+		amount, ok := floatFromAny(delta["amount"])
+		asset := stringFromAny(delta["token"])
+		if !ok || amount == 0 || asset == "" {
+			return events.ExternalTransfer{}, false
+		}
+		out.Asset, out.Amount = asset, signedLedgerAmount(amount, delta, user)
+		out.USDValue, _ = floatFromAny(delta["usdcValue"])
+		if out.USDValue == 0 && asset == "USDC" {
+			out.USDValue = amount
+		}
+	default:
+		return events.ExternalTransfer{}, false
+	}
+	return out, true
+}
+
+// publishExternalTransfers reports the external money movements among
+// updates. Callers hold a.mu; Publish never blocks.
+func (a *Account) publishExternalTransfers(updates []map[string]any) {
+	if a.events == nil {
+		return
+	}
+	for _, update := range updates {
+		if transfer, ok := externalTransfer(update, a.user); ok {
+			a.events.Publish(transfer)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package account
+
+import (
+	"testing"
+
+	"hl-carry-bot/internal/events"
+)
+
+func TestLedgerPublishesExternalTransfers(t *testing.T) {
+	bus := events.New()
+	ch, unsubscribe := bus.Subscribe(8, events.KindExternalTransfer)
+	defer unsubscribe()
+	const me = "0x00000000000000000000000000000000000000aa"
+	acct := &Account{user: me}
+	acct.SetEventBus(bus)
+
+	acct.applyLedgerUpdates(map[string]any{"isSnapshot": true, "nonFundingLedgerUpdates": []any{
+		map[string]any{"time": 1000, "hash": "0x0", "delta": map[string]any{"type": "deposit", "usdc": "500"}},
+	}})
+	acct.applyLedgerUpdates([]any{
+		map[string]any{"time": 2000, "hash": "0x1", "delta": map[string]any{"type": "deposit", "usdc": "250.5"}},
+		map[string]any{"time": 2001, "hash": "0x2", "delta": map[string]any{"type": "withdraw", "usdc": "100", "fee": "1"}},
+		map[string]any{"time": 2002, "hash": "0x3", "delta": map[string]any{"type": "accountClassTransfer", "usdc": "50", "toPerp": true}},
+		map[string]any{"time": 2003, "hash": "0x4", "delta": map[string]any{"type": "spotTransfer", "token": "UETH", "amount": "0.5", "usdcValue": "1500", "user": me, "destination": "0x00000000000000000000000000000000000000bb"}},
+		map[string]any{"time": 2004, "hash": "0x5", "delta": map[string]any{"type": "vaultDeposit", "vault": "0xvault", "usdc": "20"}},
+	})
+
+	want := []events.ExternalTransfer{
+		{Type: "deposit", Asset: "USDC", Amount: 250.5, USDValue: 250.5, Hash: "0x1"},
+		{Type: "withdraw", Asset: "USDC", Amount: -100, USDValue: 100, Hash: "0x2"},
+		{Type: "spotTransfer", Asset: "UETH", Amount: -0.5, USDValue: 1500, Hash: "0x4"},
+	}
+	for _, w := range want {
+		select {
+		case ev := <-ch:
+			got := ev.(events.ExternalTransfer)
+			if got.Type != w.Type || got.Asset != w.Asset || got.Amount != w.Amount || got.USDValue != w.USDValue || got.Hash != w.Hash || got.Time.IsZero() {
+				t.Fatalf("expected %+v, got %+v", w, got)
+			}
+		default:
+			t.Fatalf("expected %s published", w.Hash)
+		}
+	}
+	select {
+	case ev := <-ch:
+		t.Fatalf("expected own and snapshot moves ignored, got %+v", ev)
+	default:
+	}
+}
@@ -0,0 +1,218 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+const maxOrderStatuses = 2000
+
+var ErrOrderUpdatesUnavailable = errors.New("order updates stream not subscribed")
+
+// OrderUpdate is the latest status pushed on the orderUpdates channel.
+type OrderUpdate struct {
+	OrderID      string
+	Cloid        string
+	Asset        string
+	Side         string
+	Status       string
+	Size         float64
+	OrigSize     float64
+	LimitPrice   float64
+	StatusTimeMS int64
+	HasSize      bool
+	HasOrigSize  bool
+	RawUpdate    map[string]any
+}
+
+// Terminal reports whether the order can no longer fill.
+func (u OrderUpdate) Terminal() bool {
+	return orderStatusTerminal(u.Status)
+}
+
+// FilledSize is the size executed before the order reached its status.
+func (u OrderUpdate) FilledSize() float64 {
+	if strings.EqualFold(u.Status, "filled") {
+		if u.HasOrigSize {
+			return u.OrigSize
+		}
+		return u.Size
+	}
+	if u.HasOrigSize && u.HasSize && u.OrigSize > u.Size {
+		return u.OrigSize - u.Size
+	}
+	return 0
+}
+
+func (a *Account) OrderUpdatesEnabled() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.orderUpdatesEnabled
+}
+
+// OrderStatus returns the last pushed status for orderID, if any.
+func (a *Account) OrderStatus(orderID string) (OrderUpdate, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	update, ok := a.orderStatuses[orderID]
+	return update, ok
+}
+
+// WaitForOrderTerminal blocks until the orderUpdates stream reports orderID
+// as filled, canceled, or rejected, or ctx ends. It returns immediately when
+// the terminal update already arrived.
+func (a *Account) WaitForOrderTerminal(ctx context.Context, orderID string) (OrderUpdate, error) {
+	if orderID == "" {
+		return OrderUpdate{}, errors.New("order id is required")
+	}
+	a.mu.Lock()
+	if !a.orderUpdatesEnabled {
+		a.mu.Unlock()
+		return OrderUpdate{}, ErrOrderUpdatesUnavailable
+	}
+	if update, ok := a.orderStatuses[orderID]; ok && update.Terminal() {
+		a.mu.Unlock()
+		return update, nil
+	}
+	ch := make(chan OrderUpdate, 1)
+	if a.orderWaiters == nil {
+		a.orderWaiters = make(map[string][]chan OrderUpdate)
+	}
+	a.orderWaiters[orderID] = append(a.orderWaiters[orderID], ch)
+	a.mu.Unlock()
+
+	select {
+	case update := <-ch:
+		return update, nil
+	case <-ctx.Done():
+		a.removeOrderWaiter(orderID, ch)
+		return OrderUpdate{}, ctx.Err()
+	}
+}
+
+func (a *Account) removeOrderWaiter(orderID string, ch chan OrderUpdate) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	waiters := a.orderWaiters[orderID]
+	for i, waiter := range waiters {
+		if waiter == ch {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(a.orderWaiters, orderID)
+		return
+	}
+	a.orderWaiters[orderID] = waiters
+}
+
+func (a *Account) applyOrderUpdates(data any) {
+	updates := parseOrderUpdates(data)
+	if len(updates) == 0 {
+		return
+	}
+	a.mu.Lock()
+	a.lastUpdate = time.Now().UTC()
+	if a.orderStatuses == nil {
+		a.orderStatuses = make(map[string]OrderUpdate)
+	}
+	for _, update := range updates {
+		if _, ok := a.orderStatuses[update.OrderID]; !ok {
+			a.orderStatusOrder = append(a.orderStatusOrder, update.OrderID)
+		}
+		a.orderStatuses[update.OrderID] = update
+		if !update.Terminal() {
+			continue
+		}
+		for _, ch := range a.orderWaiters[update.OrderID] {
+			ch <- update
+		}
+		delete(a.orderWaiters, update.OrderID)
+	}
+	if len(a.orderStatusOrder) > maxOrderStatuses {
+		evict := a.orderStatusOrder[:len(a.orderStatusOrder)-maxOrderStatuses]
+		for _, orderID := range evict {
+			delete(a.orderStatuses, orderID)
+		}
+		a.orderStatusOrder = append([]string(nil), a.orderStatusOrder[len(a.orderStatusOrder)-maxOrderStatuses:]...)
+	}
+	handler := a.activityHandler
+	a.mu.Unlock()
+	if handler == nil {
+		return
+	}
+	for _, update := range updates {
+		handler(Activity{
+			Kind:    ActivityOrder,
+			OrderID: update.OrderID,
+			Cloid:   update.Cloid,
+			Asset:   update.Asset,
+			Side:    update.Side,
+			Status:  update.Status,
+			Size:    update.Size,
+			Price:   update.LimitPrice,
+			TimeMS:  update.StatusTimeMS,
+		})
+	}
+}
+
+func parseOrderUpdates(data any) []OrderUpdate {
+	var raw []any
+	switch payload := data.(type) {
+	case []any:
+		raw = payload
+	case map[string]any:
+		raw = []any{payload}
+	default:
+		return nil
+	}
+	updates := make([]OrderUpdate, 0, len(raw))
+	for _, item := range raw {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		order, ok := entry["order"].(map[string]any)
+		if !ok {
+			order = entry
+		}
+		update := OrderUpdate{
+			OrderID:      stringFromAny(order["oid"]),
+			Cloid:        stringFromAny(order["cloid"]),
+			Asset:        stringFromAny(order["coin"]),
+			Side:         stringFromAny(order["side"]),
+			Status:       strings.TrimSpace(stringFromAny(entry["status"])),
+			LimitPrice:   floatOrZero(order["limitPx"]),
+			StatusTimeMS: int64FromAny(entry["statusTimestamp"]),
+			RawUpdate:    entry,
+		}
+		if update.OrderID == "" || update.Status == "" {
+			continue
+		}
+		if sz, ok := floatFromAny(order["sz"]); ok {
+			update.Size = sz
+			update.HasSize = true
+		}
+		if orig, ok := floatFromAny(order["origSz"]); ok {
+			update.OrigSize = orig
+			update.HasOrigSize = true
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// orderStatusTerminal treats everything except open/triggered as final;
+// Hyperliquid reports several cancel and reject variants (marginCanceled,
+// reduceOnlyRejected, ...).
+func orderStatusTerminal(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "", "open", "triggered":
+		return false
+	default:
+		return true
+	}
+}
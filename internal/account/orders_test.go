@@ -0,0 +1,113 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func orderUpdateMessage(t *testing.T, oid int, status string, sz, origSz string) json.RawMessage {
+	t.Helper()
+	msg, err := json.Marshal(map[string]any{
+		"channel": "orderUpdates",
+		"data": []any{
+			map[string]any{
+				"order": map[string]any{
+					"coin":    "BTC",
+					"side":    "B",
+					"limitPx": "30000",
+					"sz":      sz,
+					"oid":     oid,
+					"origSz":  origSz,
+				},
+				"status":          status,
+				"statusTimestamp": 1700000000000,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return msg
+}
+
+func TestWaitForOrderTerminalWakesOnPush(t *testing.T) {
+	acct := &Account{log: zap.NewNop(), orderUpdatesEnabled: true}
+	acct.handleMessage(orderUpdateMessage(t, 42, "open", "0.1", "0.1"))
+
+	done := make(chan OrderUpdate, 1)
+	go func() {
+		update, err := acct.WaitForOrderTerminal(context.Background(), "42")
+		if err != nil {
+			t.Errorf("wait: %v", err)
+		}
+		done <- update
+	}()
+	select {
+	case <-done:
+		t.Fatalf("expected wait to block while order is open")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	acct.handleMessage(orderUpdateMessage(t, 42, "canceled", "0.04", "0.1"))
+	select {
+	case update := <-done:
+		if !update.Terminal() || update.Status != "canceled" {
+			t.Fatalf("unexpected update: %+v", update)
+		}
+		if got := update.FilledSize(); math.Abs(got-0.06) > 1e-9 {
+			t.Fatalf("expected filled 0.06, got %f", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected wait to return on terminal update")
+	}
+
+	// Already terminal orders return without blocking.
+	update, err := acct.WaitForOrderTerminal(context.Background(), "42")
+	if err != nil || update.Status != "canceled" {
+		t.Fatalf("expected cached terminal update, got %+v err=%v", update, err)
+	}
+}
+
+func TestWaitForOrderTerminalTimeoutAndDisabled(t *testing.T) {
+	acct := &Account{log: zap.NewNop()}
+	if _, err := acct.WaitForOrderTerminal(context.Background(), "1"); !errors.Is(err, ErrOrderUpdatesUnavailable) {
+		t.Fatalf("expected ErrOrderUpdatesUnavailable, got %v", err)
+	}
+	acct.orderUpdatesEnabled = true
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := acct.WaitForOrderTerminal(ctx, "1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if len(acct.orderWaiters) != 0 {
+		t.Fatalf("expected waiter to be removed, got %d", len(acct.orderWaiters))
+	}
+}
+
+func TestOrderUpdateFilledSize(t *testing.T) {
+	cases := []struct {
+		update OrderUpdate
+		want   float64
+	}{
+		{OrderUpdate{Status: "filled", Size: 0, OrigSize: 0.5, HasSize: true, HasOrigSize: true}, 0.5},
+		{OrderUpdate{Status: "marginCanceled", Size: 0.2, OrigSize: 0.5, HasSize: true, HasOrigSize: true}, 0.3},
+		{OrderUpdate{Status: "rejected", Size: 0.5, OrigSize: 0.5, HasSize: true, HasOrigSize: true}, 0},
+	}
+	for _, tc := range cases {
+		if got := tc.update.FilledSize(); math.Abs(got-tc.want) > 1e-9 {
+			t.Fatalf("%s: expected %f, got %f", tc.update.Status, tc.want, got)
+		}
+		if !tc.update.Terminal() {
+			t.Fatalf("%s: expected terminal", tc.update.Status)
+		}
+	}
+	if (OrderUpdate{Status: "triggered"}).Terminal() {
+		t.Fatalf("expected triggered to be non-terminal")
+	}
+}
@@ -0,0 +1,258 @@
+// Package accounting seeds and maintains the fill/funding journal used for
+// PnL and funding reports.
+package accounting
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/state"
+)
+
+const (
+	FillsCursorKey   = "accounting:fills:cursor_ms"
+	FundingCursorKey = "accounting:funding:cursor_ms"
+
+	// Response caps for userFillsByTime and userFunding; a short page means
+	// the range is exhausted.
+	fillsPageLimit   = 2000
+	fundingPageLimit = 500
+)
+
+// Source is the subset of the account client the backfill pages through.
+type Source interface {
+	UserFillsByTime(ctx context.Context, startTimeMS, endTimeMS int64) ([]account.Fill, error)
+	UserFundingRange(ctx context.Context, startTimeMs, endTimeMs int64) ([]account.FundingPayment, error)
+}
+
+type Result struct {
+	FillsInserted    int
+	FillsDuplicate   int
+	FundingInserted  int
+	FundingDuplicate int
+	Pages            int
+	FillsCursorMS    int64
+	FundingCursorMS  int64
+}
+
+// Backfill copies fills and funding from the exchange into the journal.
+// Store is optional; when set, the latest seen timestamp of each stream is
+// kept there so later runs resume instead of starting over.
+type Backfill struct {
+	Source  Source
+	Journal state.Journal
+	Store   state.Store
+}
+
+// Run pages both streams from start to end (zero end means now). Rows
+// already in the journal are counted as duplicates and left untouched.
+func (b Backfill) Run(ctx context.Context, start, end time.Time) (Result, error) {
+	startMS := start.UnixMilli()
+	return b.run(ctx, startMS, startMS, end)
+}
+
+// Resume continues each stream from its stored cursor, falling back to
+// defaultStart for streams that have never been backfilled.
+func (b Backfill) Resume(ctx context.Context, defaultStart, end time.Time) (Result, error) {
+	fillsStart, err := b.cursor(ctx, FillsCursorKey, defaultStart.UnixMilli())
+	if err != nil {
+		return Result{}, err
+	}
+	fundingStart, err := b.cursor(ctx, FundingCursorKey, defaultStart.UnixMilli())
+	if err != nil {
+		return Result{}, err
+	}
+	return b.run(ctx, fillsStart, fundingStart, end)
+}
+
+func (b Backfill) run(ctx context.Context, fillsStart, fundingStart int64, end time.Time) (Result, error) {
+	if b.Source == nil || b.Journal == nil {
+		return Result{}, errors.New("accounting backfill requires a source and journal")
+	}
+	if fillsStart <= 0 || fundingStart <= 0 {
+		return Result{}, errors.New("backfill start must be after the epoch")
+	}
+	endMS := int64(0)
+	if !end.IsZero() {
+		endMS = end.UnixMilli()
+	}
+	var res Result
+	if err := b.backfillFills(ctx, fillsStart, endMS, &res); err != nil {
+		return res, err
+	}
+	if err := b.backfillFunding(ctx, fundingStart, endMS, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (b Backfill) backfillFills(ctx context.Context, startMS, endMS int64, res *Result) error {
+	cursor := startMS
+	for {
+		batch, err := b.Source.UserFillsByTime(ctx, cursor, endMS)
+		if err != nil {
+			return err
+		}
+		res.Pages++
+		if len(batch) == 0 {
+			return nil
+		}
+		latest := cursor
+		inserted := 0
+		for _, fill := range batch {
+			ok, err := b.Journal.RecordFill(ctx, FillRecord(fill))
+			if err != nil {
+				return err
+			}
+			if ok {
+				inserted++
+			} else {
+				res.FillsDuplicate++
+			}
+			if fill.TimeMS > latest {
+				latest = fill.TimeMS
+			}
+		}
+		res.FillsInserted += inserted
+		res.FillsCursorMS = latest
+		if err := b.saveCursor(ctx, FillsCursorKey, latest); err != nil {
+			return err
+		}
+		if len(batch) < fillsPageLimit {
+			return nil
+		}
+		cursor = nextCursor(cursor, latest, inserted)
+	}
+}
+
+func (b Backfill) backfillFunding(ctx context.Context, startMS, endMS int64, res *Result) error {
+	cursor := startMS
+	for {
+		batch, err := b.Source.UserFundingRange(ctx, cursor, endMS)
+		if err != nil {
+			return err
+		}
+		res.Pages++
+		if len(batch) == 0 {
+			return nil
+		}
+		latest := cursor
+		inserted := 0
+		for _, payment := range batch {
+			record, ok := FundingRecord(payment)
+			if !ok {
+				continue
+			}
+			ok, err := b.Journal.RecordFunding(ctx, record)
+			if err != nil {
+				return err
+			}
+			if ok {
+				inserted++
+			} else {
+				res.FundingDuplicate++
+			}
+			if record.TimeMS > latest {
+				latest = record.TimeMS
+			}
+		}
+		res.FundingInserted += inserted
+		res.FundingCursorMS = latest
+		if err := b.saveCursor(ctx, FundingCursorKey, latest); err != nil {
+			return err
+		}
+		if len(batch) < fundingPageLimit {
+			return nil
+		}
+		cursor = nextCursor(cursor, latest, inserted)
+	}
+}
+
+// nextCursor restarts a full page at its last timestamp so rows sharing that
+// millisecond are not skipped; a page that added nothing new steps past it.
+func nextCursor(cursor, latest int64, inserted int) int64 {
+	next := latest
+	if inserted == 0 {
+		next = latest + 1
+	}
+	if next <= cursor {
+		next = cursor + 1
+	}
+	return next
+}
+
+func (b Backfill) cursor(ctx context.Context, key string, fallback int64) (int64, error) {
+	if b.Store == nil {
+		return fallback, nil
+	}
+	raw, ok, err := b.Store.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if !ok || strings.TrimSpace(raw) == "" {
+		return fallback, nil
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if value <= 0 {
+		return fallback, nil
+	}
+	return value, nil
+}
+
+func (b Backfill) saveCursor(ctx context.Context, key string, value int64) error {
+	if b.Store == nil || value <= 0 {
+		return nil
+	}
+	current, err := b.cursor(ctx, key, 0)
+	if err != nil {
+		return err
+	}
+	if value <= current {
+		return nil
+	}
+	return b.Store.Set(ctx, key, strconv.FormatInt(value, 10))
+}
+
+// FillRecord converts an exchange fill into a journal row. The trade id is
+// unique per fill; older payloads without one fall back to hash/oid/time.
+func FillRecord(fill account.Fill) state.FillRecord {
+	id := fill.TradeID
+	if id == "" {
+		id = fill.Hash + ":" + fill.OrderID + ":" + strconv.FormatInt(fill.TimeMS, 10)
+	}
+	return state.FillRecord{
+		ID:        id,
+		OrderID:   fill.OrderID,
+		Asset:     fill.Asset,
+		Side:      fill.Side,
+		Size:      fill.Size,
+		Price:     fill.Price,
+		Fee:       fill.Fee,
+		ClosedPnL: fill.ClosedPnL,
+		TimeMS:    fill.TimeMS,
+		Hash:      fill.Hash,
+	}
+}
+
+// FundingRecord converts a funding payment into a journal row keyed by
+// asset and funding time. Payments without a time or amount are skipped.
+func FundingRecord(payment account.FundingPayment) (state.FundingRecord, bool) {
+	if !payment.HasTime || !payment.HasAmount || payment.Asset == "" {
+		return state.FundingRecord{}, false
+	}
+	timeMS := payment.Time.UnixMilli()
+	return state.FundingRecord{
+		ID:     payment.Asset + ":" + strconv.FormatInt(timeMS, 10),
+		Asset:  payment.Asset,
+		Amount: payment.Amount,
+		Rate:   payment.Rate,
+		TimeMS: timeMS,
+	}, true
+}
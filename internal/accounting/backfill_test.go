@@ -0,0 +1,154 @@
+package accounting
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/state/sqlite"
+)
+
+type fakeSource struct {
+	fills        []account.Fill
+	funding      []account.FundingPayment
+	fillStarts   []int64
+	fundingCalls int
+}
+
+func (s *fakeSource) UserFillsByTime(ctx context.Context, startMS, endMS int64) ([]account.Fill, error) {
+	s.fillStarts = append(s.fillStarts, startMS)
+	var out []account.Fill
+	for _, fill := range s.fills {
+		if fill.TimeMS < startMS || (endMS > 0 && fill.TimeMS > endMS) {
+			continue
+		}
+		out = append(out, fill)
+		if len(out) == fillsPageLimit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeSource) UserFundingRange(ctx context.Context, startMS, endMS int64) ([]account.FundingPayment, error) {
+	s.fundingCalls++
+	var out []account.FundingPayment
+	for _, payment := range s.funding {
+		ts := payment.Time.UnixMilli()
+		if ts < startMS || (endMS > 0 && ts > endMS) {
+			continue
+		}
+		out = append(out, payment)
+		if len(out) == fundingPageLimit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func newFakeSource(fills, hours int) *fakeSource {
+	src := &fakeSource{}
+	for i := 0; i < fills; i++ {
+		// Two fills per millisecond so page boundaries split a timestamp.
+		src.fills = append(src.fills, account.Fill{
+			TradeID: strconv.Itoa(i + 1),
+			OrderID: strconv.Itoa(i/2 + 1),
+			Asset:   "ETH",
+			Side:    "B",
+			Size:    0.01,
+			Price:   3000,
+			TimeMS:  int64(1_000 + i/2),
+		})
+	}
+	base := time.UnixMilli(1_000)
+	for i := 0; i < hours; i++ {
+		src.funding = append(src.funding, account.FundingPayment{
+			Asset:     "ETH",
+			Amount:    0.01,
+			Rate:      0.0001,
+			Time:      base.Add(time.Duration(i) * time.Hour),
+			HasAmount: true,
+			HasRate:   true,
+			HasTime:   true,
+		})
+	}
+	return src
+}
+
+func TestBackfillPagesAndDeduplicates(t *testing.T) {
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("store init: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	src := newFakeSource(fillsPageLimit+501, fundingPageLimit+20)
+	backfill := Backfill{Source: src, Journal: store, Store: store}
+
+	res, err := backfill.Run(ctx, time.UnixMilli(1), time.Time{})
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	if res.FillsInserted != len(src.fills) {
+		t.Fatalf("expected %d fills inserted, got %+v", len(src.fills), res)
+	}
+	if res.FundingInserted != len(src.funding) {
+		t.Fatalf("expected %d funding inserted, got %+v", len(src.funding), res)
+	}
+	if len(src.fillStarts) < 2 {
+		t.Fatalf("expected fills to be paged, got %v", src.fillStarts)
+	}
+	fills, err := store.Fills(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("fills query: %v", err)
+	}
+	if len(fills) != len(src.fills) {
+		t.Fatalf("expected %d journal fills, got %d", len(src.fills), len(fills))
+	}
+
+	again, err := backfill.Run(ctx, time.UnixMilli(1), time.Time{})
+	if err != nil {
+		t.Fatalf("second backfill: %v", err)
+	}
+	if again.FillsInserted != 0 || again.FundingInserted != 0 {
+		t.Fatalf("expected no new rows on rerun, got %+v", again)
+	}
+	if again.FillsDuplicate < len(src.fills) || again.FundingDuplicate < len(src.funding) {
+		t.Fatalf("expected duplicates to be counted, got %+v", again)
+	}
+}
+
+func TestBackfillResumeStartsFromCursor(t *testing.T) {
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("store init: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	src := newFakeSource(10, 3)
+	backfill := Backfill{Source: src, Journal: store, Store: store}
+
+	if _, err := backfill.Resume(ctx, time.UnixMilli(1), time.Time{}); err != nil {
+		t.Fatalf("first resume: %v", err)
+	}
+	raw, ok, err := store.Get(ctx, FillsCursorKey)
+	if err != nil || !ok || raw != "1004" {
+		t.Fatalf("expected fills cursor 1004, got %q ok=%v err=%v", raw, ok, err)
+	}
+
+	src.fills = append(src.fills, account.Fill{TradeID: "99", Asset: "ETH", TimeMS: 5_000})
+	src.fillStarts = nil
+	res, err := backfill.Resume(ctx, time.UnixMilli(1), time.Time{})
+	if err != nil {
+		t.Fatalf("second resume: %v", err)
+	}
+	if len(src.fillStarts) != 1 || src.fillStarts[0] != 1004 {
+		t.Fatalf("expected resume from cursor, got %v", src.fillStarts)
+	}
+	if res.FillsInserted != 1 {
+		t.Fatalf("expected only the new fill, got %+v", res)
+	}
+}
@@ -0,0 +1,127 @@
+package alerts
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDedupWindow  = 10 * time.Minute
+	defaultMaxPerMinute = 20
+)
+
+// limiter is the alert manager shared by a Telegram and its prefixed clones:
+// it holds back repeats of an alert sent within the dedup window, counts
+// them so they go out as one summary once the window ends, and caps how many
+// messages leave per minute, counting what it drops.
+type limiter struct {
+	mu           sync.Mutex
+	window       time.Duration
+	maxPerMinute int
+	seen         map[string]*alertEntry
+	sent         []time.Time
+	dropped      int
+}
+
+// alertEntry tracks one distinct alert text within its dedup window.
+type alertEntry struct {
+	sentAt  time.Time
+	repeats int
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		window:       defaultDedupWindow,
+		maxPerMinute: defaultMaxPerMinute,
+		seen:         make(map[string]*alertEntry),
+	}
+}
+
+func (l *limiter) configure(window time.Duration, maxPerMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if window <= 0 {
+		window = defaultDedupWindow
+	}
+	if maxPerMinute <= 0 {
+		maxPerMinute = defaultMaxPerMinute
+	}
+	l.window = window
+	l.maxPerMinute = maxPerMinute
+}
+
+// admit decides whether text goes out at now and returns the message to
+// send, which carries the count of alerts the budget dropped since the last
+// send.
+func (l *limiter) admit(text string, now time.Time) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pruneSent(now)
+	if entry, ok := l.seen[text]; ok && now.Sub(entry.sentAt) < l.window {
+		entry.repeats++
+		return "", false
+	}
+	if len(l.sent) >= l.maxPerMinute {
+		l.dropped++
+		return "", false
+	}
+	out := text
+	if entry, ok := l.seen[text]; ok && entry.repeats > 0 {
+		out += fmt.Sprintf("\n(repeated %d more times since %s)", entry.repeats, entry.sentAt.UTC().Format(time.RFC3339))
+	}
+	out += l.takeDropped()
+	l.seen[text] = &alertEntry{sentAt: now}
+	l.sent = append(l.sent, now)
+	return out, true
+}
+
+// flush returns the summaries that are due at now: one per alert whose dedup
+// window ended with repeats held back, and the dropped count when nothing
+// else carried it. Summaries spend the same per-minute budget; what does not
+// fit waits for a later flush.
+func (l *limiter) flush(now time.Time) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pruneSent(now)
+	var out []string
+	for text, entry := range l.seen {
+		if now.Sub(entry.sentAt) < l.window {
+			continue
+		}
+		if entry.repeats == 0 {
+			delete(l.seen, text)
+			continue
+		}
+		if len(l.sent) >= l.maxPerMinute {
+			break
+		}
+		out = append(out, fmt.Sprintf("Repeated %d more times since %s: %s", entry.repeats, entry.sentAt.UTC().Format(time.RFC3339), text))
+		delete(l.seen, text)
+		l.sent = append(l.sent, now)
+	}
+	if l.dropped > 0 && len(l.sent) < l.maxPerMinute {
+		out = append(out, fmt.Sprintf("%d alerts dropped by the rate limit (%d per minute)", l.dropped, l.maxPerMinute))
+		l.dropped = 0
+		l.sent = append(l.sent, now)
+	}
+	return out
+}
+
+func (l *limiter) takeDropped() string {
+	if l.dropped == 0 {
+		return ""
+	}
+	note := fmt.Sprintf("\n(%d alerts dropped by the rate limit)", l.dropped)
+	l.dropped = 0
+	return note
+}
+
+func (l *limiter) pruneSent(now time.Time) {
+	cutoff := now.Add(-time.Minute)
+	keep := 0
+	for keep < len(l.sent) && !l.sent[keep].After(cutoff) {
+		keep++
+	}
+	l.sent = l.sent[keep:]
+}
@@ -0,0 +1,115 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+
+	"go.uber.org/zap"
+)
+
+func TestLimiterDedupsRepeatsAndSummarizesAfterWindow(t *testing.T) {
+	l := newLimiter()
+	l.configure(5*time.Minute, 10)
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	if text, ok := l.admit("exit failed", start); !ok || text != "exit failed" {
+		t.Fatalf("expected first alert sent as is, got %q %v", text, ok)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, ok := l.admit("exit failed", start.Add(time.Duration(i)*time.Minute)); ok {
+			t.Fatalf("expected repeat %d held back", i)
+		}
+	}
+	if text, ok := l.admit("entry failed", start.Add(time.Minute)); !ok || text != "entry failed" {
+		t.Fatalf("expected a different alert sent, got %q %v", text, ok)
+	}
+	if out := l.flush(start.Add(4 * time.Minute)); len(out) != 0 {
+		t.Fatalf("expected nothing due inside the window, got %v", out)
+	}
+	out := l.flush(start.Add(5 * time.Minute))
+	if len(out) != 1 || !strings.HasPrefix(out[0], "Repeated 3 more times since 2026-01-02T03:00:00Z: exit failed") {
+		t.Fatalf("expected one repeat summary, got %v", out)
+	}
+	if text, ok := l.admit("exit failed", start.Add(6*time.Minute)); !ok || text != "exit failed" {
+		t.Fatalf("expected alert sent again after the summary, got %q %v", text, ok)
+	}
+}
+
+func TestLimiterRepeatAfterWindowCarriesCount(t *testing.T) {
+	l := newLimiter()
+	l.configure(time.Minute, 10)
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	l.admit("exit failed", start)
+	l.admit("exit failed", start.Add(30*time.Second))
+	text, ok := l.admit("exit failed", start.Add(2*time.Minute))
+	if !ok || text != "exit failed\n(repeated 1 more times since 2026-01-02T03:00:00Z)" {
+		t.Fatalf("expected repeat count on the next send, got %q %v", text, ok)
+	}
+}
+
+func TestLimiterBudgetDropsAndReportsOverflow(t *testing.T) {
+	l := newLimiter()
+	l.configure(time.Hour, 2)
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	for i, msg := range []string{"a", "b"} {
+		if _, ok := l.admit(msg, start.Add(time.Duration(i)*time.Second)); !ok {
+			t.Fatalf("expected %s within budget", msg)
+		}
+	}
+	for _, msg := range []string{"c", "d", "e"} {
+		if _, ok := l.admit(msg, start.Add(10*time.Second)); ok {
+			t.Fatalf("expected %s over budget", msg)
+		}
+	}
+	if out := l.flush(start.Add(30 * time.Second)); len(out) != 0 {
+		t.Fatalf("expected no summary while the budget is spent, got %v", out)
+	}
+	text, ok := l.admit("f", start.Add(61*time.Second))
+	if !ok || text != "f\n(3 alerts dropped by the rate limit)" {
+		t.Fatalf("expected dropped count on the next alert, got %q %v", text, ok)
+	}
+	l.admit("g", start.Add(62*time.Second))
+	l.admit("h", start.Add(63*time.Second))
+	out := l.flush(start.Add(3 * time.Minute))
+	if len(out) != 1 || out[0] != "1 alerts dropped by the rate limit (2 per minute)" {
+		t.Fatalf("expected dropped summary from flush, got %v", out)
+	}
+}
+
+func TestTelegramDedupsAlertsButNotReplies(t *testing.T) {
+	var texts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		texts = append(texts, payload["text"])
+		_, _ = w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer server.Close()
+
+	cfg := config.TelegramConfig{Enabled: true, Token: "token", ChatID: "123", DedupWindow: time.Hour, MaxPerMinute: 10}
+	base := newTelegram(cfg, zap.NewNop(), server.URL, server.Client())
+	clone := base.WithPrefix("[sub] ")
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := clone.Send(ctx, "exit failed"); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+		if err := clone.Reply(ctx, "paused"); err != nil {
+			t.Fatalf("reply: %v", err)
+		}
+	}
+	if err := base.Send(ctx, "exit failed"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	want := []string{"[sub] exit failed", "[sub] paused", "[sub] paused", "[sub] paused", "exit failed"}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected %v, got %v", want, texts)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"hl-carry-bot/internal/config"
@@ -19,12 +20,21 @@ import (
 const telegramBaseURL = "https://api.telegram.org"
 
 type Telegram struct {
+	settings *telegramSettings
+	limits   *limiter
+	baseURL  string
+	client   *http.Client
+	log      *zap.Logger
+	prefix   string
+}
+
+// telegramSettings are shared by a Telegram and its prefixed clones so a
+// Reconfigure reaches all of them.
+type telegramSettings struct {
+	mu      sync.RWMutex
 	enabled bool
 	token   string
 	chatID  string
-	baseURL string
-	client  *http.Client
-	log     *zap.Logger
 }
 
 type Update struct {
@@ -59,35 +69,111 @@ func newTelegram(cfg config.TelegramConfig, log *zap.Logger, baseURL string, cli
 	if client == nil {
 		client = &http.Client{Timeout: 10 * time.Second}
 	}
-	return &Telegram{
-		enabled: cfg.Enabled,
-		token:   strings.TrimSpace(cfg.Token),
-		chatID:  strings.TrimSpace(cfg.ChatID),
-		baseURL: strings.TrimRight(baseURL, "/"),
-		client:  client,
-		log:     log,
+	t := &Telegram{
+		settings: &telegramSettings{},
+		limits:   newLimiter(),
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		client:   client,
+		log:      log,
 	}
+	t.Reconfigure(cfg)
+	return t
+}
+
+// Reconfigure switches t and every sender derived from it with WithPrefix to
+// the enabled flag, token, chat and alert limits in cfg.
+func (t *Telegram) Reconfigure(cfg config.TelegramConfig) {
+	t.limits.configure(cfg.DedupWindow, cfg.MaxPerMinute)
+	t.settings.mu.Lock()
+	defer t.settings.mu.Unlock()
+	t.settings.enabled = cfg.Enabled
+	t.settings.token = strings.TrimSpace(cfg.Token)
+	t.settings.chatID = strings.TrimSpace(cfg.ChatID)
+}
+
+func (t *Telegram) current() (enabled bool, token, chatID string) {
+	t.settings.mu.RLock()
+	defer t.settings.mu.RUnlock()
+	return t.settings.enabled, t.settings.token, t.settings.chatID
+}
+
+// WithPrefix returns a sender that shares t's transport and alert limits and
+// prepends prefix to every message, e.g. "[sub-1] " to label alerts per
+// account.
+func (t *Telegram) WithPrefix(prefix string) *Telegram {
+	clone := *t
+	clone.prefix = prefix
+	return &clone
 }
 
+// Send delivers an alert. A repeat of an alert sent within
+// telegram.dedup_window is held back and summarized by Flush, and alerts past
+// telegram.max_per_minute are dropped and counted in the next message.
 func (t *Telegram) Send(ctx context.Context, message string) error {
-	if !t.enabled {
+	enabled, token, chatID := t.current()
+	if !enabled {
+		return nil
+	}
+	if err := checkMessage(token, chatID, message); err != nil {
+		return err
+	}
+	text, ok := t.limits.admit(t.prefix+message, time.Now())
+	if !ok {
+		return nil
+	}
+	return t.deliver(ctx, token, chatID, text)
+}
+
+// Reply delivers an operator command response. Replies answer a request, so
+// they skip deduplication and the alert budget.
+func (t *Telegram) Reply(ctx context.Context, message string) error {
+	enabled, token, chatID := t.current()
+	if !enabled {
 		return nil
 	}
-	if t.token == "" || t.chatID == "" {
+	if err := checkMessage(token, chatID, message); err != nil {
+		return err
+	}
+	return t.deliver(ctx, token, chatID, t.prefix+message)
+}
+
+// Flush sends the summaries of repeats held back by Send whose dedup window
+// has ended, and the count of alerts dropped by the budget if no alert has
+// carried it yet. Summaries are already prefixed per account.
+func (t *Telegram) Flush(ctx context.Context) error {
+	enabled, token, chatID := t.current()
+	if !enabled || token == "" || chatID == "" {
+		return nil
+	}
+	var errs []error
+	for _, text := range t.limits.flush(time.Now()) {
+		if err := t.deliver(ctx, token, chatID, text); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkMessage(token, chatID, message string) error {
+	if token == "" || chatID == "" {
 		return errors.New("telegram token and chat_id are required")
 	}
 	if strings.TrimSpace(message) == "" {
 		return errors.New("telegram message is empty")
 	}
+	return nil
+}
+
+func (t *Telegram) deliver(ctx context.Context, token, chatID, text string) error {
 	payload := map[string]string{
-		"chat_id": t.chatID,
-		"text":    message,
+		"chat_id": chatID,
+		"text":    text,
 	}
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
-	url := fmt.Sprintf("%s/bot%s/sendMessage", t.baseURL, t.token)
+	url := fmt.Sprintf("%s/bot%s/sendMessage", t.baseURL, token)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return err
@@ -118,8 +204,52 @@ func (t *Telegram) Send(ctx context.Context, message string) error {
 	return nil
 }
 
+// Check verifies the bot token and that the bot can reach chat_id, via
+// getChat, without sending a message.
+func (t *Telegram) Check(ctx context.Context) error {
+	_, token, chatID := t.current()
+	if token == "" || chatID == "" {
+		return errors.New("telegram token and chat_id are required")
+	}
+	body, err := json.Marshal(map[string]string{"chat_id": chatID})
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/bot%s/getChat", t.baseURL, token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("telegram getChat failed: http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.OK {
+		desc := strings.TrimSpace(result.Description)
+		if desc == "" {
+			desc = "unknown telegram error"
+		}
+		return fmt.Errorf("telegram getChat failed: %s", desc)
+	}
+	return nil
+}
+
 func (t *Telegram) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
-	if t.token == "" {
+	_, token, _ := t.current()
+	if token == "" {
 		return nil, errors.New("telegram token is required")
 	}
 	payload := map[string]any{
@@ -135,7 +265,7 @@ func (t *Telegram) GetUpdates(ctx context.Context, offset int64, timeout time.Du
 	if err != nil {
 		return nil, err
 	}
-	url := fmt.Sprintf("%s/bot%s/getUpdates", t.baseURL, t.token)
+	url := fmt.Sprintf("%s/bot%s/getUpdates", t.baseURL, token)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
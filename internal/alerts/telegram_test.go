@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"hl-carry-bot/internal/config"
@@ -94,3 +95,80 @@ func TestTelegramGetUpdates(t *testing.T) {
 		t.Fatalf("expected message /status")
 	}
 }
+
+func TestTelegramWithPrefix(t *testing.T) {
+	var gotPayload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&gotPayload)
+		_, _ = w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer server.Close()
+
+	cfg := config.TelegramConfig{Enabled: true, Token: "token", ChatID: "123"}
+	base := newTelegram(cfg, zap.NewNop(), server.URL, server.Client())
+	if err := base.WithPrefix("[sub-1] ").Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("expected send success, got %v", err)
+	}
+	if gotPayload["text"] != "[sub-1] hello" {
+		t.Fatalf("expected prefixed text, got %q", gotPayload["text"])
+	}
+	if err := base.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("expected send success, got %v", err)
+	}
+	if gotPayload["text"] != "hello" {
+		t.Fatalf("expected base sender unprefixed, got %q", gotPayload["text"])
+	}
+}
+
+func TestTelegramReconfigureReachesPrefixedClones(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer server.Close()
+
+	base := newTelegram(config.TelegramConfig{}, zap.NewNop(), server.URL, server.Client())
+	clone := base.WithPrefix("[sub] ")
+	if err := clone.Send(context.Background(), "hello"); err != nil || gotPath != "" {
+		t.Fatalf("expected a disabled clone to stay quiet, got %v %q", err, gotPath)
+	}
+	base.Reconfigure(config.TelegramConfig{Enabled: true, Token: "next", ChatID: "123"})
+	if err := clone.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("expected send success, got %v", err)
+	}
+	if gotPath != "/botnext/sendMessage" {
+		t.Fatalf("expected the new token used, got %s", gotPath)
+	}
+}
+
+func TestTelegramCheck(t *testing.T) {
+	var gotPath, gotChat string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		gotChat = payload["chat_id"]
+		w.Header().Set("Content-Type", "application/json")
+		if gotChat != "42" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"id":42,"type":"private"}}`))
+	}))
+	defer server.Close()
+
+	client := newTelegram(config.TelegramConfig{Enabled: true, Token: "token", ChatID: "42"}, zap.NewNop(), server.URL, server.Client())
+	if err := client.Check(context.Background()); err != nil {
+		t.Fatalf("expected check success, got %v", err)
+	}
+	if gotPath != "/bottoken/getChat" || gotChat != "42" {
+		t.Fatalf("unexpected getChat request %s chat %q", gotPath, gotChat)
+	}
+	wrong := newTelegram(config.TelegramConfig{Enabled: true, Token: "token", ChatID: "7"}, zap.NewNop(), server.URL, server.Client())
+	if err := wrong.Check(context.Background()); err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Fatalf("expected chat not found, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hl-carry-bot/internal/accounting"
+	persist "hl-carry-bot/internal/state"
+
+	"go.uber.org/zap"
+)
+
+func (a *App) accountingBackfill() (accounting.Backfill, error) {
+	if a.account == nil {
+		return accounting.Backfill{}, errors.New("account is required")
+	}
+	journal, ok := a.store.(persist.Journal)
+	if !ok {
+		return accounting.Backfill{}, errors.New("state store does not support the accounting journal")
+	}
+	return accounting.Backfill{Source: a.account, Journal: journal, Store: a.store}, nil
+}
+
+// RunBackfill pages fill and funding history from start into the journal
+// and returns; rows already present are skipped.
+func (a *App) RunBackfill(ctx context.Context, start time.Time) (accounting.Result, error) {
+	defer a.store.Close()
+	backfill, err := a.accountingBackfill()
+	if err != nil {
+		return accounting.Result{}, err
+	}
+	if a.log != nil {
+		a.log.Info("accounting backfill started", zap.Time("start", start.UTC()))
+	}
+	res, err := backfill.Run(ctx, start, time.Time{})
+	if err != nil {
+		return res, err
+	}
+	a.logAccountingResult("accounting backfill complete", res)
+	return res, nil
+}
+
+func (a *App) startAccountingSync(ctx context.Context) {
+	if a.config() == nil || !a.config().Accounting.EnabledValue() {
+		return
+	}
+	if _, err := a.accountingBackfill(); err != nil {
+		if a.log != nil {
+			a.log.Warn("accounting sync disabled", zap.Error(err))
+		}
+		return
+	}
+	interval := a.config().Accounting.SyncInterval
+	if a.log != nil {
+		a.log.Info("accounting sync started", zap.Duration("interval", interval))
+	}
+	go func() {
+		a.syncAccounting(ctx)
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.syncAccounting(ctx)
+			}
+		}
+	}()
+}
+
+// syncAccounting resumes the journal from its stored cursors. The first run
+// against a fresh store backfills from accounting.backfill_start.
+func (a *App) syncAccounting(ctx context.Context) {
+	backfill, err := a.accountingBackfill()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	start, err := a.config().Accounting.BackfillStartTime(now)
+	if err != nil {
+		return
+	}
+	res, err := backfill.Resume(ctx, start, now)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		if !a.accountingWarned {
+			a.accountingWarned = true
+			if a.log != nil {
+				a.log.Warn("accounting sync failed", zap.Error(err))
+			}
+		}
+		return
+	}
+	if a.accountingWarned {
+		a.accountingWarned = false
+		if a.log != nil {
+			a.log.Info("accounting sync recovered")
+		}
+	}
+	if res.FillsInserted > 0 || res.FundingInserted > 0 {
+		a.logAccountingResult("accounting sync", res)
+	}
+}
+
+func (a *App) logAccountingResult(msg string, res accounting.Result) {
+	if a.log == nil {
+		return
+	}
+	a.log.Info(msg,
+		zap.Int("fills_inserted", res.FillsInserted),
+		zap.Int("fills_duplicate", res.FillsDuplicate),
+		zap.Int("funding_inserted", res.FundingInserted),
+		zap.Int("funding_duplicate", res.FundingDuplicate),
+		zap.Int("pages", res.Pages),
+	)
+}
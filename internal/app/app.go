@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/binary"
 	"encoding/hex"
@@ -10,26 +11,34 @@ import (
 	"math"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"hl-carry-bot/internal/account"
 	"hl-carry-bot/internal/alerts"
 	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/events"
 	"hl-carry-bot/internal/exec"
 	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/precision"
 	"hl-carry-bot/internal/hl/rest"
 	"hl-carry-bot/internal/hl/ws"
+	"hl-carry-bot/internal/keystore"
+	"hl-carry-bot/internal/logging"
 	"hl-carry-bot/internal/market"
 	"hl-carry-bot/internal/metrics"
 	persist "hl-carry-bot/internal/state"
-	"hl-carry-bot/internal/state/sqlite"
+	"hl-carry-bot/internal/state/backend"
 	"hl-carry-bot/internal/strategy"
 	"hl-carry-bot/internal/timescale"
+	"hl-carry-bot/internal/tracing"
 
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -50,22 +59,94 @@ type App struct {
 	timescale     *timescale.Writer
 	alerts        *alerts.Telegram
 	strategy      *strategy.StateMachine
-
-	snapshotPersistWarned   bool
-	spotRefreshWarned       bool
-	killSwitchActive        bool
-	fundingOKCount          int
-	fundingBadCount         int
-	fundingForecastWarned   bool
-	fundingReceiptWarned    bool
-	entryCooldownUntil      time.Time
-	hedgeCooldownUntil      time.Time
-	lastFundingReceiptCheck time.Time
-	lastFundingReceiptAt    time.Time
-	operatorWarned          bool
-	opsMu                   sync.RWMutex
-	paused                  bool
-	riskOverride            *config.RiskConfig
+	shadow        *shadowEvaluator
+	interference  *interferenceWatch
+	events        *events.Bus
+
+	accountAddress  string
+	keyMu           sync.Mutex
+	activeSigner    *exchange.Signer
+	secondarySigner *exchange.Signer
+	// signerAgents caches whether a signing address is an API agent.
+	signerAgents map[common.Address]bool
+
+	// strategySnap is the market snapshot the tick is acting on; the
+	// transition hook persists it with each new state.
+	strategySnap strategy.MarketSnapshot
+
+	rt runtimeState
+
+	// perpLeverageSet is the leverage last applied to the perp asset.
+	perpLeverageSet perpLeverage
+	// perpLeverageRejected is the last setting refused, not resent until the
+	// wanted setting or the perp position changes.
+	perpLeverageRejected *leverageRejection
+
+	snapshotPersistWarned bool
+	spotRefreshWarned     bool
+	scheduleCancelWarned  bool
+	perpLeverageWarned    bool
+	isolatedTopUpWarned   bool
+	liquidationAlerted    bool
+	// fundingReportedFor is the funding time the last funding report
+	// covered.
+	fundingReportedFor        time.Time
+	accountingWarned          bool
+	fundingForecastWarned     bool
+	fundingHistoryWarned      bool
+	fundingHistoryAttempt     time.Time
+	fundingReceiptWarned      bool
+	valuationDivergenceWarned bool
+	compoundStoreWarned       bool
+	vaultStoreWarned          bool
+	vaultParkWarned           bool
+	transferStoreWarned       bool
+	decisionStoreWarned       bool
+	decisionsPrunedAt         time.Time
+	lastDustSweep             time.Time
+	lastJanitorRun            time.Time
+	lastUSDCTransfer          time.Time
+	feesAttempt               time.Time
+	feesWarned                bool
+	crashStop                 *crashStop
+	basisStoreWarned          bool
+	riskReducedWarned         bool
+	positionBase              *strategy.DailyPnLBaseline
+	positionStoreWarned       bool
+	bookWarned                bool
+	crashStopWarned           bool
+	dailyFetchedAt            time.Time
+	dailyPnLWarned            bool
+	dailyStoreWarned          bool
+	lossHalt                  *lossHaltRecord
+	lastFundingReceiptCheck   time.Time
+	operatorWarned            bool
+	opsMu                     sync.RWMutex
+	paused                    bool
+	riskOverride              *config.RiskConfig
+	strategyOverride          *strategyParams
+	confirmPending            *pendingConfirmation
+	flattenRequested          bool
+	reduceRequested           float64
+	tunedIOCBps               float64
+	hasTunedIOC               bool
+	capitalChanged            bool
+	notionalCapUSD            float64
+	equityShare               float64
+	wake                      chan struct{}
+	pendingConfig             *config.Config
+	reloadedConfig            atomic.Pointer[config.Config]
+	nextTickAt                time.Time
+	runStartedAt              time.Time
+	lastTickAt                time.Time
+	startupComplete           bool
+	standalone                bool
+	heartbeat                 func(time.Time)
+	lastTickKey               string
+	tickRepeats               int
+	clockSyncAttempt          time.Time
+	clockSyncWarned           bool
+	clockDriftWarned          bool
 }
 
 const (
@@ -75,73 +156,159 @@ const (
 	fundingReceiptCheckInterval  = 30 * time.Second
 	fundingReceiptLookback       = 6 * time.Hour
 	fundingReceiptLookbackBuffer = 1 * time.Minute
+	fundingHistoryRefresh        = 15 * time.Minute
 )
 
+// credentials are the keys and addresses one App trades with. PrivateKey is
+// the decrypted signing key, held in memory only, and nil in read_only.
+type credentials struct {
+	WalletAddress  string
+	PrivateKey     *ecdsa.PrivateKey
+	AccountAddress string
+	VaultAddress   string
+	// Secondary is where the standby key for /key rotate is read from.
+	Secondary keystore.Source
+}
+
+// envCredentials reads the single-account credentials from HL_* variables
+// and the signing key from keys.source; read_only loads no key.
+func envCredentials(cfg *config.Config) (credentials, error) {
+	walletAddress := strings.TrimSpace(os.Getenv("HL_WALLET_ADDRESS"))
+	if walletAddress == "" {
+		return credentials{}, errors.New("HL_WALLET_ADDRESS is required")
+	}
+	var privateKey *ecdsa.PrivateKey
+	if !cfg.ReadOnly {
+		key, err := keystore.Load(context.Background(), keySource(cfg.Keys, "HL_PRIVATE_KEY", cfg.Keys.KeystorePath, walletAddress))
+		if err != nil {
+			return credentials{}, err
+		}
+		privateKey = key
+	}
+	accountAddress := strings.TrimSpace(os.Getenv("HL_ACCOUNT_ADDRESS"))
+	if accountAddress == "" {
+		accountAddress = walletAddress
+	}
+	return credentials{
+		WalletAddress:  walletAddress,
+		PrivateKey:     privateKey,
+		AccountAddress: accountAddress,
+		VaultAddress:   strings.TrimSpace(os.Getenv("HL_VAULT_ADDRESS")),
+		Secondary:      keySource(cfg.Keys, cfg.Keys.SecondaryKeyEnv, cfg.Keys.SecondaryKeystorePath, cfg.Keys.SecondaryKeychainAccount),
+	}, nil
+}
+
+// sinks overrides where an App reports. A nil metrics set means the App
+// builds and serves its own from cfg.Metrics.
+type sinks struct {
+	metrics *metrics.Metrics
+	alerts  *alerts.Telegram
+}
+
 func New(cfg *config.Config, log *zap.Logger) (*App, error) {
-	if err := os.MkdirAll(filepath.Dir(cfg.State.SQLitePath), 0o755); err != nil {
+	creds, err := envCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+	app, err := newApp(cfg, log, creds, sinks{})
+	if err != nil {
 		return nil, err
 	}
-	store, err := sqlite.New(cfg.State.SQLitePath)
+	app.standalone = true
+	if path := cfg.Health.HeartbeatFile; path != "" {
+		app.heartbeat = func(now time.Time) {
+			if err := writeHeartbeat(path, now); err != nil {
+				app.log.Warn("heartbeat write failed", zap.Error(err))
+			}
+		}
+	}
+	return app, nil
+}
+
+func newApp(cfg *config.Config, log *zap.Logger, creds credentials, out sinks) (*App, error) {
+	store, err := backend.Open(cfg)
 	if err != nil {
 		return nil, err
 	}
 	restClient := rest.New(cfg.REST.BaseURL, cfg.REST.Timeout, log)
+	limiter := rest.NewLimiter(cfg.REST.WeightPerMinute, cfg.REST.ReserveWeightValue())
+	restClient.SetLimiter(limiter)
+	restClient.SetRetry(rest.RetryPolicy{MaxAttempts: cfg.REST.InfoMaxAttempts, BaseDelay: cfg.REST.InfoRetryBackoff, MaxDelay: rest.DefaultRetryPolicy().MaxDelay})
 	wsClient := ws.New(cfg.WS.URL, cfg.WS.ReconnectDelay, cfg.WS.PingInterval, log)
 	marketData := market.New(restClient, wsClient, log)
 	marketData.EnableCandle(cfg.Strategy.PerpAsset, cfg.Strategy.CandleInterval, cfg.Strategy.CandleWindow)
-
-	walletAddress := strings.TrimSpace(os.Getenv("HL_WALLET_ADDRESS"))
-	if walletAddress == "" {
-		return nil, errors.New("HL_WALLET_ADDRESS is required")
-	}
-	privateKey := strings.TrimSpace(os.Getenv("HL_PRIVATE_KEY"))
-	if privateKey == "" {
-		return nil, errors.New("HL_PRIVATE_KEY is required")
-	}
-	accountAddress := strings.TrimSpace(os.Getenv("HL_ACCOUNT_ADDRESS"))
-	if accountAddress == "" {
-		accountAddress = walletAddress
+	if cfg.Timescale.Enabled {
+		for _, interval := range cfg.Timescale.CandleIntervals {
+			marketData.EnableCandle(cfg.Strategy.PerpAsset, interval, cfg.Strategy.CandleWindow)
+		}
 	}
-	vaultAddress := strings.TrimSpace(os.Getenv("HL_VAULT_ADDRESS"))
+	marketData.EnableVolatility(cfg.Strategy.VolatilityEstimator, cfg.Strategy.VolatilityEWMALambda, cfg.Strategy.RealizedVolWindow)
+	marketData.EnableTradeFlow(cfg.Strategy.TradeFlowWindow)
+
+	walletAddress := creds.WalletAddress
+	accountAddress := creds.AccountAddress
 	isMainnet := !strings.Contains(strings.ToLower(cfg.REST.BaseURL), "testnet")
-	signer, err := exchange.NewSigner(privateKey, isMainnet)
+	signer, err := signerFor(creds.PrivateKey, isMainnet)
 	if err != nil {
 		return nil, err
 	}
-	if !strings.EqualFold(walletAddress, signer.Address().Hex()) {
+	if creds.PrivateKey != nil && !strings.EqualFold(walletAddress, signer.Address().Hex()) {
 		return nil, fmt.Errorf("wallet address does not match private key: got %s expected %s", walletAddress, signer.Address().Hex())
 	}
-	exClient, err := exchange.NewClient(cfg.REST.BaseURL, cfg.REST.Timeout, signer, vaultAddress)
+	exClient, err := exchange.NewClient(cfg.REST.BaseURL, cfg.REST.Timeout, signer, creds.VaultAddress)
 	if err != nil {
 		return nil, err
 	}
+	var secondarySigner *exchange.Signer
+	if !cfg.ReadOnly {
+		secondarySigner, err = loadSecondarySigner(context.Background(), creds.Secondary, isMainnet)
+		if err != nil {
+			return nil, err
+		}
+	}
+	exClient.SetReadOnly(cfg.ReadOnly)
 	exClient.SetLogger(log)
+	exClient.SetLimiter(limiter)
+	exClient.SetRetry(cfg.REST.ExchangeMaxAttempts, cfg.REST.ExchangeRetryBackoff)
+	exClient.SetAllowedDestinations(allowedDestinations(cfg))
 
 	accountWS := ws.New(cfg.WS.URL, cfg.WS.ReconnectDelay, cfg.WS.PingInterval, log)
 	accountClient := account.New(restClient, accountWS, log, accountAddress)
+	bus := events.New()
+	marketData.SetEventBus(bus)
+	accountClient.SetEventBus(bus)
 	executor := exec.New(&exchangeAdapter{client: exClient, tif: exchange.TifGtc, log: log}, store, log)
 	metricsClient := metrics.NewNoop()
 	var metricsServer *http.Server
+	var mux *http.ServeMux
 	metricsAddr := ""
 	metricsPath := ""
-	if cfg.Metrics.EnabledValue() {
+	if out.metrics != nil {
+		metricsClient = out.metrics
+	} else if cfg.Metrics.EnabledValue() {
 		prom := metrics.NewPrometheus()
 		metricsClient = prom.Metrics
 		metricsAddr = cfg.Metrics.Address
 		metricsPath = cfg.Metrics.Path
-		mux := http.NewServeMux()
+		mux = http.NewServeMux()
 		mux.Handle(metricsPath, prom.Handler())
 		metricsServer = &http.Server{
 			Addr:    metricsAddr,
 			Handler: mux,
 		}
 	}
-	alertsClient := alerts.NewTelegram(cfg.Telegram, log)
+	limiter.SetMetrics(metricsClient.RESTWeightLeft, metricsClient.RESTWeightShed)
+	restClient.SetMetrics(metricsClient.RESTLatency, metricsClient.RESTRetries)
+	exClient.SetNonceMetrics(metricsClient.NonceRejected)
+	alertsClient := out.alerts
+	if alertsClient == nil {
+		alertsClient = alerts.NewTelegram(cfg.Telegram, log)
+	}
 	timescaleWriter, err := timescale.New(cfg.Timescale, log)
 	if err != nil {
 		return nil, err
 	}
-	return &App{
+	app := &App{
 		cfg:           cfg,
 		log:           log,
 		store:         store,
@@ -158,7 +325,45 @@ func New(cfg *config.Config, log *zap.Logger) (*App, error) {
 		timescale:     timescaleWriter,
 		alerts:        alertsClient,
 		strategy:      strategy.NewStateMachine(),
-	}, nil
+		wake:          make(chan struct{}, 1),
+		shadow:        newShadowEvaluator(cfg),
+		events:        bus,
+
+		accountAddress:  accountAddress,
+		secondarySigner: secondarySigner,
+	}
+	if creds.PrivateKey != nil {
+		app.activeSigner = signer
+	}
+	app.strategy.OnTransition(app.observeStateTransition)
+	app.strategy.OnTransition(app.persistTransition)
+	app.strategy.OnTransition(app.publishTransition)
+	if mux != nil {
+		mux.HandleFunc("/healthz", app.handleHealthz)
+		mux.HandleFunc("/readyz", app.handleReadyz)
+		mux.HandleFunc("/api/next", app.handleNextAPI)
+		mux.HandleFunc("/api/simulate", app.handleSimulateAPI)
+		mux.HandleFunc("/api/data-age", app.handleDataAgeAPI)
+		mux.HandleFunc("/api/shadow", app.handleShadowAPI)
+		mux.Handle("/api/metrics-catalog", metrics.CatalogHandler())
+	}
+	if cfg.CircuitBreaker.EnabledValue() {
+		executor.SetCircuitBreaker(exec.CircuitBreaker{
+			MaxFailures: cfg.CircuitBreaker.MaxFailures,
+			Window:      cfg.CircuitBreaker.Window,
+			CoolOff:     cfg.CircuitBreaker.CoolOff,
+			OnOpen:      app.onCircuitOpen,
+		})
+	}
+	executor.SetOrderBudget(cfg.Risk.MaxOpenOrders)
+	executor.SetOrderLookup(cloidLookup{account: accountClient})
+	executor.SetSlippageTracking(app.referenceMid, cfg.Strategy.SlippageWindow)
+	if timescaleWriter != nil {
+		timescaleWriter.SetMetrics(metricsClient.TimescaleWritten, metricsClient.TimescaleDropped, metricsClient.TimescaleLatency)
+		executor.SetOrderObserver(app.recordTimescaleOrder)
+	}
+	accountClient.SetDriftHandler(app.onAccountDrift)
+	return app, nil
 }
 
 func (a *App) Run(ctx context.Context) error {
@@ -166,15 +371,28 @@ func (a *App) Run(ctx context.Context) error {
 	if a.timescale != nil {
 		a.timescale.Start(ctx)
 		defer a.timescale.Close()
+		go a.recordTimescaleFills(ctx)
 	}
+	go a.recordFillSlippage(ctx)
+	go a.watchExternalTransfers(ctx)
+	a.markRunStarted(time.Now())
 	a.startMetricsServer(ctx)
-	if a.exchange != nil && a.store != nil {
+	if a.standalone {
+		go runWatchdog(ctx, a.log, a.startupDone, a.livenessFailures)
+	}
+	if err := a.checkImportedState(ctx, time.Now()); err != nil {
+		return err
+	}
+	if a.readOnly() {
+		a.log.Info("read-only mode: no exchange actions or transfers will be sent")
+	} else if a.exchange != nil && a.store != nil {
 		if err := a.exchange.InitNonceStore(ctx, a.store); err != nil {
 			a.log.Warn("nonce store init failed", zap.Error(err))
 		} else if state, ok := a.exchange.NonceState(); ok {
 			a.log.Info("nonce persistence enabled", zap.String("nonce_key", state.Key), zap.Uint64("nonce_seed", state.Last))
 		}
 	}
+	a.refreshClockSync(ctx, time.Now())
 	if a.log != nil {
 		a.log.Info("startup: reconciling account state")
 	}
@@ -185,6 +403,7 @@ func (a *App) Run(ctx context.Context) error {
 	if err := a.market.RefreshContexts(ctx); err != nil {
 		a.log.Warn("context refresh failed", zap.Error(err))
 	}
+	a.syncPerpLeverage(ctx)
 	restored, ok, err := persist.LoadStrategySnapshot(ctx, a.store)
 	if err != nil {
 		a.log.Warn("strategy snapshot load failed", zap.Error(err))
@@ -210,25 +429,35 @@ func (a *App) Run(ctx context.Context) error {
 		a.cancelOpenOrders(ctx, state.OpenOrders)
 	}
 	a.restoreStrategyState(state, restored, ok)
+	a.loadCompoundAccrual(ctx)
+	a.loadVaultParked(ctx)
+	if err := a.checkCapitalAllocation(ctx, *state); err != nil {
+		return err
+	}
+	a.loadEntryBasis(ctx)
+	a.loadRiskReduced(ctx)
+	a.loadPositionBaseline(ctx)
+	a.loadDailyPnL(ctx)
 	spotMidPrice := restored.SpotMidPrice
 	perpMidPrice := restored.PerpMidPrice
-	if a.cfg != nil {
-		if mid, _, err := a.spotMid(ctx, a.cfg.Strategy.SpotAsset); err == nil && mid > 0 {
+	if a.config() != nil {
+		if mid, _, err := a.spotMid(ctx, a.config().Strategy.SpotAsset); err == nil && mid > 0 {
 			spotMidPrice = mid
 		}
-		if mid, err := a.market.Mid(ctx, a.cfg.Strategy.PerpAsset); err == nil && mid > 0 {
+		if mid, err := a.market.Mid(ctx, a.config().Strategy.PerpAsset); err == nil && mid > 0 {
 			perpMidPrice = mid
 		}
 	}
 	a.persistStrategySnapshot(ctx, strategy.MarketSnapshot{
-		PerpAsset:      a.cfg.Strategy.PerpAsset,
-		SpotAsset:      a.cfg.Strategy.SpotAsset,
+		PerpAsset:      a.config().Strategy.PerpAsset,
+		SpotAsset:      a.config().Strategy.SpotAsset,
 		SpotMidPrice:   spotMidPrice,
 		PerpMidPrice:   perpMidPrice,
-		SpotBalance:    a.spotBalanceForAsset(a.cfg.Strategy.SpotAsset, state.SpotBalances),
-		PerpPosition:   state.PerpPosition[a.cfg.Strategy.PerpAsset],
+		SpotBalance:    a.spotBalanceForAsset(a.config().Strategy.SpotAsset, state.SpotBalances),
+		PerpPosition:   state.PerpPosition[a.config().Strategy.PerpAsset],
 		OpenOrderCount: len(state.OpenOrders),
 	})
+	a.startInterferenceWatch(ctx)
 	if err := a.account.Start(ctx); err != nil {
 		return err
 	}
@@ -236,6 +465,8 @@ func (a *App) Run(ctx context.Context) error {
 		a.log.Info("startup: account ws started")
 	}
 	a.startSpotReconciler(ctx)
+	a.startScheduleCancel(ctx)
+	a.startAccountingSync(ctx)
 	if err := a.market.Start(ctx); err != nil {
 		return err
 	}
@@ -246,15 +477,20 @@ func (a *App) Run(ctx context.Context) error {
 		a.log.Warn("context refresh failed", zap.Error(err))
 	}
 	a.refreshFundingForecast(ctx)
+	a.markStartupComplete()
 	if a.log != nil {
 		a.log.Info("startup: complete")
 	}
 	a.startOperator(ctx)
+	if a.config().EventLoop.Enabled {
+		return a.runEventLoop(ctx)
+	}
 
-	ticker := time.NewTicker(a.cfg.Strategy.EntryInterval)
+	ticker := time.NewTicker(a.config().Strategy.EntryInterval)
 	defer ticker.Stop()
+	a.setNextTick(time.Now().Add(a.config().Strategy.EntryInterval))
 	if a.log != nil {
-		a.log.Info("strategy loop started", zap.Duration("entry_interval", a.cfg.Strategy.EntryInterval))
+		a.log.Info("strategy loop started", zap.Duration("entry_interval", a.config().Strategy.EntryInterval))
 	}
 
 	for {
@@ -262,6 +498,13 @@ func (a *App) Run(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
+			a.setNextTick(time.Now().Add(a.config().Strategy.EntryInterval))
+			if err := a.tick(ctx); err != nil {
+				a.log.Warn("strategy tick failed", zap.Error(err))
+			}
+		case <-a.wake:
+			ticker.Reset(a.config().Strategy.EntryInterval)
+			a.setNextTick(time.Now().Add(a.config().Strategy.EntryInterval))
 			if err := a.tick(ctx); err != nil {
 				a.log.Warn("strategy tick failed", zap.Error(err))
 			}
@@ -269,253 +512,267 @@ func (a *App) Run(ctx context.Context) error {
 	}
 }
 
-func (a *App) tick(ctx context.Context) error {
+func (a *App) tick(ctx context.Context) (err error) {
+	ctx, cancel := a.tickContext(ctx)
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "tick")
+	defer func() {
+		err = a.checkTickTimeout(ctx, err)
+		if err == nil {
+			a.markTickSucceeded(time.Now())
+		}
+		tracing.End(span, err)
+	}()
+	a.markTickStarted(time.Now())
+	a.observeStateAge(time.Now())
+	a.applyPendingConfig()
+	a.syncPerpLeverage(ctx)
+	a.flushAlerts(ctx)
 	if err := a.market.RefreshContexts(ctx); err != nil {
 		a.log.Warn("context refresh failed", zap.Error(err))
 	}
 	a.refreshFundingForecast(ctx)
-	perpAsset := a.cfg.Strategy.PerpAsset
-	spotAsset := a.cfg.Strategy.SpotAsset
-	spotMid, spotCtx, err := a.spotMid(ctx, spotAsset)
+	a.refreshFundingHistory(ctx)
+	a.refreshFees(ctx, time.Now())
+	a.refreshClockSync(ctx, time.Now())
+	a.refreshPricingBooks(ctx)
+	a.maybeRunJanitor(ctx, time.Now().UTC())
+	a.reevaluateCapital(ctx)
+	in, err := a.collectTickInputsTraced(ctx)
 	if err != nil {
 		return err
 	}
-	perpMid, _ := a.market.Mid(ctx, perpAsset)
-	oraclePrice, _ := a.market.OraclePrice(perpAsset)
-	funding, _ := a.market.FundingRate(perpAsset)
-	vol, _ := a.market.Volatility(perpAsset)
-
-	accountSnap := a.account.Snapshot()
-	spotBalance := 0.0
-	if spotCtx.Base != "" {
-		spotBalance = accountSnap.SpotBalances[spotCtx.Base]
-	} else {
-		spotBalance = accountSnap.SpotBalances[spotAsset]
-	}
-	perpPosition := accountSnap.PerpPosition[perpAsset]
-
-	snap := strategy.MarketSnapshot{
-		PerpAsset:      perpAsset,
-		SpotAsset:      spotAsset,
-		SpotMidPrice:   spotMid,
-		PerpMidPrice:   perpMid,
-		OraclePrice:    oraclePrice,
-		FundingRate:    funding,
-		Volatility:     vol,
-		NotionalUSD:    a.cfg.Strategy.NotionalUSD,
-		SpotBalance:    spotBalance,
-		PerpPosition:   perpPosition,
-		OpenOrderCount: len(accountSnap.OpenOrders),
-	}
-	if accountSnap.HasMarginSummary {
-		snap.MarginRatio = accountSnap.MarginSummary.MarginRatio
-		snap.HealthRatio = accountSnap.MarginSummary.HealthRatio
-		snap.HasMarginRatio = accountSnap.MarginSummary.HasMarginRatio
-		snap.HasHealthRatio = accountSnap.MarginSummary.HasHealthRatio
+	a.strategySnap = in.Snap
+	a.syncOrderBudget(in)
+	a.observeSlippage()
+	if a.takeFlattenRequest() {
+		return a.forceFlatten(ctx, in)
 	}
-	defer a.persistStrategySnapshot(ctx, snap)
-	flatStrict := isFlat(spotBalance, perpPosition)
-	flat := a.isExposureFlat(spotBalance, perpPosition, spotMid, perpMid)
-	spotExposureUSD := math.Abs(spotBalance) * spotMid
-	perpExposureUSD := math.Abs(perpPosition) * perpMid
-	priceRef := snap.OraclePrice
-	if priceRef == 0 {
-		priceRef = snap.PerpMidPrice
-	}
-	if priceRef == 0 {
-		priceRef = snap.SpotMidPrice
-	}
-	deltaUSD := (spotBalance + perpPosition) * priceRef
-	marketAge := time.Duration(0)
-	if a.market != nil {
-		marketAge = time.Since(a.market.LastMidUpdate())
-	}
-	accountAge := time.Duration(0)
-	if a.account != nil {
-		accountAge = time.Since(a.account.LastUpdate())
-	}
-	now := time.Now().UTC()
-	entryCooldownActive := a.entryCooldownActive(now)
-	hedgeCooldownActive := a.hedgeCooldownActive(now)
-	paused := a.isPaused()
-	forecast, hasForecast := a.market.FundingForecast(perpAsset)
-	forecastAge := time.Duration(0)
-	if hasForecast && !forecast.ObservedAt.IsZero() {
-		forecastAge = time.Since(forecast.ObservedAt)
-	}
-	minExpectedFunding := snap.NotionalUSD * a.cfg.Strategy.MinFundingRate
-	expectedFunding := strategy.FundingPaymentEstimateUSD(snap)
-	netCarryUSD, estimatedCostUSD := strategy.NetExpectedCarryUSD(snap, a.cfg.Strategy.FeeBps, a.cfg.Strategy.SlippageBps)
-	carryBufferUSD := a.cfg.Strategy.CarryBufferUSD
-	fundingRateOK := funding >= a.cfg.Strategy.MinFundingRate
-	netCarryOK := netCarryUSD >= carryBufferUSD
-	_, fundingOKConfirmed, fundingBadConfirmed := a.updateFundingRegime(funding, a.cfg.Strategy.MinFundingRate, netCarryUSD, carryBufferUSD)
-	state := a.strategy.State
-	logTick := func(decision string, extra ...zap.Field) {
-		if a.log == nil {
-			return
-		}
-		fields := []zap.Field{
-			zap.String("state", string(state)),
-			zap.String("decision", decision),
-			zap.Bool("flat", flat),
-			zap.Bool("flat_strict", flatStrict),
-			zap.Int("open_orders", snap.OpenOrderCount),
-			zap.Float64("spot_balance", spotBalance),
-			zap.Float64("perp_position", perpPosition),
-			zap.Float64("spot_mid", spotMid),
-			zap.Float64("perp_mid", perpMid),
-			zap.Float64("spot_exposure_usd", spotExposureUSD),
-			zap.Float64("perp_exposure_usd", perpExposureUSD),
-			zap.Float64("delta_usd", deltaUSD),
-			zap.Float64("delta_band_usd", a.cfg.Strategy.DeltaBandUSD),
-			zap.Float64("funding_rate", funding),
-			zap.Float64("expected_funding_usd", expectedFunding),
-			zap.Float64("min_expected_funding_usd", minExpectedFunding),
-			zap.Float64("estimated_cost_usd", estimatedCostUSD),
-			zap.Float64("net_expected_carry_usd", netCarryUSD),
-			zap.Float64("carry_buffer_usd", carryBufferUSD),
-			zap.Float64("fee_bps", a.cfg.Strategy.FeeBps),
-			zap.Float64("slippage_bps", a.cfg.Strategy.SlippageBps),
-			zap.Bool("funding_rate_ok", fundingRateOK),
-			zap.Bool("net_carry_ok", netCarryOK),
-			zap.Int("funding_ok_count", a.fundingOKCount),
-			zap.Int("funding_bad_count", a.fundingBadCount),
-			zap.Int("funding_confirmations", a.cfg.Strategy.FundingConfirmations),
-			zap.Int("funding_dip_confirmations", a.cfg.Strategy.FundingDipConfirmations),
-			zap.Float64("volatility", vol),
-			zap.Float64("max_volatility", a.cfg.Strategy.MaxVolatility),
-			zap.Float64("min_exposure_usd", a.cfg.Strategy.MinExposureUSD),
-			zap.Float64("margin_ratio", snap.MarginRatio),
-			zap.Float64("health_ratio", snap.HealthRatio),
-			zap.Bool("has_margin_ratio", snap.HasMarginRatio),
-			zap.Bool("has_health_ratio", snap.HasHealthRatio),
-			zap.Bool("has_funding_forecast", hasForecast),
-			zap.Float64("predicted_funding_rate", forecast.Rate),
-			zap.Time("next_funding_at", forecast.NextFunding),
-			zap.String("predicted_funding_source", forecast.Source),
-			zap.Time("predicted_funding_observed_at", forecast.ObservedAt),
-			zap.Duration("predicted_funding_age", forecastAge),
-			zap.Duration("market_age", marketAge),
-			zap.Duration("account_age", accountAge),
-			zap.Bool("entry_cooldown_active", entryCooldownActive),
-			zap.Bool("hedge_cooldown_active", hedgeCooldownActive),
-			zap.Bool("paused", paused),
-		}
-		fields = append(fields, extra...)
-		a.log.Debug("tick", fields...)
-	}
-	if (state == strategy.StateEnter || state == strategy.StateExit) && snap.OpenOrderCount == 0 {
-		if flat {
-			a.resetToIdle()
-		} else {
-			a.strategy.Apply(strategy.EventHedgeOK)
+	if pct, ok := a.takeReduceRequest(); ok {
+		if ran, err := a.runReduceRequest(ctx, in, pct); ran {
+			return err
 		}
-		state = a.strategy.State
 	}
-	if state == strategy.StateHedgeOK && flat {
-		if !entryCooldownActive {
-			a.resetToIdle()
-			state = a.strategy.State
+	plan := a.decide(ctx, in)
+	a.observeFundingAPR(in.Snap)
+	a.setFundingCounts(plan.FundingOKCount, plan.FundingBadCount)
+	a.observeShadow(in)
+	a.observeRisk(plan.Risk)
+	a.alertLiquidationDistance(ctx, in, plan.Risk)
+	if plan.Risk.Action < strategy.RiskActionReduce {
+		a.setRiskReduced(ctx, false)
+	}
+	a.observeDailyPnL(ctx, in, plan.Risk)
+	a.refreshDailyPnL(ctx, in)
+	a.reportFundingProjection(ctx, in)
+	a.observeBasis(ctx, in.Now, in.Snap, plan.State == strategy.StateHedgeOK && !in.Flat)
+	a.observePositionBaseline(ctx, in.Now, in.Snap, plan.State == strategy.StateHedgeOK && !in.Flat)
+	a.checkValuationDivergence(ctx, in)
+	snap := in.Snap
+	defer a.persistStrategySnapshot(ctx, snap)
+	if plan.State != plan.StateBefore {
+		if plan.State == strategy.StateIdle {
+			a.applyEvent(strategy.EventFlat)
+		} else {
+			a.applyEvent(strategy.EventHedgeOK)
 		}
 	}
-	a.recordTimescale(state, snap, spotExposureUSD, perpExposureUSD, deltaUSD)
-	if err := a.checkConnectivity(ctx, a.riskConfig(), accountSnap.OpenOrders, marketAge, accountAge); err != nil {
-		logTick("skip_connectivity", zap.Error(err))
+	a.recordTimescale(plan.State, snap, in.SpotExposureUSD, in.PerpExposureUSD, in.DeltaUSD)
+	if err := a.checkConnectivity(ctx, in.Risk, in.OpenOrders, in.Ages); err != nil {
+		a.countSkippedTick(metrics.SkipConnectivity)
+		a.traceTick(ctx, in, plan, "skip_connectivity", err)
 		return nil
 	}
-	if state == strategy.StateIdle {
-		if !flat || snap.OpenOrderCount > 0 {
-			logTick("skip_idle_not_ready")
-			return nil
-		}
+	if reason, ok := tickSkipReason(plan.Decision); ok {
+		a.countSkippedTick(reason)
 	}
-	if err := strategy.CheckRisk(a.riskConfig(), snap); err != nil {
-		a.log.Warn("risk check failed", zap.Error(err))
-		logTick("skip_risk", zap.Error(err))
+	if plan.Decision == "skip_risk" && a.log != nil {
+		a.log.Warn("risk halt", zap.Error(plan.Err))
+	}
+	a.traceTick(ctx, in, plan, plan.Decision, plan.Err)
+	if a.readOnly() {
+		a.observeReadOnlyTick(ctx, in, plan)
 		return nil
 	}
-
-	switch state {
-	case strategy.StateIdle:
-		if paused {
-			logTick("paused")
-			return nil
+	switch plan.Action {
+	case tickActionEnter:
+		if a.log != nil {
+			a.log.Info("enter signal", logging.Unsampled(),
+				zap.Float64("expected_funding_usd", in.ExpectedFunding),
+				zap.Float64("min_expected_funding_usd", in.MinExpectedFunding),
+				zap.Float64("net_expected_carry_usd", in.NetCarryUSD),
+				zap.Float64("carry_buffer_usd", a.strategyConfig().CarryBufferUSD),
+				zap.Float64("estimated_cost_usd", in.EstimatedCostUSD),
+				zap.Float64("volatility", snap.Volatility),
+				zap.Float64("max_volatility", a.strategyConfig().MaxVolatility),
+			)
 		}
-		enterSignal := fundingOKConfirmed && vol <= a.cfg.Strategy.MaxVolatility
-		if enterSignal && entryCooldownActive {
-			logTick("skip_entry_cooldown", zap.Bool("enter_signal", enterSignal), zap.Bool("funding_confirmed", fundingOKConfirmed))
+		if !a.haveTradeBudget(ctx, "entry") {
 			return nil
 		}
-		logTick("idle", zap.Bool("enter_signal", enterSignal), zap.Bool("funding_confirmed", fundingOKConfirmed))
-		if enterSignal {
-			if a.log != nil {
-				a.log.Info("enter signal",
-					zap.Float64("expected_funding_usd", expectedFunding),
-					zap.Float64("min_expected_funding_usd", minExpectedFunding),
-					zap.Float64("net_expected_carry_usd", netCarryUSD),
-					zap.Float64("carry_buffer_usd", carryBufferUSD),
-					zap.Float64("estimated_cost_usd", estimatedCostUSD),
-					zap.Float64("volatility", vol),
-					zap.Float64("max_volatility", a.cfg.Strategy.MaxVolatility),
-				)
-			}
-			return a.enterPosition(ctx, snap)
+		return a.enterPosition(ctx, snap)
+	case tickActionExit:
+		if a.log != nil {
+			a.log.Info("exit signal", logging.Unsampled(),
+				zap.Float64("expected_funding_usd", in.ExpectedFunding),
+				zap.Float64("min_expected_funding_usd", in.MinExpectedFunding),
+				zap.Float64("net_expected_carry_usd", in.NetCarryUSD),
+				zap.Float64("carry_buffer_usd", a.strategyConfig().CarryBufferUSD),
+				zap.Float64("estimated_cost_usd", in.EstimatedCostUSD),
+				zap.Float64("basis_adverse_bps", strategy.BasisAdverseBps(in.EntryBasis, in.Basis)),
+				zap.Error(plan.Err),
+			)
 		}
-	case strategy.StateHedgeOK:
-		if paused {
-			logTick("paused")
+		if !a.haveTradeBudget(ctx, "exit") {
 			return nil
 		}
-		exitSignal := a.cfg.Strategy.ExitOnFundingDip && fundingBadConfirmed
-		exitGuarded := false
-		timeToFunding := time.Duration(0)
-		if exitSignal {
-			exitGuarded, timeToFunding = a.shouldDeferExitForFunding(time.Now().UTC(), forecast, hasForecast, funding)
-		}
-		decision := "hedge_ok"
-		if exitSignal {
-			if exitGuarded {
-				decision = "exit_guarded"
-			} else {
-				decision = "exit_signal"
-			}
-		}
-		logTick(decision,
-			zap.Bool("exit_signal", exitSignal),
-			zap.Bool("exit_on_funding_dip", a.cfg.Strategy.ExitOnFundingDip),
-			zap.Bool("funding_bad_confirmed", fundingBadConfirmed),
-			zap.Bool("exit_guarded", exitGuarded),
-			zap.Bool("exit_funding_guard_enabled", a.exitFundingGuardEnabled()),
-			zap.Duration("exit_funding_guard", a.cfg.Strategy.ExitFundingGuard),
-			zap.Duration("time_to_funding", timeToFunding),
-		)
-		if exitSignal && !exitGuarded {
-			if a.log != nil {
-				a.log.Info("exit signal",
-					zap.Float64("expected_funding_usd", expectedFunding),
-					zap.Float64("min_expected_funding_usd", minExpectedFunding),
-					zap.Float64("net_expected_carry_usd", netCarryUSD),
-					zap.Float64("carry_buffer_usd", carryBufferUSD),
-					zap.Float64("estimated_cost_usd", estimatedCostUSD),
-				)
-			}
-			return a.exitPosition(ctx, snap)
+		return a.exitPosition(ctx, snap)
+	case tickActionCompound:
+		if !a.haveTradeBudget(ctx, "compound") {
+			return nil
 		}
-		a.maybeLogFundingReceipt(ctx, now, snap, forecast, hasForecast)
-		if hedgeCooldownActive {
+		return a.compoundPosition(ctx, snap)
+	case tickActionReduce:
+		if !a.haveTradeBudget(ctx, "reduce") {
 			return nil
 		}
-		if err := a.rebalanceDelta(ctx, snap); err != nil {
-			a.log.Warn("delta hedge failed", zap.Error(err))
-			logTick("hedge_failed", zap.Error(err))
+		return a.runRiskReduce(ctx, snap, riskReducePct(in.Risk), "risk: "+strings.Join(plan.Risk.Rules(), ","))
+	}
+	if plan.State == strategy.StateIdle && plan.Decision == "idle" && in.Flat {
+		a.cancelCrashStop(ctx)
+		a.clearEntryBasis(ctx)
+		a.clearPositionBaseline(ctx)
+		a.maybeSweepDust(ctx, in.Now, false)
+		a.maybeParkIdleUSDC(ctx, snap, plan.FundingRateOK)
+	}
+	if !plan.Steady {
+		return nil
+	}
+	a.ensureCrashStop(ctx, snap.PerpAsset, snap.PerpPosition, snap.PerpMidPrice)
+	a.maybeTopUpIsolatedMargin(ctx, snap.PerpAsset)
+	a.maybeLogFundingReceipt(ctx, in.Now, snap, in.Forecast, in.HasForecast)
+	if in.HedgeCooldownActive {
+		return nil
+	}
+	if err := a.rebalanceDelta(ctx, snap); err != nil {
+		if a.skipOnOrderBudget("delta_hedge", err) {
+			return nil
 		}
-	default:
-		logTick("hold")
+		a.noteTradeOutcome(err)
+		a.log.Warn("delta hedge failed", logging.Unsampled(), zap.Error(err))
+		a.traceTick(ctx, in, plan, "hedge_failed", err)
 	}
 	return nil
 }
 
+// flushAlerts sends the summaries of alerts held back by deduplication or
+// dropped by the alert budget.
+func (a *App) flushAlerts(ctx context.Context) {
+	if a.alerts == nil {
+		return
+	}
+	if err := a.alerts.Flush(ctx); err != nil && a.log != nil {
+		a.log.Warn("alert summary send failed", zap.Error(err))
+	}
+}
+
+func (a *App) logTick(in tickInputs, plan tickPlan, decision string, extra ...zap.Field) {
+	cfg := a.config()
+	if a.log == nil {
+		return
+	}
+	snap := in.Snap
+	fields := []zap.Field{
+		zap.String("state", string(plan.State)),
+		zap.String("decision", decision),
+		zap.String("action", plan.Action),
+		zap.Bool("flat", in.Flat),
+		zap.Bool("flat_strict", in.FlatStrict),
+		zap.Int("open_orders", snap.OpenOrderCount),
+		zap.Float64("spot_balance", snap.SpotBalance),
+		zap.Float64("perp_position", snap.PerpPosition),
+		zap.Float64("spot_mid", snap.SpotMidPrice),
+		zap.Float64("perp_mid", snap.PerpMidPrice),
+		zap.Float64("spot_exposure_usd", in.SpotExposureUSD),
+		zap.Float64("perp_exposure_usd", in.PerpExposureUSD),
+		zap.Float64("delta_usd", in.DeltaUSD),
+		zap.Float64("delta_band_usd", a.strategyConfig().DeltaBandUSD),
+		zap.Float64("funding_rate", snap.FundingRate),
+		zap.Float64("expected_funding_usd", in.ExpectedFunding),
+		zap.Float64("min_expected_funding_usd", in.MinExpectedFunding),
+		zap.Float64("estimated_cost_usd", in.EstimatedCostUSD),
+		zap.Float64("net_expected_carry_usd", in.NetCarryUSD),
+		zap.Float64("carry_buffer_usd", a.strategyConfig().CarryBufferUSD),
+		zap.Float64("fee_bps", a.feeBps()),
+		zap.Float64("slippage_bps", cfg.Strategy.SlippageBps),
+		zap.Float64("funding_apr", snap.FundingAPR()),
+		zap.Float64("min_funding_apr", a.strategyConfig().MinFundingAPR),
+		zap.Duration("funding_interval", snap.FundingInterval),
+		zap.Bool("funding_rate_ok", plan.FundingRateOK),
+		zap.Bool("net_carry_ok", plan.NetCarryOK),
+		zap.Int("funding_ok_count", plan.FundingOKCount),
+		zap.Int("funding_bad_count", plan.FundingBadCount),
+		zap.Int("funding_confirmations", cfg.Strategy.FundingConfirmations),
+		zap.Int("funding_dip_confirmations", cfg.Strategy.FundingDipConfirmations),
+		zap.Bool("funding_confirmed", plan.FundingOKConfirmed),
+		zap.Bool("funding_bad_confirmed", plan.FundingBadConfirmed),
+		zap.Bool("enter_signal", plan.EnterSignal),
+		zap.Bool("exit_signal", plan.ExitSignal),
+		zap.Bool("exit_on_funding_dip", cfg.Strategy.ExitOnFundingDip),
+		zap.Bool("exit_guarded", plan.ExitGuarded),
+		zap.Bool("exit_funding_guard_enabled", a.exitFundingGuardEnabled()),
+		zap.Duration("exit_funding_guard", cfg.Strategy.ExitFundingGuard),
+		zap.Duration("entry_funding_guard", cfg.Strategy.EntryFundingGuard),
+		zap.Duration("time_to_funding", plan.TimeToFunding),
+		zap.Float64("volatility", snap.Volatility),
+		zap.Float64("max_volatility", a.strategyConfig().MaxVolatility),
+		zap.Float64("basis", in.Basis),
+		zap.Float64("entry_basis", in.EntryBasis),
+		zap.Bool("has_entry_basis", in.HasEntryBasis),
+		zap.Float64("exit_basis_bps", cfg.Strategy.ExitBasisBps),
+		zap.Float64("trade_imbalance", snap.TradeImbalance),
+		zap.Bool("has_trade_imbalance", snap.HasTradeImbalance),
+		zap.Float64("book_imbalance", snap.BookImbalance),
+		zap.Bool("has_book_imbalance", snap.HasBookImbalance),
+		zap.Float64("min_exposure_usd", cfg.Strategy.MinExposureUSD),
+		zap.Float64("margin_ratio", snap.MarginRatio),
+		zap.Float64("health_ratio", snap.HealthRatio),
+		zap.String("risk_action", plan.Risk.Action.String()),
+		zap.Strings("risk_rules", plan.Risk.Rules()),
+		zap.Float64("daily_pnl_usd", in.DailyPnL.TotalUSD()),
+		zap.Int("consecutive_failures", in.ConsecutiveFailures),
+		zap.Bool("circuit_open", in.CircuitOpen),
+		zap.Bool("has_margin_ratio", snap.HasMarginRatio),
+		zap.Bool("has_health_ratio", snap.HasHealthRatio),
+		zap.Bool("has_funding_forecast", in.HasForecast),
+		zap.Float64("predicted_funding_rate", in.Forecast.Rate),
+		zap.Time("next_funding_at", in.Forecast.NextFunding),
+		zap.String("predicted_funding_source", in.Forecast.Source),
+		zap.Time("predicted_funding_observed_at", in.Forecast.ObservedAt),
+		zap.Duration("predicted_funding_age", in.ForecastAge),
+		zap.Duration("market_age", in.Ages.Market()),
+		zap.Duration("perp_mid_age", in.Ages.PerpMid),
+		zap.Duration("spot_mid_age", in.Ages.SpotMid),
+		zap.Duration("account_age", in.Ages.Account),
+		zap.Bool("entry_cooldown_active", in.EntryCooldownActive),
+		zap.Bool("hedge_cooldown_active", in.HedgeCooldownActive),
+		zap.Bool("paused", in.Paused),
+	}
+	fields = append(fields, extra...)
+	// Full detail is always kept when the outcome changes; repeats of the
+	// same outcome carry a counter and are subject to log.sampling.debug.
+	key := string(plan.State) + "|" + decision + "|" + plan.Action
+	if key != a.lastTickKey {
+		a.lastTickKey = key
+		a.tickRepeats = 0
+		fields = append(fields, logging.Unsampled())
+	} else {
+		a.tickRepeats++
+		fields = append(fields, zap.Int("unchanged_ticks", a.tickRepeats))
+	}
+	a.log.Debug("tick", fields...)
+}
+
 func (a *App) refreshSpotBalancesWS(ctx context.Context) {
 	if a.account == nil {
 		return
@@ -554,6 +811,14 @@ func (a *App) reconcileAccount(ctx context.Context, reason string) {
 	}
 }
 
+// onAccountDrift counts reconciles that found the WS account state diverged;
+// the account logs the details.
+func (a *App) onAccountDrift(account.Drift) {
+	if a.metrics != nil && a.metrics.AccountDrift != nil {
+		a.metrics.AccountDrift.Inc()
+	}
+}
+
 func (a *App) startMetricsServer(ctx context.Context) {
 	if a.metricsServer == nil {
 		return
@@ -579,10 +844,10 @@ func (a *App) startMetricsServer(ctx context.Context) {
 }
 
 func (a *App) startSpotReconciler(ctx context.Context) {
-	if a.cfg == nil {
+	if a.config() == nil {
 		return
 	}
-	interval := a.cfg.Strategy.SpotReconcileInterval
+	interval := a.config().Strategy.SpotReconcileInterval
 	if interval <= 0 {
 		return
 	}
@@ -604,43 +869,191 @@ func (a *App) startSpotReconciler(ctx context.Context) {
 	}()
 }
 
-func (a *App) checkConnectivity(ctx context.Context, risk config.RiskConfig, openOrders []map[string]any, marketAge, accountAge time.Duration) error {
-	if a.cfg == nil {
+func (a *App) startScheduleCancel(ctx context.Context) {
+	if a.config() == nil || a.exchange == nil || !a.config().ScheduleCancel.Enabled || a.readOnly() {
+		return
+	}
+	interval := a.config().Strategy.EntryInterval
+	if interval <= 0 {
+		return
+	}
+	if a.log != nil {
+		a.log.Info("schedule cancel heartbeat started", zap.Duration("interval", interval), zap.Duration("window", a.config().ScheduleCancel.Window))
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		a.refreshScheduleCancel(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.refreshScheduleCancel(ctx)
+			}
+		}
+	}()
+}
+
+// refreshScheduleCancel pushes the exchange-side cancel-all deadline forward so
+// resting orders are pulled if the bot stops heartbeating.
+func (a *App) refreshScheduleCancel(ctx context.Context) {
+	if a.config() == nil || a.exchange == nil {
+		return
+	}
+	deadline := time.Now().Add(a.config().ScheduleCancel.Window)
+	resp, err := a.exchange.ScheduleCancel(ctx, deadline)
+	if err == nil {
+		err = exchange.ResponseError(resp)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		if !a.scheduleCancelWarned {
+			a.scheduleCancelWarned = true
+			if a.log != nil {
+				a.log.Warn("schedule cancel refresh failed", zap.Error(err))
+			}
+		}
+		return
+	}
+	if a.scheduleCancelWarned {
+		a.scheduleCancelWarned = false
+		if a.log != nil {
+			a.log.Info("schedule cancel refresh recovered", zap.Time("deadline", deadline.UTC()))
+		}
+	}
+}
+
+// setKillSwitch records the kill switch state and reports whether it
+// changed.
+func (a *App) setKillSwitch(active bool) bool {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	if a.rt.killSwitchActive == active {
+		return false
+	}
+	a.rt.killSwitchActive = active
+	if a.metrics != nil && a.metrics.KillSwitchActive != nil {
+		a.metrics.KillSwitchActive.Set(boolGauge(active))
+	}
+	return true
+}
+
+func (a *App) killSwitchEngaged() bool {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.killSwitchActive
+}
+
+func boolGauge(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func (a *App) checkConnectivity(ctx context.Context, risk config.RiskConfig, openOrders []map[string]any, ages strategy.DataAges) error {
+	if a.config() == nil {
 		return nil
 	}
-	err := strategy.CheckConnectivity(risk, marketAge, accountAge)
+	err := strategy.CheckDataAges(risk, ages)
 	if err == nil {
-		if a.killSwitchActive {
-			a.killSwitchActive = false
+		if a.setKillSwitch(false) {
 			if a.metrics != nil {
 				a.metrics.KillSwitchRestored.Inc()
 			}
 			if a.log != nil {
-				a.log.Info("connectivity restored", zap.Duration("market_age", marketAge), zap.Duration("account_age", accountAge))
+				a.log.Info("connectivity restored",
+					zap.Duration("perp_mid_age", ages.PerpMid),
+					zap.Duration("spot_mid_age", ages.SpotMid),
+					zap.Duration("account_age", ages.Account),
+				)
 			}
 		}
 		return nil
 	}
-	if !a.killSwitchActive {
-		a.killSwitchActive = true
+	legs := staleLegs(err)
+	if a.setKillSwitch(true) {
 		if a.metrics != nil {
 			a.metrics.KillSwitchEngaged.Inc()
 		}
 		if a.log != nil {
-			a.log.Warn("connectivity kill switch engaged", zap.Error(err), zap.Duration("market_age", marketAge), zap.Duration("account_age", accountAge))
+			a.log.Warn("connectivity kill switch engaged", zap.Error(err),
+				zap.Strings("stale", legs),
+				zap.Duration("perp_mid_age", ages.PerpMid),
+				zap.Duration("spot_mid_age", ages.SpotMid),
+				zap.Duration("account_age", ages.Account),
+				zap.Bool("market_subs_pending", ages.MarketSubsPending),
+				zap.Bool("account_subs_pending", ages.AccountSubsPending),
+			)
 		}
 		if a.alerts != nil {
-			if alertErr := a.alerts.Send(ctx, fmt.Sprintf("Connectivity kill switch: %v", err)); alertErr != nil && a.log != nil {
+			if alertErr := a.alerts.Send(ctx, fmt.Sprintf("Connectivity kill switch (%s): %v", strings.Join(legs, ", "), err)); alertErr != nil && a.log != nil {
 				a.log.Warn("alert send failed", zap.Error(alertErr))
 			}
 		}
 	}
-	if len(openOrders) > 0 {
-		a.cancelOpenOrders(ctx, openOrders)
+	if orders := a.withoutCrashStop(a.ordersOnBlindLegs(openOrders, err)); len(orders) > 0 {
+		a.cancelOpenOrders(ctx, orders)
 	}
 	return err
 }
 
+func staleLegs(err error) []string {
+	var legs []string
+	if errors.Is(err, strategy.ErrPerpMidStale) {
+		legs = append(legs, "perp_mid")
+	}
+	if errors.Is(err, strategy.ErrSpotMidStale) {
+		legs = append(legs, "spot_mid")
+	}
+	if errors.Is(err, strategy.ErrAccountStale) {
+		legs = append(legs, "account")
+	}
+	return legs
+}
+
+// ordersOnBlindLegs picks the open orders to pull for a connectivity error.
+// A stale mid only blinds its own leg, so orders on the other leg are left
+// resting; stale account data pulls everything.
+func (a *App) ordersOnBlindLegs(openOrders []map[string]any, err error) []map[string]any {
+	perpStale := errors.Is(err, strategy.ErrPerpMidStale)
+	spotStale := errors.Is(err, strategy.ErrSpotMidStale)
+	if errors.Is(err, strategy.ErrAccountStale) || (perpStale && spotStale) {
+		return openOrders
+	}
+	out := make([]map[string]any, 0, len(openOrders))
+	for _, order := range openOrders {
+		spot := a.isSpotOrder(order)
+		if (spot && spotStale) || (!spot && perpStale) {
+			out = append(out, order)
+		}
+	}
+	return out
+}
+
+func (a *App) isSpotOrder(order map[string]any) bool {
+	cfg := a.config()
+	refs := account.OpenOrderRefs([]map[string]any{order})
+	if len(refs) == 0 {
+		return false
+	}
+	ref := refs[0]
+	if ref.AssetID >= 10000 {
+		return true
+	}
+	symbol := ref.AssetSymbol
+	if strings.HasPrefix(symbol, "@") || strings.Contains(symbol, "/") {
+		return true
+	}
+	if cfg != nil && symbol != "" && symbol == cfg.Strategy.SpotAsset && symbol != cfg.Strategy.PerpAsset {
+		return true
+	}
+	return false
+}
+
 func (a *App) logFundingForecastError(err error) {
 	if a.log == nil {
 		return
@@ -656,7 +1069,7 @@ func (a *App) refreshFundingForecast(ctx context.Context) {
 	if a.market == nil {
 		return
 	}
-	updated, err := a.market.RefreshFundingForecast(ctx)
+	updated, err := a.market.RefreshFundingForecast(rest.WithPriority(ctx, rest.PriorityLow))
 	if err != nil {
 		a.logFundingForecastError(err)
 		return
@@ -669,6 +1082,35 @@ func (a *App) refreshFundingForecast(ctx context.Context) {
 	}
 }
 
+// refreshFundingHistory keeps the trailing funding averages current when the
+// trailing funding gate is configured. Settled funding changes hourly, so the
+// fetch is throttled to fundingHistoryRefresh. When fetches keep failing the
+// cached samples age out of the trailing window and the gate reports the
+// average as unavailable.
+func (a *App) refreshFundingHistory(ctx context.Context) {
+	cfg := a.config()
+	if a.market == nil || cfg == nil || cfg.Strategy.MinTrailingFunding == 0 {
+		return
+	}
+	now := time.Now()
+	if !a.fundingHistoryAttempt.IsZero() && now.Sub(a.fundingHistoryAttempt) < fundingHistoryRefresh {
+		return
+	}
+	a.fundingHistoryAttempt = now
+	_, err := a.market.FundingHistory(rest.WithPriority(ctx, rest.PriorityLow), cfg.Strategy.PerpAsset, market.FundingWindow7d)
+	if err != nil {
+		if !a.fundingHistoryWarned && a.log != nil {
+			a.log.Warn("funding history fetch failed", zap.Error(err))
+		}
+		a.fundingHistoryWarned = true
+		return
+	}
+	if a.fundingHistoryWarned && a.log != nil {
+		a.log.Info("funding history fetch recovered")
+	}
+	a.fundingHistoryWarned = false
+}
+
 func (a *App) logFundingReceiptError(err error) {
 	if a.log == nil {
 		return
@@ -693,7 +1135,8 @@ func (a *App) maybeLogFundingReceipt(ctx context.Context, now time.Time, snap st
 	if now.Before(forecast.NextFunding.Add(fundingReceiptGrace)) {
 		return
 	}
-	if !a.lastFundingReceiptAt.IsZero() && !a.lastFundingReceiptAt.Before(forecast.NextFunding) {
+	lastReceipt := a.lastFundingReceipt()
+	if !lastReceipt.IsZero() && !lastReceipt.Before(forecast.NextFunding) {
 		return
 	}
 	if !a.lastFundingReceiptCheck.IsZero() && now.Sub(a.lastFundingReceiptCheck) < fundingReceiptCheckInterval {
@@ -709,13 +1152,13 @@ func (a *App) maybeLogFundingReceipt(ctx context.Context, now time.Time, snap st
 		}
 	}
 	start := now.Add(-lookback)
-	if !a.lastFundingReceiptAt.IsZero() {
-		candidate := a.lastFundingReceiptAt.Add(-fundingReceiptLookbackBuffer)
+	if !lastReceipt.IsZero() {
+		candidate := lastReceipt.Add(-fundingReceiptLookbackBuffer)
 		if candidate.After(start) {
 			start = candidate
 		}
 	}
-	fundingCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	fundingCtx, cancel := context.WithTimeout(rest.WithPriority(ctx, rest.PriorityLow), 3*time.Second)
 	defer cancel()
 	entries, err := a.account.UserFunding(fundingCtx, start.UnixMilli())
 	if err != nil {
@@ -728,11 +1171,12 @@ func (a *App) maybeLogFundingReceipt(ctx context.Context, now time.Time, snap st
 	a.fundingReceiptWarned = false
 
 	var newest time.Time
+	received := 0.0
 	for _, entry := range entries {
 		if entry.Asset == "" || !strings.EqualFold(entry.Asset, snap.PerpAsset) {
 			continue
 		}
-		if entry.HasTime && !a.lastFundingReceiptAt.IsZero() && !entry.Time.After(a.lastFundingReceiptAt) {
+		if entry.HasTime && !lastReceipt.IsZero() && !entry.Time.After(lastReceipt) {
 			continue
 		}
 		if entry.HasTime {
@@ -745,6 +1189,7 @@ func (a *App) maybeLogFundingReceipt(ctx context.Context, now time.Time, snap st
 		}
 		if entry.HasAmount {
 			fields = append(fields, zap.Float64("amount_usdc", entry.Amount))
+			received += entry.Amount
 		}
 		if entry.HasRate {
 			fields = append(fields, zap.Float64("funding_rate", entry.Rate))
@@ -757,43 +1202,63 @@ func (a *App) maybeLogFundingReceipt(ctx context.Context, now time.Time, snap st
 			zap.Float64("oracle_price", snap.OraclePrice),
 		)
 		a.log.Info("funding payment received", fields...)
+		a.events.Publish(events.FundingPaid{Asset: entry.Asset, Amount: entry.Amount, Rate: entry.Rate, Time: entry.Time})
 	}
 	if !newest.IsZero() {
-		a.lastFundingReceiptAt = newest
+		a.setLastFundingReceipt(newest)
 	}
+	a.accrueCompoundFunding(ctx, received)
 }
 
 func (a *App) updateFundingRegime(funding, minRate, netCarryUSD, carryBufferUSD float64) (bool, bool, bool) {
-	if a.cfg == nil {
+	if a.config() == nil {
 		return false, false, false
 	}
-	ok := funding >= minRate && netCarryUSD >= carryBufferUSD
-	if ok {
-		a.fundingOKCount++
-		a.fundingBadCount = 0
+	okCount, badCount, okConfirmed, badConfirmed := a.nextFundingRegime(funding, minRate, netCarryUSD, carryBufferUSD)
+	a.setFundingCounts(okCount, badCount)
+	return funding >= minRate && netCarryUSD >= carryBufferUSD, okConfirmed, badConfirmed
+}
+
+// nextFundingRegime returns the confirmation counters after one more
+// observation without committing them.
+func (a *App) nextFundingRegime(funding, minRate, netCarryUSD, carryBufferUSD float64) (int, int, bool, bool) {
+	cfg := a.config()
+	if cfg == nil {
+		return 0, 0, false, false
+	}
+	okCount, badCount := a.fundingCounts()
+	return fundingRegime(cfg.Strategy, okCount, badCount, funding, minRate, netCarryUSD, carryBufferUSD)
+}
+
+// fundingRegime advances okCount and badCount by one observation and reports
+// whether cfg's entry and dip confirmations are met.
+func fundingRegime(cfg config.StrategyConfig, okCount, badCount int, funding, minRate, netCarryUSD, carryBufferUSD float64) (int, int, bool, bool) {
+	if funding >= minRate && netCarryUSD >= carryBufferUSD {
+		okCount++
+		badCount = 0
 	} else {
-		a.fundingBadCount++
-		a.fundingOKCount = 0
+		badCount++
+		okCount = 0
 	}
-	okNeeded := a.cfg.Strategy.FundingConfirmations
+	okNeeded := cfg.FundingConfirmations
 	if okNeeded < 1 {
 		okNeeded = 1
 	}
-	badNeeded := a.cfg.Strategy.FundingDipConfirmations
+	badNeeded := cfg.FundingDipConfirmations
 	if badNeeded < 1 {
 		badNeeded = 1
 	}
-	return ok, a.fundingOKCount >= okNeeded, a.fundingBadCount >= badNeeded
+	return okCount, badCount, okCount >= okNeeded, badCount >= badNeeded
 }
 
 func (a *App) shouldDeferExitForFunding(now time.Time, forecast market.FundingForecast, hasForecast bool, fundingRate float64) (bool, time.Duration) {
-	if a.cfg == nil {
+	if a.config() == nil {
 		return false, 0
 	}
 	if !a.exitFundingGuardEnabled() {
 		return false, 0
 	}
-	guard := a.cfg.Strategy.ExitFundingGuard
+	guard := a.config().Strategy.ExitFundingGuard
 	if guard <= 0 || !hasForecast || !forecast.HasNext || forecast.NextFunding.IsZero() {
 		return false, 0
 	}
@@ -815,77 +1280,38 @@ func (a *App) shouldDeferExitForFunding(now time.Time, forecast market.FundingFo
 }
 
 func (a *App) exitFundingGuardEnabled() bool {
-	if a.cfg == nil {
+	cfg := a.config()
+	if cfg == nil {
 		return false
 	}
-	if a.cfg.Strategy.ExitFundingGuardEnabled == nil {
+	if cfg.Strategy.ExitFundingGuardEnabled == nil {
 		return true
 	}
-	return *a.cfg.Strategy.ExitFundingGuardEnabled
+	return *cfg.Strategy.ExitFundingGuardEnabled
 }
 
-func (a *App) rebalanceDelta(ctx context.Context, snap strategy.MarketSnapshot) error {
-	if a.cfg == nil || a.executor == nil || a.market == nil {
-		return nil
-	}
-	band := a.cfg.Strategy.DeltaBandUSD
-	if band <= 0 {
-		return nil
-	}
-	if snap.OpenOrderCount > 0 {
-		return nil
-	}
-	priceRef := snap.OraclePrice
-	if priceRef == 0 {
-		priceRef = snap.PerpMidPrice
-	}
-	if priceRef == 0 {
-		priceRef = snap.SpotMidPrice
-	}
-	if priceRef == 0 {
-		return errors.New("delta hedge price reference missing")
-	}
-	deltaBase := snap.SpotBalance + snap.PerpPosition
-	deltaUSD := deltaBase * priceRef
-	if math.Abs(deltaUSD) <= band {
-		return nil
-	}
-	if math.Abs(deltaUSD) < a.cfg.Strategy.MinExposureUSD {
+func (a *App) rebalanceDelta(ctx context.Context, snap strategy.MarketSnapshot) (err error) {
+	ctx, span := tracer.Start(ctx, "delta_hedge")
+	defer func() { tracing.End(span, err) }()
+	if a.config() == nil || a.executor == nil || a.market == nil {
 		return nil
 	}
-	perpCtx, ok := a.market.PerpContext(snap.PerpAsset)
-	if !ok {
-		return fmt.Errorf("perp context not found for %s", snap.PerpAsset)
-	}
-	size := math.Abs(deltaBase)
-	if perpCtx.SzDecimals >= 0 {
-		size = roundDown(size, perpCtx.SzDecimals)
-	}
-	if size <= 0 {
-		return errors.New("delta hedge size rounded to zero")
-	}
-	limit := snap.PerpMidPrice
-	if limit == 0 {
-		limit = snap.SpotMidPrice
-	}
-	isBuy := deltaUSD < 0
-	reduceOnly := (isBuy && snap.PerpPosition < 0) || (!isBuy && snap.PerpPosition > 0)
-	limit = limitPriceWithOffset(limit, isBuy, false, perpCtx.SzDecimals, a.cfg.Strategy.IOCPriceBps)
-	if limit <= 0 {
-		return errors.New("delta hedge limit price invalid")
+	plan, ok, err := a.planRebalance(snap)
+	if err != nil || !ok {
+		return err
 	}
 	cloid, err := newCloid()
 	if err != nil {
 		return err
 	}
 	order := exec.Order{
-		Asset:         perpCtx.Index,
-		IsBuy:         isBuy,
-		Size:          size,
-		LimitPrice:    limit,
-		ReduceOnly:    reduceOnly,
+		Asset:         plan.Order.AssetID,
+		IsBuy:         plan.Order.IsBuy,
+		Size:          plan.Order.Size,
+		LimitPrice:    plan.Order.LimitPrice,
+		ReduceOnly:    plan.Order.ReduceOnly,
 		ClientOrderID: cloid,
-		Tif:           string(exchange.TifIoc),
+		Tif:           plan.Order.Tif,
 	}
 	if _, err := a.executor.PlaceOrder(ctx, order); err != nil {
 		if a.metrics != nil {
@@ -896,32 +1322,54 @@ func (a *App) rebalanceDelta(ctx context.Context, snap strategy.MarketSnapshot)
 	if a.metrics != nil {
 		a.metrics.OrdersPlaced.Inc()
 	}
+	a.noteTradeOutcome(nil)
 	a.startHedgeCooldown(time.Now().UTC())
 	if a.log != nil {
-		a.log.Info("delta hedge order placed",
+		a.log.Info("delta hedge order placed", logging.Unsampled(),
 			zap.String("perp_asset", snap.PerpAsset),
-			zap.Float64("delta_usd", deltaUSD),
-			zap.Float64("band_usd", band),
-			zap.Float64("size", size),
-			zap.Bool("is_buy", isBuy),
-			zap.Bool("reduce_only", reduceOnly),
+			zap.String("leg", plan.Order.Leg),
+			zap.String("leg_reason", plan.LegReason),
+			zap.Float64("delta_usd", plan.DeltaUSD),
+			zap.Float64("band_usd", plan.BandUSD),
+			zap.Float64("target_delta_usd", plan.TargetUSD),
+			zap.Float64("size", plan.Order.Size),
+			zap.Bool("is_buy", plan.Order.IsBuy),
+			zap.Bool("reduce_only", plan.Order.ReduceOnly),
 		)
 	}
 	return nil
 }
 
 func (a *App) enterPosition(ctx context.Context, snap strategy.MarketSnapshot) (err error) {
+	ctx, span := tracer.Start(ctx, "entry", trace.WithAttributes(attribute.String("perp_asset", snap.PerpAsset), attribute.String("spot_asset", snap.SpotAsset)))
+	defer func() { tracing.End(span, err) }()
 	start := time.Now().UTC()
-	spotCloid := ""
-	perpCloid := ""
-	spotLimit := 0.0
-	perpLimit := 0.0
-	spotRollbackLimit := 0.0
-	spotSize := 0.0
-	perpSize := 0.0
-	spotFilled := 0.0
-	perpFilled := 0.0
+	var legs entryLegs
 	defer func() {
+		if errors.Is(err, errTransferCooldown) {
+			a.applyEvent(strategy.EventAbort)
+			a.countSkippedTick(metrics.SkipCooldown)
+			if a.log != nil {
+				a.log.Info("entry deferred until the next USDC transfer", zap.Error(err))
+			}
+			err = nil
+			return
+		}
+		if errors.Is(err, errVaultRecall) {
+			a.applyEvent(strategy.EventAbort)
+			a.countSkippedTick(metrics.SkipVaultRecall)
+			if a.log != nil {
+				a.log.Warn("entry blocked: parked USDC could not be recalled from the vault", zap.Error(err))
+			}
+			err = nil
+			return
+		}
+		if legs.SpotFilled == 0 && (a.skipOnOrderBudget("entry", err) || a.skipOnMarginForecast(err)) {
+			a.applyEvent(strategy.EventAbort)
+			err = nil
+			return
+		}
+		a.noteTradeOutcome(err)
 		if err == nil {
 			return
 		}
@@ -929,137 +1377,166 @@ func (a *App) enterPosition(ctx context.Context, snap strategy.MarketSnapshot) (
 			a.metrics.EntryFailed.Inc()
 		}
 		if a.log != nil {
-			a.log.Warn("enter failed",
+			a.log.Warn("enter failed", logging.Unsampled(),
 				zap.Error(err),
 				zap.String("perp_asset", snap.PerpAsset),
 				zap.String("spot_asset", snap.SpotAsset),
-				zap.String("spot_cloid", spotCloid),
-				zap.String("perp_cloid", perpCloid),
+				zap.String("spot_cloid", legs.SpotCloid),
+				zap.String("perp_cloid", legs.PerpCloid),
 				zap.Duration("duration", time.Since(start)),
-				zap.Float64("spot_limit", spotLimit),
-				zap.Float64("perp_limit", perpLimit),
-				zap.Float64("spot_size", spotSize),
-				zap.Float64("perp_size", perpSize),
-				zap.Float64("spot_filled", spotFilled),
-				zap.Float64("perp_filled", perpFilled),
+				zap.Float64("spot_limit", legs.SpotLimit),
+				zap.Float64("perp_limit", legs.PerpLimit),
+				zap.Float64("spot_size", legs.SpotSize),
+				zap.Float64("perp_size", legs.PerpSize),
+				zap.Float64("spot_filled", legs.SpotFilled),
+				zap.Float64("perp_filled", legs.PerpFilled),
 			)
 		}
 		if a.alerts != nil {
-			if alertErr := a.alerts.Send(ctx, fmt.Sprintf("Entry failed for %s/%s: %v", snap.PerpAsset, snap.SpotAsset, err)); alertErr != nil && a.log != nil {
+			if alertErr := a.alerts.Send(context.WithoutCancel(ctx), fmt.Sprintf("Entry failed for %s/%s: %v", snap.PerpAsset, snap.SpotAsset, err)); alertErr != nil && a.log != nil {
 				a.log.Warn("alert send failed", zap.Error(alertErr))
 			}
 		}
 	}()
-	a.strategy.Apply(strategy.EventEnter)
-	a.persistStrategySnapshot(ctx, snap)
-	priceRef := snap.SpotMidPrice
-	if snap.OraclePrice > 0 {
-		priceRef = snap.OraclePrice
-	}
-	if priceRef == 0 {
-		priceRef = snap.PerpMidPrice
-	}
-	size := snap.NotionalUSD / priceRef
-	perpCtx, ok := a.market.PerpContext(snap.PerpAsset)
-	if !ok {
-		err = fmt.Errorf("perp context not found for %s", snap.PerpAsset)
+	if err = a.applyEvent(strategy.EventEnter); err != nil {
 		return err
 	}
-	perpID := perpCtx.Index
-	spotCtx, err := a.spotContext(snap.SpotAsset)
+	legs, err = a.placeEntryLegs(ctx, snap, func() { a.applyEvent(strategy.EventAbort) })
 	if err != nil {
 		return err
 	}
-	spotID, ok := a.market.SpotAssetID(spotCtx.Symbol)
-	if !ok {
-		err = fmt.Errorf("spot asset id not found for %s", snap.SpotAsset)
-		return err
+	a.applyEvent(strategy.EventHedgeOK)
+	a.log.Info("entered delta-neutral position", logging.Unsampled(),
+		zap.String("perp_asset", snap.PerpAsset),
+		zap.String("spot_asset", snap.SpotAsset),
+		zap.String("spot_cloid", legs.SpotCloid),
+		zap.String("perp_cloid", legs.PerpCloid),
+		zap.Float64("spot_limit", legs.SpotLimit),
+		zap.Float64("perp_limit", legs.PerpLimit),
+		zap.Float64("spot_size", legs.SpotSize),
+		zap.Float64("perp_size", legs.PerpSize),
+		zap.Float64("spot_filled", legs.SpotFilled),
+		zap.Float64("perp_filled", legs.PerpFilled),
+		zap.Float64("spot_avg_px", legs.SpotAvgPx),
+		zap.Float64("perp_avg_px", legs.PerpAvgPx),
+		zap.Duration("duration", time.Since(start)),
+	)
+	a.ensureCrashStop(ctx, snap.PerpAsset, snap.PerpPosition-legs.PerpFilled, snap.PerpMidPrice)
+	a.recordEntryBasis(ctx, snap, legs)
+	a.recordPositionBaseline(ctx, start, snap)
+	a.startEntryCooldown(time.Now().UTC())
+	a.resetCompoundAccrual(ctx)
+	a.reconcileAccount(ctx, "entry")
+	if err := a.alerts.Send(ctx, fmt.Sprintf("Entered delta-neutral %s/%s size %.6f", snap.PerpAsset, snap.SpotAsset, legs.PerpFilled)); err != nil {
+		a.log.Warn("alert send failed", zap.Error(err))
 	}
-	spotRef := snap.SpotMidPrice
-	if spotRef == 0 {
-		spotRef = snap.PerpMidPrice
+	return nil
+}
+
+// entryLegs describes one spot buy plus perp short placement.
+type entryLegs struct {
+	SpotCloid  string
+	PerpCloid  string
+	SpotLimit  float64
+	PerpLimit  float64
+	SpotSize   float64
+	PerpSize   float64
+	SpotFilled float64
+	PerpFilled float64
+	// SpotAvgPx and PerpAvgPx are the volume-weighted fill prices, 0 when
+	// no fill price was reported.
+	SpotAvgPx float64
+	PerpAvgPx float64
+}
+
+// placeEntryLegs buys spot for snap.NotionalUSD and shorts the filled size on
+// the perp, rolling back unhedged spot. abort runs once orders have been sent
+// and a leg fails; legs is populated as far as placement got.
+func (a *App) placeEntryLegs(ctx context.Context, snap strategy.MarketSnapshot, abort func()) (legs entryLegs, err error) {
+	if abort == nil {
+		abort = func() {}
 	}
-	perpRef := snap.PerpMidPrice
-	if perpRef == 0 {
-		perpRef = snap.SpotMidPrice
+	plan, err := a.planEntry(snap)
+	if err != nil {
+		return legs, err
 	}
-	bps := a.cfg.Strategy.IOCPriceBps
-	spotLimit = limitPriceWithOffset(spotRef, true, true, spotCtx.BaseSzDecimals, bps)
-	perpLimit = limitPriceWithOffset(perpRef, false, false, perpCtx.SzDecimals, bps)
-	spotRollbackLimit = limitPriceWithOffset(spotRef, false, true, spotCtx.BaseSzDecimals, bps)
-	spotSize = size
-	if spotCtx.BaseSzDecimals >= 0 {
-		spotSize = roundDown(spotSize, spotCtx.BaseSzDecimals)
+	if plan.BumpedFromSize > 0 && a.log != nil {
+		a.log.Info("entry size raised to the exchange minimum", logging.Unsampled(),
+			zap.Float64("notional_usd", snap.NotionalUSD),
+			zap.Float64("planned_size", plan.BumpedFromSize),
+			zap.Float64("size", plan.Spot.Size),
+		)
 	}
-	if spotSize <= 0 || spotLimit <= 0 || perpLimit <= 0 {
-		err = errors.New("derived order size or limit price is invalid")
-		return err
+	spotID := plan.Spot.AssetID
+	perpID := plan.Perp.AssetID
+	legs.SpotLimit = plan.Spot.LimitPrice
+	legs.PerpLimit = plan.Perp.LimitPrice
+	spotRollbackLimit := plan.SpotRollbackLimit
+	legs.SpotSize = plan.Spot.Size
+	spotNotional := legs.SpotSize * legs.SpotLimit
+	perpNotional := legs.SpotSize * legs.PerpLimit
+	if err := a.executor.CheckOrderBudget(spotID, perpID); err != nil {
+		return legs, err
 	}
-	spotNotional := spotSize * spotLimit
-	perpNotional := spotSize * perpLimit
 	if err := a.ensureEntryUSDC(ctx, spotNotional, perpNotional); err != nil {
-		return err
+		return legs, err
 	}
-	spotCloid, err = newCloid()
+	if err := a.checkMarginForecast(snap.PerpAsset, perpNotional); err != nil {
+		return legs, err
+	}
+	legs.SpotCloid, err = newCloid()
 	if err != nil {
-		return err
+		return legs, err
 	}
-	perpCloid, err = newCloid()
+	legs.PerpCloid, err = newCloid()
 	if err != nil {
-		return err
+		return legs, err
 	}
 	spotOrder := exec.Order{
 		Asset:         spotID,
 		IsBuy:         true,
-		Size:          spotSize,
-		LimitPrice:    spotLimit,
-		ClientOrderID: spotCloid,
+		Size:          legs.SpotSize,
+		LimitPrice:    legs.SpotLimit,
+		ClientOrderID: legs.SpotCloid,
 		Tif:           string(exchange.TifIoc),
 	}
-	spotOrderID, spotFilled, spotOpen, err := a.placeAndWait(ctx, spotOrder)
+	spotFill, err := a.placeSpotEntry(ctx, snap, spotOrder, plan.SpotSzDecimals)
+	spotFilled := spotFill.Size
+	legs.SpotFilled = spotFilled
+	legs.SpotAvgPx = spotFill.AvgPrice()
 	if err != nil {
-		a.metrics.OrdersFailed.Inc()
-		a.resetToIdle()
-		return err
-	}
-	a.metrics.OrdersPlaced.Inc()
-	if spotOpen {
-		a.cancelBestEffort(ctx, spotID, spotOrderID)
-	}
-	if spotFilled <= 0 {
-		a.resetToIdle()
-		err = errors.New("spot entry did not fill")
-		return err
+		abort()
+		return legs, err
 	}
 
-	perpSize = spotFilled
-	if perpCtx.SzDecimals >= 0 {
-		perpSize = roundDown(perpSize, perpCtx.SzDecimals)
-	}
-	if perpSize <= 0 {
+	legs.PerpSize = spotFilled
+	legs.PerpSize = precision.Perp(plan.PerpSzDecimals).RoundSize(legs.PerpSize)
+	if legs.PerpSize <= 0 {
 		if rollbackErr := a.rollbackSpot(ctx, spotID, spotFilled, spotRollbackLimit); rollbackErr != nil {
-			a.log.Warn("spot rollback failed", zap.Error(rollbackErr))
+			a.log.Warn("spot rollback failed", logging.Unsampled(), zap.Error(rollbackErr))
 		}
-		a.resetToIdle()
-		err = errors.New("perp entry size rounded to zero")
-		return err
+		abort()
+		return legs, errors.New("perp entry size rounded to zero")
 	}
 	perpOrder := exec.Order{
 		Asset:         perpID,
 		IsBuy:         false,
-		Size:          perpSize,
-		LimitPrice:    perpLimit,
-		ClientOrderID: perpCloid,
+		Size:          legs.PerpSize,
+		LimitPrice:    legs.PerpLimit,
+		ClientOrderID: legs.PerpCloid,
 		Tif:           string(exchange.TifIoc),
 	}
-	perpOrderID, perpFilled, perpOpen, err := a.placeAndWait(ctx, perpOrder)
+	perpOutcome, err := a.placeAndFill(ctx, perpOrder, a.config().Strategy.EntryTimeout)
+	perpOrderID, perpFilled, perpOpen := perpOutcome.OrderID, perpOutcome.Fill.Size, perpOutcome.Open
+	legs.PerpFilled = perpFilled
+	legs.PerpAvgPx = perpOutcome.Fill.AvgPrice()
 	if err != nil {
 		a.metrics.OrdersFailed.Inc()
 		if rollbackErr := a.rollbackSpot(ctx, spotID, spotFilled, spotRollbackLimit); rollbackErr != nil {
-			a.log.Warn("spot rollback failed", zap.Error(rollbackErr))
+			a.log.Warn("spot rollback failed", logging.Unsampled(), zap.Error(rollbackErr))
 		}
-		a.resetToIdle()
-		return err
+		abort()
+		return legs, err
 	}
 	a.metrics.OrdersPlaced.Inc()
 	if perpOpen {
@@ -1067,41 +1544,22 @@ func (a *App) enterPosition(ctx context.Context, snap strategy.MarketSnapshot) (
 	}
 	if perpFilled <= 0 {
 		if rollbackErr := a.rollbackSpot(ctx, spotID, spotFilled, spotRollbackLimit); rollbackErr != nil {
-			a.log.Warn("spot rollback failed", zap.Error(rollbackErr))
+			a.log.Warn("spot rollback failed", logging.Unsampled(), zap.Error(rollbackErr))
 		}
-		a.resetToIdle()
-		err = errors.New("perp entry did not fill")
-		return err
+		abort()
+		return legs, errors.New("perp entry did not fill")
 	}
 	if residual := spotFilled - perpFilled; residual > 0 {
 		if rollbackErr := a.rollbackSpot(ctx, spotID, residual, spotRollbackLimit); rollbackErr != nil {
-			a.log.Warn("spot rollback failed", zap.Error(rollbackErr))
+			a.log.Warn("spot rollback failed", logging.Unsampled(), zap.Error(rollbackErr))
 		}
 	}
-	a.strategy.Apply(strategy.EventHedgeOK)
-	a.persistStrategySnapshot(ctx, snap)
-	a.log.Info("entered delta-neutral position",
-		zap.String("perp_asset", snap.PerpAsset),
-		zap.String("spot_asset", snap.SpotAsset),
-		zap.String("spot_cloid", spotCloid),
-		zap.String("perp_cloid", perpCloid),
-		zap.Float64("spot_limit", spotLimit),
-		zap.Float64("perp_limit", perpLimit),
-		zap.Float64("spot_size", spotSize),
-		zap.Float64("perp_size", perpSize),
-		zap.Float64("spot_filled", spotFilled),
-		zap.Float64("perp_filled", perpFilled),
-		zap.Duration("duration", time.Since(start)),
-	)
-	a.startEntryCooldown(time.Now().UTC())
-	a.reconcileAccount(ctx, "entry")
-	if err := a.alerts.Send(ctx, fmt.Sprintf("Entered delta-neutral %s/%s size %.6f", snap.PerpAsset, snap.SpotAsset, perpFilled)); err != nil {
-		a.log.Warn("alert send failed", zap.Error(err))
-	}
-	return nil
+	return legs, nil
 }
 
 func (a *App) exitPosition(ctx context.Context, snap strategy.MarketSnapshot) (err error) {
+	ctx, span := tracer.Start(ctx, "exit", trace.WithAttributes(attribute.String("perp_asset", snap.PerpAsset), attribute.String("spot_asset", snap.SpotAsset)))
+	defer func() { tracing.End(span, err) }()
 	start := time.Now().UTC()
 	spotCloid := ""
 	perpCloid := ""
@@ -1113,6 +1571,7 @@ func (a *App) exitPosition(ctx context.Context, snap strategy.MarketSnapshot) (e
 	spotFilled := 0.0
 	perpFilled := 0.0
 	defer func() {
+		a.noteTradeOutcome(err)
 		if err == nil {
 			return
 		}
@@ -1120,7 +1579,7 @@ func (a *App) exitPosition(ctx context.Context, snap strategy.MarketSnapshot) (e
 			a.metrics.ExitFailed.Inc()
 		}
 		if a.log != nil {
-			a.log.Warn("exit failed",
+			a.log.Warn("exit failed", logging.Unsampled(),
 				zap.Error(err),
 				zap.String("perp_asset", snap.PerpAsset),
 				zap.String("spot_asset", snap.SpotAsset),
@@ -1136,61 +1595,29 @@ func (a *App) exitPosition(ctx context.Context, snap strategy.MarketSnapshot) (e
 			)
 		}
 		if a.alerts != nil {
-			if alertErr := a.alerts.Send(ctx, fmt.Sprintf("Exit failed for %s/%s: %v", snap.PerpAsset, snap.SpotAsset, err)); alertErr != nil && a.log != nil {
+			if alertErr := a.alerts.Send(context.WithoutCancel(ctx), fmt.Sprintf("Exit failed for %s/%s: %v", snap.PerpAsset, snap.SpotAsset, err)); alertErr != nil && a.log != nil {
 				a.log.Warn("alert send failed", zap.Error(alertErr))
 			}
 		}
 	}()
-	a.strategy.Apply(strategy.EventExit)
-	a.persistStrategySnapshot(ctx, snap)
-	perpCtx, ok := a.market.PerpContext(snap.PerpAsset)
-	if !ok {
-		err = fmt.Errorf("perp context not found for %s", snap.PerpAsset)
+	if err = a.applyEvent(strategy.EventExit); err != nil {
 		return err
 	}
-	perpID := perpCtx.Index
-	spotCtx, err := a.spotContext(snap.SpotAsset)
+	plan, err := a.planExit(snap)
 	if err != nil {
 		return err
 	}
-	spotID, ok := a.market.SpotAssetID(spotCtx.Symbol)
-	if !ok {
-		err = fmt.Errorf("spot asset id not found for %s", snap.SpotAsset)
-		return err
-	}
-	spotRef := snap.SpotMidPrice
-	if spotRef == 0 {
-		spotRef = snap.PerpMidPrice
-	}
-	perpRef := snap.PerpMidPrice
-	if perpRef == 0 {
-		perpRef = snap.SpotMidPrice
-	}
-	spotLimit = normalizeLimitPrice(spotRef, true, spotCtx.BaseSzDecimals)
-	perpLimit = normalizeLimitPrice(perpRef, false, perpCtx.SzDecimals)
-	if spotLimit <= 0 || perpLimit <= 0 {
-		err = errors.New("derived order size or limit price is invalid")
-		return err
-	}
+	spotID := plan.Spot.AssetID
+	perpID := plan.Perp.AssetID
+	spotLimit = plan.Spot.LimitPrice
+	perpLimit = plan.Perp.LimitPrice
+	spotRollbackLimit = plan.SpotRollbackLimit
+	spotSize = plan.Spot.Size
+	perpSize = plan.Perp.Size
 	spotBalance := snap.SpotBalance
 	perpPosition := snap.PerpPosition
-	spotRollbackLimit = limitPriceWithOffset(spotRef, spotBalance >= 0, true, spotCtx.BaseSzDecimals, a.cfg.Strategy.IOCPriceBps)
-	spotSize = math.Abs(spotBalance)
-	if spotCtx.BaseSzDecimals >= 0 {
-		spotSize = roundDown(spotSize, spotCtx.BaseSzDecimals)
-	}
-	if a.exposureBelowThreshold(spotSize, spotLimit) {
-		spotSize = 0
-	}
-	perpSize = math.Abs(perpPosition)
-	if perpCtx.SzDecimals >= 0 {
-		perpSize = roundDown(perpSize, perpCtx.SzDecimals)
-	}
-	if a.exposureBelowThreshold(perpSize, perpLimit) {
-		perpSize = 0
-	}
 	if spotSize <= 0 && perpSize <= 0 {
-		a.strategy.Apply(strategy.EventDone)
+		a.applyEvent(strategy.EventDone)
 		return nil
 	}
 	if spotSize > 0 {
@@ -1224,10 +1651,10 @@ func (a *App) exitPosition(ctx context.Context, snap strategy.MarketSnapshot) (e
 		if spotFilled+flatEpsilon < spotSize {
 			if spotFilled > 0 {
 				if rollbackErr := a.rollbackSpotWith(ctx, spotID, spotFilled, spotRollbackLimit, spotBalance >= 0); rollbackErr != nil {
-					a.log.Warn("spot rollback failed", zap.Error(rollbackErr))
+					a.log.Warn("spot rollback failed", logging.Unsampled(), zap.Error(rollbackErr))
 				}
 			}
-			a.strategy.Apply(strategy.EventHedgeOK)
+			a.applyEvent(strategy.EventHedgeOK)
 			err = errors.New("spot exit did not fully fill")
 			return err
 		}
@@ -1245,10 +1672,10 @@ func (a *App) exitPosition(ctx context.Context, snap strategy.MarketSnapshot) (e
 		if err != nil {
 			if spotFilled > 0 {
 				if rollbackErr := a.rollbackSpotWith(ctx, spotID, spotFilled, spotRollbackLimit, spotBalance >= 0); rollbackErr != nil {
-					a.log.Warn("spot rollback failed", zap.Error(rollbackErr))
+					a.log.Warn("spot rollback failed", logging.Unsampled(), zap.Error(rollbackErr))
 				}
 			}
-			a.strategy.Apply(strategy.EventHedgeOK)
+			a.applyEvent(strategy.EventHedgeOK)
 			return err
 		}
 		if perpOpen {
@@ -1257,17 +1684,19 @@ func (a *App) exitPosition(ctx context.Context, snap strategy.MarketSnapshot) (e
 		if perpFilled+flatEpsilon < perpSize {
 			if spotFilled > 0 {
 				if rollbackErr := a.rollbackSpotWith(ctx, spotID, spotFilled, spotRollbackLimit, spotBalance >= 0); rollbackErr != nil {
-					a.log.Warn("spot rollback failed", zap.Error(rollbackErr))
+					a.log.Warn("spot rollback failed", logging.Unsampled(), zap.Error(rollbackErr))
 				}
 			}
-			a.strategy.Apply(strategy.EventHedgeOK)
+			a.applyEvent(strategy.EventHedgeOK)
 			err = errors.New("perp exit did not fully fill")
 			return err
 		}
 	}
-	a.strategy.Apply(strategy.EventDone)
-	a.persistStrategySnapshot(ctx, snap)
-	a.log.Info("exited delta-neutral position",
+	a.applyEvent(strategy.EventDone)
+	a.cancelCrashStop(ctx)
+	a.clearEntryBasis(ctx)
+	a.clearPositionBaseline(ctx)
+	a.log.Info("exited delta-neutral position", logging.Unsampled(),
 		zap.String("perp_asset", snap.PerpAsset),
 		zap.String("spot_asset", snap.SpotAsset),
 		zap.String("spot_cloid", spotCloid),
@@ -1283,6 +1712,9 @@ func (a *App) exitPosition(ctx context.Context, snap strategy.MarketSnapshot) (e
 	if err := a.alerts.Send(ctx, fmt.Sprintf("Exited delta-neutral %s/%s", snap.PerpAsset, snap.SpotAsset)); err != nil {
 		a.log.Warn("alert send failed", zap.Error(err))
 	}
+	if a.config().Dust.SweepOnExit {
+		a.maybeSweepDust(ctx, time.Now().UTC(), true)
+	}
 	return nil
 }
 
@@ -1301,19 +1733,11 @@ func (a *App) spotMid(ctx context.Context, asset string) (float64, market.SpotCo
 			return mid, spotCtx, nil
 		}
 	}
-	if asset != "" {
-		if mid, err := a.market.Mid(ctx, asset); err == nil {
-			return mid, spotCtx, nil
-		}
-	}
 	return 0, spotCtx, errors.New("spot mid price not found")
 }
 
 func (a *App) spotContext(asset string) (market.SpotContext, error) {
-	spotCtx, ok := a.market.SpotContext(asset)
-	if !ok && !strings.Contains(asset, "/") {
-		spotCtx, ok = a.market.SpotContext(asset + "/USDC")
-	}
+	spotCtx, ok := a.market.ResolveSpot(asset)
 	if !ok {
 		return market.SpotContext{}, fmt.Errorf("spot asset not found for %s", asset)
 	}
@@ -1333,13 +1757,11 @@ func (a *App) ensureSpotUSDC(ctx context.Context, required float64) error {
 	if shortfall <= 0 {
 		return nil
 	}
-	if a.exchange == nil {
-		return errors.New("exchange client is required for transfers")
-	}
-	if _, err := a.exchange.USDClassTransfer(ctx, shortfall, false); err != nil {
+	if err := a.usdClassTransfer(ctx, shortfall, false); err != nil {
 		return err
 	}
-	a.log.Info("transferred USDC to spot wallet", zap.Float64("amount", shortfall))
+	a.log.Info("transferred USDC to spot wallet", logging.Audit(), zap.Float64("amount", shortfall))
+	a.journalTransfer(ctx, persist.TransferRecord{Kind: persist.TransferUSDClass, Direction: "to_spot", Amount: shortfall})
 	_, err = a.account.Reconcile(ctx)
 	return err
 }
@@ -1347,9 +1769,19 @@ func (a *App) ensureSpotUSDC(ctx context.Context, required float64) error {
 type usdcTransferPlan struct {
 	Amount float64
 	ToPerp bool
+	// Required is set when a leg cannot be placed without the transfer;
+	// otherwise it only tops up the fee buffer.
+	Required bool
+	// Spare is what the source wallet holds above its own requirement, the
+	// most the transfer may grow to.
+	Spare float64
 }
 
-func planUSDCTransfer(spotUSDC, perpUSDC, spotRequired, perpRequired float64) (usdcTransferPlan, error) {
+// planUSDCTransfer plans the class transfer that funds both entry legs. The
+// requirements are checked as given; bufferBps then pads each wallet's need
+// so taker fees cannot leave an order short, taking only what the other
+// wallet holds above its own requirement.
+func planUSDCTransfer(spotUSDC, perpUSDC, spotRequired, perpRequired, bufferBps float64) (usdcTransferPlan, error) {
 	if spotRequired < 0 {
 		spotRequired = 0
 	}
@@ -1366,15 +1798,46 @@ func planUSDCTransfer(spotUSDC, perpUSDC, spotRequired, perpRequired float64) (u
 	if spotShort > flatEpsilon && perpShort > flatEpsilon {
 		return usdcTransferPlan{}, fmt.Errorf("insufficient USDC split: need spot %.2f and perp %.2f", spotRequired, perpRequired)
 	}
-	if spotShort > flatEpsilon {
-		return usdcTransferPlan{Amount: spotShort, ToPerp: false}, nil
+	pad := 1 + math.Max(bufferBps, 0)/10000
+	spare := perpUSDC - perpRequired
+	if amount := math.Min(spotRequired*pad-spotUSDC, spare); amount > flatEpsilon {
+		return usdcTransferPlan{Amount: amount, ToPerp: false, Required: spotShort > flatEpsilon, Spare: spare}, nil
 	}
-	if perpShort > flatEpsilon {
-		return usdcTransferPlan{Amount: perpShort, ToPerp: true}, nil
+	spare = spotUSDC - spotRequired
+	if amount := math.Min(perpRequired*pad-perpUSDC, spare); amount > flatEpsilon {
+		return usdcTransferPlan{Amount: amount, ToPerp: true, Required: perpShort > flatEpsilon, Spare: spare}, nil
 	}
 	return usdcTransferPlan{}, nil
 }
 
+// withFloor raises a transfer below minUSD to the floor, or as close as the
+// source wallet allows, so one transfer covers several entries. A buffer
+// top-up that cannot reach the floor is dropped and batched into a later
+// transfer.
+func (p usdcTransferPlan) withFloor(minUSD float64) usdcTransferPlan {
+	if p.Amount <= flatEpsilon || p.Amount >= minUSD {
+		return p
+	}
+	raised := math.Min(minUSD, p.Spare)
+	if raised+flatEpsilon < minUSD && !p.Required {
+		return usdcTransferPlan{}
+	}
+	p.Amount = math.Max(p.Amount, raised)
+	return p
+}
+
+// errTransferCooldown defers an entry whose legs need a class transfer while
+// strategy.transfer_cooldown runs.
+var errTransferCooldown = errors.New("USDC transfer cooldown active")
+
+func (a *App) transferCooldownLeft(now time.Time) time.Duration {
+	cooldown := a.strategyConfig().TransferCooldown
+	if cooldown <= 0 || a.lastUSDCTransfer.IsZero() {
+		return 0
+	}
+	return max(a.lastUSDCTransfer.Add(cooldown).Sub(now), 0)
+}
+
 func (a *App) ensureEntryUSDC(ctx context.Context, spotRequired, perpRequired float64) error {
 	if spotRequired <= 0 && perpRequired <= 0 {
 		return nil
@@ -1389,46 +1852,182 @@ func (a *App) ensureEntryUSDC(ctx context.Context, spotRequired, perpRequired fl
 	spotUSDC := state.SpotBalances["USDC"]
 	perpUSDC := 0.0
 	if state.HasMarginSummary {
-		perpUSDC = state.MarginSummary.AccountValue
+		perpUSDC = state.MarginSummary.FreeUSD()
+	}
+	cfg := a.strategyConfig()
+	recalled, err := a.recallVaultUSDC(ctx, spotUSDC, perpUSDC, (spotRequired+perpRequired)*(1+cfg.USDCBufferBps/10000))
+	if err != nil {
+		return err
 	}
-	plan, err := planUSDCTransfer(spotUSDC, perpUSDC, spotRequired, perpRequired)
+	if recalled {
+		refreshed := a.account.Snapshot()
+		spotUSDC = refreshed.SpotBalances["USDC"]
+		if refreshed.HasMarginSummary {
+			perpUSDC = refreshed.MarginSummary.FreeUSD()
+		}
+	}
+	plan, err := planUSDCTransfer(spotUSDC, perpUSDC, spotRequired, perpRequired, cfg.USDCBufferBps)
 	if err != nil {
 		return err
 	}
+	plan = plan.withFloor(cfg.MinTransferUSD)
 	if plan.Amount <= flatEpsilon {
 		return nil
 	}
-	if a.exchange == nil {
-		return errors.New("exchange client is required for transfers")
+	if left := a.transferCooldownLeft(time.Now()); left > 0 {
+		if plan.Required {
+			return fmt.Errorf("%w for another %s", errTransferCooldown, left.Round(time.Second))
+		}
+		return nil
 	}
-	if _, err := a.exchange.USDClassTransfer(ctx, plan.Amount, plan.ToPerp); err != nil {
+	if err := a.usdClassTransfer(ctx, plan.Amount, plan.ToPerp); err != nil {
 		return err
 	}
+	a.lastUSDCTransfer = time.Now()
+	dest := "spot"
+	direction := metrics.TransferToSpot
+	if plan.ToPerp {
+		dest = "perp"
+		direction = metrics.TransferToPerp
+	}
 	if a.log != nil {
-		dest := "spot"
-		if plan.ToPerp {
-			dest = "perp"
-		}
-		a.log.Info("transferred USDC to wallet", zap.String("wallet", dest), zap.Float64("amount", plan.Amount))
+		a.log.Info("transferred USDC to wallet", logging.Audit(), zap.String("wallet", dest), zap.Float64("amount", plan.Amount))
+	}
+	if a.metrics != nil && a.metrics.USDCTransfers != nil {
+		a.metrics.USDCTransfers.With(direction).Inc()
+	}
+	if a.metrics != nil && a.metrics.USDCTransferredUSD != nil {
+		a.metrics.USDCTransferredUSD.With(direction).Add(plan.Amount)
 	}
+	a.journalTransfer(ctx, persist.TransferRecord{Kind: persist.TransferUSDClass, Direction: direction, Amount: plan.Amount})
 	_, err = a.account.Reconcile(ctx)
 	return err
 }
 
 func (a *App) placeAndWait(ctx context.Context, order exec.Order) (string, float64, bool, error) {
+	return a.placeAndWaitFor(ctx, order, a.config().Strategy.EntryTimeout)
+}
+
+func (a *App) placeAndWaitFor(ctx context.Context, order exec.Order, timeout time.Duration) (string, float64, bool, error) {
+	result, err := a.placeAndFill(ctx, order, timeout)
+	return result.OrderID, result.Fill.Size, result.Open, err
+}
+
+// orderOutcome is where a placed order ended up: what filled, at which
+// volume-weighted price, and whether a remainder still rests.
+type orderOutcome struct {
+	OrderID string
+	Fill    account.OrderFill
+	Open    bool
+}
+
+// placeAndFill places order and waits up to timeout for it to fill. The
+// average price comes from the order's fills (or the placement response),
+// not the limit.
+func (a *App) placeAndFill(ctx context.Context, order exec.Order, timeout time.Duration) (orderOutcome, error) {
 	startMS := time.Now().Add(-entryFillLookback).UnixMilli()
-	orderID, err := a.executor.PlaceOrder(ctx, order)
+	placement, err := a.executor.Place(ctx, order)
+	if errors.Is(err, exec.ErrWouldCross) {
+		// A post-only order that would cross is rejected outright; callers
+		// reprice or fall back to IOC.
+		if a.metrics != nil {
+			a.metrics.PostOnlyRejected.Inc()
+		}
+		if a.log != nil {
+			a.log.Debug("post-only order rejected", zap.Error(err), zap.Int("asset", order.Asset), zap.Float64("limit", order.LimitPrice))
+		}
+		return orderOutcome{}, err
+	}
 	if err != nil {
-		return "", 0, false, err
+		return orderOutcome{}, err
+	}
+	outcome := orderOutcome{OrderID: placement.OrderID}
+	if placement.Filled() {
+		// The response already carries the final fill: IOC orders never rest
+		// and a partially filled GTC order would have been reported resting.
+		if a.log != nil {
+			a.log.Debug("order filled on placement",
+				zap.String("order_id", placement.OrderID),
+				zap.Float64("filled", placement.FilledSize),
+				zap.Float64("avg_px", placement.AvgPrice),
+			)
+		}
+		outcome.Fill = account.OrderFill{Size: placement.FilledSize, Notional: placement.FilledSize * placement.AvgPrice}
+		a.recordOrderSlippage(outcome, order)
+		return outcome, nil
+	}
+	outcome.Fill, outcome.Open, err = a.waitForOrderFill(ctx, placement.OrderID, startMS, timeout, a.config().Strategy.EntryPollInterval)
+	if err == nil {
+		a.recordOrderSlippage(outcome, order)
 	}
-	filled, open, err := a.waitForOrderFill(ctx, orderID, startMS, a.cfg.Strategy.EntryTimeout, a.cfg.Strategy.EntryPollInterval)
-	return orderID, filled, open, err
+	return outcome, err
 }
 
-func (a *App) waitForOrderFill(ctx context.Context, orderID string, startMS int64, timeout, poll time.Duration) (float64, bool, error) {
+// recordOrderSlippage measures an order's average fill price against its
+// placement mid when no fill stream feeds recordFillSlippage.
+func (a *App) recordOrderSlippage(outcome orderOutcome, order exec.Order) {
+	if a.account != nil && a.account.FillsEnabled() {
+		return
+	}
+	a.executor.RecordFill(outcome.OrderID, order.ClientOrderID, outcome.Fill.AvgPrice(), outcome.Fill.Size)
+}
+
+func (a *App) waitForOrderFill(ctx context.Context, orderID string, startMS int64, timeout, poll time.Duration) (filled account.OrderFill, open bool, err error) {
 	if orderID == "" {
-		return 0, false, errors.New("order id is required")
+		return account.OrderFill{}, false, errors.New("order id is required")
+	}
+	ctx, span := tracer.Start(ctx, "fill_wait", trace.WithAttributes(attribute.String("order_id", orderID), attribute.String("timeout", timeout.String())))
+	defer func() {
+		span.SetAttributes(attribute.Float64("filled", filled.Size), attribute.Bool("open", open))
+		tracing.End(span, err)
+	}()
+	if a.account != nil && a.account.OrderUpdatesEnabled() {
+		return a.waitForOrderTerminal(ctx, orderID, startMS, timeout, poll)
+	}
+	return a.pollOrderFill(ctx, orderID, startMS, timeout, poll)
+}
+
+// waitForOrderTerminal waits for the orderUpdates stream to report the order
+// as filled or canceled. If nothing arrives before the timeout, open orders
+// and REST fills are checked once so a missed push does not hide a fill.
+func (a *App) waitForOrderTerminal(ctx context.Context, orderID string, startMS int64, timeout, poll time.Duration) (account.OrderFill, bool, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	update, err := a.account.WaitForOrderTerminal(waitCtx, orderID)
+	if err == nil {
+		filled := a.account.OrderFill(orderID)
+		if size := update.FilledSize(); size > filled.Size {
+			// Not all fills have arrived yet; their average stands in for
+			// the rest.
+			filled = account.OrderFill{Size: size, Notional: size * filled.AvgPrice()}
+		}
+		return filled, false, nil
+	}
+	if ctx.Err() != nil {
+		return a.account.OrderFill(orderID), false, ctx.Err()
 	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return a.pollOrderFill(ctx, orderID, startMS, timeout, poll)
+	}
+	filled := a.account.OrderFill(orderID)
+	open, err := a.orderIsOpen(ctx, orderID)
+	if err != nil {
+		return filled, false, err
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		restFilled, err := a.orderFillREST(ctx, orderID, startMS)
+		if err != nil {
+			continue
+		}
+		if restFilled.Size > filled.Size {
+			filled = restFilled
+		}
+		break
+	}
+	return filled, open, nil
+}
+
+func (a *App) pollOrderFill(ctx context.Context, orderID string, startMS int64, timeout, poll time.Duration) (account.OrderFill, bool, error) {
 	deadline := time.NewTimer(timeout)
 	defer deadline.Stop()
 	ticker := time.NewTicker(poll)
@@ -1437,7 +2036,7 @@ func (a *App) waitForOrderFill(ctx context.Context, orderID string, startMS int6
 	restAttempted := false
 	restChecked := false
 	for {
-		filled, err := a.fillSizeForOrder(ctx, orderID, startMS)
+		filled, err := a.orderFill(ctx, orderID, startMS)
 		if err != nil {
 			return filled, false, err
 		}
@@ -1447,15 +2046,15 @@ func (a *App) waitForOrderFill(ctx context.Context, orderID string, startMS int6
 		}
 		if !open && a.account != nil && a.account.FillsEnabled() && !restAttempted {
 			restAttempted = true
-			if restFilled, err := a.fillSizeForOrderREST(ctx, orderID, startMS); err == nil {
+			if restFilled, err := a.orderFillREST(ctx, orderID, startMS); err == nil {
 				restChecked = true
-				if restFilled > filled {
+				if restFilled.Size > filled.Size {
 					filled = restFilled
 				}
 			}
 		}
 		lastOpen = open
-		if !open && filled > 0 {
+		if !open && filled.Size > 0 {
 			return filled, false, nil
 		}
 		select {
@@ -1463,9 +2062,9 @@ func (a *App) waitForOrderFill(ctx context.Context, orderID string, startMS int6
 			return filled, false, ctx.Err()
 		case <-deadline.C:
 			if a.account != nil && a.account.FillsEnabled() && !restChecked {
-				if restFilled, err := a.fillSizeForOrderREST(ctx, orderID, startMS); err == nil {
+				if restFilled, err := a.orderFillREST(ctx, orderID, startMS); err == nil {
 					restChecked = true
-					if restFilled > filled {
+					if restFilled.Size > filled.Size {
 						filled = restFilled
 					}
 				}
@@ -1476,26 +2075,19 @@ func (a *App) waitForOrderFill(ctx context.Context, orderID string, startMS int6
 	}
 }
 
-func (a *App) fillSizeForOrder(ctx context.Context, orderID string, startMS int64) (float64, error) {
+func (a *App) orderFill(ctx context.Context, orderID string, startMS int64) (account.OrderFill, error) {
 	if a.account != nil && a.account.FillsEnabled() {
-		return a.account.FillSize(orderID), nil
+		return a.account.OrderFill(orderID), nil
 	}
-	return a.fillSizeForOrderREST(ctx, orderID, startMS)
+	return a.orderFillREST(ctx, orderID, startMS)
 }
 
-func (a *App) fillSizeForOrderREST(ctx context.Context, orderID string, startMS int64) (float64, error) {
+func (a *App) orderFillREST(ctx context.Context, orderID string, startMS int64) (account.OrderFill, error) {
 	fills, err := a.account.UserFillsByTime(ctx, startMS, 0)
 	if err != nil {
-		return 0, err
+		return account.OrderFill{}, err
 	}
-	var total float64
-	for _, fill := range fills {
-		if fill.OrderID != orderID {
-			continue
-		}
-		total += math.Abs(fill.Size)
-	}
-	return total, nil
+	return account.SumOrderFills(fills, orderID), nil
 }
 
 func (a *App) orderIsOpen(ctx context.Context, orderID string) (bool, error) {
@@ -1512,48 +2104,23 @@ func (a *App) orderIsOpen(ctx context.Context, orderID string) (bool, error) {
 }
 
 func (a *App) cancelBestEffort(ctx context.Context, assetID int, orderID string) {
-	if orderID == "" || assetID == 0 {
+	if orderID == "" || assetID < 0 {
 		return
 	}
+	ctx, cancel := a.unwindContext(ctx)
+	defer cancel()
 	if err := a.executor.CancelOrder(ctx, exec.Cancel{Asset: assetID, OrderID: orderID}); err != nil {
-		a.log.Warn("failed to cancel order", zap.String("order_id", orderID), zap.Error(err))
+		a.log.Warn("failed to cancel order", logging.Unsampled(), zap.String("order_id", orderID), zap.Error(err))
 	}
 }
 
-func (a *App) rollbackSpot(ctx context.Context, assetID int, size, limit float64) error {
-	return a.rollbackSpotWith(ctx, assetID, size, limit, false)
-}
-
-func (a *App) rollbackSpotWith(ctx context.Context, assetID int, size, limit float64, isBuy bool) error {
-	if size <= 0 {
-		return nil
-	}
-	order := exec.Order{
-		Asset:      assetID,
-		IsBuy:      isBuy,
-		Size:       size,
-		LimitPrice: limit,
-		Tif:        string(exchange.TifIoc),
-	}
-	orderID, filled, open, err := a.placeAndWait(ctx, order)
-	if err != nil {
-		return err
-	}
-	if open {
-		a.cancelBestEffort(ctx, assetID, orderID)
-	}
-	if filled+1e-9 < size {
-		return fmt.Errorf("spot rollback filled %.6f of %.6f", filled, size)
-	}
-	return nil
-}
-
 func (a *App) persistStrategySnapshot(ctx context.Context, snap strategy.MarketSnapshot) {
+	a.strategySnap = snap
 	if a.store == nil {
 		return
 	}
 	snapshot := persist.StrategySnapshot{
-		Action:       string(a.strategy.State),
+		Action:       string(a.strategy.Current()),
 		SpotAsset:    snap.SpotAsset,
 		PerpAsset:    snap.PerpAsset,
 		SpotMidPrice: snap.SpotMidPrice,
@@ -1585,6 +2152,7 @@ func (a *App) logSnapshotPersistError(err error) {
 }
 
 func (a *App) restoreStrategyState(accountState *account.State, restored persist.StrategySnapshot, ok bool) {
+	cfg := a.config()
 	if !ok || a.strategy == nil {
 		return
 	}
@@ -1593,9 +2161,9 @@ func (a *App) restoreStrategyState(accountState *account.State, restored persist
 	perpPosition := 0.0
 	spotPrice := restored.SpotMidPrice
 	perpPrice := restored.PerpMidPrice
-	if accountState != nil && a.cfg != nil {
-		spotBalance = a.spotBalanceForAsset(a.cfg.Strategy.SpotAsset, accountState.SpotBalances)
-		perpPosition = accountState.PerpPosition[a.cfg.Strategy.PerpAsset]
+	if accountState != nil && cfg != nil {
+		spotBalance = a.spotBalanceForAsset(cfg.Strategy.SpotAsset, accountState.SpotBalances)
+		perpPosition = accountState.PerpPosition[cfg.Strategy.PerpAsset]
 		if a.isExposureFlat(spotBalance, perpPosition, spotPrice, perpPrice) {
 			state = strategy.StateIdle
 		} else if state == strategy.StateIdle {
@@ -1613,7 +2181,7 @@ func (a *App) spotBalanceForAsset(asset string, balances map[string]float64) flo
 		return 0
 	}
 	if a.market != nil {
-		if ctx, ok := a.market.SpotContext(asset); ok && ctx.Base != "" {
+		if ctx, ok := a.market.ResolveSpot(asset); ok && ctx.Base != "" {
 			return balances[ctx.Base]
 		}
 	}
@@ -1631,10 +2199,11 @@ func (a *App) isExposureFlat(spotBalance, perpPosition, spotPrice, perpPrice flo
 }
 
 func (a *App) exposureBelowThreshold(size, price float64) bool {
-	if a.cfg == nil || a.cfg.Strategy.MinExposureUSD <= 0 || price <= 0 {
+	cfg := a.config()
+	if cfg == nil || cfg.Strategy.MinExposureUSD <= 0 || price <= 0 {
 		return false
 	}
-	return math.Abs(size)*price < a.cfg.Strategy.MinExposureUSD
+	return math.Abs(size)*price < cfg.Strategy.MinExposureUSD
 }
 
 func parseStrategyState(raw string) strategy.State {
@@ -1646,108 +2215,69 @@ func parseStrategyState(raw string) strategy.State {
 	}
 }
 
-func (a *App) resetToIdle() {
-	a.strategy.Apply(strategy.EventExit)
-	a.strategy.Apply(strategy.EventDone)
-}
-
 func (a *App) entryCooldownActive(now time.Time) bool {
-	if a.cfg == nil {
+	if a.config() == nil {
 		return false
 	}
-	if a.cfg.Strategy.EntryCooldown <= 0 {
+	if a.config().Strategy.EntryCooldown <= 0 {
 		return false
 	}
-	return now.Before(a.entryCooldownUntil)
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return now.Before(a.rt.entryCooldownUntil)
 }
 
 func (a *App) startEntryCooldown(now time.Time) {
-	if a.cfg == nil {
+	cfg := a.config()
+	if cfg == nil {
 		return
 	}
-	if a.cfg.Strategy.EntryCooldown <= 0 {
+	if cfg.Strategy.EntryCooldown <= 0 {
 		return
 	}
-	a.entryCooldownUntil = now.Add(a.cfg.Strategy.EntryCooldown)
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.entryCooldownUntil = now.Add(cfg.Strategy.EntryCooldown)
 }
 
 func (a *App) hedgeCooldownActive(now time.Time) bool {
-	if a.cfg == nil {
+	if a.config() == nil {
 		return false
 	}
-	if a.cfg.Strategy.HedgeCooldown <= 0 {
+	if a.config().Strategy.HedgeCooldown <= 0 {
 		return false
 	}
-	return now.Before(a.hedgeCooldownUntil)
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return now.Before(a.rt.hedgeCooldownUntil)
 }
 
 func (a *App) startHedgeCooldown(now time.Time) {
-	if a.cfg == nil {
+	cfg := a.config()
+	if cfg == nil {
 		return
 	}
-	if a.cfg.Strategy.HedgeCooldown <= 0 {
+	if cfg.Strategy.HedgeCooldown <= 0 {
 		return
 	}
-	a.hedgeCooldownUntil = now.Add(a.cfg.Strategy.HedgeCooldown)
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.hedgeCooldownUntil = now.Add(cfg.Strategy.HedgeCooldown)
 }
 
 func isFlat(spotBalance, perpPosition float64) bool {
 	return math.Abs(spotBalance) <= flatEpsilon && math.Abs(perpPosition) <= flatEpsilon
 }
 
-func roundDown(value float64, decimals int) float64 {
-	if decimals <= 0 {
-		return math.Floor(value)
-	}
-	factor := math.Pow10(decimals)
-	return math.Floor(value*factor) / factor
-}
-
-func roundTo(value float64, decimals int) float64 {
-	if decimals <= 0 {
-		return math.Round(value)
-	}
-	factor := math.Pow10(decimals)
-	return math.Round(value*factor) / factor
-}
-
-func normalizeLimitPrice(price float64, isSpot bool, szDecimals int) float64 {
-	if price == 0 {
-		return 0
-	}
-	if sig, err := strconv.ParseFloat(strconv.FormatFloat(price, 'g', 5, 64), 64); err == nil {
-		price = sig
-	}
-	decimals := 6
-	if isSpot {
-		decimals = 8
-	}
-	if szDecimals >= 0 {
-		decimals -= szDecimals
-		if decimals < 0 {
-			decimals = 0
-		}
-	}
-	return roundTo(price, decimals)
-}
-
-func applyPriceOffset(price float64, isBuy bool, bps float64) float64 {
-	if price <= 0 || bps <= 0 {
-		return price
-	}
-	scale := bps / 10000
-	if isBuy {
-		return price * (1 + scale)
-	}
-	return price * (1 - scale)
-}
-
+// limitPriceWithOffset is the aggressive-IOC pricing used outside the
+// configurable entry/exit legs (rollbacks, hedges, dust): bps through price,
+// normalized to the exchange tick.
 func limitPriceWithOffset(price float64, isBuy bool, isSpot bool, szDecimals int, bps float64) float64 {
 	if price == 0 {
 		return 0
 	}
-	price = applyPriceOffset(price, isBuy, bps)
-	return normalizeLimitPrice(price, isSpot, szDecimals)
+	price = exec.OffsetPrice(price, isBuy, bps)
+	return precision.LimitPrice(price, isSpot, szDecimals)
 }
 
 func newCloid() (string, error) {
@@ -1765,53 +2295,116 @@ func (a *App) cancelOpenOrders(ctx context.Context, orders []map[string]any) {
 		a.log.Warn("open orders present but no ids parsed")
 		return
 	}
+	a.cancelOrderRefs(ctx, refs)
+}
+
+// cancelOrderRefs cancels the orders by oid, resolving a missing asset id
+// from the coin name. Each asset's orders go out in one cancel action so
+// they leave the book together.
+func (a *App) cancelOrderRefs(ctx context.Context, refs []account.OrderRef) {
+	if a.readOnly() {
+		a.log.Info("read-only: leaving open orders in place", zap.Int("open_orders", len(refs)))
+		return
+	}
+	var assets []int
+	byAsset := make(map[int][]string)
 	for _, ref := range refs {
 		if ref.OrderID == "" {
 			a.log.Warn("open order missing id", zap.String("asset", ref.AssetSymbol))
 			continue
 		}
-		assetID := ref.AssetID
-		if assetID == 0 && ref.AssetSymbol != "" {
+		// Asset 0 is the BTC perp, so a zero id only counts once the coin
+		// name resolves to it.
+		assetID, resolved := ref.AssetID, ref.AssetID != 0
+		if !resolved && ref.AssetSymbol != "" {
 			if id, ok := a.market.PerpAssetID(ref.AssetSymbol); ok {
-				assetID = id
+				assetID, resolved = id, true
 			} else if id, ok := a.market.SpotAssetID(ref.AssetSymbol); ok {
-				assetID = id
+				assetID, resolved = id, true
 			}
 		}
-		if assetID == 0 {
+		if !resolved {
 			a.log.Warn("open order missing asset id", zap.String("order_id", ref.OrderID), zap.String("asset", ref.AssetSymbol))
 			continue
 		}
-		if err := a.executor.CancelOrder(ctx, exec.Cancel{Asset: assetID, OrderID: ref.OrderID}); err != nil {
-			a.log.Warn("failed to cancel order", zap.String("order_id", ref.OrderID), zap.Error(err))
+		if _, ok := byAsset[assetID]; !ok {
+			assets = append(assets, assetID)
+		}
+		byAsset[assetID] = append(byAsset[assetID], ref.OrderID)
+	}
+	for _, assetID := range assets {
+		if err := a.executor.CancelAll(ctx, assetID, byAsset[assetID]); err != nil {
+			a.log.Warn("failed to cancel orders", logging.Unsampled(), zap.Int("asset", assetID), zap.Strings("order_ids", byAsset[assetID]), zap.Error(err))
 		}
 	}
 }
 
+// cloidLookup answers the executor's cloid lookups from the account's open
+// orders and fills.
+type cloidLookup struct {
+	account *account.Account
+}
+
+func (l cloidLookup) LookupCloid(ctx context.Context, cloid string, since time.Time) (exec.Placement, bool, error) {
+	order, found, err := l.account.OrderByCloid(ctx, cloid, since)
+	if err != nil || !found {
+		return exec.Placement{}, found, err
+	}
+	placement := exec.Placement{
+		OrderID:    order.OrderID,
+		Status:     exec.PlacementFilled,
+		FilledSize: order.Fill.Size,
+		AvgPrice:   order.Fill.AvgPrice(),
+	}
+	if order.Resting {
+		placement.Status = exec.PlacementResting
+	}
+	return placement, true, nil
+}
+
 type exchangeAdapter struct {
 	client *exchange.Client
 	tif    exchange.Tif
 	log    *zap.Logger
 }
 
-func (e *exchangeAdapter) PlaceOrder(ctx context.Context, order exec.Order) (string, error) {
+func (e *exchangeAdapter) PlaceOrder(ctx context.Context, order exec.Order) (exec.Placement, error) {
 	if e.client == nil {
-		return "", errors.New("exchange client is required")
+		return exec.Placement{}, errors.New("exchange client is required")
 	}
 	tif := e.tif
 	if order.Tif != "" {
 		tif = exchange.Tif(order.Tif)
 	}
-	wire, err := exchange.LimitOrderWire(order.Asset, order.IsBuy, order.Size, order.LimitPrice, order.ReduceOnly, tif, order.ClientOrderID)
+	var wire exchange.OrderWire
+	var err error
+	if order.TriggerPrice > 0 {
+		wire, err = exchange.TriggerOrderWire(order.Asset, order.IsBuy, order.Size, order.LimitPrice, order.TriggerPrice, true, order.ReduceOnly, exchange.Tpsl(order.Tpsl), order.ClientOrderID)
+	} else {
+		wire, err = exchange.LimitOrderWire(order.Asset, order.IsBuy, order.Size, order.LimitPrice, order.ReduceOnly, tif, order.ClientOrderID)
+	}
 	if err != nil {
-		return "", err
+		return exec.Placement{}, err
 	}
 	resp, err := e.client.PlaceOrder(ctx, wire)
+	if errors.Is(err, exchange.ErrAlreadyProcessed) {
+		if e.log != nil {
+			e.log.Warn("order retry hit an already processed nonce; not resubmitting", logging.Unsampled(),
+				zap.Error(err),
+				zap.Int("asset", order.Asset),
+				zap.String("cloid", order.ClientOrderID),
+			)
+		}
+		return exec.Placement{}, exec.Permanent(fmt.Errorf("%w: %v", exec.ErrAlreadyProcessed, err))
+	}
 	if err != nil {
-		return "", err
+		return exec.Placement{}, err
 	}
-	orderID := exchange.OrderIDFromResponse(resp)
-	if orderID == "" {
+	results, err := exchange.OrderResults(resp)
+	if err == nil && len(results) == 0 {
+		err = errors.New("order response has no statuses")
+	}
+	if err != nil {
 		if e.log != nil {
 			e.log.Debug("exchange response missing order id",
 				zap.Any("response", resp),
@@ -1819,16 +2412,52 @@ func (e *exchangeAdapter) PlaceOrder(ctx context.Context, order exec.Order) (str
 				zap.String("cloid", order.ClientOrderID),
 			)
 		}
-		return "", errors.New("missing order id in exchange response")
+		return exec.Placement{}, err
+	}
+	result := results[0]
+	if result.Status == exchange.OrderStatusRejected {
+		return exec.Placement{}, exec.Permanent(orderRejection(result.Err))
+	}
+	if result.OrderID == "" {
+		return exec.Placement{}, fmt.Errorf("%s order status missing order id", result.Status)
+	}
+	return exec.Placement{
+		OrderID:    result.OrderID,
+		Status:     string(result.Status),
+		FilledSize: result.FilledSize,
+		AvgPrice:   result.AvgPrice,
+	}, nil
+}
+
+// orderRejections maps the exchange's rejection classes onto the executor's.
+var orderRejections = []struct {
+	exchange error
+	exec     error
+}{
+	{exchange.ErrPostOnlyCross, exec.ErrWouldCross},
+	{exchange.ErrInsufficientMargin, exec.ErrInsufficientMargin},
+	{exchange.ErrInvalidPrice, exec.ErrInvalidPrice},
+	{exchange.ErrInvalidSize, exec.ErrInvalidSize},
+	{exchange.ErrMinOrderValue, exec.ErrMinOrderValue},
+	{exchange.ErrReduceOnly, exec.ErrReduceOnly},
+	{exchange.ErrNoImmediateMatch, exec.ErrNoImmediateMatch},
+	{exchange.ErrPriceBand, exec.ErrPriceBand},
+}
+
+func orderRejection(err error) error {
+	for _, rejection := range orderRejections {
+		if errors.Is(err, rejection.exchange) {
+			return fmt.Errorf("%w: %v", rejection.exec, err)
+		}
 	}
-	return orderID, nil
+	return fmt.Errorf("%w: %v", exec.ErrRejected, err)
 }
 
 func (e *exchangeAdapter) CancelOrder(ctx context.Context, cancel exec.Cancel) error {
 	if e.client == nil {
 		return errors.New("exchange client is required")
 	}
-	if cancel.Asset == 0 {
+	if cancel.Asset < 0 {
 		return errors.New("cancel asset is required")
 	}
 	if cancel.OrderID == "" {
@@ -1839,5 +2468,37 @@ func (e *exchangeAdapter) CancelOrder(ctx context.Context, cancel exec.Cancel) e
 		return fmt.Errorf("invalid order id %s: %w", cancel.OrderID, err)
 	}
 	_, err = e.client.CancelOrder(ctx, cancel.Asset, oid)
+	if errors.Is(err, exchange.ErrAlreadyProcessed) {
+		return exec.Permanent(err)
+	}
 	return err
 }
+
+func (e *exchangeAdapter) CancelOrders(ctx context.Context, cancels []exec.Cancel) ([]error, error) {
+	if e.client == nil {
+		return nil, errors.New("exchange client is required")
+	}
+	wires := make([]exchange.CancelWire, len(cancels))
+	for i, cancel := range cancels {
+		if cancel.Asset < 0 {
+			return nil, exec.Permanent(errors.New("cancel asset is required"))
+		}
+		oid, err := strconv.ParseInt(cancel.OrderID, 10, 64)
+		if err != nil {
+			return nil, exec.Permanent(fmt.Errorf("invalid order id %s: %w", cancel.OrderID, err))
+		}
+		wires[i] = exchange.CancelWire{Asset: cancel.Asset, OrderID: oid}
+	}
+	resp, err := e.client.CancelOrders(ctx, wires)
+	if err != nil {
+		if errors.Is(err, exchange.ErrAlreadyProcessed) {
+			return nil, exec.Permanent(err)
+		}
+		return nil, err
+	}
+	results, err := exchange.CancelResults(resp)
+	if err != nil {
+		return nil, exec.Permanent(err)
+	}
+	return results, nil
+}
@@ -23,6 +23,8 @@ import (
 	"hl-carry-bot/internal/hl/exchange"
 	"hl-carry-bot/internal/hl/rest"
 	"hl-carry-bot/internal/hl/ws"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/logging"
 	"hl-carry-bot/internal/market"
 	"hl-carry-bot/internal/metrics"
 	persist "hl-carry-bot/internal/state"
@@ -33,26 +35,6 @@ import (
 	"nhooyr.io/websocket"
 )
 
-func TestRoundDown(t *testing.T) {
-	got := roundDown(1.239, 2)
-	if math.Abs(got-1.23) > 1e-9 {
-		t.Fatalf("expected 1.23, got %f", got)
-	}
-}
-
-func TestNormalizeLimitPriceDecimals(t *testing.T) {
-	price := normalizeLimitPrice(123.456789, true, 2)
-	scaled := price * 1e6
-	if math.Abs(scaled-math.Round(scaled)) > 1e-9 {
-		t.Fatalf("expected spot price rounded to 6 decimals, got %f", price)
-	}
-	perpPrice := normalizeLimitPrice(123.456789, false, 1)
-	perpScaled := perpPrice * 1e5
-	if math.Abs(perpScaled-math.Round(perpScaled)) > 1e-9 {
-		t.Fatalf("expected perp price rounded to 5 decimals, got %f", perpPrice)
-	}
-}
-
 func TestNewCloidFormat(t *testing.T) {
 	cloid, err := newCloid()
 	if err != nil {
@@ -96,16 +78,16 @@ func TestHedgeCooldownActive(t *testing.T) {
 }
 
 func TestTickSkipsEntryDuringCooldown(t *testing.T) {
-	server := newMockInfoServer(t)
+	server := hltest.NewServer(t)
 	defer server.Close()
-	server.nextFundingTime = time.Now().Add(1 * time.Hour).UnixMilli()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
 
 	cfg := &config.Config{
 		Strategy: config.StrategyConfig{
 			PerpAsset:               "ETH",
 			SpotAsset:               "UETH",
 			NotionalUSD:             10,
-			MinFundingRate:          0,
+			MinFundingAPR:           0,
 			MaxVolatility:           1,
 			FeeBps:                  0,
 			SlippageBps:             0,
@@ -131,7 +113,7 @@ func TestTickSkipsEntryDuringCooldown(t *testing.T) {
 	if _, err := app.account.Reconcile(context.Background()); err != nil {
 		t.Fatalf("account reconcile: %v", err)
 	}
-	app.entryCooldownUntil = time.Now().Add(1 * time.Minute)
+	app.rt.entryCooldownUntil = time.Now().Add(1 * time.Minute)
 
 	if err := app.tick(context.Background()); err != nil {
 		t.Fatalf("tick error: %v", err)
@@ -152,20 +134,20 @@ func TestTickSkipsEntryDuringCooldown(t *testing.T) {
 }
 
 func TestTickSkipsHedgeDuringCooldown(t *testing.T) {
-	server := newMockInfoServer(t)
+	server := hltest.NewServer(t)
 	defer server.Close()
-	server.nextFundingTime = time.Now().Add(1 * time.Hour).UnixMilli()
-	server.spotBalances = []any{
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	server.SetSpotBalances([]any{
 		map[string]any{"coin": "UETH", "total": "0.01"},
 		map[string]any{"coin": "USDC", "total": "100"},
-	}
+	})
 
 	cfg := &config.Config{
 		Strategy: config.StrategyConfig{
 			PerpAsset:               "ETH",
 			SpotAsset:               "UETH",
 			NotionalUSD:             10,
-			MinFundingRate:          0,
+			MinFundingAPR:           0,
 			MaxVolatility:           1,
 			FeeBps:                  0,
 			SlippageBps:             0,
@@ -190,7 +172,7 @@ func TestTickSkipsHedgeDuringCooldown(t *testing.T) {
 		strategy: strategy.NewStateMachine(),
 	}
 	app.strategy.SetState(strategy.StateHedgeOK)
-	app.hedgeCooldownUntil = time.Now().Add(1 * time.Minute)
+	app.rt.hedgeCooldownUntil = time.Now().Add(1 * time.Minute)
 	if _, err := app.account.Reconcile(context.Background()); err != nil {
 		t.Fatalf("account reconcile: %v", err)
 	}
@@ -206,16 +188,16 @@ func TestTickSkipsHedgeDuringCooldown(t *testing.T) {
 }
 
 func TestTickKeepsHedgeOKDuringEntryCooldown(t *testing.T) {
-	server := newMockInfoServer(t)
+	server := hltest.NewServer(t)
 	defer server.Close()
-	server.nextFundingTime = time.Now().Add(1 * time.Hour).UnixMilli()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
 
 	cfg := &config.Config{
 		Strategy: config.StrategyConfig{
 			PerpAsset:               "ETH",
 			SpotAsset:               "UETH",
 			NotionalUSD:             10,
-			MinFundingRate:          0,
+			MinFundingAPR:           0,
 			MaxVolatility:           1,
 			FeeBps:                  0,
 			SlippageBps:             0,
@@ -238,7 +220,7 @@ func TestTickKeepsHedgeOKDuringEntryCooldown(t *testing.T) {
 		strategy: strategy.NewStateMachine(),
 	}
 	app.strategy.SetState(strategy.StateHedgeOK)
-	app.entryCooldownUntil = time.Now().Add(1 * time.Minute)
+	app.rt.entryCooldownUntil = time.Now().Add(1 * time.Minute)
 	if _, err := app.account.Reconcile(context.Background()); err != nil {
 		t.Fatalf("account reconcile: %v", err)
 	}
@@ -298,24 +280,24 @@ func TestMaybeLogFundingReceiptLogsEntry(t *testing.T) {
 }
 
 func TestEnterPositionReconcilesAccount(t *testing.T) {
-	server := newMockInfoServer(t)
+	server := hltest.NewServer(t)
 	defer server.Close()
-	server.nextFundingTime = time.Now().Add(1 * time.Hour).UnixMilli()
-	server.spotBalances = []any{
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	server.SetSpotBalances([]any{
 		map[string]any{"coin": "USDC", "total": "100"},
-	}
-	server.accountValue = 100
-	server.fills = []any{
-		map[string]any{"oid": "spot-oid", "coin": "ETH", "side": "B", "sz": "0.0038", "px": "3000", "time": 1700000000000},
-		map[string]any{"oid": "perp-oid", "coin": "ETH", "side": "S", "sz": "0.0038", "px": "3000", "time": 1700000000000},
-	}
+	})
+	server.SetAccountValue(100)
+	server.SetFills([]any{
+		map[string]any{"oid": "spot-oid", "coin": "ETH", "side": "B", "sz": "0.0066", "px": "3000", "time": 1700000000000},
+		map[string]any{"oid": "perp-oid", "coin": "ETH", "side": "S", "sz": "0.0066", "px": "3000", "time": 1700000000000},
+	})
 
 	cfg := &config.Config{
 		Strategy: config.StrategyConfig{
 			PerpAsset:         "ETH",
 			SpotAsset:         "UETH",
-			NotionalUSD:       10,
-			MinFundingRate:    0,
+			NotionalUSD:       20,
+			MinFundingAPR:     0,
 			MaxVolatility:     1,
 			EntryTimeout:      500 * time.Millisecond,
 			EntryPollInterval: 10 * time.Millisecond,
@@ -347,7 +329,7 @@ func TestEnterPositionReconcilesAccount(t *testing.T) {
 		SpotMidPrice: 3000,
 		PerpMidPrice: 3000,
 		OraclePrice:  3000,
-		NotionalUSD:  10,
+		NotionalUSD:  20,
 	}
 	if err := app.enterPosition(context.Background(), snap); err != nil {
 		t.Fatalf("enter position: %v", err)
@@ -361,7 +343,7 @@ func TestEnterPositionReconcilesAccount(t *testing.T) {
 }
 
 func TestPlanUSDCTransferToSpot(t *testing.T) {
-	plan, err := planUSDCTransfer(5, 20, 10, 5)
+	plan, err := planUSDCTransfer(5, 20, 10, 5, 0)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -374,7 +356,7 @@ func TestPlanUSDCTransferToSpot(t *testing.T) {
 }
 
 func TestPlanUSDCTransferToPerp(t *testing.T) {
-	plan, err := planUSDCTransfer(25, 2, 10, 10)
+	plan, err := planUSDCTransfer(25, 2, 10, 10, 0)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -387,13 +369,13 @@ func TestPlanUSDCTransferToPerp(t *testing.T) {
 }
 
 func TestPlanUSDCTransferInsufficientTotal(t *testing.T) {
-	if _, err := planUSDCTransfer(5, 2, 10, 10); err == nil {
+	if _, err := planUSDCTransfer(5, 2, 10, 10, 0); err == nil {
 		t.Fatalf("expected error for insufficient total")
 	}
 }
 
 func TestPlanUSDCTransferNoop(t *testing.T) {
-	plan, err := planUSDCTransfer(10, 10, 10, 10)
+	plan, err := planUSDCTransfer(10, 10, 10, 10, 0)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -402,6 +384,68 @@ func TestPlanUSDCTransferNoop(t *testing.T) {
 	}
 }
 
+func TestPlanUSDCTransferBuffer(t *testing.T) {
+	// 100 bps pads the 10 USDC spot need to 10.1 even though spot already has 10.
+	plan, err := planUSDCTransfer(10, 20, 10, 5, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.ToPerp || math.Abs(plan.Amount-0.1) > 1e-9 {
+		t.Fatalf("expected 0.1 to spot, got %+v", plan)
+	}
+	// The padding never dips into the perp requirement.
+	plan, err = planUSDCTransfer(5, 10.05, 10, 5, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.ToPerp || math.Abs(plan.Amount-5.05) > 1e-9 {
+		t.Fatalf("expected 5.05 to spot, got %+v", plan)
+	}
+	// With nothing to spare on either side the buffer is dropped, not an error.
+	plan, err = planUSDCTransfer(10, 10, 10, 10, 100)
+	if err != nil || plan.Amount != 0 {
+		t.Fatalf("expected no transfer, got %+v (err=%v)", plan, err)
+	}
+}
+
+func TestUSDCTransferFloor(t *testing.T) {
+	raised := usdcTransferPlan{Amount: 2, Required: true, Spare: 50}.withFloor(10)
+	if raised.Amount != 10 {
+		t.Fatalf("expected the transfer raised to the 10 USDC floor, got %f", raised.Amount)
+	}
+	capped := usdcTransferPlan{Amount: 2, Required: true, Spare: 4}.withFloor(10)
+	if capped.Amount != 4 {
+		t.Fatalf("expected the floor capped at the 4 USDC spare, got %f", capped.Amount)
+	}
+	if dropped := (usdcTransferPlan{Amount: 0.05, Spare: 4}).withFloor(10); dropped.Amount != 0 {
+		t.Fatalf("expected a buffer top-up below the floor batched, got %f", dropped.Amount)
+	}
+	if kept := (usdcTransferPlan{Amount: 12, Spare: 50}).withFloor(10); kept.Amount != 12 {
+		t.Fatalf("expected a transfer above the floor unchanged, got %f", kept.Amount)
+	}
+}
+
+func TestEnsureEntryUSDCDefersDuringTransferCooldown(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetSpotBalances([]any{map[string]any{"coin": "USDC", "total": "5"}})
+	server.SetAccountValue(100)
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.TransferCooldown = time.Minute
+	app.lastUSDCTransfer = time.Now().Add(-10 * time.Second)
+
+	err := app.ensureEntryUSDC(context.Background(), 20, 20)
+	if !errors.Is(err, errTransferCooldown) {
+		t.Fatalf("expected the entry deferred by the transfer cooldown, got %v", err)
+	}
+	// A buffer top-up alone is not worth deferring the entry for.
+	server.SetSpotBalances([]any{map[string]any{"coin": "USDC", "total": "20"}})
+	app.cfg.Strategy.USDCBufferBps = 100
+	if err := app.ensureEntryUSDC(context.Background(), 20, 20); err != nil {
+		t.Fatalf("expected the top-up skipped during the cooldown, got %v", err)
+	}
+}
+
 func TestExchangeAdapterLogsMissingOrderID(t *testing.T) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/exchange", func(w http.ResponseWriter, r *http.Request) {
@@ -443,6 +487,178 @@ func TestExchangeAdapterLogsMissingOrderID(t *testing.T) {
 	}
 }
 
+func TestPostOnlyCrossIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/exchange", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"order","data":{"statuses":[{"error":"Post only order would have immediately matched, bbo was 2999.9@3000.1. asset=10051"}]}}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(srv.URL, 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	m, counters := newTestMetrics()
+	app := &App{
+		cfg:      &config.Config{Strategy: config.StrategyConfig{EntryTimeout: time.Second, EntryPollInterval: 10 * time.Millisecond}},
+		log:      zap.NewNop(),
+		metrics:  m,
+		executor: exec.New(&exchangeAdapter{client: client, tif: exchange.TifGtc, log: zap.NewNop()}, nil, zap.NewNop()),
+	}
+	_, _, _, err = app.placeAndWait(context.Background(), exec.Order{
+		Asset:         10051,
+		IsBuy:         true,
+		Size:          0.01,
+		LimitPrice:    3000.1,
+		ClientOrderID: "0x00000000000000000000000000000001",
+		Tif:           string(exchange.TifAlo),
+	})
+	if !errors.Is(err, exec.ErrWouldCross) {
+		t.Fatalf("expected ErrWouldCross, got %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected 1 exchange call, got %d", got)
+	}
+	if counters.postOnly.count != 1 {
+		t.Fatalf("expected post-only rejection counted, got %d", counters.postOnly.count)
+	}
+}
+
+func TestFilledPlacementSkipsFillPolling(t *testing.T) {
+	var infoCalls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/exchange", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"order","data":{"statuses":[{"filled":{"totalSz":"0.004","avgPx":"3000.5","oid":77}}]}}}`))
+	})
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		infoCalls.Add(1)
+		http.Error(w, "unexpected", http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(srv.URL, 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	app := &App{
+		cfg:      &config.Config{Strategy: config.StrategyConfig{EntryTimeout: time.Second, EntryPollInterval: 10 * time.Millisecond}},
+		log:      zap.NewNop(),
+		executor: exec.New(&exchangeAdapter{client: client, tif: exchange.TifIoc, log: zap.NewNop()}, nil, zap.NewNop()),
+	}
+	orderID, filled, open, err := app.placeAndWait(context.Background(), exec.Order{
+		Asset:      4,
+		IsBuy:      true,
+		Size:       0.01,
+		LimitPrice: 3003,
+	})
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if orderID != "77" || filled != 0.004 || open {
+		t.Fatalf("expected the partial IOC fill from the response, got %s/%v/%v", orderID, filled, open)
+	}
+	if got := infoCalls.Load(); got != 0 {
+		t.Fatalf("expected no fill polling, got %d info calls", got)
+	}
+}
+
+func TestOrderRejectionsAreClassifiedAndNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	var statusJSON atomic.Value
+	mux := http.NewServeMux()
+	mux.HandleFunc("/exchange", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"order","data":{"statuses":[` + statusJSON.Load().(string) + `]}}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(srv.URL, 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	executor := exec.New(&exchangeAdapter{client: client, tif: exchange.TifGtc, log: zap.NewNop()}, nil, zap.NewNop())
+	order := exec.Order{Asset: 1, IsBuy: true, Size: 0.01, LimitPrice: 3000}
+
+	cases := []struct {
+		status string
+		want   error
+	}{
+		{`{"error":"Insufficient margin to place order. asset=1"}`, exec.ErrInsufficientMargin},
+		{`{"error":"Order has invalid price."}`, exec.ErrInvalidPrice},
+		{`{"error":"Order must have minimum value of $10. asset=1"}`, exec.ErrMinOrderValue},
+		{`{"error":"Something new"}`, exec.ErrRejected},
+	}
+	for _, tc := range cases {
+		calls.Store(0)
+		statusJSON.Store(tc.status)
+		_, err := executor.PlaceOrder(context.Background(), order)
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.status, tc.want, err)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Fatalf("%s: expected 1 exchange call, got %d", tc.status, got)
+		}
+	}
+
+	statusJSON.Store(`{"filled":{"totalSz":"0.01","avgPx":"3000","oid":42}}`)
+	if orderID, err := executor.PlaceOrder(context.Background(), order); err != nil || orderID != "42" {
+		t.Fatalf("expected filled order id 42, got %q (%v)", orderID, err)
+	}
+}
+
+func TestExchangeAdapterCancelsAssetZero(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	executor := exec.New(&exchangeAdapter{client: client, tif: exchange.TifGtc, log: zap.NewNop()}, nil, zap.NewNop())
+	ctx := context.Background()
+
+	// Asset 0 is the BTC perp; the fake exchange has no such orders, so each
+	// cancel comes back failed, but the action must go out.
+	if err := executor.CancelAll(ctx, 0, []string{"7", "8"}); err == nil || strings.Contains(err.Error(), "asset is required") {
+		t.Fatalf("expected per-order failures from the exchange, got %v", err)
+	}
+	if err := executor.CancelOrder(ctx, exec.Cancel{Asset: 0, OrderID: "9"}); err != nil {
+		t.Fatalf("cancel asset 0: %v", err)
+	}
+	cancels := server.Cancels()
+	if len(cancels) != 3 {
+		t.Fatalf("expected 3 cancels sent, got %+v", cancels)
+	}
+	for _, cancel := range cancels {
+		if cancel.Asset != 0 {
+			t.Fatalf("expected asset 0 cancels, got %+v", cancels)
+		}
+	}
+}
+
 func TestIsFlat(t *testing.T) {
 	if !isFlat(0, 0) {
 		t.Fatalf("expected flat state")
@@ -535,8 +751,8 @@ func TestWaitForOrderFillRestFallbackRetryOnError(t *testing.T) {
 	if open {
 		t.Fatalf("expected open=false, got true")
 	}
-	if math.Abs(filled-0.1) > 1e-9 {
-		t.Fatalf("expected filled=0.1, got %f", filled)
+	if math.Abs(filled.Size-0.1) > 1e-9 {
+		t.Fatalf("expected filled=0.1, got %f", filled.Size)
 	}
 	if got := userFillsCalls.Load(); got != 2 {
 		t.Fatalf("expected 2 userFillsByTime calls, got %d", got)
@@ -906,10 +1122,10 @@ func TestConnectivityKillSwitchRetriesCancel(t *testing.T) {
 		metrics:  metricsStub,
 	}
 	openOrders := []map[string]any{{"oid": "1", "asset": 1}}
-	if err := app.checkConnectivity(context.Background(), app.riskConfig(), openOrders, 2*time.Second, 0); err == nil {
+	if err := app.checkConnectivity(context.Background(), app.riskConfig(), openOrders, strategy.DataAges{PerpMid: 2 * time.Second, SpotMid: 2 * time.Second}); err == nil {
 		t.Fatalf("expected connectivity error")
 	}
-	if !app.killSwitchActive {
+	if !app.rt.killSwitchActive {
 		t.Fatalf("expected kill switch active")
 	}
 	if got := len(stub.cancels); got != 1 {
@@ -918,7 +1134,7 @@ func TestConnectivityKillSwitchRetriesCancel(t *testing.T) {
 	if counters.killEngaged.count != 1 {
 		t.Fatalf("expected kill switch engaged count 1, got %d", counters.killEngaged.count)
 	}
-	if err := app.checkConnectivity(context.Background(), app.riskConfig(), openOrders, 2*time.Second, 0); err == nil {
+	if err := app.checkConnectivity(context.Background(), app.riskConfig(), openOrders, strategy.DataAges{PerpMid: 2 * time.Second, SpotMid: 2 * time.Second}); err == nil {
 		t.Fatalf("expected connectivity error on retry")
 	}
 	if got := len(stub.cancels); got != 2 {
@@ -939,14 +1155,14 @@ func TestConnectivityKillSwitchRestores(t *testing.T) {
 		metrics:  metricsStub,
 	}
 	openOrders := []map[string]any{{"oid": "1", "asset": 1}}
-	_ = app.checkConnectivity(context.Background(), app.riskConfig(), openOrders, 2*time.Second, 0)
-	if !app.killSwitchActive {
+	_ = app.checkConnectivity(context.Background(), app.riskConfig(), openOrders, strategy.DataAges{PerpMid: 2 * time.Second, SpotMid: 2 * time.Second})
+	if !app.killSwitchEngaged() || counters.killActive.value != 1 {
 		t.Fatalf("expected kill switch active")
 	}
-	if err := app.checkConnectivity(context.Background(), app.riskConfig(), openOrders, 0, 0); err != nil {
+	if err := app.checkConnectivity(context.Background(), app.riskConfig(), openOrders, strategy.DataAges{}); err != nil {
 		t.Fatalf("expected connectivity restored, got %v", err)
 	}
-	if app.killSwitchActive {
+	if app.killSwitchEngaged() || counters.killActive.value != 0 {
 		t.Fatalf("expected kill switch cleared")
 	}
 	if counters.killEngaged.count != 1 {
@@ -960,7 +1176,7 @@ func TestConnectivityKillSwitchRestores(t *testing.T) {
 func TestFundingRegimeConfirmations(t *testing.T) {
 	app := &App{
 		cfg: &config.Config{Strategy: config.StrategyConfig{
-			MinFundingRate:          0.01,
+			MinFundingAPR:           0.01,
 			CarryBufferUSD:          1,
 			FundingConfirmations:    2,
 			FundingDipConfirmations: 2,
@@ -1095,22 +1311,89 @@ func (c *testCounter) Inc() {
 }
 
 type metricsCounters struct {
-	ordersPlaced *testCounter
-	ordersFailed *testCounter
-	entryFailed  *testCounter
-	exitFailed   *testCounter
-	killEngaged  *testCounter
-	killRestored *testCounter
+	ordersPlaced  *testCounter
+	ordersFailed  *testCounter
+	entryFailed   *testCounter
+	exitFailed    *testCounter
+	killEngaged   *testCounter
+	killRestored  *testCounter
+	rollbacks     *testCounter
+	rollbackFail  *testCounter
+	rollbackRetry *testCounter
+	foreign       *testCounter
+	postOnly      *testCounter
+	riskAction    *testGauge
+	riskViolation *testCounter
+	circuitOpened *testCounter
+	accountDrift  *testCounter
+	nonceRejected *testCounter
+	killActive    *testGauge
+	paused        *testGauge
+	ticksSkipped  testCounterVec
+	fundingAPR    *testGauge
+	perpSlippage  *testGauge
+	spotSlippage  *testGauge
+	iocPriceBps   *testGauge
+	orphanOrders  *testCounter
+	transitions   testTransitionCounter
+	stateSeconds  *testGauge
+}
+
+type testGauge struct {
+	value float64
+}
+
+func (g *testGauge) Set(v float64) {
+	g.value = v
+}
+
+type testCounterVec map[string]*testCounter
+
+func (v testCounterVec) With(value string) metrics.Counter {
+	if v[value] == nil {
+		v[value] = &testCounter{}
+	}
+	return v[value]
+}
+
+type testTransitionCounter map[string]*testCounter
+
+func (v testTransitionCounter) With(from, to, reason string) metrics.Counter {
+	key := from + ">" + to + ":" + reason
+	if v[key] == nil {
+		v[key] = &testCounter{}
+	}
+	return v[key]
 }
 
 func newTestMetrics() (*metrics.Metrics, *metricsCounters) {
 	counters := &metricsCounters{
-		ordersPlaced: &testCounter{},
-		ordersFailed: &testCounter{},
-		entryFailed:  &testCounter{},
-		exitFailed:   &testCounter{},
-		killEngaged:  &testCounter{},
-		killRestored: &testCounter{},
+		ordersPlaced:  &testCounter{},
+		ordersFailed:  &testCounter{},
+		entryFailed:   &testCounter{},
+		exitFailed:    &testCounter{},
+		killEngaged:   &testCounter{},
+		killRestored:  &testCounter{},
+		rollbacks:     &testCounter{},
+		rollbackFail:  &testCounter{},
+		rollbackRetry: &testCounter{},
+		foreign:       &testCounter{},
+		postOnly:      &testCounter{},
+		riskAction:    &testGauge{},
+		riskViolation: &testCounter{},
+		circuitOpened: &testCounter{},
+		accountDrift:  &testCounter{},
+		nonceRejected: &testCounter{},
+		killActive:    &testGauge{},
+		paused:        &testGauge{},
+		ticksSkipped:  testCounterVec{},
+		fundingAPR:    &testGauge{},
+		perpSlippage:  &testGauge{},
+		spotSlippage:  &testGauge{},
+		iocPriceBps:   &testGauge{},
+		orphanOrders:  &testCounter{},
+		transitions:   testTransitionCounter{},
+		stateSeconds:  &testGauge{},
 	}
 	m := &metrics.Metrics{
 		OrdersPlaced:       counters.ordersPlaced,
@@ -1119,6 +1402,26 @@ func newTestMetrics() (*metrics.Metrics, *metricsCounters) {
 		ExitFailed:         counters.exitFailed,
 		KillSwitchEngaged:  counters.killEngaged,
 		KillSwitchRestored: counters.killRestored,
+		Rollbacks:          counters.rollbacks,
+		RollbacksFailed:    counters.rollbackFail,
+		RollbackRetries:    counters.rollbackRetry,
+		ForeignActivity:    counters.foreign,
+		PostOnlyRejected:   counters.postOnly,
+		RiskAction:         counters.riskAction,
+		RiskViolations:     counters.riskViolation,
+		CircuitOpened:      counters.circuitOpened,
+		AccountDrift:       counters.accountDrift,
+		NonceRejected:      counters.nonceRejected,
+		KillSwitchActive:   counters.killActive,
+		Paused:             counters.paused,
+		TicksSkipped:       counters.ticksSkipped,
+		FundingAPR:         counters.fundingAPR,
+		PerpSlippageBps:    counters.perpSlippage,
+		SpotSlippageBps:    counters.spotSlippage,
+		IOCPriceBps:        counters.iocPriceBps,
+		OrphanOrders:       counters.orphanOrders,
+		StateTransitions:   counters.transitions,
+		StateSeconds:       counters.stateSeconds,
 	}
 	return m, counters
 }
@@ -1126,6 +1429,7 @@ func newTestMetrics() (*metrics.Metrics, *metricsCounters) {
 type fillServer struct {
 	mu    sync.RWMutex
 	fills map[string]float64
+	mids  map[string]any
 }
 
 func (s *fillServer) handle(w http.ResponseWriter, r *http.Request) {
@@ -1146,6 +1450,11 @@ func (s *fillServer) handle(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, spotCtxPayload())
 	case "openOrders":
 		writeJSON(w, []any{})
+	case "allMids":
+		s.mu.RLock()
+		mids := s.mids
+		s.mu.RUnlock()
+		writeJSON(w, mids)
 	case "userFillsByTime":
 		s.mu.RLock()
 		fills := make([]map[string]any, 0, len(s.fills))
@@ -1209,16 +1518,16 @@ type stubRestClient struct {
 	cancels  []exec.Cancel
 }
 
-func (s *stubRestClient) PlaceOrder(ctx context.Context, order exec.Order) (string, error) {
+func (s *stubRestClient) PlaceOrder(ctx context.Context, order exec.Order) (exec.Placement, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.orders = append(s.orders, order)
 	if len(s.orderIDs) == 0 {
-		return "", errors.New("no order ids available")
+		return exec.Placement{}, errors.New("no order ids available")
 	}
 	orderID := s.orderIDs[0]
 	s.orderIDs = s.orderIDs[1:]
-	return orderID, nil
+	return exec.Placement{OrderID: orderID, Status: exec.PlacementResting}, nil
 }
 
 func (s *stubRestClient) CancelOrder(ctx context.Context, cancel exec.Cancel) error {
@@ -1228,141 +1537,208 @@ func (s *stubRestClient) CancelOrder(ctx context.Context, cancel exec.Cancel) er
 	return nil
 }
 
-type mockInfoServer struct {
-	t *testing.T
-
-	mu              sync.Mutex
-	counts          map[string]int
-	spotBalances    []any
-	accountValue    float64
-	mids            map[string]any
-	fundingRate     string
-	nextFundingTime int64
-	fills           []any
-	server          *httptest.Server
-}
-
-func newMockInfoServer(t *testing.T) *mockInfoServer {
+func newTestMarket(t *testing.T, baseURL string) *market.MarketData {
 	t.Helper()
-	m := &mockInfoServer{
-		t:            t,
-		counts:       make(map[string]int),
-		accountValue: 100,
-		fundingRate:  "0.00001",
-		mids: map[string]any{
-			"ETH":       "3000",
-			"UETH/USDC": "3000",
-		},
-		spotBalances: []any{
-			map[string]any{"coin": "USDC", "total": "100"},
-		},
+	restClient := rest.New(baseURL, 2*time.Second, zap.NewNop())
+	wsClient := ws.New("ws://unused", 10*time.Millisecond, 0, zap.NewNop())
+	marketData := market.New(restClient, wsClient, zap.NewNop())
+	if err := marketData.RefreshContexts(context.Background()); err != nil {
+		t.Fatalf("market refresh: %v", err)
 	}
-	m.server = httptest.NewServer(http.HandlerFunc(m.handle))
-	return m
+	return marketData
 }
 
-func (m *mockInfoServer) Close() {
-	m.server.Close()
+func newTestAccount(t *testing.T, baseURL string) *account.Account {
+	t.Helper()
+	restClient := rest.New(baseURL, 2*time.Second, zap.NewNop())
+	return account.New(restClient, nil, zap.NewNop(), "0xabc")
 }
 
-func (m *mockInfoServer) URL() string {
-	return m.server.URL
-}
+func TestRefreshScheduleCancelWarnsOnceAndRecovers(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	var bodies []map[string]any
+	var mu sync.Mutex
+	mux := http.NewServeMux()
+	mux.HandleFunc("/exchange", func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		bodies = append(bodies, payload)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if fail.Load() {
+			_, _ = w.Write([]byte(`{"status":"err","response":"Cannot set scheduled cancel time until enough volume traded"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
 
-func (m *mockInfoServer) Count(typ string) int {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.counts[typ]
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(srv.URL, 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	core, logs := observer.New(zap.DebugLevel)
+	app := &App{
+		cfg: &config.Config{
+			ScheduleCancel: config.ScheduleCancelConfig{Enabled: true, Window: 30 * time.Second},
+		},
+		log:      zap.New(core),
+		exchange: client,
+	}
+
+	start := time.Now()
+	app.refreshScheduleCancel(context.Background())
+	app.refreshScheduleCancel(context.Background())
+	if got := logs.FilterMessage("schedule cancel refresh failed").Len(); got != 1 {
+		t.Fatalf("expected 1 warning, got %d", got)
+	}
+	fail.Store(false)
+	app.refreshScheduleCancel(context.Background())
+	if got := logs.FilterMessage("schedule cancel refresh recovered").Len(); got != 1 {
+		t.Fatalf("expected 1 recovery log, got %d", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 3 {
+		t.Fatalf("expected 3 exchange requests, got %d", len(bodies))
+	}
+	action, ok := bodies[2]["action"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected action payload, got %#v", bodies[2])
+	}
+	if action["type"] != "scheduleCancel" {
+		t.Fatalf("expected scheduleCancel action, got %v", action["type"])
+	}
+	at, ok := action["time"].(float64)
+	if !ok {
+		t.Fatalf("expected time field, got %#v", action)
+	}
+	if min := float64(start.Add(30 * time.Second).UnixMilli()); at < min {
+		t.Fatalf("expected time >= %.0f, got %.0f", min, at)
+	}
 }
 
-func (m *mockInfoServer) handle(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+func TestLogTickSamplesRepeatsAndKeepsChanges(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	a := &App{
+		cfg: &config.Config{},
+		log: zap.New(core, logging.WithSampling(config.LogSamplingConfig{Debug: 10})),
 	}
-	var payload map[string]any
-	if err := json.Unmarshal(body, &payload); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		return
+	hold := tickPlan{State: strategy.StateHedgeOK, Action: tickActionHold}
+	for i := 0; i < 5; i++ {
+		a.logTick(tickInputs{}, hold, "hold")
 	}
-	typ, _ := payload["type"].(string)
-	if typ == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		return
+	a.logTick(tickInputs{}, tickPlan{State: strategy.StateHedgeOK, Action: tickActionExit}, "exit")
+	a.logTick(tickInputs{}, hold, "hold")
+
+	entries := logs.FilterMessage("tick").All()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 tick logs (first, change, change back), got %d", len(entries))
 	}
-	m.mu.Lock()
-	m.counts[typ]++
-	spotBalances := m.spotBalances
-	accountValue := m.accountValue
-	mids := m.mids
-	fundingRate := m.fundingRate
-	nextFundingTime := m.nextFundingTime
-	fills := m.fills
-	m.mu.Unlock()
-
-	switch typ {
-	case "metaAndAssetCtxs":
-		writeJSON(w, []any{
-			map[string]any{"universe": []any{
-				map[string]any{"name": "ETH", "szDecimals": 3, "index": 1},
-			}},
-			[]any{
-				map[string]any{"funding": fundingRate, "oraclePx": "3000", "markPx": "3000"},
-			},
-		})
-	case "spotMetaAndAssetCtxs", "spotMeta":
-		writeJSON(w, []any{
-			map[string]any{"universe": []any{
-				map[string]any{"name": "UETH/USDC", "index": 51, "base": "UETH", "quote": "USDC", "szDecimals": 3},
-			}},
-		})
-	case "allMids":
-		writeJSON(w, mids)
-	case "predictedFundings":
-		writeJSON(w, []any{
-			[]any{"ETH", []any{
-				[]any{"HlPerp", map[string]any{
-					"fundingRate":          fundingRate,
-					"nextFundingTime":      nextFundingTime,
-					"fundingIntervalHours": 1,
-				}},
-			}},
-		})
-	case "spotClearinghouseState":
-		writeJSON(w, map[string]any{"balances": spotBalances})
-	case "clearinghouseState":
-		writeJSON(w, map[string]any{
-			"assetPositions": []any{},
-			"marginSummary":  map[string]any{"accountValue": accountValue},
-		})
-	case "openOrders":
-		writeJSON(w, []any{})
-	case "userFillsByTime":
-		if fills == nil {
-			fills = []any{}
+	decisions := []string{"hold", "exit", "hold"}
+	for i, entry := range entries {
+		if got := entry.ContextMap()["decision"]; got != decisions[i] {
+			t.Fatalf("entry %d: expected decision %q, got %v", i, decisions[i], got)
 		}
-		writeJSON(w, fills)
-	case "userFunding":
-		writeJSON(w, []any{})
-	default:
-		w.WriteHeader(http.StatusBadRequest)
 	}
 }
 
-func newTestMarket(t *testing.T, baseURL string) *market.MarketData {
-	t.Helper()
-	restClient := rest.New(baseURL, 2*time.Second, zap.NewNop())
-	wsClient := ws.New("ws://unused", 10*time.Millisecond, 0, zap.NewNop())
-	marketData := market.New(restClient, wsClient, zap.NewNop())
-	if err := marketData.RefreshContexts(context.Background()); err != nil {
-		t.Fatalf("market refresh: %v", err)
+func TestWaitForOrderFillUsesOrderUpdatesPush(t *testing.T) {
+	var infoCalls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		infoCalls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "done")
+		for {
+			_, data, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+			if !strings.Contains(string(data), `"orderUpdates"`) {
+				continue
+			}
+			push := `{"channel":"orderUpdates","data":[{"order":{"coin":"BTC","side":"B","limitPx":"30000","sz":"0.0","oid":42,"origSz":"0.1"},"status":"filled","statusTimestamp":1700000000000}]}`
+			if err := conn.Write(r.Context(), websocket.MessageText, []byte(push)); err != nil {
+				return
+			}
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	restClient := rest.New(srv.URL, 2*time.Second, zap.NewNop())
+	wsURL := strings.Replace(srv.URL, "http", "ws", 1) + "/ws"
+	wsClient := ws.New(wsURL, 10*time.Millisecond, 0, zap.NewNop())
+	acct := account.New(restClient, wsClient, zap.NewNop(), "0xabc")
+	if err := acct.Start(ctx); err != nil {
+		t.Fatalf("account start: %v", err)
+	}
+	if !acct.OrderUpdatesEnabled() {
+		t.Fatalf("expected order updates enabled")
+	}
+
+	app := &App{account: acct}
+	filled, open, err := app.waitForOrderFill(ctx, "42", time.Now().UnixMilli(), 5*time.Second, time.Millisecond)
+	if err != nil {
+		t.Fatalf("waitForOrderFill: %v", err)
+	}
+	if open {
+		t.Fatalf("expected open=false")
+	}
+	if math.Abs(filled.Size-0.1) > 1e-9 {
+		t.Fatalf("expected filled=0.1, got %f", filled.Size)
+	}
+	if got := infoCalls.Load(); got != 0 {
+		t.Fatalf("expected no REST polling, got %d info calls", got)
 	}
-	return marketData
 }
 
-func newTestAccount(t *testing.T, baseURL string) *account.Account {
-	t.Helper()
-	restClient := rest.New(baseURL, 2*time.Second, zap.NewNop())
-	return account.New(restClient, nil, zap.NewNop(), "0xabc")
+func TestValuationDivergenceWarnsOnceAndRecovers(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	a := &App{
+		cfg: &config.Config{Risk: config.RiskConfig{MaxMarkOracleDivergence: 0.01}},
+		log: zap.New(core),
+	}
+	in := tickInputs{Snap: strategy.MarketSnapshot{
+		PerpAsset:    "ETH",
+		OraclePrice:  3000,
+		MarkPrice:    3060,
+		SpotBalance:  0.01,
+		PerpPosition: -0.01,
+	}}
+	a.checkValuationDivergence(context.Background(), in)
+	a.checkValuationDivergence(context.Background(), in)
+	if got := logs.FilterMessage("mark/oracle divergence").Len(); got != 1 {
+		t.Fatalf("expected 1 divergence warning, got %d", got)
+	}
+	flat := in
+	flat.Snap.PerpPosition = 0
+	flat.Snap.SpotBalance = 0
+	a.checkValuationDivergence(context.Background(), flat)
+	if got := logs.FilterMessage("mark/oracle divergence recovered").Len(); got != 1 {
+		t.Fatalf("expected recovery log once flat, got %d", got)
+	}
+	in.Snap.MarkPrice = 3010
+	a.checkValuationDivergence(context.Background(), in)
+	if got := logs.FilterMessage("mark/oracle divergence").Len(); got != 1 {
+		t.Fatalf("expected no warning within limit, got %d", got)
+	}
 }
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+const entryBasisKey = "strategy:entry_basis"
+
+// loadEntryBasis restores the basis recorded when the held position was
+// entered, so the basis exit survives restarts.
+func (a *App) loadEntryBasis(ctx context.Context) {
+	if a.store == nil {
+		return
+	}
+	raw, ok, err := a.store.Get(ctx, entryBasisKey)
+	if err != nil {
+		a.logBasisStoreError(err)
+		return
+	}
+	if !ok || raw == "" {
+		return
+	}
+	basis, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		a.logBasisStoreError(fmt.Errorf("parse %s: %w", entryBasisKey, err))
+		return
+	}
+	a.storeEntryBasis(basis, true)
+	if a.log != nil {
+		a.log.Info("loaded entry basis", zap.Float64("entry_basis", basis))
+	}
+}
+
+// observeBasis feeds the tick's basis into the rolling tracker. A hedged
+// position without a recorded entry basis (entered before basis tracking, or
+// the store write failed) adopts the current one.
+func (a *App) observeBasis(ctx context.Context, now time.Time, snap strategy.MarketSnapshot, hedged bool) {
+	basis, ok := strategy.SpotPerpBasis(snap)
+	if !ok {
+		return
+	}
+	a.observeBasisSample(now, basis, a.config().Strategy.BasisWindow)
+	if _, has := a.entryBasis(); hedged && !has {
+		a.setEntryBasis(ctx, basis, true)
+		if a.log != nil {
+			a.log.Info("entry basis adopted from current basis", zap.Float64("entry_basis", basis))
+		}
+	}
+}
+
+// recordEntryBasis stores the basis a new position was entered at: between
+// the legs' average fill prices when both were reported, else the mids.
+func (a *App) recordEntryBasis(ctx context.Context, snap strategy.MarketSnapshot, legs entryLegs) {
+	if legs.SpotAvgPx > 0 && legs.PerpAvgPx > 0 {
+		snap.SpotMidPrice, snap.PerpMidPrice = legs.SpotAvgPx, legs.PerpAvgPx
+	}
+	if basis, ok := strategy.SpotPerpBasis(snap); ok {
+		a.setEntryBasis(ctx, basis, true)
+	}
+}
+
+// clearEntryBasis forgets the entry basis once the position is closed.
+func (a *App) clearEntryBasis(ctx context.Context) {
+	if _, ok := a.entryBasis(); !ok {
+		return
+	}
+	a.setEntryBasis(ctx, 0, false)
+}
+
+func (a *App) setEntryBasis(ctx context.Context, basis float64, ok bool) {
+	a.storeEntryBasis(basis, ok)
+	if a.store == nil {
+		return
+	}
+	raw := ""
+	if ok {
+		raw = strconv.FormatFloat(basis, 'f', -1, 64)
+	}
+	if err := a.store.Set(ctx, entryBasisKey, raw); err != nil {
+		a.logBasisStoreError(err)
+		return
+	}
+	if a.basisStoreWarned && a.log != nil {
+		a.log.Info("entry basis write recovered")
+	}
+	a.basisStoreWarned = false
+}
+
+// basisExit returns strategy.ErrBasisAdverse (wrapped with the move) when the
+// basis has widened past strategy.exit_basis_bps since entry.
+func (a *App) basisExit(in tickInputs) error {
+	if !in.HasBasis || !in.HasEntryBasis {
+		return nil
+	}
+	if err := strategy.CheckBasis(a.config().Strategy.ExitBasisBps, in.EntryBasis, in.Basis); err != nil {
+		return fmt.Errorf("basis %.2f bps above entry exceeds %.2f bps: %w",
+			strategy.BasisAdverseBps(in.EntryBasis, in.Basis), a.config().Strategy.ExitBasisBps, err)
+	}
+	return nil
+}
+
+func (a *App) basisStatus() string {
+	stats, ok := a.basisStats()
+	if !ok {
+		return "basis_bps: n/a"
+	}
+	line := fmt.Sprintf("basis_bps: %.2f (%s mean %.2f min %.2f max %.2f)",
+		stats.Last*10000, a.config().Strategy.BasisWindow, stats.Mean*10000, stats.Min*10000, stats.Max*10000)
+	if entry, ok := a.entryBasis(); ok {
+		line += fmt.Sprintf(" entry %.2f adverse %.2f", entry*10000, strategy.BasisAdverseBps(entry, stats.Last))
+	}
+	return line
+}
+
+func (a *App) logBasisStoreError(err error) {
+	if a.basisStoreWarned || a.log == nil {
+		return
+	}
+	a.basisStoreWarned = true
+	a.log.Warn("entry basis store failed", zap.Error(err))
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+)
+
+func TestBasisExitPlannedWhenBasisWidens(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Minute).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.strategy.State = strategy.StateHedgeOK
+	app.cfg.Strategy.ExitBasisBps = 50
+	app.cfg.Strategy.ExitFundingGuard = 10 * time.Minute
+
+	in := hedgedCompoundInputs(t, app)
+	in.EntryBasis, in.HasEntryBasis = 0.001, true
+	in.Basis, in.HasBasis = 0.005, true
+	if plan := app.evaluateTick(in); plan.Action != tickActionHold || plan.Decision != "hedge_ok" {
+		t.Fatalf("expected hold within the basis limit, got %s/%s", plan.Decision, plan.Action)
+	}
+
+	in.Basis = 0.007
+	plan := app.evaluateTick(in)
+	if plan.Action != tickActionExit || plan.Decision != "exit_basis" || plan.ExitGuarded {
+		t.Fatalf("expected unguarded basis exit, got %s/%s guarded=%t", plan.Decision, plan.Action, plan.ExitGuarded)
+	}
+	if !errors.Is(plan.Err, strategy.ErrBasisAdverse) {
+		t.Fatalf("expected ErrBasisAdverse, got %v", plan.Err)
+	}
+
+	in.HasEntryBasis = false
+	if plan := app.evaluateTick(in); plan.Action != tickActionHold {
+		t.Fatalf("expected no basis exit without an entry basis, got %s", plan.Action)
+	}
+}
+
+func TestEntryBasisPersistsAndAdopts(t *testing.T) {
+	store := &memoryStore{data: make(map[string]string)}
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	app.store = store
+	app.cfg.Strategy.BasisWindow = time.Hour
+	ctx := context.Background()
+	now := time.Now()
+	snap := strategy.MarketSnapshot{SpotMidPrice: 2000, PerpMidPrice: 2004}
+
+	app.observeBasis(ctx, now, snap, false)
+	if app.rt.hasEntryBasis {
+		t.Fatalf("expected no entry basis while flat")
+	}
+	app.observeBasis(ctx, now.Add(time.Minute), snap, true)
+	if !app.rt.hasEntryBasis || app.rt.entryBasis != 0.002 || store.data[entryBasisKey] != "0.002" {
+		t.Fatalf("expected adopted entry basis 0.002, got %f (stored %q)", app.rt.entryBasis, store.data[entryBasisKey])
+	}
+	if stats, ok := app.rt.basis.Stats(); !ok || stats.Samples != 2 {
+		t.Fatalf("expected 2 basis samples, got %+v", stats)
+	}
+
+	restored := &App{cfg: app.cfg, store: store}
+	restored.loadEntryBasis(ctx)
+	if !restored.rt.hasEntryBasis || restored.rt.entryBasis != 0.002 {
+		t.Fatalf("expected restored entry basis, got %f", restored.rt.entryBasis)
+	}
+	restored.clearEntryBasis(ctx)
+	if restored.rt.hasEntryBasis || store.data[entryBasisKey] != "" {
+		t.Fatalf("expected cleared entry basis")
+	}
+	fresh := &App{cfg: app.cfg, store: store}
+	fresh.loadEntryBasis(ctx)
+	if fresh.rt.hasEntryBasis {
+		t.Fatalf("expected no entry basis after clear")
+	}
+}
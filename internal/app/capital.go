@@ -0,0 +1,237 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/events"
+	"hl-carry-bot/internal/logging"
+
+	"go.uber.org/zap"
+)
+
+const externalTransferBuffer = 64
+
+// watchExternalTransfers alerts on deposits and withdrawals made outside the
+// bot and queues a notional re-evaluation for the next tick, until ctx ends.
+func (a *App) watchExternalTransfers(ctx context.Context) {
+	if a.events == nil {
+		return
+	}
+	ch, unsubscribe := a.events.Subscribe(externalTransferBuffer, events.KindExternalTransfer)
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, open := <-ch:
+			if !open {
+				return
+			}
+			if transfer, ok := event.(events.ExternalTransfer); ok {
+				a.noteExternalTransfer(ctx, transfer)
+			}
+		}
+	}
+}
+
+func (a *App) noteExternalTransfer(ctx context.Context, transfer events.ExternalTransfer) {
+	direction := "deposit"
+	if transfer.Amount < 0 {
+		direction = "withdrawal"
+	}
+	if a.log != nil {
+		a.log.Warn("external transfer detected", logging.Unsampled(),
+			zap.String("direction", direction),
+			zap.String("type", transfer.Type),
+			zap.String("asset", transfer.Asset),
+			zap.Float64("amount", transfer.Amount),
+			zap.Float64("usd_value", transfer.USDValue),
+			zap.String("hash", transfer.Hash),
+		)
+	}
+	a.opsMu.Lock()
+	a.capitalChanged = true
+	a.opsMu.Unlock()
+	a.requestTick()
+	if a.alerts == nil {
+		return
+	}
+	msg := fmt.Sprintf("External %s (%s): %.6f %s", direction, transfer.Type, math.Abs(transfer.Amount), transfer.Asset)
+	if transfer.USDValue > 0 && transfer.Asset != "USDC" {
+		msg += fmt.Sprintf(" (~%.2f USD)", transfer.USDValue)
+	}
+	if err := a.alerts.Send(ctx, msg+"; re-evaluating strategy notional"); err != nil && a.log != nil {
+		a.log.Warn("external transfer alert failed", zap.Error(err))
+	}
+}
+
+func (a *App) takeCapitalChange() bool {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+	changed := a.capitalChanged
+	a.capitalChanged = false
+	return changed
+}
+
+// notionalCap reports the equity cap on strategy.notional_usd set by the
+// last re-evaluation, if one applies.
+func (a *App) notionalCap() (float64, bool) {
+	a.opsMu.RLock()
+	defer a.opsMu.RUnlock()
+	return a.notionalCapUSD, a.notionalCapUSD > 0
+}
+
+func (a *App) cappedNotional(notional float64) float64 {
+	if capUSD, ok := a.notionalCap(); ok && capUSD < notional {
+		return capUSD
+	}
+	return notional
+}
+
+// accountEquityUSD is what the account could put into a hedge: spot USDC,
+// the spot leg's holdings at mid, the perp account value, and USDC parked in
+// the vault.
+func (a *App) accountEquityUSD(ctx context.Context, state account.State) float64 {
+	equity := state.SpotBalances["USDC"] + a.vaultParked()
+	if state.HasMarginSummary {
+		equity += state.MarginSummary.AccountValue
+	}
+	spotAsset := a.config().Strategy.SpotAsset
+	if held := a.spotBalanceForAsset(spotAsset, state.SpotBalances); held > 0 {
+		if mid, _, err := a.spotMid(ctx, spotAsset); err == nil {
+			equity += held * mid
+		}
+	}
+	return equity
+}
+
+// capitalPerNotional is the equity one USD of strategy notional ties up: the
+// spot leg in full plus the perp leg's margin at risk.allocation_leverage.
+func (a *App) capitalPerNotional() float64 {
+	leverage := a.riskConfig().AllocationLeverage
+	if leverage < 1 {
+		leverage = 1
+	}
+	return 1 + 1/leverage
+}
+
+// allocatedEquityUSD is this App's share of the account equity. Accounts in
+// multi-account mode that trade the same exchange account split its equity
+// in proportion to their notional.
+func (a *App) allocatedEquityUSD(ctx context.Context, state account.State) float64 {
+	equity := a.accountEquityUSD(ctx, state)
+	if a.equityShare > 0 {
+		equity *= a.equityShare
+	}
+	return equity
+}
+
+// capitalRequirement is the configured notional, the equity it needs with
+// its perp margin, and this App's share of the account equity.
+func (a *App) capitalRequirement(ctx context.Context, state account.State) (notional, required, equity float64) {
+	notional = a.strategyConfig().NotionalUSD
+	return notional, notional * a.capitalPerNotional(), a.allocatedEquityUSD(ctx, state)
+}
+
+// checkCapitalAllocation compares the configured notional plus its perp
+// margin against the account equity at startup. An over-allocated account
+// refuses to start, or with risk.over_allocation scale trades a notional
+// capped to fit until an external transfer re-evaluates it. Read-only only
+// warns.
+func (a *App) checkCapitalAllocation(ctx context.Context, state account.State) error {
+	if a.config() == nil {
+		return nil
+	}
+	notional, required, equity := a.capitalRequirement(ctx, state)
+	if notional <= 0 || required <= equity {
+		return nil
+	}
+	fields := []zap.Field{
+		logging.Unsampled(),
+		zap.Float64("notional_usd", notional),
+		zap.Float64("required_usd", required),
+		zap.Float64("equity_usd", equity),
+	}
+	if a.readOnly() {
+		a.log.Warn("capital check: account is over-allocated", fields...)
+		return nil
+	}
+	if a.riskConfig().OverAllocation != "scale" || equity <= 0 {
+		return fmt.Errorf("capital check: notional %.2f USD needs %.2f USD with perp margin but account equity is %.2f USD (lower strategy.notional_usd or set risk.over_allocation: scale)", notional, required, equity)
+	}
+	capUSD := equity / a.capitalPerNotional()
+	a.opsMu.Lock()
+	a.notionalCapUSD = capUSD
+	a.opsMu.Unlock()
+	a.log.Warn("capital check: scaling strategy notional down to account equity", append(fields, zap.Float64("notional_cap_usd", capUSD))...)
+	if a.alerts == nil {
+		return nil
+	}
+	msg := fmt.Sprintf("Strategy notional scaled down to %.2f USD at startup: %.2f USD configured needs %.2f USD with perp margin, equity is %.2f USD", capUSD, notional, required, equity)
+	if err := a.alerts.Send(ctx, msg); err != nil {
+		a.log.Warn("notional cap alert failed", zap.Error(err))
+	}
+	return nil
+}
+
+// reevaluateCapital runs on the tick after an external transfer. An entry
+// spends notional on the spot leg and margin on the perp leg, so the
+// notional is capped at what the account equity can fund (half of it at
+// allocation_leverage 1) until a later transfer lifts it; a hedge already
+// larger than the cap is reported, and the delta hedge keeps the legs
+// matched.
+func (a *App) reevaluateCapital(ctx context.Context) {
+	cfg := a.config()
+	if a.account == nil || cfg == nil || !a.takeCapitalChange() {
+		return
+	}
+	state, err := a.account.Reconcile(ctx)
+	if err != nil {
+		a.opsMu.Lock()
+		a.capitalChanged = true
+		a.opsMu.Unlock()
+		if a.log != nil {
+			a.log.Warn("capital re-evaluation reconcile failed", zap.Error(err))
+		}
+		return
+	}
+	equity := a.allocatedEquityUSD(ctx, *state)
+	notional := a.strategyConfig().NotionalUSD
+	capUSD := 0.0
+	if fundable := equity / a.capitalPerNotional(); fundable < notional {
+		capUSD = math.Max(fundable, 0)
+	}
+	a.opsMu.Lock()
+	prev := a.notionalCapUSD
+	a.notionalCapUSD = capUSD
+	a.opsMu.Unlock()
+	held := math.Abs(state.PerpPosition[cfg.Strategy.PerpAsset])
+	heldUSD := 0.0
+	if mid, err := a.market.Mid(ctx, cfg.Strategy.PerpAsset); err == nil {
+		heldUSD = held * mid
+	}
+	if a.log != nil {
+		a.log.Info("strategy notional re-evaluated", logging.Unsampled(),
+			zap.Float64("equity_usd", equity),
+			zap.Float64("notional_usd", notional),
+			zap.Float64("notional_cap_usd", capUSD),
+			zap.Float64("hedge_usd", heldUSD),
+		)
+	}
+	if capUSD == prev || a.alerts == nil {
+		return
+	}
+	msg := fmt.Sprintf("Strategy notional cap lifted: equity %.2f USD covers %.2f USD", equity, notional)
+	if capUSD > 0 {
+		msg = fmt.Sprintf("Strategy notional capped at %.2f USD (equity %.2f USD, configured %.2f USD)", capUSD, equity, notional)
+		if heldUSD > capUSD {
+			msg += fmt.Sprintf("; the current hedge of %.2f USD exceeds it and will not be added to", heldUSD)
+		}
+	}
+	if err := a.alerts.Send(ctx, msg); err != nil && a.log != nil {
+		a.log.Warn("notional cap alert failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/events"
+	"hl-carry-bot/internal/hltest"
+)
+
+func TestExternalWithdrawalCapsNotional(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.NotionalUSD = 60
+	ctx := context.Background()
+
+	app.reevaluateCapital(ctx)
+	if _, ok := app.notionalCap(); ok {
+		t.Fatal("expected no re-evaluation without an external transfer")
+	}
+
+	server.SetAccountValue(20)
+	server.SetSpotBalances([]any{map[string]any{"coin": "USDC", "total": "10"}})
+	app.noteExternalTransfer(ctx, events.ExternalTransfer{Type: "withdraw", Asset: "USDC", Amount: -170, USDValue: 170})
+	app.reevaluateCapital(ctx)
+	if capUSD, ok := app.notionalCap(); !ok || capUSD != 15 {
+		t.Fatalf("expected the notional capped at half the 30 USD equity, got %v %v", capUSD, ok)
+	}
+	in, err := app.collectTickInputs(ctx)
+	if err != nil {
+		t.Fatalf("tick inputs: %v", err)
+	}
+	if in.Snap.NotionalUSD != 15 {
+		t.Fatalf("expected entries sized at the cap, got %v", in.Snap.NotionalUSD)
+	}
+	if status := app.operatorStatus(ctx); !strings.Contains(status, "notional_cap_usd: 15.00") {
+		t.Fatalf("expected the cap in /status, got:\n%s", status)
+	}
+
+	server.SetAccountValue(100)
+	server.SetSpotBalances([]any{map[string]any{"coin": "USDC", "total": "100"}})
+	app.noteExternalTransfer(ctx, events.ExternalTransfer{Type: "deposit", Asset: "USDC", Amount: 170, USDValue: 170})
+	app.reevaluateCapital(ctx)
+	if _, ok := app.notionalCap(); ok {
+		t.Fatal("expected a deposit covering the notional to lift the cap")
+	}
+}
+
+func TestStartupCapitalCheckRefusesOrScales(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	ctx := context.Background()
+	state, err := app.account.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	app.cfg.Strategy.NotionalUSD = 100
+	if err := app.checkCapitalAllocation(ctx, *state); err != nil {
+		t.Fatalf("expected 100 USD plus margin to fit 200 USD equity: %v", err)
+	}
+	app.cfg.Strategy.NotionalUSD = 150
+	if err := app.checkCapitalAllocation(ctx, *state); err == nil || !strings.Contains(err.Error(), "needs 300.00 USD") {
+		t.Fatalf("expected an over-allocated start refused, got %v", err)
+	}
+	app.cfg.Risk.AllocationLeverage = 3
+	if err := app.checkCapitalAllocation(ctx, *state); err != nil {
+		t.Fatalf("expected 150 USD at 3x margin to fit: %v", err)
+	}
+
+	app.cfg.Risk.AllocationLeverage = 1
+	app.cfg.Risk.OverAllocation = "scale"
+	app.equityShare = 0.5
+	if err := app.checkCapitalAllocation(ctx, *state); err != nil {
+		t.Fatalf("scale: %v", err)
+	}
+	if capUSD, ok := app.notionalCap(); !ok || capUSD != 50 {
+		t.Fatalf("expected the notional scaled to half the 100 USD equity share, got %v %v", capUSD, ok)
+	}
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hl-carry-bot/internal/exec"
+
+	"go.uber.org/zap"
+)
+
+// onCircuitOpen is the executor's callback when an asset's order circuit
+// opens after repeated failures.
+func (a *App) onCircuitOpen(ctx context.Context, event exec.CircuitEvent) {
+	if a.metrics != nil && a.metrics.CircuitOpened != nil {
+		a.metrics.CircuitOpened.Inc()
+	}
+	if a.log != nil {
+		a.log.Error("order circuit opened; orders on asset blocked",
+			zap.Int("asset", event.Asset),
+			zap.Int("failures", event.Failures),
+			zap.Time("open_until", event.OpenUntil),
+			zap.Error(event.Err),
+		)
+	}
+	if a.alerts != nil {
+		msg := fmt.Sprintf("Order circuit opened for asset %d after %d failed orders (last: %v). Orders blocked until %s.",
+			event.Asset, event.Failures, event.Err, event.OpenUntil.UTC().Format(time.RFC3339))
+		if err := a.alerts.Send(ctx, msg); err != nil && a.log != nil {
+			a.log.Warn("alert send failed", zap.Error(err))
+		}
+	}
+}
+
+// strategyCircuitOpen reports whether either strategy leg's order circuit is
+// open, and the latest time one closes.
+func (a *App) strategyCircuitOpen() (time.Time, bool) {
+	cfg := a.config()
+	if a.executor == nil || a.market == nil || cfg == nil {
+		return time.Time{}, false
+	}
+	var ids []int
+	if id, ok := a.market.PerpAssetID(cfg.Strategy.PerpAsset); ok {
+		ids = append(ids, id)
+	}
+	if spotCtx, err := a.spotContext(cfg.Strategy.SpotAsset); err == nil {
+		if id, ok := a.market.SpotAssetID(spotCtx.Symbol); ok {
+			ids = append(ids, id)
+		}
+	}
+	var until time.Time
+	open := false
+	for _, id := range ids {
+		if at, ok := a.executor.CircuitOpen(id); ok {
+			open = true
+			if at.After(until) {
+				until = at
+			}
+		}
+	}
+	return until, open
+}
+
+// circuitOpenErr is the reason entries and compounding wait on an open
+// circuit.
+func circuitOpenErr(until time.Time) error {
+	return fmt.Errorf("until %s: %w", until.UTC().Format(time.RFC3339), exec.ErrCircuitOpen)
+}
+
+func (a *App) circuitStatus() string {
+	if a.config() == nil || !a.config().CircuitBreaker.EnabledValue() {
+		return "order_circuit: disabled"
+	}
+	if until, open := a.strategyCircuitOpen(); open {
+		return fmt.Sprintf("order_circuit: open until %s", until.UTC().Format(time.RFC3339))
+	}
+	return "order_circuit: closed"
+}
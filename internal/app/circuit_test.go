@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hltest"
+
+	"go.uber.org/zap"
+)
+
+type rejectingRest struct{}
+
+func (rejectingRest) PlaceOrder(context.Context, exec.Order) (exec.Placement, error) {
+	return exec.Placement{}, exec.Permanent(errors.New("insufficient margin"))
+}
+
+func (rejectingRest) CancelOrder(context.Context, exec.Cancel) error {
+	return nil
+}
+
+func TestOpenCircuitBlocksEntry(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+	m, counters := newTestMetrics()
+	app.metrics = m
+	app.executor = exec.New(rejectingRest{}, nil, zap.NewNop())
+	app.executor.SetCircuitBreaker(exec.CircuitBreaker{
+		MaxFailures: 2,
+		Window:      time.Minute,
+		CoolOff:     time.Hour,
+		OnOpen:      app.onCircuitOpen,
+	})
+
+	in, err := app.collectTickInputs(ctx)
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	if in.CircuitOpen {
+		t.Fatalf("expected circuit closed before any failure")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := app.executor.PlaceOrder(ctx, exec.Order{Asset: 1, Size: 0.01, LimitPrice: 3000}); err == nil {
+			t.Fatalf("expected rejected order")
+		}
+	}
+	if counters.circuitOpened.count != 1 {
+		t.Fatalf("expected one circuit open, got %d", counters.circuitOpened.count)
+	}
+
+	in, err = app.collectTickInputs(ctx)
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	if !in.CircuitOpen {
+		t.Fatalf("expected perp circuit to be open")
+	}
+	plan := app.evaluateTick(in)
+	if plan.Decision != "skip_circuit_open" || plan.Action != tickActionHold || !errors.Is(plan.Err, exec.ErrCircuitOpen) {
+		t.Fatalf("expected entry held on open circuit, got %s/%s err=%v", plan.Decision, plan.Action, plan.Err)
+	}
+	if status := app.circuitStatus(); !strings.HasPrefix(status, "order_circuit: open until ") {
+		t.Fatalf("unexpected status %q", status)
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"hl-carry-bot/internal/hl/rest"
+
+	"go.uber.org/zap"
+)
+
+const clockSyncRetry = 30 * time.Second
+
+// refreshClockSync samples the exchange clock every risk.clock_sync_interval.
+// Nonces are drawn from the exchange clock so skew cannot push them outside
+// the exchange's acceptance window; the offset also feeds the clock_drift
+// risk rule, since funding timing runs on the local clock. A failed sample is
+// retried after clockSyncRetry and the last offset stays in use.
+func (a *App) refreshClockSync(ctx context.Context, now time.Time) {
+	cfg := a.config()
+	if cfg == nil || a.rest == nil {
+		return
+	}
+	wait := cfg.Risk.ClockSyncInterval
+	if _, synced := a.clockDrift(); !synced {
+		wait = clockSyncRetry
+	}
+	if !a.clockSyncAttempt.IsZero() && now.Sub(a.clockSyncAttempt) < wait {
+		return
+	}
+	a.clockSyncAttempt = now
+	syncCtx, cancel := context.WithTimeout(rest.WithPriority(ctx, rest.PriorityLow), 3*time.Second)
+	defer cancel()
+	offset, rtt, err := a.rest.ClockOffset(syncCtx)
+	if err != nil {
+		if !a.clockSyncWarned && a.log != nil {
+			a.log.Warn("exchange clock sync failed", zap.Error(err))
+		}
+		a.clockSyncWarned = true
+		return
+	}
+	if a.clockSyncWarned && a.log != nil {
+		a.log.Info("exchange clock sync recovered")
+	}
+	a.clockSyncWarned = false
+	a.rt.mu.Lock()
+	first := !a.rt.hasClockOffset
+	a.rt.clockOffset, a.rt.hasClockOffset = offset, true
+	a.rt.mu.Unlock()
+	if a.exchange != nil {
+		a.exchange.SetClockOffset(offset)
+	}
+	if a.log == nil {
+		return
+	}
+	if first {
+		a.log.Info("exchange clock synced", zap.Duration("offset", offset), zap.Duration("rtt", rtt))
+	}
+	drifted := offset.Abs() > cfg.Risk.MaxClockDrift
+	if drifted && !a.clockDriftWarned {
+		a.log.Warn("local clock drifted from exchange clock",
+			zap.Duration("offset", offset),
+			zap.Duration("rtt", rtt),
+			zap.Duration("max_clock_drift", cfg.Risk.MaxClockDrift),
+		)
+	} else if !drifted && a.clockDriftWarned {
+		a.log.Info("local clock drift recovered", zap.Duration("offset", offset))
+	}
+	a.clockDriftWarned = drifted
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+func TestClockSyncFeedsNoncesAndDriftRule(t *testing.T) {
+	server := hltest.NewServer(t)
+	server.SetNextFundingTime(time.Now().Add(time.Hour).UnixMilli())
+	server.SetClockSkew(-10 * time.Second)
+	app := newNextTestApp(t, server)
+	app.cfg.Risk.MaxClockDrift = 5 * time.Second
+	app.cfg.Risk.ClockSyncInterval = time.Minute
+	app.rest = rest.New(server.URL(), 2*time.Second, zap.NewNop())
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	app.exchange, err = exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("exchange client: %v", err)
+	}
+
+	now := time.Now()
+	ctx := context.Background()
+	app.refreshClockSync(ctx, now)
+	if !app.rt.hasClockOffset || app.rt.clockOffset > -9*time.Second || app.rt.clockOffset < -11*time.Second {
+		t.Fatalf("expected a ~-10s offset, got %s (synced %v)", app.rt.clockOffset, app.rt.hasClockOffset)
+	}
+	if got := app.exchange.ClockOffset(); got != app.rt.clockOffset {
+		t.Fatalf("expected nonces to follow the exchange clock, got offset %s", got)
+	}
+	app.refreshClockSync(ctx, now.Add(30*time.Second))
+	if got := server.Count("exchangeStatus"); got != 1 {
+		t.Fatalf("expected the sync to wait for clock_sync_interval, got %d requests", got)
+	}
+
+	in, err := app.collectTickInputs(ctx)
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	plan := app.evaluateTick(in)
+	if plan.Risk.Action != strategy.RiskActionHalt || !slices.Contains(plan.Risk.Rules(), strategy.RiskRuleClockDrift) {
+		t.Fatalf("expected the clock_drift rule to halt, got %s %v", plan.Risk.Action, plan.Risk.Rules())
+	}
+}
@@ -0,0 +1,251 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/logging"
+	persist "hl-carry-bot/internal/state"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+const (
+	compoundAccruedKey = "compound:accrued_usd"
+	// compoundThroughKey is the time (unix ms) of the newest funding payment
+	// counted in the accrual, so a restart does not count it again.
+	compoundThroughKey = "compound:funding_through"
+)
+
+// loadCompoundAccrual restores the funding received since entry that has not
+// been rolled into the position yet, and the receipt cursor it was counted
+// up to.
+func (a *App) loadCompoundAccrual(ctx context.Context) {
+	if a.config() == nil || !a.config().Compound.Enabled || a.store == nil {
+		return
+	}
+	a.loadCompoundThrough(ctx)
+	raw, ok, err := a.store.Get(ctx, compoundAccruedKey)
+	if err != nil {
+		a.logCompoundStoreError(err)
+		return
+	}
+	if !ok {
+		return
+	}
+	accrued, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		a.logCompoundStoreError(fmt.Errorf("parse %s: %w", compoundAccruedKey, err))
+		return
+	}
+	a.storeCompoundAccrued(accrued)
+	if a.log != nil {
+		a.log.Info("loaded compound accrual", zap.Float64("accrued_usd", accrued))
+	}
+}
+
+// loadCompoundThrough resumes the funding receipt cursor from the last
+// payment the accrual counted.
+func (a *App) loadCompoundThrough(ctx context.Context) {
+	raw, ok, err := a.store.Get(ctx, compoundThroughKey)
+	if err != nil {
+		a.logCompoundStoreError(err)
+		return
+	}
+	if !ok {
+		return
+	}
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		a.logCompoundStoreError(fmt.Errorf("parse %s: %w", compoundThroughKey, err))
+		return
+	}
+	if through := time.UnixMilli(ms).UTC(); through.After(a.lastFundingReceipt()) {
+		a.setLastFundingReceipt(through)
+	}
+}
+
+// accrueCompoundFunding adds funding payments (USDC, signed) to the accrual.
+// The receipt cursor is saved first: a crash between the two writes loses
+// the payments rather than counting them twice.
+func (a *App) accrueCompoundFunding(ctx context.Context, amountUSD float64) {
+	if a.config() == nil || !a.config().Compound.Enabled || amountUSD == 0 {
+		return
+	}
+	if through := a.lastFundingReceipt(); !through.IsZero() && a.store != nil {
+		if err := a.store.Set(ctx, compoundThroughKey, strconv.FormatInt(through.UnixMilli(), 10)); err != nil {
+			a.logCompoundStoreError(err)
+			return
+		}
+	}
+	a.setCompoundAccrual(ctx, a.compoundAccrued()+amountUSD)
+}
+
+// resetCompoundAccrual starts the accrual over for a new position.
+func (a *App) resetCompoundAccrual(ctx context.Context) {
+	if a.config() == nil || !a.config().Compound.Enabled || a.compoundAccrued() == 0 {
+		return
+	}
+	a.setCompoundAccrual(ctx, 0)
+}
+
+func (a *App) setCompoundAccrual(ctx context.Context, accrued float64) {
+	a.storeCompoundAccrued(accrued)
+	if a.store == nil {
+		return
+	}
+	if err := a.store.Set(ctx, compoundAccruedKey, strconv.FormatFloat(accrued, 'f', -1, 64)); err != nil {
+		a.logCompoundStoreError(err)
+		return
+	}
+	a.clearCompoundStoreError()
+}
+
+// compoundDue reports whether a hedged position should grow by one
+// compound.increment_usd add-on: enough funding has accrued and the entry
+// gates still pass.
+func (a *App) compoundDue(strategyCfg config.StrategyConfig, in tickInputs, plan tickPlan) bool {
+	cfg := a.config().Compound
+	if !cfg.Enabled || cfg.IncrementUSD <= 0 || in.CompoundAccruedUSD < cfg.IncrementUSD {
+		return false
+	}
+	if in.EntryCooldownActive || (in.ForeignActivity && a.config().Interference.PauseEntries) {
+		return false
+	}
+	if !plan.FundingOKConfirmed || in.Snap.Volatility > strategyCfg.MaxVolatility {
+		return false
+	}
+	if _, err := a.entryGate(strategyCfg, in); err != nil {
+		return false
+	}
+	held := strategy.Value(in.Snap, in.Risk.ValuationBasis).NotionalUSD
+	if in.Risk.MaxNotionalUSD > 0 && held+cfg.IncrementUSD > in.Risk.MaxNotionalUSD {
+		return false
+	}
+	if capUSD, ok := a.notionalCap(); ok && held+cfg.IncrementUSD > capUSD {
+		return false
+	}
+	return true
+}
+
+// compoundSnapshot sizes an add-on at compound.increment_usd.
+func (a *App) compoundSnapshot(snap strategy.MarketSnapshot) strategy.MarketSnapshot {
+	snap.NotionalUSD = a.config().Compound.IncrementUSD
+	return snap
+}
+
+// compoundPosition places one spot+perp add-on funded by accrued funding and
+// journals it as compound_start followed by compound_filled or
+// compound_failed. The strategy stays hedged either way; a failed add-on is
+// retried after the entry cooldown.
+func (a *App) compoundPosition(ctx context.Context, snap strategy.MarketSnapshot) error {
+	start := time.Now().UTC()
+	accrued := a.compoundAccrued()
+	addSnap := a.compoundSnapshot(snap)
+	attempt := "compound-" + strconv.FormatInt(start.UnixMilli(), 10)
+	event := persist.LifecycleRecord{
+		Attempt:     attempt,
+		PerpAsset:   snap.PerpAsset,
+		SpotAsset:   snap.SpotAsset,
+		NotionalUSD: addSnap.NotionalUSD,
+		FundingUSD:  accrued,
+	}
+	a.recordLifecycle(ctx, event, persist.LifecycleCompoundStart, start)
+	if a.log != nil {
+		a.log.Info("compound add-on started", logging.Unsampled(),
+			zap.String("attempt", attempt),
+			zap.Float64("accrued_usd", accrued),
+			zap.Float64("increment_usd", addSnap.NotionalUSD),
+		)
+	}
+	legs, err := a.placeEntryLegs(ctx, addSnap, nil)
+	if err != nil && legs.SpotFilled == 0 && a.skipOnOrderBudget("compound", err) {
+		event.Detail = err.Error()
+		a.recordLifecycle(ctx, event, persist.LifecycleCompoundFailed, time.Now().UTC())
+		return nil
+	}
+	a.startEntryCooldown(time.Now().UTC())
+	event.SpotFilled = legs.SpotFilled
+	event.PerpFilled = legs.PerpFilled
+	a.noteTradeOutcome(err)
+	if err != nil {
+		event.Detail = err.Error()
+		a.recordLifecycle(ctx, event, persist.LifecycleCompoundFailed, time.Now().UTC())
+		if a.log != nil {
+			a.log.Warn("compound add-on failed", logging.Unsampled(),
+				zap.Error(err),
+				zap.String("attempt", attempt),
+				zap.String("spot_cloid", legs.SpotCloid),
+				zap.String("perp_cloid", legs.PerpCloid),
+				zap.Float64("spot_filled", legs.SpotFilled),
+				zap.Float64("perp_filled", legs.PerpFilled),
+			)
+		}
+		a.reconcileAccount(ctx, "compound")
+		if a.alerts != nil {
+			if alertErr := a.alerts.Send(ctx, fmt.Sprintf("Compound add-on failed for %s/%s: %v", snap.PerpAsset, snap.SpotAsset, err)); alertErr != nil && a.log != nil {
+				a.log.Warn("alert send failed", zap.Error(alertErr))
+			}
+		}
+		return nil
+	}
+	used := legs.PerpFilled * strategy.BasisPrice(snap, strategy.ValuationOracle)
+	if used <= 0 || used > accrued {
+		used = accrued
+	}
+	a.setCompoundAccrual(ctx, accrued-used)
+	a.recordLifecycle(ctx, event, persist.LifecycleCompoundFilled, time.Now().UTC())
+	if a.log != nil {
+		a.log.Info("compounded funding into position", logging.Unsampled(),
+			zap.String("attempt", attempt),
+			zap.String("spot_cloid", legs.SpotCloid),
+			zap.String("perp_cloid", legs.PerpCloid),
+			zap.Float64("spot_filled", legs.SpotFilled),
+			zap.Float64("perp_filled", legs.PerpFilled),
+			zap.Float64("used_usd", used),
+			zap.Float64("accrued_usd", a.compoundAccrued()),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}
+	a.reconcileAccount(ctx, "compound")
+	if a.alerts != nil {
+		if err := a.alerts.Send(ctx, fmt.Sprintf("Compounded %.2f USD of funding into %s/%s: size +%.6f", used, snap.PerpAsset, snap.SpotAsset, legs.PerpFilled)); err != nil && a.log != nil {
+			a.log.Warn("alert send failed", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (a *App) recordLifecycle(ctx context.Context, rec persist.LifecycleRecord, event string, at time.Time) {
+	journal, ok := a.store.(persist.LifecycleJournal)
+	if !ok {
+		return
+	}
+	rec.ID = rec.Attempt + ":" + event
+	rec.Event = event
+	rec.TimeMS = at.UnixMilli()
+	if _, err := journal.RecordLifecycle(ctx, rec); err != nil {
+		a.logCompoundStoreError(err)
+		return
+	}
+	a.clearCompoundStoreError()
+}
+
+func (a *App) logCompoundStoreError(err error) {
+	if a.compoundStoreWarned || a.log == nil {
+		return
+	}
+	a.compoundStoreWarned = true
+	a.log.Warn("compound state write failed", zap.Error(err))
+}
+
+func (a *App) clearCompoundStoreError() {
+	if a.compoundStoreWarned && a.log != nil {
+		a.log.Info("compound state write recovered")
+	}
+	a.compoundStoreWarned = false
+}
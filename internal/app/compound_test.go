@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/market"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+func hedgedCompoundInputs(t *testing.T, app *App) tickInputs {
+	t.Helper()
+	in, err := app.collectTickInputs(context.Background())
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	in.Snap.SpotBalance = 0.01
+	in.Snap.PerpPosition = -0.01
+	in.Flat = false
+	in.FlatStrict = false
+	in.DeltaUSD = 0
+	return in
+}
+
+func TestCompoundPlannedOnceFundingAccrues(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.cfg.Compound = config.CompoundConfig{Enabled: true, IncrementUSD: 20}
+	app.strategy.State = strategy.StateHedgeOK
+
+	in := hedgedCompoundInputs(t, app)
+	in.CompoundAccruedUSD = 12
+	if plan := app.evaluateTick(in); plan.Action != tickActionHold || plan.Decision != "hedge_ok" {
+		t.Fatalf("expected hold below increment, got %s/%s", plan.Decision, plan.Action)
+	}
+
+	in.CompoundAccruedUSD = 21
+	plan := app.evaluateTick(in)
+	if plan.Action != tickActionCompound || plan.Decision != "hedge_ok" {
+		t.Fatalf("expected compound, got %s/%s", plan.Decision, plan.Action)
+	}
+	if len(plan.Orders) != 2 {
+		t.Fatalf("expected 2 add-on orders, got %d", len(plan.Orders))
+	}
+	if spot := plan.Orders[0]; !spot.IsBuy || math.Abs(spot.Size*3000-20) > 1e-9 {
+		t.Fatalf("expected 20 USD spot add-on, got %+v", spot)
+	}
+
+	in.EntryCooldownActive = true
+	if plan := app.evaluateTick(in); plan.Action != tickActionHold {
+		t.Fatalf("expected hold during entry cooldown, got %s", plan.Action)
+	}
+	in.EntryCooldownActive = false
+	in.Risk.MaxNotionalUSD = 40
+	if plan := app.evaluateTick(in); plan.Action != tickActionHold {
+		t.Fatalf("expected hold when add-on would exceed max notional, got %s", plan.Action)
+	}
+	in.Risk.MaxNotionalUSD = 0
+	in.Snap.TradeImbalance = -0.9
+	in.Snap.HasTradeImbalance = true
+	app.cfg.Strategy.MaxSellImbalance = 0.5
+	if plan := app.evaluateTick(in); plan.Action != tickActionHold {
+		t.Fatalf("expected hold when entry gates fail, got %s", plan.Action)
+	}
+}
+
+func TestCompoundAccrualPersists(t *testing.T) {
+	store := &memoryStore{data: make(map[string]string)}
+	cfg := &config.Config{Compound: config.CompoundConfig{Enabled: true, IncrementUSD: 20}}
+	app := &App{cfg: cfg, store: store}
+	ctx := context.Background()
+
+	app.accrueCompoundFunding(ctx, 1.5)
+	app.accrueCompoundFunding(ctx, -0.25)
+	if app.rt.compoundAccruedUSD != 1.25 {
+		t.Fatalf("expected 1.25 accrued, got %f", app.rt.compoundAccruedUSD)
+	}
+	restored := &App{cfg: cfg, store: store}
+	restored.loadCompoundAccrual(ctx)
+	if restored.rt.compoundAccruedUSD != 1.25 {
+		t.Fatalf("expected restored accrual 1.25, got %f", restored.rt.compoundAccruedUSD)
+	}
+	restored.resetCompoundAccrual(ctx)
+	if raw := store.data[compoundAccruedKey]; raw != "0" {
+		t.Fatalf("expected reset accrual persisted, got %q", raw)
+	}
+
+	cfg.Compound.Enabled = false
+	disabled := &App{cfg: cfg, store: store}
+	disabled.accrueCompoundFunding(ctx, 5)
+	if disabled.rt.compoundAccruedUSD != 0 {
+		t.Fatalf("expected no accrual when disabled, got %f", disabled.rt.compoundAccruedUSD)
+	}
+}
+
+func TestCompoundAccrualNotRecountedAfterRestart(t *testing.T) {
+	nextFunding := time.Now().UTC().Add(-10 * time.Minute).Truncate(time.Hour)
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetUserFunding([]any{
+		map[string]any{"time": nextFunding.Add(-time.Hour).UnixMilli(), "delta": map[string]any{"type": "funding", "coin": "ETH", "usdc": "0.5", "fundingRate": "0.0001"}},
+		map[string]any{"time": nextFunding.UnixMilli(), "delta": map[string]any{"type": "funding", "coin": "ETH", "usdc": "0.75", "fundingRate": "0.0001"}},
+	})
+	store := &memoryStore{data: make(map[string]string)}
+	cfg := &config.Config{Compound: config.CompoundConfig{Enabled: true, IncrementUSD: 20}}
+	snap := strategy.MarketSnapshot{PerpAsset: "ETH", PerpPosition: -0.1, OraclePrice: 3000}
+	forecast := market.FundingForecast{HasNext: true, NextFunding: nextFunding, Interval: time.Hour}
+	now := nextFunding.Add(fundingReceiptGrace + time.Second)
+	ctx := context.Background()
+
+	app := &App{cfg: cfg, store: store, account: newTestAccount(t, server.URL()), log: zap.NewNop()}
+	app.maybeLogFundingReceipt(ctx, now, snap, forecast, true)
+	if app.compoundAccrued() != 1.25 {
+		t.Fatalf("expected 1.25 accrued, got %f", app.compoundAccrued())
+	}
+
+	restarted := &App{cfg: cfg, store: store, account: newTestAccount(t, server.URL()), log: zap.NewNop()}
+	restarted.loadCompoundAccrual(ctx)
+	if !restarted.lastFundingReceipt().Equal(nextFunding) {
+		t.Fatalf("expected the receipt cursor restored to %s, got %s", nextFunding, restarted.lastFundingReceipt())
+	}
+	restarted.maybeLogFundingReceipt(ctx, now.Add(time.Minute), snap, forecast, true)
+	if restarted.compoundAccrued() != 1.25 {
+		t.Fatalf("expected the restored accrual not counted again, got %f", restarted.compoundAccrued())
+	}
+}
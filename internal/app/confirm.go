@@ -0,0 +1,188 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/config"
+)
+
+// defaultConfirmWindow mirrors the telegram.confirmation_timeout default for
+// hand-built configs.
+const defaultConfirmWindow = time.Minute
+
+// pendingConfirmation is a risky operator command held until a second
+// message confirms it. Only one is held at a time; a newer request replaces
+// it.
+type pendingConfirmation struct {
+	Action    string
+	Requester operatorMeta
+	ExpiresAt time.Time
+	run       func(ctx context.Context) (string, error)
+}
+
+func (a *App) confirmationRequired() bool {
+	return a.config() != nil && a.config().Telegram.RequireConfirmation
+}
+
+func (a *App) confirmationDistinctUser() bool {
+	return a.config() != nil && a.config().Telegram.ConfirmationDistinctUser
+}
+
+func (a *App) confirmationWindow() time.Duration {
+	cfg := a.config()
+	if cfg != nil && cfg.Telegram.ConfirmationTimeout > 0 {
+		return cfg.Telegram.ConfirmationTimeout
+	}
+	return defaultConfirmWindow
+}
+
+// requestConfirmation holds run until /confirm and tells the operator what
+// confirming does. The request is audited as confirm_request.
+func (a *App) requestConfirmation(ctx context.Context, action, summary string, meta operatorMeta, run func(ctx context.Context) (string, error)) string {
+	now := time.Now().UTC()
+	window := a.confirmationWindow()
+	a.opsMu.Lock()
+	a.confirmPending = &pendingConfirmation{
+		Action:    action,
+		Requester: meta,
+		ExpiresAt: now.Add(window),
+		run:       run,
+	}
+	a.opsMu.Unlock()
+	paused := a.isPaused()
+	a.auditOperatorEvent(ctx, operatorAuditEvent{
+		UpdateID:      meta.UpdateID,
+		Time:          now,
+		Action:        "confirm_request",
+		Command:       meta.Raw,
+		UserID:        meta.UserID,
+		Username:      meta.Username,
+		ChatID:        meta.ChatID,
+		PausedBefore:  paused,
+		PausedAfter:   paused,
+		ConfirmAction: action,
+	})
+	who := "reply /confirm"
+	if a.confirmationDistinctUser() {
+		who = "another operator must reply /confirm"
+	}
+	return fmt.Sprintf("%s; %s within %s or /cancel", summary, who, window)
+}
+
+// confirmPendingCommand runs the held command once meta may confirm it: the
+// requester, or with telegram.confirmation_distinct_user any other allowed
+// user. An empty action confirms whatever is held. A rejected or expired
+// confirmation drops the request, and every outcome is audited.
+func (a *App) confirmPendingCommand(ctx context.Context, action string, meta operatorMeta) (string, error) {
+	now := time.Now().UTC()
+	a.opsMu.Lock()
+	pending := a.confirmPending
+	if pending == nil || (action != "" && pending.Action != action) {
+		a.opsMu.Unlock()
+		return "", noPendingErr(action)
+	}
+	a.confirmPending = nil
+	a.opsMu.Unlock()
+
+	paused := a.isPaused()
+	event := operatorAuditEvent{
+		UpdateID:       meta.UpdateID,
+		Time:           now,
+		Action:         "confirm",
+		Command:        meta.Raw,
+		UserID:         meta.UserID,
+		Username:       meta.Username,
+		ChatID:         meta.ChatID,
+		PausedBefore:   paused,
+		PausedAfter:    paused,
+		ConfirmAction:  pending.Action,
+		RequestedBy:    pending.Requester.UserID,
+		RequestCommand: pending.Requester.Raw,
+	}
+	var err error
+	switch {
+	case now.After(pending.ExpiresAt):
+		event.Action = "confirm_expired"
+		err = noPendingErr(action)
+	case a.confirmationDistinctUser() && meta.UserID == pending.Requester.UserID:
+		event.Action = "confirm_rejected"
+		err = fmt.Errorf("%s must be confirmed by another operator", pending.Action)
+	case !a.confirmationDistinctUser() && meta.UserID != pending.Requester.UserID:
+		event.Action = "confirm_rejected"
+		err = fmt.Errorf("%s must be confirmed by the user who requested it", pending.Action)
+	}
+	if err != nil {
+		event.Error = err.Error()
+		a.auditOperatorEvent(ctx, event)
+		return "", err
+	}
+	a.auditOperatorEvent(ctx, event)
+	return pending.run(ctx)
+}
+
+// cancelPendingCommand drops the held command; an empty action drops
+// whatever is held.
+func (a *App) cancelPendingCommand(ctx context.Context, action string, meta operatorMeta) (string, error) {
+	a.opsMu.Lock()
+	pending := a.confirmPending
+	if pending == nil || (action != "" && pending.Action != action) {
+		a.opsMu.Unlock()
+		return "", noPendingErr(action)
+	}
+	a.confirmPending = nil
+	a.opsMu.Unlock()
+	paused := a.isPaused()
+	a.auditOperatorEvent(ctx, operatorAuditEvent{
+		UpdateID:       meta.UpdateID,
+		Time:           time.Now().UTC(),
+		Action:         "confirm_cancel",
+		Command:        meta.Raw,
+		UserID:         meta.UserID,
+		Username:       meta.Username,
+		ChatID:         meta.ChatID,
+		PausedBefore:   paused,
+		PausedAfter:    paused,
+		ConfirmAction:  pending.Action,
+		RequestedBy:    pending.Requester.UserID,
+		RequestCommand: pending.Requester.Raw,
+	})
+	return pending.Action + " cancelled", nil
+}
+
+func noPendingErr(action string) error {
+	if action == "" {
+		return errors.New("nothing to confirm")
+	}
+	return fmt.Errorf("no pending %s: send /%s first", action, action)
+}
+
+// riskLoosened lists the /risk keys whose limit next relaxes against cur. A
+// zero maximum disables its check, so clearing a set maximum loosens it.
+func riskLoosened(next, cur config.RiskConfig) []string {
+	var keys []string
+	raised := func(key string, next, cur float64) {
+		if cur > 0 && (next <= 0 || next > cur) {
+			keys = append(keys, key)
+		}
+	}
+	lowered := func(key string, next, cur float64) {
+		if next < cur {
+			keys = append(keys, key)
+		}
+	}
+	raised("max_notional_usd", next.MaxNotionalUSD, cur.MaxNotionalUSD)
+	raised("max_open_orders", float64(next.MaxOpenOrders), float64(cur.MaxOpenOrders))
+	lowered("min_margin_ratio", next.MinMarginRatio, cur.MinMarginRatio)
+	lowered("min_health_ratio", next.MinHealthRatio, cur.MinHealthRatio)
+	raised("max_market_age", next.MaxMarketAge.Seconds(), cur.MaxMarketAge.Seconds())
+	raised("max_account_age", next.MaxAccountAge.Seconds(), cur.MaxAccountAge.Seconds())
+	return keys
+}
+
+func looserRiskSummary(keys []string) string {
+	return "this loosens risk limits (" + strings.Join(keys, ", ") + ")"
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+)
+
+func TestLooserRiskSetNeedsConfirmationFromAnotherUser(t *testing.T) {
+	store := &memoryStore{data: make(map[string]string)}
+	cfg := &config.Config{
+		Risk: config.RiskConfig{MaxNotionalUSD: 100, MaxOpenOrders: 5},
+		Telegram: config.TelegramConfig{
+			RequireConfirmation:      true,
+			ConfirmationTimeout:      time.Minute,
+			ConfirmationDistinctUser: true,
+		},
+	}
+	app := &App{cfg: cfg, store: store}
+	ctx := context.Background()
+	alice := operatorMeta{UserID: 1, ChatID: 2, Raw: "/risk set max_notional_usd=50"}
+	bob := operatorMeta{UserID: 2, ChatID: 2, Raw: "/confirm"}
+
+	if resp, err := app.handleOperatorCommand(ctx, "risk", []string{"set", "max_notional_usd=50"}, alice); err != nil || resp != "risk override updated" {
+		t.Fatalf("expected a tighter limit applied at once, got %q (err=%v)", resp, err)
+	}
+
+	alice.Raw = "/risk set max_notional_usd=200 max_open_orders=3"
+	resp, err := app.handleOperatorCommand(ctx, "risk", []string{"set", "max_notional_usd=200", "max_open_orders=3"}, alice)
+	if err != nil || !strings.Contains(resp, "(max_notional_usd)") || !strings.Contains(resp, "another operator") {
+		t.Fatalf("expected a looser limit held for confirmation, got %q (err=%v)", resp, err)
+	}
+	if got := app.riskConfig().MaxNotionalUSD; got != 50 {
+		t.Fatalf("expected the held change not applied, got %f", got)
+	}
+	if _, err := app.handleOperatorCommand(ctx, "confirm", nil, operatorMeta{UserID: 1, Raw: "/confirm"}); err == nil {
+		t.Fatal("expected the requester's own confirmation rejected")
+	}
+	if _, err := app.handleOperatorCommand(ctx, "confirm", nil, bob); err == nil {
+		t.Fatal("expected the rejected request dropped")
+	}
+
+	if _, err := app.handleOperatorCommand(ctx, "risk", []string{"set", "max_notional_usd=200", "max_open_orders=3"}, alice); err != nil {
+		t.Fatalf("risk set: %v", err)
+	}
+	if resp, err := app.handleOperatorCommand(ctx, "confirm", nil, bob); err != nil || resp != "risk override updated" {
+		t.Fatalf("expected the confirmed change applied, got %q (err=%v)", resp, err)
+	}
+	if risk := app.riskConfig(); risk.MaxNotionalUSD != 200 || risk.MaxOpenOrders != 3 {
+		t.Fatalf("unexpected risk after confirm %+v", risk)
+	}
+	actions := map[string]int{}
+	for key, val := range store.data {
+		if !strings.HasPrefix(key, "ops:audit:") {
+			continue
+		}
+		for _, action := range []string{"confirm_request", "confirm_rejected", "confirm", "risk_set"} {
+			if strings.Contains(val, `"action":"`+action+`"`) {
+				actions[action]++
+			}
+		}
+		if strings.Contains(val, `"action":"confirm"`) && !strings.Contains(val, `"requested_by":1`) {
+			t.Fatalf("expected the confirmation to name the requester: %s", val)
+		}
+	}
+	if actions["confirm_request"] != 2 || actions["confirm_rejected"] != 1 || actions["confirm"] != 1 || actions["risk_set"] != 2 {
+		t.Fatalf("unexpected audit trail %v", actions)
+	}
+}
+
+func TestResumeAfterLossHaltNeedsConfirmation(t *testing.T) {
+	store := &memoryStore{data: make(map[string]string)}
+	cfg := &config.Config{Telegram: config.TelegramConfig{RequireConfirmation: true}}
+	app := &App{cfg: cfg, store: store}
+	ctx := context.Background()
+	meta := operatorMeta{UserID: 1, ChatID: 2, Raw: "/resume"}
+
+	app.setPaused(true)
+	if resp, err := app.handleOperatorCommand(ctx, "resume", nil, meta); err != nil || resp != "trading resumed" {
+		t.Fatalf("expected a plain resume without a halt, got %q (err=%v)", resp, err)
+	}
+
+	app.setPaused(true)
+	app.lossHalt = &lossHaltRecord{WindowStart: time.Now().UTC()}
+	if _, err := app.handleOperatorCommand(ctx, "resume", nil, meta); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if !app.isPaused() || !app.lossHaltActive() {
+		t.Fatal("expected the halt kept until confirmed")
+	}
+	if resp, err := app.handleOperatorCommand(ctx, "cancel", nil, meta); err != nil || resp != "resume cancelled" {
+		t.Fatalf("cancel: %q (err=%v)", resp, err)
+	}
+
+	if _, err := app.handleOperatorCommand(ctx, "resume", nil, meta); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	app.confirmPending.ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := app.handleOperatorCommand(ctx, "confirm", nil, meta); err == nil {
+		t.Fatal("expected an expired confirmation rejected")
+	}
+
+	if _, err := app.handleOperatorCommand(ctx, "resume", nil, meta); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if resp, err := app.handleOperatorCommand(ctx, "confirm", nil, meta); err != nil || resp != "trading resumed" {
+		t.Fatalf("confirm: %q (err=%v)", resp, err)
+	}
+	if app.isPaused() || app.lossHaltActive() {
+		t.Fatal("expected the confirmed resume to acknowledge the halt")
+	}
+}
@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"math"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/precision"
+	"hl-carry-bot/internal/logging"
+
+	"go.uber.org/zap"
+)
+
+// crashStop is the reduce-only stop-market order resting on the perp leg.
+type crashStop struct {
+	Asset     int
+	OrderID   string
+	Size      float64
+	TriggerPx float64
+}
+
+// planCrashStop prices a stop that closes position if the perp moves
+// stopBps against it from mid: a short is bought back above mid, a long
+// sold below. limit sits slippageBps past the trigger.
+func planCrashStop(mid, position, stopBps, slippageBps float64, szDecimals int) (trigger, limit float64, isBuy bool) {
+	if mid <= 0 || position == 0 || stopBps <= 0 {
+		return 0, 0, false
+	}
+	isBuy = position < 0
+	move := stopBps / 10000
+	if isBuy {
+		trigger = mid * (1 + move)
+	} else {
+		trigger = mid * (1 - move)
+	}
+	return precision.LimitPrice(trigger, false, szDecimals),
+		limitPriceWithOffset(trigger, isBuy, false, szDecimals, slippageBps),
+		isBuy
+}
+
+// ensureCrashStop keeps one crash stop sized to the perp position while
+// risk.crash_stop_bps is set. A stop whose size no longer matches the
+// position (compounding, rebalances) is replaced at the current mid.
+func (a *App) ensureCrashStop(ctx context.Context, perpAsset string, position, mid float64) {
+	cfg := a.config()
+	if cfg == nil || cfg.Risk.CrashStopBps <= 0 || a.executor == nil || a.market == nil {
+		return
+	}
+	perpCtx, ok := a.market.PerpContext(perpAsset)
+	if !ok {
+		return
+	}
+	size := math.Abs(position)
+	size = perpCtx.Increments.RoundSize(size)
+	if size <= 0 || a.exposureBelowThreshold(size, mid) {
+		a.cancelCrashStop(ctx)
+		return
+	}
+	if a.crashStop != nil {
+		if math.Abs(a.crashStop.Size-size) <= flatEpsilon {
+			return
+		}
+		a.cancelCrashStop(ctx)
+	}
+	trigger, limit, isBuy := planCrashStop(mid, position, cfg.Risk.CrashStopBps, cfg.Risk.CrashStopSlippageBps, perpCtx.SzDecimals)
+	if trigger <= 0 {
+		return
+	}
+	cloid, err := newCloid()
+	if err != nil {
+		return
+	}
+	orderID, err := a.executor.PlaceOrder(ctx, exec.Order{
+		Asset:         perpCtx.Index,
+		IsBuy:         isBuy,
+		Size:          size,
+		LimitPrice:    limit,
+		ReduceOnly:    true,
+		ClientOrderID: cloid,
+		TriggerPrice:  trigger,
+		Tpsl:          string(exchange.TpslStopLoss),
+	})
+	if err != nil {
+		if a.metrics != nil {
+			a.metrics.OrdersFailed.Inc()
+		}
+		if !a.crashStopWarned && a.log != nil {
+			a.log.Warn("crash stop placement failed", logging.Unsampled(), zap.Error(err), zap.Float64("trigger_px", trigger))
+		}
+		a.crashStopWarned = true
+		return
+	}
+	if a.crashStopWarned && a.log != nil {
+		a.log.Info("crash stop placement recovered")
+	}
+	a.crashStopWarned = false
+	a.countOrderPlaced()
+	a.setCrashStop(&crashStop{Asset: perpCtx.Index, OrderID: orderID, Size: size, TriggerPx: trigger})
+	if a.log != nil {
+		a.log.Info("crash stop placed", logging.Unsampled(),
+			zap.String("perp_asset", perpAsset),
+			zap.String("cloid", cloid),
+			zap.String("order_id", orderID),
+			zap.Float64("size", size),
+			zap.Float64("trigger_px", trigger),
+			zap.Float64("limit", limit),
+		)
+	}
+}
+
+// cancelCrashStop pulls the tracked crash stop. A stop that already fired
+// or was cancelled exchange-side fails the cancel harmlessly.
+func (a *App) cancelCrashStop(ctx context.Context) {
+	if a.crashStop == nil {
+		return
+	}
+	stop := a.crashStop
+	a.setCrashStop(nil)
+	if a.executor == nil {
+		return
+	}
+	if err := a.executor.CancelOrder(ctx, exec.Cancel{Asset: stop.Asset, OrderID: stop.OrderID}); err != nil {
+		if a.log != nil {
+			a.log.Debug("crash stop cancel failed", zap.String("order_id", stop.OrderID), zap.Error(err))
+		}
+		return
+	}
+	if a.log != nil {
+		a.log.Info("crash stop cancelled", zap.String("order_id", stop.OrderID))
+	}
+}
+
+// setCrashStop tracks stop as the resting crash stop, nil once it is gone,
+// and publishes its oid for readers off the strategy loop.
+func (a *App) setCrashStop(stop *crashStop) {
+	a.crashStop = stop
+	orderID := ""
+	if stop != nil {
+		orderID = stop.OrderID
+	}
+	a.rt.mu.Lock()
+	a.rt.crashStopOrderID = orderID
+	a.rt.mu.Unlock()
+}
+
+// withoutCrashStop drops the crash stop from orders so connectivity pulls
+// leave it resting, since a blind perp leg is exactly when it is needed, and
+// so it does not count as a working order in the tick snapshot.
+func (a *App) withoutCrashStop(orders []map[string]any) []map[string]any {
+	stopID := a.crashStopOrderID()
+	if stopID == "" {
+		return orders
+	}
+	out := make([]map[string]any, 0, len(orders))
+	for _, order := range orders {
+		refs := account.OpenOrderRefs([]map[string]any{order})
+		if len(refs) == 1 && refs[0].OrderID == stopID {
+			continue
+		}
+		out = append(out, order)
+	}
+	return out
+}
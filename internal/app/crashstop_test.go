@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+func TestPlanCrashStop(t *testing.T) {
+	trigger, limit, isBuy := planCrashStop(3000, -0.5, 1000, 500, 3)
+	if !isBuy || trigger != 3300 || limit != 3465 {
+		t.Fatalf("unexpected short stop: trigger %f limit %f buy %t", trigger, limit, isBuy)
+	}
+	trigger, limit, isBuy = planCrashStop(3000, 0.5, 1000, 500, 3)
+	if isBuy || trigger != 2700 || limit != 2565 {
+		t.Fatalf("unexpected long stop: trigger %f limit %f buy %t", trigger, limit, isBuy)
+	}
+	if trigger, _, _ := planCrashStop(3000, -0.5, 0, 500, 3); trigger != 0 {
+		t.Fatalf("expected no stop when disabled, got %f", trigger)
+	}
+}
+
+func TestEnsureCrashStopFollowsPosition(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+	stub := &stubRestClient{orderIDs: []string{"101", "102"}}
+	app.executor = exec.New(stub, nil, zap.NewNop())
+	app.cfg.Risk.CrashStopBps = 1000
+	app.cfg.Risk.CrashStopSlippageBps = 500
+
+	app.ensureCrashStop(ctx, "ETH", -0.01, 3000)
+	app.ensureCrashStop(ctx, "ETH", -0.01, 3050)
+	if len(stub.orders) != 1 {
+		t.Fatalf("expected one stop for an unchanged position, got %d", len(stub.orders))
+	}
+	order := stub.orders[0]
+	if order.Asset != 1 || !order.IsBuy || !order.ReduceOnly || order.Size != 0.01 || order.TriggerPrice != 3300 || order.Tpsl != "sl" {
+		t.Fatalf("unexpected stop order: %+v", order)
+	}
+
+	app.ensureCrashStop(ctx, "ETH", -0.02, 3000)
+	if len(stub.orders) != 2 || len(stub.cancels) != 1 || stub.cancels[0].OrderID != "101" {
+		t.Fatalf("expected resized stop to replace the old one, got %d orders %d cancels", len(stub.orders), len(stub.cancels))
+	}
+	if app.crashStop == nil || app.crashStop.OrderID != "102" || app.crashStop.Size != 0.02 {
+		t.Fatalf("unexpected tracked stop: %+v", app.crashStop)
+	}
+
+	app.ensureCrashStop(ctx, "ETH", 0, 3000)
+	if app.crashStop != nil || len(stub.cancels) != 2 {
+		t.Fatalf("expected stop cancelled once flat")
+	}
+}
+
+func TestWithoutCrashStopKeepsStopResting(t *testing.T) {
+	app := &App{}
+	app.setCrashStop(&crashStop{Asset: 1, OrderID: "101"})
+	orders := []map[string]any{
+		{"coin": "ETH", "oid": 101},
+		{"coin": "ETH", "oid": 102},
+	}
+	got := app.withoutCrashStop(orders)
+	if len(got) != 1 || got[0]["oid"] != 102 {
+		t.Fatalf("expected only the non-stop order, got %v", got)
+	}
+}
+
+func TestCrashStopDoesNotBlockDeltaHedge(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	app.executor = exec.New(&exchangeAdapter{client: client, tif: exchange.TifGtc, log: zap.NewNop()}, nil, zap.NewNop())
+	ctx := context.Background()
+	orderID, err := app.executor.PlaceOrder(ctx, exec.Order{Asset: hltest.PerpAsset, IsBuy: true, Size: 0.05, LimitPrice: 2000, ReduceOnly: true})
+	if err != nil {
+		t.Fatalf("place stop: %v", err)
+	}
+	app.setCrashStop(&crashStop{Asset: hltest.PerpAsset, OrderID: orderID, Size: 0.05})
+	if _, err := app.account.Reconcile(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	app.strategy.State = strategy.StateHedgeOK
+
+	in := hedgedCompoundInputs(t, app)
+	if in.Snap.OpenOrderCount != 0 || len(in.OpenOrders) != 1 {
+		t.Fatalf("expected the resting crash stop left out of the order count, got %d of %d", in.Snap.OpenOrderCount, len(in.OpenOrders))
+	}
+	// 0.05 ETH long of delta at 3000 is far outside the 5 USD band.
+	in.Snap.SpotBalance = 0.1
+	in.Snap.PerpPosition = -0.05
+	in.DeltaUSD = 150
+	plan := app.evaluateTick(in)
+	if plan.Action != tickActionHedge || len(plan.Orders) != 1 || plan.Orders[0].IsBuy {
+		t.Fatalf("expected a delta hedge with the crash stop resting, got %s/%s", plan.Decision, plan.Action)
+	}
+
+	app.setCrashStop(nil)
+	if in := hedgedCompoundInputs(t, app); in.Snap.OpenOrderCount != 1 {
+		t.Fatalf("expected an untracked resting order counted, got %d", in.Snap.OpenOrderCount)
+	}
+}
@@ -0,0 +1,308 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+const (
+	dailyBaselineKey = "risk:daily_pnl_baseline"
+	lossHaltKey      = "risk:daily_loss_halt"
+)
+
+// lossHaltRecord latches a daily loss halt. It stays active until /resume
+// acknowledges it; an acknowledged halt does not re-fire within its window.
+type lossHaltRecord struct {
+	WindowStart time.Time `json:"window_start"`
+	Acked       bool      `json:"acked"`
+}
+
+// loadDailyPnL restores the day's PnL baseline and any unacknowledged loss
+// halt, which re-pauses trading after a restart.
+func (a *App) loadDailyPnL(ctx context.Context) {
+	if a.store == nil {
+		return
+	}
+	var base strategy.DailyPnLBaseline
+	if ok := a.loadDailyRecord(ctx, dailyBaselineKey, &base); ok {
+		a.startDailyWindow(&base)
+	}
+	var halt lossHaltRecord
+	if ok := a.loadDailyRecord(ctx, lossHaltKey, &halt); ok {
+		a.lossHalt = &halt
+		if !halt.Acked {
+			a.setPaused(true)
+			if a.log != nil {
+				a.log.Warn("daily loss halt restored; trading paused until /resume", zap.Time("window_start", halt.WindowStart))
+			}
+		}
+	}
+}
+
+func (a *App) loadDailyRecord(ctx context.Context, key string, out any) bool {
+	raw, ok, err := a.store.Get(ctx, key)
+	if err != nil {
+		a.logDailyStoreError(err)
+		return false
+	}
+	if !ok || raw == "" {
+		return false
+	}
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		a.logDailyStoreError(fmt.Errorf("parse %s: %w", key, err))
+		return false
+	}
+	return true
+}
+
+func (a *App) saveDailyRecord(ctx context.Context, key string, value any) {
+	if a.store == nil {
+		return
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		a.logDailyStoreError(err)
+		return
+	}
+	if err := a.store.Set(ctx, key, string(raw)); err != nil {
+		a.logDailyStoreError(err)
+		return
+	}
+	if a.dailyStoreWarned && a.log != nil {
+		a.log.Info("daily pnl store write recovered")
+	}
+	a.dailyStoreWarned = false
+}
+
+// dailyPnL is the PnL of the current day, or false until the day's baseline
+// is set and its fills have been fetched at least once.
+func (a *App) dailyPnL(now time.Time, snap strategy.MarketSnapshot) (strategy.DailyPnL, bool) {
+	a.rt.mu.RLock()
+	base, fills, fundingUSD, fetched := a.rt.dailyBase, a.rt.dailyFills, a.rt.dailyFundingUSD, a.rt.hasDailyFetch
+	a.rt.mu.RUnlock()
+	if base == nil || !fetched || snap.SpotMidPrice <= 0 || snap.PerpMidPrice <= 0 {
+		return strategy.DailyPnL{}, false
+	}
+	if !base.WindowStart.Equal(strategy.DailyWindowStart(now, a.config().Risk.DailyResetHour)) {
+		return strategy.DailyPnL{}, false
+	}
+	return strategy.ComputeDailyPnL(*base, snap, fills, fundingUSD), true
+}
+
+// refreshDailyPnL marks the baseline on the first tick of each PnL day and
+// refetches the day's strategy fills and funding every
+// risk.daily_pnl_refresh. It is a no-op unless risk.max_daily_loss_usd is
+// set.
+func (a *App) refreshDailyPnL(ctx context.Context, in tickInputs) {
+	cfg := a.config()
+	if cfg == nil || a.account == nil || cfg.Risk.MaxDailyLossUSD <= 0 {
+		return
+	}
+	window := strategy.DailyWindowStart(in.Now, cfg.Risk.DailyResetHour)
+	if base := a.dailyBaseline(); base == nil || !base.WindowStart.Equal(window) {
+		snap := in.Snap
+		if snap.SpotMidPrice <= 0 || snap.PerpMidPrice <= 0 {
+			return
+		}
+		base = &strategy.DailyPnLBaseline{
+			WindowStart:  window,
+			ObservedAt:   in.Now,
+			SpotBalance:  snap.SpotBalance,
+			PerpPosition: snap.PerpPosition,
+			SpotMid:      snap.SpotMidPrice,
+			PerpMid:      snap.PerpMidPrice,
+		}
+		a.startDailyWindow(base)
+		a.saveDailyRecord(ctx, dailyBaselineKey, base)
+		if a.log != nil {
+			a.log.Info("daily pnl window started", zap.Time("window_start", window))
+		}
+	}
+	a.rt.mu.RLock()
+	fetched := a.rt.hasDailyFetch
+	a.rt.mu.RUnlock()
+	if fetched && in.Now.Sub(a.dailyFetchedAt) < cfg.Risk.DailyPnLRefresh {
+		return
+	}
+	if err := a.fetchDailyFlows(ctx); err != nil {
+		if !a.dailyPnLWarned && a.log != nil {
+			a.log.Warn("daily pnl fetch failed; daily loss limit uses the last fetch", zap.Error(err))
+		}
+		a.dailyPnLWarned = true
+		return
+	}
+	if a.dailyPnLWarned && a.log != nil {
+		a.log.Info("daily pnl fetch recovered")
+	}
+	a.dailyPnLWarned = false
+	a.dailyFetchedAt = in.Now
+}
+
+// fetchDailyFlows loads the fills on both strategy legs and the perp funding
+// since the baseline was observed.
+func (a *App) fetchDailyFlows(ctx context.Context) error {
+	fills, payments, err := a.strategyFlows(ctx, a.dailyBaseline().ObservedAt.UnixMilli())
+	if err != nil {
+		return err
+	}
+	a.storeDailyFlows(pnlFills(fills), fundingTotal(payments))
+	return nil
+}
+
+// strategyFlows fetches the fills on both strategy legs and the perp funding
+// payments since startMS.
+func (a *App) strategyFlows(ctx context.Context, startMS int64) ([]account.Fill, []account.FundingPayment, error) {
+	cfg := a.config()
+	ctx = rest.WithPriority(ctx, rest.PriorityLow)
+	fills, err := a.account.UserFillsByTime(ctx, startMS, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	payments, err := a.account.UserFunding(ctx, startMS)
+	if err != nil {
+		return nil, nil, err
+	}
+	legs := map[string]bool{cfg.Strategy.PerpAsset: true}
+	if spotCtx, err := a.spotContext(cfg.Strategy.SpotAsset); err == nil {
+		legs[spotCtx.Symbol] = spotCtx.Symbol != ""
+		legs[spotCtx.MidKey] = spotCtx.MidKey != ""
+		// Spot fills name the pair by index.
+		legs[fmt.Sprintf("@%d", spotCtx.Index)] = true
+	}
+	legFills := make([]account.Fill, 0, len(fills))
+	for _, fill := range fills {
+		if !legs[fill.Asset] || fill.TimeMS < startMS {
+			continue
+		}
+		legFills = append(legFills, fill)
+	}
+	perpPayments := make([]account.FundingPayment, 0, len(payments))
+	for _, payment := range payments {
+		if payment.Asset != cfg.Strategy.PerpAsset || !payment.HasAmount {
+			continue
+		}
+		if payment.HasTime && payment.Time.UnixMilli() < startMS {
+			continue
+		}
+		perpPayments = append(perpPayments, payment)
+	}
+	return legFills, perpPayments, nil
+}
+
+func pnlFills(fills []account.Fill) []strategy.PnLFill {
+	out := make([]strategy.PnLFill, 0, len(fills))
+	for _, fill := range fills {
+		out = append(out, strategy.PnLFill{
+			IsBuy:      fill.Side == "B",
+			Size:       fill.Size,
+			Price:      fill.Price,
+			Fee:        fill.FeeUSD(),
+			FeeInAsset: fill.FeeInAsset(),
+			ClosedPnL:  fill.ClosedPnL,
+		})
+	}
+	return out
+}
+
+func fundingTotal(payments []account.FundingPayment) float64 {
+	total := 0.0
+	for _, payment := range payments {
+		total += payment.Amount
+	}
+	return total
+}
+
+// lossHaltActive reports an unacknowledged daily loss halt.
+func (a *App) lossHaltActive() bool {
+	return a.lossHalt != nil && !a.lossHalt.Acked
+}
+
+// observeDailyPnL keeps the tick's PnL for /status and latches the halt the
+// first time the daily_loss rule fires in a window: trading pauses as with
+// /pause, the position is flattened by the next plan, and the operator must
+// /resume.
+func (a *App) observeDailyPnL(ctx context.Context, in tickInputs, risk strategy.RiskAssessment) {
+	cfg := a.config()
+	a.setLastDailyPnL(in.DailyPnL, in.HasDailyPnL)
+	fired := false
+	for _, v := range risk.Violations {
+		if v.Rule == strategy.RiskRuleDailyLoss {
+			fired = true
+			break
+		}
+	}
+	if !fired {
+		return
+	}
+	window := strategy.DailyWindowStart(in.Now, cfg.Risk.DailyResetHour)
+	if a.lossHalt != nil && a.lossHalt.WindowStart.Equal(window) {
+		return
+	}
+	a.lossHalt = &lossHaltRecord{WindowStart: window}
+	a.saveDailyRecord(ctx, lossHaltKey, a.lossHalt)
+	a.setPaused(true)
+	if a.log != nil {
+		a.log.Error("daily loss limit breached; flattening and pausing",
+			zap.Float64("daily_pnl_usd", in.DailyPnL.TotalUSD()),
+			zap.Float64("realized_usd", in.DailyPnL.RealizedUSD),
+			zap.Float64("unrealized_usd", in.DailyPnL.UnrealizedUSD),
+			zap.Float64("max_daily_loss_usd", cfg.Risk.MaxDailyLossUSD),
+		)
+	}
+	if a.alerts != nil {
+		msg := fmt.Sprintf("Daily loss limit breached: PnL %.2f USD (realized %.2f, unrealized %.2f) exceeds -%.2f. Flattening and pausing; send /resume to trade again.",
+			in.DailyPnL.TotalUSD(), in.DailyPnL.RealizedUSD, in.DailyPnL.UnrealizedUSD, cfg.Risk.MaxDailyLossUSD)
+		if err := a.alerts.Send(ctx, msg); err != nil && a.log != nil {
+			a.log.Warn("alert send failed", zap.Error(err))
+		}
+	}
+}
+
+// acknowledgeLossHalt clears an active halt on /resume.
+func (a *App) acknowledgeLossHalt(ctx context.Context) {
+	if !a.lossHaltActive() {
+		return
+	}
+	a.lossHalt.Acked = true
+	a.saveDailyRecord(ctx, lossHaltKey, a.lossHalt)
+	if a.log != nil {
+		a.log.Info("daily loss halt acknowledged", zap.Time("window_start", a.lossHalt.WindowStart))
+	}
+}
+
+// lossHaltErr is the reason a halted tick flattens once the daily_loss rule
+// itself has stopped firing.
+func lossHaltErr() error {
+	return fmt.Errorf("trading halted until /resume: %w", strategy.ErrDailyLoss)
+}
+
+func (a *App) dailyPnLStatus() string {
+	if a.config().Risk.MaxDailyLossUSD <= 0 {
+		return "daily_pnl: disabled"
+	}
+	line := "daily_pnl: n/a"
+	if pnl, ok := a.lastDailyPnL(); ok {
+		if base := a.dailyBaseline(); base != nil {
+			line = fmt.Sprintf("daily_pnl: %.2f (realized %.2f unrealized %.2f) since %s",
+				pnl.TotalUSD(), pnl.RealizedUSD, pnl.UnrealizedUSD, base.WindowStart.Format(time.RFC3339))
+		}
+	}
+	line += fmt.Sprintf(" limit -%.2f halted %t", a.config().Risk.MaxDailyLossUSD, a.lossHaltActive())
+	return line
+}
+
+func (a *App) logDailyStoreError(err error) {
+	if a.dailyStoreWarned || a.log == nil {
+		return
+	}
+	a.dailyStoreWarned = true
+	a.log.Warn("daily pnl store failed", zap.Error(err))
+}
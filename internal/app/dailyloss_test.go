@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+)
+
+func TestDailyLossHaltFlattensAndRequiresResume(t *testing.T) {
+	store := &memoryStore{data: make(map[string]string)}
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.store = store
+	app.strategy.State = strategy.StateHedgeOK
+	app.cfg.Risk.MaxDailyLossUSD = 50
+	app.cfg.Risk.DailyPnLRefresh = time.Minute
+	ctx := context.Background()
+
+	in := hedgedCompoundInputs(t, app)
+	in.Snap.SpotBalance = 1
+	in.Snap.PerpPosition = -1
+	app.refreshDailyPnL(ctx, in)
+	if app.rt.dailyBase == nil || !app.rt.hasDailyFetch || server.Count("userFillsByTime") != 1 || server.Count("userFunding") != 1 {
+		t.Fatalf("expected baseline and one fetch, got base=%v fetched=%t", app.rt.dailyBase, app.rt.hasDailyFetch)
+	}
+	app.refreshDailyPnL(ctx, in)
+	if server.Count("userFillsByTime") != 1 {
+		t.Fatalf("expected fills refetched only after risk.daily_pnl_refresh")
+	}
+
+	in.Snap.SpotMidPrice = 2940
+	in.DailyPnL, in.HasDailyPnL = app.dailyPnL(in.Now, in.Snap)
+	if !in.HasDailyPnL || in.DailyPnL.TotalUSD() != -60 {
+		t.Fatalf("expected -60 daily pnl, got %+v (ok=%t)", in.DailyPnL, in.HasDailyPnL)
+	}
+	plan := app.evaluateTick(in)
+	if plan.Decision != "risk_flatten" || plan.Action != tickActionExit || !errors.Is(plan.Err, strategy.ErrDailyLoss) {
+		t.Fatalf("expected daily loss flatten, got %s/%s (%v)", plan.Decision, plan.Action, plan.Err)
+	}
+	app.observeDailyPnL(ctx, in, plan.Risk)
+	if !app.isPaused() || !app.lossHaltActive() || store.data[lossHaltKey] == "" {
+		t.Fatalf("expected a persisted halt and pause")
+	}
+
+	// Paused by the halt, the position is still flattened even once the loss
+	// recovers.
+	in.Paused, in.LossHalt = app.isPaused(), app.lossHaltActive()
+	in.HasDailyPnL = false
+	plan = app.evaluateTick(in)
+	if plan.Decision != "risk_flatten" || plan.Action != tickActionExit || !errors.Is(plan.Err, strategy.ErrDailyLoss) {
+		t.Fatalf("expected halted flatten, got %s/%s (%v)", plan.Decision, plan.Action, plan.Err)
+	}
+
+	restarted := &App{cfg: app.cfg, store: store}
+	restarted.loadDailyPnL(ctx)
+	if !restarted.isPaused() || !restarted.lossHaltActive() || restarted.rt.dailyBase == nil {
+		t.Fatalf("expected the halt and baseline restored after restart")
+	}
+
+	if _, err := app.handleOperatorCommand(ctx, "resume", nil, operatorMeta{}); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if app.isPaused() || app.lossHaltActive() {
+		t.Fatalf("expected /resume to clear the halt")
+	}
+	in.HasDailyPnL = true
+	app.observeDailyPnL(ctx, in, plan.Risk)
+	app.observeDailyPnL(ctx, in, strategy.EvaluateRisk(app.cfg.Risk, in.riskInputs()))
+	if app.isPaused() {
+		t.Fatalf("expected an acknowledged halt not to re-fire within its window")
+	}
+	resumed := &App{cfg: app.cfg, store: store}
+	resumed.loadDailyPnL(ctx)
+	if resumed.isPaused() {
+		t.Fatalf("expected no pause after an acknowledged halt is reloaded")
+	}
+}
+
+func TestDailyPnLStartsNewWindow(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	app.cfg.Risk.MaxDailyLossUSD = 50
+	app.cfg.Risk.DailyResetHour = 8
+	ctx := context.Background()
+
+	in, err := app.collectTickInputs(ctx)
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	in.Now = time.Date(2026, 3, 2, 7, 59, 0, 0, time.UTC)
+	app.refreshDailyPnL(ctx, in)
+	if got := app.rt.dailyBase.WindowStart; !got.Equal(time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected window start %s", got)
+	}
+	if _, ok := app.dailyPnL(in.Now.Add(2*time.Minute), in.Snap); ok {
+		t.Fatalf("expected no pnl once the next window starts")
+	}
+	in.Now = in.Now.Add(2 * time.Minute)
+	app.refreshDailyPnL(ctx, in)
+	if got := app.rt.dailyBase.WindowStart; !got.Equal(time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)) || server.Count("userFillsByTime") != 2 {
+		t.Fatalf("expected a new window and refetch, got %s", got)
+	}
+	if _, ok := app.dailyPnL(in.Now, in.Snap); !ok {
+		t.Fatalf("expected pnl in the new window")
+	}
+}
@@ -0,0 +1,280 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	persist "hl-carry-bot/internal/state"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+const (
+	decisionPruneInterval = time.Hour
+	decisionDefaultWindow = time.Hour
+	decisionAroundWindow  = 15 * time.Minute
+	decisionMaxRuns       = 20
+)
+
+// decisionInputs is the subset of tick inputs stored with each decision.
+type decisionInputs struct {
+	FundingRate          float64 `json:"funding_rate"`
+	FundingAPR           float64 `json:"funding_apr"`
+	MinFundingAPR        float64 `json:"min_funding_apr"`
+	PredictedFundingRate float64 `json:"predicted_funding_rate,omitempty"`
+	ExpectedFundingUSD   float64 `json:"expected_funding_usd"`
+	EstimatedCostUSD     float64 `json:"estimated_cost_usd"`
+	NetCarryUSD          float64 `json:"net_carry_usd"`
+	CarryBufferUSD       float64 `json:"carry_buffer_usd"`
+	FundingOKCount       int     `json:"funding_ok_count"`
+	FundingBadCount      int     `json:"funding_bad_count"`
+	Volatility           float64 `json:"volatility"`
+	MaxVolatility        float64 `json:"max_volatility"`
+	SpotMid              float64 `json:"spot_mid"`
+	PerpMid              float64 `json:"perp_mid"`
+	Basis                float64 `json:"basis,omitempty"`
+	SpotBalance          float64 `json:"spot_balance"`
+	PerpPosition         float64 `json:"perp_position"`
+	DeltaUSD             float64 `json:"delta_usd"`
+	MarginRatio          float64 `json:"margin_ratio,omitempty"`
+	HealthRatio          float64 `json:"health_ratio,omitempty"`
+	OpenOrders           int     `json:"open_orders"`
+	MarketAgeMS          int64   `json:"market_age_ms"`
+	AccountAgeMS         int64   `json:"account_age_ms"`
+	RiskAction           string  `json:"risk_action"`
+	Flat                 bool    `json:"flat"`
+	Paused               bool    `json:"paused"`
+	EntryCooldown        bool    `json:"entry_cooldown"`
+	HedgeCooldown        bool    `json:"hedge_cooldown"`
+	CircuitOpen          bool    `json:"circuit_open"`
+	ForeignActivity      bool    `json:"foreign_activity"`
+}
+
+// traceTick logs the tick at debug level and, with decision_log enabled,
+// records it in the decision journal.
+func (a *App) traceTick(ctx context.Context, in tickInputs, plan tickPlan, decision string, err error) {
+	if err != nil {
+		a.logTick(in, plan, decision, zap.Error(err))
+	} else {
+		a.logTick(in, plan, decision)
+	}
+	a.journalDecision(ctx, in, plan, decision, err)
+}
+
+func (a *App) journalDecision(ctx context.Context, in tickInputs, plan tickPlan, decision string, err error) {
+	if a.config() == nil || !a.config().DecisionLog.Enabled {
+		return
+	}
+	journal, ok := a.store.(persist.DecisionJournal)
+	if !ok {
+		return
+	}
+	cfg := a.strategyConfig()
+	snap := in.Snap
+	inputs := decisionInputs{
+		FundingRate:        snap.FundingRate,
+		FundingAPR:         snap.FundingAPR(),
+		MinFundingAPR:      cfg.MinFundingAPR,
+		ExpectedFundingUSD: in.ExpectedFunding,
+		EstimatedCostUSD:   in.EstimatedCostUSD,
+		NetCarryUSD:        in.NetCarryUSD,
+		CarryBufferUSD:     cfg.CarryBufferUSD,
+		FundingOKCount:     plan.FundingOKCount,
+		FundingBadCount:    plan.FundingBadCount,
+		Volatility:         snap.Volatility,
+		MaxVolatility:      cfg.MaxVolatility,
+		SpotMid:            snap.SpotMidPrice,
+		PerpMid:            snap.PerpMidPrice,
+		SpotBalance:        snap.SpotBalance,
+		PerpPosition:       snap.PerpPosition,
+		DeltaUSD:           in.DeltaUSD,
+		MarginRatio:        snap.MarginRatio,
+		HealthRatio:        snap.HealthRatio,
+		OpenOrders:         snap.OpenOrderCount,
+		MarketAgeMS:        in.Ages.Market().Milliseconds(),
+		AccountAgeMS:       in.Ages.Account.Milliseconds(),
+		RiskAction:         plan.Risk.Action.String(),
+		Flat:               in.Flat,
+		Paused:             in.Paused,
+		EntryCooldown:      in.EntryCooldownActive,
+		HedgeCooldown:      in.HedgeCooldownActive,
+		CircuitOpen:        in.CircuitOpen,
+		ForeignActivity:    in.ForeignActivity,
+	}
+	if in.HasForecast {
+		inputs.PredictedFundingRate = in.Forecast.Rate
+	}
+	if in.HasBasis {
+		inputs.Basis = in.Basis
+	}
+	payload, _ := json.Marshal(inputs)
+	rec := persist.DecisionRecord{
+		ID:       fmt.Sprintf("%d:%s", in.Now.UnixNano(), decision),
+		State:    string(plan.State),
+		Decision: decision,
+		Action:   plan.Action,
+		Reasons:  strings.Join(decisionReasons(in, plan, err, cfg.MaxVolatility), "; "),
+		Inputs:   string(payload),
+		TimeMS:   in.Now.UnixMilli(),
+	}
+	if _, err := journal.RecordDecision(ctx, rec); err != nil {
+		if !a.decisionStoreWarned && a.log != nil {
+			a.log.Warn("decision journal write failed", zap.Error(err))
+		}
+		a.decisionStoreWarned = true
+		return
+	}
+	if a.decisionStoreWarned && a.log != nil {
+		a.log.Info("decision journal write recovered")
+	}
+	a.decisionStoreWarned = false
+	a.pruneDecisions(ctx, journal, in.Now)
+}
+
+// pruneDecisions drops decisions older than decision_log.retention at most
+// once per decisionPruneInterval.
+func (a *App) pruneDecisions(ctx context.Context, journal persist.DecisionJournal, now time.Time) {
+	retention := a.config().DecisionLog.Retention
+	if retention <= 0 || now.Sub(a.decisionsPrunedAt) < decisionPruneInterval {
+		return
+	}
+	a.decisionsPrunedAt = now
+	if _, err := journal.PruneDecisions(ctx, now.Add(-retention).UnixMilli()); err != nil && a.log != nil {
+		a.log.Warn("decision journal prune failed", zap.Error(err))
+	}
+}
+
+// decisionReasons explains a tick that did not trade: the gate error, an
+// order planning error, and for an idle tick without an entry the entry
+// conditions that failed.
+func decisionReasons(in tickInputs, plan tickPlan, err error, maxVolatility float64) []string {
+	var reasons []string
+	if err != nil {
+		reasons = append(reasons, err.Error())
+	}
+	if plan.OrderErr != nil {
+		reasons = append(reasons, "order plan: "+plan.OrderErr.Error())
+	}
+	if plan.State != strategy.StateIdle || plan.Action == tickActionEnter || err != nil {
+		return reasons
+	}
+	if !plan.FundingRateOK {
+		reasons = append(reasons, "funding apr below min")
+	}
+	if !plan.NetCarryOK {
+		reasons = append(reasons, "net carry below buffer")
+	}
+	if plan.FundingRateOK && plan.NetCarryOK && !plan.FundingOKConfirmed {
+		reasons = append(reasons, "funding not confirmed")
+	}
+	if in.Snap.Volatility > maxVolatility {
+		reasons = append(reasons, "volatility above max")
+	}
+	return reasons
+}
+
+// decisionsReport answers /decisions [window|time]: the ticks of the last
+// window (default 1h), or those within 15 minutes of a UTC time given as
+// HH:MM or RFC3339. Consecutive ticks with the same outcome are collapsed.
+func (a *App) decisionsReport(ctx context.Context, args []string) (string, error) {
+	if a.config() == nil || !a.config().DecisionLog.Enabled {
+		return "decision log disabled (decision_log.enabled)", nil
+	}
+	journal, ok := a.store.(persist.DecisionJournal)
+	if !ok {
+		return "decision log unavailable: state store has no decision journal", nil
+	}
+	start, end, err := decisionWindow(args, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	records, err := journal.Decisions(ctx, start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return "", err
+	}
+	header := fmt.Sprintf("decisions %s to %s UTC", start.Format("2006-01-02 15:04:05"), end.Format("15:04:05"))
+	if len(records) == 0 {
+		return header + ": none recorded", nil
+	}
+	runs := collapseDecisions(records)
+	lines := []string{fmt.Sprintf("%s: %d ticks", header, len(records))}
+	if len(runs) > decisionMaxRuns {
+		lines = append(lines, fmt.Sprintf("(%d earlier entries omitted)", len(runs)-decisionMaxRuns))
+		runs = runs[len(runs)-decisionMaxRuns:]
+	}
+	for _, run := range runs {
+		lines = append(lines, run.String())
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func decisionWindow(args []string, now time.Time) (time.Time, time.Time, error) {
+	if len(args) == 0 {
+		return now.Add(-decisionDefaultWindow), now, nil
+	}
+	if len(args) > 1 {
+		return time.Time{}, time.Time{}, errors.New("usage: /decisions [window|HH:MM|RFC3339 time]")
+	}
+	arg := args[0]
+	if window, err := time.ParseDuration(arg); err == nil {
+		if window <= 0 {
+			return time.Time{}, time.Time{}, errors.New("decisions window must be > 0")
+		}
+		return now.Add(-window), now, nil
+	}
+	at, err := time.Parse(time.RFC3339, arg)
+	if err != nil {
+		clock, clockErr := time.Parse("15:04", arg)
+		if clockErr != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid decisions window or time %q", arg)
+		}
+		// The most recent occurrence of HH:MM in UTC.
+		at = time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+		if at.After(now) {
+			at = at.AddDate(0, 0, -1)
+		}
+	}
+	at = at.UTC()
+	return at.Add(-decisionAroundWindow), at.Add(decisionAroundWindow), nil
+}
+
+type decisionRun struct {
+	first persist.DecisionRecord
+	last  persist.DecisionRecord
+	count int
+}
+
+func (r decisionRun) String() string {
+	line := fmt.Sprintf("%s %s %s", time.UnixMilli(r.first.TimeMS).UTC().Format("15:04:05"), r.first.State, r.first.Decision)
+	if r.first.Action != "" && r.first.Action != tickActionHold {
+		line += " action=" + r.first.Action
+	}
+	if r.count > 1 {
+		line += fmt.Sprintf(" x%d until %s", r.count, time.UnixMilli(r.last.TimeMS).UTC().Format("15:04:05"))
+	}
+	if r.first.Reasons != "" {
+		line += ": " + r.first.Reasons
+	}
+	return line
+}
+
+func collapseDecisions(records []persist.DecisionRecord) []decisionRun {
+	var runs []decisionRun
+	for _, rec := range records {
+		if n := len(runs); n > 0 {
+			prev := runs[n-1].first
+			if prev.State == rec.State && prev.Decision == rec.Decision && prev.Action == rec.Action && prev.Reasons == rec.Reasons {
+				runs[n-1].last = rec
+				runs[n-1].count++
+				continue
+			}
+		}
+		runs = append(runs, decisionRun{first: rec, last: rec, count: 1})
+	}
+	return runs
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/state/sqlite"
+)
+
+func TestDecisionJournalRecordsTicks(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("store init: %v", err)
+	}
+	defer store.Close()
+	app.store = store
+	ctx := context.Background()
+
+	app.setPaused(true)
+	if err := app.tick(ctx); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if got, err := store.Decisions(ctx, 0, 0); err != nil || len(got) != 0 {
+		t.Fatalf("expected nothing recorded while disabled, got %+v (err=%v)", got, err)
+	}
+
+	app.cfg.DecisionLog.Enabled = true
+	app.cfg.DecisionLog.Retention = time.Hour
+	for i := 0; i < 2; i++ {
+		if err := app.tick(ctx); err != nil {
+			t.Fatalf("tick: %v", err)
+		}
+	}
+	app.setPaused(false)
+	app.cfg.Strategy.MinFundingAPR = 100
+	if err := app.tick(ctx); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	records, err := store.Decisions(ctx, 0, 0)
+	if err != nil || len(records) != 3 {
+		t.Fatalf("expected three recorded ticks, got %+v (err=%v)", records, err)
+	}
+	last := records[2]
+	if last.Decision != "idle" || !strings.Contains(last.Reasons, "funding apr below min") || !strings.Contains(last.Inputs, `"min_funding_apr":100`) {
+		t.Fatalf("unexpected idle decision %+v", last)
+	}
+
+	report, err := app.decisionsReport(ctx, nil)
+	if err != nil {
+		t.Fatalf("decisions report: %v", err)
+	}
+	if !strings.Contains(report, "3 ticks") || !strings.Contains(report, "IDLE paused x2") || !strings.Contains(report, "IDLE idle: funding apr below min") {
+		t.Fatalf("unexpected report:\n%s", report)
+	}
+}
+
+func TestDecisionWindow(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+	start, end, err := decisionWindow([]string{"14:00"}, now)
+	if err != nil {
+		t.Fatalf("decision window: %v", err)
+	}
+	if want := time.Date(2026, 3, 1, 13, 45, 0, 0, time.UTC); !start.Equal(want) || !end.Equal(want.Add(30*time.Minute)) {
+		t.Fatalf("expected yesterday's 14:00 +/- 15m, got %s to %s", start, end)
+	}
+	if start, end, err := decisionWindow([]string{"2h"}, now); err != nil || !start.Equal(now.Add(-2*time.Hour)) || !end.Equal(now) {
+		t.Fatalf("unexpected window %s to %s (err=%v)", start, end, err)
+	}
+	if _, _, err := decisionWindow([]string{"soon"}, now); err == nil {
+		t.Fatal("expected an invalid window to fail")
+	}
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/logging"
+
+	"go.uber.org/zap"
+)
+
+// dustBalance is one spot balance worth less than strategy.min_exposure_usd.
+type dustBalance struct {
+	Coin     string
+	AssetID  int
+	Size     float64
+	Price    float64
+	ValueUSD float64
+	Limit    float64
+}
+
+// dustBalances values every non-USDC spot balance at its mid and keeps the
+// ones below strategy.min_exposure_usd, sorted by coin.
+func (a *App) dustBalances(ctx context.Context, balances map[string]float64) []dustBalance {
+	out := make([]dustBalance, 0)
+	for coin, balance := range balances {
+		if coin == "USDC" || balance <= 0 {
+			continue
+		}
+		mid, spotCtx, err := a.spotMid(ctx, coin)
+		if err != nil || mid <= 0 {
+			continue
+		}
+		size := balance
+		size = spotCtx.Increments.RoundSize(size)
+		value := size * mid
+		if size <= 0 || value >= a.config().Strategy.MinExposureUSD {
+			continue
+		}
+		assetID, ok := a.market.SpotAssetID(spotCtx.Symbol)
+		if !ok {
+			continue
+		}
+		out = append(out, dustBalance{
+			Coin:     coin,
+			AssetID:  assetID,
+			Size:     size,
+			Price:    mid,
+			ValueUSD: value,
+			Limit:    limitPriceWithOffset(mid, false, true, spotCtx.BaseSzDecimals, a.iocPriceBps()),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Coin < out[j].Coin })
+	return out
+}
+
+// sellableDust drops the balances worth less than the exchange minimum order
+// value, which cannot be sold on their own and are left in place.
+func (a *App) sellableDust(dust []dustBalance) []dustBalance {
+	out := make([]dustBalance, 0, len(dust))
+	for _, d := range dust {
+		if d.ValueUSD < config.MinOrderValueUSD {
+			if a.log != nil {
+				a.log.Debug("dust below exchange minimum order value",
+					zap.String("coin", d.Coin),
+					zap.Float64("size", d.Size),
+					zap.Float64("value_usd", d.ValueUSD),
+				)
+			}
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// maybeSweepDust sells spot dust once the combined value of the sellable
+// balances reaches dust.threshold_usd. It runs at most every dust.interval
+// unless force is set (after an exit).
+func (a *App) maybeSweepDust(ctx context.Context, now time.Time, force bool) {
+	cfg := a.config()
+	if cfg == nil || !cfg.Dust.Enabled || a.account == nil || a.executor == nil {
+		return
+	}
+	if !force && !a.lastDustSweep.IsZero() && now.Sub(a.lastDustSweep) < cfg.Dust.Interval {
+		return
+	}
+	a.lastDustSweep = now
+	state, err := a.account.Reconcile(ctx)
+	if err != nil {
+		if a.log != nil {
+			a.log.Warn("dust sweep reconcile failed", zap.Error(err))
+		}
+		return
+	}
+	dust := a.sellableDust(a.dustBalances(ctx, state.SpotBalances))
+	total := 0.0
+	for _, d := range dust {
+		total += d.ValueUSD
+	}
+	if total <= 0 || (!force && total < cfg.Dust.ThresholdUSD) {
+		return
+	}
+	swept := 0.0
+	for _, d := range dust {
+		cloid, err := newCloid()
+		if err != nil {
+			return
+		}
+		orderID, filled, open, err := a.placeAndWait(ctx, exec.Order{
+			Asset:         d.AssetID,
+			Size:          d.Size,
+			LimitPrice:    d.Limit,
+			ClientOrderID: cloid,
+			Tif:           string(exchange.TifIoc),
+		})
+		if err != nil {
+			if a.log != nil {
+				a.log.Warn("dust sweep order failed", logging.Unsampled(), zap.Error(err), zap.String("coin", d.Coin))
+			}
+			continue
+		}
+		if open {
+			a.cancelBestEffort(ctx, d.AssetID, orderID)
+		}
+		swept += filled * d.Price
+		if a.log != nil {
+			a.log.Info("dust swept", logging.Unsampled(),
+				zap.String("coin", d.Coin),
+				zap.String("cloid", cloid),
+				zap.Float64("size", d.Size),
+				zap.Float64("filled", filled),
+				zap.Float64("limit", d.Limit),
+				zap.Float64("value_usd", d.ValueUSD),
+			)
+		}
+	}
+	if swept > 0 && a.log != nil {
+		a.log.Info("dust sweep complete", zap.Float64("dust_usd", total), zap.Float64("swept_usd", swept))
+	}
+}
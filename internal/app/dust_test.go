@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hltest"
+
+	"go.uber.org/zap"
+)
+
+func TestDustBalancesKeepsSmallSpotResiduals(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	ctx := context.Background()
+
+	dust := app.dustBalances(ctx, map[string]float64{"USDC": 100, "UETH": 0.003, "NOPE": 5})
+	if len(dust) != 1 {
+		t.Fatalf("expected 1 dust balance, got %+v", dust)
+	}
+	d := dust[0]
+	if d.Coin != "UETH" || d.AssetID != 10051 || d.Size != 0.003 {
+		t.Fatalf("unexpected dust balance %+v", d)
+	}
+	if math.Abs(d.ValueUSD-9) > 1e-9 {
+		t.Fatalf("expected 9 USD of dust, got %f", d.ValueUSD)
+	}
+	if d.Limit >= d.Price {
+		t.Fatalf("expected sell limit below mid, got %f vs %f", d.Limit, d.Price)
+	}
+
+	if dust := app.dustBalances(ctx, map[string]float64{"UETH": 0.02}); len(dust) != 0 {
+		t.Fatalf("expected balance above min exposure to be kept, got %+v", dust)
+	}
+}
+
+func TestMaybeSweepDustSellsOnlySellableDust(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetSpotBalances([]any{
+		map[string]any{"coin": "USDC", "total": "100"},
+		map[string]any{"coin": "UETH", "total": "0.002"},
+	})
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.MinExposureUSD = 20
+	app.cfg.Strategy.EntryTimeout = 500 * time.Millisecond
+	app.cfg.Strategy.EntryPollInterval = 10 * time.Millisecond
+	app.cfg.Dust = config.DustConfig{Enabled: true, Interval: time.Hour, ThresholdUSD: 10}
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	app.executor = exec.New(&exchangeAdapter{client: client, tif: exchange.TifIoc, log: zap.NewNop()}, nil, zap.NewNop())
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	// 6 USD of UETH is dust but below the exchange minimum order value, so it
+	// neither sells nor counts toward the threshold.
+	app.maybeSweepDust(ctx, now, false)
+	if orders := server.Orders(); len(orders) != 0 {
+		t.Fatalf("expected no sweep below the exchange minimum, got %+v", orders)
+	}
+
+	server.SetSpotBalances([]any{
+		map[string]any{"coin": "USDC", "total": "100"},
+		map[string]any{"coin": "UETH", "total": "0.005"},
+	})
+	app.maybeSweepDust(ctx, now.Add(30*time.Minute), false)
+	if orders := server.Orders(); len(orders) != 0 {
+		t.Fatalf("expected the sweep to wait out dust.interval, got %+v", orders)
+	}
+	app.maybeSweepDust(ctx, now.Add(2*time.Hour), false)
+	orders := server.Orders()
+	if len(orders) != 1 || orders[0].IsBuy || orders[0].Asset != 10051 {
+		t.Fatalf("expected one UETH sell, got %+v", orders)
+	}
+	if got := server.SpotBalance("UETH"); got > 1e-9 {
+		t.Fatalf("expected the 15 USD of dust sold, got %v UETH", got)
+	}
+}
@@ -0,0 +1,227 @@
+package app
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/events"
+	"hl-carry-bot/internal/hl/rest"
+
+	"go.uber.org/zap"
+)
+
+const eventLoopBuffer = 256
+
+// eventTrigger decides which bus events are worth a strategy tick. Mid and
+// margin moves are measured against the values seen at the last tick, so a
+// slow drift still triggers once it adds up.
+type eventTrigger struct {
+	perpAsset       string
+	midKeys         map[string]bool
+	midMoveBps      float64
+	marginRatioMove float64
+
+	lastMid     map[string]float64
+	refMid      map[string]float64
+	lastMargin  float64
+	refMargin   float64
+	hasMargin   bool
+	hasRefRatio bool
+}
+
+func newEventTrigger(perpAsset string, midKeys []string, midMoveBps, marginRatioMove float64) *eventTrigger {
+	keys := make(map[string]bool, len(midKeys))
+	for _, key := range midKeys {
+		if key != "" {
+			keys[key] = true
+		}
+	}
+	return &eventTrigger{
+		perpAsset:       perpAsset,
+		midKeys:         keys,
+		midMoveBps:      midMoveBps,
+		marginRatioMove: marginRatioMove,
+		lastMid:         make(map[string]float64),
+		refMid:          make(map[string]float64),
+	}
+}
+
+// observe records ev and returns the tick reason it warrants, or "".
+func (t *eventTrigger) observe(ev events.Event) string {
+	switch e := ev.(type) {
+	case events.FillReceived:
+		return "fill"
+	case events.PositionChanged:
+		if strings.EqualFold(e.Asset, t.perpAsset) {
+			return "position"
+		}
+	case events.FundingForecastUpdated:
+		if strings.EqualFold(e.Asset, t.perpAsset) {
+			return "funding_forecast"
+		}
+	case events.MidUpdated:
+		if !t.midKeys[e.Asset] || e.Mid <= 0 {
+			return ""
+		}
+		t.lastMid[e.Asset] = e.Mid
+		ref, ok := t.refMid[e.Asset]
+		if !ok || ref <= 0 {
+			t.refMid[e.Asset] = e.Mid
+			return ""
+		}
+		if math.Abs(e.Mid-ref)/ref*1e4 >= t.midMoveBps {
+			return "mid_move"
+		}
+	case events.MarginChanged:
+		if !e.HasMarginRatio {
+			return ""
+		}
+		t.lastMargin = e.MarginRatio
+		t.hasMargin = true
+		if !t.hasRefRatio {
+			t.refMargin = e.MarginRatio
+			t.hasRefRatio = true
+			return ""
+		}
+		if math.Abs(e.MarginRatio-t.refMargin) >= t.marginRatioMove {
+			return "margin"
+		}
+	}
+	return ""
+}
+
+// rebase makes the latest observed values the reference for the next tick.
+func (t *eventTrigger) rebase() {
+	for key, mid := range t.lastMid {
+		t.refMid[key] = mid
+	}
+	if t.hasMargin {
+		t.refMargin = t.lastMargin
+		t.hasRefRatio = true
+	}
+}
+
+// runEventLoop ticks the strategy when the bus reports a fill, a perp
+// position change, a predicted funding update, or a mid or margin ratio move
+// beyond its threshold. Events arriving while a tick runs are folded into
+// that tick: they are mostly its own fills and refreshes.
+func (a *App) runEventLoop(ctx context.Context) error {
+	cfg := a.config().EventLoop
+	ch, unsubscribe := a.events.Subscribe(eventLoopBuffer,
+		events.KindFillReceived,
+		events.KindPositionChanged,
+		events.KindFundingForecast,
+		events.KindMidUpdated,
+		events.KindMarginChanged,
+	)
+	defer unsubscribe()
+	trigger := newEventTrigger(a.config().Strategy.PerpAsset, a.eventMidKeys(), cfg.MidMoveBps, cfg.MarginRatioMove)
+	a.startFundingPoller(ctx, cfg.FundingPoll)
+
+	idle := time.NewTimer(cfg.MaxIdle)
+	defer idle.Stop()
+	var spacing *time.Timer
+	var spacingC <-chan time.Time
+	pending := ""
+	lastTick := time.Time{}
+	a.setNextTick(time.Now().Add(cfg.MaxIdle))
+	if a.log != nil {
+		a.log.Info("strategy loop started",
+			zap.String("mode", "event"),
+			zap.Duration("min_spacing", cfg.MinSpacing),
+			zap.Duration("max_idle", cfg.MaxIdle),
+		)
+	}
+
+	runTick := func(reason string) {
+		lastTick = time.Now()
+		a.setNextTick(lastTick.Add(cfg.MaxIdle))
+		if a.log != nil {
+			a.log.Debug("event tick", zap.String("trigger", reason))
+		}
+		if err := a.tick(ctx); err != nil {
+			a.log.Warn("strategy tick failed", zap.Error(err))
+		}
+		for len(ch) > 0 {
+			trigger.observe(<-ch)
+		}
+		trigger.rebase()
+		if !idle.Stop() {
+			select {
+			case <-idle.C:
+			default:
+			}
+		}
+		idle.Reset(cfg.MaxIdle)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if spacing != nil {
+				spacing.Stop()
+			}
+			return ctx.Err()
+		case ev, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			reason := trigger.observe(ev)
+			if reason == "" || pending != "" {
+				continue
+			}
+			wait := time.Until(lastTick.Add(cfg.MinSpacing))
+			if wait <= 0 {
+				runTick(reason)
+				continue
+			}
+			pending = reason
+			spacing = time.NewTimer(wait)
+			spacingC = spacing.C
+		case <-spacingC:
+			reason := pending
+			pending = ""
+			spacingC = nil
+			runTick(reason)
+		case <-idle.C:
+			runTick("idle")
+		case <-a.wake:
+			runTick("operator")
+		}
+	}
+}
+
+// eventMidKeys lists the allMids keys of both legs.
+func (a *App) eventMidKeys() []string {
+	cfg := a.config()
+	keys := []string{cfg.Strategy.PerpAsset, cfg.Strategy.SpotAsset}
+	if spotCtx, err := a.spotContext(cfg.Strategy.SpotAsset); err == nil {
+		keys = append(keys, spotCtx.MidKey, spotCtx.Symbol)
+	}
+	return keys
+}
+
+// startFundingPoller refreshes predicted funding between ticks so forecast
+// changes reach the event loop. The refresh is throttled by market data, so
+// ticks that also refresh do not double the REST load.
+func (a *App) startFundingPoller(ctx context.Context, interval time.Duration) {
+	if a.market == nil || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := a.market.RefreshFundingForecast(rest.WithPriority(ctx, rest.PriorityLow)); err != nil && a.log != nil {
+					a.log.Debug("funding poll failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
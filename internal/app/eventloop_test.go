@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/events"
+	"hl-carry-bot/internal/hltest"
+)
+
+func TestEventTriggerThresholds(t *testing.T) {
+	trigger := newEventTrigger("ETH", []string{"ETH", "UETH/USDC"}, 10, 0.02)
+	if got := trigger.observe(events.MidUpdated{Asset: "ETH", Mid: 3000}); got != "" {
+		t.Fatalf("expected the first mid to set the reference, got %q", got)
+	}
+	if got := trigger.observe(events.MidUpdated{Asset: "ETH", Mid: 3002}); got != "" {
+		t.Fatalf("expected a 6.7 bps move to stay quiet, got %q", got)
+	}
+	if got := trigger.observe(events.MidUpdated{Asset: "ETH", Mid: 3003}); got != "mid_move" {
+		t.Fatalf("expected a 10 bps move to trigger, got %q", got)
+	}
+	trigger.rebase()
+	if got := trigger.observe(events.MidUpdated{Asset: "ETH", Mid: 3004}); got != "" {
+		t.Fatalf("expected the reference to move after rebase, got %q", got)
+	}
+	if got := trigger.observe(events.MidUpdated{Asset: "BTC", Mid: 1}); got != "" {
+		t.Fatalf("expected unrelated mids ignored, got %q", got)
+	}
+
+	trigger.observe(events.MarginChanged{MarginRatio: 0.10, HasMarginRatio: true})
+	if got := trigger.observe(events.MarginChanged{MarginRatio: 0.11, HasMarginRatio: true}); got != "" {
+		t.Fatalf("expected a small margin move to stay quiet, got %q", got)
+	}
+	if got := trigger.observe(events.MarginChanged{MarginRatio: 0.13, HasMarginRatio: true}); got != "margin" {
+		t.Fatalf("expected a margin ratio move to trigger, got %q", got)
+	}
+
+	if got := trigger.observe(events.PositionChanged{Asset: "BTC"}); got != "" {
+		t.Fatalf("expected other positions ignored, got %q", got)
+	}
+	if got := trigger.observe(events.PositionChanged{Asset: "ETH", Size: -0.1}); got != "position" {
+		t.Fatalf("expected a perp position change to trigger, got %q", got)
+	}
+	if got := trigger.observe(events.FundingForecastUpdated{Asset: "ETH"}); got != "funding_forecast" {
+		t.Fatalf("expected a forecast update to trigger, got %q", got)
+	}
+	if got := trigger.observe(events.FillReceived{OrderID: "1"}); got != "fill" {
+		t.Fatalf("expected a fill to trigger, got %q", got)
+	}
+}
+
+func TestEventLoopTicksOnEventsWithSpacing(t *testing.T) {
+	server := hltest.NewServer(t)
+	server.SetNextFundingTime(time.Now().Add(time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.setPaused(true)
+	app.events = events.New()
+	app.cfg.EventLoop = config.EventLoopConfig{
+		Enabled:         true,
+		MinSpacing:      200 * time.Millisecond,
+		MaxIdle:         time.Hour,
+		MidMoveBps:      10,
+		MarginRatioMove: 0.02,
+		FundingPoll:     time.Hour,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- app.runEventLoop(ctx) }()
+
+	waitTick := func(after time.Time, within time.Duration) bool {
+		deadline := time.Now().Add(within)
+		for time.Now().Before(deadline) {
+			if app.nextTick().After(after.Add(time.Hour)) {
+				return true
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		return false
+	}
+	subscribed := time.Now().Add(time.Second)
+	for app.nextTick().IsZero() {
+		if time.Now().After(subscribed) {
+			t.Fatalf("event loop never started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	app.events.Publish(events.FillReceived{OrderID: "1"})
+	if !waitTick(start, time.Second) {
+		t.Fatalf("expected a fill to trigger a tick")
+	}
+
+	second := time.Now()
+	app.events.Publish(events.FillReceived{OrderID: "2"})
+	if waitTick(second, 100*time.Millisecond) {
+		t.Fatalf("expected the second tick to wait for min_spacing")
+	}
+	if !waitTick(second, time.Second) {
+		t.Fatalf("expected the spaced tick to run")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected context cancellation, got %v", err)
+	}
+}
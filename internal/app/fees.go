@@ -0,0 +1,230 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/precision"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/logging"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+const (
+	feesRetry                = 5 * time.Minute
+	executionModeMakerIfThin = "maker_when_thin"
+	executionModeMakerFirst  = "maker_first"
+)
+
+// strategy.maker_price values.
+const (
+	makerPriceMid    = "mid"
+	makerPriceTouch  = "touch"
+	makerPriceInside = "inside"
+)
+
+// refreshFees fetches the account fee rates at startup and every
+// fees.refresh_interval; a failed fetch is retried after feesRetry and the
+// last known (or configured) rates stay in use.
+func (a *App) refreshFees(ctx context.Context, now time.Time) {
+	cfg := a.config()
+	if cfg == nil || a.account == nil || !cfg.Fees.EnabledValue() {
+		return
+	}
+	wait := cfg.Fees.RefreshInterval
+	if _, ok := a.feeSchedule(); !ok {
+		wait = feesRetry
+	}
+	if !a.feesAttempt.IsZero() && now.Sub(a.feesAttempt) < wait {
+		return
+	}
+	a.feesAttempt = now
+	fees, err := a.account.UserFees(rest.WithPriority(ctx, rest.PriorityLow))
+	if err != nil {
+		if !a.feesWarned && a.log != nil {
+			a.log.Warn("fee schedule fetch failed; using strategy.fee_bps", zap.Error(err))
+		}
+		a.feesWarned = true
+		return
+	}
+	if a.feesWarned && a.log != nil {
+		a.log.Info("fee schedule fetch recovered")
+	}
+	a.feesWarned = false
+	if changed := a.setFeeSchedule(fees); changed && a.log != nil {
+		a.log.Info("fee schedule updated",
+			zap.Float64("perp_taker_bps", fees.PerpTakerBps),
+			zap.Float64("perp_maker_bps", fees.PerpMakerBps),
+			zap.Float64("spot_taker_bps", fees.SpotTakerBps),
+			zap.Float64("spot_maker_bps", fees.SpotMakerBps),
+		)
+	}
+}
+
+// feeBps is the per-leg fee used in the carry estimate: the average of the
+// account's spot and perp taker rates once fetched, else strategy.fee_bps.
+// Maker fills only make the real cost lower.
+func (a *App) feeBps() float64 {
+	if fees, ok := a.feeSchedule(); ok {
+		return (fees.SpotTakerBps + fees.PerpTakerBps) / 2
+	}
+	return a.config().Strategy.FeeBps
+}
+
+func (a *App) feeSource() string {
+	if _, ok := a.feeSchedule(); ok {
+		return "account"
+	}
+	return "config"
+}
+
+// makerSpotEntry reports whether the spot entry leg should first rest as a
+// post-only order: always in maker_first mode, and in maker_when_thin mode
+// when net carry is less than strategy.maker_margin_usd above
+// carry_buffer_usd.
+func (a *App) makerSpotEntry(snap strategy.MarketSnapshot) bool {
+	cfg := a.strategyConfig()
+	if cfg.MakerTimeout <= 0 {
+		return false
+	}
+	switch cfg.ExecutionMode {
+	case executionModeMakerFirst:
+		return true
+	case executionModeMakerIfThin:
+		netCarry, _ := strategy.NetExpectedCarryUSD(snap, a.feeBps(), cfg.SlippageBps)
+		return netCarry-cfg.CarryBufferUSD < cfg.MakerMarginUSD
+	}
+	return false
+}
+
+// makerBookPrice reports whether the post-only entry is priced off the spot
+// book rather than the mid.
+func (a *App) makerBookPrice() bool {
+	cfg := a.config().Strategy
+	if cfg.ExecutionMode != executionModeMakerFirst && cfg.ExecutionMode != executionModeMakerIfThin {
+		return false
+	}
+	return cfg.MakerPrice == makerPriceTouch || cfg.MakerPrice == makerPriceInside
+}
+
+// makerEntryPrice is the post-only spot buy price under strategy.maker_price:
+// the best bid for touch, one tick above it for inside as long as that stays
+// below the best ask, else (mid, or no fresh book) the spot mid.
+func (a *App) makerEntryPrice(snap strategy.MarketSnapshot, szDecimals int) (float64, string) {
+	ref := snap.SpotMidPrice
+	if ref == 0 {
+		ref = snap.PerpMidPrice
+	}
+	mid := precision.LimitPrice(ref, true, szDecimals)
+	if !a.makerBookPrice() {
+		return mid, makerPriceMid
+	}
+	book, ok := a.freshBook(a.spotBookCoin())
+	if !ok || len(book.Bids) == 0 || book.Bids[0].Px <= 0 {
+		return mid, makerPriceMid
+	}
+	bid := precision.LimitPrice(book.Bids[0].Px, true, szDecimals)
+	if a.config().Strategy.MakerPrice == makerPriceInside {
+		inside := precision.LimitPrice(bid+spotPriceTick(bid, szDecimals), true, szDecimals)
+		if len(book.Asks) == 0 || inside < book.Asks[0].Px {
+			return inside, makerPriceInside
+		}
+	}
+	return bid, makerPriceTouch
+}
+
+// spotPriceTick is the smallest spot price step at price: the larger of the
+// decimal tick and the significant-figure step.
+func spotPriceTick(price float64, szDecimals int) float64 {
+	tick := math.Pow10(-precision.Spot(szDecimals).PriceDecimals)
+	if price > 0 {
+		tick = math.Max(tick, math.Pow10(int(math.Floor(math.Log10(price)))-(precision.MaxSigFigs-1)))
+	}
+	return tick
+}
+
+// placeSpotEntry buys the spot leg and returns what filled. When
+// makerSpotEntry holds, a post-only (ALO) buy first rests at makerEntryPrice
+// for strategy.maker_timeout; only the unfilled remainder is sent as IOC at
+// order.LimitPrice. A rejected post-only order (it would cross) falls back
+// to IOC for the full size. The perp leg is placed by the caller only after
+// this returns the confirmed fill.
+func (a *App) placeSpotEntry(ctx context.Context, snap strategy.MarketSnapshot, order exec.Order, szDecimals int) (account.OrderFill, error) {
+	var makerFill account.OrderFill
+	if a.makerSpotEntry(snap) {
+		maker := order
+		var priceSource string
+		maker.LimitPrice, priceSource = a.makerEntryPrice(snap, szDecimals)
+		maker.Tif = string(exchange.TifAlo)
+		outcome, err := a.placeAndFill(ctx, maker, a.config().Strategy.MakerTimeout)
+		if err != nil {
+			if a.log != nil && !errors.Is(err, exec.ErrWouldCross) {
+				a.log.Warn("post-only spot entry failed; sending IOC", zap.Error(err), zap.Float64("limit", maker.LimitPrice))
+			}
+		} else {
+			a.countOrderPlaced()
+			if outcome.Open {
+				a.cancelBestEffort(ctx, order.Asset, outcome.OrderID)
+			}
+			if outcome.Fill.Size > 0 {
+				makerFill = outcome.Fill
+			}
+			if a.log != nil {
+				a.log.Info("post-only spot entry", logging.Unsampled(),
+					zap.String("cloid", maker.ClientOrderID),
+					zap.Float64("limit", maker.LimitPrice),
+					zap.String("price_source", priceSource),
+					zap.Float64("size", maker.Size),
+					zap.Float64("filled", makerFill.Size),
+					zap.Float64("avg_px", makerFill.AvgPrice()),
+				)
+			}
+		}
+		remaining := order.Size - makerFill.Size
+		remaining = precision.Spot(szDecimals).RoundSize(remaining)
+		if makerFill.Size > 0 && (remaining <= 0 || remaining*order.LimitPrice < config.MinOrderValueUSD) {
+			return makerFill, nil
+		}
+		cloid, err := newCloid()
+		if err != nil {
+			return makerFill, err
+		}
+		order.Size = remaining
+		order.ClientOrderID = cloid
+	}
+	outcome, err := a.placeAndFill(ctx, order, a.config().Strategy.EntryTimeout)
+	if err != nil {
+		if a.metrics != nil {
+			a.metrics.OrdersFailed.Inc()
+		}
+		if makerFill.Size > 0 {
+			return makerFill, nil
+		}
+		return outcome.Fill, err
+	}
+	a.countOrderPlaced()
+	if outcome.Open {
+		a.cancelBestEffort(ctx, order.Asset, outcome.OrderID)
+	}
+	total := makerFill
+	total.Size += outcome.Fill.Size
+	total.Notional += outcome.Fill.Notional
+	if total.Size <= 0 {
+		return account.OrderFill{}, errors.New("spot entry did not fill")
+	}
+	return total, nil
+}
+
+func (a *App) countOrderPlaced() {
+	if a.metrics != nil {
+		a.metrics.OrdersPlaced.Inc()
+	}
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+)
+
+func TestRefreshFeesReplacesConfiguredFeeBps(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.FeeBps = 9
+	app.cfg.Fees.RefreshInterval = time.Hour
+	ctx := context.Background()
+	now := time.Now()
+
+	app.refreshFees(ctx, now)
+	if app.rt.hasFees || app.feeBps() != 9 || app.feeSource() != "config" {
+		t.Fatalf("expected configured fee after failed fetch, got %f (%s)", app.feeBps(), app.feeSource())
+	}
+
+	server.SetUserFees(map[string]any{"userCrossRate": "0.00035", "userAddRate": "0.0001", "userSpotCrossRate": "0.0007", "userSpotAddRate": "0.0004"})
+	app.refreshFees(ctx, now.Add(time.Minute))
+	if app.rt.hasFees {
+		t.Fatalf("expected retry to wait for feesRetry")
+	}
+	app.refreshFees(ctx, now.Add(feesRetry))
+	if !app.rt.hasFees || math.Abs(app.feeBps()-5.25) > 1e-9 || app.feeSource() != "account" {
+		t.Fatalf("expected account fee 5.25 bps, got %f (%s)", app.feeBps(), app.feeSource())
+	}
+	calls := server.Count("userFees")
+	app.refreshFees(ctx, now.Add(feesRetry+30*time.Minute))
+	if server.Count("userFees") != calls {
+		t.Fatalf("expected no refetch within fees.refresh_interval")
+	}
+}
+
+func TestMakerSpotEntryOnlyWhenCarryIsThin(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.MakerTimeout = time.Second
+	app.cfg.Strategy.MakerMarginUSD = 0.5
+	snap := strategy.MarketSnapshot{NotionalUSD: 1000, FundingRate: 0.001, SpotMidPrice: 3000, PerpMidPrice: 3000}
+
+	app.cfg.Strategy.ExecutionMode = "taker"
+	if app.makerSpotEntry(snap) {
+		t.Fatalf("expected taker mode to skip maker entry")
+	}
+	app.cfg.Strategy.ExecutionMode = executionModeMakerIfThin
+	app.cfg.Strategy.FeeBps = 0
+	if app.makerSpotEntry(snap) {
+		t.Fatalf("expected wide carry margin to use taker entry")
+	}
+	app.cfg.Strategy.FeeBps = 2
+	if !app.makerSpotEntry(snap) {
+		t.Fatalf("expected thin carry margin to use maker entry")
+	}
+}
+
+func TestMakerFirstEntryPricesFromSpotBook(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.ExecutionMode = executionModeMakerFirst
+	app.cfg.Strategy.MakerTimeout = time.Second
+	app.cfg.Strategy.FeeBps = 0
+	snap := strategy.MarketSnapshot{NotionalUSD: 1000, FundingRate: 0.001, SpotMidPrice: 3000, PerpMidPrice: 3000}
+	if !app.makerSpotEntry(snap) {
+		t.Fatalf("expected maker_first to rest the spot leg regardless of carry")
+	}
+	if price, source := app.makerEntryPrice(snap, 4); price != 3000 || source != makerPriceMid {
+		t.Fatalf("expected the mid by default, got %f (%s)", price, source)
+	}
+
+	coin := app.spotBookCoin()
+	server.SetBook(coin, map[string]any{"coin": coin, "levels": []any{
+		[]any{map[string]any{"px": "2998", "sz": "1", "n": 1}},
+		[]any{map[string]any{"px": "3002", "sz": "1", "n": 1}},
+	}})
+	app.cfg.Strategy.MakerPrice = makerPriceInside
+	app.refreshPricingBooks(context.Background())
+	if price, source := app.makerEntryPrice(snap, 4); math.Abs(price-2998.1) > 1e-9 || source != makerPriceInside {
+		t.Fatalf("expected one tick above the bid, got %f (%s)", price, source)
+	}
+	app.cfg.Strategy.MakerPrice = makerPriceTouch
+	if price, source := app.makerEntryPrice(snap, 4); price != 2998 || source != makerPriceTouch {
+		t.Fatalf("expected the best bid, got %f (%s)", price, source)
+	}
+
+	server.SetBook(coin, map[string]any{"coin": coin, "levels": []any{
+		[]any{map[string]any{"px": "2999.9", "sz": "1", "n": 1}},
+		[]any{map[string]any{"px": "3000", "sz": "1", "n": 1}},
+	}})
+	app.cfg.Strategy.MakerPrice = makerPriceInside
+	app.refreshPricingBooks(context.Background())
+	if price, source := app.makerEntryPrice(snap, 4); math.Abs(price-2999.9) > 1e-9 || source != makerPriceTouch {
+		t.Fatalf("expected the bid on a one-tick spread, got %f (%s)", price, source)
+	}
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/logging"
+
+	"go.uber.org/zap"
+)
+
+// handleFlattenCommand is the two-step /flatten: the first call holds it for
+// confirmation, and "/flatten confirm" (or /confirm) within
+// telegram.confirmation_timeout pauses trading and queues a forced exit for
+// an immediate tick.
+func (a *App) handleFlattenCommand(ctx context.Context, args []string, meta operatorMeta) (string, error) {
+	if len(args) == 0 {
+		summary := "this cancels open orders and exits the position regardless of funding guards"
+		return a.requestConfirmation(ctx, "flatten", summary, meta, func(ctx context.Context) (string, error) {
+			return a.queueFlatten(ctx, meta), nil
+		}), nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "confirm":
+		return a.confirmPendingCommand(ctx, "flatten", meta)
+	case "cancel":
+		return a.cancelPendingCommand(ctx, "flatten", meta)
+	default:
+		return "", errors.New("unknown flatten command: use /flatten, /flatten confirm, or /flatten cancel")
+	}
+}
+
+// queueFlatten pauses trading and queues the confirmed /flatten.
+func (a *App) queueFlatten(ctx context.Context, meta operatorMeta) string {
+	before := a.isPaused()
+	after := a.setPaused(true)
+	a.opsMu.Lock()
+	a.flattenRequested = true
+	a.opsMu.Unlock()
+	a.auditOperatorEvent(ctx, operatorAuditEvent{
+		UpdateID:     meta.UpdateID,
+		Time:         time.Now().UTC(),
+		Action:       "flatten",
+		Command:      meta.Raw,
+		UserID:       meta.UserID,
+		Username:     meta.Username,
+		ChatID:       meta.ChatID,
+		PausedBefore: before,
+		PausedAfter:  after,
+	})
+	a.requestTick()
+	return "flatten queued: cancelling open orders and exiting now; trading stays paused until /resume"
+}
+
+// takeFlattenRequest reports and clears a confirmed /flatten.
+func (a *App) takeFlattenRequest() bool {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+	requested := a.flattenRequested
+	a.flattenRequested = false
+	return requested
+}
+
+// forceFlatten runs a confirmed /flatten on the tick goroutine: it cancels
+// every open order and exits through exitPosition, skipping the funding
+// guard, risk and cooldown checks a normal exit goes through.
+func (a *App) forceFlatten(ctx context.Context, in tickInputs) error {
+	if a.log != nil {
+		a.log.Warn("operator flatten: cancelling open orders and exiting", logging.Unsampled(),
+			zap.Int("open_orders", len(in.OpenOrders)),
+			zap.Float64("spot_balance", in.Snap.SpotBalance),
+			zap.Float64("perp_position", in.Snap.PerpPosition),
+		)
+	}
+	if len(in.OpenOrders) > 0 {
+		a.cancelOpenOrders(ctx, in.OpenOrders)
+	}
+	if in.FlatStrict {
+		if a.log != nil {
+			a.log.Info("operator flatten: already flat")
+		}
+		return nil
+	}
+	return a.exitPosition(ctx, in.Snap)
+}
+
+// requestTick asks the strategy loop to tick now instead of waiting for its
+// timer or the next event.
+func (a *App) requestTick() {
+	if a.wake == nil {
+		return
+	}
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
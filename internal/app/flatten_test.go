@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hltest"
+)
+
+func TestFlattenRequiresConfirmation(t *testing.T) {
+	store := &memoryStore{data: make(map[string]string)}
+	app := &App{store: store, wake: make(chan struct{}, 1)}
+	ctx := context.Background()
+	meta := operatorMeta{UserID: 1, ChatID: 2, Raw: "/flatten confirm"}
+
+	if _, err := app.handleFlattenCommand(ctx, []string{"confirm"}, meta); err == nil {
+		t.Fatalf("expected confirm without a request rejected")
+	}
+	if _, err := app.handleFlattenCommand(ctx, nil, meta); err != nil {
+		t.Fatalf("flatten request: %v", err)
+	}
+	if _, err := app.handleFlattenCommand(ctx, []string{"confirm"}, operatorMeta{UserID: 9}); err == nil {
+		t.Fatalf("expected confirm by another user rejected")
+	}
+	if app.takeFlattenRequest() {
+		t.Fatalf("expected no flatten queued after a rejected confirm")
+	}
+
+	if _, err := app.handleFlattenCommand(ctx, nil, meta); err != nil {
+		t.Fatalf("flatten request: %v", err)
+	}
+	app.confirmPending.ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := app.handleFlattenCommand(ctx, []string{"confirm"}, meta); err == nil {
+		t.Fatalf("expected an expired request rejected")
+	}
+
+	if _, err := app.handleFlattenCommand(ctx, nil, meta); err != nil {
+		t.Fatalf("flatten request: %v", err)
+	}
+	resp, err := app.handleFlattenCommand(ctx, []string{"confirm"}, meta)
+	if err != nil {
+		t.Fatalf("flatten confirm: %v", err)
+	}
+	if !strings.HasPrefix(resp, "flatten queued") {
+		t.Fatalf("unexpected response %q", resp)
+	}
+	if !app.isPaused() {
+		t.Fatalf("expected trading paused by flatten")
+	}
+	select {
+	case <-app.wake:
+	default:
+		t.Fatalf("expected an immediate tick requested")
+	}
+	audited := false
+	for key, val := range store.data {
+		if strings.HasPrefix(key, "ops:audit:") && strings.Contains(val, `"action":"flatten"`) {
+			audited = true
+		}
+	}
+	if !audited {
+		t.Fatalf("expected a flatten audit event")
+	}
+	if !app.takeFlattenRequest() || app.takeFlattenRequest() {
+		t.Fatalf("expected exactly one queued flatten")
+	}
+}
+
+func TestTickRunsQueuedFlatten(t *testing.T) {
+	server := hltest.NewServer(t)
+	server.SetNextFundingTime(time.Now().Add(time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.flattenRequested = true
+	if err := app.tick(context.Background()); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if app.takeFlattenRequest() {
+		t.Fatalf("expected the tick to consume the flatten")
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"hl-carry-bot/internal/market"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+// dataAgeReport is the per-leg view of the connectivity kill switch inputs.
+type dataAgeReport struct {
+	GeneratedAt     time.Time           `json:"generated_at"`
+	PerpMidAgeMS    int64               `json:"perp_mid_age_ms"`
+	SpotMidAgeMS    int64               `json:"spot_mid_age_ms"`
+	AccountAgeMS    int64               `json:"account_age_ms"`
+	MaxMarketAgeMS  int64               `json:"max_market_age_ms"`
+	MaxAccountAgeMS int64               `json:"max_account_age_ms"`
+	Stale           []string            `json:"stale"`
+	Feeds           []market.FeedUpdate `json:"feeds"`
+}
+
+// dataAges measures each kill switch input from cached update times.
+func (a *App) dataAges(spotCtx market.SpotContext) strategy.DataAges {
+	cfg := a.config()
+	var ages strategy.DataAges
+	if a.market != nil && cfg != nil {
+		ages.PerpMid = time.Since(a.market.LastUpdate(market.FeedMids, cfg.Strategy.PerpAsset))
+		ages.SpotMid = time.Since(a.spotMidUpdatedAt(spotCtx, cfg.Strategy.SpotAsset))
+		ages.MarketSubsPending = !a.market.SubscriptionsHealthy()
+	}
+	if a.account != nil {
+		ages.Account = time.Since(a.account.LastUpdate())
+		ages.AccountSubsPending = !a.account.SubscriptionsHealthy()
+	}
+	return ages
+}
+
+// spotMidUpdatedAt returns the last mid update for the spot leg, trying the
+// same keys as spotMid.
+func (a *App) spotMidUpdatedAt(spotCtx market.SpotContext, asset string) time.Time {
+	for _, key := range []string{spotCtx.MidKey, spotCtx.Symbol, asset} {
+		if key == "" {
+			continue
+		}
+		if at := a.market.LastUpdate(market.FeedMids, key); !at.IsZero() {
+			return at
+		}
+	}
+	return time.Time{}
+}
+
+func (a *App) dataAgeReport() dataAgeReport {
+	cfg := a.config()
+	spotCtx, _ := a.spotContext(cfg.Strategy.SpotAsset)
+	ages := a.dataAges(spotCtx)
+	risk := a.riskConfig()
+	report := dataAgeReport{
+		GeneratedAt:     time.Now().UTC(),
+		PerpMidAgeMS:    ages.PerpMid.Milliseconds(),
+		SpotMidAgeMS:    ages.SpotMid.Milliseconds(),
+		AccountAgeMS:    ages.Account.Milliseconds(),
+		MaxMarketAgeMS:  risk.MaxMarketAge.Milliseconds(),
+		MaxAccountAgeMS: risk.MaxAccountAge.Milliseconds(),
+		Stale:           staleLegs(strategy.CheckDataAges(risk, ages)),
+	}
+	assets := []string{cfg.Strategy.PerpAsset, cfg.Strategy.SpotAsset}
+	for _, key := range []string{spotCtx.Symbol, spotCtx.MidKey, spotCtx.Base} {
+		if key != "" {
+			assets = append(assets, key)
+		}
+	}
+	report.Feeds = a.market.FeedUpdates(assets...)
+	if report.Stale == nil {
+		report.Stale = []string{}
+	}
+	return report
+}
+
+func (a *App) handleDataAgeAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if a.config() == nil || a.market == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "market data unavailable"})
+		return
+	}
+	if err := json.NewEncoder(w).Encode(a.dataAgeReport()); err != nil && a.log != nil {
+		a.log.Warn("data age api response failed", zap.Error(err))
+	}
+}
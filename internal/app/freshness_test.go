@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/market"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+func TestConnectivityCancelsOnlyBlindLeg(t *testing.T) {
+	stub := &stubRestClient{}
+	app := &App{
+		cfg: &config.Config{
+			Strategy: config.StrategyConfig{PerpAsset: "ETH", SpotAsset: "UETH"},
+			Risk:     config.RiskConfig{MaxMarketAge: time.Second, MaxAccountAge: time.Second},
+		},
+		log:      zap.NewNop(),
+		executor: exec.New(stub, nil, zap.NewNop()),
+	}
+	openOrders := []map[string]any{
+		{"oid": "1", "coin": "ETH", "asset": 4},
+		{"oid": "2", "coin": "@151", "asset": 10151},
+	}
+	err := app.checkConnectivity(context.Background(), app.riskConfig(), openOrders, strategy.DataAges{SpotMid: 2 * time.Second})
+	if err == nil {
+		t.Fatalf("expected connectivity error")
+	}
+	if len(stub.cancels) != 1 || stub.cancels[0].OrderID != "2" {
+		t.Fatalf("expected only the spot order to be cancelled, got %+v", stub.cancels)
+	}
+
+	stub.cancels = nil
+	err = app.checkConnectivity(context.Background(), app.riskConfig(), openOrders, strategy.DataAges{Account: 2 * time.Second})
+	if err == nil {
+		t.Fatalf("expected connectivity error")
+	}
+	if len(stub.cancels) != 2 {
+		t.Fatalf("expected stale account data to cancel both legs, got %+v", stub.cancels)
+	}
+}
+
+func TestDataAgeAPI(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	app.cfg.Risk.MaxMarketAge = time.Hour
+	if _, err := app.market.Mid(context.Background(), "ETH"); err != nil {
+		t.Fatalf("mid: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	app.handleDataAgeAPI(rec, httptest.NewRequest(http.MethodGet, "/api/data-age", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var report dataAgeReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.PerpMidAgeMS > time.Minute.Milliseconds() {
+		t.Fatalf("expected fresh perp mid, got %+v", report)
+	}
+	found := false
+	for _, feed := range report.Feeds {
+		if feed.Feed == market.FeedMids && feed.Asset == "ETH" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected ETH mids feed, got %+v", report.Feeds)
+	}
+	for _, leg := range report.Stale {
+		if leg == "perp_mid" {
+			t.Fatalf("expected perp mid not stale, got %+v", report.Stale)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+// projectedFundingUSD is the next funding payment on the perp position at
+// the predicted rate: position × rate × oracle, signed as a receipt, so a
+// short receives a positive rate and pays a negative one.
+func projectedFundingUSD(position, rate, oracle float64) float64 {
+	return -position * rate * oracle
+}
+
+// reportFundingProjection sends the funding summary telegram.funding_report
+// asks for once per funding time, on the first tick within
+// telegram.funding_report_lead of it, while a perp position is held. It
+// pairs the projected payment with the perp funding realized since the start
+// of the PnL day (risk.daily_reset_hour), so a negative interval is flagged
+// before it is charged.
+func (a *App) reportFundingProjection(ctx context.Context, in tickInputs) {
+	cfg := a.config()
+	if cfg == nil || !cfg.Telegram.FundingReport || a.alerts == nil || a.account == nil {
+		return
+	}
+	if !in.HasForecast || !in.Forecast.HasNext || !in.Forecast.HasRate || in.Snap.PerpPosition == 0 {
+		return
+	}
+	fundingAt := in.Forecast.NextFunding
+	if !fundingAt.After(in.Now) || in.Now.Before(fundingAt.Add(-cfg.Telegram.FundingReportLead)) || a.fundingReportedFor.Equal(fundingAt) {
+		return
+	}
+	oracle := in.Snap.OraclePrice
+	if oracle <= 0 {
+		oracle = in.Snap.PerpMidPrice
+	}
+	if oracle <= 0 {
+		return
+	}
+	a.fundingReportedFor = fundingAt
+	msg, projected := a.fundingProjectionText(ctx, in, oracle)
+	if a.log != nil {
+		a.log.Info("funding report sent",
+			zap.String("asset", in.Snap.PerpAsset),
+			zap.Time("funding_at", fundingAt),
+			zap.Float64("projected_usd", projected),
+			zap.Duration("until_funding", fundingAt.Sub(in.Now).Round(time.Second)),
+		)
+	}
+	if err := a.alerts.Send(ctx, msg); err != nil && a.log != nil {
+		a.log.Warn("alert send failed", zap.Error(err))
+	}
+}
+
+// fundingProjectionText is the funding report for the next funding time and
+// its projected payment.
+func (a *App) fundingProjectionText(ctx context.Context, in tickInputs, oracle float64) (string, float64) {
+	projected := projectedFundingUSD(in.Snap.PerpPosition, in.Forecast.Rate, oracle)
+	msg := fmt.Sprintf("Funding on %s at %s: projected %+.4f USD (position %.6f × rate %.8f × oracle %.4f)",
+		in.Snap.PerpAsset, in.Forecast.NextFunding.UTC().Format("15:04 MST"), projected, in.Snap.PerpPosition, in.Forecast.Rate, oracle)
+	if projected < 0 {
+		msg = "Negative funding ahead. " + msg
+	}
+	since := strategy.DailyWindowStart(in.Now, a.config().Risk.DailyResetHour)
+	if _, payments, err := a.strategyFlows(ctx, since.UnixMilli()); err != nil {
+		if a.log != nil {
+			a.log.Warn("funding report: realized funding fetch failed", zap.Error(err))
+		}
+		msg += "\nrealized today: n/a"
+	} else {
+		msg += fmt.Sprintf("\nrealized today: %+.4f USD over %d payments", fundingTotal(payments), len(payments))
+	}
+	return msg, projected
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/alerts"
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/market"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestReportFundingProjectionOncePerFundingTime(t *testing.T) {
+	now := time.Date(2026, 3, 2, 14, 10, 0, 0, time.UTC)
+	server := hltest.NewServer(t)
+	server.SetUserFunding([]any{
+		map[string]any{"time": now.Add(-2 * time.Hour).UnixMilli(), "delta": map[string]any{"type": "funding", "coin": "ETH", "usdc": "0.05", "fundingRate": "0.0001"}},
+		map[string]any{"time": now.Add(-time.Hour).UnixMilli(), "delta": map[string]any{"type": "funding", "coin": "ETH", "usdc": "-0.02", "fundingRate": "-0.00004"}},
+		map[string]any{"time": now.Add(-time.Hour).UnixMilli(), "delta": map[string]any{"type": "funding", "coin": "BTC", "usdc": "1", "fundingRate": "0.0001"}},
+	})
+	app := newNextTestApp(t, server)
+	core, logs := observer.New(zap.DebugLevel)
+	app.log = zap.New(core)
+	app.alerts = alerts.NewTelegram(config.TelegramConfig{}, app.log)
+	app.cfg.Telegram.FundingReport = true
+	app.cfg.Telegram.FundingReportLead = time.Hour
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+
+	fundingAt := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	in := tickInputs{
+		Now:         now,
+		Snap:        strategy.MarketSnapshot{PerpAsset: "ETH", PerpPosition: -2, OraclePrice: 2000},
+		Forecast:    market.FundingForecast{Rate: -0.00001, NextFunding: fundingAt, HasNext: true, HasRate: true},
+		HasForecast: true,
+	}
+	msg, projected := app.fundingProjectionText(ctx, in, 2000)
+	if projected != -0.04 {
+		t.Fatalf("expected -0.04 projected, got %v", projected)
+	}
+	for _, want := range []string{"Negative funding ahead.", "at 15:00 UTC", "projected -0.0400 USD", "realized today: +0.0300 USD over 2 payments"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q in %q", want, msg)
+		}
+	}
+
+	early := in
+	early.Now = fundingAt.Add(-2 * time.Hour)
+	app.reportFundingProjection(ctx, early)
+	if n := logs.FilterMessage("funding report sent").Len(); n != 0 {
+		t.Fatalf("expected no report before the lead, got %d", n)
+	}
+	app.reportFundingProjection(ctx, in)
+	in.Now = now.Add(time.Minute)
+	app.reportFundingProjection(ctx, in)
+	if n := logs.FilterMessage("funding report sent").Len(); n != 1 {
+		t.Fatalf("expected one report per funding time, got %d", n)
+	}
+	in.Forecast.NextFunding = fundingAt.Add(time.Hour)
+	in.Now = fundingAt.Add(time.Minute)
+	app.reportFundingProjection(ctx, in)
+	if n := logs.FilterMessage("funding report sent").Len(); n != 2 {
+		t.Fatalf("expected a report for the next funding time, got %d", n)
+	}
+
+	flat := in
+	flat.Snap.PerpPosition = 0
+	flat.Forecast.NextFunding = fundingAt.Add(2 * time.Hour)
+	flat.Now = fundingAt.Add(time.Hour + time.Minute)
+	app.reportFundingProjection(ctx, flat)
+	if n := logs.FilterMessage("funding report sent").Len(); n != 2 {
+		t.Fatalf("expected no report while flat, got %d", n)
+	}
+}
+
+func TestProjectedFundingUSD(t *testing.T) {
+	if got := projectedFundingUSD(-1, 0.0001, 2000); got != 0.2 {
+		t.Fatalf("expected a short to receive 0.2, got %v", got)
+	}
+	if got := projectedFundingUSD(1, 0.0001, 2000); got != -0.2 {
+		t.Fatalf("expected a long to pay 0.2, got %v", got)
+	}
+}
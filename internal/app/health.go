@@ -0,0 +1,223 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// healthReport is the /healthz and /readyz body. Failures lists every failed
+// check; the endpoint answers 503 when it is not empty.
+type healthReport struct {
+	Status             string       `json:"status"`
+	GeneratedAt        time.Time    `json:"generated_at"`
+	State              string       `json:"state"`
+	StartupComplete    bool         `json:"startup_complete"`
+	LastTickAgeMS      int64        `json:"last_tick_age_ms"`
+	MarketWSConnected  bool         `json:"market_ws_connected"`
+	AccountWSConnected bool         `json:"account_ws_connected"`
+	PerpMidAgeMS       int64        `json:"perp_mid_age_ms"`
+	SpotMidAgeMS       int64        `json:"spot_mid_age_ms"`
+	AccountAgeMS       int64        `json:"account_age_ms"`
+	NonceStore         nonceHealth  `json:"nonce_store"`
+	Thresholds         healthLimits `json:"thresholds"`
+	Failures           []string     `json:"failures"`
+}
+
+type nonceHealth struct {
+	Enabled   bool   `json:"enabled"`
+	Last      uint64 `json:"last,omitempty"`
+	Persisted uint64 `json:"persisted,omitempty"`
+	Failing   bool   `json:"failing"`
+}
+
+type healthLimits struct {
+	MaxTickAgeMS    int64 `json:"max_tick_age_ms"`
+	MaxMarketAgeMS  int64 `json:"max_market_age_ms"`
+	MaxAccountAgeMS int64 `json:"max_account_age_ms"`
+}
+
+// markRunStarted starts the liveness clock before the first tick.
+func (a *App) markRunStarted(now time.Time) {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+	a.runStartedAt = now
+}
+
+func (a *App) markStartupComplete() {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+	a.startupComplete = true
+}
+
+func (a *App) markTickStarted(now time.Time) {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+	a.lastTickAt = now
+}
+
+// healthReport runs the liveness checks, plus the readiness checks when
+// ready is set. Liveness only fails when the strategy loop stops ticking
+// (including a startup that never finishes), so a restart can help;
+// readiness also fails on disconnected or stale feeds and nonce
+// persistence problems.
+func (a *App) healthReport(now time.Time, ready bool) healthReport {
+	a.opsMu.RLock()
+	started, lastTick, startupComplete := a.runStartedAt, a.lastTickAt, a.startupComplete
+	a.opsMu.RUnlock()
+	cfg := a.config().Health
+	report := healthReport{
+		GeneratedAt:     now,
+		StartupComplete: startupComplete,
+		Thresholds: healthLimits{
+			MaxTickAgeMS:    cfg.MaxTickAge.Milliseconds(),
+			MaxMarketAgeMS:  cfg.MaxMarketAge.Milliseconds(),
+			MaxAccountAgeMS: cfg.MaxAccountAge.Milliseconds(),
+		},
+		Failures: []string{},
+	}
+	if a.strategy != nil {
+		report.State = string(a.strategy.Current())
+	}
+	fail := func(check string) {
+		report.Failures = append(report.Failures, check)
+	}
+
+	since := lastTick
+	if since.IsZero() {
+		since = started
+	}
+	if !since.IsZero() {
+		report.LastTickAgeMS = now.Sub(since).Milliseconds()
+		if cfg.MaxTickAge > 0 && now.Sub(since) > cfg.MaxTickAge {
+			if lastTick.IsZero() {
+				fail("no strategy tick since start")
+			} else {
+				fail("strategy tick stalled")
+			}
+		}
+	}
+
+	if a.market != nil {
+		report.MarketWSConnected = a.market.Connected()
+	}
+	if a.account != nil {
+		report.AccountWSConnected = a.account.Connected()
+	}
+	spotCtx, _ := a.spotContext(a.config().Strategy.SpotAsset)
+	ages := a.dataAges(spotCtx)
+	report.PerpMidAgeMS = ages.PerpMid.Milliseconds()
+	report.SpotMidAgeMS = ages.SpotMid.Milliseconds()
+	report.AccountAgeMS = ages.Account.Milliseconds()
+	if a.exchange != nil {
+		if state, ok := a.exchange.NonceState(); ok {
+			report.NonceStore = nonceHealth{Enabled: true, Last: state.Last, Persisted: state.Persisted, Failing: state.PersistFailing}
+		}
+	}
+	if ready {
+		if !startupComplete {
+			fail("startup incomplete")
+		}
+		if a.market != nil && !report.MarketWSConnected {
+			fail("market ws disconnected")
+		}
+		if a.account != nil && !report.AccountWSConnected {
+			fail("account ws disconnected")
+		}
+		if ages.MarketSubsPending {
+			fail("market subscriptions pending")
+		}
+		if ages.AccountSubsPending {
+			fail("account subscriptions pending")
+		}
+		if cfg.MaxMarketAge > 0 && ages.PerpMid > cfg.MaxMarketAge {
+			fail("perp mid stale")
+		}
+		if cfg.MaxMarketAge > 0 && ages.SpotMid > cfg.MaxMarketAge {
+			fail("spot mid stale")
+		}
+		if cfg.MaxAccountAge > 0 && ages.Account > cfg.MaxAccountAge {
+			fail("account data stale")
+		}
+		if a.exchange != nil && a.store != nil && startupComplete && !a.readOnly() {
+			if !report.NonceStore.Enabled {
+				fail("nonce store not initialized")
+			} else if report.NonceStore.Failing {
+				fail("nonce persistence failing")
+			}
+		}
+	}
+	report.Status = "ok"
+	if len(report.Failures) > 0 {
+		report.Status = "fail"
+	}
+	return report
+}
+
+func (a *App) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	a.serveHealth(w, r, false)
+}
+
+func (a *App) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	a.serveHealth(w, r, true)
+}
+
+func (a *App) serveHealth(w http.ResponseWriter, r *http.Request, ready bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if a.config() == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "config unavailable"})
+		return
+	}
+	report := a.healthReport(time.Now().UTC(), ready)
+	writeHealth(w, report.Status == "ok", report, a.log)
+}
+
+// writeHealth answers 200 when healthy and 503 otherwise, with body as JSON.
+func writeHealth(w http.ResponseWriter, healthy bool, body any, log *zap.Logger) {
+	if healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(body); err != nil && log != nil {
+		log.Warn("health response failed", zap.Error(err))
+	}
+}
+
+// handleHealthz and handleReadyz report every account, keyed by name, and
+// fail when any account fails.
+func (m *Multi) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	m.serveHealth(w, r, false)
+}
+
+func (m *Multi) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	m.serveHealth(w, r, true)
+}
+
+func (m *Multi) serveHealth(w http.ResponseWriter, r *http.Request, ready bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	now := time.Now().UTC()
+	healthy := true
+	reports := make(map[string]healthReport, len(m.names))
+	for _, name := range m.names {
+		report := m.apps[name].healthReport(now, ready)
+		if report.Status != "ok" {
+			healthy = false
+		}
+		reports[name] = report
+	}
+	writeHealth(w, healthy, reports, m.log)
+}
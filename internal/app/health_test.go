@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/market"
+
+	"go.uber.org/zap"
+)
+
+func getHealth(t *testing.T, handler http.HandlerFunc, path string) (int, healthReport) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var report healthReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	return rec.Code, report
+}
+
+func hasFailure(report healthReport, check string) bool {
+	for _, failure := range report.Failures {
+		if failure == check {
+			return true
+		}
+	}
+	return false
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	app.cfg.Health = config.HealthConfig{MaxTickAge: time.Minute, MaxMarketAge: time.Hour, MaxAccountAge: time.Hour}
+	ctx := context.Background()
+
+	app.markRunStarted(time.Now().Add(-2 * time.Minute))
+	code, report := getHealth(t, app.handleHealthz, "/healthz")
+	if code != http.StatusServiceUnavailable || len(report.Failures) != 1 || report.Failures[0] != "no strategy tick since start" {
+		t.Fatalf("expected liveness to fail before the first tick, got %d %+v", code, report)
+	}
+
+	app.markTickStarted(time.Now())
+	if code, report := getHealth(t, app.handleHealthz, "/healthz"); code != http.StatusOK || report.State != "IDLE" {
+		t.Fatalf("expected liveness ok after a tick, got %d %+v", code, report)
+	}
+	code, report = getHealth(t, app.handleReadyz, "/readyz")
+	if code != http.StatusServiceUnavailable || !hasFailure(report, "startup incomplete") ||
+		!hasFailure(report, "market ws disconnected") || !hasFailure(report, "perp mid stale") {
+		t.Fatalf("expected readiness to wait for startup, the market ws and mids, got %d %+v", code, report)
+	}
+
+	app.markStartupComplete()
+	app.market = market.New(rest.New(server.URL(), 2*time.Second, zap.NewNop()), nil, zap.NewNop())
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+	if _, err := app.market.Mid(ctx, "ETH"); err != nil {
+		t.Fatalf("mid: %v", err)
+	}
+	if code, report := getHealth(t, app.handleReadyz, "/readyz"); code != http.StatusOK {
+		t.Fatalf("expected ready, got %d %+v", code, report)
+	}
+
+	app.cfg.Health.MaxMarketAge = time.Nanosecond
+	code, report = getHealth(t, app.handleReadyz, "/readyz")
+	if code != http.StatusServiceUnavailable || len(report.Failures) != 2 || !hasFailure(report, "spot mid stale") {
+		t.Fatalf("expected stale mids to fail readiness, got %d %+v", code, report)
+	}
+	if code, _ := getHealth(t, app.handleHealthz, "/healthz"); code != http.StatusOK {
+		t.Fatalf("expected stale data not to fail liveness, got %d", code)
+	}
+}
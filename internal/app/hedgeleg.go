@@ -0,0 +1,145 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/strategy"
+)
+
+// strategy.hedge_leg values.
+const (
+	hedgeLegPerp = "perp"
+	hedgeLegSpot = "spot"
+	hedgeLegAuto = "auto"
+)
+
+// hedgeLegCost is the estimated cost of placing one delta hedge on a leg.
+// Blocked says why the leg cannot take the hedge, if it cannot.
+type hedgeLegCost struct {
+	Leg         string  `json:"leg"`
+	FeeUSD      float64 `json:"fee_usd"`
+	SlippageUSD float64 `json:"slippage_usd"`
+	// FundingUSD is the funding payment a perp hedge that shrinks the
+	// position forfeits when it lands inside the exit funding guard.
+	FundingUSD float64 `json:"funding_usd,omitempty"`
+	Blocked    string  `json:"blocked,omitempty"`
+}
+
+func (c hedgeLegCost) totalUSD() float64 {
+	return c.FeeUSD + c.SlippageUSD + c.FundingUSD
+}
+
+// legFeeBps is the taker fee of one leg: the account rate once fetched, else
+// strategy.fee_bps.
+func (a *App) legFeeBps(spot bool) float64 {
+	if fees, ok := a.feeSchedule(); ok {
+		if spot {
+			return fees.SpotTakerBps
+		}
+		return fees.PerpTakerBps
+	}
+	return a.config().Strategy.FeeBps
+}
+
+// hedgeLegCosts estimates a hedge of size (base units) at price on each leg
+// and marks a leg blocked when it cannot take the hedge: the perp when it
+// would grow below strategy.hedge_min_margin_ratio or shrink right before a
+// positive funding payment, the spot leg when its balance or the spot USDC
+// cannot cover the trade.
+func (a *App) hedgeLegCosts(snap strategy.MarketSnapshot, size, price float64, isBuy bool, now time.Time) (perp, spot hedgeLegCost) {
+	notional := size * price
+	slippage := notional * a.config().Strategy.SlippageBps / 10000
+	perp = hedgeLegCost{Leg: hedgeLegPerp, FeeUSD: notional * a.legFeeBps(false) / 10000, SlippageUSD: slippage}
+	spot = hedgeLegCost{Leg: hedgeLegSpot, FeeUSD: notional * a.legFeeBps(true) / 10000, SlippageUSD: slippage}
+
+	shrinksPerp := (isBuy && snap.PerpPosition < 0) || (!isBuy && snap.PerpPosition > 0)
+	if shrinksPerp {
+		forecast, hasForecast := a.market.FundingForecast(snap.PerpAsset)
+		if guarded, until := a.shouldDeferExitForFunding(now, forecast, hasForecast, snap.FundingRate); guarded {
+			rate := snap.FundingRate
+			if forecast.HasRate {
+				rate = forecast.Rate
+			}
+			perp.FundingUSD = notional * rate
+			perp.Blocked = fmt.Sprintf("funding payment in %s", until.Round(time.Second))
+		}
+	} else if minRatio := a.config().Strategy.HedgeMinMarginRatio; minRatio > 0 && snap.HasMarginRatio && snap.MarginRatio < minRatio {
+		perp.Blocked = fmt.Sprintf("margin ratio %.4f below %.4f", snap.MarginRatio, minRatio)
+	}
+
+	if isBuy {
+		required := notional * (1 + a.iocPriceBps()/10000)
+		usdc := 0.0
+		if a.account != nil {
+			usdc = a.account.Snapshot().SpotBalances["USDC"]
+		}
+		if usdc < required {
+			spot.Blocked = fmt.Sprintf("spot usdc %.2f below %.2f", usdc, required)
+		}
+	} else if snap.SpotBalance+flatEpsilon < size {
+		spot.Blocked = fmt.Sprintf("spot balance %.6f below %.6f", snap.SpotBalance, size)
+	}
+	return perp, spot
+}
+
+// chooseHedgeLeg applies strategy.hedge_leg to the leg estimates and says why
+// the leg was picked. A blocked spot leg always falls back to the perp, which
+// stays the leg of last resort.
+func chooseHedgeLeg(mode string, perp, spot hedgeLegCost) (string, string) {
+	switch mode {
+	case hedgeLegSpot:
+		if spot.Blocked != "" {
+			return hedgeLegPerp, "spot blocked: " + spot.Blocked
+		}
+		return hedgeLegSpot, "hedge_leg spot"
+	case hedgeLegAuto:
+		if spot.Blocked != "" {
+			return hedgeLegPerp, "spot blocked: " + spot.Blocked
+		}
+		if perp.Blocked != "" {
+			return hedgeLegSpot, "perp blocked: " + perp.Blocked
+		}
+		if spot.totalUSD() < perp.totalUSD() {
+			return hedgeLegSpot, fmt.Sprintf("spot cheaper: %.4f vs %.4f USD", spot.totalUSD(), perp.totalUSD())
+		}
+		return hedgeLegPerp, fmt.Sprintf("perp cheaper: %.4f vs %.4f USD", perp.totalUSD(), spot.totalUSD())
+	}
+	return hedgeLegPerp, ""
+}
+
+// spotHedgeOrder is the spot IOC that takes a hedge of size (base units).
+func (a *App) spotHedgeOrder(snap strategy.MarketSnapshot, size float64, isBuy bool) (plannedOrder, error) {
+	spotCtx, err := a.spotContext(snap.SpotAsset)
+	if err != nil {
+		return plannedOrder{}, err
+	}
+	spotID, ok := a.market.SpotAssetID(spotCtx.Symbol)
+	if !ok {
+		return plannedOrder{}, fmt.Errorf("spot asset id not found for %s", snap.SpotAsset)
+	}
+	ref := snap.SpotMidPrice
+	if ref == 0 {
+		ref = snap.PerpMidPrice
+	}
+	limit := limitPriceWithOffset(ref, isBuy, true, spotCtx.BaseSzDecimals, a.iocPriceBps())
+	if limit <= 0 {
+		return plannedOrder{}, errors.New("delta hedge limit price invalid")
+	}
+	size = a.hedgeSizeFloor(spotCtx.Increments.RoundSize(math.Abs(size)), limit, spotCtx.Increments)
+	if size <= 0 {
+		return plannedOrder{}, errors.New("delta hedge size rounded to zero")
+	}
+	return plannedOrder{
+		Leg:        hedgeLegSpot,
+		Asset:      snap.SpotAsset,
+		AssetID:    spotID,
+		IsBuy:      isBuy,
+		Size:       size,
+		LimitPrice: limit,
+		Tif:        string(exchange.TifIoc),
+	}, nil
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/hltest"
+)
+
+func TestChooseHedgeLeg(t *testing.T) {
+	cheap := hedgeLegCost{Leg: hedgeLegSpot, FeeUSD: 0.01}
+	dear := hedgeLegCost{Leg: hedgeLegPerp, FeeUSD: 0.02}
+	blocked := hedgeLegCost{Leg: hedgeLegPerp, Blocked: "margin ratio 0.1000 below 0.5000"}
+	cases := []struct {
+		mode       string
+		perp, spot hedgeLegCost
+		want       string
+	}{
+		{hedgeLegPerp, dear, cheap, hedgeLegPerp},
+		{"", dear, cheap, hedgeLegPerp},
+		{hedgeLegSpot, dear, hedgeLegCost{Blocked: "spot usdc 0.00 below 1.00"}, hedgeLegPerp},
+		{hedgeLegAuto, dear, cheap, hedgeLegSpot},
+		{hedgeLegAuto, dear, dear, hedgeLegPerp},
+		{hedgeLegAuto, blocked, hedgeLegCost{FeeUSD: 1}, hedgeLegSpot},
+	}
+	for _, tc := range cases {
+		if got, reason := chooseHedgeLeg(tc.mode, tc.perp, tc.spot); got != tc.want {
+			t.Fatalf("mode %q: expected %s, got %s (%s)", tc.mode, tc.want, got, reason)
+		}
+	}
+}
+
+func TestPlanRebalanceMovesToSpotWhenPerpConstrained(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.HedgeLeg = hedgeLegAuto
+	app.cfg.Strategy.HedgeMinMarginRatio = 0.5
+	snap := hedgedCompoundInputs(t, app).Snap
+	snap.SpotBalance = 0.1
+	snap.PerpPosition = -0.05
+	snap.HasMarginRatio = true
+	snap.MarginRatio = 0.2
+
+	plan, ok, err := app.planRebalance(snap)
+	if err != nil || !ok {
+		t.Fatalf("expected a hedge, got ok=%t err=%v", ok, err)
+	}
+	if plan.Order.Leg != hedgeLegSpot || plan.Order.IsBuy || math.Abs(plan.Order.Size-0.05) > 1e-9 || !strings.HasPrefix(plan.LegReason, "perp blocked: margin ratio") {
+		t.Fatalf("expected a spot sell while the perp margin is tight, got %+v (%s)", plan.Order, plan.LegReason)
+	}
+
+	snap.MarginRatio = 0.9
+	if plan, _, _ := app.planRebalance(snap); plan.Order.Leg != hedgeLegPerp || plan.Order.ReduceOnly {
+		t.Fatalf("expected the perp on equal costs, got %+v (%s)", plan.Order, plan.LegReason)
+	}
+	app.rt.fees = account.FeeSchedule{PerpTakerBps: 4.5, SpotTakerBps: 3.5}
+	app.rt.hasFees = true
+	if plan, _, _ := app.planRebalance(snap); plan.Order.Leg != hedgeLegSpot || len(plan.Costs) != 2 {
+		t.Fatalf("expected the cheaper spot leg, got %+v (%s)", plan.Order, plan.LegReason)
+	}
+
+	// Short of delta: buying spot needs more USDC than the 100 held.
+	snap.SpotBalance = 0.05
+	snap.PerpPosition = -0.1
+	plan, _, _ = app.planRebalance(snap)
+	if plan.Order.Leg != hedgeLegPerp || !plan.Order.ReduceOnly || !strings.HasPrefix(plan.LegReason, "spot blocked: spot usdc") {
+		t.Fatalf("expected the perp when spot usdc is short, got %+v (%s)", plan.Order, plan.LegReason)
+	}
+}
+
+func TestPlanRebalanceRecentersAndFloorsHedgeSize(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.DeltaRecenterPct = 50
+	snap := hedgedCompoundInputs(t, app).Snap
+	snap.SpotBalance = 0.1
+	snap.PerpPosition = -0.09
+
+	// 30 USD long against a 5 USD band: hedge back to +2.5 USD, not zero.
+	plan, ok, err := app.planRebalance(snap)
+	if err != nil || !ok {
+		t.Fatalf("expected a hedge, got ok=%t err=%v", ok, err)
+	}
+	price := deltaPriceRef(snap)
+	if plan.Order.IsBuy || math.Abs(plan.TargetUSD-2.5) > 1e-9 || math.Abs(plan.Order.Size*price-27.5) > 0.001*price {
+		t.Fatalf("expected a ~27.5 USD sell leaving 2.5 USD, got %+v target %.4f", plan.Order, plan.TargetUSD)
+	}
+
+	// 12 USD long: the 9.5 USD re-centered hedge is raised to min_exposure_usd.
+	snap.PerpPosition = -0.096
+	plan, ok, err = app.planRebalance(snap)
+	if err != nil || !ok {
+		t.Fatalf("expected a hedge, got ok=%t err=%v", ok, err)
+	}
+	if notional := plan.Order.Size * plan.Order.LimitPrice; notional < app.cfg.Strategy.MinExposureUSD || plan.Order.Size > 0.004+1e-12 {
+		t.Fatalf("expected the hedge floored to %.2f USD, got %+v (%.4f USD)", app.cfg.Strategy.MinExposureUSD, plan.Order, notional)
+	}
+}
+
+func TestPlanRebalanceSkipsFlooredHedgePastBand(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.DeltaBandUSD = 1
+	snap := hedgedCompoundInputs(t, app).Snap
+	price := deltaPriceRef(snap)
+
+	// 10.5 USD long rounds to a 0.003 hedge, which min_exposure_usd raises to
+	// 0.004: about 1.5 USD short, past the 1 USD band.
+	snap.SpotBalance = 0.1
+	snap.PerpPosition = -0.1 + 10.5/price
+	if plan, ok, err := app.planRebalance(snap); err != nil || ok {
+		t.Fatalf("expected the floored hedge skipped, got ok=%t err=%v order %+v", ok, err, plan.Order)
+	}
+
+	app.cfg.Strategy.DeltaBandUSD = 2
+	plan, ok, err := app.planRebalance(snap)
+	if err != nil || !ok || plan.Order.Size != 0.004 {
+		t.Fatalf("expected the floored hedge within a 2 USD band, got ok=%t err=%v order %+v", ok, err, plan.Order)
+	}
+}
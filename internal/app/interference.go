@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/logging"
+	persist "hl-carry-bot/internal/state"
+
+	"go.uber.org/zap"
+)
+
+const (
+	interferenceTimeout = 5 * time.Second
+	maxForeignAlerted   = 1024
+)
+
+// interferenceWatch tracks orders and fills on the account that this
+// instance did not place (another bot, a manual trade in the UI).
+type interferenceWatch struct {
+	mu            sync.Mutex
+	foreignOpen   map[string]time.Time
+	lastFill      time.Time
+	events        int
+	alerted       map[string]struct{}
+	alertedOrder  []string
+	journalWarned bool
+}
+
+func newInterferenceWatch() *interferenceWatch {
+	return &interferenceWatch{
+		foreignOpen: make(map[string]time.Time),
+		alerted:     make(map[string]struct{}),
+	}
+}
+
+// startInterferenceWatch hooks the account streams. Each update waits
+// interference.grace before it is classified so the bot's own placement
+// response has time to register the oid.
+func (a *App) startInterferenceWatch(ctx context.Context) {
+	if a.config() == nil || a.account == nil || !a.config().Interference.EnabledValue() {
+		return
+	}
+	if a.interference == nil {
+		a.interference = newInterferenceWatch()
+	}
+	grace := a.config().Interference.Grace
+	a.account.SetActivityHandler(func(activity account.Activity) {
+		if grace <= 0 {
+			a.classifyActivity(ctx, activity, time.Now().UTC())
+			return
+		}
+		time.AfterFunc(grace, func() {
+			if ctx.Err() != nil {
+				return
+			}
+			a.classifyActivity(ctx, activity, time.Now().UTC())
+		})
+	})
+}
+
+// classifyActivity ignores activity this instance placed and records the rest
+// as foreign: metric, log, journal row, and one alert per order.
+func (a *App) classifyActivity(ctx context.Context, activity account.Activity, now time.Time) {
+	if a.interference == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, interferenceTimeout)
+	defer cancel()
+	if a.executor != nil && a.executor.Owns(ctx, activity.OrderID, activity.Cloid) {
+		return
+	}
+	w := a.interference
+	w.mu.Lock()
+	switch activity.Kind {
+	case account.ActivityOrder:
+		if (account.OrderUpdate{Status: activity.Status}).Terminal() {
+			delete(w.foreignOpen, activity.OrderID)
+		} else if _, ok := w.foreignOpen[activity.OrderID]; !ok {
+			w.foreignOpen[activity.OrderID] = now
+		}
+	case account.ActivityFill:
+		w.lastFill = now
+	}
+	w.events++
+	alertKey := activity.OrderID
+	if alertKey == "" {
+		alertKey = activity.Kind + ":" + activity.Hash
+	}
+	_, alerted := w.alerted[alertKey]
+	if !alerted {
+		w.alerted[alertKey] = struct{}{}
+		w.alertedOrder = append(w.alertedOrder, alertKey)
+		if len(w.alertedOrder) > maxForeignAlerted {
+			for _, key := range w.alertedOrder[:len(w.alertedOrder)-maxForeignAlerted] {
+				delete(w.alerted, key)
+			}
+			w.alertedOrder = append([]string(nil), w.alertedOrder[len(w.alertedOrder)-maxForeignAlerted:]...)
+		}
+	}
+	w.mu.Unlock()
+
+	if a.metrics != nil {
+		a.metrics.ForeignActivity.Inc()
+	}
+	if a.log != nil {
+		a.log.Warn("foreign account activity", logging.Unsampled(),
+			zap.String("kind", activity.Kind),
+			zap.String("order_id", activity.OrderID),
+			zap.String("cloid", activity.Cloid),
+			zap.String("asset", activity.Asset),
+			zap.String("side", activity.Side),
+			zap.String("status", activity.Status),
+			zap.Float64("size", activity.Size),
+			zap.Float64("price", activity.Price),
+		)
+	}
+	a.journalForeignActivity(ctx, activity, now)
+	if alerted || a.alerts == nil {
+		return
+	}
+	msg := fmt.Sprintf("Foreign %s on account: %s %s %.6f @ %.6f (oid %s, status %s)",
+		activity.Kind, activity.Asset, activity.Side, activity.Size, activity.Price, activity.OrderID, activity.Status)
+	if a.config().Interference.PauseEntries {
+		msg += "; entries paused while it is active"
+	}
+	if err := a.alerts.Send(ctx, msg); err != nil && a.log != nil {
+		a.log.Warn("foreign activity alert failed", zap.Error(err))
+	}
+}
+
+func (a *App) journalForeignActivity(ctx context.Context, activity account.Activity, now time.Time) {
+	journal, ok := a.store.(persist.ForeignJournal)
+	if !ok {
+		return
+	}
+	id := activity.Kind + ":" + activity.OrderID + ":" + activity.Status
+	if activity.Kind == account.ActivityFill {
+		id = activity.Kind + ":" + activity.Hash
+		if activity.Hash == "" {
+			id = activity.Kind + ":" + activity.OrderID + ":" + strconv.FormatInt(activity.TimeMS, 10)
+		}
+	}
+	timeMS := activity.TimeMS
+	if timeMS == 0 {
+		timeMS = now.UnixMilli()
+	}
+	_, err := journal.RecordForeignActivity(ctx, persist.ForeignActivityRecord{
+		ID:         id,
+		Kind:       activity.Kind,
+		OrderID:    activity.OrderID,
+		Cloid:      activity.Cloid,
+		Asset:      activity.Asset,
+		Side:       activity.Side,
+		Status:     activity.Status,
+		Size:       activity.Size,
+		Price:      activity.Price,
+		TimeMS:     timeMS,
+		DetectedMS: now.UnixMilli(),
+	})
+	w := a.interference
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		if !w.journalWarned && a.log != nil {
+			a.log.Warn("foreign activity journal write failed", zap.Error(err))
+		}
+		w.journalWarned = true
+		return
+	}
+	if w.journalWarned && a.log != nil {
+		a.log.Info("foreign activity journal write recovered")
+	}
+	w.journalWarned = false
+}
+
+// foreignActivityActive reports whether a foreign order is still open or a
+// foreign fill landed within interference.hold of now.
+func (a *App) foreignActivityActive(now time.Time) bool {
+	if a.interference == nil || a.config() == nil {
+		return false
+	}
+	w := a.interference
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.foreignOpen) > 0 {
+		return true
+	}
+	return !w.lastFill.IsZero() && now.Sub(w.lastFill) < a.config().Interference.Hold
+}
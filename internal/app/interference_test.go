@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/state/sqlite"
+
+	"go.uber.org/zap"
+)
+
+func TestForeignActivityDetectedJournaledAndPausesEntries(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("store init: %v", err)
+	}
+	defer store.Close()
+	m, counters := newTestMetrics()
+	app.store = store
+	app.metrics = m
+	app.executor = exec.New(&stubRestClient{orderIDs: []string{"100"}}, store, zap.NewNop())
+	app.cfg.Interference.PauseEntries = true
+	app.cfg.Interference.Hold = time.Minute
+	app.interference = newInterferenceWatch()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	if _, err := app.executor.PlaceOrder(ctx, exec.Order{Asset: 1, ClientOrderID: "0xown"}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	app.classifyActivity(ctx, account.Activity{Kind: account.ActivityOrder, OrderID: "100", Cloid: "0xown", Status: "open"}, now)
+	app.classifyActivity(ctx, account.Activity{Kind: account.ActivityFill, OrderID: "100", Hash: "0xa"}, now)
+	if counters.foreign.count != 0 || app.foreignActivityActive(now) {
+		t.Fatalf("expected own activity ignored")
+	}
+
+	foreign := account.Activity{Kind: account.ActivityOrder, OrderID: "200", Asset: "ETH", Side: "B", Status: "open", Size: 1, Price: 2000, TimeMS: now.UnixMilli()}
+	app.classifyActivity(ctx, foreign, now)
+	if counters.foreign.count != 1 || !app.foreignActivityActive(now) {
+		t.Fatalf("expected foreign order detected")
+	}
+	report, err := app.nextAction(ctx)
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Decision != "skip_foreign_activity" || !report.ForeignActivity {
+		t.Fatalf("expected entries paused, got %s", report.Decision)
+	}
+
+	foreign.Status = "canceled"
+	app.classifyActivity(ctx, foreign, now)
+	if app.foreignActivityActive(now) {
+		t.Fatalf("expected foreign activity cleared once the order is closed")
+	}
+	app.classifyActivity(ctx, account.Activity{Kind: account.ActivityFill, OrderID: "300", Hash: "0xb", TimeMS: now.UnixMilli()}, now)
+	if !app.foreignActivityActive(now.Add(30*time.Second)) || app.foreignActivityActive(now.Add(2*time.Minute)) {
+		t.Fatalf("expected foreign fill to hold entries for interference.hold")
+	}
+
+	records, err := store.ForeignActivity(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("journal query: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 journaled foreign events, got %+v", records)
+	}
+	app.cfg.Interference.PauseEntries = false
+	app.classifyActivity(ctx, account.Activity{Kind: account.ActivityOrder, OrderID: "400", Status: "open"}, now)
+	if report, _ := app.nextAction(ctx); report.Decision == "skip_foreign_activity" {
+		t.Fatalf("expected entries to continue when pause_entries is off")
+	}
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/logging"
+	persist "hl-carry-bot/internal/state"
+
+	"go.uber.org/zap"
+)
+
+// minIsolatedTopUpUSD is the smallest margin top-up worth an exchange action.
+const minIsolatedTopUpUSD = 1.0
+
+// isolatedMarginRatio is an isolated position's margin over its maintenance
+// margin, half the initial margin at the asset's max leverage. maxLeverage
+// of 0 (not listed) charges maintenance at the position's own leverage.
+func isolatedMarginRatio(margin account.IsolatedMargin, maxLeverage float64) (ratio, maintenance float64, ok bool) {
+	if maxLeverage <= 0 {
+		maxLeverage = float64(margin.Leverage)
+	}
+	if maxLeverage <= 0 || margin.PositionValue <= 0 {
+		return 0, 0, false
+	}
+	maintenance = margin.PositionValue / (2 * maxLeverage)
+	return margin.MarginUsed / maintenance, maintenance, true
+}
+
+// isolatedMargin is perpAsset's isolated position with its margin ratio.
+func (a *App) isolatedMargin(perpAsset string) (account.IsolatedMargin, float64, float64, bool) {
+	if a.account == nil {
+		return account.IsolatedMargin{}, 0, 0, false
+	}
+	margin, ok := a.account.Snapshot().IsolatedMargin[perpAsset]
+	if !ok {
+		return account.IsolatedMargin{}, 0, 0, false
+	}
+	maxLeverage := 0.0
+	if a.market != nil {
+		if perpCtx, ok := a.market.PerpContext(perpAsset); ok {
+			maxLeverage = perpCtx.MaxLeverage
+		}
+	}
+	ratio, maintenance, ok := isolatedMarginRatio(margin, maxLeverage)
+	return margin, ratio, maintenance, ok
+}
+
+// maybeTopUpIsolatedMargin adds free perp USDC to an isolated perp position
+// whose margin fell below isolated_margin.top_up_ratio times its maintenance
+// margin, bringing it back to target_ratio. It stands in for the cross-margin
+// health rules, which do not see isolated positions.
+func (a *App) maybeTopUpIsolatedMargin(ctx context.Context, perpAsset string) {
+	if a.config() == nil || a.config().IsolatedMargin.TopUpRatio <= 0 || a.exchange == nil || a.readOnly() {
+		return
+	}
+	cfg := a.config().IsolatedMargin
+	margin, ratio, maintenance, ok := a.isolatedMargin(perpAsset)
+	if !ok || ratio >= cfg.TopUpRatio {
+		a.isolatedTopUpWarned = false
+		return
+	}
+	asset, ok := a.market.PerpAssetID(perpAsset)
+	if !ok {
+		return
+	}
+	amount := cfg.TargetRatio*maintenance - margin.MarginUsed
+	if cfg.MaxTopUpUSD > 0 {
+		amount = math.Min(amount, cfg.MaxTopUpUSD)
+	}
+	state := a.account.Snapshot()
+	if state.HasMarginSummary {
+		amount = math.Min(amount, state.MarginSummary.FreeUSD())
+	}
+	var err error
+	if amount < minIsolatedTopUpUSD {
+		err = fmt.Errorf("%.2f USDC free in the perp wallet", math.Max(amount, 0))
+	} else {
+		var resp map[string]any
+		resp, err = a.exchange.UpdateIsolatedMargin(ctx, asset, amount)
+		if err == nil {
+			err = exchange.ResponseError(resp)
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil || a.isolatedTopUpWarned {
+			return
+		}
+		a.isolatedTopUpWarned = true
+		if a.log != nil {
+			a.log.Warn("isolated margin top-up failed", logging.Unsampled(),
+				zap.Error(err),
+				zap.String("asset", perpAsset),
+				zap.Float64("margin_used", margin.MarginUsed),
+				zap.Float64("maintenance_margin", maintenance),
+				zap.Float64("margin_ratio", ratio),
+			)
+		}
+		if a.alerts != nil {
+			if alertErr := a.alerts.Send(ctx, fmt.Sprintf("Isolated margin on %s is %.2fx maintenance and the top-up failed: %v", perpAsset, ratio, err)); alertErr != nil && a.log != nil {
+				a.log.Warn("alert send failed", zap.Error(alertErr))
+			}
+		}
+		return
+	}
+	a.isolatedTopUpWarned = false
+	a.journalTransfer(ctx, persist.TransferRecord{Kind: persist.TransferIsolatedMargin, Direction: "top_up", Destination: perpAsset, Amount: amount})
+	if a.log != nil {
+		a.log.Info("isolated margin topped up", logging.Audit(),
+			zap.String("asset", perpAsset),
+			zap.Float64("amount", amount),
+			zap.Float64("margin_used", margin.MarginUsed),
+			zap.Float64("maintenance_margin", maintenance),
+			zap.Float64("margin_ratio", ratio),
+		)
+	}
+	if a.alerts != nil {
+		if err := a.alerts.Send(ctx, fmt.Sprintf("Topped up %s isolated margin by %.2f USDC (was %.2fx maintenance)", perpAsset, amount, ratio)); err != nil && a.log != nil {
+			a.log.Warn("alert send failed", zap.Error(err))
+		}
+	}
+	if _, err := a.account.Reconcile(ctx); err != nil && a.log != nil {
+		a.log.Warn("reconcile after isolated margin top-up failed", zap.Error(err))
+	}
+}
+
+// isolatedMarginStatus is the perp's isolated margin for /status.
+func (a *App) isolatedMarginStatus(perpAsset string) (string, bool) {
+	margin, ratio, maintenance, ok := a.isolatedMargin(perpAsset)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("isolated_margin: %.2f (maintenance %.2f, ratio %.2f, leverage %dx)", margin.MarginUsed, maintenance, ratio, margin.Leverage), true
+}
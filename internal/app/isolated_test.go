@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hltest"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestIsolatedMarginRatio(t *testing.T) {
+	margin := account.IsolatedMargin{MarginUsed: 3, PositionValue: 300, Leverage: 5}
+	ratio, maintenance, ok := isolatedMarginRatio(margin, 50)
+	if !ok || math.Abs(maintenance-3) > 1e-9 || math.Abs(ratio-1) > 1e-9 {
+		t.Fatalf("expected maintenance 3 and ratio 1, got %v %v %v", maintenance, ratio, ok)
+	}
+	if _, maintenance, ok := isolatedMarginRatio(margin, 0); !ok || math.Abs(maintenance-30) > 1e-9 {
+		t.Fatalf("expected maintenance at the position leverage without a max, got %v %v", maintenance, ok)
+	}
+	if _, _, ok := isolatedMarginRatio(account.IsolatedMargin{MarginUsed: 3}, 50); ok {
+		t.Fatalf("expected no ratio without a position value")
+	}
+}
+
+func TestTopUpIsolatedMargin(t *testing.T) {
+	server := hltest.NewServer(t)
+	server.SetPerpPosition("ETH", -0.01)
+	server.SetIsolatedMargin("ETH", 1)
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.PerpMarginMode = "isolated"
+	app.cfg.IsolatedMargin.TopUpRatio = 2
+	app.cfg.IsolatedMargin.TargetRatio = 4
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	app.exchange, err = exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("exchange client: %v", err)
+	}
+	core, logs := observer.New(zap.DebugLevel)
+	app.log = zap.New(core)
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+
+	// 30 USD of ETH at 25x max leverage keeps 0.60 maintenance: 1.00 is
+	// 1.67x, below top_up_ratio, so 1.40 brings it to 4x.
+	line, ok := app.isolatedMarginStatus("ETH")
+	if !ok || !strings.Contains(line, "ratio 1.67") {
+		t.Fatalf("unexpected status %q", line)
+	}
+	app.cfg.IsolatedMargin.MaxTopUpUSD = 0.5
+	app.maybeTopUpIsolatedMargin(ctx, "ETH")
+	app.maybeTopUpIsolatedMargin(ctx, "ETH")
+	if got := server.IsolatedMargin("ETH"); got != 1 {
+		t.Fatalf("expected a top-up below the minimum skipped, got margin %v", got)
+	}
+	if n := logs.FilterMessage("isolated margin top-up failed").Len(); n != 1 {
+		t.Fatalf("expected one warning, got %d", n)
+	}
+
+	app.cfg.IsolatedMargin.MaxTopUpUSD = 0
+	app.cfg.ReadOnly = true
+	app.maybeTopUpIsolatedMargin(ctx, "ETH")
+	if got := server.IsolatedMargin("ETH"); got != 1 {
+		t.Fatalf("expected no top-up in read-only mode, got margin %v", got)
+	}
+
+	app.cfg.ReadOnly = false
+	app.maybeTopUpIsolatedMargin(ctx, "ETH")
+	if got := server.IsolatedMargin("ETH"); math.Abs(got-2.4) > 1e-9 {
+		t.Fatalf("expected margin topped up to 2.40, got %v", got)
+	}
+	if got := app.account.Snapshot().IsolatedMargin["ETH"].MarginUsed; math.Abs(got-2.4) > 1e-9 {
+		t.Fatalf("expected the account reconciled after the top-up, got %v", got)
+	}
+	app.maybeTopUpIsolatedMargin(ctx, "ETH")
+	if got := server.IsolatedMargin("ETH"); math.Abs(got-2.4) > 1e-9 {
+		t.Fatalf("expected no top-up above top_up_ratio, got %v", got)
+	}
+	if n := logs.FilterMessage("isolated margin topped up").Len(); n != 1 {
+		t.Fatalf("expected one top-up log, got %d", n)
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/logging"
+
+	"go.uber.org/zap"
+)
+
+// Reasons the janitor gives for cancelling an open order.
+const (
+	orphanUnknown = "unknown"
+	orphanStale   = "stale"
+)
+
+// orphanOrder is one resting order the janitor cancels.
+type orphanOrder struct {
+	Ref    account.OrderRef
+	Reason string
+	Age    time.Duration
+}
+
+// orphanOrders picks the open orders to cancel: ones this instance did not
+// place, by cloid or oid, and its own resting longer than janitor.max_age.
+// The crash stop rests by design and is never picked.
+func (a *App) orphanOrders(ctx context.Context, orders []map[string]any, now time.Time) []orphanOrder {
+	maxAge := a.config().Janitor.MaxAge
+	out := make([]orphanOrder, 0)
+	for _, ref := range account.OpenOrderRefs(a.withoutCrashStop(orders)) {
+		var age time.Duration
+		if !ref.PlacedAt.IsZero() {
+			age = now.Sub(ref.PlacedAt)
+		}
+		switch {
+		case !a.executor.Owns(ctx, ref.OrderID, ref.Cloid):
+			out = append(out, orphanOrder{Ref: ref, Reason: orphanUnknown, Age: age})
+		case maxAge > 0 && age > maxAge:
+			out = append(out, orphanOrder{Ref: ref, Reason: orphanStale, Age: age})
+		}
+	}
+	return out
+}
+
+// maybeRunJanitor lists open orders at most every janitor.interval and
+// cancels the orphans. It runs on the tick goroutine before the tick places
+// anything, so none of the tick's own orders are resting yet.
+func (a *App) maybeRunJanitor(ctx context.Context, now time.Time) {
+	cfg := a.config()
+	if cfg == nil || !cfg.Janitor.Enabled || a.readOnly() || a.account == nil || a.executor == nil || a.market == nil {
+		return
+	}
+	if !a.lastJanitorRun.IsZero() && now.Sub(a.lastJanitorRun) < cfg.Janitor.Interval {
+		return
+	}
+	a.lastJanitorRun = now
+	orders, err := a.account.OpenOrders(ctx)
+	if err != nil {
+		if a.log != nil {
+			a.log.Warn("janitor open orders failed", zap.Error(err))
+		}
+		return
+	}
+	orphans := a.orphanOrders(ctx, orders, now)
+	if len(orphans) == 0 {
+		return
+	}
+	lines := make([]string, 0, len(orphans))
+	refs := make([]account.OrderRef, 0, len(orphans))
+	for _, o := range orphans {
+		if a.metrics != nil {
+			a.metrics.OrphanOrders.Inc()
+		}
+		if a.log != nil {
+			a.log.Warn("orphan order found", logging.Unsampled(),
+				zap.String("reason", o.Reason),
+				zap.String("order_id", o.Ref.OrderID),
+				zap.String("cloid", o.Ref.Cloid),
+				zap.String("asset", o.Ref.AssetSymbol),
+				zap.Duration("age", o.Age),
+			)
+		}
+		lines = append(lines, fmt.Sprintf("%s oid %s (%s, age %s)", o.Ref.AssetSymbol, o.Ref.OrderID, o.Reason, o.Age.Round(time.Second)))
+		refs = append(refs, o.Ref)
+	}
+	a.cancelOrderRefs(ctx, refs)
+	if a.alerts == nil {
+		return
+	}
+	if err := a.alerts.Send(ctx, fmt.Sprintf("Janitor cancelled %d orphan order(s): %s", len(orphans), strings.Join(lines, "; "))); err != nil && a.log != nil {
+		a.log.Warn("janitor alert failed", zap.Error(err))
+	}
+}
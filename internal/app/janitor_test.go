@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hltest"
+
+	"go.uber.org/zap"
+)
+
+func TestJanitorCancelsUnknownAndStaleOrders(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	m, counters := newTestMetrics()
+	app.metrics = m
+	app.cfg.Janitor = config.JanitorConfig{Enabled: true, Interval: time.Minute}
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	app.executor = exec.New(&exchangeAdapter{client: client, tif: exchange.TifGtc, log: zap.NewNop()}, nil, zap.NewNop())
+	foreign := exec.New(&exchangeAdapter{client: client, tif: exchange.TifGtc, log: zap.NewNop()}, nil, zap.NewNop())
+	ctx := context.Background()
+
+	rest := exec.Order{Asset: hltest.PerpAsset, IsBuy: true, Size: 0.01, LimitPrice: 2000}
+	own := rest
+	own.ClientOrderID = "0x000000000000000000000000000000aa"
+	if _, err := app.executor.PlaceOrder(ctx, own); err != nil {
+		t.Fatalf("place own: %v", err)
+	}
+	if _, err := foreign.PlaceOrder(ctx, rest); err != nil {
+		t.Fatalf("place foreign: %v", err)
+	}
+	if got := len(server.OpenOrderIDs()); got != 2 {
+		t.Fatalf("expected 2 resting orders, got %d", got)
+	}
+
+	now := time.Now().UTC()
+	app.maybeRunJanitor(ctx, now)
+	if got := len(server.OpenOrderIDs()); got != 1 || counters.orphanOrders.count != 1 {
+		t.Fatalf("expected only the foreign order cancelled, got %d resting, %d orphans", got, counters.orphanOrders.count)
+	}
+
+	app.cfg.Janitor.MaxAge = 10 * time.Minute
+	app.maybeRunJanitor(ctx, now.Add(30*time.Second))
+	if got := len(server.OpenOrderIDs()); got != 1 {
+		t.Fatalf("expected the janitor to wait out its interval, got %d resting", got)
+	}
+	app.maybeRunJanitor(ctx, now.Add(5*time.Minute))
+	if got := len(server.OpenOrderIDs()); got != 1 {
+		t.Fatalf("expected the young own order kept, got %d resting", got)
+	}
+	app.maybeRunJanitor(ctx, now.Add(15*time.Minute))
+	if got := len(server.OpenOrderIDs()); got != 0 || counters.orphanOrders.count != 2 {
+		t.Fatalf("expected the stale own order cancelled, got %d resting, %d orphans", got, counters.orphanOrders.count)
+	}
+}
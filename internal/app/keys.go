@@ -0,0 +1,177 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/keystore"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// keySource is where one signing key is read from under keys.source: env for
+// the env source, the keystore at path, or the keychain entry of account.
+func keySource(keys config.KeysConfig, env, path, account string) keystore.Source {
+	return keystore.Source{
+		Kind:          keys.Source,
+		Env:           env,
+		Path:          path,
+		PassphraseEnv: keys.PassphraseEnv,
+		Service:       keys.KeychainService,
+		Account:       account,
+	}
+}
+
+// loadSecondarySigner reads the standby signing key from src, the same way
+// the active key is loaded. An unset variable, keystore path or keychain
+// account means no key is staged for rotation.
+func loadSecondarySigner(ctx context.Context, src keystore.Source, isMainnet bool) (*exchange.Signer, error) {
+	switch src.Kind {
+	case "", keystore.SourceEnv:
+		if src.Env == "" || strings.TrimSpace(os.Getenv(src.Env)) == "" {
+			return nil, nil
+		}
+	case keystore.SourceKeystore:
+		if src.Path == "" {
+			return nil, nil
+		}
+	case keystore.SourceKeychain:
+		if src.Account == "" {
+			return nil, nil
+		}
+	}
+	key, err := keystore.Load(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("secondary key: %w", err)
+	}
+	return exchange.NewSignerFromKey(key, isMainnet)
+}
+
+// verifySigner checks that addr may sign for the account: it is either the
+// account itself or an agent the account has approved (userRole).
+func (a *App) verifySigner(ctx context.Context, addr common.Address) error {
+	if strings.EqualFold(addr.Hex(), a.accountAddress) {
+		return nil
+	}
+	if a.rest == nil {
+		return errors.New("rest client is required to verify signer")
+	}
+	resp, err := a.rest.Info(ctx, map[string]any{"type": "userRole", "user": addr.Hex()})
+	if err != nil {
+		return fmt.Errorf("verify signer %s: %w", addr.Hex(), err)
+	}
+	role, _ := resp["role"].(string)
+	if role != "agent" {
+		return fmt.Errorf("signer %s has role %q, expected an agent of %s", addr.Hex(), role, a.accountAddress)
+	}
+	data, _ := resp["data"].(map[string]any)
+	user, _ := data["user"].(string)
+	if !strings.EqualFold(user, a.accountAddress) {
+		return fmt.Errorf("signer %s is an agent of %s, not %s", addr.Hex(), user, a.accountAddress)
+	}
+	return nil
+}
+
+// errAgentTransfer refuses a USDC class transfer while an API agent signs:
+// usdClassTransfer is user-signed, and Hyperliquid only accepts it from the
+// wallet's own key.
+var errAgentTransfer = errors.New("USDC class transfer refused: the active signer is an API agent, which cannot sign usdClassTransfer")
+
+// signerIsAgent reports whether addr signs as an API agent rather than as a
+// wallet (the account itself, or the master of a sub-account). The userRole
+// lookup is made once per address.
+func (a *App) signerIsAgent(ctx context.Context, addr common.Address) (bool, error) {
+	if strings.EqualFold(addr.Hex(), a.accountAddress) {
+		return false, nil
+	}
+	a.keyMu.Lock()
+	agent, ok := a.signerAgents[addr]
+	a.keyMu.Unlock()
+	if ok {
+		return agent, nil
+	}
+	if a.rest == nil {
+		return false, errors.New("rest client is required to look up the signer role")
+	}
+	resp, err := a.rest.Info(ctx, map[string]any{"type": "userRole", "user": addr.Hex()})
+	if err != nil {
+		return false, fmt.Errorf("signer role %s: %w", addr.Hex(), err)
+	}
+	role, _ := resp["role"].(string)
+	agent = role == "agent"
+	a.keyMu.Lock()
+	if a.signerAgents == nil {
+		a.signerAgents = make(map[common.Address]bool)
+	}
+	a.signerAgents[addr] = agent
+	a.keyMu.Unlock()
+	return agent, nil
+}
+
+// usdClassTransfer moves USDC between the spot and perp wallets, refusing
+// with errAgentTransfer before anything is signed while an agent key signs.
+func (a *App) usdClassTransfer(ctx context.Context, amount float64, toPerp bool) error {
+	if a.exchange == nil {
+		return errors.New("exchange client is required for transfers")
+	}
+	agent, err := a.signerIsAgent(ctx, a.exchange.SignerAddress())
+	if err != nil {
+		return err
+	}
+	if agent {
+		return errAgentTransfer
+	}
+	_, err = a.exchange.USDClassTransfer(ctx, amount, toPerp)
+	return err
+}
+
+// rotateSigner verifies the staged key and switches exchange signing to it.
+// The previous key becomes the staged one, so rotating again rolls back while
+// both keys are still valid.
+func (a *App) rotateSigner(ctx context.Context) (common.Address, common.Address, error) {
+	a.keyMu.Lock()
+	defer a.keyMu.Unlock()
+	if a.exchange == nil || a.activeSigner == nil {
+		return common.Address{}, common.Address{}, errors.New("exchange signer unavailable")
+	}
+	prev := a.activeSigner.Address()
+	if a.secondarySigner == nil {
+		return prev, common.Address{}, errors.New("no secondary key staged")
+	}
+	next := a.secondarySigner
+	if err := a.verifySigner(ctx, next.Address()); err != nil {
+		return prev, next.Address(), err
+	}
+	if err := a.exchange.RotateSigner(ctx, next); err != nil {
+		return prev, next.Address(), err
+	}
+	a.secondarySigner, a.activeSigner = a.activeSigner, next
+	if a.log != nil {
+		a.log.Info("signing key rotated", zap.String("from", prev.Hex()), zap.String("to", next.Address().Hex()))
+	}
+	return prev, next.Address(), nil
+}
+
+func (a *App) keyStatus() string {
+	a.keyMu.Lock()
+	defer a.keyMu.Unlock()
+	lines := []string{"active signer: none", "secondary signer: none"}
+	if a.activeSigner != nil {
+		lines[0] = "active signer: " + a.activeSigner.Address().Hex()
+	}
+	if a.secondarySigner != nil {
+		lines[1] = "secondary signer: " + a.secondarySigner.Address().Hex()
+	}
+	if a.exchange != nil {
+		if state, ok := a.exchange.NonceState(); ok {
+			lines = append(lines, "nonce key: "+state.Key)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/keystore"
+
+	gethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+func TestKeyRotateVerifiesAgentAndAudits(t *testing.T) {
+	primary, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	secondary, err := exchange.NewSigner("8da4ef21b864d2cc526dbdb2a120bd2874c36c9d0a1fb7f8c63d7f7a8b41de8f", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	account := primary.Address().Hex()
+	agentOf := account
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["type"] != "userRole" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"role": "agent", "data": map[string]any{"user": agentOf}})
+	}))
+	defer srv.Close()
+	exClient, err := exchange.NewClient("https://api.hyperliquid.xyz", 2*time.Second, primary, "")
+	if err != nil {
+		t.Fatalf("client init: %v", err)
+	}
+	store := &memoryStore{data: make(map[string]string)}
+	if err := exClient.InitNonceStore(context.Background(), store); err != nil {
+		t.Fatalf("init nonce store: %v", err)
+	}
+	app := &App{
+		rest:            rest.New(srv.URL, 2*time.Second, zap.NewNop()),
+		exchange:        exClient,
+		store:           store,
+		accountAddress:  account,
+		activeSigner:    primary,
+		secondarySigner: secondary,
+	}
+	meta := operatorMeta{UserID: 1, ChatID: 2, Raw: "/key rotate"}
+
+	agentOf = "0x0000000000000000000000000000000000000001"
+	if _, err := app.handleOperatorCommand(context.Background(), "key", []string{"rotate"}, meta); err == nil {
+		t.Fatalf("expected rotation to fail for agent of another account")
+	}
+	if exClient.SignerAddress() != primary.Address() {
+		t.Fatalf("expected signer unchanged after failed verification")
+	}
+
+	agentOf = strings.ToLower(account)
+	resp, err := app.handleOperatorCommand(context.Background(), "key", []string{"rotate"}, meta)
+	if err != nil {
+		t.Fatalf("rotate error: %v", err)
+	}
+	if !strings.Contains(resp, secondary.Address().Hex()) {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+	if exClient.SignerAddress() != secondary.Address() {
+		t.Fatalf("expected exchange to sign with secondary key")
+	}
+	if app.secondarySigner != primary {
+		t.Fatalf("expected previous key staged for rollback")
+	}
+	show, err := app.handleOperatorCommand(context.Background(), "key", []string{"show"}, meta)
+	if err != nil || !strings.Contains(show, "active signer: "+secondary.Address().Hex()) {
+		t.Fatalf("unexpected key show: %q (%v)", show, err)
+	}
+
+	var rotated, failed int
+	for key, raw := range store.data {
+		if !strings.HasPrefix(key, "ops:audit:") {
+			continue
+		}
+		var event operatorAuditEvent
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			t.Fatalf("decode audit: %v", err)
+		}
+		if event.Action != "key_rotate" {
+			continue
+		}
+		if event.Error != "" {
+			failed++
+			continue
+		}
+		if event.SignerBefore != primary.Address().Hex() || event.SignerAfter != secondary.Address().Hex() {
+			t.Fatalf("unexpected audit event: %+v", event)
+		}
+		rotated++
+	}
+	if rotated != 1 || failed != 1 {
+		t.Fatalf("expected one successful and one failed rotation audit, got %d/%d", rotated, failed)
+	}
+}
+
+func TestUSDClassTransferRefusedWhileAgentSigns(t *testing.T) {
+	agent, err := exchange.NewSigner("8da4ef21b864d2cc526dbdb2a120bd2874c36c9d0a1fb7f8c63d7f7a8b41de8f", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	var lookups, actions int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/exchange" {
+			actions++
+			_ = json.NewEncoder(w).Encode(map[string]any{"status": "ok"})
+			return
+		}
+		lookups++
+		_ = json.NewEncoder(w).Encode(map[string]any{"role": "agent", "data": map[string]any{"user": "0x0000000000000000000000000000000000000001"}})
+	}))
+	defer srv.Close()
+	exClient, err := exchange.NewClient(srv.URL, 2*time.Second, agent, "")
+	if err != nil {
+		t.Fatalf("client init: %v", err)
+	}
+	app := &App{
+		rest:           rest.New(srv.URL, 2*time.Second, zap.NewNop()),
+		exchange:       exClient,
+		accountAddress: "0x0000000000000000000000000000000000000001",
+		activeSigner:   agent,
+	}
+	for i := 0; i < 2; i++ {
+		if err := app.usdClassTransfer(context.Background(), 25, true); !errors.Is(err, errAgentTransfer) {
+			t.Fatalf("expected errAgentTransfer, got %v", err)
+		}
+	}
+	if actions != 0 {
+		t.Fatalf("expected no transfer sent, got %d", actions)
+	}
+	if lookups != 1 {
+		t.Fatalf("expected the signer role looked up once, got %d", lookups)
+	}
+
+	app.accountAddress = agent.Address().Hex()
+	if err := app.usdClassTransfer(context.Background(), 25, true); err != nil {
+		t.Fatalf("transfer signed by the account: %v", err)
+	}
+	if actions != 1 {
+		t.Fatalf("expected the account's own key to send the transfer, got %d", actions)
+	}
+}
+
+func TestLoadSecondarySignerFollowsKeySource(t *testing.T) {
+	const standbyHex = "8da4ef21b864d2cc526dbdb2a120bd2874c36c9d0a1fb7f8c63d7f7a8b41de8f"
+	standby, err := keystore.ParseHex(standbyHex)
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	want := crypto.PubkeyToAddress(standby.PublicKey)
+	ctx := context.Background()
+
+	keys := config.KeysConfig{Source: keystore.SourceEnv}
+	if signer, err := loadSecondarySigner(ctx, keySource(keys, "TEST_STANDBY_KEY", "", ""), true); signer != nil || err != nil {
+		t.Fatalf("expected no standby key staged, got %v (err=%v)", signer, err)
+	}
+	t.Setenv("TEST_STANDBY_KEY", standbyHex)
+	if signer, err := loadSecondarySigner(ctx, keySource(keys, "TEST_STANDBY_KEY", "", ""), true); err != nil || signer.Address() != want {
+		t.Fatalf("expected the env standby key, got %v (err=%v)", signer, err)
+	}
+
+	encrypted, err := gethkeystore.EncryptDataV3(crypto.FromECDSA(standby), []byte("hunter2"), gethkeystore.LightScryptN, gethkeystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	data, err := json.Marshal(map[string]any{"address": strings.TrimPrefix(strings.ToLower(want.Hex()), "0x"), "crypto": encrypted, "id": "3198bc9c-6672-5ab3-d995-4942343ae5b6", "version": 3})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "standby.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Setenv("TEST_KEYSTORE_PASSPHRASE", "hunter2")
+	keys = config.KeysConfig{Source: keystore.SourceKeystore, PassphraseEnv: "TEST_KEYSTORE_PASSPHRASE"}
+	if signer, err := loadSecondarySigner(ctx, keySource(keys, "TEST_STANDBY_KEY", "", ""), true); signer != nil || err != nil {
+		t.Fatalf("expected no standby keystore staged, got %v (err=%v)", signer, err)
+	}
+	if signer, err := loadSecondarySigner(ctx, keySource(keys, "", path, ""), true); err != nil || signer.Address() != want {
+		t.Fatalf("expected the keystore standby key, got %v (err=%v)", signer, err)
+	}
+	t.Setenv("TEST_KEYSTORE_PASSPHRASE", "wrong")
+	if _, err := loadSecondarySigner(ctx, keySource(keys, "", path, ""), true); err == nil {
+		t.Fatalf("expected a wrong passphrase to fail startup rather than stage nothing")
+	}
+
+	keys = config.KeysConfig{Source: keystore.SourceKeychain, KeychainService: "hl-carry-bot"}
+	if signer, err := loadSecondarySigner(ctx, keySource(keys, "", "", ""), true); signer != nil || err != nil {
+		t.Fatalf("expected no standby keychain entry staged, got %v (err=%v)", signer, err)
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/logging"
+
+	"go.uber.org/zap"
+)
+
+// perpLeverage is the leverage and margin mode set on the traded perp.
+type perpLeverage struct {
+	Leverage int
+	Cross    bool
+}
+
+// leverageRejection is a leverage setting that was refused, by the exchange
+// or the asset's max leverage, and the perp position it was refused at.
+type leverageRejection struct {
+	want     perpLeverage
+	position float64
+}
+
+func (l perpLeverage) mode() string {
+	if l.Cross {
+		return "cross"
+	}
+	return "isolated"
+}
+
+// syncPerpLeverage sets strategy.perp_leverage and strategy.perp_margin_mode
+// on the perp asset, so margin requirements follow the config rather than
+// whatever was last picked in the UI. It runs at startup and on every tick
+// but only sends updateLeverage when the wanted setting differs from the one
+// last applied, i.e. once per start and after a reload changes it. A failed
+// update warns and alerts once. One that could not be sent is retried on the
+// next tick; one the exchange refused (say, margin mode with a position open)
+// is not resent until the wanted setting or the perp position changes.
+func (a *App) syncPerpLeverage(ctx context.Context) {
+	if a.config() == nil || a.exchange == nil || a.market == nil || a.readOnly() {
+		return
+	}
+	cfg := a.config().Strategy
+	if cfg.PerpLeverage <= 0 {
+		return
+	}
+	want := perpLeverage{Leverage: cfg.PerpLeverage, Cross: cfg.PerpMarginMode != "isolated"}
+	if a.perpLeverageSet == want {
+		return
+	}
+	asset, ok := a.market.PerpAssetID(cfg.PerpAsset)
+	if !ok {
+		return
+	}
+	var position float64
+	if a.account != nil {
+		position = a.account.Snapshot().PerpPosition[cfg.PerpAsset]
+	}
+	rejection := leverageRejection{want: want, position: position}
+	if r := a.perpLeverageRejected; r != nil && *r == rejection {
+		return
+	}
+	var err error
+	if perpCtx, ok := a.market.PerpContext(cfg.PerpAsset); ok && perpCtx.MaxLeverage > 0 && float64(want.Leverage) > perpCtx.MaxLeverage {
+		err = fmt.Errorf("strategy.perp_leverage %d exceeds the %s max leverage of %g", want.Leverage, cfg.PerpAsset, perpCtx.MaxLeverage)
+		a.perpLeverageRejected = &rejection
+	} else {
+		var resp map[string]any
+		resp, err = a.exchange.UpdateLeverage(ctx, asset, want.Leverage, want.Cross)
+		if err == nil {
+			if err = exchange.ResponseError(resp); err != nil {
+				a.perpLeverageRejected = &rejection
+			}
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil || a.perpLeverageWarned {
+			return
+		}
+		a.perpLeverageWarned = true
+		if a.log != nil {
+			a.log.Warn("perp leverage update failed", logging.Unsampled(),
+				zap.Error(err),
+				zap.String("asset", cfg.PerpAsset),
+				zap.Int("leverage", want.Leverage),
+				zap.String("margin_mode", want.mode()),
+			)
+		}
+		if a.alerts != nil {
+			if alertErr := a.alerts.Send(ctx, fmt.Sprintf("Setting %s leverage to %dx %s failed: %v", cfg.PerpAsset, want.Leverage, want.mode(), err)); alertErr != nil && a.log != nil {
+				a.log.Warn("alert send failed", zap.Error(alertErr))
+			}
+		}
+		return
+	}
+	a.perpLeverageSet = want
+	a.perpLeverageRejected = nil
+	a.perpLeverageWarned = false
+	if a.log != nil {
+		a.log.Info("perp leverage set", logging.Audit(),
+			zap.String("asset", cfg.PerpAsset),
+			zap.Int("leverage", want.Leverage),
+			zap.String("margin_mode", want.mode()),
+		)
+	}
+}
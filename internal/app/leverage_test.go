@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hltest"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSyncPerpLeverageAppliesOnStartAndChange(t *testing.T) {
+	server := hltest.NewServer(t)
+	app := newNextTestApp(t, server)
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	app.exchange, err = exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("exchange client: %v", err)
+	}
+	core, logs := observer.New(zap.DebugLevel)
+	app.log = zap.New(core)
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+
+	app.syncPerpLeverage(ctx)
+	if got := server.LeverageUpdates(); len(got) != 0 {
+		t.Fatalf("expected no update without perp_leverage, got %+v", got)
+	}
+
+	app.cfg.Strategy.PerpLeverage = 3
+	app.cfg.Strategy.PerpMarginMode = "isolated"
+	app.syncPerpLeverage(ctx)
+	app.syncPerpLeverage(ctx)
+	got := server.LeverageUpdates()
+	if len(got) != 1 {
+		t.Fatalf("expected one update for an unchanged setting, got %+v", got)
+	}
+	if got[0].Asset != hltest.PerpAsset || got[0].Leverage != 3 || got[0].IsCross {
+		t.Fatalf("unexpected update %+v", got[0])
+	}
+
+	app.cfg.Strategy.PerpLeverage = 2
+	app.cfg.Strategy.PerpMarginMode = "cross"
+	app.syncPerpLeverage(ctx)
+	got = server.LeverageUpdates()
+	if len(got) != 2 || got[1].Leverage != 2 || !got[1].IsCross {
+		t.Fatalf("expected the changed setting applied, got %+v", got)
+	}
+	if n := logs.FilterMessage("perp leverage set").Len(); n != 2 {
+		t.Fatalf("expected 2 audit logs, got %d", n)
+	}
+
+	app.cfg.Strategy.PerpLeverage = 30
+	app.syncPerpLeverage(ctx)
+	app.syncPerpLeverage(ctx)
+	if got := server.LeverageUpdates(); len(got) != 2 {
+		t.Fatalf("expected leverage above the asset max not sent, got %+v", got)
+	}
+	if n := logs.FilterMessage("perp leverage update failed").Len(); n != 1 {
+		t.Fatalf("expected one warning, got %d", n)
+	}
+
+	app.cfg.Strategy.PerpLeverage = 5
+	app.cfg.ReadOnly = true
+	app.syncPerpLeverage(ctx)
+	if got := server.LeverageUpdates(); len(got) != 2 {
+		t.Fatalf("expected nothing sent in read-only mode, got %+v", got)
+	}
+}
+
+func TestSyncPerpLeverageBacksOffAfterRejection(t *testing.T) {
+	server := hltest.NewServer(t)
+	app := newNextTestApp(t, server)
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	app.exchange, err = exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("exchange client: %v", err)
+	}
+	core, logs := observer.New(zap.DebugLevel)
+	app.log = zap.New(core)
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+
+	app.cfg.Strategy.PerpLeverage = 3
+	app.cfg.Strategy.PerpMarginMode = "isolated"
+	server.RejectNextLeverage("Cannot switch leverage type with open position.")
+	app.syncPerpLeverage(ctx)
+	app.syncPerpLeverage(ctx)
+	app.syncPerpLeverage(ctx)
+	if got := server.LeverageUpdates(); len(got) != 1 {
+		t.Fatalf("expected the rejected update sent once, got %+v", got)
+	}
+	if n := logs.FilterMessage("perp leverage update failed").Len(); n != 1 {
+		t.Fatalf("expected one warning, got %d", n)
+	}
+
+	server.SetPerpPosition("ETH", -0.01)
+	if _, err := app.account.Reconcile(ctx); err != nil {
+		t.Fatalf("account reconcile: %v", err)
+	}
+	server.RejectNextLeverage("Cannot switch leverage type with open position.")
+	app.syncPerpLeverage(ctx)
+	app.syncPerpLeverage(ctx)
+	if got := server.LeverageUpdates(); len(got) != 2 {
+		t.Fatalf("expected one retry after the position changed, got %+v", got)
+	}
+
+	app.cfg.Strategy.PerpMarginMode = "cross"
+	app.syncPerpLeverage(ctx)
+	app.syncPerpLeverage(ctx)
+	got := server.LeverageUpdates()
+	if len(got) != 3 || !got[2].IsCross {
+		t.Fatalf("expected the changed setting sent once, got %+v", got)
+	}
+	if app.perpLeverageRejected != nil || app.perpLeverageSet.Leverage != 3 {
+		t.Fatalf("expected the accepted setting recorded, got %+v (rejected %+v)", app.perpLeverageSet, app.perpLeverageRejected)
+	}
+}
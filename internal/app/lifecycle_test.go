@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/hl/ws"
+	"hl-carry-bot/internal/hltest"
+
+	"go.uber.org/zap"
+)
+
+func TestOrderLifecycleAgainstFakeExchange(t *testing.T) {
+	server := hltest.NewServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	executor := exec.New(&exchangeAdapter{client: client, tif: exchange.TifIoc, log: zap.NewNop()}, nil, zap.NewNop())
+
+	restClient := rest.New(server.URL(), 2*time.Second, zap.NewNop())
+	wsClient := ws.New(server.WSURL(), 10*time.Millisecond, 0, zap.NewNop())
+	acct := account.New(restClient, wsClient, zap.NewNop(), "0xabc")
+	if err := acct.Start(ctx); err != nil {
+		t.Fatalf("account start: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for server.Subscribers("orderUpdates") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("account never subscribed to orderUpdates")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	app := &App{account: acct}
+
+	startMS := time.Now().Add(-time.Second).UnixMilli()
+	orderID, err := executor.PlaceOrder(ctx, exec.Order{Asset: hltest.PerpAsset, Size: 0.01, LimitPrice: 2990, Tif: string(exchange.TifIoc)})
+	if err != nil {
+		t.Fatalf("place order: %v", err)
+	}
+	filled, open, err := app.waitForOrderFill(ctx, orderID, startMS, time.Second, 10*time.Millisecond)
+	if err != nil || open || math.Abs(filled.Size-0.01) > 1e-9 {
+		t.Fatalf("expected a closed fill of 0.01, got filled=%f open=%v err=%v", filled.Size, open, err)
+	}
+	if got := server.PerpPosition("ETH"); math.Abs(got+0.01) > 1e-9 {
+		t.Fatalf("expected short 0.01 ETH on the exchange, got %v", got)
+	}
+
+	_, err = executor.PlaceOrder(ctx, exec.Order{Asset: hltest.PerpAsset, Size: 0.01, LimitPrice: 3100, Tif: string(exchange.TifIoc)})
+	if !errors.Is(err, exec.ErrNoImmediateMatch) {
+		t.Fatalf("expected an unmatched IOC rejection, got %v", err)
+	}
+	if got := len(server.Orders()); got != 2 {
+		t.Fatalf("expected the rejection not to be retried, got %d orders", got)
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/logging"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+// liquidationEstimate is the perp leg's estimated liquidation price and how
+// far mark is from it.
+type liquidationEstimate struct {
+	Price       float64
+	Mark        float64
+	DistancePct float64
+	Isolated    bool
+}
+
+// estimateLiquidation estimates the perp leg's liquidation price from its
+// size, the margin backing it, and the asset's maintenance rate (half the
+// initial margin rate at its max leverage). An isolated position is backed
+// by its own margin; a cross one by the cross account value less the other
+// cross positions' maintenance margin.
+func (a *App) estimateLiquidation(state account.State, perpAsset string, size, mark float64) (liquidationEstimate, bool) {
+	if size == 0 || mark <= 0 {
+		return liquidationEstimate{}, false
+	}
+	maxLeverage := 0.0
+	if a.market != nil {
+		if perpCtx, ok := a.market.PerpContext(perpAsset); ok {
+			maxLeverage = perpCtx.MaxLeverage
+		}
+	}
+	isolated, isIsolated := state.IsolatedMargin[perpAsset]
+	if maxLeverage <= 0 && isIsolated {
+		maxLeverage = float64(isolated.Leverage)
+	}
+	if maxLeverage <= 0 {
+		return liquidationEstimate{}, false
+	}
+	rate := 1 / (2 * maxLeverage)
+	var margin float64
+	switch {
+	case isIsolated:
+		margin = isolated.MarginUsed
+	case state.HasMarginSummary:
+		summary := state.MarginSummary
+		value := summary.AccountValue
+		if summary.HasCross {
+			value = summary.Cross.AccountValue
+		}
+		margin = value - math.Max(summary.MaintenanceMargin-math.Abs(size)*mark*rate, 0)
+	default:
+		return liquidationEstimate{}, false
+	}
+	price, ok := strategy.LiquidationPrice(size, mark, margin, rate)
+	if !ok {
+		return liquidationEstimate{}, false
+	}
+	return liquidationEstimate{
+		Price:       price,
+		Mark:        mark,
+		DistancePct: strategy.LiquidationDistancePct(mark, price),
+		Isolated:    isIsolated,
+	}, true
+}
+
+// liquidationMark is the price the liquidation estimate is taken at: the
+// perp mark, or its mid before the first mark arrives.
+func liquidationMark(snap strategy.MarketSnapshot) float64 {
+	if snap.MarkPrice > 0 {
+		return snap.MarkPrice
+	}
+	return snap.PerpMidPrice
+}
+
+// alertLiquidationDistance sends one alert when the liquidation_distance rule
+// starts firing, and logs once it clears.
+func (a *App) alertLiquidationDistance(ctx context.Context, in tickInputs, risk strategy.RiskAssessment) {
+	var violation *strategy.RiskViolation
+	for i := range risk.Violations {
+		if risk.Violations[i].Rule == strategy.RiskRuleLiquidationDistance {
+			violation = &risk.Violations[i]
+		}
+	}
+	if violation == nil {
+		if a.liquidationAlerted && a.log != nil {
+			a.log.Info("perp liquidation distance recovered")
+		}
+		a.liquidationAlerted = false
+		return
+	}
+	if a.liquidationAlerted {
+		return
+	}
+	a.liquidationAlerted = true
+	est := in.Liquidation
+	if a.log != nil {
+		a.log.Warn("perp mark near liquidation", logging.Unsampled(),
+			zap.String("asset", in.Snap.PerpAsset),
+			zap.Float64("mark", est.Mark),
+			zap.Float64("liquidation_price", est.Price),
+			zap.Float64("distance_pct", est.DistancePct),
+			zap.String("action", violation.Action.String()),
+		)
+	}
+	if a.alerts != nil {
+		msg := fmt.Sprintf("%s mark %.4f is %.2f%% from its estimated liquidation price %.4f (limit %.2f%%): risk action %s",
+			in.Snap.PerpAsset, est.Mark, est.DistancePct, est.Price, in.Risk.MinLiquidationDistancePct, violation.Action)
+		if err := a.alerts.Send(ctx, msg); err != nil && a.log != nil {
+			a.log.Warn("alert send failed", zap.Error(err))
+		}
+	}
+}
+
+// liquidationStatus is the perp leg's liquidation estimate for /status.
+func (a *App) liquidationStatus(state account.State, snap strategy.MarketSnapshot) string {
+	est, ok := a.estimateLiquidation(state, snap.PerpAsset, snap.PerpPosition, liquidationMark(snap))
+	if !ok {
+		return "liquidation: n/a"
+	}
+	return fmt.Sprintf("liquidation: %.4f (mark %.4f, distance %.2f%%, min %.2f%%)",
+		est.Price, est.Mark, est.DistancePct, a.riskConfig().MinLiquidationDistancePct)
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestEstimateLiquidation(t *testing.T) {
+	server := hltest.NewServer(t)
+	app := newNextTestApp(t, server)
+	if err := app.market.RefreshContexts(context.Background()); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+
+	// Short 1 ETH at 2000 with 25x max leverage keeps 2% maintenance: 200
+	// cross equity runs out at 2200/1.02.
+	cross := account.State{
+		HasMarginSummary: true,
+		MarginSummary: account.MarginSummary{
+			AccountValue:      250,
+			MaintenanceMargin: 40,
+			HasCross:          true,
+			Cross:             account.MarginTotals{AccountValue: 200},
+		},
+	}
+	est, ok := app.estimateLiquidation(cross, "ETH", -1, 2000)
+	if !ok || math.Abs(est.Price-2200/1.02) > 1e-6 || est.Isolated {
+		t.Fatalf("unexpected cross estimate %+v %v", est, ok)
+	}
+	if math.Abs(est.DistancePct-(2200/1.02-2000)/20) > 1e-6 {
+		t.Fatalf("unexpected distance %v", est.DistancePct)
+	}
+
+	// Other cross positions' maintenance comes out of the equity backing it.
+	cross.MarginSummary.MaintenanceMargin = 140
+	if est, ok := app.estimateLiquidation(cross, "ETH", -1, 2000); !ok || math.Abs(est.Price-2100/1.02) > 1e-6 {
+		t.Fatalf("unexpected estimate with other positions %+v %v", est, ok)
+	}
+
+	isolated := cross
+	isolated.IsolatedMargin = map[string]account.IsolatedMargin{"ETH": {MarginUsed: 50, PositionValue: 2000, Leverage: 10}}
+	if est, ok := app.estimateLiquidation(isolated, "ETH", -1, 2000); !ok || math.Abs(est.Price-2050/1.02) > 1e-6 || !est.Isolated {
+		t.Fatalf("unexpected isolated estimate %+v %v", est, ok)
+	}
+
+	if _, ok := app.estimateLiquidation(cross, "ETH", 0, 2000); ok {
+		t.Fatalf("expected no estimate when flat")
+	}
+	if _, ok := app.estimateLiquidation(account.State{}, "ETH", -1, 2000); ok {
+		t.Fatalf("expected no estimate without a margin summary")
+	}
+	snap := strategy.MarketSnapshot{PerpAsset: "ETH", PerpPosition: -1, PerpMidPrice: 2000}
+	if line := app.liquidationStatus(cross, snap); !strings.HasPrefix(line, "liquidation: 2058.8235") {
+		t.Fatalf("unexpected status %q", line)
+	}
+}
+
+func TestAlertLiquidationDistanceOnce(t *testing.T) {
+	server := hltest.NewServer(t)
+	app := newNextTestApp(t, server)
+	core, logs := observer.New(zap.DebugLevel)
+	app.log = zap.New(core)
+	ctx := context.Background()
+
+	in := tickInputs{
+		Snap:           strategy.MarketSnapshot{PerpAsset: "ETH"},
+		Liquidation:    liquidationEstimate{Price: 2100, Mark: 2000, DistancePct: 5},
+		HasLiquidation: true,
+	}
+	in.Risk.MinLiquidationDistancePct = 10
+	firing := strategy.EvaluateRisk(in.Risk, in.riskInputs())
+	if len(firing.Violations) != 1 || firing.Violations[0].Rule != strategy.RiskRuleLiquidationDistance {
+		t.Fatalf("expected the liquidation_distance rule to fire, got %+v", firing.Violations)
+	}
+	app.alertLiquidationDistance(ctx, in, firing)
+	app.alertLiquidationDistance(ctx, in, firing)
+	if n := logs.FilterMessage("perp mark near liquidation").Len(); n != 1 {
+		t.Fatalf("expected one warning, got %d", n)
+	}
+	app.alertLiquidationDistance(ctx, in, strategy.RiskAssessment{})
+	if n := logs.FilterMessage("perp liquidation distance recovered").Len(); n != 1 {
+		t.Fatalf("expected a recovery log, got %d", n)
+	}
+	app.alertLiquidationDistance(ctx, in, firing)
+	if n := logs.FilterMessage("perp mark near liquidation").Len(); n != 2 {
+		t.Fatalf("expected the warning again after recovery, got %d", n)
+	}
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// errMarginForecast refuses an entry whose perp leg would leave the perp
+// wallet short of initial margin or below risk.min_health_ratio. Nothing has
+// been sent when it is returned.
+var errMarginForecast = errors.New("projected perp margin insufficient")
+
+// marginForecast is the perp wallet's margin after opening notional USD of
+// perp at leverage: the account value is unchanged, the initial margin grows
+// by notional/leverage and the maintenance margin by half the initial margin
+// at the asset's max leverage.
+type marginForecast struct {
+	AccountValue      float64
+	InitialMargin     float64
+	MaintenanceMargin float64
+	HealthRatio       float64
+	HasHealthRatio    bool
+}
+
+// forecastMargin projects summary after the new perp notional. A
+// maxLeverage of 0 (not listed) charges maintenance at leverage instead,
+// which is never less than the exchange would.
+func forecastMargin(summary account.MarginSummary, notional, leverage, maxLeverage float64) marginForecast {
+	if leverage < 1 {
+		leverage = 1
+	}
+	if maxLeverage > 0 {
+		leverage = math.Min(leverage, maxLeverage)
+	} else {
+		maxLeverage = leverage
+	}
+	out := marginForecast{
+		AccountValue:      summary.AccountValue,
+		InitialMargin:     summary.TotalMarginUsed + notional/leverage,
+		MaintenanceMargin: summary.MaintenanceMargin + notional/(2*maxLeverage),
+	}
+	if out.MaintenanceMargin > 0 {
+		out.HealthRatio = out.AccountValue / out.MaintenanceMargin
+		out.HasHealthRatio = true
+	}
+	return out
+}
+
+// checkMarginForecast refuses an entry of notional USD on perpAsset when the
+// projected margin would not carry it. Without a margin summary there is
+// nothing to project and the exchange has the final say.
+func (a *App) checkMarginForecast(perpAsset string, notional float64) error {
+	if a.account == nil || notional <= 0 {
+		return nil
+	}
+	state := a.account.Snapshot()
+	if !state.HasMarginSummary {
+		return nil
+	}
+	maxLeverage := 0.0
+	if a.market != nil {
+		if perpCtx, ok := a.market.PerpContext(perpAsset); ok {
+			maxLeverage = perpCtx.MaxLeverage
+		}
+	}
+	risk := a.riskConfig()
+	forecast := forecastMargin(state.MarginSummary, notional, risk.AllocationLeverage, maxLeverage)
+	if forecast.InitialMargin > forecast.AccountValue {
+		return fmt.Errorf("%w: %.2f USD perp needs %.2f USD initial margin, account value is %.2f USD", errMarginForecast, notional, forecast.InitialMargin, forecast.AccountValue)
+	}
+	if risk.MinHealthRatio > 0 && forecast.HasHealthRatio && forecast.HealthRatio < risk.MinHealthRatio {
+		return fmt.Errorf("%w: health ratio after %.2f USD perp would be %.4f, below %.4f", errMarginForecast, notional, forecast.HealthRatio, risk.MinHealthRatio)
+	}
+	return nil
+}
+
+// skipOnMarginForecast reports whether err is an entry refused by the margin
+// forecast, counting it as a skipped tick.
+func (a *App) skipOnMarginForecast(err error) bool {
+	if !errors.Is(err, errMarginForecast) {
+		return false
+	}
+	a.countSkippedTick(metrics.SkipMarginForecast)
+	if a.log != nil {
+		a.log.Warn("entry refused by margin forecast", zap.Error(err))
+	}
+	return true
+}
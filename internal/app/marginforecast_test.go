@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/metrics"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+func TestForecastMargin(t *testing.T) {
+	summary := account.MarginSummary{AccountValue: 1000, TotalMarginUsed: 100, MaintenanceMargin: 50}
+	got := forecastMargin(summary, 2000, 5, 20)
+	if math.Abs(got.InitialMargin-500) > 1e-9 {
+		t.Fatalf("expected 100 + 2000/5 initial margin, got %f", got.InitialMargin)
+	}
+	if math.Abs(got.MaintenanceMargin-100) > 1e-9 {
+		t.Fatalf("expected 50 + 2000/40 maintenance margin, got %f", got.MaintenanceMargin)
+	}
+	if !got.HasHealthRatio || math.Abs(got.HealthRatio-10) > 1e-9 {
+		t.Fatalf("expected health ratio 10, got %+v", got)
+	}
+
+	// Leverage above the asset cap is clamped, and an unlisted cap charges
+	// maintenance at the configured leverage.
+	if got := forecastMargin(account.MarginSummary{AccountValue: 1000}, 1000, 50, 10); math.Abs(got.InitialMargin-100) > 1e-9 {
+		t.Fatalf("expected initial margin at the 10x cap, got %f", got.InitialMargin)
+	}
+	if got := forecastMargin(account.MarginSummary{AccountValue: 1000}, 1000, 2, 0); math.Abs(got.MaintenanceMargin-250) > 1e-9 {
+		t.Fatalf("expected maintenance at 2x, got %f", got.MaintenanceMargin)
+	}
+}
+
+func TestEntrySkipsWhenMarginForecastFails(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+	m, counters := newTestMetrics()
+	app.metrics = m
+	rest := &restingRest{}
+	app.executor = exec.New(rest, nil, zap.NewNop())
+	app.cfg.Risk.MinHealthRatio = 1e9
+
+	in, err := app.collectTickInputs(ctx)
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	if err := app.enterPosition(ctx, in.Snap); err != nil {
+		t.Fatalf("expected the entry skipped, got %v", err)
+	}
+	if rest.placed != 0 {
+		t.Fatalf("expected no entry leg sent, got %d placements", rest.placed)
+	}
+	if got := counters.ticksSkipped[metrics.SkipMarginForecast]; got == nil || got.count != 1 {
+		t.Fatalf("expected one tick skipped as margin_forecast, got %+v", counters.ticksSkipped)
+	}
+	if counters.entryFailed.count != 0 {
+		t.Fatalf("expected no entry failure, got %d", counters.entryFailed.count)
+	}
+	if app.strategy.State != strategy.StateIdle {
+		t.Fatalf("expected state back to idle, got %s", app.strategy.State)
+	}
+}
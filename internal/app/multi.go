@@ -0,0 +1,340 @@
+package app
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"hl-carry-bot/internal/accounting"
+	"hl-carry-bot/internal/alerts"
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/keystore"
+	"hl-carry-bot/internal/metrics"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// Multi runs one isolated App per entry in cfg.Accounts: each has its own
+// signer, exchange client, executor, account streams, market data and state
+// store. Accounts signed by the same key share that key's nonces. Metrics
+// share one listener with an account label, and alerts are prefixed with the
+// account name.
+type Multi struct {
+	cfg         *config.Config
+	log         *zap.Logger
+	names       []string
+	apps        map[string]*App
+	server      *http.Server
+	metricsAddr string
+	metricsPath string
+	reloadMu    sync.Mutex
+}
+
+func NewMulti(cfg *config.Config, log *zap.Logger) (*Multi, error) {
+	if len(cfg.Accounts) == 0 {
+		return nil, errors.New("accounts are required for multi-account mode")
+	}
+	if cfg.Telegram.OperatorEnabled {
+		log.Warn("telegram operator commands are disabled in multi-account mode")
+	}
+	if cfg.Timescale.Enabled {
+		log.Warn("timescale export is disabled in multi-account mode")
+	}
+	names := make([]string, 0, len(cfg.Accounts))
+	for _, acct := range cfg.Accounts {
+		names = append(names, acct.Name)
+	}
+	m := &Multi{cfg: cfg, log: log, names: names, apps: make(map[string]*App, len(names))}
+	var prom *metrics.PrometheusAccounts
+	var mux *http.ServeMux
+	if cfg.Metrics.EnabledValue() {
+		prom = metrics.NewPrometheusAccounts(names)
+		mux = http.NewServeMux()
+		mux.Handle(cfg.Metrics.Path, prom.Handler())
+		mux.Handle("/api/metrics-catalog", metrics.CatalogHandler())
+		mux.HandleFunc("/healthz", m.handleHealthz)
+		mux.HandleFunc("/readyz", m.handleReadyz)
+		m.metricsAddr = cfg.Metrics.Address
+		m.metricsPath = cfg.Metrics.Path
+		m.server = &http.Server{Addr: m.metricsAddr, Handler: mux}
+	}
+	baseAlerts := alerts.NewTelegram(cfg.Telegram, log)
+	keys := make(map[keystore.Source]*ecdsa.PrivateKey)
+	addresses := make(map[string]string, len(cfg.Accounts))
+	signers := make(map[common.Address]*App, len(cfg.Accounts))
+	for _, acct := range cfg.Accounts {
+		creds, err := accountCredentials(cfg, acct, keys)
+		if err != nil {
+			m.closeStores()
+			return nil, fmt.Errorf("account %s: %w", acct.Name, err)
+		}
+		addresses[acct.Name] = strings.ToLower(creds.AccountAddress)
+		out := sinks{alerts: baseAlerts.WithPrefix("[" + acct.Name + "] ")}
+		if prom != nil {
+			out.metrics = prom.Metrics(acct.Name)
+		} else {
+			out.metrics = metrics.NewNoop()
+		}
+		app, err := newApp(accountConfig(cfg, acct), log.With(zap.String("account", acct.Name)), creds, out)
+		if err != nil {
+			m.closeStores()
+			return nil, fmt.Errorf("account %s: %w", acct.Name, err)
+		}
+		m.apps[acct.Name] = app
+		if err := m.shareSignerNonces(app, creds, signers); err != nil {
+			m.closeStores()
+			return nil, fmt.Errorf("account %s: %w", acct.Name, err)
+		}
+		if cfg.Health.HeartbeatFile != "" {
+			app.heartbeat = m.heartbeat
+		}
+		if mux != nil {
+			mux.HandleFunc("/healthz/"+acct.Name, app.handleHealthz)
+			mux.HandleFunc("/readyz/"+acct.Name, app.handleReadyz)
+			mux.HandleFunc("/api/next/"+acct.Name, app.handleNextAPI)
+			mux.HandleFunc("/api/simulate/"+acct.Name, app.handleSimulateAPI)
+			mux.HandleFunc("/api/data-age/"+acct.Name, app.handleDataAgeAPI)
+			mux.HandleFunc("/api/shadow/"+acct.Name, app.handleShadowAPI)
+		}
+	}
+	m.splitSharedEquity(addresses)
+	return m, nil
+}
+
+// shareSignerNonces points app's exchange client at the nonces of the first
+// account signed by the same key. The exchange checks nonces per signer, so
+// separate counters (and stores) for sub-accounts of one master key would
+// collide.
+func (m *Multi) shareSignerNonces(app *App, creds credentials, signers map[common.Address]*App) error {
+	if creds.PrivateKey == nil || app.exchange == nil {
+		return nil
+	}
+	addr := app.exchange.SignerAddress()
+	owner, ok := signers[addr]
+	if !ok {
+		signers[addr] = app
+		return nil
+	}
+	if err := app.exchange.ShareNonces(owner.exchange); err != nil {
+		return err
+	}
+	m.log.Info("account shares its signer's nonces", zap.String("signer", addr.Hex()))
+	return nil
+}
+
+// splitSharedEquity gives accounts that trade the same exchange account a
+// share of its equity in proportion to their notional, so the startup
+// capital check and later re-evaluations cover their summed allocation.
+func (m *Multi) splitSharedEquity(addresses map[string]string) {
+	totals := make(map[string]float64, len(addresses))
+	counts := make(map[string]int, len(addresses))
+	for _, acct := range m.cfg.Accounts {
+		totals[addresses[acct.Name]] += acct.NotionalUSD
+		counts[addresses[acct.Name]]++
+	}
+	for _, acct := range m.cfg.Accounts {
+		address := addresses[acct.Name]
+		if counts[address] < 2 {
+			continue
+		}
+		m.apps[acct.Name].equityShare = acct.NotionalUSD / totals[address]
+		m.log.Info("account shares its exchange account; equity split by notional",
+			zap.String("account", acct.Name),
+			zap.String("address", address),
+			zap.Float64("equity_share", m.apps[acct.Name].equityShare),
+		)
+	}
+}
+
+// accountCredentials reads one account's addresses from its configured
+// variables and its signing key from keys.source; read_only loads none. Keys
+// already unlocked for another account are reused from loaded, so a shared
+// keystore is decrypted (and its passphrase prompted for) once. The account
+// queried is the vault/sub-account when set, else the wallet.
+func accountCredentials(cfg *config.Config, acct config.AccountConfig, loaded map[keystore.Source]*ecdsa.PrivateKey) (credentials, error) {
+	walletAddress := strings.TrimSpace(os.Getenv(acct.WalletAddressEnv))
+	if walletAddress == "" {
+		return credentials{}, fmt.Errorf("%s is required", acct.WalletAddressEnv)
+	}
+	src := keySource(cfg.Keys, acct.PrivateKeyEnv, acct.KeystorePath, walletAddress)
+	privateKey, ok := loaded[src]
+	if !ok && !cfg.ReadOnly {
+		key, err := keystore.Load(context.Background(), src)
+		if err != nil {
+			return credentials{}, err
+		}
+		loaded[src] = key
+		privateKey = key
+	}
+	accountAddress := acct.VaultAddress
+	if accountAddress == "" {
+		accountAddress = walletAddress
+	}
+	return credentials{
+		WalletAddress:  walletAddress,
+		PrivateKey:     privateKey,
+		AccountAddress: accountAddress,
+		VaultAddress:   acct.VaultAddress,
+		Secondary:      keySource(cfg.Keys, acct.SecondaryKeyEnv, acct.SecondaryKeystorePath, acct.SecondaryKeychainAccount),
+	}, nil
+}
+
+// accountConfig derives one account's config: its own notional and state
+// file (or Postgres namespace), an equal share of the REST weight budget (the limit is per IP), and
+// no per-account operator loop or Timescale writer.
+func accountConfig(cfg *config.Config, acct config.AccountConfig) *config.Config {
+	out := *cfg
+	out.Accounts = nil
+	out.Strategy.NotionalUSD = acct.NotionalUSD
+	out.State.SQLitePath = accountStatePath(cfg.State.SQLitePath, acct.Name)
+	out.State.Namespace = accountNamespace(cfg.State.Namespace, acct.Name)
+	n := len(cfg.Accounts)
+	if n > 1 {
+		out.REST.WeightPerMinute = cfg.REST.WeightPerMinute / n
+		reserve := cfg.REST.ReserveWeightValue() / n
+		out.REST.ReserveWeight = &reserve
+	}
+	out.Telegram.OperatorEnabled = false
+	out.Timescale.Enabled = false
+	out.Keys.SecondaryKeyEnv = acct.SecondaryKeyEnv
+	out.Keys.SecondaryKeystorePath = acct.SecondaryKeystorePath
+	out.Keys.SecondaryKeychainAccount = acct.SecondaryKeychainAccount
+	if acct.VaultAddress != "" {
+		// vaultTransfer moves the signer's own USDC, not the sub-account's.
+		out.Vault.AutoPark = false
+	}
+	return &out
+}
+
+// accountStatePath inserts the account name before the extension:
+// data/state.db -> data/state.main.db.
+func accountStatePath(path, name string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + name + ext
+}
+
+// accountNamespace scopes an account's Postgres state the same way:
+// default -> default.main.
+func accountNamespace(namespace, name string) string {
+	return namespace + "." + name
+}
+
+// Run starts every account and returns when ctx ends or any account stops
+// with an error, which cancels the rest.
+func (m *Multi) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	m.startMetricsServer(ctx)
+	go runWatchdog(ctx, m.log, m.startupDone, m.livenessFailures)
+	errs := make(chan error, len(m.names))
+	var wg sync.WaitGroup
+	for _, name := range m.names {
+		app := m.apps[name]
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			err := app.Run(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				m.log.Error("account stopped", zap.String("account", name), zap.Error(err))
+				errs <- fmt.Errorf("account %s: %w", name, err)
+				cancel()
+			}
+		}(name)
+	}
+	wg.Wait()
+	close(errs)
+	if err, ok := <-errs; ok {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Reload applies the runtime-tunable fields of cfg to every account. The
+// account list itself cannot change, and if any account would reject its
+// derived config none of them is reloaded.
+func (m *Multi) Reload(cfg *config.Config) error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+	merged, err := config.Reload(m.cfg, cfg)
+	if err != nil {
+		return err
+	}
+	queued := make(map[string]*config.Config, len(m.names))
+	for _, acct := range cfg.Accounts {
+		next, err := m.apps[acct.Name].prepareReload(accountConfig(cfg, acct))
+		if err != nil {
+			return fmt.Errorf("account %s: %w", acct.Name, err)
+		}
+		queued[acct.Name] = next
+	}
+	for name, next := range queued {
+		m.apps[name].queueReload(next)
+	}
+	m.cfg = merged
+	return nil
+}
+
+// RunBackfill backfills each account's journal in turn.
+func (m *Multi) RunBackfill(ctx context.Context, start time.Time) (accounting.Result, error) {
+	var total accounting.Result
+	var firstErr error
+	for _, name := range m.names {
+		res, err := m.apps[name].RunBackfill(ctx, start)
+		total.FillsInserted += res.FillsInserted
+		total.FillsDuplicate += res.FillsDuplicate
+		total.FundingInserted += res.FundingInserted
+		total.FundingDuplicate += res.FundingDuplicate
+		total.Pages += res.Pages
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("account %s: %w", name, err)
+		}
+	}
+	return total, firstErr
+}
+
+// Preflight runs each account's preflight in turn, naming every check after
+// its account.
+func (m *Multi) Preflight(ctx context.Context) PreflightReport {
+	var report PreflightReport
+	for _, name := range m.names {
+		for _, check := range m.apps[name].Preflight(ctx).Checks {
+			check.Name = name + "/" + check.Name
+			report.Checks = append(report.Checks, check)
+		}
+	}
+	return report
+}
+
+func (m *Multi) startMetricsServer(ctx context.Context) {
+	if m.server == nil {
+		return
+	}
+	m.log.Info("metrics server starting", zap.String("address", m.metricsAddr), zap.String("path", m.metricsPath), zap.Strings("accounts", m.names))
+	go func() {
+		if err := m.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			m.log.Warn("metrics server failed", zap.Error(err))
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := m.server.Shutdown(shutdownCtx); err != nil {
+			m.log.Warn("metrics server shutdown failed", zap.Error(err))
+		}
+	}()
+}
+
+func (m *Multi) closeStores() {
+	for _, app := range m.apps {
+		_ = app.store.Close()
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hl/exchange"
+
+	"go.uber.org/zap"
+)
+
+func TestAccountConfigIsolatesAccounts(t *testing.T) {
+	enabled := true
+	reserve := 200
+	cfg := &config.Config{
+		REST:     config.RESTConfig{WeightPerMinute: 1200, ReserveWeight: &reserve},
+		State:    config.StateConfig{SQLitePath: "data/state.db"},
+		Strategy: config.StrategyConfig{NotionalUSD: 50},
+		Telegram: config.TelegramConfig{OperatorEnabled: true},
+		Metrics:  config.MetricsConfig{Enabled: &enabled},
+		Accounts: []config.AccountConfig{
+			{Name: "main", NotionalUSD: 50},
+			{Name: "sub", NotionalUSD: 20, SecondaryKeyEnv: "HL_SUB_STANDBY_KEY"},
+		},
+	}
+	sub := accountConfig(cfg, cfg.Accounts[1])
+	if sub.State.SQLitePath != "data/state.sub.db" {
+		t.Fatalf("expected per-account state path, got %s", sub.State.SQLitePath)
+	}
+	if sub.Strategy.NotionalUSD != 20 || cfg.Strategy.NotionalUSD != 50 {
+		t.Fatalf("expected account notional without touching base config, got %f/%f", sub.Strategy.NotionalUSD, cfg.Strategy.NotionalUSD)
+	}
+	if sub.REST.WeightPerMinute != 600 || sub.REST.ReserveWeightValue() != 100 {
+		t.Fatalf("expected REST budget split across accounts, got %d/%d", sub.REST.WeightPerMinute, sub.REST.ReserveWeightValue())
+	}
+	if sub.Telegram.OperatorEnabled || len(sub.Accounts) != 0 || sub.Keys.SecondaryKeyEnv != "HL_SUB_STANDBY_KEY" {
+		t.Fatalf("unexpected derived account config: %+v", sub)
+	}
+}
+
+func TestNewMultiBuildsIsolatedAccounts(t *testing.T) {
+	const mainKey = "4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2"
+	const subKey = "8da4ef21b864d2cc526dbdb2a120bd2874c36c9d0a1fb7f8c63d7f7a8b41de8f"
+	mainSigner, err := exchange.NewSigner(mainKey, true)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	subSigner, err := exchange.NewSigner(subKey, true)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	t.Setenv("HL_MAIN_WALLET", mainSigner.Address().Hex())
+	t.Setenv("HL_MAIN_KEY", mainKey)
+	t.Setenv("HL_SUB_WALLET", subSigner.Address().Hex())
+	t.Setenv("HL_SUB_KEY", subKey)
+
+	enabled := true
+	vault := "0x1111111111111111111111111111111111111111"
+	cfg := &config.Config{
+		REST:     config.RESTConfig{BaseURL: "https://api.hyperliquid.xyz", WeightPerMinute: 1200},
+		State:    config.StateConfig{SQLitePath: filepath.Join(t.TempDir(), "state.db")},
+		Strategy: config.StrategyConfig{PerpAsset: "ETH", SpotAsset: "UETH", NotionalUSD: 50},
+		Metrics:  config.MetricsConfig{Enabled: &enabled, Address: "127.0.0.1:0", Path: "/metrics"},
+		Accounts: []config.AccountConfig{
+			{Name: "main", WalletAddressEnv: "HL_MAIN_WALLET", PrivateKeyEnv: "HL_MAIN_KEY", NotionalUSD: 50},
+			{Name: "sub", WalletAddressEnv: "HL_SUB_WALLET", PrivateKeyEnv: "HL_SUB_KEY", VaultAddress: vault, NotionalUSD: 20},
+		},
+	}
+	multi, err := NewMulti(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("new multi: %v", err)
+	}
+	defer multi.closeStores()
+
+	main, sub := multi.apps["main"], multi.apps["sub"]
+	if main == nil || sub == nil {
+		t.Fatalf("expected both accounts, got %v", multi.names)
+	}
+	if main.accountAddress != mainSigner.Address().Hex() || sub.accountAddress != vault {
+		t.Fatalf("unexpected account addresses: %s / %s", main.accountAddress, sub.accountAddress)
+	}
+	if main.exchange == sub.exchange || main.executor == sub.executor || main.store == sub.store || main.metrics == sub.metrics {
+		t.Fatalf("expected isolated per-account clients")
+	}
+	if main.metricsServer != nil || sub.metricsServer != nil || multi.server == nil {
+		t.Fatalf("expected one shared metrics server")
+	}
+	if sub.cfg.Strategy.NotionalUSD != 20 {
+		t.Fatalf("expected sub notional 20, got %f", sub.cfg.Strategy.NotionalUSD)
+	}
+
+	t.Setenv("HL_SUB_KEY", "")
+	if _, err := NewMulti(cfg, zap.NewNop()); err == nil {
+		t.Fatalf("expected error for missing account key")
+	}
+}
+
+func TestNewMultiSharesNoncesOfOneSigner(t *testing.T) {
+	const key = "4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2"
+	signer, err := exchange.NewSigner(key, true)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	t.Setenv("HL_MAIN_WALLET", signer.Address().Hex())
+	t.Setenv("HL_MAIN_KEY", key)
+
+	disabled := false
+	cfg := &config.Config{
+		REST:     config.RESTConfig{BaseURL: "https://api.hyperliquid.xyz", WeightPerMinute: 1200},
+		State:    config.StateConfig{SQLitePath: filepath.Join(t.TempDir(), "state.db")},
+		Strategy: config.StrategyConfig{PerpAsset: "ETH", SpotAsset: "UETH", NotionalUSD: 50},
+		Metrics:  config.MetricsConfig{Enabled: &disabled},
+		Accounts: []config.AccountConfig{
+			{Name: "main", WalletAddressEnv: "HL_MAIN_WALLET", PrivateKeyEnv: "HL_MAIN_KEY", NotionalUSD: 50},
+			{Name: "sub", WalletAddressEnv: "HL_MAIN_WALLET", PrivateKeyEnv: "HL_MAIN_KEY", VaultAddress: "0x1111111111111111111111111111111111111111", NotionalUSD: 20},
+		},
+	}
+	multi, err := NewMulti(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("new multi: %v", err)
+	}
+	defer multi.closeStores()
+
+	main, sub := multi.apps["main"], multi.apps["sub"]
+	ctx := context.Background()
+	for _, app := range []*App{sub, main} {
+		if err := app.exchange.InitNonceStore(ctx, app.store); err != nil {
+			t.Fatalf("init nonce store: %v", err)
+		}
+	}
+	mainState, ok := main.exchange.NonceState()
+	subState, subOK := sub.exchange.NonceState()
+	if !ok || !subOK || subState != mainState {
+		t.Fatalf("expected the sub-account on the main account's nonces, got %+v / %+v", subState, mainState)
+	}
+	if err := sub.exchange.RotateSigner(ctx, signer); err == nil {
+		t.Fatalf("expected a rotation refused while nonces are shared")
+	}
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+const nextAPITimeout = 5 * time.Second
+
+// nextActionReport is a dry run of the next tick: what the strategy would do
+// with current data, evaluated through the same path as the live tick.
+type nextActionReport struct {
+	GeneratedAt         time.Time            `json:"generated_at"`
+	NextTickAt          *time.Time           `json:"next_tick_at,omitempty"`
+	NextTickInMS        int64                `json:"next_tick_in_ms"`
+	State               string               `json:"state"`
+	Decision            string               `json:"decision"`
+	Action              string               `json:"action"`
+	Reason              string               `json:"reason,omitempty"`
+	Paused              bool                 `json:"paused"`
+	KillSwitchActive    bool                 `json:"kill_switch_active"`
+	ForeignActivity     bool                 `json:"foreign_activity"`
+	FundingRate         float64              `json:"funding_rate"`
+	FundingAPR          float64              `json:"funding_apr"`
+	NetExpectedCarryUSD float64              `json:"net_expected_carry_usd"`
+	DeltaUSD            float64              `json:"delta_usd"`
+	DeltaBandUSD        float64              `json:"delta_band_usd"`
+	Valuations          []strategy.Valuation `json:"valuations"`
+	RiskValuationBasis  string               `json:"risk_valuation_basis"`
+	RiskAction          string               `json:"risk_action"`
+	RiskRules           []string             `json:"risk_rules"`
+	CompoundAccruedUSD  float64              `json:"compound_accrued_usd"`
+	FundingOKCount      int                  `json:"funding_ok_count"`
+	FundingBadCount     int                  `json:"funding_bad_count"`
+	Orders              []plannedOrder       `json:"orders"`
+	OrderError          string               `json:"order_error,omitempty"`
+	HedgeCosts          []hedgeLegCost       `json:"hedge_costs,omitempty"`
+	HedgeLegReason      string               `json:"hedge_leg_reason,omitempty"`
+}
+
+func (a *App) nextAction(ctx context.Context) (nextActionReport, error) {
+	if a.config() == nil || a.market == nil || a.account == nil || a.strategy == nil {
+		return nextActionReport{}, errors.New("next action unavailable")
+	}
+	in, err := a.collectTickInputs(ctx)
+	if err != nil {
+		return nextActionReport{}, err
+	}
+	plan := a.evaluateTick(in)
+	report := nextActionReport{
+		GeneratedAt:         in.Now,
+		State:               string(plan.State),
+		Decision:            plan.Decision,
+		Action:              plan.Action,
+		Paused:              in.Paused,
+		KillSwitchActive:    a.killSwitchEngaged(),
+		ForeignActivity:     in.ForeignActivity,
+		FundingRate:         in.Snap.FundingRate,
+		FundingAPR:          in.Snap.FundingAPR(),
+		NetExpectedCarryUSD: in.NetCarryUSD,
+		DeltaUSD:            in.DeltaUSD,
+		DeltaBandUSD:        a.strategyConfig().DeltaBandUSD,
+		Valuations:          valuations(in.Snap),
+		RiskValuationBasis:  a.config().Risk.ValuationBasis,
+		RiskAction:          plan.Risk.Action.String(),
+		RiskRules:           plan.Risk.Rules(),
+		CompoundAccruedUSD:  in.CompoundAccruedUSD,
+		FundingOKCount:      plan.FundingOKCount,
+		FundingBadCount:     plan.FundingBadCount,
+		Orders:              plan.Orders,
+		HedgeCosts:          plan.HedgeCosts,
+		HedgeLegReason:      plan.HedgeLegReason,
+	}
+	if report.Orders == nil {
+		report.Orders = []plannedOrder{}
+	}
+	if plan.Err != nil {
+		report.Reason = plan.Err.Error()
+	}
+	if plan.OrderErr != nil {
+		report.OrderError = plan.OrderErr.Error()
+	}
+	if next := a.nextTick(); !next.IsZero() {
+		next = next.UTC()
+		report.NextTickAt = &next
+		if until := next.Sub(in.Now); until > 0 {
+			report.NextTickInMS = until.Milliseconds()
+		}
+	}
+	return report, nil
+}
+
+func formatNextAction(report nextActionReport) string {
+	lines := make([]string, 0, 8+len(report.Orders))
+	if report.NextTickAt != nil {
+		until := time.Duration(report.NextTickInMS) * time.Millisecond
+		lines = append(lines, fmt.Sprintf("next tick in %s (%s)", until.Round(time.Second), report.NextTickAt.Format(time.RFC3339)))
+	} else {
+		lines = append(lines, "next tick: n/a")
+	}
+	lines = append(lines,
+		fmt.Sprintf("state: %s", report.State),
+		fmt.Sprintf("decision: %s", report.Decision),
+		fmt.Sprintf("action: %s", report.Action),
+	)
+	if report.Reason != "" {
+		lines = append(lines, fmt.Sprintf("reason: %s", report.Reason))
+	}
+	for _, order := range report.Orders {
+		side := "sell"
+		if order.IsBuy {
+			side = "buy"
+		}
+		line := fmt.Sprintf("order: %s %s %s %.6f @ %.6f", order.Leg, order.Asset, side, order.Size, order.LimitPrice)
+		if order.Tif != "" {
+			line += " " + strings.ToLower(order.Tif)
+		}
+		if order.ReduceOnly {
+			line += " reduce_only"
+		}
+		lines = append(lines, line)
+	}
+	if report.HedgeLegReason != "" {
+		lines = append(lines, fmt.Sprintf("hedge_leg: %s", report.HedgeLegReason))
+	}
+	if report.OrderError != "" {
+		lines = append(lines, fmt.Sprintf("order_error: %s", report.OrderError))
+	}
+	lines = append(lines,
+		fmt.Sprintf("funding_rate: %.8f apr %.4f (ok %d / bad %d)", report.FundingRate, report.FundingAPR, report.FundingOKCount, report.FundingBadCount),
+		fmt.Sprintf("net_expected_carry_usd: %.4f", report.NetExpectedCarryUSD),
+		fmt.Sprintf("delta_usd: %.4f (band %.2f)", report.DeltaUSD, report.DeltaBandUSD),
+	)
+	return strings.Join(lines, "\n")
+}
+
+func (a *App) handleNextAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), nextAPITimeout)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+	report, err := a.nextAction(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil && a.log != nil {
+		a.log.Warn("next api response failed", zap.Error(err))
+	}
+}
+
+func (a *App) nextTick() time.Time {
+	a.opsMu.RLock()
+	defer a.opsMu.RUnlock()
+	return a.nextTickAt
+}
+
+func (a *App) setNextTick(at time.Time) {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+	a.nextTickAt = at
+}
@@ -0,0 +1,338 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/metrics"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+func newNextTestApp(t *testing.T, server *hltest.Server) *App {
+	t.Helper()
+	cfg := &config.Config{
+		Strategy: config.StrategyConfig{
+			PerpAsset:               "ETH",
+			SpotAsset:               "UETH",
+			NotionalUSD:             20,
+			MinFundingAPR:           0,
+			MaxVolatility:           1,
+			IOCPriceBps:             10,
+			FundingConfirmations:    1,
+			FundingDipConfirmations: 1,
+			DeltaBandUSD:            5,
+			MinExposureUSD:          10,
+			EntryInterval:           30 * time.Second,
+		},
+	}
+	app := &App{
+		cfg:      cfg,
+		log:      zap.NewNop(),
+		market:   newTestMarket(t, server.URL()),
+		account:  newTestAccount(t, server.URL()),
+		strategy: strategy.NewStateMachine(),
+	}
+	if _, err := app.account.Reconcile(context.Background()); err != nil {
+		t.Fatalf("account reconcile: %v", err)
+	}
+	return app
+}
+
+func TestNextActionPlansEntryWithoutSideEffects(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.setNextTick(time.Now().Add(20 * time.Second))
+
+	report, err := app.nextAction(context.Background())
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Action != tickActionEnter || report.Decision != "idle" {
+		t.Fatalf("expected enter/idle, got %s/%s", report.Action, report.Decision)
+	}
+	if len(report.Orders) != 2 {
+		t.Fatalf("expected 2 planned orders, got %d", len(report.Orders))
+	}
+	spot, perp := report.Orders[0], report.Orders[1]
+	if spot.Leg != "spot" || !spot.IsBuy || spot.AssetID != 10051 || math.Abs(spot.Size*3000-20) > 1e-9 {
+		t.Fatalf("unexpected spot order: %+v", spot)
+	}
+	if perp.Leg != "perp" || perp.IsBuy || perp.AssetID != 1 || perp.Size != 0.006 {
+		t.Fatalf("unexpected perp order: %+v", perp)
+	}
+	if spot.LimitPrice <= 3000 || perp.LimitPrice >= 3000 {
+		t.Fatalf("expected IOC offsets around 3000, got spot %f perp %f", spot.LimitPrice, perp.LimitPrice)
+	}
+	if report.NextTickAt == nil || report.NextTickInMS <= 0 || report.NextTickInMS > 20_000 {
+		t.Fatalf("unexpected next tick countdown: %+v", report)
+	}
+	if app.rt.fundingOKCount != 0 || app.rt.fundingBadCount != 0 {
+		t.Fatalf("expected funding counters untouched, got ok=%d bad=%d", app.rt.fundingOKCount, app.rt.fundingBadCount)
+	}
+	if app.strategy.State != strategy.StateIdle {
+		t.Fatalf("expected idle state, got %s", app.strategy.State)
+	}
+}
+
+func TestNextActionMatchesTickDecision(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.EntryCooldown = time.Minute
+	app.rt.entryCooldownUntil = time.Now().Add(time.Minute)
+
+	report, err := app.nextAction(context.Background())
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Decision != "skip_entry_cooldown" || report.Action != tickActionHold {
+		t.Fatalf("expected skip_entry_cooldown/hold, got %s/%s", report.Decision, report.Action)
+	}
+	text, err := app.handleOperatorCommand(context.Background(), "next", nil, operatorMeta{})
+	if err != nil {
+		t.Fatalf("operator next: %v", err)
+	}
+	if !strings.Contains(text, "decision: skip_entry_cooldown") || !strings.Contains(text, "next tick: n/a") {
+		t.Fatalf("unexpected /next output: %q", text)
+	}
+}
+
+func TestNextAPI(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.setPaused(true)
+
+	rec := httptest.NewRecorder()
+	app.handleNextAPI(rec, httptest.NewRequest(http.MethodGet, "/api/next", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var report nextActionReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Decision != "paused" || !report.Paused || report.Action != tickActionHold {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	rec = httptest.NewRecorder()
+	app.handleNextAPI(rec, httptest.NewRequest(http.MethodPost, "/api/next", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestPausedTickCountedAsSkipped(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	metricsStub, counters := newTestMetrics()
+	app.metrics = metricsStub
+	app.setPaused(true)
+	if counters.paused.value != 1 {
+		t.Fatalf("expected paused gauge 1, got %v", counters.paused.value)
+	}
+	if err := app.tick(context.Background()); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if got := counters.ticksSkipped[metrics.SkipPaused]; got == nil || got.count != 1 {
+		t.Fatalf("expected one tick skipped as paused, got %+v", counters.ticksSkipped)
+	}
+	app.setPaused(false)
+	if counters.paused.value != 0 {
+		t.Fatalf("expected paused gauge 0, got %v", counters.paused.value)
+	}
+}
+
+func TestEntrySkippedOnVenueFundingPremium(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetFundingRate("0.0005")
+	server.SetFundingVenues([]any{
+		[]any{"BinPerp", map[string]any{"fundingRate": "0.0008", "nextFundingTime": time.Now().Add(time.Hour).UnixMilli(), "fundingIntervalHours": 8}},
+	})
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.MaxVenueFundingPremium = 0.0002
+	if _, err := app.market.RefreshFundingForecast(context.Background()); err != nil {
+		t.Fatalf("refresh funding forecast: %v", err)
+	}
+
+	report, err := app.nextAction(context.Background())
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Decision != "skip_venue_premium" || report.Action != tickActionHold {
+		t.Fatalf("expected skip_venue_premium/hold, got %s/%s (%s)", report.Decision, report.Action, report.Reason)
+	}
+
+	app.cfg.Strategy.MaxVenueFundingPremium = 0.001
+	report, err = app.nextAction(context.Background())
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Action != tickActionEnter {
+		t.Fatalf("expected entry under a wider premium limit, got %s/%s", report.Decision, report.Action)
+	}
+}
+
+func TestEntryRequiresTrailingFunding(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	now := time.Now()
+	for i := 0; i < 24; i++ {
+		rate := "0.000001"
+		if i == 23 {
+			rate = "0.0005"
+		}
+		server.AppendFundingHistory(map[string]any{
+			"coin":        "ETH",
+			"fundingRate": rate,
+			"time":        now.Add(time.Duration(i-23) * time.Hour).UnixMilli(),
+		})
+	}
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.MinTrailingFunding = 0.00005
+	app.cfg.Strategy.TrailingFundingWindow = 24 * time.Hour
+
+	report, err := app.nextAction(context.Background())
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Decision != "skip_trailing_funding" || !strings.Contains(report.Reason, "unavailable") {
+		t.Fatalf("expected skip while history is missing, got %s (%s)", report.Decision, report.Reason)
+	}
+
+	app.refreshFundingHistory(context.Background())
+	report, err = app.nextAction(context.Background())
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Decision != "skip_trailing_funding" || !strings.Contains(report.Reason, "below") {
+		t.Fatalf("expected skip on low 24h average, got %s (%s)", report.Decision, report.Reason)
+	}
+
+	app.cfg.Strategy.TrailingFundingWindow = time.Hour
+	report, err = app.nextAction(context.Background())
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Action != tickActionEnter {
+		t.Fatalf("expected entry on a healthy 1h average, got %s/%s (%s)", report.Decision, report.Action, report.Reason)
+	}
+}
+
+func TestEntryDelayedOnSellTradeFlow(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.MaxSellImbalance = 0.6
+
+	in, err := app.collectTickInputs(context.Background())
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	if in.Snap.HasTradeImbalance {
+		t.Fatalf("expected no trade flow without trades")
+	}
+	if plan := app.evaluateTick(in); plan.Action != tickActionEnter {
+		t.Fatalf("expected entry without trade flow, got %s/%s", plan.Decision, plan.Action)
+	}
+
+	in.Snap.TradeImbalance, in.Snap.HasTradeImbalance = -0.8, true
+	plan := app.evaluateTick(in)
+	if plan.Decision != "skip_trade_flow" || plan.Action != tickActionHold || !errors.Is(plan.Err, strategy.ErrTradeFlow) {
+		t.Fatalf("expected skip_trade_flow/hold, got %s/%s (%v)", plan.Decision, plan.Action, plan.Err)
+	}
+
+	in.Snap.TradeImbalance = 0.8
+	if plan := app.evaluateTick(in); plan.Action != tickActionEnter {
+		t.Fatalf("expected entry on buy flow, got %s/%s", plan.Decision, plan.Action)
+	}
+}
+
+func TestEntryDelayedNearFundingTime(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(90 * time.Second).UnixMilli())
+	app := newNextTestApp(t, server)
+	if _, err := app.market.RefreshFundingForecast(context.Background()); err != nil {
+		t.Fatalf("refresh funding forecast: %v", err)
+	}
+
+	in, err := app.collectTickInputs(context.Background())
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	if plan := app.evaluateTick(in); plan.Action != tickActionEnter {
+		t.Fatalf("expected entry with the guard off, got %s/%s", plan.Decision, plan.Action)
+	}
+
+	app.cfg.Strategy.EntryFundingGuard = 2 * time.Minute
+	plan := app.evaluateTick(in)
+	if plan.Decision != "skip_funding_time" || plan.Action != tickActionHold || !errors.Is(plan.Err, strategy.ErrEntryFundingGuard) {
+		t.Fatalf("expected skip_funding_time/hold, got %s/%s (%v)", plan.Decision, plan.Action, plan.Err)
+	}
+
+	// Just after the funding timestamp the next one is an interval away.
+	in.Now = in.Forecast.NextFunding.Add(time.Second)
+	in.Forecast.NextFunding = in.Forecast.NextFunding.Add(time.Hour)
+	if plan := app.evaluateTick(in); plan.Action != tickActionEnter {
+		t.Fatalf("expected entry after funding, got %s/%s (%v)", plan.Decision, plan.Action, plan.Err)
+	}
+}
+
+func TestEntryStagedToExchangeMinimum(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	// 10 USD at 3000 rounds the perp leg to 0.003 ETH, about 9 USD.
+	app.cfg.Strategy.NotionalUSD = 10
+
+	report, err := app.nextAction(context.Background())
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Action != tickActionHold || report.Decision != "skip_min_order" || !strings.Contains(report.Reason, "12.00 USD") {
+		t.Fatalf("expected the entry refused with the notional needed, got %s/%s (%s)", report.Decision, report.Action, report.Reason)
+	}
+
+	app.cfg.Strategy.MinOrderBumpPct = 25
+	report, err = app.nextAction(context.Background())
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Action != tickActionEnter || len(report.Orders) != 2 {
+		t.Fatalf("expected an entry within the bump tolerance, got %s/%s (%s)", report.Decision, report.Action, report.Reason)
+	}
+	spot, perp := report.Orders[0], report.Orders[1]
+	if spot.Size != 0.004 || perp.Size != 0.004 || spot.Size*spot.LimitPrice < config.MinOrderValueUSD || perp.Size*perp.LimitPrice < config.MinOrderValueUSD {
+		t.Fatalf("expected both legs raised to 0.004 above the minimum, got spot %+v perp %+v", spot, perp)
+	}
+
+	app.cfg.Risk.MaxNotionalUSD = 11
+	report, err = app.nextAction(context.Background())
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Decision != "skip_min_order" {
+		t.Fatalf("expected the bump capped by risk.max_notional_usd, got %s (%s)", report.Decision, report.Reason)
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"strings"
 	"time"
 
+	"hl-carry-bot/internal/account"
 	"hl-carry-bot/internal/alerts"
 	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/strategy"
 
 	"go.uber.org/zap"
 )
@@ -26,37 +28,49 @@ type operatorMeta struct {
 }
 
 type operatorAuditEvent struct {
-	UpdateID     int64              `json:"update_id"`
-	Time         time.Time          `json:"time"`
-	Action       string             `json:"action"`
-	Command      string             `json:"command"`
-	UserID       int64              `json:"user_id"`
-	Username     string             `json:"username,omitempty"`
-	ChatID       int64              `json:"chat_id"`
-	PausedBefore bool               `json:"paused_before"`
-	PausedAfter  bool               `json:"paused_after"`
-	RiskBefore   *config.RiskConfig `json:"risk_before,omitempty"`
-	RiskAfter    *config.RiskConfig `json:"risk_after,omitempty"`
+	UpdateID       int64              `json:"update_id"`
+	Time           time.Time          `json:"time"`
+	Action         string             `json:"action"`
+	Command        string             `json:"command"`
+	UserID         int64              `json:"user_id"`
+	Username       string             `json:"username,omitempty"`
+	ChatID         int64              `json:"chat_id"`
+	PausedBefore   bool               `json:"paused_before"`
+	PausedAfter    bool               `json:"paused_after"`
+	RiskBefore     *config.RiskConfig `json:"risk_before,omitempty"`
+	RiskAfter      *config.RiskConfig `json:"risk_after,omitempty"`
+	StrategyBefore *strategyParams    `json:"strategy_before,omitempty"`
+	StrategyAfter  *strategyParams    `json:"strategy_after,omitempty"`
+	SignerBefore   string             `json:"signer_before,omitempty"`
+	SignerAfter    string             `json:"signer_after,omitempty"`
+	VaultUSD       float64            `json:"vault_usd,omitempty"`
+	ReducePct      float64            `json:"reduce_pct,omitempty"`
+	// ConfirmAction, RequestedBy and RequestCommand tie a confirmation step
+	// to the held command it answers.
+	ConfirmAction  string `json:"confirm_action,omitempty"`
+	RequestedBy    int64  `json:"requested_by,omitempty"`
+	RequestCommand string `json:"request_command,omitempty"`
+	Error          string `json:"error,omitempty"`
 }
 
 func (a *App) startOperator(ctx context.Context) {
-	if a.cfg == nil || a.alerts == nil || a.log == nil {
+	if a.config() == nil || a.alerts == nil || a.log == nil {
 		return
 	}
-	if !a.cfg.Telegram.OperatorEnabled {
+	if !a.config().Telegram.OperatorEnabled {
 		return
 	}
-	chatID, err := strconv.ParseInt(strings.TrimSpace(a.cfg.Telegram.ChatID), 10, 64)
+	chatID, err := strconv.ParseInt(strings.TrimSpace(a.config().Telegram.ChatID), 10, 64)
 	if err != nil {
 		a.log.Warn("telegram operator disabled: invalid chat_id", zap.Error(err))
 		return
 	}
-	pollInterval := a.cfg.Telegram.OperatorPollInterval
+	pollInterval := a.config().Telegram.OperatorPollInterval
 	if pollInterval <= 0 {
 		pollInterval = 3 * time.Second
 	}
-	allowedUsers := make(map[int64]struct{}, len(a.cfg.Telegram.OperatorAllowedUserIDs))
-	for _, id := range a.cfg.Telegram.OperatorAllowedUserIDs {
+	allowedUsers := make(map[int64]struct{}, len(a.config().Telegram.OperatorAllowedUserIDs))
+	for _, id := range a.config().Telegram.OperatorAllowedUserIDs {
 		allowedUsers[id] = struct{}{}
 	}
 	go a.operatorLoop(ctx, chatID, allowedUsers, pollInterval)
@@ -128,7 +142,7 @@ func (a *App) handleOperatorUpdate(ctx context.Context, upd alerts.Update, chatI
 	if resp == "" {
 		return
 	}
-	if err := a.alerts.Send(ctx, resp); err != nil {
+	if err := a.alerts.Reply(ctx, resp); err != nil {
 		a.log.Warn("operator response failed", zap.Error(err))
 	}
 }
@@ -150,9 +164,32 @@ func parseOperatorCommand(text string) (string, []string, bool) {
 }
 
 func (a *App) handleOperatorCommand(ctx context.Context, cmd string, args []string, meta operatorMeta) (string, error) {
+	if a.readOnly() && readOnlyRefuses(cmd, args) {
+		return "", errReadOnly
+	}
 	switch cmd {
 	case "status":
 		return a.operatorStatus(ctx), nil
+	case "next":
+		report, err := a.nextAction(ctx)
+		if err != nil {
+			return "", err
+		}
+		return formatNextAction(report), nil
+	case "simulate":
+		overrides := map[string]string{}
+		if len(args) > 0 {
+			parsed, err := parseOverrides("simulate", args)
+			if err != nil {
+				return "", err
+			}
+			overrides = parsed
+		}
+		report, err := a.simulateEntry(ctx, overrides)
+		if err != nil {
+			return "", err
+		}
+		return formatSimulation(report), nil
 	case "pause":
 		before := a.isPaused()
 		after := a.setPaused(true)
@@ -172,25 +209,35 @@ func (a *App) handleOperatorCommand(ctx context.Context, cmd string, args []stri
 		}
 		return "trading already paused", nil
 	case "resume":
-		before := a.isPaused()
-		after := a.setPaused(false)
-		a.auditOperatorEvent(ctx, operatorAuditEvent{
-			UpdateID:     meta.UpdateID,
-			Time:         time.Now().UTC(),
-			Action:       "resume",
-			Command:      meta.Raw,
-			UserID:       meta.UserID,
-			Username:     meta.Username,
-			ChatID:       meta.ChatID,
-			PausedBefore: before,
-			PausedAfter:  after,
-		})
-		if !after {
-			return "trading resumed", nil
+		if a.confirmationRequired() && a.lossHaltActive() {
+			summary := "trading is halted by the daily loss limit and resuming acknowledges the halt"
+			return a.requestConfirmation(ctx, "resume", summary, meta, func(ctx context.Context) (string, error) {
+				return a.resumeTrading(ctx, meta), nil
+			}), nil
 		}
-		return "trading already active", nil
+		return a.resumeTrading(ctx, meta), nil
+	case "confirm":
+		return a.confirmPendingCommand(ctx, "", meta)
+	case "cancel":
+		return a.cancelPendingCommand(ctx, "", meta)
 	case "risk":
 		return a.handleRiskCommand(ctx, args, meta)
+	case "strategy":
+		return a.handleStrategyCommand(ctx, args, meta)
+	case "flatten":
+		return a.handleFlattenCommand(ctx, args, meta)
+	case "reduce":
+		return a.handleReduceCommand(ctx, args, meta)
+	case "pnl":
+		return a.pnlReport(ctx)
+	case "funding":
+		return a.fundingReport(ctx)
+	case "decisions":
+		return a.decisionsReport(ctx, args)
+	case "key":
+		return a.handleKeyCommand(ctx, args, meta)
+	case "vault":
+		return a.handleVaultCommand(ctx, args, meta)
 	case "help":
 		return operatorHelpText(), nil
 	default:
@@ -198,73 +245,151 @@ func (a *App) handleOperatorCommand(ctx context.Context, cmd string, args []stri
 	}
 }
 
+// resumeTrading unpauses trading and acknowledges any daily loss halt.
+func (a *App) resumeTrading(ctx context.Context, meta operatorMeta) string {
+	before := a.isPaused()
+	after := a.setPaused(false)
+	a.acknowledgeLossHalt(ctx)
+	a.clearFailureStreak()
+	a.auditOperatorEvent(ctx, operatorAuditEvent{
+		UpdateID:     meta.UpdateID,
+		Time:         time.Now().UTC(),
+		Action:       "resume",
+		Command:      meta.Raw,
+		UserID:       meta.UserID,
+		Username:     meta.Username,
+		ChatID:       meta.ChatID,
+		PausedBefore: before,
+		PausedAfter:  after,
+	})
+	if !after {
+		return "trading resumed"
+	}
+	return "trading already active"
+}
+
 func (a *App) handleRiskCommand(ctx context.Context, args []string, meta operatorMeta) (string, error) {
 	if len(args) == 0 || strings.EqualFold(args[0], "show") {
 		return a.riskStatus(), nil
 	}
 	switch strings.ToLower(args[0]) {
 	case "reset":
-		before := a.riskOverrideSnapshot()
-		a.clearRiskOverride()
-		a.auditOperatorEvent(ctx, operatorAuditEvent{
-			UpdateID:   meta.UpdateID,
-			Time:       time.Now().UTC(),
-			Action:     "risk_reset",
-			Command:    meta.Raw,
-			UserID:     meta.UserID,
-			Username:   meta.Username,
-			ChatID:     meta.ChatID,
-			RiskBefore: before,
-		})
-		return "risk override cleared", nil
+		if loosened := riskLoosened(a.config().Risk, a.riskConfig()); a.confirmationRequired() && len(loosened) > 0 {
+			return a.requestConfirmation(ctx, "risk", looserRiskSummary(loosened), meta, func(ctx context.Context) (string, error) {
+				return a.resetRisk(ctx, meta), nil
+			}), nil
+		}
+		return a.resetRisk(ctx, meta), nil
 	case "set":
-		overrides, err := parseRiskOverrides(args[1:])
+		overrides, err := parseOverrides("risk", args[1:])
 		if err != nil {
 			return "", err
 		}
-		before := a.riskOverrideSnapshot()
-		base := a.riskConfig()
-		next, err := applyRiskOverrides(base, overrides)
+		next, err := applyRiskOverrides(a.riskConfig(), overrides)
 		if err != nil {
 			return "", err
 		}
-		if riskConfigsEqual(next, a.cfg.Risk) {
-			a.clearRiskOverride()
-		} else {
-			a.setRiskOverride(next)
+		if loosened := riskLoosened(next, a.riskConfig()); a.confirmationRequired() && len(loosened) > 0 {
+			return a.requestConfirmation(ctx, "risk", looserRiskSummary(loosened), meta, func(ctx context.Context) (string, error) {
+				return a.setRisk(ctx, overrides, meta)
+			}), nil
 		}
-		after := a.riskOverrideSnapshot()
-		a.auditOperatorEvent(ctx, operatorAuditEvent{
-			UpdateID:   meta.UpdateID,
-			Time:       time.Now().UTC(),
-			Action:     "risk_set",
-			Command:    meta.Raw,
-			UserID:     meta.UserID,
-			Username:   meta.Username,
-			ChatID:     meta.ChatID,
-			RiskBefore: before,
-			RiskAfter:  after,
-		})
-		return "risk override updated", nil
+		return a.setRisk(ctx, overrides, meta)
 	default:
 		return "", errors.New("unknown risk command: use /risk show|set|reset")
 	}
 }
 
-func parseRiskOverrides(args []string) (map[string]string, error) {
+func (a *App) resetRisk(ctx context.Context, meta operatorMeta) string {
+	before := a.riskOverrideSnapshot()
+	a.clearRiskOverride()
+	a.clearFailureStreak()
+	a.auditOperatorEvent(ctx, operatorAuditEvent{
+		UpdateID:   meta.UpdateID,
+		Time:       time.Now().UTC(),
+		Action:     "risk_reset",
+		Command:    meta.Raw,
+		UserID:     meta.UserID,
+		Username:   meta.Username,
+		ChatID:     meta.ChatID,
+		RiskBefore: before,
+	})
+	return "risk override cleared"
+}
+
+// setRisk applies the /risk set overrides on top of the limits in force when
+// it runs, which for a confirmed request may differ from those it was sent
+// against.
+func (a *App) setRisk(ctx context.Context, overrides map[string]string, meta operatorMeta) (string, error) {
+	before := a.riskOverrideSnapshot()
+	next, err := applyRiskOverrides(a.riskConfig(), overrides)
+	if err != nil {
+		return "", err
+	}
+	if riskConfigsEqual(next, a.config().Risk) {
+		a.clearRiskOverride()
+	} else {
+		a.setRiskOverride(next)
+	}
+	after := a.riskOverrideSnapshot()
+	a.auditOperatorEvent(ctx, operatorAuditEvent{
+		UpdateID:   meta.UpdateID,
+		Time:       time.Now().UTC(),
+		Action:     "risk_set",
+		Command:    meta.Raw,
+		UserID:     meta.UserID,
+		Username:   meta.Username,
+		ChatID:     meta.ChatID,
+		RiskBefore: before,
+		RiskAfter:  after,
+	})
+	return "risk override updated", nil
+}
+
+func (a *App) handleKeyCommand(ctx context.Context, args []string, meta operatorMeta) (string, error) {
+	if len(args) == 0 || strings.EqualFold(args[0], "show") {
+		return a.keyStatus(), nil
+	}
+	if !strings.EqualFold(args[0], "rotate") {
+		return "", errors.New("unknown key command: use /key show|rotate")
+	}
+	prev, next, err := a.rotateSigner(ctx)
+	event := operatorAuditEvent{
+		UpdateID:     meta.UpdateID,
+		Time:         time.Now().UTC(),
+		Action:       "key_rotate",
+		Command:      meta.Raw,
+		UserID:       meta.UserID,
+		Username:     meta.Username,
+		ChatID:       meta.ChatID,
+		SignerBefore: prev.Hex(),
+		SignerAfter:  prev.Hex(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+		a.auditOperatorEvent(ctx, event)
+		return "", err
+	}
+	event.SignerAfter = next.Hex()
+	a.auditOperatorEvent(ctx, event)
+	return fmt.Sprintf("signing key rotated: %s -> %s (previous key staged for rollback)", prev.Hex(), next.Hex()), nil
+}
+
+// parseOverrides reads the key=value pairs of a /risk or /strategy set.
+func parseOverrides(kind string, args []string) (map[string]string, error) {
 	if len(args) == 0 {
-		return nil, errors.New("risk set requires key=value pairs")
+		return nil, fmt.Errorf("%s set requires key=value pairs", kind)
 	}
 	out := make(map[string]string)
 	for _, arg := range args {
 		parts := strings.SplitN(arg, "=", 2)
 		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid risk setting: %s", arg)
+			return nil, fmt.Errorf("invalid %s setting: %s", kind, arg)
 		}
 		key := strings.ToLower(strings.TrimSpace(parts[0]))
 		val := strings.TrimSpace(parts[1])
 		if key == "" || val == "" {
-			return nil, fmt.Errorf("invalid risk setting: %s", arg)
+			return nil, fmt.Errorf("invalid %s setting: %s", kind, arg)
 		}
 		out[key] = val
 	}
@@ -344,20 +469,21 @@ func validateRiskOverride(risk config.RiskConfig) error {
 }
 
 func (a *App) operatorStatus(ctx context.Context) string {
-	if a.cfg == nil {
+	cfg := a.config()
+	if cfg == nil {
 		return "status unavailable"
 	}
 	state := "unknown"
 	if a.strategy != nil {
-		state = string(a.strategy.State)
+		state = string(a.strategy.Current())
 	}
 	accountSnap := a.account.Snapshot()
-	spotBalance := a.spotBalanceForAsset(a.cfg.Strategy.SpotAsset, accountSnap.SpotBalances)
-	perpPosition := accountSnap.PerpPosition[a.cfg.Strategy.PerpAsset]
-	spotMid, _, _ := a.spotMid(ctx, a.cfg.Strategy.SpotAsset)
-	perpMid, _ := a.market.Mid(ctx, a.cfg.Strategy.PerpAsset)
-	oraclePrice, _ := a.market.OraclePrice(a.cfg.Strategy.PerpAsset)
-	fundingRate, _ := a.market.FundingRate(a.cfg.Strategy.PerpAsset)
+	spotBalance := a.spotBalanceForAsset(cfg.Strategy.SpotAsset, accountSnap.SpotBalances)
+	perpPosition := accountSnap.PerpPosition[cfg.Strategy.PerpAsset]
+	spotMid, _, _ := a.spotMid(ctx, cfg.Strategy.SpotAsset)
+	perpMid, _ := a.market.Mid(ctx, cfg.Strategy.PerpAsset)
+	oraclePrice, _ := a.market.OraclePrice(cfg.Strategy.PerpAsset)
+	fundingRate, _ := a.market.FundingRate(cfg.Strategy.PerpAsset)
 	priceRef := oraclePrice
 	if priceRef == 0 {
 		priceRef = perpMid
@@ -366,7 +492,17 @@ func (a *App) operatorStatus(ctx context.Context) string {
 		priceRef = spotMid
 	}
 	deltaUSD := (spotBalance + perpPosition) * priceRef
-	forecast, hasForecast := a.market.FundingForecast(a.cfg.Strategy.PerpAsset)
+	markPrice, _ := a.market.MarkPrice(cfg.Strategy.PerpAsset)
+	valuationSnap := strategy.MarketSnapshot{
+		PerpAsset:    cfg.Strategy.PerpAsset,
+		SpotMidPrice: spotMid,
+		PerpMidPrice: perpMid,
+		OraclePrice:  oraclePrice,
+		MarkPrice:    markPrice,
+		SpotBalance:  spotBalance,
+		PerpPosition: perpPosition,
+	}
+	forecast, hasForecast := a.market.FundingForecast(cfg.Strategy.PerpAsset)
 	nextFunding := "n/a"
 	if hasForecast && forecast.HasNext {
 		nextFunding = forecast.NextFunding.UTC().Format(time.RFC3339)
@@ -376,22 +512,63 @@ func (a *App) operatorStatus(ctx context.Context) string {
 	hedgeCooldownActive := a.hedgeCooldownActive(time.Now().UTC())
 	riskOverride := a.riskOverrideActive()
 	lastFunding := "n/a"
-	if !a.lastFundingReceiptAt.IsZero() {
-		lastFunding = a.lastFundingReceiptAt.UTC().Format(time.RFC3339)
+	if lastReceipt := a.lastFundingReceipt(); !lastReceipt.IsZero() {
+		lastFunding = lastReceipt.UTC().Format(time.RFC3339)
 	}
-	return strings.Join([]string{
+	lines := []string{
 		fmt.Sprintf("state: %s", state),
 		fmt.Sprintf("paused: %t", paused),
-		fmt.Sprintf("spot_balance: %.6f %s", spotBalance, a.cfg.Strategy.SpotAsset),
-		fmt.Sprintf("perp_position: %.6f %s", perpPosition, a.cfg.Strategy.PerpAsset),
-		fmt.Sprintf("delta_usd: %.4f (band %.2f)", deltaUSD, a.cfg.Strategy.DeltaBandUSD),
-		fmt.Sprintf("funding_rate: %.8f", fundingRate),
+		fmt.Sprintf("kill_switch_active: %t", a.killSwitchEngaged()),
+		fmt.Sprintf("foreign_activity: %t", a.foreignActivityActive(time.Now().UTC())),
+		fmt.Sprintf("spot_balance: %.6f %s", spotBalance, cfg.Strategy.SpotAsset),
+		fmt.Sprintf("perp_position: %.6f %s", perpPosition, cfg.Strategy.PerpAsset),
+		fmt.Sprintf("delta_usd: %.4f (band %.2f)", deltaUSD, a.strategyConfig().DeltaBandUSD),
+		valuationStatus(valuationSnap),
+		marginStatus(accountSnap),
+		a.liquidationStatus(accountSnap, valuationSnap),
+		fmt.Sprintf("funding_rate: %.8f (apr %.4f, min %.4f)", fundingRate, strategy.FundingAPR(fundingRate, forecast.Interval), a.strategyConfig().MinFundingAPR),
+		fmt.Sprintf("fee_bps: %.4f (%s)", a.feeBps(), a.feeSource()),
+		a.basisStatus(),
 		fmt.Sprintf("next_funding_at: %s", nextFunding),
 		fmt.Sprintf("entry_cooldown_active: %t", entryCooldownActive),
 		fmt.Sprintf("hedge_cooldown_active: %t", hedgeCooldownActive),
 		fmt.Sprintf("risk_override_active: %t", riskOverride),
+		fmt.Sprintf("strategy_override_active: %t", a.strategyOverrideActive()),
+		a.riskEngineStatus(),
+		a.dailyPnLStatus(),
+		a.circuitStatus(),
 		fmt.Sprintf("last_funding_receipt: %s", lastFunding),
-	}, "\n")
+	}
+	if a.readOnly() {
+		lines = append([]string{"mode: read-only (no orders or transfers)"}, lines...)
+	}
+	if cfg.Compound.Enabled {
+		lines = append(lines, fmt.Sprintf("compound_accrued_usd: %.4f (increment %.2f)", a.compoundAccrued(), cfg.Compound.IncrementUSD))
+	}
+	if line, ok := a.isolatedMarginStatus(cfg.Strategy.PerpAsset); ok {
+		lines = append(lines, line)
+	}
+	if cfg.Vault.Address != "" {
+		lines = append(lines, fmt.Sprintf("vault_parked_usd: %.2f", a.vaultParked()))
+	}
+	if capUSD, ok := a.notionalCap(); ok {
+		lines = append(lines, fmt.Sprintf("notional_cap_usd: %.2f (capped to account equity)", capUSD))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// marginStatus is the perp wallet's margin summary for /status.
+func marginStatus(state account.State) string {
+	if !state.HasMarginSummary {
+		return "margin: n/a"
+	}
+	m := state.MarginSummary
+	withdrawable := "n/a"
+	if m.HasWithdrawable {
+		withdrawable = fmt.Sprintf("%.2f", m.Withdrawable)
+	}
+	return fmt.Sprintf("margin: account_value %.2f, margin_used %.2f, ntl_pos %.2f, withdrawable %s",
+		m.AccountValue, m.TotalMarginUsed, m.TotalNtlPos, withdrawable)
 }
 
 func (a *App) riskStatus() string {
@@ -426,11 +603,28 @@ func operatorHelpText() string {
 	return strings.Join([]string{
 		"commands:",
 		"/status - current bot status",
+		"/next - dry run of the next tick (decision, orders, countdown)",
+		"/simulate [key=value ...] - entry gates and projected carry with hypothetical strategy values (keys as /strategy set, or notional, min_apr)",
+		"/pnl - realized and unrealized PnL since entry",
+		"/funding - funding received since entry and the next payment",
+		"/decisions [window|HH:MM] - recorded tick decisions for the last window (default 1h) or around a UTC time",
 		"/pause - pause new trading actions",
-		"/resume - resume trading actions",
+		"/resume - resume trading actions (after a daily loss halt, asks for /confirm when confirmation is required)",
 		"/risk show - show active risk settings",
 		"/risk set key=value ... - override risk (keys: max_notional_usd, max_open_orders, min_margin_ratio, min_health_ratio, max_market_age, max_account_age)",
 		"/risk reset - clear risk override",
+		"/confirm - confirm the held risky command (looser /risk, /resume after a loss halt, /flatten)",
+		"/cancel - drop the held command",
+		"/strategy show - show active strategy parameters",
+		"/strategy set key=value ... - override strategy (keys: min_funding_apr, carry_buffer_usd, notional_usd, max_volatility, delta_band_usd)",
+		"/strategy reset - clear strategy override",
+		"/flatten - cancel open orders and exit now (asks for /confirm)",
+		"/reduce PCT - reduce the hedge to PCT percent of its current size",
+		"/key show - active and staged signing keys",
+		"/key rotate - verify the staged key and switch signing to it",
+		"/vault show - configured vault and USDC parked in it",
+		"/vault deposit X - move X USDC from perp into the vault",
+		"/vault withdraw X - move X USDC from the vault back to perp",
 	}, "\n")
 }
 
@@ -444,6 +638,9 @@ func (a *App) setPaused(paused bool) bool {
 	a.opsMu.Lock()
 	defer a.opsMu.Unlock()
 	a.paused = paused
+	if a.metrics != nil && a.metrics.Paused != nil {
+		a.metrics.Paused.Set(boolGauge(paused))
+	}
 	return a.paused
 }
 
@@ -452,7 +649,7 @@ func (a *App) riskConfig() config.RiskConfig {
 	override := a.riskOverride
 	a.opsMu.RUnlock()
 	if override == nil {
-		return a.cfg.Risk
+		return a.config().Risk
 	}
 	return *override
 }
@@ -539,5 +736,27 @@ func riskConfigsEqual(aCfg config.RiskConfig, bCfg config.RiskConfig) bool {
 		aCfg.MinMarginRatio == bCfg.MinMarginRatio &&
 		aCfg.MinHealthRatio == bCfg.MinHealthRatio &&
 		aCfg.MaxMarketAge == bCfg.MaxMarketAge &&
-		aCfg.MaxAccountAge == bCfg.MaxAccountAge
+		aCfg.MaxAccountAge == bCfg.MaxAccountAge &&
+		aCfg.MaxDeltaUSD == bCfg.MaxDeltaUSD &&
+		aCfg.MaxDailyLossUSD == bCfg.MaxDailyLossUSD &&
+		aCfg.DailyResetHour == bCfg.DailyResetHour &&
+		aCfg.DailyPnLRefresh == bCfg.DailyPnLRefresh &&
+		aCfg.MaxConsecutiveFailures == bCfg.MaxConsecutiveFailures &&
+		aCfg.FailureCoolOffValue() == bCfg.FailureCoolOffValue() &&
+		aCfg.MaxClockDrift == bCfg.MaxClockDrift &&
+		aCfg.ClockSyncInterval == bCfg.ClockSyncInterval &&
+		aCfg.MinLiquidationDistancePct == bCfg.MinLiquidationDistancePct &&
+		aCfg.Actions == bCfg.Actions
+}
+
+func valuationStatus(snap strategy.MarketSnapshot) string {
+	parts := make([]string, 0, 2)
+	for _, v := range valuations(snap) {
+		parts = append(parts, fmt.Sprintf("%s %.4f (notional %.2f, delta %.4f)", v.Basis, v.Price, v.NotionalUSD, v.DeltaUSD))
+	}
+	out := "valuation: " + strings.Join(parts, "; ")
+	if divergence, ok := strategy.MarkOracleDivergence(snap); ok {
+		out += fmt.Sprintf("; divergence %.3f%%", divergence*100)
+	}
+	return out
 }
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"errors"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// syncOrderBudget applies risk.max_open_orders as the executor's per-asset
+// order cap and forgets tracked resting orders the account no longer lists.
+func (a *App) syncOrderBudget(in tickInputs) {
+	if a.executor == nil {
+		return
+	}
+	a.executor.SetOrderBudget(in.Risk.MaxOpenOrders)
+	if a.account == nil {
+		return
+	}
+	asOf := a.account.LastUpdate()
+	if asOf.IsZero() {
+		return
+	}
+	refs := account.OpenOrderRefs(in.OpenOrders)
+	open := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.OrderID != "" {
+			open = append(open, ref.OrderID)
+		}
+	}
+	a.executor.SyncOpenOrders(open, asOf)
+}
+
+// skipOnOrderBudget reports whether err is the executor refusing an order
+// over an asset's order budget. Such a refusal sent nothing, so it is
+// counted as a skipped tick rather than a failed trade.
+func (a *App) skipOnOrderBudget(action string, err error) bool {
+	if !errors.Is(err, exec.ErrOrderBudgetExceeded) {
+		return false
+	}
+	a.countSkippedTick(metrics.SkipOrderBudget)
+	if a.log != nil {
+		a.log.Info("order budget used up; skipping", zap.String("action", action), zap.Error(err))
+	}
+	return true
+}
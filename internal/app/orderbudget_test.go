@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/metrics"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+type restingRest struct {
+	placed int
+}
+
+func (r *restingRest) PlaceOrder(context.Context, exec.Order) (exec.Placement, error) {
+	r.placed++
+	return exec.Placement{OrderID: "9001", Status: exec.PlacementResting}, nil
+}
+
+func (r *restingRest) CancelOrder(context.Context, exec.Cancel) error {
+	return nil
+}
+
+func TestEntrySkipsWhenOrderBudgetUsedUp(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+	m, counters := newTestMetrics()
+	app.metrics = m
+	rest := &restingRest{}
+	app.executor = exec.New(rest, nil, zap.NewNop())
+	app.cfg.Risk.MaxOpenOrders = 1
+
+	in, err := app.collectTickInputs(ctx)
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	app.syncOrderBudget(in)
+	perpID, ok := app.market.PerpAssetID(app.cfg.Strategy.PerpAsset)
+	if !ok {
+		t.Fatalf("perp asset id not found")
+	}
+	if _, err := app.executor.PlaceOrder(ctx, exec.Order{Asset: perpID, Size: 0.01, LimitPrice: 1000}); err != nil {
+		t.Fatalf("place resting order: %v", err)
+	}
+
+	if err := app.enterPosition(ctx, in.Snap); err != nil {
+		t.Fatalf("expected the entry skipped, got %v", err)
+	}
+	if rest.placed != 1 {
+		t.Fatalf("expected no entry leg sent, got %d placements", rest.placed)
+	}
+	if got := counters.ticksSkipped[metrics.SkipOrderBudget]; got == nil || got.count != 1 {
+		t.Fatalf("expected one tick skipped as order_budget, got %+v", counters.ticksSkipped)
+	}
+	if counters.entryFailed.count != 0 {
+		t.Fatalf("expected no entry failure, got %d", counters.entryFailed.count)
+	}
+	if app.strategy.State != strategy.StateIdle {
+		t.Fatalf("expected state back to idle, got %s", app.strategy.State)
+	}
+}
@@ -0,0 +1,857 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/precision"
+	"hl-carry-bot/internal/market"
+	"hl-carry-bot/internal/metrics"
+	"hl-carry-bot/internal/strategy"
+)
+
+const (
+	tickActionEnter = "enter"
+	tickActionExit  = "exit"
+	tickActionHedge = "hedge"
+	tickActionHold  = "hold"
+	// tickActionCompound grows a hedged position by one funding-funded add-on.
+	tickActionCompound = "compound"
+	// tickActionReduce shrinks a hedged position for a reduce risk action.
+	tickActionReduce = "reduce"
+)
+
+// tickInputs is the market/account view a tick decides on. Collecting it reads
+// cached market and account state, except that a mid the feed has not
+// delivered yet is fetched over REST (market.Mid falls back to allMids); it
+// never places orders or mutates App.
+type tickInputs struct {
+	Now                 time.Time
+	Snap                strategy.MarketSnapshot
+	OpenOrders          []map[string]any
+	Risk                config.RiskConfig
+	Flat                bool
+	FlatStrict          bool
+	SpotExposureUSD     float64
+	PerpExposureUSD     float64
+	DeltaUSD            float64
+	Ages                strategy.DataAges
+	EntryCooldownActive bool
+	HedgeCooldownActive bool
+	Paused              bool
+	ForeignActivity     bool
+	Forecast            market.FundingForecast
+	HasForecast         bool
+	ForecastAge         time.Duration
+	VenuePremium        float64
+	HasVenuePremium     bool
+	TrailingFunding     float64
+	HasTrailingFunding  bool
+	// VaultRecallLocked is set when flat and an entry would need parked USDC
+	// back before VaultLockedUntil.
+	VaultRecallLocked  bool
+	VaultLockedUntil   time.Time
+	MinExpectedFunding float64
+	ExpectedFunding    float64
+	NetCarryUSD        float64
+	EstimatedCostUSD   float64
+	CompoundAccruedUSD float64
+	// RiskReduced is set once a reduce risk action has shrunk the position;
+	// it clears when the action does.
+	RiskReduced         bool
+	Basis               float64
+	HasBasis            bool
+	EntryBasis          float64
+	HasEntryBasis       bool
+	DailyPnL            strategy.DailyPnL
+	HasDailyPnL         bool
+	LossHalt            bool
+	ConsecutiveFailures int
+	ClockDrift          time.Duration
+	HasClockDrift       bool
+	Liquidation         liquidationEstimate
+	HasLiquidation      bool
+	CircuitOpen         bool
+	CircuitOpenUntil    time.Time
+}
+
+// tickPlan is the side-effect-free outcome of evaluating tickInputs. The real
+// tick applies it; /next and /api/next only report it.
+type tickPlan struct {
+	StateBefore         strategy.State
+	State               strategy.State
+	Decision            string
+	Action              string
+	Err                 error
+	FundingRateOK       bool
+	NetCarryOK          bool
+	FundingOKCount      int
+	FundingBadCount     int
+	FundingOKConfirmed  bool
+	FundingBadConfirmed bool
+	EnterSignal         bool
+	ExitSignal          bool
+	ExitGuarded         bool
+	TimeToFunding       time.Duration
+	Steady              bool
+	Orders              []plannedOrder
+	OrderErr            error
+	Risk                strategy.RiskAssessment
+	// HedgeCosts and HedgeLegReason explain a hedge's leg choice.
+	HedgeCosts     []hedgeLegCost
+	HedgeLegReason string
+}
+
+type plannedOrder struct {
+	Leg        string  `json:"leg"`
+	Asset      string  `json:"asset"`
+	AssetID    int     `json:"asset_id"`
+	IsBuy      bool    `json:"is_buy"`
+	Size       float64 `json:"size"`
+	LimitPrice float64 `json:"limit_price"`
+	ReduceOnly bool    `json:"reduce_only,omitempty"`
+	Tif        string  `json:"tif,omitempty"`
+}
+
+type entryPlan struct {
+	Spot              plannedOrder
+	Perp              plannedOrder
+	SpotRollbackLimit float64
+	PerpSzDecimals    int
+	SpotSzDecimals    int
+	// BumpedFromSize is the size before it was raised to clear the exchange
+	// minimum order value, or 0 when it was not.
+	BumpedFromSize float64
+}
+
+type exitPlan struct {
+	Spot              plannedOrder
+	Perp              plannedOrder
+	SpotRollbackLimit float64
+}
+
+type rebalancePlan struct {
+	Order    plannedOrder
+	DeltaUSD float64
+	BandUSD  float64
+	// TargetUSD is the delta the hedge aims to leave, per
+	// strategy.delta_recenter_pct.
+	TargetUSD float64
+	// Costs and LegReason are set when strategy.hedge_leg chose the leg.
+	Costs     []hedgeLegCost
+	LegReason string
+}
+
+func (a *App) collectTickInputs(ctx context.Context) (tickInputs, error) {
+	cfg := a.config()
+	perpAsset := cfg.Strategy.PerpAsset
+	spotAsset := cfg.Strategy.SpotAsset
+	spotMid, spotCtx, err := a.spotMid(ctx, spotAsset)
+	if err != nil {
+		return tickInputs{}, err
+	}
+	perpMid, _ := a.market.Mid(ctx, perpAsset)
+	oraclePrice, _ := a.market.OraclePrice(perpAsset)
+	markPrice, _ := a.market.MarkPrice(perpAsset)
+	funding, _ := a.market.FundingRate(perpAsset)
+	vol, _ := a.market.Volatility(perpAsset, cfg.Strategy.CandleInterval)
+
+	accountSnap := a.account.Snapshot()
+	spotBase := spotCtx.Base
+	if spotBase == "" {
+		spotBase = spotAsset
+	}
+	spotBalance := accountSnap.SpotBalances[spotBase]
+	perpPosition := accountSnap.PerpPosition[perpAsset]
+
+	snap := strategy.MarketSnapshot{
+		PerpAsset:      perpAsset,
+		SpotAsset:      spotAsset,
+		SpotMidPrice:   spotMid,
+		PerpMidPrice:   perpMid,
+		OraclePrice:    oraclePrice,
+		MarkPrice:      markPrice,
+		FundingRate:    funding,
+		Volatility:     vol,
+		NotionalUSD:    a.strategyConfig().NotionalUSD,
+		SpotBalance:    spotBalance,
+		PerpPosition:   perpPosition,
+		OpenOrderCount: len(a.withoutCrashStop(accountSnap.OpenOrders)),
+	}
+	if flow, ok := a.market.TradeFlow(perpAsset); ok {
+		snap.TradeImbalance = flow.Imbalance
+		snap.HasTradeImbalance = true
+	}
+	if book, ok := a.freshBook(perpAsset); ok {
+		snap.BookImbalance, snap.HasBookImbalance = book.Imbalance(a.strategyConfig().BookImbalanceLevels)
+	}
+	if accountSnap.HasMarginSummary {
+		snap.MarginRatio = accountSnap.MarginSummary.MarginRatio
+		snap.HealthRatio = accountSnap.MarginSummary.HealthRatio
+		snap.HasMarginRatio = accountSnap.MarginSummary.HasMarginRatio
+		snap.HasHealthRatio = accountSnap.MarginSummary.HasHealthRatio
+	}
+	now := time.Now().UTC()
+	in := tickInputs{
+		Now:             now,
+		Snap:            snap,
+		OpenOrders:      accountSnap.OpenOrders,
+		Risk:            a.riskConfig(),
+		FlatStrict:      isFlat(spotBalance, perpPosition),
+		Flat:            a.isExposureFlat(spotBalance, perpPosition, spotMid, perpMid),
+		SpotExposureUSD: math.Abs(spotBalance) * spotMid,
+		PerpExposureUSD: math.Abs(perpPosition) * perpMid,
+		DeltaUSD:        (spotBalance + perpPosition) * deltaPriceRef(snap),
+		Paused:          a.isPaused(),
+		ForeignActivity: a.foreignActivityActive(now),
+
+		CompoundAccruedUSD: a.compoundAccrued(),
+		RiskReduced:        a.riskReducedActive(),
+
+		ConsecutiveFailures: a.consecutiveFailures(now),
+	}
+	in.ClockDrift, in.HasClockDrift = a.clockDrift()
+	in.Liquidation, in.HasLiquidation = a.estimateLiquidation(accountSnap, perpAsset, perpPosition, liquidationMark(snap))
+	in.EntryBasis, in.HasEntryBasis = a.entryBasis()
+	in.DailyPnL, in.HasDailyPnL = a.dailyPnL(in.Now, snap)
+	in.LossHalt = a.lossHaltActive()
+	in.CircuitOpenUntil, in.CircuitOpen = a.strategyCircuitOpen()
+	in.Basis, in.HasBasis = strategy.SpotPerpBasis(snap)
+	in.Ages = a.dataAges(spotCtx)
+	in.EntryCooldownActive = a.entryCooldownActive(in.Now)
+	in.HedgeCooldownActive = a.hedgeCooldownActive(in.Now)
+	in.Forecast, in.HasForecast = a.market.FundingForecast(perpAsset)
+	if in.HasForecast && !in.Forecast.ObservedAt.IsZero() {
+		in.ForecastAge = time.Since(in.Forecast.ObservedAt)
+	}
+	if in.HasForecast {
+		in.Snap.FundingInterval = in.Forecast.Interval
+	}
+	in.VenuePremium, in.HasVenuePremium = a.venueFundingPremium(perpAsset, in.Forecast, in.HasForecast)
+	if history, ok := a.market.CachedFundingHistory(perpAsset); ok {
+		in.TrailingFunding, in.HasTrailingFunding = history.TrailingAverage(in.Now, cfg.Strategy.TrailingFundingWindow)
+	}
+	a.applyCarryInputs(&in, a.strategyConfig())
+	in.VaultLockedUntil = a.vaultLockedUntil()
+	if in.Flat {
+		in.VaultRecallLocked = a.vaultRecallLocked(in.Now, accountSnap, in.Snap)
+	}
+	return in, nil
+}
+
+// applyCarryInputs sizes the snapshot at cfg's notional, capped by the
+// account equity after an external withdrawal, and derives the expected
+// funding and net carry from it.
+func (a *App) applyCarryInputs(in *tickInputs, cfg config.StrategyConfig) {
+	in.Snap.NotionalUSD = a.cappedNotional(cfg.NotionalUSD)
+	in.MinExpectedFunding = in.Snap.NotionalUSD * strategy.FundingRateForAPR(cfg.MinFundingAPR, in.Snap.FundingInterval)
+	in.ExpectedFunding = strategy.FundingPaymentEstimateUSD(in.Snap)
+	in.NetCarryUSD, in.EstimatedCostUSD = strategy.NetExpectedCarryUSD(in.Snap, a.feeBps(), a.config().Strategy.SlippageBps)
+}
+
+// tickRun is what evaluateTick reads besides its inputs: the strategy
+// parameters, the state machine position and the funding regime counters.
+// The live tick takes them from the App; each shadow run keeps its own.
+type tickRun struct {
+	Strategy        config.StrategyConfig
+	State           strategy.State
+	FundingOKCount  int
+	FundingBadCount int
+}
+
+// evaluateTick decides what a tick would do with the given inputs without
+// touching exchange, store, or App state.
+func (a *App) evaluateTick(in tickInputs) tickPlan {
+	okCount, badCount := a.fundingCounts()
+	return a.evaluateTickFor(tickRun{
+		Strategy:        a.strategyConfig(),
+		State:           a.strategy.Current(),
+		FundingOKCount:  okCount,
+		FundingBadCount: badCount,
+	}, in)
+}
+
+// evaluateTickFor is evaluateTick for run's parameters, state and funding
+// counters instead of the live ones.
+func (a *App) evaluateTickFor(run tickRun, in tickInputs) tickPlan {
+	cfg := run.Strategy
+	snap := in.Snap
+	state := run.State
+	plan := tickPlan{StateBefore: state, Action: tickActionHold}
+	plan.FundingRateOK = snap.FundingAPR() >= cfg.MinFundingAPR
+	plan.NetCarryOK = in.NetCarryUSD >= cfg.CarryBufferUSD
+	plan.FundingOKCount, plan.FundingBadCount, plan.FundingOKConfirmed, plan.FundingBadConfirmed = fundingRegime(cfg, run.FundingOKCount, run.FundingBadCount, snap.FundingAPR(), cfg.MinFundingAPR, in.NetCarryUSD, cfg.CarryBufferUSD)
+
+	if (state == strategy.StateEnter || state == strategy.StateExit) && snap.OpenOrderCount == 0 {
+		if in.Flat {
+			state = strategy.StateIdle
+		} else {
+			state = strategy.StateHedgeOK
+		}
+	}
+	if state == strategy.StateHedgeOK && in.Flat && !in.EntryCooldownActive {
+		state = strategy.StateIdle
+	}
+	plan.State = state
+	plan.Risk = strategy.EvaluateRisk(in.Risk, in.riskInputs())
+
+	if err := strategy.CheckDataAges(in.Risk, in.Ages); err != nil {
+		plan.Decision = "skip_connectivity"
+		plan.Err = err
+		return plan
+	}
+	if state == strategy.StateIdle && (!in.Flat || snap.OpenOrderCount > 0) {
+		plan.Decision = "skip_idle_not_ready"
+		return plan
+	}
+	if plan.Risk.Action == strategy.RiskActionHalt {
+		plan.Decision = "skip_risk"
+		plan.Err = plan.Risk.Err()
+		return plan
+	}
+
+	switch state {
+	case strategy.StateIdle:
+		if in.Paused {
+			plan.Decision = "paused"
+			return plan
+		}
+		if in.ForeignActivity && a.config().Interference.PauseEntries {
+			plan.Decision = "skip_foreign_activity"
+			return plan
+		}
+		if plan.Risk.Action >= strategy.RiskActionBlockEntry {
+			plan.Decision = "risk_block_entry"
+			plan.Err = plan.Risk.Err()
+			return plan
+		}
+		if in.CircuitOpen {
+			plan.Decision = "skip_circuit_open"
+			plan.Err = circuitOpenErr(in.CircuitOpenUntil)
+			return plan
+		}
+		plan.EnterSignal = plan.FundingOKConfirmed && snap.Volatility <= cfg.MaxVolatility
+		if plan.EnterSignal {
+			if decision, err := a.entryGate(cfg, in); err != nil {
+				plan.Decision = decision
+				plan.Err = err
+				return plan
+			}
+		}
+		if plan.EnterSignal && in.EntryCooldownActive {
+			plan.Decision = "skip_entry_cooldown"
+			return plan
+		}
+		plan.Decision = "idle"
+		if plan.EnterSignal {
+			plan.Action = tickActionEnter
+			entry, err := a.planEntry(snap)
+			if errors.Is(err, errBelowMinOrder) {
+				plan.Action = tickActionHold
+				plan.Decision = "skip_min_order"
+				plan.Err = err
+				return plan
+			}
+			if err != nil {
+				plan.OrderErr = err
+				return plan
+			}
+			plan.Orders = []plannedOrder{entry.Spot, entry.Perp}
+		}
+	case strategy.StateHedgeOK:
+		// A daily loss halt pauses trading but still flattens.
+		if in.Paused && !in.LossHalt {
+			plan.Decision = "paused"
+			return plan
+		}
+		flatten := plan.Risk.Action == strategy.RiskActionFlatten || in.LossHalt
+		holdOnly := plan.Risk.Action == strategy.RiskActionHedgeOnly || plan.Risk.Action == strategy.RiskActionReduce
+		basisErr := a.basisExit(in)
+		plan.ExitSignal = flatten || (!holdOnly && ((cfg.ExitOnFundingDip && plan.FundingBadConfirmed) || basisErr != nil))
+		if plan.ExitSignal && !flatten && basisErr == nil {
+			plan.ExitGuarded, plan.TimeToFunding = a.shouldDeferExitForFunding(in.Now, in.Forecast, in.HasForecast, snap.FundingRate)
+		}
+		plan.Decision = "hedge_ok"
+		if holdOnly {
+			plan.Decision = "risk_hedge_only"
+			plan.Err = plan.Risk.Err()
+		}
+		if plan.Risk.Action == strategy.RiskActionReduce && !in.RiskReduced {
+			plan.Decision = "risk_reduce"
+			plan.Action = tickActionReduce
+			reduce, err := a.planExit(reduceSnapshot(snap, riskReducePct(in.Risk)))
+			if err != nil {
+				plan.OrderErr = err
+				return plan
+			}
+			for _, order := range []plannedOrder{reduce.Perp, reduce.Spot} {
+				if order.Size > 0 {
+					plan.Orders = append(plan.Orders, order)
+				}
+			}
+			return plan
+		}
+		if plan.ExitSignal {
+			if flatten {
+				// A flatten violation exits regardless of funding timing.
+				plan.Decision = "risk_flatten"
+				plan.Err = plan.Risk.Err()
+				if plan.Err == nil {
+					plan.Err = lossHaltErr()
+				}
+			} else if basisErr != nil {
+				// Basis losses are not offset by the next funding payment,
+				// so the funding guard does not defer this exit.
+				plan.Decision = "exit_basis"
+				plan.Err = basisErr
+			} else if plan.ExitGuarded {
+				plan.Decision = "exit_guarded"
+			} else {
+				plan.Decision = "exit_signal"
+			}
+		}
+		if plan.ExitSignal && !plan.ExitGuarded {
+			plan.Action = tickActionExit
+			exit, err := a.planExit(snap)
+			if err != nil {
+				plan.OrderErr = err
+				return plan
+			}
+			for _, order := range []plannedOrder{exit.Spot, exit.Perp} {
+				if order.Size > 0 {
+					plan.Orders = append(plan.Orders, order)
+				}
+			}
+			return plan
+		}
+		plan.Steady = true
+		if in.HedgeCooldownActive {
+			return plan
+		}
+		rebalance, ok, err := a.planRebalance(snap)
+		if err != nil {
+			plan.Action = tickActionHedge
+			plan.OrderErr = err
+			return plan
+		}
+		if ok {
+			plan.Action = tickActionHedge
+			plan.Orders = []plannedOrder{rebalance.Order}
+			plan.HedgeCosts = rebalance.Costs
+			plan.HedgeLegReason = rebalance.LegReason
+			return plan
+		}
+		if plan.Risk.Action == strategy.RiskActionNone && !in.CircuitOpen && a.compoundDue(cfg, in, plan) {
+			plan.Action = tickActionCompound
+			entry, err := a.planEntry(a.compoundSnapshot(snap))
+			if err != nil {
+				plan.OrderErr = err
+				return plan
+			}
+			plan.Orders = []plannedOrder{entry.Spot, entry.Perp}
+		}
+	default:
+		plan.Decision = "hold"
+	}
+	return plan
+}
+
+// tickSkipReason maps a tick decision that kept the bot from trading to its
+// TicksSkipped reason.
+func tickSkipReason(decision string) (string, bool) {
+	switch decision {
+	case "skip_connectivity":
+		return metrics.SkipConnectivity, true
+	case "skip_risk", "risk_block_entry":
+		return metrics.SkipRisk, true
+	case "skip_entry_cooldown":
+		return metrics.SkipCooldown, true
+	case "paused":
+		return metrics.SkipPaused, true
+	case "skip_circuit_open":
+		return metrics.SkipCircuit, true
+	case "skip_foreign_activity":
+		return metrics.SkipForeignActivity, true
+	case "skip_min_order":
+		return metrics.SkipMinOrder, true
+	case "skip_vault_locked":
+		return metrics.SkipVaultRecall, true
+	}
+	return "", false
+}
+
+func (a *App) countSkippedTick(reason string) {
+	if a.metrics != nil && a.metrics.TicksSkipped != nil {
+		a.metrics.TicksSkipped.With(reason).Inc()
+	}
+}
+
+// entryGate runs the market-condition gates an entry (or compounding add-on)
+// must pass once funding is confirmed. It returns the skip decision and the
+// reason when a gate blocks.
+func (a *App) entryGate(cfg config.StrategyConfig, in tickInputs) (string, error) {
+	snap := in.Snap
+	if cfg.MaxVenueFundingPremium > 0 && in.HasVenuePremium && in.VenuePremium > cfg.MaxVenueFundingPremium {
+		return "skip_venue_premium", fmt.Errorf("premium %.8f exceeds %.8f: %w", in.VenuePremium, cfg.MaxVenueFundingPremium, strategy.ErrVenuePremium)
+	}
+	if cfg.MinTrailingFunding != 0 {
+		if err := strategy.CheckTrailingFunding(cfg.MinTrailingFunding, in.TrailingFunding, in.HasTrailingFunding); err != nil {
+			return "skip_trailing_funding", err
+		}
+	}
+	if in.VaultRecallLocked {
+		return "skip_vault_locked", vaultLockedErr(in.VaultLockedUntil)
+	}
+	if err := strategy.CheckTradeFlow(cfg.MaxSellImbalance, snap.TradeImbalance, snap.HasTradeImbalance); err != nil {
+		return "skip_trade_flow", err
+	}
+	if err := strategy.CheckBookImbalance(cfg.MaxBookSellImbalance, snap.BookImbalance, snap.HasBookImbalance); err != nil {
+		return "skip_book_imbalance", err
+	}
+	until, hasNext := in.untilFunding()
+	if err := strategy.CheckEntryFundingTime(cfg.EntryFundingGuard, until, hasNext); err != nil {
+		return "skip_funding_time", err
+	}
+	return "", nil
+}
+
+// untilFunding is the time left to the forecast next funding timestamp.
+func (in tickInputs) untilFunding() (time.Duration, bool) {
+	if !in.HasForecast || !in.Forecast.HasNext || in.Forecast.NextFunding.IsZero() {
+		return 0, false
+	}
+	return in.Forecast.NextFunding.Sub(in.Now), true
+}
+
+// venueFundingPremium compares Hyperliquid's predicted hourly funding with the
+// other venues in predictedFundings.
+func (a *App) venueFundingPremium(asset string, forecast market.FundingForecast, hasForecast bool) (float64, bool) {
+	hlHourly, hasHL := 0.0, false
+	if hasForecast && forecast.HasRate && (forecast.Source == "" || strings.EqualFold(forecast.Source, market.HyperliquidVenue)) {
+		hlHourly, hasHL = forecast.HourlyRate(), true
+	}
+	var others []float64
+	for _, venue := range a.market.FundingForecastAll(asset) {
+		if !venue.HasRate {
+			continue
+		}
+		if strings.EqualFold(venue.Source, market.HyperliquidVenue) {
+			hlHourly, hasHL = venue.HourlyRate(), true
+			continue
+		}
+		others = append(others, venue.HourlyRate())
+	}
+	if !hasHL {
+		return 0, false
+	}
+	return strategy.VenueFundingPremium(hlHourly, others)
+}
+
+func (a *App) planEntry(snap strategy.MarketSnapshot) (entryPlan, error) {
+	priceRef := snap.SpotMidPrice
+	if snap.OraclePrice > 0 {
+		priceRef = snap.OraclePrice
+	}
+	if priceRef == 0 {
+		priceRef = snap.PerpMidPrice
+	}
+	size := snap.NotionalUSD / priceRef
+	perpCtx, ok := a.market.PerpContext(snap.PerpAsset)
+	if !ok {
+		return entryPlan{}, fmt.Errorf("perp context not found for %s", snap.PerpAsset)
+	}
+	spotCtx, err := a.spotContext(snap.SpotAsset)
+	if err != nil {
+		return entryPlan{}, err
+	}
+	spotID, ok := a.market.SpotAssetID(spotCtx.Symbol)
+	if !ok {
+		return entryPlan{}, fmt.Errorf("spot asset id not found for %s", snap.SpotAsset)
+	}
+	spotRef := snap.SpotMidPrice
+	if spotRef == 0 {
+		spotRef = snap.PerpMidPrice
+	}
+	perpRef := snap.PerpMidPrice
+	if perpRef == 0 {
+		perpRef = snap.SpotMidPrice
+	}
+	bps := a.iocPriceBps()
+	spotSize := size
+	spotSize = spotCtx.Increments.RoundSize(spotSize)
+	spotLimit := a.legLimitPrice(pricingSpotEntry, a.spotBookCoin(), spotRef, true, true, spotSize, spotCtx.BaseSzDecimals)
+	perpLimit := a.legLimitPrice(pricingPerpEntry, snap.PerpAsset, perpRef, false, false, spotSize, perpCtx.SzDecimals)
+	bumpedFrom := 0.0
+	if spotSize > 0 && spotLimit > 0 && perpLimit > 0 {
+		minSize, err := a.entryMinOrderSize(snap, priceRef, spotSize, spotLimit, perpLimit, spotCtx.Increments, perpCtx.Increments)
+		if err != nil {
+			return entryPlan{}, err
+		}
+		if minSize > spotSize {
+			bumpedFrom, spotSize = spotSize, minSize
+			spotLimit = a.legLimitPrice(pricingSpotEntry, a.spotBookCoin(), spotRef, true, true, spotSize, spotCtx.BaseSzDecimals)
+			perpLimit = a.legLimitPrice(pricingPerpEntry, snap.PerpAsset, perpRef, false, false, spotSize, perpCtx.SzDecimals)
+		}
+	}
+	plan := entryPlan{
+		Spot: plannedOrder{
+			Leg:        "spot",
+			Asset:      snap.SpotAsset,
+			AssetID:    spotID,
+			IsBuy:      true,
+			Size:       spotSize,
+			LimitPrice: spotLimit,
+			Tif:        string(exchange.TifIoc),
+		},
+		Perp: plannedOrder{
+			Leg:        "perp",
+			Asset:      snap.PerpAsset,
+			AssetID:    perpCtx.Index,
+			Size:       spotSize,
+			LimitPrice: perpLimit,
+			Tif:        string(exchange.TifIoc),
+		},
+		SpotRollbackLimit: limitPriceWithOffset(spotRef, false, true, spotCtx.BaseSzDecimals, bps),
+		PerpSzDecimals:    perpCtx.SzDecimals,
+		SpotSzDecimals:    spotCtx.BaseSzDecimals,
+		BumpedFromSize:    bumpedFrom,
+	}
+	plan.Perp.Size = perpCtx.Increments.RoundSize(plan.Perp.Size)
+	if spotSize <= 0 || spotLimit <= 0 || perpLimit <= 0 {
+		return plan, errors.New("derived order size or limit price is invalid")
+	}
+	return plan, nil
+}
+
+// errBelowMinOrder refuses an entry whose legs would fall under the
+// exchange minimum order value.
+var errBelowMinOrder = errors.New("entry below exchange minimum order value")
+
+// entryMinOrderSize checks both entry legs of size against the exchange
+// minimum order value at their limit prices, the perp leg at its own
+// rounding. It returns size when both clear it, else the smallest size on
+// both lots that does, as long as that stays within
+// strategy.min_order_bump_pct of the notional and within
+// risk.max_notional_usd; otherwise it returns errBelowMinOrder.
+func (a *App) entryMinOrderSize(snap strategy.MarketSnapshot, priceRef, size, spotLimit, perpLimit float64, spotIncr, perpIncr precision.Increments) (float64, error) {
+	spotUSD := size * spotLimit
+	perpUSD := perpIncr.RoundSize(size) * perpLimit
+	if spotUSD >= config.MinOrderValueUSD && perpUSD >= config.MinOrderValueUSD {
+		return size, nil
+	}
+	minSize := perpIncr.RoundSizeUp(spotIncr.RoundSizeUp(config.MinOrderValueUSD / math.Min(spotLimit, perpLimit)))
+	neededUSD := minSize * priceRef
+	bumpPct := a.strategyConfig().MinOrderBumpPct
+	limitUSD := snap.NotionalUSD * (1 + bumpPct/100)
+	if maxNotional := a.riskConfig().MaxNotionalUSD; maxNotional > 0 && maxNotional < limitUSD {
+		limitUSD = maxNotional
+	}
+	if neededUSD > limitUSD {
+		return 0, fmt.Errorf("%w: legs of %.2f / %.2f USD at notional %.2f USD are under %.0f USD; clearing it needs %.2f USD, over strategy.min_order_bump_pct %.4g%% or risk.max_notional_usd",
+			errBelowMinOrder, spotUSD, perpUSD, snap.NotionalUSD, config.MinOrderValueUSD, neededUSD, bumpPct)
+	}
+	return minSize, nil
+}
+
+func (a *App) planExit(snap strategy.MarketSnapshot) (exitPlan, error) {
+	perpCtx, ok := a.market.PerpContext(snap.PerpAsset)
+	if !ok {
+		return exitPlan{}, fmt.Errorf("perp context not found for %s", snap.PerpAsset)
+	}
+	spotCtx, err := a.spotContext(snap.SpotAsset)
+	if err != nil {
+		return exitPlan{}, err
+	}
+	spotID, ok := a.market.SpotAssetID(spotCtx.Symbol)
+	if !ok {
+		return exitPlan{}, fmt.Errorf("spot asset id not found for %s", snap.SpotAsset)
+	}
+	spotRef := snap.SpotMidPrice
+	if spotRef == 0 {
+		spotRef = snap.PerpMidPrice
+	}
+	perpRef := snap.PerpMidPrice
+	if perpRef == 0 {
+		perpRef = snap.SpotMidPrice
+	}
+	spotSize := math.Abs(snap.SpotBalance)
+	spotSize = spotCtx.Increments.RoundSize(spotSize)
+	perpSize := math.Abs(snap.PerpPosition)
+	perpSize = perpCtx.Increments.RoundSize(perpSize)
+	spotLimit := a.legLimitPrice(pricingSpotExit, a.spotBookCoin(), spotRef, snap.SpotBalance < 0, true, spotSize, spotCtx.BaseSzDecimals)
+	perpLimit := a.legLimitPrice(pricingPerpExit, snap.PerpAsset, perpRef, snap.PerpPosition < 0, false, perpSize, perpCtx.SzDecimals)
+	plan := exitPlan{
+		Spot: plannedOrder{
+			Leg:        "spot",
+			Asset:      snap.SpotAsset,
+			AssetID:    spotID,
+			IsBuy:      snap.SpotBalance < 0,
+			LimitPrice: spotLimit,
+		},
+		Perp: plannedOrder{
+			Leg:        "perp",
+			Asset:      snap.PerpAsset,
+			AssetID:    perpCtx.Index,
+			IsBuy:      snap.PerpPosition < 0,
+			LimitPrice: perpLimit,
+			ReduceOnly: true,
+		},
+	}
+	if spotLimit <= 0 || perpLimit <= 0 {
+		return plan, errors.New("derived order size or limit price is invalid")
+	}
+	plan.SpotRollbackLimit = limitPriceWithOffset(spotRef, snap.SpotBalance >= 0, true, spotCtx.BaseSzDecimals, a.iocPriceBps())
+	if a.exposureBelowThreshold(spotSize, spotLimit) {
+		spotSize = 0
+	}
+	if a.exposureBelowThreshold(perpSize, perpLimit) {
+		perpSize = 0
+	}
+	plan.Spot.Size = spotSize
+	plan.Perp.Size = perpSize
+	return plan, nil
+}
+
+// planRebalance returns the IOC that would bring delta back inside the band,
+// to strategy.delta_recenter_pct of it and at least strategy.min_exposure_usd,
+// on the perp or, per strategy.hedge_leg, the spot leg; ok is false when no
+// hedge is needed.
+func (a *App) planRebalance(snap strategy.MarketSnapshot) (rebalancePlan, bool, error) {
+	cfg := a.config()
+	if cfg == nil || a.market == nil {
+		return rebalancePlan{}, false, nil
+	}
+	band := a.strategyConfig().DeltaBandUSD
+	if band <= 0 {
+		return rebalancePlan{}, false, nil
+	}
+	if snap.OpenOrderCount > 0 {
+		return rebalancePlan{}, false, nil
+	}
+	priceRef := deltaPriceRef(snap)
+	if priceRef == 0 {
+		return rebalancePlan{}, false, errors.New("delta hedge price reference missing")
+	}
+	deltaBase := snap.SpotBalance + snap.PerpPosition
+	deltaUSD := deltaBase * priceRef
+	if math.Abs(deltaUSD) <= band {
+		return rebalancePlan{}, false, nil
+	}
+	if math.Abs(deltaUSD) < cfg.Strategy.MinExposureUSD {
+		return rebalancePlan{}, false, nil
+	}
+	isBuy := deltaUSD < 0
+	keepBase := a.recenterBase(band, priceRef)
+	if deltaBase < 0 {
+		keepBase = -keepBase
+	}
+	size := math.Abs(deltaBase - keepBase)
+	plan := rebalancePlan{DeltaUSD: deltaUSD, BandUSD: band, TargetUSD: keepBase * priceRef}
+	if mode := cfg.Strategy.HedgeLeg; mode == hedgeLegSpot || mode == hedgeLegAuto {
+		perpCost, spotCost := a.hedgeLegCosts(snap, size, priceRef, isBuy, time.Now())
+		plan.Costs = []hedgeLegCost{perpCost, spotCost}
+		var leg string
+		leg, plan.LegReason = chooseHedgeLeg(mode, perpCost, spotCost)
+		if leg == hedgeLegSpot {
+			order, err := a.spotHedgeOrder(snap, size, isBuy)
+			if err != nil {
+				return rebalancePlan{}, false, err
+			}
+			if hedgeOvershoots(deltaBase, order.Size, priceRef, band, isBuy) {
+				return rebalancePlan{}, false, nil
+			}
+			plan.Order = order
+			return plan, true, nil
+		}
+	}
+	perpCtx, ok := a.market.PerpContext(snap.PerpAsset)
+	if !ok {
+		return rebalancePlan{}, false, fmt.Errorf("perp context not found for %s", snap.PerpAsset)
+	}
+	limit := snap.PerpMidPrice
+	if limit == 0 {
+		limit = snap.SpotMidPrice
+	}
+	limit = limitPriceWithOffset(limit, isBuy, false, perpCtx.SzDecimals, a.iocPriceBps())
+	if limit <= 0 {
+		return rebalancePlan{}, false, errors.New("delta hedge limit price invalid")
+	}
+	size = a.hedgeSizeFloor(perpCtx.Increments.RoundSize(size), limit, perpCtx.Increments)
+	if size <= 0 {
+		return rebalancePlan{}, false, errors.New("delta hedge size rounded to zero")
+	}
+	if hedgeOvershoots(deltaBase, size, priceRef, band, isBuy) {
+		return rebalancePlan{}, false, nil
+	}
+	plan.Order = plannedOrder{
+		Leg:        "perp",
+		Asset:      snap.PerpAsset,
+		AssetID:    perpCtx.Index,
+		IsBuy:      isBuy,
+		Size:       size,
+		LimitPrice: limit,
+		ReduceOnly: (isBuy && snap.PerpPosition < 0) || (!isBuy && snap.PerpPosition > 0),
+		Tif:        string(exchange.TifIoc),
+	}
+	return plan, true, nil
+}
+
+// recenterBase is the part of the band, in base units, a hedge leaves in
+// place under strategy.delta_recenter_pct so the next small drift back does
+// not immediately breach the other side.
+func (a *App) recenterBase(band, priceRef float64) float64 {
+	pct := a.config().Strategy.DeltaRecenterPct
+	if pct <= 0 || priceRef <= 0 {
+		return 0
+	}
+	return band * pct / 100 / priceRef
+}
+
+// hedgeSizeFloor raises a hedge of size at limit to strategy.min_exposure_usd,
+// rounded up to the lot, so the exchange does not reject it under its minimum
+// order value. The raised hedge can overshoot the target by up to the floor;
+// planRebalance skips one that would leave delta outside the band.
+func (a *App) hedgeSizeFloor(size, limit float64, incr precision.Increments) float64 {
+	floor := a.config().Strategy.MinExposureUSD
+	if size <= 0 || floor <= 0 || limit <= 0 || size*limit >= floor {
+		return size
+	}
+	return incr.RoundSizeUp(floor / limit)
+}
+
+// hedgeOvershoots reports whether a hedge of size would carry delta past the
+// far side of the band, as one raised to strategy.min_exposure_usd can on a
+// narrow band; the next hedge would only flip it back, paying fees each way.
+func hedgeOvershoots(deltaBase, size, priceRef, band float64, isBuy bool) bool {
+	after := deltaBase - size
+	if isBuy {
+		after = deltaBase + size
+	}
+	return after*deltaBase < 0 && math.Abs(after*priceRef) > band
+}
+
+func deltaPriceRef(snap strategy.MarketSnapshot) float64 {
+	priceRef := snap.OraclePrice
+	if priceRef == 0 {
+		priceRef = snap.PerpMidPrice
+	}
+	if priceRef == 0 {
+		priceRef = snap.SpotMidPrice
+	}
+	return priceRef
+}
+
+// fundingInterval is the perp's funding interval from the predicted funding
+// feed, or zero (hourly) when unknown.
+func (a *App) fundingInterval(asset string) time.Duration {
+	if forecast, ok := a.market.FundingForecast(asset); ok {
+		return forecast.Interval
+	}
+	return 0
+}
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+const positionBaselineKey = "strategy:position_baseline"
+
+// loadPositionBaseline restores what the held position was measured from,
+// so /pnl and /funding keep counting from entry across restarts.
+func (a *App) loadPositionBaseline(ctx context.Context) {
+	if a.store == nil {
+		return
+	}
+	raw, ok, err := a.store.Get(ctx, positionBaselineKey)
+	if err != nil {
+		a.logPositionStoreError(err)
+		return
+	}
+	if !ok || raw == "" {
+		return
+	}
+	var base strategy.DailyPnLBaseline
+	if err := json.Unmarshal([]byte(raw), &base); err != nil {
+		a.logPositionStoreError(fmt.Errorf("parse %s: %w", positionBaselineKey, err))
+		return
+	}
+	a.positionBase = &base
+}
+
+// recordPositionBaseline marks the holdings in snap at their mids as the
+// starting point of the position. On entry snap is the pre-entry snapshot,
+// so the entry fills are counted as flows.
+func (a *App) recordPositionBaseline(ctx context.Context, at time.Time, snap strategy.MarketSnapshot) {
+	a.setPositionBaseline(ctx, &strategy.DailyPnLBaseline{
+		WindowStart:  at,
+		ObservedAt:   at,
+		SpotBalance:  snap.SpotBalance,
+		PerpPosition: snap.PerpPosition,
+		SpotMid:      snap.SpotMidPrice,
+		PerpMid:      snap.PerpMidPrice,
+	})
+}
+
+// observePositionBaseline adopts the current holdings as the baseline of a
+// hedged position entered before the baseline was tracked.
+func (a *App) observePositionBaseline(ctx context.Context, now time.Time, snap strategy.MarketSnapshot, hedged bool) {
+	if !hedged || a.positionBase != nil || snap.SpotMidPrice <= 0 || snap.PerpMidPrice <= 0 {
+		return
+	}
+	a.recordPositionBaseline(ctx, now, snap)
+	if a.log != nil {
+		a.log.Info("position baseline adopted from current holdings", zap.Time("observed_at", now))
+	}
+}
+
+// clearPositionBaseline forgets the baseline once the position is closed.
+func (a *App) clearPositionBaseline(ctx context.Context) {
+	if a.positionBase == nil {
+		return
+	}
+	a.setPositionBaseline(ctx, nil)
+}
+
+func (a *App) setPositionBaseline(ctx context.Context, base *strategy.DailyPnLBaseline) {
+	a.positionBase = base
+	if a.store == nil {
+		return
+	}
+	raw := ""
+	if base != nil {
+		payload, err := json.Marshal(base)
+		if err != nil {
+			a.logPositionStoreError(err)
+			return
+		}
+		raw = string(payload)
+	}
+	if err := a.store.Set(ctx, positionBaselineKey, raw); err != nil {
+		a.logPositionStoreError(err)
+		return
+	}
+	if a.positionStoreWarned && a.log != nil {
+		a.log.Info("position baseline write recovered")
+	}
+	a.positionStoreWarned = false
+}
+
+func (a *App) logPositionStoreError(err error) {
+	if a.positionStoreWarned || a.log == nil {
+		return
+	}
+	a.positionStoreWarned = true
+	a.log.Warn("position baseline store failed", zap.Error(err))
+}
+
+// flowsSince is where /pnl and /funding start counting: position entry, or
+// the start of the PnL day while flat.
+func (a *App) flowsSince(now time.Time) (time.Time, string) {
+	if base := a.positionBase; base != nil {
+		return base.ObservedAt, "entry"
+	}
+	return strategy.DailyWindowStart(now, a.config().Risk.DailyResetHour), "day start"
+}
+
+// reportSnapshot marks the strategy legs for an operator report.
+func (a *App) reportSnapshot(ctx context.Context) strategy.MarketSnapshot {
+	cfg := a.config()
+	accountSnap := a.account.Snapshot()
+	spotMid, _, _ := a.spotMid(ctx, cfg.Strategy.SpotAsset)
+	perpMid, _ := a.market.Mid(ctx, cfg.Strategy.PerpAsset)
+	oraclePrice, _ := a.market.OraclePrice(cfg.Strategy.PerpAsset)
+	fundingRate, _ := a.market.FundingRate(cfg.Strategy.PerpAsset)
+	return strategy.MarketSnapshot{
+		PerpAsset:       cfg.Strategy.PerpAsset,
+		SpotAsset:       cfg.Strategy.SpotAsset,
+		SpotMidPrice:    spotMid,
+		PerpMidPrice:    perpMid,
+		OraclePrice:     oraclePrice,
+		FundingRate:     fundingRate,
+		NotionalUSD:     a.strategyConfig().NotionalUSD,
+		FundingInterval: a.fundingInterval(cfg.Strategy.PerpAsset),
+		SpotBalance:     a.spotBalanceForAsset(cfg.Strategy.SpotAsset, accountSnap.SpotBalances),
+		PerpPosition:    accountSnap.PerpPosition[cfg.Strategy.PerpAsset],
+	}
+}
+
+// pnlReport answers /pnl: realized PnL (closed PnL plus funding, net of
+// fees) from the fill and funding history since entry, and the unrealized
+// PnL of the held legs, which for a hedged position is the basis move.
+func (a *App) pnlReport(ctx context.Context) (string, error) {
+	if a.config() == nil || a.account == nil || a.market == nil {
+		return "pnl unavailable", nil
+	}
+	now := time.Now().UTC()
+	since, label := a.flowsSince(now)
+	fills, payments, err := a.strategyFlows(ctx, since.UnixMilli())
+	if err != nil {
+		return "", err
+	}
+	closed, fees := 0.0, 0.0
+	for _, fill := range fills {
+		closed += fill.ClosedPnL
+		fees += fill.FeeUSD()
+	}
+	funding := fundingTotal(payments)
+	realized := closed + funding - fees
+	lines := []string{
+		fmt.Sprintf("pnl since %s (%s)", since.Format(time.RFC3339), label),
+		fmt.Sprintf("realized: %.2f USD (closed %.2f, funding %.2f, fees -%.2f, %d fills)", realized, closed, funding, fees, len(fills)),
+	}
+	base := a.positionBase
+	if base == nil {
+		lines = append(lines, "unrealized_basis: n/a (no open position)")
+		return strings.Join(lines, "\n"), nil
+	}
+	snap := a.reportSnapshot(ctx)
+	if snap.SpotMidPrice <= 0 || snap.PerpMidPrice <= 0 {
+		lines = append(lines, "unrealized_basis: n/a (mids unavailable)")
+		return strings.Join(lines, "\n"), nil
+	}
+	pnl := strategy.ComputeDailyPnL(*base, snap, pnlFills(fills), funding)
+	unrealized := fmt.Sprintf("unrealized_basis: %.2f USD", pnl.UnrealizedUSD)
+	if entry, hasEntry := a.entryBasis(); hasEntry {
+		if basis, ok := strategy.SpotPerpBasis(snap); ok {
+			unrealized += fmt.Sprintf(" (basis entry %.2f bps now %.2f bps)", entry*10000, basis*10000)
+		}
+	}
+	lines = append(lines, unrealized, fmt.Sprintf("total: %.2f USD", pnl.TotalUSD()))
+	return strings.Join(lines, "\n"), nil
+}
+
+// fundingReport answers /funding: perp funding received since entry and
+// what the next payment looks like at the current rate.
+func (a *App) fundingReport(ctx context.Context) (string, error) {
+	if a.config() == nil || a.account == nil || a.market == nil {
+		return "funding unavailable", nil
+	}
+	now := time.Now().UTC()
+	since, label := a.flowsSince(now)
+	_, payments, err := a.strategyFlows(ctx, since.UnixMilli())
+	if err != nil {
+		return "", err
+	}
+	lines := []string{
+		fmt.Sprintf("funding since %s (%s): %.4f USD over %d payments", since.Format(time.RFC3339), label, fundingTotal(payments), len(payments)),
+	}
+	if n := len(payments); n > 0 {
+		last := payments[0]
+		for _, payment := range payments[1:] {
+			if payment.Time.After(last.Time) {
+				last = payment
+			}
+		}
+		line := fmt.Sprintf("last_payment: %.4f USD", last.Amount)
+		if last.HasTime {
+			line += " at " + last.Time.UTC().Format(time.RFC3339)
+		}
+		if last.HasRate {
+			line += fmt.Sprintf(" (rate %.8f)", last.Rate)
+		}
+		lines = append(lines, line)
+	}
+	snap := a.reportSnapshot(ctx)
+	next := "n/a"
+	if forecast, ok := a.market.FundingForecast(a.config().Strategy.PerpAsset); ok && forecast.HasNext {
+		next = forecast.NextFunding.UTC().Format(time.RFC3339)
+	}
+	lines = append(lines, fmt.Sprintf("funding_rate: %.8f next_funding_at: %s estimated_next_usd: %.4f",
+		snap.FundingRate, next, strategy.FundingPaymentEstimateUSD(snap)))
+	return strings.Join(lines, "\n"), nil
+}
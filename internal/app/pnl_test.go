@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+)
+
+func TestPnLAndFundingReportsSinceEntry(t *testing.T) {
+	server := hltest.NewServer(t)
+	server.SetNextFundingTime(time.Now().Add(time.Hour).UnixMilli())
+	entered := time.Now().Add(-2 * time.Hour)
+	fillMS := entered.Add(time.Second).UnixMilli()
+	server.SetFills([]any{
+		map[string]any{"oid": 1, "coin": "@51", "side": "B", "sz": "0.01", "px": "3000", "fee": "0.02", "closedPnl": "0", "time": fillMS},
+		map[string]any{"oid": 2, "coin": "ETH", "side": "A", "sz": "0.01", "px": "3000", "fee": "0.01", "closedPnl": "0", "time": fillMS},
+		map[string]any{"oid": 3, "coin": "BTC", "side": "B", "sz": "1", "px": "60000", "fee": "5", "closedPnl": "0", "time": fillMS},
+	})
+	server.SetUserFunding([]any{
+		map[string]any{"time": entered.Add(time.Hour).UnixMilli(), "delta": map[string]any{"type": "funding", "coin": "ETH", "usdc": "0.05", "fundingRate": "0.0001"}},
+		map[string]any{"time": entered.Add(90 * time.Minute).UnixMilli(), "delta": map[string]any{"type": "funding", "coin": "ETH", "usdc": "0.03", "fundingRate": "0.00006"}},
+		map[string]any{"time": entered.Add(time.Hour).UnixMilli(), "delta": map[string]any{"type": "funding", "coin": "BTC", "usdc": "1", "fundingRate": "0.0001"}},
+	})
+	app := newNextTestApp(t, server)
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+
+	report, err := app.pnlReport(ctx)
+	if err != nil {
+		t.Fatalf("pnl report: %v", err)
+	}
+	if !strings.Contains(report, "(day start)") || !strings.Contains(report, "no open position") {
+		t.Fatalf("expected a flat report from day start, got %q", report)
+	}
+
+	server.SetSpotBalances([]any{
+		map[string]any{"coin": "UETH", "total": "0.01"},
+		map[string]any{"coin": "USDC", "total": "70"},
+	})
+	server.SetPerpPosition("ETH", -0.01)
+	server.SetMid("ETH", "3010")
+	if _, err := app.account.Reconcile(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	app.recordPositionBaseline(ctx, entered, strategy.MarketSnapshot{SpotMidPrice: 3000, PerpMidPrice: 3000})
+
+	report, err = app.pnlReport(ctx)
+	if err != nil {
+		t.Fatalf("pnl report: %v", err)
+	}
+	for _, want := range []string{
+		"realized: 0.05 USD (closed 0.00, funding 0.08, fees -0.03, 2 fills)",
+		"unrealized_basis: -0.10 USD",
+		"total: -0.05 USD",
+	} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("expected %q in %q", want, report)
+		}
+	}
+
+	report, err = app.fundingReport(ctx)
+	if err != nil {
+		t.Fatalf("funding report: %v", err)
+	}
+	if !strings.Contains(report, "(entry): 0.0800 USD over 2 payments") || !strings.Contains(report, "last_payment: 0.0300 USD") {
+		t.Fatalf("unexpected funding report %q", report)
+	}
+
+	app.clearPositionBaseline(ctx)
+	if app.positionBase != nil {
+		t.Fatalf("expected the baseline cleared")
+	}
+}
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/config"
+)
+
+const (
+	preflightTimeout  = 10 * time.Second
+	preflightStoreKey = "preflight:write_check"
+)
+
+// PreflightCheck is one line of the preflight report. A skipped check does
+// not apply to this configuration and does not fail the report.
+type PreflightCheck struct {
+	Name    string
+	OK      bool
+	Skipped bool
+	Detail  string
+}
+
+// PreflightReport is the outcome of Preflight, one check per line.
+type PreflightReport struct {
+	Checks []PreflightCheck
+}
+
+// Passed reports whether every check that ran passed.
+func (r PreflightReport) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.OK && !check.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+func (r PreflightReport) String() string {
+	verdict := "PASS"
+	if !r.Passed() {
+		verdict = "FAIL"
+	}
+	lines := []string{"preflight: " + verdict}
+	for _, check := range r.Checks {
+		status := "PASS"
+		switch {
+		case check.Skipped:
+			status = "SKIP"
+		case !check.OK:
+			status = "FAIL"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: %s", status, check.Name, check.Detail))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (r *PreflightReport) pass(name, format string, args ...any) {
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, OK: true, Detail: fmt.Sprintf(format, args...)})
+}
+
+func (r *PreflightReport) fail(name, format string, args ...any) {
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, Detail: fmt.Sprintf(format, args...)})
+}
+
+func (r *PreflightReport) skip(name, format string, args ...any) {
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, Skipped: true, Detail: fmt.Sprintf(format, args...)})
+}
+
+// Preflight checks everything the bot needs before it may trade, without
+// sending an order or starting the streams: exchange connectivity, the
+// signing key against the wallet, that both strategy assets resolve, that
+// each entry leg clears the exchange minimum order value, that the account
+// equity funds the notional, the clock skew, that the state store takes
+// writes, and that Timescale and Telegram answer. It closes the state
+// store when done, like RunBackfill.
+func (a *App) Preflight(ctx context.Context) PreflightReport {
+	defer a.store.Close()
+	defer a.timescale.Close()
+	var report PreflightReport
+
+	start := time.Now()
+	checkCtx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	err := a.market.RefreshContexts(checkCtx)
+	cancel()
+	connected := err == nil
+	if connected {
+		report.pass("connectivity", "%s answered meta and spotMeta in %s", a.config().REST.BaseURL, time.Since(start).Round(time.Millisecond))
+	} else {
+		report.fail("connectivity", "%s: %v", a.config().REST.BaseURL, err)
+	}
+
+	a.preflightClock(ctx, &report)
+	a.preflightKey(ctx, &report)
+
+	if connected {
+		a.preflightAssets(&report)
+	} else {
+		report.skip("assets", "exchange unreachable")
+	}
+
+	checkCtx, cancel = context.WithTimeout(ctx, preflightTimeout)
+	state, err := a.account.Reconcile(checkCtx)
+	cancel()
+	switch {
+	case err != nil:
+		report.fail("account", "reconcile %s: %v", a.accountAddress, err)
+		report.skip("min_order", "account state unavailable")
+		report.skip("balances", "account state unavailable")
+	case !connected:
+		report.pass("account", "reconciled %s", a.accountAddress)
+		report.skip("min_order", "exchange unreachable")
+		report.skip("balances", "exchange unreachable")
+	default:
+		report.pass("account", "reconciled %s", a.accountAddress)
+		a.preflightMinOrder(ctx, &report)
+		a.loadVaultParked(ctx)
+		a.preflightBalances(ctx, &report, *state)
+	}
+
+	a.preflightStore(ctx, &report)
+	a.preflightSinks(ctx, &report)
+	return report
+}
+
+func (a *App) preflightClock(ctx context.Context, report *PreflightReport) {
+	checkCtx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+	offset, rtt, err := a.rest.ClockOffset(checkCtx)
+	if err != nil {
+		report.fail("clock", "exchange clock unavailable: %v", err)
+		return
+	}
+	maxDrift := a.config().Risk.MaxClockDrift
+	if maxDrift > 0 && offset.Abs() > maxDrift {
+		report.fail("clock", "local clock is %s off the exchange (rtt %s), above risk.max_clock_drift %s", offset, rtt, maxDrift)
+		return
+	}
+	report.pass("clock", "offset %s (rtt %s)", offset, rtt)
+}
+
+// preflightKey confirms the signing key may trade for the account: it is
+// the account's own key or an agent the account approved.
+func (a *App) preflightKey(ctx context.Context, report *PreflightReport) {
+	if a.readOnly() {
+		report.skip("signing_key", "read_only: no signing key loaded")
+		return
+	}
+	a.keyMu.Lock()
+	signer := a.activeSigner
+	a.keyMu.Unlock()
+	if signer == nil {
+		report.fail("signing_key", "no signing key loaded")
+		return
+	}
+	address := signer.Address()
+	checkCtx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+	if err := a.verifySigner(checkCtx, address); err != nil {
+		report.fail("signing_key", "%v", err)
+		return
+	}
+	if strings.EqualFold(address.Hex(), a.accountAddress) {
+		report.pass("signing_key", "key matches wallet %s", address.Hex())
+		return
+	}
+	report.pass("signing_key", "key %s is an approved agent of %s", address.Hex(), a.accountAddress)
+}
+
+func (a *App) preflightAssets(report *PreflightReport) {
+	cfg := a.config()
+	perpAsset := cfg.Strategy.PerpAsset
+	perpID, ok := a.market.PerpAssetID(perpAsset)
+	if !ok {
+		report.fail("assets", "perp %s not found in meta", perpAsset)
+		return
+	}
+	spotCtx, err := a.spotContext(cfg.Strategy.SpotAsset)
+	if err != nil {
+		report.fail("assets", "spot %s: %v", cfg.Strategy.SpotAsset, err)
+		return
+	}
+	spotID, ok := a.market.SpotAssetID(spotCtx.Symbol)
+	if !ok {
+		report.fail("assets", "spot %s (%s) has no asset id", cfg.Strategy.SpotAsset, spotCtx.Symbol)
+		return
+	}
+	report.pass("assets", "perp %s id %d, spot %s (%s) id %d", perpAsset, perpID, cfg.Strategy.SpotAsset, spotCtx.Symbol, spotID)
+}
+
+// preflightMinOrder plans an entry at the current mids and checks both legs
+// clear the exchange minimum once sizes are rounded.
+func (a *App) preflightMinOrder(ctx context.Context, report *PreflightReport) {
+	snap := a.reportSnapshot(ctx)
+	if snap.SpotMidPrice <= 0 || snap.PerpMidPrice <= 0 {
+		report.fail("min_order", "mids unavailable for %s/%s", snap.SpotAsset, snap.PerpAsset)
+		return
+	}
+	plan, err := a.planEntry(snap)
+	if err != nil {
+		report.fail("min_order", "plan entry: %v", err)
+		return
+	}
+	spotUSD := plan.Spot.Size * plan.Spot.LimitPrice
+	perpUSD := plan.Spot.Size * plan.Perp.LimitPrice
+	if spotUSD < config.MinOrderValueUSD || perpUSD < config.MinOrderValueUSD {
+		report.fail("min_order", "notional %.2f USD gives legs of %.2f / %.2f USD, below the exchange minimum of %.0f USD", snap.NotionalUSD, spotUSD, perpUSD, config.MinOrderValueUSD)
+		return
+	}
+	report.pass("min_order", "legs of %.2f / %.2f USD clear the %.0f USD minimum", spotUSD, perpUSD, config.MinOrderValueUSD)
+}
+
+func (a *App) preflightBalances(ctx context.Context, report *PreflightReport, state account.State) {
+	notional, required, equity := a.capitalRequirement(ctx, state)
+	switch {
+	case required <= equity:
+		report.pass("balances", "equity %.2f USD covers notional %.2f USD (%.2f USD with perp margin)", equity, notional, required)
+	case a.riskConfig().OverAllocation == "scale" && equity > 0:
+		report.pass("balances", "equity %.2f USD is short of %.2f USD; notional will scale down to %.2f USD", equity, required, equity/a.capitalPerNotional())
+	default:
+		report.fail("balances", "notional %.2f USD needs %.2f USD with perp margin but equity is %.2f USD", notional, required, equity)
+	}
+}
+
+func (a *App) preflightStore(ctx context.Context, report *PreflightReport) {
+	if a.store == nil {
+		report.fail("state_store", "no state store")
+		return
+	}
+	value := time.Now().UTC().Format(time.RFC3339Nano)
+	if err := a.store.Set(ctx, preflightStoreKey, value); err != nil {
+		report.fail("state_store", "write: %v", err)
+		return
+	}
+	got, ok, err := a.store.Get(ctx, preflightStoreKey)
+	if err != nil || !ok || got != value {
+		report.fail("state_store", "read back failed (ok=%v): %v", ok, err)
+		return
+	}
+	if err := a.store.Delete(ctx, preflightStoreKey); err != nil {
+		report.fail("state_store", "delete: %v", err)
+		return
+	}
+	report.pass("state_store", "%s backend is writable", a.config().State.Backend)
+}
+
+func (a *App) preflightSinks(ctx context.Context, report *PreflightReport) {
+	if a.timescale == nil {
+		report.skip("timescale", "disabled")
+	} else {
+		checkCtx, cancel := context.WithTimeout(ctx, preflightTimeout)
+		err := a.timescale.Ping(checkCtx)
+		cancel()
+		if err != nil {
+			report.fail("timescale", "%v", err)
+		} else {
+			report.pass("timescale", "database answered")
+		}
+	}
+	if !a.config().Telegram.Enabled || a.alerts == nil {
+		report.skip("telegram", "disabled")
+		return
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+	if err := a.alerts.Check(checkCtx); err != nil {
+		report.fail("telegram", "%v", err)
+		return
+	}
+	report.pass("telegram", "bot can reach chat %s", a.config().Telegram.ChatID)
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/hltest"
+
+	"go.uber.org/zap"
+)
+
+func TestPreflightReport(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetAccountValue(1000)
+	app := newNextTestApp(t, server)
+	app.cfg.ReadOnly = true
+	app.cfg.Strategy.NotionalUSD = 20
+	app.cfg.Risk.MaxClockDrift = time.Second
+	app.rest = rest.New(server.URL(), 2*time.Second, zap.NewNop())
+	store := &memoryStore{data: make(map[string]string)}
+	app.store = store
+
+	report := app.Preflight(context.Background())
+	if !report.Passed() {
+		t.Fatalf("expected preflight to pass:\n%s", report)
+	}
+	if _, ok := store.data[preflightStoreKey]; ok {
+		t.Fatal("expected the write check key removed")
+	}
+	out := report.String()
+	for _, want := range []string{"preflight: PASS", "PASS connectivity:", "PASS min_order:", "SKIP signing_key:", "SKIP telegram:"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in report:\n%s", want, out)
+		}
+	}
+
+	server.SetClockSkew(5 * time.Second)
+	app.cfg.Strategy.NotionalUSD = 5
+	report = app.Preflight(context.Background())
+	if report.Passed() {
+		t.Fatalf("expected preflight to fail:\n%s", report)
+	}
+	failed := map[string]bool{}
+	for _, check := range report.Checks {
+		if !check.OK && !check.Skipped {
+			failed[check.Name] = true
+		}
+	}
+	if len(failed) != 2 || !failed["clock"] || !failed["min_order"] {
+		t.Fatalf("expected clock and min_order to fail, got:\n%s", report)
+	}
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/precision"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/market"
+
+	"go.uber.org/zap"
+)
+
+// pricingLeg names a pricing.* leg; the policies are configured per leg.
+type pricingLeg string
+
+const (
+	pricingSpotEntry pricingLeg = "spot_entry"
+	pricingPerpEntry pricingLeg = "perp_entry"
+	pricingSpotExit  pricingLeg = "spot_exit"
+	pricingPerpExit  pricingLeg = "perp_exit"
+)
+
+func (a *App) pricingPolicyName(leg pricingLeg) string {
+	cfg := a.config()
+	switch leg {
+	case pricingSpotEntry:
+		return cfg.Pricing.SpotEntry
+	case pricingPerpEntry:
+		return cfg.Pricing.PerpEntry
+	case pricingSpotExit:
+		return cfg.Pricing.SpotExit
+	case pricingPerpExit:
+		return cfg.Pricing.PerpExit
+	}
+	return ""
+}
+
+// pricingPolicy returns the leg's policy, offset by strategy.ioc_price_bps.
+// An unset or unknown name (tests, hand-built configs) uses the legacy
+// pricing: aggressive entries and mid-pegged exits.
+func (a *App) pricingPolicy(leg pricingLeg) exec.PricingPolicy {
+	name := a.pricingPolicyName(leg)
+	if !exec.PricingPolicyValid(name) {
+		name = exec.PricingAggressiveIOC
+		if leg == pricingSpotExit || leg == pricingPerpExit {
+			name = exec.PricingMidPeg
+		}
+	}
+	policy, _ := exec.NewPricingPolicy(name, a.iocPriceBps())
+	return policy
+}
+
+// legLimitPrice prices one entry/exit leg from mid (and the cached book when
+// the policy reads one) and normalizes it to the exchange tick.
+func (a *App) legLimitPrice(leg pricingLeg, coin string, mid float64, isBuy, isSpot bool, size float64, szDecimals int) float64 {
+	policy := a.pricingPolicy(leg)
+	quote := exec.Quote{Mid: mid}
+	if policy.NeedsBook() {
+		if book, ok := a.freshBook(coin); ok {
+			quote.Bids = bookLevels(book.Bids)
+			quote.Asks = bookLevels(book.Asks)
+		}
+	}
+	price, err := policy.LimitPrice(quote, isBuy, size)
+	if err != nil {
+		return 0
+	}
+	return precision.LimitPrice(price, isSpot, szDecimals)
+}
+
+// freshBook returns coin's cached l2Book unless it is older than
+// pricing.book_max_age.
+func (a *App) freshBook(coin string) (market.L2Book, bool) {
+	if a.market == nil || coin == "" {
+		return market.L2Book{}, false
+	}
+	book, ok := a.market.CachedL2Book(coin)
+	if !ok {
+		return market.L2Book{}, false
+	}
+	if maxAge := a.config().Pricing.BookMaxAge; maxAge > 0 && time.Since(book.FetchedAt) > maxAge {
+		return market.L2Book{}, false
+	}
+	return book, true
+}
+
+// refreshPricingBooks fetches l2Book for the legs whose policy reads the
+// book, the spot book for a touch/inside maker entry, and the perp book for
+// the strategy.max_book_sell_imbalance gate, so planning sees a snapshot no
+// older than one tick. A failed fetch leaves those legs on
+// aggressive_ioc pricing and the maker entry at the mid.
+func (a *App) refreshPricingBooks(ctx context.Context) {
+	cfg := a.config()
+	if cfg == nil || a.market == nil {
+		return
+	}
+	coins := make(map[string]struct{})
+	for _, leg := range []pricingLeg{pricingSpotEntry, pricingPerpEntry, pricingSpotExit, pricingPerpExit} {
+		if !a.pricingPolicy(leg).NeedsBook() {
+			continue
+		}
+		coin := cfg.Strategy.PerpAsset
+		if leg == pricingSpotEntry || leg == pricingSpotExit {
+			coin = a.spotBookCoin()
+		}
+		if coin != "" {
+			coins[coin] = struct{}{}
+		}
+	}
+	if a.makerBookPrice() {
+		if coin := a.spotBookCoin(); coin != "" {
+			coins[coin] = struct{}{}
+		}
+	}
+	if cfg.Strategy.MaxBookSellImbalance > 0 && cfg.Strategy.PerpAsset != "" {
+		coins[cfg.Strategy.PerpAsset] = struct{}{}
+	}
+	for coin := range coins {
+		if _, err := a.market.L2Book(rest.WithPriority(ctx, rest.PriorityLow), coin); err != nil {
+			if !a.bookWarned && a.log != nil {
+				a.log.Warn("l2Book fetch failed; pricing falls back to aggressive_ioc", zap.String("coin", coin), zap.Error(err))
+			}
+			a.bookWarned = true
+			continue
+		}
+		if a.bookWarned && a.log != nil {
+			a.log.Info("l2Book fetch recovered", zap.String("coin", coin))
+		}
+		a.bookWarned = false
+	}
+}
+
+// spotBookCoin is the l2Book coin key for the spot leg ("@index" or pair).
+func (a *App) spotBookCoin() string {
+	spotCtx, err := a.spotContext(a.config().Strategy.SpotAsset)
+	if err != nil {
+		return ""
+	}
+	if spotCtx.MidKey != "" {
+		return spotCtx.MidKey
+	}
+	return spotCtx.Symbol
+}
+
+func bookLevels(levels []market.BookLevel) []exec.BookLevel {
+	out := make([]exec.BookLevel, len(levels))
+	for i, level := range levels {
+		out[i] = exec.BookLevel{Px: level.Px, Sz: level.Sz}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+)
+
+func TestPlanEntryUsesBookAwarePerpPricing(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetBook("ETH", map[string]any{"coin": "ETH", "levels": []any{
+		[]any{
+			map[string]any{"px": "2999", "sz": "0.001", "n": 1},
+			map[string]any{"px": "2990", "sz": "1", "n": 4},
+		},
+		[]any{
+			map[string]any{"px": "3001", "sz": "1", "n": 2},
+		},
+	}})
+	app := newNextTestApp(t, server)
+	app.cfg.Pricing.PerpEntry = "book_aware"
+	app.cfg.Pricing.BookMaxAge = time.Minute
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+	snap := strategy.MarketSnapshot{PerpAsset: "ETH", SpotAsset: "UETH", SpotMidPrice: 3000, PerpMidPrice: 3000, NotionalUSD: 20}
+
+	plan, err := app.planEntry(snap)
+	if err != nil {
+		t.Fatalf("plan entry: %v", err)
+	}
+	if plan.Perp.LimitPrice != 2997 {
+		t.Fatalf("expected aggressive fallback without a book, got %f", plan.Perp.LimitPrice)
+	}
+
+	app.refreshPricingBooks(ctx)
+	if server.Count("l2Book") != 1 {
+		t.Fatalf("expected one l2Book fetch for the perp leg, got %d", server.Count("l2Book"))
+	}
+	plan, err = app.planEntry(snap)
+	if err != nil {
+		t.Fatalf("plan entry: %v", err)
+	}
+	if plan.Perp.Size != 0.006 || plan.Perp.LimitPrice != 2987 {
+		t.Fatalf("expected perp priced through the covering level, got size %f limit %f", plan.Perp.Size, plan.Perp.LimitPrice)
+	}
+	if plan.Spot.LimitPrice != 3003 {
+		t.Fatalf("expected aggressive spot pricing, got %f", plan.Spot.LimitPrice)
+	}
+
+	app.cfg.Pricing.BookMaxAge = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if plan, _ := app.planEntry(snap); plan.Perp.LimitPrice != 2997 {
+		t.Fatalf("expected stale book to fall back to aggressive pricing, got %f", plan.Perp.LimitPrice)
+	}
+}
+
+func TestEntryDelayedOnBookImbalance(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetBook("ETH", map[string]any{"coin": "ETH", "levels": []any{
+		[]any{map[string]any{"px": "2999", "sz": "1", "n": 1}},
+		[]any{map[string]any{"px": "3001", "sz": "9", "n": 3}},
+	}})
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.MaxBookSellImbalance = 0.6
+	app.cfg.Strategy.BookImbalanceLevels = 5
+	app.cfg.Pricing.BookMaxAge = time.Minute
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+
+	app.refreshPricingBooks(ctx)
+	if server.Count("l2Book") != 1 {
+		t.Fatalf("expected the perp book fetched for the gate, got %d", server.Count("l2Book"))
+	}
+	in, err := app.collectTickInputs(ctx)
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	if !in.Snap.HasBookImbalance || in.Snap.BookImbalance > -0.6 {
+		t.Fatalf("expected an offer-heavy book imbalance, got %f (%v)", in.Snap.BookImbalance, in.Snap.HasBookImbalance)
+	}
+	plan := app.evaluateTick(in)
+	if plan.Decision != "skip_book_imbalance" || plan.Action != tickActionHold || !errors.Is(plan.Err, strategy.ErrBookImbalance) {
+		t.Fatalf("expected skip_book_imbalance/hold, got %s/%s (%v)", plan.Decision, plan.Action, plan.Err)
+	}
+
+	in.Snap.BookImbalance = 0.8
+	if plan := app.evaluateTick(in); plan.Action != tickActionEnter {
+		t.Fatalf("expected entry on a bid-heavy book, got %s/%s", plan.Decision, plan.Action)
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"strings"
+
+	"hl-carry-bot/internal/hl/exchange"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+// errReadOnly refuses operator commands that would trade or move funds.
+var errReadOnly = errors.New("read-only mode: trading and transfers are disabled")
+
+// readOnly reports read_only: the App observes and reports but never sends an
+// exchange action or transfer. The exchange client refuses them as well.
+func (a *App) readOnly() bool {
+	return a.config() != nil && a.config().ReadOnly
+}
+
+// signerFor builds the exchange signer from key. Without one (read_only) the
+// client gets a throwaway key: it never signs, and the signer only keys its
+// nonce bookkeeping.
+func signerFor(key *ecdsa.PrivateKey, isMainnet bool) (*exchange.Signer, error) {
+	if key == nil {
+		throwaway, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		key = throwaway
+	}
+	return exchange.NewSignerFromKey(key, isMainnet)
+}
+
+// readOnlyRefuses reports whether an operator command would trade, transfer,
+// or sign in read-only mode.
+func readOnlyRefuses(cmd string, args []string) bool {
+	sub := ""
+	if len(args) > 0 {
+		sub = strings.ToLower(args[0])
+	}
+	switch cmd {
+	case "flatten", "reduce":
+		return true
+	case "vault":
+		return sub == "deposit" || sub == "withdraw"
+	case "key":
+		return sub == "rotate"
+	}
+	return false
+}
+
+// observeReadOnlyTick ends a read-only tick once the decision is traced: the
+// planned action is logged instead of taken, and funding receipts are still
+// logged while the account is hedged.
+func (a *App) observeReadOnlyTick(ctx context.Context, in tickInputs, plan tickPlan) {
+	if plan.Action != tickActionHold && a.log != nil {
+		a.log.Info("read-only: action not taken",
+			zap.String("action", plan.Action),
+			zap.String("decision", plan.Decision),
+			zap.String("state", string(plan.State)),
+		)
+	}
+	if plan.Steady {
+		a.maybeLogFundingReceipt(ctx, in.Now, in.Snap, in.Forecast, in.HasForecast)
+	}
+}
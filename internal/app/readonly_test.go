@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+func TestReadOnlyTickObservesWithoutTrading(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.cfg.ReadOnly = true
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	writer := exec.New(&exchangeAdapter{client: client, tif: exchange.TifGtc, log: zap.NewNop()}, nil, zap.NewNop())
+	client.SetReadOnly(true)
+	app.exchange = client
+	app.executor = writer
+	ctx := context.Background()
+
+	if report, err := app.nextAction(ctx); err != nil || report.Action != tickActionEnter {
+		t.Fatalf("expected an entry signal to observe, got %+v (err=%v)", report, err)
+	}
+	if err := app.tick(ctx); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if len(server.Orders()) != 0 || app.strategy.State != strategy.StateIdle {
+		t.Fatalf("expected no entry in read-only, got %d orders in %s", len(server.Orders()), app.strategy.State)
+	}
+
+	client.SetReadOnly(false)
+	if _, err := writer.PlaceOrder(ctx, exec.Order{Asset: hltest.PerpAsset, IsBuy: true, Size: 0.01, LimitPrice: 2000}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	client.SetReadOnly(true)
+	if _, err := app.account.Reconcile(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	app.cancelOpenOrders(ctx, app.account.Snapshot().OpenOrders)
+	if len(server.Cancels()) != 0 || len(server.OpenOrderIDs()) != 1 {
+		t.Fatalf("expected the resting order left alone, got %d cancels", len(server.Cancels()))
+	}
+
+	if _, err := app.handleOperatorCommand(ctx, "flatten", nil, operatorMeta{UserID: 1}); !errors.Is(err, errReadOnly) {
+		t.Fatalf("expected /flatten refused, got %v", err)
+	}
+	if _, err := app.handleOperatorCommand(ctx, "vault", []string{"deposit", "10"}, operatorMeta{UserID: 1}); !errors.Is(err, errReadOnly) {
+		t.Fatalf("expected /vault deposit refused, got %v", err)
+	}
+	if status := app.operatorStatus(ctx); !strings.HasPrefix(status, "mode: read-only") {
+		t.Fatalf("expected the mode in /status, got:\n%s", status)
+	}
+}
@@ -0,0 +1,290 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/logging"
+	persist "hl-carry-bot/internal/state"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+// handleReduceCommand queues /reduce <pct>: the next tick shrinks the hedge
+// to pct percent of its current size.
+func (a *App) handleReduceCommand(ctx context.Context, args []string, meta operatorMeta) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("usage: /reduce <pct> keeps pct percent of the hedge")
+	}
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "%"), 64)
+	if err != nil || pct <= 0 || pct >= 100 {
+		return "", errors.New("reduce pct must be > 0 and < 100; use /flatten to exit")
+	}
+	a.opsMu.Lock()
+	a.reduceRequested = pct
+	a.opsMu.Unlock()
+	paused := a.isPaused()
+	a.auditOperatorEvent(ctx, operatorAuditEvent{
+		UpdateID:     meta.UpdateID,
+		Time:         time.Now().UTC(),
+		Action:       "reduce",
+		Command:      meta.Raw,
+		UserID:       meta.UserID,
+		Username:     meta.Username,
+		ChatID:       meta.ChatID,
+		PausedBefore: paused,
+		PausedAfter:  paused,
+		ReducePct:    pct,
+	})
+	a.requestTick()
+	return fmt.Sprintf("reduce queued: hedge to %.4g%% of its current size", pct), nil
+}
+
+// takeReduceRequest reports and clears a queued /reduce.
+func (a *App) takeReduceRequest() (float64, bool) {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+	pct := a.reduceRequested
+	a.reduceRequested = 0
+	return pct, pct > 0
+}
+
+// runReduceRequest runs a queued /reduce on the tick goroutine. It reports
+// false when nothing is hedged, leaving the tick to carry on.
+func (a *App) runReduceRequest(ctx context.Context, in tickInputs, pct float64) (bool, error) {
+	if a.strategy.Current() != strategy.StateHedgeOK || in.Flat {
+		if a.log != nil {
+			a.log.Warn("operator reduce skipped: no hedged position", zap.String("state", string(a.strategy.Current())))
+		}
+		return false, nil
+	}
+	_, err := a.reducePosition(ctx, in.Snap, pct, fmt.Sprintf("operator /reduce %.4g%%", pct))
+	return true, err
+}
+
+// runRiskReduce runs the reduce a risk rule planned. The episode is latched
+// as soon as the perp leg fills, even if the spot leg then falls short: the
+// hedge and rebalance ticks square the legs, and reducing again would cut the
+// hedge past risk.reduce_to_pct.
+func (a *App) runRiskReduce(ctx context.Context, snap strategy.MarketSnapshot, keepPct float64, trigger string) error {
+	perpFilled, err := a.reducePosition(ctx, snap, keepPct, trigger)
+	if perpFilled > flatEpsilon {
+		a.setRiskReduced(ctx, true)
+	}
+	return err
+}
+
+// defaultReducePct mirrors the risk.reduce_to_pct default for hand-built
+// configs.
+const defaultReducePct = 50
+
+func riskReducePct(cfg config.RiskConfig) float64 {
+	if cfg.ReduceToPct <= 0 || cfg.ReduceToPct >= 100 {
+		return defaultReducePct
+	}
+	return cfg.ReduceToPct
+}
+
+// reduceSnapshot is snap with both legs cut to the part a reduce to keepPct
+// percent removes, so planExit sizes and prices the reduce orders.
+func reduceSnapshot(snap strategy.MarketSnapshot, keepPct float64) strategy.MarketSnapshot {
+	cut := 1 - keepPct/100
+	snap.SpotBalance *= cut
+	snap.PerpPosition *= cut
+	return snap
+}
+
+// reducePosition shrinks the hedge to keepPct percent of its size. The perp
+// leg goes first as a reduce-only IOC so it can never flip the position; the
+// spot leg then sells in proportion to what the perp actually reduced, keeping
+// the legs matched on a partial fill. A spot shortfall leaves a delta for the
+// hedge and rebalance ticks rather than a retried reduce, so perpFilled is
+// reported alongside any error. Each attempt is journaled as reduce_start
+// followed by reduce_filled or reduce_failed; the strategy stays hedged either
+// way.
+func (a *App) reducePosition(ctx context.Context, snap strategy.MarketSnapshot, keepPct float64, trigger string) (perpFilled float64, err error) {
+	start := time.Now().UTC()
+	plan, err := a.planExit(reduceSnapshot(snap, keepPct))
+	if err != nil {
+		a.noteTradeOutcome(err)
+		return 0, err
+	}
+	if plan.Perp.Size <= 0 {
+		if a.log != nil {
+			a.log.Info("reduce skipped: size below exposure threshold", zap.String("trigger", trigger))
+		}
+		return 0, nil
+	}
+	spotCtx, err := a.spotContext(snap.SpotAsset)
+	if err != nil {
+		a.noteTradeOutcome(err)
+		return 0, err
+	}
+	event := persist.LifecycleRecord{
+		Attempt:     "reduce-" + strconv.FormatInt(start.UnixMilli(), 10),
+		PerpAsset:   snap.PerpAsset,
+		SpotAsset:   snap.SpotAsset,
+		NotionalUSD: plan.Perp.Size * strategy.BasisPrice(snap, strategy.ValuationOracle),
+		Detail:      trigger,
+	}
+	a.recordLifecycle(ctx, event, persist.LifecycleReduceStart, start)
+	if a.log != nil {
+		a.log.Info("reduce started", logging.Unsampled(),
+			zap.String("attempt", event.Attempt),
+			zap.String("trigger", trigger),
+			zap.Float64("keep_pct", keepPct),
+			zap.Float64("perp_size", plan.Perp.Size),
+			zap.Float64("spot_size", plan.Spot.Size),
+		)
+	}
+	defer func() {
+		a.noteTradeOutcome(err)
+		if err == nil {
+			return
+		}
+		event.Detail = trigger + ": " + err.Error()
+		a.recordLifecycle(ctx, event, persist.LifecycleReduceFailed, time.Now().UTC())
+		if a.log != nil {
+			a.log.Warn("reduce failed", logging.Unsampled(),
+				zap.Error(err),
+				zap.String("attempt", event.Attempt),
+				zap.Float64("perp_filled", event.PerpFilled),
+				zap.Float64("spot_filled", event.SpotFilled),
+			)
+		}
+		a.reconcileAccount(ctx, "reduce")
+		if a.alerts != nil {
+			if alertErr := a.alerts.Send(ctx, fmt.Sprintf("Reduce failed for %s/%s: %v", snap.PerpAsset, snap.SpotAsset, err)); alertErr != nil && a.log != nil {
+				a.log.Warn("alert send failed", zap.Error(alertErr))
+			}
+		}
+	}()
+
+	perpCloid, err := newCloid()
+	if err != nil {
+		return 0, err
+	}
+	perpOrderID, perpFilled, perpOpen, err := a.placeAndWait(ctx, exec.Order{
+		Asset:         plan.Perp.AssetID,
+		IsBuy:         plan.Perp.IsBuy,
+		Size:          plan.Perp.Size,
+		LimitPrice:    plan.Perp.LimitPrice,
+		ReduceOnly:    true,
+		ClientOrderID: perpCloid,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if perpOpen {
+		a.cancelBestEffort(ctx, plan.Perp.AssetID, perpOrderID)
+	}
+	event.PerpFilled = perpFilled
+	if perpFilled <= flatEpsilon {
+		err = errors.New("perp reduce did not fill")
+		return perpFilled, err
+	}
+	spotSize := spotCtx.Increments.RoundSize(plan.Spot.Size * perpFilled / plan.Perp.Size)
+	if spotSize > 0 {
+		spotCloid, err := newCloid()
+		if err != nil {
+			return perpFilled, err
+		}
+		spotOrderID, spotFilled, spotOpen, err := a.placeAndWait(ctx, exec.Order{
+			Asset:         plan.Spot.AssetID,
+			IsBuy:         plan.Spot.IsBuy,
+			Size:          spotSize,
+			LimitPrice:    plan.Spot.LimitPrice,
+			ClientOrderID: spotCloid,
+		})
+		if err != nil {
+			return perpFilled, err
+		}
+		if spotOpen {
+			a.cancelBestEffort(ctx, plan.Spot.AssetID, spotOrderID)
+		}
+		event.SpotFilled = spotFilled
+		if spotFilled+flatEpsilon < spotSize {
+			err = errors.New("spot reduce did not fully fill")
+			return perpFilled, err
+		}
+	}
+	a.recordLifecycle(ctx, event, persist.LifecycleReduceFilled, time.Now().UTC())
+	if a.log != nil {
+		a.log.Info("reduced delta-neutral position", logging.Unsampled(),
+			zap.String("attempt", event.Attempt),
+			zap.String("trigger", trigger),
+			zap.Float64("keep_pct", keepPct),
+			zap.Float64("perp_filled", event.PerpFilled),
+			zap.Float64("spot_filled", event.SpotFilled),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}
+	a.reconcileAccount(ctx, "reduce")
+	if a.alerts != nil {
+		if err := a.alerts.Send(ctx, fmt.Sprintf("Reduced %s/%s to %.4g%%: perp %.6f, spot %.6f (%s)", snap.PerpAsset, snap.SpotAsset, keepPct, event.PerpFilled, event.SpotFilled, trigger)); err != nil && a.log != nil {
+			a.log.Warn("alert send failed", zap.Error(err))
+		}
+	}
+	return perpFilled, nil
+}
+
+const riskReducedKey = "risk:reduced"
+
+// loadRiskReduced restores the risk reduce latch, so a restart inside a risk
+// episode does not reduce the hedge a second time.
+func (a *App) loadRiskReduced(ctx context.Context) {
+	if a.store == nil {
+		return
+	}
+	raw, ok, err := a.store.Get(ctx, riskReducedKey)
+	if err != nil {
+		a.logRiskReducedStoreError(err)
+		return
+	}
+	if !ok || raw == "" {
+		return
+	}
+	a.storeRiskReduced(true)
+	if a.log != nil {
+		a.log.Info("loaded risk reduce latch")
+	}
+}
+
+// setRiskReduced latches (or clears) the reduce for the current risk episode
+// and persists it.
+func (a *App) setRiskReduced(ctx context.Context, reduced bool) {
+	if a.riskReducedActive() == reduced {
+		return
+	}
+	a.storeRiskReduced(reduced)
+	if a.store == nil {
+		return
+	}
+	raw := ""
+	if reduced {
+		raw = "1"
+	}
+	if err := a.store.Set(ctx, riskReducedKey, raw); err != nil {
+		a.logRiskReducedStoreError(err)
+		return
+	}
+	if a.riskReducedWarned && a.log != nil {
+		a.log.Info("risk reduce latch write recovered")
+	}
+	a.riskReducedWarned = false
+}
+
+func (a *App) logRiskReducedStoreError(err error) {
+	if a.riskReducedWarned || a.log == nil {
+		return
+	}
+	a.riskReducedWarned = true
+	a.log.Warn("risk reduce latch store failed", zap.Error(err))
+}
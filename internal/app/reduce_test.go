@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/metrics"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+func TestReducePlannedOncePerRiskEpisode(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.strategy.State = strategy.StateHedgeOK
+
+	in := hedgedCompoundInputs(t, app)
+	in.Snap.SpotBalance = 0.1
+	in.Snap.PerpPosition = -0.1
+	in.Risk.MaxNotionalUSD = 100
+	in.Risk.ReduceToPct = 40
+	in.Risk.Actions = config.RiskActionsConfig{MaxNotional: "reduce"}
+	plan := app.evaluateTick(in)
+	if plan.Action != tickActionReduce || plan.Decision != "risk_reduce" || plan.Risk.Action != strategy.RiskActionReduce {
+		t.Fatalf("expected a risk reduce, got %s/%s (%s)", plan.Decision, plan.Action, plan.Risk.Action)
+	}
+	if len(plan.Orders) != 2 {
+		t.Fatalf("expected perp and spot reduce orders, got %+v", plan.Orders)
+	}
+	perp, spot := plan.Orders[0], plan.Orders[1]
+	if perp.Leg != "perp" || !perp.ReduceOnly || !perp.IsBuy || math.Abs(perp.Size-0.06) > 1e-9 {
+		t.Fatalf("expected a reduce-only perp buy of 0.06 first, got %+v", perp)
+	}
+	if spot.Leg != "spot" || spot.IsBuy || math.Abs(spot.Size-0.06) > 1e-9 {
+		t.Fatalf("expected a spot sell of 0.06, got %+v", spot)
+	}
+
+	in.RiskReduced = true
+	if plan := app.evaluateTick(in); plan.Action != tickActionHold || plan.Decision != "risk_hedge_only" {
+		t.Fatalf("expected the reduced position held, got %s/%s", plan.Decision, plan.Action)
+	}
+}
+
+func TestReduceCommandQueuesOneReduce(t *testing.T) {
+	store := &memoryStore{data: make(map[string]string)}
+	app := &App{store: store, wake: make(chan struct{}, 1)}
+	ctx := context.Background()
+	meta := operatorMeta{UserID: 1, ChatID: 2, Raw: "/reduce 25"}
+
+	for _, args := range [][]string{nil, {"0"}, {"100"}, {"half"}} {
+		if _, err := app.handleReduceCommand(ctx, args, meta); err == nil {
+			t.Fatalf("expected /reduce %v rejected", args)
+		}
+	}
+	if _, ok := app.takeReduceRequest(); ok {
+		t.Fatalf("expected nothing queued after rejected commands")
+	}
+	resp, err := app.handleOperatorCommand(ctx, "reduce", []string{"25%"}, meta)
+	if err != nil || !strings.HasPrefix(resp, "reduce queued") {
+		t.Fatalf("unexpected /reduce response %q (err=%v)", resp, err)
+	}
+	select {
+	case <-app.wake:
+	default:
+		t.Fatalf("expected an immediate tick requested")
+	}
+	audited := false
+	for key, val := range store.data {
+		if strings.HasPrefix(key, "ops:audit:") && strings.Contains(val, `"reduce_pct":25`) {
+			audited = true
+		}
+	}
+	if !audited {
+		t.Fatalf("expected a reduce audit event")
+	}
+	if pct, ok := app.takeReduceRequest(); !ok || pct != 25 {
+		t.Fatalf("expected a queued reduce to 25%%, got %v %t", pct, ok)
+	}
+	if _, ok := app.takeReduceRequest(); ok {
+		t.Fatalf("expected the reduce consumed")
+	}
+}
+
+func TestRiskReduceLatchesOnPerpFillAndPersists(t *testing.T) {
+	info := &fillServer{fills: map[string]float64{"perp-1": 0.5, "spot-1": 0.1}}
+	srv := httptest.NewServer(http.HandlerFunc(info.handle))
+	defer srv.Close()
+
+	store := &memoryStore{data: make(map[string]string)}
+	stub := &stubRestClient{orderIDs: []string{"perp-1", "spot-1"}}
+	app := &App{
+		cfg: &config.Config{Strategy: config.StrategyConfig{
+			EntryTimeout:      30 * time.Millisecond,
+			EntryPollInterval: 5 * time.Millisecond,
+		}},
+		log:      zap.NewNop(),
+		store:    store,
+		market:   newTestMarket(t, srv.URL),
+		account:  newTestAccount(t, srv.URL),
+		executor: exec.New(stub, nil, zap.NewNop()),
+		metrics:  metrics.NewNoop(),
+		strategy: strategy.NewStateMachine(),
+	}
+	snap := strategy.MarketSnapshot{
+		PerpAsset:    "BTC",
+		SpotAsset:    "UBTC",
+		SpotMidPrice: 100,
+		PerpMidPrice: 100,
+		SpotBalance:  1,
+		PerpPosition: -1,
+	}
+	ctx := context.Background()
+	if err := app.runRiskReduce(ctx, snap, 50, "risk: test"); err == nil {
+		t.Fatalf("expected the spot shortfall reported")
+	}
+	if got := len(stub.orders); got != 2 {
+		t.Fatalf("expected one perp and one spot order, got %d", got)
+	}
+	if !app.riskReducedActive() {
+		t.Fatalf("expected the reduce latched once the perp leg filled")
+	}
+	if store.data[riskReducedKey] == "" {
+		t.Fatalf("expected the latch persisted")
+	}
+
+	restarted := &App{store: store}
+	restarted.loadRiskReduced(ctx)
+	if !restarted.riskReducedActive() {
+		t.Fatalf("expected the latch restored after a restart")
+	}
+	restarted.setRiskReduced(ctx, false)
+	fresh := &App{store: store}
+	fresh.loadRiskReduced(ctx)
+	if fresh.riskReducedActive() {
+		t.Fatalf("expected a cleared latch to stay cleared")
+	}
+}
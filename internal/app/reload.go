@@ -0,0 +1,80 @@
+package app
+
+import (
+	"reflect"
+
+	"hl-carry-bot/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// Reload checks cfg against the running config and queues the fields that
+// can change at runtime (see config.Reload) for the start of the next tick,
+// so a tick never sees half of a reload. Open orders, cooldowns and the
+// strategy state carry over. A reload that touches restart-only fields is
+// rejected as a whole.
+func (a *App) Reload(cfg *config.Config) error {
+	next, err := a.prepareReload(cfg)
+	if err != nil {
+		return err
+	}
+	a.queueReload(next)
+	return nil
+}
+
+func (a *App) prepareReload(cfg *config.Config) (*config.Config, error) {
+	a.opsMu.RLock()
+	base := a.pendingConfig
+	if base == nil {
+		base = a.config()
+	}
+	a.opsMu.RUnlock()
+	return config.Reload(base, cfg)
+}
+
+func (a *App) queueReload(next *config.Config) {
+	a.opsMu.Lock()
+	a.pendingConfig = next
+	a.opsMu.Unlock()
+	if a.log != nil {
+		a.log.Info("config reload queued for next tick")
+	}
+}
+
+// config returns the running config: the one the App was built with until a
+// reload publishes its successor. Status queries and operator commands call it
+// off the tick goroutine, so it is safe for concurrent use.
+func (a *App) config() *config.Config {
+	if cfg := a.reloadedConfig.Load(); cfg != nil {
+		return cfg
+	}
+	return a.cfg
+}
+
+// applyPendingConfig swaps in a queued reload. It runs on the tick goroutine.
+func (a *App) applyPendingConfig() {
+	a.opsMu.Lock()
+	next := a.pendingConfig
+	prev := a.config()
+	if next != nil {
+		a.reloadedConfig.Store(next)
+		a.pendingConfig = nil
+	}
+	a.opsMu.Unlock()
+	if next == nil {
+		return
+	}
+	if a.alerts != nil && !reflect.DeepEqual(prev.Telegram, next.Telegram) {
+		a.alerts.Reconfigure(next.Telegram)
+	}
+	if a.log == nil {
+		return
+	}
+	a.log.Info("config reloaded", zap.Strings("changed", config.ChangedFields(prev, next)))
+	if a.riskOverrideActive() {
+		a.log.Info("operator risk override still active; reloaded risk settings apply after /risk reset")
+	}
+	if a.strategyOverrideActive() {
+		a.log.Info("operator strategy override still active; its parameters win over the reloaded ones until /strategy reset")
+	}
+}
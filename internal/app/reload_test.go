@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hltest"
+)
+
+func TestReloadAppliesAtNextTick(t *testing.T) {
+	server := hltest.NewServer(t)
+	server.SetNextFundingTime(time.Now().Add(time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	cooldownUntil := time.Now().Add(time.Hour)
+	app.rt.entryCooldownUntil = cooldownUntil
+
+	next := *app.config()
+	next.Strategy.MinFundingAPR = 0.2
+	next.Strategy.EntryCooldown = 2 * time.Hour
+	if err := app.Reload(&next); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if app.config().Strategy.EntryCooldown == 2*time.Hour {
+		t.Fatalf("expected the reload to wait for the next tick")
+	}
+	if err := app.tick(context.Background()); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if app.config().Strategy.MinFundingAPR != next.Strategy.MinFundingAPR || app.config().Strategy.EntryCooldown != 2*time.Hour {
+		t.Fatalf("expected reloaded strategy settings, got %+v", app.config().Strategy)
+	}
+	if !app.rt.entryCooldownUntil.Equal(cooldownUntil) {
+		t.Fatalf("expected the running cooldown kept, got %s", app.rt.entryCooldownUntil)
+	}
+
+	bad := *app.config()
+	bad.Strategy.SpotAsset = "UBTC"
+	if err := app.Reload(&bad); err == nil {
+		t.Fatalf("expected an asset change rejected")
+	}
+	app.applyPendingConfig()
+	if app.config().Strategy.SpotAsset == "UBTC" {
+		t.Fatalf("expected the rejected reload not applied")
+	}
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+// riskInputs is the slice of tickInputs the risk engine evaluates.
+func (in tickInputs) riskInputs() strategy.RiskInputs {
+	return strategy.RiskInputs{
+		Snap:                in.Snap,
+		DeltaUSD:            in.DeltaUSD,
+		DailyPnLUSD:         in.DailyPnL.TotalUSD(),
+		HasDailyPnL:         in.HasDailyPnL,
+		ConsecutiveFailures: in.ConsecutiveFailures,
+		ClockDrift:          in.ClockDrift,
+		HasClockDrift:       in.HasClockDrift,
+
+		LiquidationDistancePct: in.Liquidation.DistancePct,
+		HasLiquidationDistance: in.HasLiquidation,
+	}
+}
+
+// noteTradeOutcome feeds the consecutive_failures rule: a failed entry, exit,
+// hedge, or compound attempt extends the streak and a success resets it. A
+// failure after the streak lapsed (risk.failure_cool_off) starts a new one;
+// /resume and /risk reset clear it too.
+func (a *App) noteTradeOutcome(err error) {
+	now := time.Now().UTC()
+	coolOff := a.failureCoolOff()
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	if err == nil {
+		a.rt.consecutiveFailures = 0
+		return
+	}
+	if failureStreakLapsed(a.rt.lastFailureAt, now, coolOff) {
+		a.rt.consecutiveFailures = 0
+	}
+	a.rt.consecutiveFailures++
+	a.rt.lastFailureAt = now
+}
+
+func (a *App) failureCoolOff() time.Duration {
+	if a.config() == nil {
+		return 0
+	}
+	return a.riskConfig().FailureCoolOffValue()
+}
+
+func failureStreakLapsed(lastFailure, now time.Time, coolOff time.Duration) bool {
+	return coolOff > 0 && !lastFailure.IsZero() && now.Sub(lastFailure) >= coolOff
+}
+
+// observeRisk records the tick's assessment for /status and metrics, logging
+// and counting each rule when it starts firing.
+func (a *App) observeRisk(assessment strategy.RiskAssessment) {
+	last := a.swapRiskAssessment(assessment)
+	previous := make(map[string]bool, len(last.Violations))
+	for _, v := range last.Violations {
+		previous[v.Rule] = true
+	}
+	for _, v := range assessment.Violations {
+		if previous[v.Rule] {
+			continue
+		}
+		if a.metrics != nil && a.metrics.RiskViolations != nil {
+			a.metrics.RiskViolations.Inc()
+		}
+		if a.log != nil {
+			a.log.Warn("risk rule violated",
+				zap.String("rule", v.Rule),
+				zap.String("action", v.Action.String()),
+				zap.Error(v.Err),
+			)
+		}
+	}
+	if assessment.Action != last.Action && a.log != nil {
+		a.log.Info("risk action changed",
+			zap.String("from", last.Action.String()),
+			zap.String("to", assessment.Action.String()),
+			zap.Strings("rules", assessment.Rules()),
+		)
+	}
+	if a.metrics != nil && a.metrics.RiskAction != nil {
+		a.metrics.RiskAction.Set(float64(assessment.Action))
+	}
+}
+
+// observeFundingAPR exports the perp funding rate annualized over its
+// interval, the value entry gating compares with strategy.min_funding_apr.
+func (a *App) observeFundingAPR(snap strategy.MarketSnapshot) {
+	if a.metrics != nil && a.metrics.FundingAPR != nil {
+		a.metrics.FundingAPR.Set(snap.FundingAPR())
+	}
+}
+
+func (a *App) riskEngineStatus() string {
+	risk, failures := a.riskAssessment(), a.consecutiveFailures(time.Now().UTC())
+	if len(risk.Violations) == 0 {
+		return fmt.Sprintf("risk_action: %s (consecutive_failures %d)", risk.Action, failures)
+	}
+	parts := make([]string, len(risk.Violations))
+	for i, v := range risk.Violations {
+		parts[i] = fmt.Sprintf("%s=%s", v.Rule, v.Action)
+	}
+	return fmt.Sprintf("risk_action: %s (%s; consecutive_failures %d)", risk.Action, strings.Join(parts, " "), failures)
+}
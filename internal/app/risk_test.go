@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+)
+
+func TestRiskActionsShapeHedgedPlan(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Minute).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.strategy.State = strategy.StateHedgeOK
+	app.cfg.Strategy.ExitFundingGuard = 10 * time.Minute
+
+	in := hedgedCompoundInputs(t, app)
+	in.Risk.MaxConsecutiveFailures = 2
+	in.ConsecutiveFailures = 2
+	if plan := app.evaluateTick(in); plan.Action != tickActionHold || plan.Decision != "hedge_ok" || !plan.Steady {
+		t.Fatalf("expected steady hold when only entries are blocked, got %s/%s", plan.Decision, plan.Action)
+	}
+
+	in.Risk.Actions.ConsecutiveFailures = "hedge_only"
+	plan := app.evaluateTick(in)
+	if plan.Decision != "risk_hedge_only" || plan.Action != tickActionHold || !plan.Steady {
+		t.Fatalf("expected hedge-only hold, got %s/%s steady=%t", plan.Decision, plan.Action, plan.Steady)
+	}
+
+	in.Risk.Actions.ConsecutiveFailures = "flatten"
+	plan = app.evaluateTick(in)
+	if plan.Decision != "risk_flatten" || plan.Action != tickActionExit || plan.ExitGuarded {
+		t.Fatalf("expected unguarded risk flatten, got %s/%s guarded=%t", plan.Decision, plan.Action, plan.ExitGuarded)
+	}
+	if !errors.Is(plan.Err, strategy.ErrConsecutiveFailures) {
+		t.Fatalf("expected ErrConsecutiveFailures, got %v", plan.Err)
+	}
+
+	in.Risk.Actions.ConsecutiveFailures = "halt"
+	if plan := app.evaluateTick(in); plan.Decision != "skip_risk" || plan.Action != tickActionHold {
+		t.Fatalf("expected risk halt, got %s/%s", plan.Decision, plan.Action)
+	}
+}
+
+func TestRiskBlocksIdleEntry(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	in, err := app.collectTickInputs(context.Background())
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	in.Risk.MaxConsecutiveFailures = 1
+	in.ConsecutiveFailures = 1
+	plan := app.evaluateTick(in)
+	if plan.Decision != "risk_block_entry" || plan.Action != tickActionHold {
+		t.Fatalf("expected blocked entry, got %s/%s", plan.Decision, plan.Action)
+	}
+	if plan.Risk.Action != strategy.RiskActionBlockEntry {
+		t.Fatalf("expected block_entry assessment, got %s", plan.Risk.Action)
+	}
+}
+
+func TestObserveRiskCountsNewViolations(t *testing.T) {
+	m, counters := newTestMetrics()
+	app := &App{metrics: m}
+	flatten := strategy.RiskAssessment{
+		Action:     strategy.RiskActionFlatten,
+		Violations: []strategy.RiskViolation{{Rule: strategy.RiskRuleMaxDelta, Action: strategy.RiskActionFlatten, Err: strategy.ErrMaxDelta}},
+	}
+	app.observeRisk(flatten)
+	app.observeRisk(flatten)
+	if counters.riskViolation.count != 1 || counters.riskAction.value != float64(strategy.RiskActionFlatten) {
+		t.Fatalf("expected one violation at flatten, got %d at %v", counters.riskViolation.count, counters.riskAction.value)
+	}
+	if got := app.riskEngineStatus(); got != "risk_action: flatten (max_delta=flatten; consecutive_failures 0)" {
+		t.Fatalf("unexpected status %q", got)
+	}
+	app.observeRisk(strategy.RiskAssessment{})
+	app.observeRisk(flatten)
+	if counters.riskViolation.count != 2 || counters.riskAction.value != float64(strategy.RiskActionFlatten) {
+		t.Fatalf("expected a recurring violation counted again, got %d", counters.riskViolation.count)
+	}
+}
+
+func TestFailureStreak(t *testing.T) {
+	app := &App{}
+	app.noteTradeOutcome(errors.New("boom"))
+	app.noteTradeOutcome(errors.New("boom"))
+	if app.rt.consecutiveFailures != 2 {
+		t.Fatalf("expected 2 consecutive failures, got %d", app.rt.consecutiveFailures)
+	}
+	app.noteTradeOutcome(nil)
+	if app.rt.consecutiveFailures != 0 {
+		t.Fatalf("expected streak reset, got %d", app.rt.consecutiveFailures)
+	}
+}
+
+func TestFailureStreakClearsSoEntriesResume(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.cfg.Risk.MaxConsecutiveFailures = 2
+	coolOff := time.Hour
+	app.cfg.Risk.FailureCoolOff = &coolOff
+	ctx := context.Background()
+	plan := func() tickPlan {
+		t.Helper()
+		in, err := app.collectTickInputs(ctx)
+		if err != nil {
+			t.Fatalf("collect inputs: %v", err)
+		}
+		return app.evaluateTick(in)
+	}
+
+	app.noteTradeOutcome(errors.New("boom"))
+	app.noteTradeOutcome(errors.New("boom"))
+	if p := plan(); p.Decision != "risk_block_entry" {
+		t.Fatalf("expected entries blocked by the streak, got %s/%s", p.Decision, p.Action)
+	}
+
+	// The streak lapses once its last failure is a cool-off old.
+	app.rt.lastFailureAt = time.Now().Add(-2 * time.Hour)
+	if p := plan(); p.Action != tickActionEnter {
+		t.Fatalf("expected entries to resume after the cool-off, got %s/%s", p.Decision, p.Action)
+	}
+	app.noteTradeOutcome(errors.New("boom"))
+	if app.rt.consecutiveFailures != 1 {
+		t.Fatalf("expected a new streak after the cool-off, got %d", app.rt.consecutiveFailures)
+	}
+
+	app.noteTradeOutcome(errors.New("boom"))
+	if p := plan(); p.Decision != "risk_block_entry" {
+		t.Fatalf("expected entries blocked again, got %s/%s", p.Decision, p.Action)
+	}
+	if _, err := app.handleOperatorCommand(ctx, "resume", nil, operatorMeta{Raw: "/resume"}); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if p := plan(); p.Action != tickActionEnter {
+		t.Fatalf("expected entries to resume after /resume, got %s/%s", p.Decision, p.Action)
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/precision"
+	"hl-carry-bot/internal/logging"
+
+	"go.uber.org/zap"
+)
+
+func (a *App) rollbackSpot(ctx context.Context, assetID int, size, limit float64) error {
+	return a.rollbackSpotWith(ctx, assetID, size, limit, false)
+}
+
+// rollbackSpotWith unwinds size of spot with IOCs, starting at limit. When
+// the market has moved past limit, each retry re-prices off a fresh mid and
+// widens the offset by strategy.rollback_step_bps, capped at
+// strategy.rollback_max_bps. Whatever is still unfilled after the last attempt
+// is alerted as residual exposure.
+func (a *App) rollbackSpotWith(ctx context.Context, assetID int, size, limit float64, isBuy bool) error {
+	if size <= 0 {
+		return nil
+	}
+	ctx, cancel := a.unwindContext(ctx)
+	defer cancel()
+	if a.metrics != nil {
+		a.metrics.Rollbacks.Inc()
+	}
+	attempts, stepBps, maxBps := a.rollbackPolicy()
+	remaining := size
+	var errs []error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if a.metrics != nil {
+				a.metrics.RollbackRetries.Inc()
+			}
+			repriced, szDecimals, err := a.rollbackLimit(ctx, isBuy, math.Min(a.iocPriceBps()+stepBps*float64(attempt), maxBps))
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				limit = repriced
+				remaining = precision.Spot(szDecimals).RoundSize(remaining)
+			}
+			if remaining <= 0 {
+				// What is left is below the lot size and cannot be sold.
+				return nil
+			}
+			if a.log != nil {
+				a.log.Warn("spot rollback retry", logging.Unsampled(),
+					zap.Int("attempt", attempt+1),
+					zap.Float64("remaining", remaining),
+					zap.Float64("limit", limit),
+				)
+			}
+		}
+		filled, err := a.rollbackOnce(ctx, assetID, remaining, limit, isBuy)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		remaining -= filled
+		if remaining <= 1e-9 {
+			return nil
+		}
+	}
+	if a.metrics != nil {
+		a.metrics.RollbacksFailed.Inc()
+	}
+	err := fmt.Errorf("spot rollback filled %.6f of %.6f after %d attempts", size-remaining, size, attempts)
+	if len(errs) > 0 {
+		err = fmt.Errorf("%w: %w", err, errors.Join(errs...))
+	}
+	a.alertRollbackResidual(ctx, assetID, remaining, limit, isBuy)
+	return err
+}
+
+func (a *App) rollbackOnce(ctx context.Context, assetID int, size, limit float64, isBuy bool) (float64, error) {
+	order := exec.Order{
+		Asset:      assetID,
+		IsBuy:      isBuy,
+		Size:       size,
+		LimitPrice: limit,
+		Tif:        string(exchange.TifIoc),
+	}
+	orderID, filled, open, err := a.placeAndWait(ctx, order)
+	if err != nil {
+		return filled, err
+	}
+	if open {
+		a.cancelBestEffort(ctx, assetID, orderID)
+	}
+	return filled, nil
+}
+
+// rollbackLimit prices a rollback IOC off the current spot mid.
+func (a *App) rollbackLimit(ctx context.Context, isBuy bool, bps float64) (float64, int, error) {
+	mid, spotCtx, err := a.spotMid(ctx, a.config().Strategy.SpotAsset)
+	if err != nil {
+		return 0, -1, err
+	}
+	limit := limitPriceWithOffset(mid, isBuy, true, spotCtx.BaseSzDecimals, bps)
+	if limit <= 0 {
+		return 0, -1, errors.New("rollback limit price invalid")
+	}
+	return limit, spotCtx.BaseSzDecimals, nil
+}
+
+func (a *App) rollbackPolicy() (int, float64, float64) {
+	cfg := a.config().Strategy
+	attempts := cfg.RollbackAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	maxBps := cfg.RollbackMaxBps
+	if ioc := a.iocPriceBps(); maxBps < ioc {
+		maxBps = ioc
+	}
+	return attempts, cfg.RollbackStepBps, maxBps
+}
+
+func (a *App) alertRollbackResidual(ctx context.Context, assetID int, remaining, limit float64, isBuy bool) {
+	side := "sell"
+	if isBuy {
+		side = "buy"
+	}
+	if a.log != nil {
+		a.log.Error("spot rollback left residual exposure", logging.Unsampled(),
+			zap.Int("asset", assetID),
+			zap.String("side", side),
+			zap.Float64("residual", remaining),
+			zap.Float64("residual_usd", remaining*limit),
+			zap.Float64("last_limit", limit),
+		)
+	}
+	if a.alerts == nil {
+		return
+	}
+	msg := fmt.Sprintf("Spot rollback incomplete: %.6f %s (~%.2f USD) still to %s on asset %d; manual unwind needed", remaining, a.config().Strategy.SpotAsset, remaining*limit, side, assetID)
+	if err := a.alerts.Send(ctx, msg); err != nil && a.log != nil {
+		a.log.Warn("alert send failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/exec"
+
+	"go.uber.org/zap"
+)
+
+func newRollbackTestApp(t *testing.T, fills map[string]float64, orderIDs []string) (*App, *stubRestClient, *metricsCounters) {
+	t.Helper()
+	info := &fillServer{fills: fills, mids: map[string]any{"UBTC/USDC": "110"}}
+	srv := httptest.NewServer(http.HandlerFunc(info.handle))
+	t.Cleanup(srv.Close)
+	stub := &stubRestClient{orderIDs: orderIDs}
+	metricsStub, counters := newTestMetrics()
+	app := &App{
+		cfg: &config.Config{Strategy: config.StrategyConfig{
+			SpotAsset:         "UBTC",
+			IOCPriceBps:       10,
+			RollbackAttempts:  3,
+			RollbackStepBps:   50,
+			RollbackMaxBps:    100,
+			EntryTimeout:      30 * time.Millisecond,
+			EntryPollInterval: 5 * time.Millisecond,
+		}},
+		log:      zap.NewNop(),
+		market:   newTestMarket(t, srv.URL),
+		account:  newTestAccount(t, srv.URL),
+		executor: exec.New(stub, nil, zap.NewNop()),
+		metrics:  metricsStub,
+	}
+	return app, stub, counters
+}
+
+func TestRollbackSpotRepricesRemainder(t *testing.T) {
+	app, stub, counters := newRollbackTestApp(t, map[string]float64{"rb-1": 0.4, "rb-2": 0.6}, []string{"rb-1", "rb-2"})
+
+	if err := app.rollbackSpot(context.Background(), 10000, 1, 99); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if len(stub.orders) != 2 {
+		t.Fatalf("expected 2 rollback orders, got %d", len(stub.orders))
+	}
+	first, retry := stub.orders[0], stub.orders[1]
+	if first.LimitPrice != 99 || first.Size != 1 {
+		t.Fatalf("expected first attempt at precomputed limit, got %+v", first)
+	}
+	// Retry prices off the fresh 110 mid with 10+50 bps.
+	if retry.IsBuy || retry.Size != 0.6 || retry.LimitPrice >= 110 || retry.LimitPrice < 110*(1-0.0061) {
+		t.Fatalf("unexpected retry order: %+v", retry)
+	}
+	if counters.rollbacks.count != 1 || counters.rollbackRetry.count != 1 || counters.rollbackFail.count != 0 {
+		t.Fatalf("unexpected rollback counters: %d/%d/%d", counters.rollbacks.count, counters.rollbackRetry.count, counters.rollbackFail.count)
+	}
+}
+
+func TestRollbackSpotCapsOffsetAndReportsResidual(t *testing.T) {
+	app, stub, counters := newRollbackTestApp(t, map[string]float64{}, []string{"rb-1", "rb-2", "rb-3"})
+
+	err := app.rollbackSpotWith(context.Background(), 10000, 1, 99, false)
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("expected residual error, got %v", err)
+	}
+	if len(stub.orders) != 3 {
+		t.Fatalf("expected 3 rollback orders, got %d", len(stub.orders))
+	}
+	// 10+100 bps is capped at rollback_max_bps.
+	if last := stub.orders[2]; last.LimitPrice < 110*(1-0.0101) {
+		t.Fatalf("expected offset capped at 100 bps, got %+v", last)
+	}
+	if counters.rollbackFail.count != 1 || counters.rollbackRetry.count != 2 {
+		t.Fatalf("unexpected rollback counters: failed %d retries %d", counters.rollbackFail.count, counters.rollbackRetry.count)
+	}
+}
+
+func TestRollbackSpotTreatsSubLotRemainderAsDone(t *testing.T) {
+	app, stub, _ := newRollbackTestApp(t, map[string]float64{"rb-1": 0.99999999}, []string{"rb-1", "rb-2"})
+	app.metrics = nil
+
+	if err := app.rollbackSpot(context.Background(), 10000, 1, 99); err != nil {
+		t.Fatalf("expected a remainder below the lot treated as done, got %v", err)
+	}
+	if len(stub.orders) != 1 {
+		t.Fatalf("expected no order for the sub-lot remainder, got %d", len(stub.orders))
+	}
+}
@@ -0,0 +1,255 @@
+package app
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/strategy"
+)
+
+// runtimeState is the strategy loop's mutable state that /status, operator
+// commands and the HTTP handlers also read while a tick runs. Writes hold mu,
+// and so does every read off the strategy loop, through the accessors below;
+// the loop's private bookkeeping (warn-once flags, retry timers) stays on
+// App.
+type runtimeState struct {
+	mu                   sync.RWMutex
+	killSwitchActive     bool
+	entryCooldownUntil   time.Time
+	hedgeCooldownUntil   time.Time
+	lastFundingReceiptAt time.Time
+	consecutiveFailures  int
+	lastFailureAt        time.Time
+	risk                 strategy.RiskAssessment
+	fundingOKCount       int
+	fundingBadCount      int
+	basis                *strategy.BasisTracker
+	entryBasis           float64
+	hasEntryBasis        bool
+	lastDailyPnL         strategy.DailyPnL
+	hasLastDailyPnL      bool
+	fees                 account.FeeSchedule
+	hasFees              bool
+	compoundAccruedUSD   float64
+	vaultParkedUSD       float64
+	vaultLockedUntil     time.Time
+	riskReduced          bool
+	clockOffset          time.Duration
+	hasClockOffset       bool
+	dailyBase            *strategy.DailyPnLBaseline
+	dailyFills           []strategy.PnLFill
+	dailyFundingUSD      float64
+	hasDailyFetch        bool
+	crashStopOrderID     string
+}
+
+func (a *App) lastFundingReceipt() time.Time {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.lastFundingReceiptAt
+}
+
+func (a *App) setLastFundingReceipt(at time.Time) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.lastFundingReceiptAt = at
+}
+
+// crashStopOrderID is the oid of the resting crash stop, or "" without one.
+func (a *App) crashStopOrderID() string {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.crashStopOrderID
+}
+
+// consecutiveFailures is the failure streak at now; one whose last failure
+// is risk.failure_cool_off old has lapsed.
+func (a *App) consecutiveFailures(now time.Time) int {
+	coolOff := a.failureCoolOff()
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	if failureStreakLapsed(a.rt.lastFailureAt, now, coolOff) {
+		return 0
+	}
+	return a.rt.consecutiveFailures
+}
+
+func (a *App) clearFailureStreak() {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.consecutiveFailures = 0
+}
+
+func (a *App) riskAssessment() strategy.RiskAssessment {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.risk
+}
+
+// swapRiskAssessment stores the tick's assessment and returns the previous
+// one.
+func (a *App) swapRiskAssessment(assessment strategy.RiskAssessment) strategy.RiskAssessment {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	previous := a.rt.risk
+	a.rt.risk = assessment
+	return previous
+}
+
+func (a *App) fundingCounts() (int, int) {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.fundingOKCount, a.rt.fundingBadCount
+}
+
+func (a *App) setFundingCounts(okCount, badCount int) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.fundingOKCount, a.rt.fundingBadCount = okCount, badCount
+}
+
+// observeBasisSample adds a sample to the rolling basis tracker, creating it
+// with window on first use.
+func (a *App) observeBasisSample(now time.Time, basis float64, window time.Duration) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	if a.rt.basis == nil {
+		a.rt.basis = strategy.NewBasisTracker(window)
+	}
+	a.rt.basis.Observe(now, basis)
+}
+
+func (a *App) basisStats() (strategy.BasisStats, bool) {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.basis.Stats()
+}
+
+func (a *App) entryBasis() (float64, bool) {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.entryBasis, a.rt.hasEntryBasis
+}
+
+func (a *App) storeEntryBasis(basis float64, ok bool) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.entryBasis, a.rt.hasEntryBasis = basis, ok
+}
+
+func (a *App) lastDailyPnL() (strategy.DailyPnL, bool) {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.lastDailyPnL, a.rt.hasLastDailyPnL
+}
+
+func (a *App) setLastDailyPnL(pnl strategy.DailyPnL, ok bool) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.lastDailyPnL, a.rt.hasLastDailyPnL = pnl, ok
+}
+
+func (a *App) feeSchedule() (account.FeeSchedule, bool) {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.fees, a.rt.hasFees
+}
+
+// setFeeSchedule stores fees and reports whether they changed.
+func (a *App) setFeeSchedule(fees account.FeeSchedule) bool {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	changed := !a.rt.hasFees || fees != a.rt.fees
+	a.rt.fees, a.rt.hasFees = fees, true
+	return changed
+}
+
+func (a *App) compoundAccrued() float64 {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.compoundAccruedUSD
+}
+
+func (a *App) storeCompoundAccrued(accrued float64) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.compoundAccruedUSD = accrued
+}
+
+func (a *App) vaultParked() float64 {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.vaultParkedUSD
+}
+
+func (a *App) storeVaultParked(parked float64) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.vaultParkedUSD = parked
+}
+
+func (a *App) vaultLockedUntil() time.Time {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.vaultLockedUntil
+}
+
+func (a *App) storeVaultLockedUntil(until time.Time) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.vaultLockedUntil = until
+}
+
+// addVaultParked moves the parked balance by delta, never below zero, and
+// returns the new balance. Operator transfers and auto-parking both move it.
+func (a *App) addVaultParked(delta float64) float64 {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.vaultParkedUSD = math.Max(a.rt.vaultParkedUSD+delta, 0)
+	return a.rt.vaultParkedUSD
+}
+
+func (a *App) riskReducedActive() bool {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.riskReduced
+}
+
+func (a *App) storeRiskReduced(reduced bool) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.riskReduced = reduced
+}
+
+func (a *App) clockDrift() (time.Duration, bool) {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.clockOffset, a.rt.hasClockOffset
+}
+
+func (a *App) dailyBaseline() *strategy.DailyPnLBaseline {
+	a.rt.mu.RLock()
+	defer a.rt.mu.RUnlock()
+	return a.rt.dailyBase
+}
+
+// startDailyWindow installs a new day's baseline and drops the previous
+// day's flows until the next fetch.
+func (a *App) startDailyWindow(base *strategy.DailyPnLBaseline) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.dailyBase = base
+	a.rt.dailyFills = nil
+	a.rt.dailyFundingUSD = 0
+	a.rt.hasDailyFetch = false
+}
+
+func (a *App) storeDailyFlows(fills []strategy.PnLFill, fundingUSD float64) {
+	a.rt.mu.Lock()
+	defer a.rt.mu.Unlock()
+	a.rt.dailyFills = fills
+	a.rt.dailyFundingUSD = fundingUSD
+	a.rt.hasDailyFetch = true
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// TestConcurrentStatusQueriesDuringTicks runs operator and HTTP status queries while
+// the strategy loop ticks and attempts entries; run under -race it fails on any state
+// the loop writes without holding the runtime lock.
+func TestConcurrentStatusQueriesDuringTicks(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.metrics = metrics.NewNoop()
+	rest := &restingRest{}
+	app.executor = exec.New(rest, nil, zap.NewNop())
+	app.cfg.Strategy.EntryTimeout = 50 * time.Millisecond
+	app.cfg.Strategy.EntryPollInterval = 10 * time.Millisecond
+	app.cfg.Strategy.EntryCooldown = time.Minute
+	app.cfg.Strategy.HedgeCooldown = time.Minute
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	query := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					fn()
+				}
+			}
+		}()
+	}
+	serve := func(handler http.HandlerFunc, path string) func() {
+		return func() { handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil)) }
+	}
+	query(func() { _, _ = app.handleOperatorCommand(ctx, "status", nil, operatorMeta{}) })
+	query(func() { _, _ = app.handleOperatorCommand(ctx, "pnl", nil, operatorMeta{}) })
+	query(func() { _, _ = app.handleOperatorCommand(ctx, "funding", nil, operatorMeta{}) })
+	query(func() { _, _ = app.handleOperatorCommand(ctx, "next", nil, operatorMeta{}) })
+	query(func() { _, _ = app.handleOperatorCommand(ctx, "risk", nil, operatorMeta{}) })
+	query(serve(app.handleSimulateAPI, "/api/simulate"))
+	query(serve(app.handleNextAPI, "/api/next"))
+	query(serve(app.handleDataAgeAPI, "/api/data-age"))
+	query(serve(app.handleShadowAPI, "/api/shadow"))
+	query(serve(app.handleHealthz, "/healthz"))
+	// A SIGHUP reload queues a new config that the ticks swap in.
+	reloaded := *app.config()
+	reloaded.Strategy.MinFundingAPR = 0.01
+	query(func() { _ = app.Reload(&reloaded) })
+
+	// The spot leg rests and is canceled, so every tick attempts and aborts
+	// an entry.
+	for i := 0; i < 5; i++ {
+		_ = app.tick(ctx)
+	}
+	close(done)
+	wg.Wait()
+	if rest.placed == 0 {
+		t.Fatalf("expected the ticks to attempt entries")
+	}
+}
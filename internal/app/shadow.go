@@ -0,0 +1,267 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/logging"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+const (
+	shadowRunLive   = "live"
+	shadowRunShadow = "shadow"
+)
+
+// shadowRun paper-trades one strategy parameter set on the live tick inputs.
+// Each tick runs evaluateTick with the run's parameters against its virtual
+// position, so every gate the live tick applies applies here too. Entries and
+// exits are assumed to fill at notional, funding accrues on that notional, and
+// fees/slippage are charged per leg. The live parameters get a run too so both
+// sides of the comparison are simulated the same way.
+type shadowRun struct {
+	name string
+	cfg  config.StrategyConfig
+	// ownCarry re-derives the net carry at cfg's fee and slippage instead of
+	// taking the live tick's, for a run that overrides either.
+	ownCarry bool
+
+	inPosition  bool
+	okCount     int
+	badCount    int
+	notionalUSD float64
+	enteredAt   time.Time
+	lastAccrual time.Time
+	entries     int
+	exits       int
+	fundingUSD  float64
+	costUSD     float64
+	decision    string
+	action      string
+}
+
+type shadowRunReport struct {
+	Name             string     `json:"name"`
+	Decision         string     `json:"decision"`
+	Action           string     `json:"action"`
+	InPosition       bool       `json:"in_position"`
+	EnteredAt        *time.Time `json:"entered_at,omitempty"`
+	Entries          int        `json:"entries"`
+	Exits            int        `json:"exits"`
+	FundingUSD       float64    `json:"funding_usd"`
+	CostUSD          float64    `json:"cost_usd"`
+	PnLUSD           float64    `json:"pnl_usd"`
+	MinFundingAPR    float64    `json:"min_funding_apr"`
+	CarryBufferUSD   float64    `json:"carry_buffer_usd"`
+	MaxVolatility    float64    `json:"max_volatility"`
+	ExitOnFundingDip bool       `json:"exit_on_funding_dip"`
+}
+
+type shadowReport struct {
+	StartedAt     time.Time         `json:"started_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	Ticks         int               `json:"ticks"`
+	DivergedTicks int               `json:"diverged_ticks"`
+	Runs          []shadowRunReport `json:"runs"`
+}
+
+// shadowEvaluator runs the live and shadow parameter sets side by side.
+type shadowEvaluator struct {
+	mu        sync.Mutex
+	live      *shadowRun
+	shadow    *shadowRun
+	startedAt time.Time
+	updatedAt time.Time
+	ticks     int
+	diverged  int
+}
+
+func newShadowEvaluator(cfg *config.Config) *shadowEvaluator {
+	if cfg == nil || !cfg.Shadow.Enabled {
+		return nil
+	}
+	return &shadowEvaluator{
+		live: &shadowRun{name: shadowRunLive, cfg: cfg.Strategy},
+		shadow: &shadowRun{
+			name:     shadowRunShadow,
+			cfg:      cfg.Shadow.Apply(cfg.Strategy),
+			ownCarry: cfg.Shadow.FeeBps != nil || cfg.Shadow.SlippageBps != nil,
+		},
+	}
+}
+
+// observeShadow feeds one tick to the shadow evaluator and logs whenever
+// either parameter set would have entered or exited.
+func (a *App) observeShadow(in tickInputs) {
+	if a.shadow == nil {
+		return
+	}
+	liveAction, shadowAction, diverged := a.shadow.observe(in, a.evaluateTickFor)
+	if a.log == nil || (shadowAction == tickActionHold && liveAction == tickActionHold) {
+		return
+	}
+	report := a.shadow.report()
+	fields := []zap.Field{
+		logging.Unsampled(),
+		zap.String("live_action", liveAction),
+		zap.String("shadow_action", shadowAction),
+		zap.Bool("diverged", diverged),
+		zap.Float64("funding_rate", in.Snap.FundingRate),
+		zap.Float64("funding_apr", in.Snap.FundingAPR()),
+		zap.Float64("net_expected_carry_usd", in.NetCarryUSD),
+	}
+	for _, run := range report.Runs {
+		fields = append(fields, zap.Float64(run.Name+"_pnl_usd", run.PnLUSD))
+	}
+	a.log.Info("shadow decision", fields...)
+}
+
+func (s *shadowEvaluator) observe(in tickInputs, evaluate tickEvaluator) (string, string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.startedAt.IsZero() {
+		s.startedAt = in.Now
+	}
+	s.updatedAt = in.Now
+	s.ticks++
+	liveAction := s.live.step(in, evaluate)
+	shadowAction := s.shadow.step(in, evaluate)
+	diverged := liveAction != shadowAction
+	if diverged {
+		s.diverged++
+	}
+	return liveAction, shadowAction, diverged
+}
+
+func (s *shadowEvaluator) report() shadowReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return shadowReport{
+		StartedAt:     s.startedAt,
+		UpdatedAt:     s.updatedAt,
+		Ticks:         s.ticks,
+		DivergedTicks: s.diverged,
+		Runs:          []shadowRunReport{s.live.report(), s.shadow.report()},
+	}
+}
+
+// tickEvaluator is evaluateTickFor.
+type tickEvaluator func(run tickRun, in tickInputs) tickPlan
+
+// step advances the virtual position by one tick and returns the action it
+// would have taken. Hedges, reduces and compounding leave the virtual
+// position as it is.
+func (r *shadowRun) step(in tickInputs, evaluate tickEvaluator) string {
+	if r.inPosition && !r.lastAccrual.IsZero() && in.Now.After(r.lastAccrual) {
+		r.fundingUSD += r.notionalUSD * in.Snap.FundingRate * in.Now.Sub(r.lastAccrual).Hours()
+	}
+	r.lastAccrual = in.Now
+
+	run := tickRun{Strategy: r.cfg, State: strategy.StateIdle, FundingOKCount: r.okCount, FundingBadCount: r.badCount}
+	if r.inPosition {
+		run.State = strategy.StateHedgeOK
+	}
+	plan := evaluate(run, r.virtualInputs(in))
+	r.okCount, r.badCount = plan.FundingOKCount, plan.FundingBadCount
+	r.decision = plan.Decision
+	r.action = tickActionHold
+	switch {
+	case plan.Action == tickActionEnter && !r.inPosition:
+		r.inPosition = true
+		r.notionalUSD = in.Snap.NotionalUSD
+		r.enteredAt = in.Now
+		r.entries++
+		r.costUSD += r.legCostUSD()
+		r.action = tickActionEnter
+	case plan.Action == tickActionExit && r.inPosition:
+		r.costUSD += r.legCostUSD()
+		r.inPosition = false
+		r.exits++
+		r.action = tickActionExit
+	}
+	return r.action
+}
+
+// virtualInputs is in with the account side replaced by the run's virtual
+// position: a delta-neutral hedge at its notional, or flat, with no open
+// orders, cooldowns, compounding or entry basis of its own.
+func (r *shadowRun) virtualInputs(in tickInputs) tickInputs {
+	snap := in.Snap
+	snap.OpenOrderCount = 0
+	snap.SpotBalance, snap.PerpPosition = 0, 0
+	in.SpotExposureUSD, in.PerpExposureUSD = 0, 0
+	if r.inPosition {
+		if price := strategy.BasisPrice(snap, strategy.ValuationOracle); price > 0 {
+			size := r.notionalUSD / price
+			snap.SpotBalance, snap.PerpPosition = size, -size
+		}
+		in.SpotExposureUSD, in.PerpExposureUSD = r.notionalUSD, r.notionalUSD
+	} else {
+		in.HasLiquidation = false
+	}
+	in.Snap = snap
+	in.OpenOrders = nil
+	in.Flat = !r.inPosition
+	in.FlatStrict = !r.inPosition
+	in.DeltaUSD = 0
+	in.EntryCooldownActive = false
+	in.HedgeCooldownActive = false
+	in.CompoundAccruedUSD = 0
+	in.RiskReduced = false
+	in.HasEntryBasis = false
+	in.VaultRecallLocked = false
+	if r.ownCarry {
+		in.NetCarryUSD, in.EstimatedCostUSD = strategy.NetExpectedCarryUSD(snap, r.cfg.FeeBps, r.cfg.SlippageBps)
+	}
+	return in
+}
+
+// legCostUSD is the fee and slippage of opening or closing both legs.
+func (r *shadowRun) legCostUSD() float64 {
+	return r.notionalUSD * (r.cfg.FeeBps + r.cfg.SlippageBps) / 10000 * 2
+}
+
+func (r *shadowRun) report() shadowRunReport {
+	out := shadowRunReport{
+		Name:             r.name,
+		Decision:         r.decision,
+		Action:           r.action,
+		InPosition:       r.inPosition,
+		Entries:          r.entries,
+		Exits:            r.exits,
+		FundingUSD:       r.fundingUSD,
+		CostUSD:          r.costUSD,
+		PnLUSD:           r.fundingUSD - r.costUSD,
+		MinFundingAPR:    r.cfg.MinFundingAPR,
+		CarryBufferUSD:   r.cfg.CarryBufferUSD,
+		MaxVolatility:    r.cfg.MaxVolatility,
+		ExitOnFundingDip: r.cfg.ExitOnFundingDip,
+	}
+	if r.inPosition {
+		entered := r.enteredAt
+		out.EnteredAt = &entered
+	}
+	return out
+}
+
+func (a *App) handleShadowAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if a.shadow == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "shadow evaluation disabled"})
+		return
+	}
+	if err := json.NewEncoder(w).Encode(a.shadow.report()); err != nil && a.log != nil {
+		a.log.Warn("shadow api response failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,165 @@
+package app
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+func shadowTestInputs(now time.Time, funding float64) tickInputs {
+	return tickInputs{
+		Now: now,
+		Snap: strategy.MarketSnapshot{
+			PerpAsset:    "ETH",
+			SpotAsset:    "UETH",
+			SpotMidPrice: 3000,
+			PerpMidPrice: 3000,
+			FundingRate:  funding,
+			NotionalUSD:  1000,
+		},
+	}
+}
+
+func TestShadowEvaluatorComparesParameterSets(t *testing.T) {
+	lower := 0.0001
+	exit := true
+	cfg := &config.Config{
+		Strategy: config.StrategyConfig{
+			MinFundingAPR:           0.0005 * strategy.HoursPerYear,
+			MaxVolatility:           1,
+			FeeBps:                  0.1,
+			FundingConfirmations:    1,
+			FundingDipConfirmations: 1,
+		},
+		Shadow: config.ShadowConfig{Enabled: true, MinFundingRate: &lower, ExitOnFundingDip: &exit},
+	}
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	eval := newShadowEvaluator(cfg)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	live, shadow, diverged := eval.observe(shadowTestInputs(start, 0.0002), app.evaluateTickFor)
+	if live != tickActionHold || shadow != tickActionEnter || !diverged {
+		t.Fatalf("expected live hold / shadow enter, got %s/%s diverged=%v", live, shadow, diverged)
+	}
+	eval.observe(shadowTestInputs(start.Add(2*time.Hour), 0.0002), app.evaluateTickFor)
+	live, shadow, _ = eval.observe(shadowTestInputs(start.Add(3*time.Hour), 0), app.evaluateTickFor)
+	if live != tickActionHold || shadow != tickActionExit {
+		t.Fatalf("expected live hold / shadow exit, got %s/%s", live, shadow)
+	}
+
+	report := eval.report()
+	if report.Ticks != 3 || report.DivergedTicks != 2 {
+		t.Fatalf("unexpected tick counts: %+v", report)
+	}
+	liveRun, shadowRun := report.Runs[0], report.Runs[1]
+	if liveRun.Entries != 0 || liveRun.PnLUSD != 0 {
+		t.Fatalf("expected live run untouched, got %+v", liveRun)
+	}
+	// 1000 USD * 0.0002/h * 3h of funding minus 0.1 bps per leg on entry and exit.
+	if shadowRun.Entries != 1 || shadowRun.Exits != 1 || shadowRun.InPosition {
+		t.Fatalf("unexpected shadow run: %+v", shadowRun)
+	}
+	if math.Abs(shadowRun.FundingUSD-0.4) > 1e-9 || math.Abs(shadowRun.CostUSD-0.04) > 1e-9 {
+		t.Fatalf("expected funding 0.4 and cost 0.04, got %+v", shadowRun)
+	}
+}
+
+func TestShadowRunAppliesLiveTickGates(t *testing.T) {
+	lower := 0.0001
+	cfg := &config.Config{
+		Strategy: config.StrategyConfig{
+			MinFundingAPR:           0.0005 * strategy.HoursPerYear,
+			MaxVolatility:           1,
+			MaxSellImbalance:        0.5,
+			FundingConfirmations:    1,
+			FundingDipConfirmations: 1,
+		},
+		Shadow: config.ShadowConfig{Enabled: true, MinFundingRate: &lower},
+	}
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	eval := newShadowEvaluator(cfg)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Sell flow above strategy.max_sell_imbalance blocks the entry exactly as
+	// it would the live tick, whatever the account holds.
+	in := shadowTestInputs(start, 0.0002)
+	in.Snap.TradeImbalance, in.Snap.HasTradeImbalance = -0.8, true
+	in.Snap.SpotBalance, in.Snap.PerpPosition = 1, 0
+	if _, shadow, _ := eval.observe(in, app.evaluateTickFor); shadow != tickActionHold {
+		t.Fatalf("expected the trade flow gate to hold the shadow entry, got %s", shadow)
+	}
+	if got := eval.report().Runs[1].Decision; got != "skip_trade_flow" {
+		t.Fatalf("expected skip_trade_flow, got %q", got)
+	}
+
+	in = shadowTestInputs(start.Add(time.Hour), 0.0002)
+	in.Paused = true
+	if _, shadow, _ := eval.observe(in, app.evaluateTickFor); shadow != tickActionHold {
+		t.Fatalf("expected a paused shadow run to hold, got %s", shadow)
+	}
+	in.Paused = false
+	if _, shadow, _ := eval.observe(in, app.evaluateTickFor); shadow != tickActionEnter {
+		t.Fatalf("expected the shadow entry once the gates clear, got %s", shadow)
+	}
+	if run := eval.report().Runs[1]; !run.InPosition || run.Decision != "idle" {
+		t.Fatalf("unexpected shadow run after entry: %+v", run)
+	}
+	if _, shadow, _ := eval.observe(shadowTestInputs(start.Add(2*time.Hour), 0.0002), app.evaluateTickFor); shadow != tickActionHold {
+		t.Fatalf("expected the virtual hedge held, got %s", shadow)
+	}
+	if got := eval.report().Runs[1].Decision; got != "hedge_ok" {
+		t.Fatalf("expected hedge_ok for the virtual hedge, got %q", got)
+	}
+}
+
+func TestShadowEvaluatorDisabled(t *testing.T) {
+	if newShadowEvaluator(&config.Config{}) != nil {
+		t.Fatalf("expected nil evaluator when shadow disabled")
+	}
+	app := &App{log: zap.NewNop()}
+	app.observeShadow(shadowTestInputs(time.Now(), 0.001))
+	rec := httptest.NewRecorder()
+	app.handleShadowAPI(rec, httptest.NewRequest(http.MethodGet, "/api/shadow", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+}
+
+func TestShadowAPI(t *testing.T) {
+	cfg := &config.Config{
+		Strategy: config.StrategyConfig{MaxVolatility: 1, FundingConfirmations: 1, FundingDipConfirmations: 1},
+		Shadow:   config.ShadowConfig{Enabled: true},
+	}
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	app.cfg.Strategy.FundingConfirmations = 1
+	app.cfg.Shadow = cfg.Shadow
+	app.shadow = newShadowEvaluator(cfg)
+	app.observeShadow(shadowTestInputs(time.Now(), 0.001))
+	rec := httptest.NewRecorder()
+	app.handleShadowAPI(rec, httptest.NewRequest(http.MethodGet, "/api/shadow", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var report shadowReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Ticks != 1 || len(report.Runs) != 2 || report.Runs[1].Name != shadowRunShadow || !report.Runs[1].InPosition {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+// simulateAliases are the short forms /simulate accepts for strategy keys.
+var simulateAliases = map[string]string{
+	"notional":     "notional_usd",
+	"min_apr":      "min_funding_apr",
+	"min_funding":  "min_funding_rate",
+	"carry_buffer": "carry_buffer_usd",
+	"max_vol":      "max_volatility",
+}
+
+type simulationGate struct {
+	Name   string `json:"name"`
+	Pass   bool   `json:"pass"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// simulationReport evaluates the entry gates against current data with
+// hypothetical strategy parameters. Nothing is stored or traded.
+type simulationReport struct {
+	GeneratedAt         time.Time         `json:"generated_at"`
+	Overrides           map[string]string `json:"overrides"`
+	Params              strategyParams    `json:"params"`
+	State               string            `json:"state"`
+	WouldEnter          bool              `json:"would_enter"`
+	FundingRate         float64           `json:"funding_rate"`
+	FundingAPR          float64           `json:"funding_apr"`
+	ExpectedFundingUSD  float64           `json:"expected_funding_usd"`
+	EstimatedCostUSD    float64           `json:"estimated_cost_usd"`
+	NetExpectedCarryUSD float64           `json:"net_expected_carry_usd"`
+	Gates               []simulationGate  `json:"gates"`
+	Orders              []plannedOrder    `json:"orders"`
+	OrderError          string            `json:"order_error,omitempty"`
+}
+
+// simulateEntry runs the idle-state entry gates of evaluateTick in order,
+// without stopping at the first failure, on the current tick inputs resized
+// to the overridden parameters. Overrides use the /strategy set keys (or
+// simulateAliases) and are validated the same way.
+func (a *App) simulateEntry(ctx context.Context, overrides map[string]string) (simulationReport, error) {
+	if a.config() == nil || a.market == nil || a.account == nil || a.strategy == nil {
+		return simulationReport{}, errors.New("simulation unavailable")
+	}
+	keys := make(map[string]string, len(overrides))
+	for key, val := range overrides {
+		if full, ok := simulateAliases[key]; ok {
+			key = full
+		}
+		keys[key] = val
+	}
+	cfg := a.strategyConfig()
+	params, err := applyStrategyOverrides(strategyParamsOf(cfg), keys, a.riskConfig())
+	if err != nil {
+		return simulationReport{}, err
+	}
+	params.applyTo(&cfg)
+	in, err := a.collectTickInputs(ctx)
+	if err != nil {
+		return simulationReport{}, err
+	}
+	a.applyCarryInputs(&in, cfg)
+	if in.Flat {
+		in.VaultRecallLocked = a.vaultRecallLocked(in.Now, a.account.Snapshot(), in.Snap)
+	}
+	snap := in.Snap
+	report := simulationReport{
+		GeneratedAt:         in.Now,
+		Overrides:           keys,
+		Params:              params,
+		State:               string(a.strategy.Current()),
+		FundingRate:         snap.FundingRate,
+		FundingAPR:          snap.FundingAPR(),
+		ExpectedFundingUSD:  in.ExpectedFunding,
+		EstimatedCostUSD:    in.EstimatedCostUSD,
+		NetExpectedCarryUSD: in.NetCarryUSD,
+		Orders:              []plannedOrder{},
+	}
+	gate := func(name string, pass bool, detail string) {
+		report.Gates = append(report.Gates, simulationGate{Name: name, Pass: pass, Detail: detail})
+	}
+	errDetail := func(err error) string {
+		if err == nil {
+			return ""
+		}
+		return err.Error()
+	}
+
+	ageErr := strategy.CheckDataAges(in.Risk, in.Ages)
+	gate("data_fresh", ageErr == nil, errDetail(ageErr))
+	flat := a.strategy.Current() == strategy.StateIdle && in.Flat && snap.OpenOrderCount == 0
+	gate("flat", flat, fmt.Sprintf("state %s, flat %t, open orders %d", a.strategy.Current(), in.Flat, snap.OpenOrderCount))
+	risk := strategy.EvaluateRisk(in.Risk, in.riskInputs())
+	gate("risk", risk.Action < strategy.RiskActionBlockEntry, errDetail(risk.Err()))
+	gate("not_paused", !in.Paused, "")
+	gate("no_foreign_activity", !(in.ForeignActivity && a.config().Interference.PauseEntries), "")
+	var circuitErr error
+	if in.CircuitOpen {
+		circuitErr = circuitOpenErr(in.CircuitOpenUntil)
+	}
+	gate("circuit_closed", !in.CircuitOpen, errDetail(circuitErr))
+	gate("funding_apr", snap.FundingAPR() >= cfg.MinFundingAPR,
+		fmt.Sprintf("%.4f vs min %.4f (rate %.8f per %s)", snap.FundingAPR(), cfg.MinFundingAPR, snap.FundingRate, fundingIntervalLabel(snap.FundingInterval)))
+	gate("net_carry", in.NetCarryUSD >= cfg.CarryBufferUSD,
+		fmt.Sprintf("%.4f vs buffer %.4f USD", in.NetCarryUSD, cfg.CarryBufferUSD))
+	okCount, _, confirmed, _ := a.nextFundingRegime(snap.FundingAPR(), cfg.MinFundingAPR, in.NetCarryUSD, cfg.CarryBufferUSD)
+	gate("funding_confirmed", confirmed, fmt.Sprintf("%d of %d ticks", okCount, max(a.config().Strategy.FundingConfirmations, 1)))
+	gate("volatility", snap.Volatility <= cfg.MaxVolatility,
+		fmt.Sprintf("%.6f vs max %.6f", snap.Volatility, cfg.MaxVolatility))
+	if decision, err := a.entryGate(cfg, in); err != nil {
+		gate(strings.TrimPrefix(decision, "skip_"), false, err.Error())
+	} else {
+		gate("entry_filters", true, "")
+	}
+	gate("entry_cooldown", !in.EntryCooldownActive, "")
+
+	report.WouldEnter = true
+	for _, g := range report.Gates {
+		report.WouldEnter = report.WouldEnter && g.Pass
+	}
+	if entry, err := a.planEntry(snap); err != nil {
+		report.OrderError = err.Error()
+	} else {
+		report.Orders = []plannedOrder{entry.Spot, entry.Perp}
+	}
+	return report, nil
+}
+
+func formatSimulation(report simulationReport) string {
+	verdict := "would not enter"
+	if report.WouldEnter {
+		verdict = "would enter"
+	}
+	keys := make([]string, 0, len(report.Overrides))
+	for key, val := range report.Overrides {
+		keys = append(keys, key+"="+val)
+	}
+	sort.Strings(keys)
+	lines := []string{
+		fmt.Sprintf("simulation (%s): %s", strings.Join(keys, " "), verdict),
+		"params: " + report.Params.String(),
+		fmt.Sprintf("carry: expected funding %.4f - cost %.4f = net %.4f USD", report.ExpectedFundingUSD, report.EstimatedCostUSD, report.NetExpectedCarryUSD),
+	}
+	for _, g := range report.Gates {
+		status := "pass"
+		if !g.Pass {
+			status = "FAIL"
+		}
+		line := fmt.Sprintf("%s %s", status, g.Name)
+		if g.Detail != "" {
+			line += ": " + g.Detail
+		}
+		lines = append(lines, line)
+	}
+	for _, order := range report.Orders {
+		lines = append(lines, fmt.Sprintf("order: %s %s %.6f @ %.6f", order.Leg, order.Asset, order.Size, order.LimitPrice))
+	}
+	if report.OrderError != "" {
+		lines = append(lines, "order_error: "+report.OrderError)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleSimulateAPI serves GET /api/simulate; query parameters are the
+// overrides, e.g. /api/simulate?notional=500&min_apr=0.08.
+func (a *App) handleSimulateAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	overrides := make(map[string]string)
+	for key, vals := range r.URL.Query() {
+		if len(vals) > 0 {
+			overrides[strings.ToLower(key)] = vals[len(vals)-1]
+		}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), nextAPITimeout)
+	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+	report, err := a.simulateEntry(ctx, overrides)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil && a.log != nil {
+		a.log.Warn("simulate api response failed", zap.Error(err))
+	}
+}
+
+func fundingIntervalLabel(interval time.Duration) string {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return interval.String()
+}
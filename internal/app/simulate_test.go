@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hltest"
+)
+
+func TestSimulateEntryWithOverrides(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	ctx := context.Background()
+
+	report, err := app.simulateEntry(ctx, map[string]string{"notional": "30"})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if !report.WouldEnter || report.Params.NotionalUSD != 30 {
+		t.Fatalf("expected an entry at 30 USD, got %+v", report)
+	}
+	if len(report.Orders) != 2 || math.Abs(report.Orders[0].Size*3000-30) > 1e-9 {
+		t.Fatalf("expected orders sized to the simulated notional, got %+v", report.Orders)
+	}
+	if app.cfg.Strategy.NotionalUSD != 20 || app.strategyOverrideActive() || app.rt.fundingOKCount != 0 {
+		t.Fatalf("expected the simulation to leave the app untouched")
+	}
+
+	text, err := app.handleOperatorCommand(ctx, "simulate", []string{"min_funding=1"}, operatorMeta{})
+	if err != nil {
+		t.Fatalf("operator simulate: %v", err)
+	}
+	if !strings.Contains(text, "would not enter") || !strings.Contains(text, "FAIL funding_apr") || !strings.Contains(text, "pass volatility") {
+		t.Fatalf("unexpected /simulate output:\n%s", text)
+	}
+	if _, err := app.simulateEntry(ctx, map[string]string{"leverage": "3"}); err == nil {
+		t.Fatal("expected an unknown key to fail")
+	}
+}
+
+func TestSimulateAPI(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	server.SetNextFundingTime(time.Now().Add(1 * time.Hour).UnixMilli())
+	app := newNextTestApp(t, server)
+	app.setPaused(true)
+
+	rec := httptest.NewRecorder()
+	app.handleSimulateAPI(rec, httptest.NewRequest(http.MethodGet, "/api/simulate?notional=20", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report simulationReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	failed := []string{}
+	for _, gate := range report.Gates {
+		if !gate.Pass {
+			failed = append(failed, gate.Name)
+		}
+	}
+	if report.WouldEnter || len(failed) != 1 || failed[0] != "not_paused" {
+		t.Fatalf("expected only the pause gate to fail, got %v", failed)
+	}
+
+	rec = httptest.NewRecorder()
+	app.handleSimulateAPI(rec, httptest.NewRequest(http.MethodGet, "/api/simulate?notional=-1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid override, got %d", rec.Code)
+	}
+}
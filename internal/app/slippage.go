@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"math"
+
+	"hl-carry-bot/internal/events"
+
+	"go.uber.org/zap"
+)
+
+const slippageFillBuffer = 256
+
+// referenceMid is the mid a strategy-leg order is measured against for
+// realized slippage; orders on other assets (e.g. dust sweeps) are not
+// tracked.
+func (a *App) referenceMid(ctx context.Context, asset int) (float64, bool) {
+	cfg := a.config()
+	if cfg == nil || a.market == nil {
+		return 0, false
+	}
+	if id, ok := a.market.PerpAssetID(cfg.Strategy.PerpAsset); ok && id == asset {
+		mid, err := a.market.Mid(ctx, cfg.Strategy.PerpAsset)
+		return mid, err == nil && mid > 0
+	}
+	if id, ok := a.spotAssetID(); ok && id == asset {
+		mid, _, err := a.spotMid(ctx, cfg.Strategy.SpotAsset)
+		return mid, err == nil && mid > 0
+	}
+	return 0, false
+}
+
+func (a *App) spotAssetID() (int, bool) {
+	spotCtx, err := a.spotContext(a.config().Strategy.SpotAsset)
+	if err != nil {
+		return 0, false
+	}
+	return a.market.SpotAssetID(spotCtx.Symbol)
+}
+
+// recordFillSlippage feeds the account fill stream to the executor's
+// slippage tracking until ctx ends.
+func (a *App) recordFillSlippage(ctx context.Context) {
+	if a.events == nil || a.executor == nil {
+		return
+	}
+	ch, unsubscribe := a.events.Subscribe(slippageFillBuffer, events.KindFillReceived)
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, open := <-ch:
+			if !open {
+				return
+			}
+			fill, ok := event.(events.FillReceived)
+			if !ok {
+				continue
+			}
+			sample, ok := a.executor.RecordFill(fill.OrderID, fill.Cloid, fill.Price, fill.Size)
+			if ok && a.log != nil {
+				a.log.Debug("fill slippage",
+					zap.String("order_id", fill.OrderID),
+					zap.String("coin", fill.Asset),
+					zap.Float64("mid", sample.Mid),
+					zap.Float64("price", sample.Price),
+					zap.Float64("slippage_bps", sample.Bps),
+				)
+			}
+		}
+	}
+}
+
+// iocPriceBps is the IOC offset from the mid: the tuned value under
+// strategy.ioc_price_bps_auto once fills have been measured, else
+// strategy.ioc_price_bps.
+func (a *App) iocPriceBps() float64 {
+	a.opsMu.RLock()
+	tuned, ok := a.tunedIOCBps, a.hasTunedIOC
+	a.opsMu.RUnlock()
+	if ok && a.config().Strategy.IOCPriceBpsAuto {
+		return tuned
+	}
+	return a.config().Strategy.IOCPriceBps
+}
+
+// observeSlippage exports each leg's realized slippage and, under
+// strategy.ioc_price_bps_auto, sets the IOC offset to twice the worse leg's
+// slippage within ioc_price_bps_min and ioc_price_bps_max.
+func (a *App) observeSlippage() {
+	if a.config() == nil || a.executor == nil || a.market == nil {
+		return
+	}
+	worst, measured := 0.0, false
+	if id, ok := a.market.PerpAssetID(a.config().Strategy.PerpAsset); ok {
+		if bps, ok := a.executor.RealizedSlippageBps(id); ok {
+			worst, measured = bps, true
+			if a.metrics != nil && a.metrics.PerpSlippageBps != nil {
+				a.metrics.PerpSlippageBps.Set(bps)
+			}
+		}
+	}
+	if id, ok := a.spotAssetID(); ok {
+		if bps, ok := a.executor.RealizedSlippageBps(id); ok {
+			if !measured || bps > worst {
+				worst = bps
+			}
+			measured = true
+			if a.metrics != nil && a.metrics.SpotSlippageBps != nil {
+				a.metrics.SpotSlippageBps.Set(bps)
+			}
+		}
+	}
+	cfg := a.config().Strategy
+	if cfg.IOCPriceBpsAuto && measured {
+		target := math.Min(math.Max(2*worst, cfg.IOCPriceBpsMin), cfg.IOCPriceBpsMax)
+		before := a.iocPriceBps()
+		a.opsMu.Lock()
+		a.tunedIOCBps, a.hasTunedIOC = target, true
+		a.opsMu.Unlock()
+		if math.Abs(target-before) >= 0.01 && a.log != nil {
+			a.log.Info("ioc price offset tuned",
+				zap.Float64("from_bps", before),
+				zap.Float64("to_bps", target),
+				zap.Float64("realized_slippage_bps", worst),
+			)
+		}
+	}
+	if a.metrics != nil && a.metrics.IOCPriceBps != nil {
+		a.metrics.IOCPriceBps.Set(a.iocPriceBps())
+	}
+}
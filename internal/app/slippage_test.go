@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hltest"
+
+	"go.uber.org/zap"
+)
+
+func TestObserveSlippageTunesIOCPriceBps(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newNextTestApp(t, server)
+	metricsStub, counters := newTestMetrics()
+	app.metrics = metricsStub
+	stub := &stubRestClient{orderIDs: []string{"p-1", "s-1", "d-1"}}
+	app.executor = exec.New(stub, nil, zap.NewNop())
+	app.executor.SetSlippageTracking(app.referenceMid, 20)
+	app.cfg.Strategy.IOCPriceBpsMin = 2
+	app.cfg.Strategy.IOCPriceBpsMax = 20
+	ctx := context.Background()
+
+	spotID, ok := app.spotAssetID()
+	if !ok {
+		t.Fatal("spot asset id missing")
+	}
+	if _, err := app.executor.PlaceOrder(ctx, exec.Order{Asset: 1, Size: 0.01, LimitPrice: 2997, ClientOrderID: "0x1"}); err != nil {
+		t.Fatalf("place perp: %v", err)
+	}
+	if _, err := app.executor.PlaceOrder(ctx, exec.Order{Asset: spotID, IsBuy: true, Size: 0.01, LimitPrice: 3003, ClientOrderID: "0x2"}); err != nil {
+		t.Fatalf("place spot: %v", err)
+	}
+	if _, err := app.executor.PlaceOrder(ctx, exec.Order{Asset: 10099, Size: 1, LimitPrice: 1, ClientOrderID: "0x3"}); err != nil {
+		t.Fatalf("place dust: %v", err)
+	}
+	if _, ok := app.executor.RecordFill("p-1", "0x1", 2998.2, -0.01); !ok {
+		t.Fatal("expected the perp fill measured")
+	}
+	if _, ok := app.executor.RecordFill("s-1", "0x2", 3000.9, 0.01); !ok {
+		t.Fatal("expected the spot fill measured")
+	}
+	if _, ok := app.executor.RecordFill("d-1", "0x3", 1, 1); ok {
+		t.Fatal("expected orders off the strategy legs untracked")
+	}
+
+	app.observeSlippage()
+	if math.Abs(counters.perpSlippage.value-6) > 1e-6 || math.Abs(counters.spotSlippage.value-3) > 1e-6 {
+		t.Fatalf("expected 6/3 bps realized, got perp %f spot %f", counters.perpSlippage.value, counters.spotSlippage.value)
+	}
+	if app.iocPriceBps() != 10 || counters.iocPriceBps.value != 10 {
+		t.Fatalf("expected the configured offset without auto tuning, got %f", app.iocPriceBps())
+	}
+
+	app.cfg.Strategy.IOCPriceBpsAuto = true
+	app.observeSlippage()
+	if math.Abs(app.iocPriceBps()-12) > 1e-6 || math.Abs(counters.iocPriceBps.value-12) > 1e-6 {
+		t.Fatalf("expected twice the worse leg, 12 bps, got %f", app.iocPriceBps())
+	}
+	app.cfg.Strategy.IOCPriceBpsMax = 8
+	app.observeSlippage()
+	if app.iocPriceBps() != 8 {
+		t.Fatalf("expected the offset capped at 8 bps, got %f", app.iocPriceBps())
+	}
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"hl-carry-bot/internal/events"
+	"hl-carry-bot/internal/logging"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+const persistTimeout = 5 * time.Second
+
+// observeStateTransition is the strategy state machine's transition hook: it
+// counts the change by from, to, and reason and restarts the time-in-state
+// gauge.
+func (a *App) observeStateTransition(tr strategy.Transition) {
+	if a.log != nil {
+		a.log.Info("strategy state changed", zap.String("from", string(tr.From)), zap.String("to", string(tr.To)), zap.String("reason", tr.Reason))
+	}
+	if a.metrics == nil {
+		return
+	}
+	if a.metrics.StateTransitions != nil {
+		a.metrics.StateTransitions.With(string(tr.From), string(tr.To), tr.Reason).Inc()
+	}
+	if a.metrics.StateSeconds != nil {
+		a.metrics.StateSeconds.Set(0)
+	}
+}
+
+// observeStateAge exports how long the strategy has been in its current
+// state; a state that keeps growing between expected transitions is stuck.
+func (a *App) observeStateAge(now time.Time) {
+	if a.strategy == nil || a.metrics == nil || a.metrics.StateSeconds == nil {
+		return
+	}
+	a.metrics.StateSeconds.Set(a.strategy.TimeInState(now).Seconds())
+}
+
+// applyEvent moves the strategy state machine by event. An event the
+// current state refuses leaves the state as it was and is logged and
+// returned.
+func (a *App) applyEvent(event strategy.Event) error {
+	_, err := a.strategy.Apply(event)
+	if err != nil && a.log != nil {
+		a.log.Warn("strategy event refused", logging.Unsampled(), zap.String("event", string(event)), zap.Error(err))
+	}
+	return err
+}
+
+// persistTransition saves the strategy snapshot with the new state, so a
+// restart resumes from it.
+func (a *App) persistTransition(strategy.Transition) {
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+	a.persistStrategySnapshot(ctx, a.strategySnap)
+}
+
+func (a *App) publishTransition(tr strategy.Transition) {
+	a.events.Publish(events.StateChanged{From: string(tr.From), To: string(tr.To), Reason: tr.Reason, Time: tr.At})
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	persist "hl-carry-bot/internal/state"
+
+	"go.uber.org/zap"
+)
+
+// checkImportedState runs on the first start after `statectl import`. State
+// from another host carries the nonce seed the source had at export; if the
+// source kept signing after that, the seed is stale. Unless the import was
+// made with -source-stopped, a bundle older than state.import_max_age is
+// refused. The marker is cleared once the check passes.
+func (a *App) checkImportedState(ctx context.Context, now time.Time) error {
+	cfg := a.config()
+	if a.store == nil {
+		return nil
+	}
+	marker, ok, err := persist.LoadImportMarker(ctx, a.store)
+	if err != nil {
+		return fmt.Errorf("imported state marker: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	host, _ := os.Hostname()
+	age := now.Sub(time.UnixMilli(marker.ExportedAtMS))
+	if marker.Host != host && !marker.SourceStopped && cfg != nil && age > cfg.State.ImportMaxAge {
+		return fmt.Errorf("imported state from %s was exported %s ago (state.import_max_age %s) and its nonce seed may be stale; stop the bot on %s, export again, and import with -source-stopped",
+			marker.Host, age.Round(time.Second), cfg.State.ImportMaxAge, marker.Host)
+	}
+	if err := a.store.Delete(ctx, persist.ImportMarkerKey); err != nil {
+		return err
+	}
+	if a.log != nil {
+		a.log.Info("starting on imported state",
+			zap.String("source_host", marker.Host),
+			zap.Time("exported_at", time.UnixMilli(marker.ExportedAtMS).UTC()),
+			zap.Bool("source_stopped", marker.SourceStopped),
+		)
+	}
+	return nil
+}
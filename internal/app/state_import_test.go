@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	persist "hl-carry-bot/internal/state"
+)
+
+func TestCheckImportedStateRefusesStaleBundle(t *testing.T) {
+	store := &memoryStore{}
+	a := &App{cfg: &config.Config{State: config.StateConfig{ImportMaxAge: 15 * time.Minute}}, store: store}
+	ctx := context.Background()
+	now := time.UnixMilli(10_000_000)
+	setMarker := func(marker persist.ImportMarker) {
+		payload, _ := json.Marshal(marker)
+		_ = store.Set(ctx, persist.ImportMarkerKey, string(payload))
+	}
+
+	if err := a.checkImportedState(ctx, now); err != nil {
+		t.Fatalf("expected no check without an import, got %v", err)
+	}
+	stale := persist.ImportMarker{Host: "other-host", ExportedAtMS: now.Add(-time.Hour).UnixMilli()}
+	setMarker(stale)
+	if err := a.checkImportedState(ctx, now); err == nil || !strings.Contains(err.Error(), "nonce seed may be stale") {
+		t.Fatalf("expected a stale import to be refused, got %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, persist.ImportMarkerKey); !ok {
+		t.Fatalf("expected the marker kept after a refusal")
+	}
+
+	stale.SourceStopped = true
+	setMarker(stale)
+	if err := a.checkImportedState(ctx, now); err != nil {
+		t.Fatalf("expected an import from a stopped source to start, got %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, persist.ImportMarkerKey); ok {
+		t.Fatalf("expected the marker cleared after the check passed")
+	}
+
+	setMarker(persist.ImportMarker{Host: "other-host", ExportedAtMS: now.Add(-time.Minute).UnixMilli()})
+	if err := a.checkImportedState(ctx, now); err != nil {
+		t.Fatalf("expected a fresh import to start, got %v", err)
+	}
+}
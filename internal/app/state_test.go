@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/events"
+	persist "hl-carry-bot/internal/state"
+	"hl-carry-bot/internal/strategy"
+)
+
+func TestStateTransitionTelemetry(t *testing.T) {
+	m, counters := newTestMetrics()
+	app := &App{metrics: m, strategy: strategy.NewStateMachine()}
+	app.strategy.OnTransition(app.observeStateTransition)
+
+	app.observeStateAge(time.Now().Add(time.Minute))
+	if counters.stateSeconds.value < 59 {
+		t.Fatalf("expected about a minute in IDLE, got %v", counters.stateSeconds.value)
+	}
+	app.strategy.Apply(strategy.EventEnter)
+	app.strategy.Apply(strategy.EventEnter)
+	app.strategy.Apply(strategy.EventHedgeOK)
+	app.strategy.SetState(strategy.StateIdle)
+	if counters.stateSeconds.value != 0 {
+		t.Fatalf("expected the state age reset on a transition, got %v", counters.stateSeconds.value)
+	}
+	for key, want := range map[string]int{
+		"IDLE>ENTER:ENTER":                    1,
+		"ENTER>HEDGE_OK:HEDGE_OK":             1,
+		"HEDGE_OK>IDLE:" + strategy.ReasonSet: 1,
+	} {
+		if got := counters.transitions[key]; got == nil || got.count != want {
+			t.Fatalf("expected %s counted %d times, got %+v", key, want, got)
+		}
+	}
+	if len(counters.transitions) != 3 {
+		t.Fatalf("expected the repeated event not counted, got %d series", len(counters.transitions))
+	}
+}
+
+func TestTransitionHooksPersistAndPublish(t *testing.T) {
+	store := &memoryStore{data: make(map[string]string)}
+	bus := events.New()
+	changes, unsubscribe := bus.Subscribe(4, events.KindStateChanged)
+	defer unsubscribe()
+	app := &App{store: store, events: bus, strategy: strategy.NewStateMachine()}
+	app.strategy.OnTransition(app.persistTransition)
+	app.strategy.OnTransition(app.publishTransition)
+	app.strategySnap = strategy.MarketSnapshot{PerpAsset: "ETH", SpotAsset: "UETH", SpotMidPrice: 3000, PerpMidPrice: 3001}
+
+	if err := app.applyEvent(strategy.EventEnter); err != nil {
+		t.Fatalf("enter: %v", err)
+	}
+	saved, ok, err := persist.LoadStrategySnapshot(context.Background(), store)
+	if err != nil || !ok || saved.Action != string(strategy.StateEnter) || saved.PerpMidPrice != 3001 {
+		t.Fatalf("expected ENTER persisted with the tick snapshot, got %+v (ok=%v err=%v)", saved, ok, err)
+	}
+	select {
+	case ev := <-changes:
+		change, _ := ev.(events.StateChanged)
+		if change.From != "IDLE" || change.To != "ENTER" || change.Reason != "ENTER" {
+			t.Fatalf("unexpected state change event %+v", ev)
+		}
+	default:
+		t.Fatal("expected a state change event")
+	}
+
+	if err := app.applyEvent(strategy.EventDone); !errors.Is(err, strategy.ErrInvalidTransition) {
+		t.Fatalf("expected DONE refused in ENTER, got %v", err)
+	}
+	if app.strategy.State != strategy.StateEnter || len(changes) != 0 {
+		t.Fatalf("expected a refused event to change nothing, got %s with %d events", app.strategy.State, len(changes))
+	}
+	if err := app.applyEvent(strategy.EventAbort); err != nil {
+		t.Fatalf("abort: %v", err)
+	}
+	if saved, _, _ := persist.LoadStrategySnapshot(context.Background(), store); saved.Action != string(strategy.StateIdle) {
+		t.Fatalf("expected IDLE persisted after abort, got %s", saved.Action)
+	}
+}
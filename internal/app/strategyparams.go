@@ -0,0 +1,205 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/strategy"
+)
+
+// strategyParams are the strategy settings an operator can override at
+// runtime with /strategy set. Everything else in strategy.* comes from the
+// config (and its reloads).
+type strategyParams struct {
+	MinFundingAPR  float64 `json:"min_funding_apr"`
+	CarryBufferUSD float64 `json:"carry_buffer_usd"`
+	NotionalUSD    float64 `json:"notional_usd"`
+	MaxVolatility  float64 `json:"max_volatility"`
+	DeltaBandUSD   float64 `json:"delta_band_usd"`
+}
+
+func strategyParamsOf(cfg config.StrategyConfig) strategyParams {
+	return strategyParams{
+		MinFundingAPR:  cfg.MinFundingAPR,
+		CarryBufferUSD: cfg.CarryBufferUSD,
+		NotionalUSD:    cfg.NotionalUSD,
+		MaxVolatility:  cfg.MaxVolatility,
+		DeltaBandUSD:   cfg.DeltaBandUSD,
+	}
+}
+
+func (p strategyParams) applyTo(cfg *config.StrategyConfig) {
+	cfg.MinFundingAPR = p.MinFundingAPR
+	cfg.CarryBufferUSD = p.CarryBufferUSD
+	cfg.NotionalUSD = p.NotionalUSD
+	cfg.MaxVolatility = p.MaxVolatility
+	cfg.DeltaBandUSD = p.DeltaBandUSD
+}
+
+func (p strategyParams) String() string {
+	return fmt.Sprintf("min_funding_apr=%.4f carry_buffer_usd=%.4f notional_usd=%.2f max_volatility=%.4f delta_band_usd=%.2f",
+		p.MinFundingAPR, p.CarryBufferUSD, p.NotionalUSD, p.MaxVolatility, p.DeltaBandUSD)
+}
+
+func (a *App) handleStrategyCommand(ctx context.Context, args []string, meta operatorMeta) (string, error) {
+	if len(args) == 0 || strings.EqualFold(args[0], "show") {
+		return a.strategyStatus(), nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "reset":
+		before := a.strategyOverrideSnapshot()
+		a.clearStrategyOverride()
+		a.auditOperatorEvent(ctx, operatorAuditEvent{
+			UpdateID:       meta.UpdateID,
+			Time:           time.Now().UTC(),
+			Action:         "strategy_reset",
+			Command:        meta.Raw,
+			UserID:         meta.UserID,
+			Username:       meta.Username,
+			ChatID:         meta.ChatID,
+			StrategyBefore: before,
+		})
+		return "strategy override cleared", nil
+	case "set":
+		overrides, err := parseOverrides("strategy", args[1:])
+		if err != nil {
+			return "", err
+		}
+		before := a.strategyOverrideSnapshot()
+		base := strategyParamsOf(a.strategyConfig())
+		next, err := applyStrategyOverrides(base, overrides, a.riskConfig())
+		if err != nil {
+			return "", err
+		}
+		if next == strategyParamsOf(a.config().Strategy) {
+			a.clearStrategyOverride()
+		} else {
+			a.setStrategyOverride(next)
+		}
+		after := a.strategyOverrideSnapshot()
+		a.auditOperatorEvent(ctx, operatorAuditEvent{
+			UpdateID:       meta.UpdateID,
+			Time:           time.Now().UTC(),
+			Action:         "strategy_set",
+			Command:        meta.Raw,
+			UserID:         meta.UserID,
+			Username:       meta.Username,
+			ChatID:         meta.ChatID,
+			StrategyBefore: before,
+			StrategyAfter:  after,
+		})
+		return "strategy override updated", nil
+	default:
+		return "", errors.New("unknown strategy command: use /strategy show|set|reset")
+	}
+}
+
+func applyStrategyOverrides(base strategyParams, overrides map[string]string, risk config.RiskConfig) (strategyParams, error) {
+	next := base
+	for key, val := range overrides {
+		parsed, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return strategyParams{}, fmt.Errorf("%s: %w", key, err)
+		}
+		switch key {
+		case "min_funding_apr":
+			next.MinFundingAPR = parsed
+		case "min_funding_rate":
+			// Deprecated hourly form, as in the config.
+			next.MinFundingAPR = parsed * strategy.HoursPerYear
+		case "carry_buffer_usd":
+			next.CarryBufferUSD = parsed
+		case "notional_usd":
+			next.NotionalUSD = parsed
+		case "max_volatility":
+			next.MaxVolatility = parsed
+		case "delta_band_usd":
+			next.DeltaBandUSD = parsed
+		default:
+			return strategyParams{}, fmt.Errorf("unknown strategy key: %s", key)
+		}
+	}
+	if err := validateStrategyOverride(next, risk); err != nil {
+		return strategyParams{}, err
+	}
+	return next, nil
+}
+
+// validateStrategyOverride applies the config's rules for these fields,
+// checked against the risk limits in effect.
+func validateStrategyOverride(params strategyParams, risk config.RiskConfig) error {
+	if params.NotionalUSD <= 0 {
+		return errors.New("notional_usd must be > 0")
+	}
+	if risk.MaxNotionalUSD > 0 && params.NotionalUSD > risk.MaxNotionalUSD {
+		return errors.New("notional_usd exceeds risk max_notional_usd")
+	}
+	if params.CarryBufferUSD < 0 {
+		return errors.New("carry_buffer_usd must be >= 0")
+	}
+	if params.MaxVolatility < 0 {
+		return errors.New("max_volatility must be >= 0")
+	}
+	if params.DeltaBandUSD < 0 {
+		return errors.New("delta_band_usd must be >= 0")
+	}
+	if risk.MaxDeltaUSD > 0 && params.DeltaBandUSD > risk.MaxDeltaUSD {
+		return errors.New("delta_band_usd exceeds risk max_delta_usd")
+	}
+	return nil
+}
+
+func (a *App) strategyStatus() string {
+	lines := []string{"strategy effective: " + strategyParamsOf(a.strategyConfig()).String()}
+	if override := a.strategyOverrideSnapshot(); override != nil {
+		lines = append(lines, "strategy override: "+override.String())
+	} else {
+		lines = append(lines, "strategy override: none")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// strategyConfig is cfg.Strategy with the operator override applied.
+func (a *App) strategyConfig() config.StrategyConfig {
+	cfg := a.config().Strategy
+	a.opsMu.RLock()
+	override := a.strategyOverride
+	a.opsMu.RUnlock()
+	if override != nil {
+		override.applyTo(&cfg)
+	}
+	return cfg
+}
+
+func (a *App) strategyOverrideActive() bool {
+	a.opsMu.RLock()
+	defer a.opsMu.RUnlock()
+	return a.strategyOverride != nil
+}
+
+func (a *App) strategyOverrideSnapshot() *strategyParams {
+	a.opsMu.RLock()
+	defer a.opsMu.RUnlock()
+	if a.strategyOverride == nil {
+		return nil
+	}
+	copy := *a.strategyOverride
+	return &copy
+}
+
+func (a *App) setStrategyOverride(params strategyParams) {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+	a.strategyOverride = &params
+}
+
+func (a *App) clearStrategyOverride() {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+	a.strategyOverride = nil
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/strategy"
+)
+
+func TestStrategyOverrideSetReset(t *testing.T) {
+	store := &memoryStore{data: make(map[string]string)}
+	cfg := &config.Config{
+		Strategy: config.StrategyConfig{MinFundingAPR: 0.0001 * strategy.HoursPerYear, CarryBufferUSD: 0.5, NotionalUSD: 100, MaxVolatility: 0.05, DeltaBandUSD: 5, EntryCooldown: 1},
+		Risk:     config.RiskConfig{MaxNotionalUSD: 500},
+	}
+	app := &App{cfg: cfg, store: store}
+	meta := operatorMeta{UserID: 1, ChatID: 2, Raw: "/strategy set notional_usd=250 min_funding_rate=0.0002"}
+
+	resp, err := app.handleStrategyCommand(context.Background(), []string{"set", "notional_usd=250", "min_funding_rate=0.0002"}, meta)
+	if err != nil {
+		t.Fatalf("strategy set error: %v", err)
+	}
+	if resp != "strategy override updated" {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+	effective := app.strategyConfig()
+	if effective.NotionalUSD != 250 || effective.MinFundingAPR != 0.0002*strategy.HoursPerYear || effective.CarryBufferUSD != 0.5 {
+		t.Fatalf("unexpected effective strategy %+v", effective)
+	}
+	if effective.EntryCooldown != 1 {
+		t.Fatalf("expected non-overridable fields from config, got %+v", effective)
+	}
+	if !strings.Contains(app.strategyStatus(), "strategy override: min_funding_apr=1.7520") {
+		t.Fatalf("unexpected status %q", app.strategyStatus())
+	}
+
+	var audit operatorAuditEvent
+	for key, val := range store.data {
+		if strings.HasPrefix(key, "ops:audit:") {
+			if err := json.Unmarshal([]byte(val), &audit); err != nil {
+				t.Fatalf("audit decode: %v", err)
+			}
+		}
+	}
+	if audit.Action != "strategy_set" || audit.StrategyBefore != nil || audit.StrategyAfter == nil || audit.StrategyAfter.NotionalUSD != 250 {
+		t.Fatalf("unexpected audit event %+v", audit)
+	}
+
+	if _, err := app.handleStrategyCommand(context.Background(), []string{"set", "notional_usd=600"}, meta); err == nil {
+		t.Fatalf("expected notional above risk max_notional_usd rejected")
+	}
+	if _, err := app.handleStrategyCommand(context.Background(), []string{"set", "entry_cooldown=1"}, meta); err == nil {
+		t.Fatalf("expected unknown key rejected")
+	}
+
+	// Setting the config values back drops the override.
+	if _, err := app.handleStrategyCommand(context.Background(), []string{"set", "notional_usd=100", "min_funding_rate=0.0001"}, meta); err != nil {
+		t.Fatalf("strategy set error: %v", err)
+	}
+	if app.strategyOverrideActive() {
+		t.Fatalf("expected override cleared when equal to config")
+	}
+
+	if _, err := app.handleStrategyCommand(context.Background(), []string{"set", "delta_band_usd=8"}, meta); err != nil {
+		t.Fatalf("strategy set error: %v", err)
+	}
+	resp, err = app.handleStrategyCommand(context.Background(), []string{"reset"}, meta)
+	if err != nil || resp != "strategy override cleared" {
+		t.Fatalf("unexpected reset result %q %v", resp, err)
+	}
+	if got := app.strategyConfig().DeltaBandUSD; got != 5 {
+		t.Fatalf("expected config delta band after reset, got %v", got)
+	}
+}
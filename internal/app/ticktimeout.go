@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hl-carry-bot/internal/logging"
+	"hl-carry-bot/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+var errTickTimeout = errors.New("tick timed out")
+
+// tickContext bounds a tick by strategy.tick_timeout, so a hung REST or WS
+// call cannot hold the strategy loop. A tick_timeout of 0 leaves it unbounded.
+func (a *App) tickContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := a.strategyConfig().TickTimeoutValue()
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// checkTickTimeout turns a tick that ran out of its budget into a skipped
+// tick, counted and alerted, whatever error the interrupted step returned.
+func (a *App) checkTickTimeout(ctx context.Context, err error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	a.countSkippedTick(metrics.SkipTickTimeout)
+	timeout := a.strategyConfig().TickTimeoutValue()
+	if err == nil {
+		err = context.DeadlineExceeded
+	}
+	if a.log != nil {
+		a.log.Error("strategy tick exceeded tick_timeout", logging.Unsampled(), zap.Duration("tick_timeout", timeout), zap.Error(err))
+	}
+	if a.alerts != nil {
+		if alertErr := a.alerts.Send(context.WithoutCancel(ctx), fmt.Sprintf("Strategy tick exceeded %s and was abandoned: %v", timeout, err)); alertErr != nil && a.log != nil {
+			a.log.Warn("alert send failed", zap.Error(alertErr))
+		}
+	}
+	return fmt.Errorf("%w after %s: %w", errTickTimeout, timeout, err)
+}
+
+// tradeBudget is the tick time an entry or exit needs for its legs: an IOC
+// wait per leg, plus the post-only wait when the spot leg may rest first.
+func (a *App) tradeBudget() time.Duration {
+	cfg := a.strategyConfig()
+	budget := 2 * cfg.EntryTimeout
+	if cfg.ExecutionMode != "" && cfg.ExecutionMode != "taker" {
+		budget += cfg.MakerTimeout
+	}
+	return budget
+}
+
+// haveTradeBudget reports whether enough of the tick remains to start step.
+// Starting a trade that the deadline would cut between legs leaves one leg
+// unhedged, so it waits for the next tick instead.
+func (a *App) haveTradeBudget(ctx context.Context, step string) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+	left, need := time.Until(deadline), a.tradeBudget()
+	if left >= need {
+		return true
+	}
+	a.countSkippedTick(metrics.SkipTickBudget)
+	if a.log != nil {
+		a.log.Warn("not enough tick budget left; deferring to the next tick",
+			zap.String("step", step),
+			zap.Duration("remaining", left),
+			zap.Duration("needed", need),
+		)
+	}
+	return false
+}
+
+// unwindContext detaches a rollback or cancel from the tick deadline: once a
+// leg has filled, unwinding it matters more than the tick budget. It gets a
+// fresh tick_timeout of its own.
+func (a *App) unwindContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = context.WithoutCancel(ctx)
+	timeout := a.strategyConfig().TickTimeoutValue()
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
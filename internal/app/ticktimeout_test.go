@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/metrics"
+)
+
+func TestCheckTickTimeoutSkipsAnExpiredTick(t *testing.T) {
+	m, counters := newTestMetrics()
+	timeout := time.Millisecond
+	app := &App{cfg: &config.Config{Strategy: config.StrategyConfig{TickTimeout: &timeout}}, metrics: m}
+	ctx, cancel := app.tickContext(context.Background())
+	defer cancel()
+	<-ctx.Done()
+
+	stepErr := errors.New("info request: context deadline exceeded")
+	err := app.checkTickTimeout(ctx, stepErr)
+	if !errors.Is(err, errTickTimeout) || !errors.Is(err, stepErr) {
+		t.Fatalf("expected a tick timeout wrapping the step error, got %v", err)
+	}
+	if got := counters.ticksSkipped[metrics.SkipTickTimeout]; got == nil || got.count != 1 {
+		t.Fatalf("expected one tick skipped as tick_timeout, got %+v", counters.ticksSkipped)
+	}
+
+	live, stop := context.WithCancel(context.Background())
+	stop()
+	if err := app.checkTickTimeout(live, stepErr); err != stepErr {
+		t.Fatalf("expected a canceled (not timed out) tick to keep its error, got %v", err)
+	}
+}
+
+func TestHaveTradeBudget(t *testing.T) {
+	m, counters := newTestMetrics()
+	timeout := time.Minute
+	app := &App{cfg: &config.Config{Strategy: config.StrategyConfig{
+		TickTimeout:   &timeout,
+		EntryTimeout:  5 * time.Second,
+		MakerTimeout:  30 * time.Second,
+		ExecutionMode: "maker_first",
+	}}, metrics: m}
+	if got := app.tradeBudget(); got != 40*time.Second {
+		t.Fatalf("expected 2x entry_timeout plus maker_timeout, got %s", got)
+	}
+	if !app.haveTradeBudget(context.Background(), "entry") {
+		t.Fatalf("expected no deadline to leave the budget unlimited")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if app.haveTradeBudget(ctx, "entry") {
+		t.Fatalf("expected an entry with 10s left to wait for the next tick")
+	}
+	if got := counters.ticksSkipped[metrics.SkipTickBudget]; got == nil || got.count != 1 {
+		t.Fatalf("expected one tick skipped as tick_budget, got %+v", counters.ticksSkipped)
+	}
+}
+
+func TestUnwindContextOutlivesTheTick(t *testing.T) {
+	timeout := time.Minute
+	app := &App{cfg: &config.Config{Strategy: config.StrategyConfig{TickTimeout: &timeout}}}
+	tickCtx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-tickCtx.Done()
+	ctx, stop := app.unwindContext(tickCtx)
+	defer stop()
+	if ctx.Err() != nil {
+		t.Fatalf("expected the unwind to survive the tick deadline, got %v", ctx.Err())
+	}
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) < 50*time.Second {
+		t.Fatalf("expected a fresh tick_timeout for the unwind, got %v %v", deadline, ok)
+	}
+}
+
+func TestTickContextUnboundedWhenDisabled(t *testing.T) {
+	disabled := time.Duration(0)
+	app := &App{cfg: &config.Config{Strategy: config.StrategyConfig{TickTimeout: &disabled}}}
+	ctx, cancel := app.tickContext(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatalf("expected tick_timeout 0 to leave the tick without a deadline")
+	}
+}
@@ -1,12 +1,20 @@
 package app
 
 import (
+	"context"
+	"strconv"
 	"time"
 
+	"hl-carry-bot/internal/events"
+	"hl-carry-bot/internal/exec"
 	"hl-carry-bot/internal/strategy"
 	"hl-carry-bot/internal/timescale"
 )
 
+// timescaleFillBuffer absorbs fill bursts (an entry fills both legs, often
+// in several pieces) while the writer catches up.
+const timescaleFillBuffer = 256
+
 func (a *App) recordTimescale(state strategy.State, snap strategy.MarketSnapshot, spotExposureUSD, perpExposureUSD, deltaUSD float64) {
 	if a.timescale == nil {
 		return
@@ -37,24 +45,100 @@ func (a *App) recordTimescale(state strategy.State, snap strategy.MarketSnapshot
 	if a.market == nil {
 		return
 	}
-	candle, ok := a.market.LatestCandle(snap.PerpAsset)
-	if !ok {
+	if a.config() == nil {
 		return
 	}
-	if candle.Interval == "" && a.cfg != nil {
-		candle.Interval = a.cfg.Strategy.CandleInterval
-	}
-	if candle.Start.IsZero() {
-		candle.Start = now
-	}
-	a.timescale.EnqueueCandle(timescale.Candle{
-		Asset:    candle.Asset,
-		Interval: candle.Interval,
-		Start:    candle.Start,
-		Open:     candle.Open,
-		High:     candle.High,
-		Low:      candle.Low,
-		Close:    candle.Close,
-		Volume:   candle.Volume,
+	for _, interval := range a.config().Timescale.CandleIntervals {
+		candle, ok := a.market.LatestCandle(snap.PerpAsset, interval)
+		if !ok {
+			continue
+		}
+		if candle.Interval == "" {
+			candle.Interval = interval
+		}
+		if candle.Start.IsZero() {
+			candle.Start = now
+		}
+		a.timescale.EnqueueCandle(timescale.Candle{
+			Asset:    candle.Asset,
+			Interval: candle.Interval,
+			Start:    candle.Start,
+			Open:     candle.Open,
+			High:     candle.High,
+			Low:      candle.Low,
+			Close:    candle.Close,
+			Volume:   candle.Volume,
+		})
+	}
+}
+
+// recordTimescaleOrder is the executor's order observer.
+func (a *App) recordTimescaleOrder(event exec.OrderEvent) {
+	a.timescale.EnqueueOrder(timescale.Order{
+		Time:       event.Time,
+		Event:      event.Event,
+		OrderID:    event.OrderID,
+		Cloid:      event.Order.ClientOrderID,
+		AssetID:    event.Order.Asset,
+		Coin:       a.orderCoin(event.Order.Asset),
+		IsBuy:      event.Order.IsBuy,
+		Size:       event.Order.Size,
+		LimitPrice: event.Order.LimitPrice,
+		Tif:        event.Order.Tif,
+		ReduceOnly: event.Order.ReduceOnly,
+		Reason:     event.Reason,
 	})
 }
+
+// recordTimescaleFills copies the account fill stream into Timescale until
+// ctx ends.
+func (a *App) recordTimescaleFills(ctx context.Context) {
+	if a.events == nil {
+		return
+	}
+	ch, unsubscribe := a.events.Subscribe(timescaleFillBuffer, events.KindFillReceived)
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, open := <-ch:
+			if !open {
+				return
+			}
+			fill, ok := event.(events.FillReceived)
+			if !ok {
+				continue
+			}
+			a.timescale.EnqueueFill(timescale.Fill{
+				Time:      fill.Time,
+				OrderID:   fill.OrderID,
+				Cloid:     fill.Cloid,
+				TradeID:   fill.TradeID,
+				Hash:      fill.Hash,
+				Coin:      fill.Asset,
+				Side:      fill.Side,
+				Size:      fill.Size,
+				Price:     fill.Price,
+				Fee:       fill.Fee,
+				ClosedPnL: fill.ClosedPnL,
+			})
+		}
+	}
+}
+
+// orderCoin names an order's asset the way fills do: the perp name, or
+// "@<index>" for a spot pair, so orders and fills join on coin.
+func (a *App) orderCoin(assetID int) string {
+	cfg := a.config()
+	if assetID >= 10000 {
+		return "@" + strconv.Itoa(assetID-10000)
+	}
+	if a.market == nil || cfg == nil {
+		return ""
+	}
+	if id, ok := a.market.PerpAssetID(cfg.Strategy.PerpAsset); ok && id == assetID {
+		return cfg.Strategy.PerpAsset
+	}
+	return ""
+}
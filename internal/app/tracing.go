@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+
+	"hl-carry-bot/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = tracing.Tracer("hl-carry-bot/internal/app")
+
+// collectTickInputsTraced gathers the tick inputs under their own span; the
+// account reconcile and REST calls they make nest under it.
+func (a *App) collectTickInputsTraced(ctx context.Context) (in tickInputs, err error) {
+	ctx, span := tracer.Start(ctx, "tick_inputs")
+	defer func() { tracing.End(span, err) }()
+	return a.collectTickInputs(ctx)
+}
+
+// decide evaluates the tick and records the decision on a span of its own.
+func (a *App) decide(ctx context.Context, in tickInputs) tickPlan {
+	_, span := tracer.Start(ctx, "decision")
+	defer span.End()
+	plan := a.evaluateTick(in)
+	span.SetAttributes(
+		attribute.String("state", string(plan.State)),
+		attribute.String("decision", plan.Decision),
+		attribute.String("action", plan.Action),
+	)
+	return plan
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	persist "hl-carry-bot/internal/state"
+
+	"go.uber.org/zap"
+)
+
+// journalTransfer records a completed transfer for the trade blotter. The
+// ID is the kind, direction and send time, which is unique per transfer
+// since they are sent one at a time from the tick goroutine.
+func (a *App) journalTransfer(ctx context.Context, rec persist.TransferRecord) {
+	journal, ok := a.store.(persist.TransferJournal)
+	if !ok {
+		return
+	}
+	rec.TimeMS = time.Now().UnixMilli()
+	rec.ID = fmt.Sprintf("%s:%s:%d", rec.Kind, rec.Direction, rec.TimeMS)
+	if _, err := journal.RecordTransfer(ctx, rec); err != nil {
+		if !a.transferStoreWarned && a.log != nil {
+			a.log.Warn("transfer journal write failed", zap.Error(err))
+		}
+		a.transferStoreWarned = true
+		return
+	}
+	if a.transferStoreWarned && a.log != nil {
+		a.log.Info("transfer journal write recovered")
+	}
+	a.transferStoreWarned = false
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"hl-carry-bot/internal/logging"
+	"hl-carry-bot/internal/strategy"
+
+	"go.uber.org/zap"
+)
+
+// valuations prices the position on both bases, oracle first.
+func valuations(snap strategy.MarketSnapshot) []strategy.Valuation {
+	return []strategy.Valuation{
+		strategy.Value(snap, strategy.ValuationOracle),
+		strategy.Value(snap, strategy.ValuationMark),
+	}
+}
+
+// checkValuationDivergence alerts once when mark and oracle drift apart by
+// more than risk.max_mark_oracle_divergence while a perp position is held,
+// and logs when they converge again.
+func (a *App) checkValuationDivergence(ctx context.Context, in tickInputs) {
+	cfg := a.config()
+	if cfg == nil || cfg.Risk.MaxMarkOracleDivergence <= 0 {
+		return
+	}
+	snap := in.Snap
+	divergence, ok := strategy.MarkOracleDivergence(snap)
+	diverged := ok && snap.PerpPosition != 0 && divergence > cfg.Risk.MaxMarkOracleDivergence
+	if !diverged {
+		if a.valuationDivergenceWarned && a.log != nil {
+			a.log.Info("mark/oracle divergence recovered", zap.Float64("divergence", divergence))
+		}
+		a.valuationDivergenceWarned = false
+		return
+	}
+	if a.valuationDivergenceWarned {
+		return
+	}
+	a.valuationDivergenceWarned = true
+	oracle := strategy.Value(snap, strategy.ValuationOracle)
+	mark := strategy.Value(snap, strategy.ValuationMark)
+	if a.log != nil {
+		a.log.Warn("mark/oracle divergence", logging.Unsampled(),
+			zap.Float64("divergence", divergence),
+			zap.Float64("max_divergence", cfg.Risk.MaxMarkOracleDivergence),
+			zap.Float64("mark_price", snap.MarkPrice),
+			zap.Float64("oracle_price", snap.OraclePrice),
+			zap.Float64("mark_notional_usd", mark.NotionalUSD),
+			zap.Float64("oracle_notional_usd", oracle.NotionalUSD),
+			zap.String("risk_valuation_basis", cfg.Risk.ValuationBasis),
+		)
+	}
+	if a.alerts == nil {
+		return
+	}
+	msg := fmt.Sprintf("Mark/oracle divergence on %s: mark %.4f vs oracle %.4f (%.2f%%); position %.2f USD on mark, %.2f USD on oracle",
+		snap.PerpAsset, snap.MarkPrice, snap.OraclePrice, divergence*100, mark.NotionalUSD, oracle.NotionalUSD)
+	if err := a.alerts.Send(ctx, msg); err != nil && a.log != nil {
+		a.log.Warn("divergence alert failed", zap.Error(err))
+	}
+}
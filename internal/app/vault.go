@@ -0,0 +1,333 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/logging"
+	persist "hl-carry-bot/internal/state"
+	"hl-carry-bot/internal/strategy"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+const (
+	vaultParkedKey = "vault:parked_usd"
+	// vaultLockedUntilKey is when (unix ms) the newest deposit's lockup ends.
+	vaultLockedUntilKey = "vault:locked_until"
+)
+
+// errVaultRecall blocks an entry that needs parked USDC the vault will not
+// release.
+var errVaultRecall = errors.New("vault recall failed")
+
+// loadVaultParked restores how much USDC this instance has parked in the
+// configured vault and when its lockup ends. Only parked USDC is recalled
+// automatically.
+func (a *App) loadVaultParked(ctx context.Context) {
+	if a.config() == nil || a.config().Vault.Address == "" || a.store == nil {
+		return
+	}
+	a.loadVaultLockedUntil(ctx)
+	raw, ok, err := a.store.Get(ctx, vaultParkedKey)
+	if err != nil {
+		a.logVaultWarn("vault state read failed", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	parked, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		a.logVaultWarn("vault state read failed", fmt.Errorf("parse %s: %w", vaultParkedKey, err))
+		return
+	}
+	a.storeVaultParked(parked)
+	if a.log != nil {
+		a.log.Info("loaded vault parked balance", zap.Float64("parked_usd", parked))
+	}
+}
+
+func (a *App) loadVaultLockedUntil(ctx context.Context) {
+	raw, ok, err := a.store.Get(ctx, vaultLockedUntilKey)
+	if err != nil {
+		a.logVaultWarn("vault state read failed", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		a.logVaultWarn("vault state read failed", fmt.Errorf("parse %s: %w", vaultLockedUntilKey, err))
+		return
+	}
+	a.storeVaultLockedUntil(time.UnixMilli(ms).UTC())
+}
+
+func (a *App) vaultAddress() (common.Address, error) {
+	cfg := a.config()
+	if cfg == nil || cfg.Vault.Address == "" {
+		return common.Address{}, errors.New("vault.address is not configured")
+	}
+	return common.HexToAddress(cfg.Vault.Address), nil
+}
+
+// allowedDestinations is transfers.allowed_destinations. An empty list
+// allows nothing: vault.address is never trusted on its own.
+func allowedDestinations(cfg *config.Config) []common.Address {
+	var out []common.Address
+	for _, addr := range cfg.Transfers.AllowedDestinations {
+		out = append(out, common.HexToAddress(addr))
+	}
+	return out
+}
+
+// transferVault deposits into or withdraws from the configured vault and
+// keeps the parked balance in step.
+func (a *App) transferVault(ctx context.Context, usd float64, deposit bool) error {
+	vault, err := a.vaultAddress()
+	if err != nil {
+		return err
+	}
+	if a.exchange == nil {
+		return errors.New("exchange client is required for transfers")
+	}
+	resp, err := a.exchange.VaultTransfer(ctx, vault, usd, deposit)
+	if err == nil {
+		err = exchange.ResponseError(resp)
+	}
+	if err != nil {
+		if errors.Is(err, exchange.ErrDestinationNotAllowed) && a.alerts != nil {
+			if alertErr := a.alerts.Send(ctx, fmt.Sprintf("Vault deposit of %.2f USDC refused: %s is not in transfers.allowed_destinations", usd, vault.Hex())); alertErr != nil && a.log != nil {
+				a.log.Warn("alert send failed", zap.Error(alertErr))
+			}
+		}
+		return err
+	}
+	delta := -usd
+	if deposit {
+		delta = usd
+	}
+	parked := a.addVaultParked(delta)
+	a.persistVaultParked(ctx, parked)
+	if deposit {
+		a.startVaultLockup(ctx, time.Now().UTC())
+	}
+	direction := "withdraw"
+	if deposit {
+		direction = "deposit"
+	}
+	a.journalTransfer(ctx, persist.TransferRecord{Kind: persist.TransferVault, Direction: direction, Destination: vault.Hex(), Amount: usd})
+	if a.log != nil {
+		a.log.Info("vault transfer", logging.Audit(),
+			zap.String("vault", vault.Hex()),
+			zap.Bool("deposit", deposit),
+			zap.Float64("amount", usd),
+			zap.Float64("parked_usd", parked),
+		)
+	}
+	if a.account != nil {
+		_, err = a.account.Reconcile(ctx)
+	}
+	return err
+}
+
+func (a *App) persistVaultParked(ctx context.Context, parked float64) {
+	if a.store == nil {
+		return
+	}
+	if err := a.store.Set(ctx, vaultParkedKey, strconv.FormatFloat(parked, 'f', -1, 64)); err != nil {
+		a.logVaultWarn("vault state write failed", err)
+		return
+	}
+	a.clearVaultWarn()
+}
+
+// startVaultLockup records that parked USDC cannot be withdrawn for
+// vault.lockup after a deposit at now.
+func (a *App) startVaultLockup(ctx context.Context, now time.Time) {
+	cfg := a.config()
+	if cfg == nil || cfg.Vault.Lockup <= 0 {
+		return
+	}
+	until := now.Add(cfg.Vault.Lockup)
+	a.storeVaultLockedUntil(until)
+	if a.store == nil {
+		return
+	}
+	if err := a.store.Set(ctx, vaultLockedUntilKey, strconv.FormatInt(until.UnixMilli(), 10)); err != nil {
+		a.logVaultWarn("vault state write failed", err)
+	}
+}
+
+func vaultLockedErr(until time.Time) error {
+	return fmt.Errorf("%w: deposit lockup runs until %s", errVaultRecall, until.UTC().Format(time.RFC3339))
+}
+
+// planVaultPark returns how much perp USDC can be deposited while flat:
+// everything above the next entry's requirement plus reserve, limited to the
+// free perp balance the deposit is drawn from.
+func planVaultPark(spotUSDC, perpFree, entryRequired, reserve, minTransfer float64) float64 {
+	excess := spotUSDC + perpFree - entryRequired - reserve
+	amount := math.Min(excess, perpFree)
+	if amount <= flatEpsilon || amount < minTransfer {
+		return 0
+	}
+	return math.Floor(amount*100) / 100
+}
+
+// planVaultRecall returns how much parked USDC to withdraw so available
+// covers required. Withdrawals are raised to minTransfer and capped at what
+// was parked.
+func planVaultRecall(available, required, parked, minTransfer float64) float64 {
+	shortfall := required - available
+	if shortfall <= flatEpsilon || parked <= flatEpsilon {
+		return 0
+	}
+	amount := math.Ceil(math.Max(shortfall, minTransfer)*100) / 100
+	return math.Min(amount, parked)
+}
+
+// entryUSDCRequired is what enterPosition funds: both legs at notional.
+func entryUSDCRequired(snap strategy.MarketSnapshot) float64 {
+	return 2 * snap.NotionalUSD
+}
+
+// maybeParkIdleUSDC deposits USDC the next entry will not need into the
+// vault. It runs only on flat, idle ticks with vault.auto_park set, and not
+// while funding already clears the entry threshold: an entry can follow
+// within a few ticks, and USDC parked now would stay in the lockup.
+func (a *App) maybeParkIdleUSDC(ctx context.Context, snap strategy.MarketSnapshot, entrySoon bool) {
+	cfg := a.config()
+	if cfg == nil || !cfg.Vault.AutoPark || a.account == nil || a.exchange == nil || entrySoon {
+		return
+	}
+	state := a.account.Snapshot()
+	if !state.HasMarginSummary {
+		return
+	}
+	amount := planVaultPark(state.SpotBalances["USDC"], state.MarginSummary.FreeUSD(), entryUSDCRequired(snap), cfg.Vault.ReserveUSD, cfg.Vault.MinTransferUSD)
+	if amount <= 0 {
+		return
+	}
+	if err := a.transferVault(ctx, amount, true); err != nil {
+		if !a.vaultParkWarned && a.log != nil {
+			a.log.Warn("vault park failed", zap.Error(err), zap.Float64("amount", amount))
+		}
+		a.vaultParkWarned = true
+		return
+	}
+	if a.vaultParkWarned && a.log != nil {
+		a.log.Info("vault park recovered")
+	}
+	a.vaultParkWarned = false
+}
+
+// recallVaultUSDC withdraws parked USDC when spot plus perp balances fall
+// short of required. A recall needed during the deposit lockup, or refused
+// by the exchange, returns errVaultRecall so the entry is skipped with that
+// reason.
+func (a *App) recallVaultUSDC(ctx context.Context, spotUSDC, perpUSDC, required float64) (bool, error) {
+	cfg := a.config()
+	if cfg == nil || !cfg.Vault.AutoPark {
+		return false, nil
+	}
+	amount := planVaultRecall(spotUSDC+perpUSDC, required, a.vaultParked(), cfg.Vault.MinTransferUSD)
+	if amount <= 0 {
+		return false, nil
+	}
+	if until := a.vaultLockedUntil(); time.Now().Before(until) {
+		return false, vaultLockedErr(until)
+	}
+	if err := a.transferVault(ctx, amount, false); err != nil {
+		return false, fmt.Errorf("%w: %w", errVaultRecall, err)
+	}
+	return true, nil
+}
+
+// vaultRecallLocked reports whether an entry at snap's notional would need
+// parked USDC back before the deposit lockup ends.
+func (a *App) vaultRecallLocked(now time.Time, state account.State, snap strategy.MarketSnapshot) bool {
+	cfg := a.config()
+	if cfg == nil || !cfg.Vault.AutoPark || !now.Before(a.vaultLockedUntil()) {
+		return false
+	}
+	available := state.SpotBalances["USDC"]
+	if state.HasMarginSummary {
+		available += state.MarginSummary.FreeUSD()
+	}
+	required := entryUSDCRequired(snap) * (1 + a.strategyConfig().USDCBufferBps/10000)
+	return planVaultRecall(available, required, a.vaultParked(), cfg.Vault.MinTransferUSD) > 0
+}
+
+func (a *App) handleVaultCommand(ctx context.Context, args []string, meta operatorMeta) (string, error) {
+	if len(args) == 0 || strings.EqualFold(args[0], "show") {
+		return a.vaultStatus(), nil
+	}
+	action := strings.ToLower(args[0])
+	if action != "deposit" && action != "withdraw" {
+		return "", errors.New("unknown vault command: use /vault show|deposit X|withdraw X")
+	}
+	if len(args) != 2 {
+		return "", fmt.Errorf("vault %s requires an amount in USDC", action)
+	}
+	amount, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || amount <= 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return "", fmt.Errorf("invalid vault amount: %s", args[1])
+	}
+	deposit := action == "deposit"
+	event := operatorAuditEvent{
+		UpdateID: meta.UpdateID,
+		Time:     time.Now().UTC(),
+		Action:   "vault_" + action,
+		Command:  meta.Raw,
+		UserID:   meta.UserID,
+		Username: meta.Username,
+		ChatID:   meta.ChatID,
+		VaultUSD: amount,
+	}
+	if err := a.transferVault(ctx, amount, deposit); err != nil {
+		event.Error = err.Error()
+		a.auditOperatorEvent(ctx, event)
+		return "", err
+	}
+	a.auditOperatorEvent(ctx, event)
+	if deposit {
+		return fmt.Sprintf("deposited %.2f USDC into vault (parked %.2f)", amount, a.vaultParked()), nil
+	}
+	return fmt.Sprintf("withdrew %.2f USDC from vault (parked %.2f)", amount, a.vaultParked()), nil
+}
+
+func (a *App) vaultStatus() string {
+	cfg := a.config()
+	if cfg == nil || cfg.Vault.Address == "" {
+		return "vault: not configured"
+	}
+	return fmt.Sprintf("vault: %s parked_usd=%.2f auto_park=%t reserve_usd=%.2f",
+		cfg.Vault.Address, a.vaultParked(), cfg.Vault.AutoPark, cfg.Vault.ReserveUSD)
+}
+
+func (a *App) logVaultWarn(msg string, err error) {
+	if a.vaultStoreWarned || a.log == nil {
+		return
+	}
+	a.vaultStoreWarned = true
+	a.log.Warn(msg, zap.Error(err))
+}
+
+func (a *App) clearVaultWarn() {
+	if a.vaultStoreWarned && a.log != nil {
+		a.log.Info("vault state write recovered")
+	}
+	a.vaultStoreWarned = false
+}
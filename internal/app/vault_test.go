@@ -0,0 +1,269 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hltest"
+	"hl-carry-bot/internal/metrics"
+	"hl-carry-bot/internal/strategy"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+func TestPlanVaultPark(t *testing.T) {
+	cases := []struct {
+		name                                   string
+		spot, perpFree, required, reserve, min float64
+		want                                   float64
+	}{
+		{name: "excess above requirement", spot: 100, perpFree: 400, required: 200, reserve: 50, min: 10, want: 250},
+		{name: "limited to free perp", spot: 500, perpFree: 80, required: 200, reserve: 0, min: 10, want: 80},
+		{name: "below minimum transfer", spot: 100, perpFree: 105, required: 200, reserve: 0, min: 10, want: 0},
+		{name: "short of requirement", spot: 50, perpFree: 100, required: 200, reserve: 0, min: 10, want: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := planVaultPark(tc.spot, tc.perpFree, tc.required, tc.reserve, tc.min); got != tc.want {
+				t.Fatalf("expected %f, got %f", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPlanVaultRecall(t *testing.T) {
+	if got := planVaultRecall(300, 200, 100, 10); got != 0 {
+		t.Fatalf("expected no recall when covered, got %f", got)
+	}
+	if got := planVaultRecall(150, 200, 100, 10); got != 50 {
+		t.Fatalf("expected 50 recall, got %f", got)
+	}
+	if got := planVaultRecall(197, 200, 100, 10); got != 10 {
+		t.Fatalf("expected recall raised to minimum, got %f", got)
+	}
+	if got := planVaultRecall(0, 200, 60, 10); got != 60 {
+		t.Fatalf("expected recall capped at parked, got %f", got)
+	}
+	if got := planVaultRecall(0, 200, 0, 10); got != 0 {
+		t.Fatalf("expected no recall with nothing parked, got %f", got)
+	}
+}
+
+func TestVaultCommandTransfersAndAudits(t *testing.T) {
+	var mu sync.Mutex
+	var actions []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body struct {
+			Action map[string]any `json:"action"`
+		}
+		_ = json.Unmarshal(raw, &body)
+		mu.Lock()
+		actions = append(actions, body.Action)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+	}))
+	defer srv.Close()
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(srv.URL, 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	cfg := &config.Config{Vault: config.VaultConfig{Address: "0xdfc24b077bc1425ad1dea75bcb6f8158e10df303"}}
+	client.SetAllowedDestinations(allowedDestinations(cfg))
+	if _, err := client.VaultTransfer(context.Background(), common.HexToAddress(cfg.Vault.Address), 10, true); !errors.Is(err, exchange.ErrDestinationNotAllowed) {
+		t.Fatalf("expected an empty allow-list to refuse the vault, got %v", err)
+	}
+	cfg.Transfers.AllowedDestinations = []string{cfg.Vault.Address}
+	client.SetAllowedDestinations(allowedDestinations(cfg))
+	store := &memoryStore{data: make(map[string]string)}
+	app := &App{
+		cfg:      cfg,
+		store:    store,
+		exchange: client,
+	}
+	ctx := context.Background()
+	meta := operatorMeta{UpdateID: 7, Raw: "/vault deposit 150"}
+
+	if _, err := app.handleOperatorCommand(ctx, "vault", []string{"deposit", "150"}, meta); err != nil {
+		t.Fatalf("deposit: %v", err)
+	}
+	if _, err := app.handleOperatorCommand(ctx, "vault", []string{"withdraw", "40"}, meta); err != nil {
+		t.Fatalf("withdraw: %v", err)
+	}
+	if app.rt.vaultParkedUSD != 110 || store.data[vaultParkedKey] != "110" {
+		t.Fatalf("expected 110 parked, got %f (stored %q)", app.rt.vaultParkedUSD, store.data[vaultParkedKey])
+	}
+	mu.Lock()
+	if len(actions) != 2 || actions[0]["isDeposit"] != true || actions[1]["isDeposit"] != false {
+		t.Fatalf("unexpected vault actions: %#v", actions)
+	}
+	mu.Unlock()
+	audits := 0
+	for key, val := range store.data {
+		if strings.HasPrefix(key, "ops:audit:") && strings.Contains(val, `"action":"vault_`) {
+			audits++
+		}
+	}
+	if audits != 2 {
+		t.Fatalf("expected 2 vault audit entries, got %d", audits)
+	}
+
+	if _, err := app.handleOperatorCommand(ctx, "vault", []string{"deposit", "-5"}, meta); err == nil {
+		t.Fatalf("expected error for negative amount")
+	}
+	if _, err := app.handleOperatorCommand(ctx, "vault", []string{"deposit"}, meta); err == nil {
+		t.Fatalf("expected error for missing amount")
+	}
+	if got, _ := app.handleOperatorCommand(ctx, "vault", nil, meta); !strings.Contains(got, "parked_usd=110.00") {
+		t.Fatalf("unexpected vault status: %s", got)
+	}
+}
+
+func newVaultTestApp(t *testing.T, server *hltest.Server) *App {
+	t.Helper()
+	app := newNextTestApp(t, server)
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := exchange.NewClient(server.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client error: %v", err)
+	}
+	vault := "0xdfc24b077bc1425ad1dea75bcb6f8158e10df303"
+	app.cfg.Vault = config.VaultConfig{Address: vault, AutoPark: true, MinTransferUSD: 10, Lockup: 96 * time.Hour}
+	app.cfg.Transfers.AllowedDestinations = []string{vault}
+	client.SetAllowedDestinations(allowedDestinations(app.cfg))
+	app.exchange = client
+	app.store = &memoryStore{data: make(map[string]string)}
+	return app
+}
+
+func TestAutoParkHoldsOffWhileEntryIsNear(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newVaultTestApp(t, server)
+	ctx := context.Background()
+	in, err := app.collectTickInputs(ctx)
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+
+	app.maybeParkIdleUSDC(ctx, in.Snap, true)
+	if got := server.VaultTransfers(); len(got) != 0 {
+		t.Fatalf("expected no deposit while funding clears the entry threshold, got %+v", got)
+	}
+
+	before := time.Now()
+	app.maybeParkIdleUSDC(ctx, in.Snap, false)
+	got := server.VaultTransfers()
+	if len(got) != 1 || !got[0].IsDeposit {
+		t.Fatalf("expected one deposit, got %+v", got)
+	}
+	until := app.vaultLockedUntil()
+	if until.Before(before.Add(96*time.Hour)) || until.After(time.Now().Add(96*time.Hour)) {
+		t.Fatalf("expected the lockup to run 96h from the deposit, got %s", until)
+	}
+	store := app.store.(*memoryStore)
+	if store.data[vaultLockedUntilKey] == "" {
+		t.Fatalf("expected the lockup persisted under %s", vaultLockedUntilKey)
+	}
+	app.storeVaultLockedUntil(time.Time{})
+	app.loadVaultParked(ctx)
+	if !app.vaultLockedUntil().Equal(until.Truncate(time.Millisecond)) {
+		t.Fatalf("expected the lockup restored, got %s want %s", app.vaultLockedUntil(), until)
+	}
+}
+
+func TestEntrySkippedWhileVaultLockupHoldsParkedUSDC(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newVaultTestApp(t, server)
+	ctx := context.Background()
+	// 100 spot + 100 perp USDC fund 200 of the 300 both legs need.
+	app.cfg.Strategy.NotionalUSD = 150
+	app.storeVaultParked(500)
+	app.storeVaultLockedUntil(time.Now().Add(time.Hour))
+
+	report, err := app.nextAction(ctx)
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Decision != "skip_vault_locked" || !strings.Contains(report.Reason, "lockup") {
+		t.Fatalf("expected skip_vault_locked, got %s (%s)", report.Decision, report.Reason)
+	}
+	if _, err := app.recallVaultUSDC(ctx, 100, 100, 300); !errors.Is(err, errVaultRecall) {
+		t.Fatalf("expected errVaultRecall during the lockup, got %v", err)
+	}
+	if got := server.VaultTransfers(); len(got) != 0 {
+		t.Fatalf("expected no withdrawal sent during the lockup, got %+v", got)
+	}
+
+	app.storeVaultLockedUntil(time.Now().Add(-time.Minute))
+	report, err = app.nextAction(ctx)
+	if err != nil {
+		t.Fatalf("next action: %v", err)
+	}
+	if report.Action != tickActionEnter {
+		t.Fatalf("expected entry once the lockup ends, got %s/%s (%s)", report.Decision, report.Action, report.Reason)
+	}
+}
+
+func TestEntrySkipsWhenVaultRecallRefused(t *testing.T) {
+	server := hltest.NewServer(t)
+	defer server.Close()
+	app := newVaultTestApp(t, server)
+	ctx := context.Background()
+	if err := app.market.RefreshContexts(ctx); err != nil {
+		t.Fatalf("refresh contexts: %v", err)
+	}
+	m, counters := newTestMetrics()
+	app.metrics = m
+	rest := &restingRest{}
+	app.executor = exec.New(rest, nil, zap.NewNop())
+	app.cfg.Strategy.NotionalUSD = 150
+	app.storeVaultParked(500)
+	server.RejectNextVaultTransfer("Cannot withdraw with a pending lockup")
+
+	in, err := app.collectTickInputs(ctx)
+	if err != nil {
+		t.Fatalf("collect inputs: %v", err)
+	}
+	if err := app.enterPosition(ctx, in.Snap); err != nil {
+		t.Fatalf("expected the entry skipped, got %v", err)
+	}
+	if got := server.VaultTransfers(); len(got) != 1 || got[0].IsDeposit {
+		t.Fatalf("expected one refused withdrawal, got %+v", got)
+	}
+	if rest.placed != 0 {
+		t.Fatalf("expected no entry leg sent, got %d placements", rest.placed)
+	}
+	if got := counters.ticksSkipped[metrics.SkipVaultRecall]; got == nil || got.count != 1 {
+		t.Fatalf("expected one tick skipped as vault_recall, got %+v", counters.ticksSkipped)
+	}
+	if counters.entryFailed.count != 0 {
+		t.Fatalf("expected no entry failure, got %d", counters.entryFailed.count)
+	}
+	if app.vaultParked() != 500 {
+		t.Fatalf("expected parked balance unchanged, got %f", app.vaultParked())
+	}
+	if app.strategy.State != strategy.StateIdle {
+		t.Fatalf("expected state back to idle, got %s", app.strategy.State)
+	}
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/sdnotify"
+
+	"go.uber.org/zap"
+)
+
+// markTickSucceeded runs the heartbeat hook after a tick that returned
+// without error.
+func (a *App) markTickSucceeded(now time.Time) {
+	if a.heartbeat != nil {
+		a.heartbeat(now)
+	}
+}
+
+func (a *App) startupDone() bool {
+	a.opsMu.RLock()
+	defer a.opsMu.RUnlock()
+	return a.startupComplete
+}
+
+func (a *App) livenessFailures(now time.Time) []string {
+	return a.healthReport(now, false).Failures
+}
+
+func (m *Multi) startupDone() bool {
+	for _, name := range m.names {
+		if !m.apps[name].startupDone() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Multi) livenessFailures(now time.Time) []string {
+	var failures []string
+	for _, name := range m.names {
+		for _, failure := range m.apps[name].livenessFailures(now) {
+			failures = append(failures, name+": "+failure)
+		}
+	}
+	return failures
+}
+
+// heartbeat rewrites the shared heartbeat file only while every account is
+// live, so one wedged account stops it like a wedged single bot would.
+func (m *Multi) heartbeat(now time.Time) {
+	if failures := m.livenessFailures(now); len(failures) > 0 {
+		return
+	}
+	if err := writeHeartbeat(m.cfg.Health.HeartbeatFile, now); err != nil {
+		m.log.Warn("heartbeat write failed", zap.Error(err))
+	}
+}
+
+// writeHeartbeat replaces path with now in RFC 3339, through a rename so
+// readers never see a partial file. The file's mtime carries the same time
+// for supervisors that only stat it.
+func writeHeartbeat(path string, now time.Time) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(now.UTC().Format(time.RFC3339Nano) + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// runWatchdog speaks sd_notify for a Type=notify unit: READY=1 once startup
+// completes, then WATCHDOG=1 every half WatchdogSec for as long as liveness
+// passes, and STOPPING=1 on shutdown. A wedged strategy loop stops the
+// keep-alives and systemd restarts the bot. It returns at once when the
+// process was not started by systemd with NOTIFY_SOCKET.
+func runWatchdog(ctx context.Context, log *zap.Logger, ready func() bool, failures func(time.Time) []string) {
+	if !sdnotify.Enabled() {
+		return
+	}
+	interval := time.Second
+	watchdog, armed := sdnotify.WatchdogInterval()
+	if armed {
+		interval = min(interval, watchdog/2)
+	}
+	notify := func(states ...string) {
+		if _, err := sdnotify.Notify(states...); err != nil && log != nil {
+			log.Warn("sd_notify failed", zap.Strings("states", states), zap.Error(err))
+		}
+	}
+	defer notify(sdnotify.Stopping)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	readySent := false
+	lastKeepAlive := time.Time{}
+	stalled := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if !readySent && ready() {
+				notify(sdnotify.Ready, sdnotify.Status("running"))
+				readySent = true
+			}
+			if !armed || now.Sub(lastKeepAlive) < watchdog/2 {
+				continue
+			}
+			if failed := failures(now); len(failed) > 0 {
+				if !stalled {
+					if log != nil {
+						log.Error("liveness failing; withholding watchdog keep-alive", zap.Strings("failures", failed))
+					}
+					notify(sdnotify.Status("liveness failing: " + strings.Join(failed, ", ")))
+					stalled = true
+				}
+				continue
+			}
+			if stalled {
+				notify(sdnotify.Status("running"))
+				stalled = false
+			}
+			notify(sdnotify.Watchdog)
+			lastKeepAlive = now
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWriteHeartbeat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heartbeat")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := writeHeartbeat(path, now); err != nil {
+		t.Fatalf("write heartbeat: %v", err)
+	}
+	if err := writeHeartbeat(path, now.Add(time.Minute)); err != nil {
+		t.Fatalf("rewrite heartbeat: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read heartbeat: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "2026-03-01T12:01:00Z" {
+		t.Fatalf("unexpected heartbeat %q", got)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("expected no temp files left, got %d entries", len(entries))
+	}
+}
+
+func TestRunWatchdogWithholdsKeepAliveWhileStalled(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+	t.Setenv("WATCHDOG_USEC", "100000")
+	t.Setenv("WATCHDOG_PID", "")
+
+	var stalled atomic.Bool
+	failures := func(time.Time) []string {
+		if stalled.Load() {
+			return []string{"strategy tick stalled"}
+		}
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		runWatchdog(ctx, nil, func() bool { return true }, failures)
+		close(done)
+	}()
+
+	read := func() string {
+		t.Helper()
+		buf := make([]byte, 256)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("read notification: %v", err)
+		}
+		return string(buf[:n])
+	}
+	if got := read(); got != "READY=1\nSTATUS=running" {
+		t.Fatalf("expected readiness first, got %q", got)
+	}
+	if got := read(); got != "WATCHDOG=1" {
+		t.Fatalf("expected a keep-alive, got %q", got)
+	}
+	stalled.Store(true)
+	for {
+		got := read()
+		if strings.HasPrefix(got, "STATUS=liveness failing") {
+			break
+		}
+		if got != "WATCHDOG=1" {
+			t.Fatalf("unexpected notification %q", got)
+		}
+	}
+	buf := make([]byte, 256)
+	_ = conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+	if n, err := conn.Read(buf); err == nil {
+		t.Fatalf("expected keep-alives withheld while stalled, got %q", buf[:n])
+	}
+	cancel()
+	<-done
+	if got := read(); got != "STOPPING=1" {
+		t.Fatalf("expected STOPPING=1 on shutdown, got %q", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -17,19 +18,92 @@ type Config struct {
 	WS        WSConfig        `yaml:"ws"`
 	State     StateConfig     `yaml:"state"`
 	Metrics   MetricsConfig   `yaml:"metrics"`
+	Health    HealthConfig    `yaml:"health"`
 	Timescale TimescaleConfig `yaml:"timescale"`
 	Strategy  StrategyConfig  `yaml:"strategy"`
 	Risk      RiskConfig      `yaml:"risk"`
 	Telegram  TelegramConfig  `yaml:"telegram"`
+
+	ScheduleCancel ScheduleCancelConfig `yaml:"schedule_cancel"`
+	Accounting     AccountingConfig     `yaml:"accounting"`
+	Shadow         ShadowConfig         `yaml:"shadow"`
+	Keys           KeysConfig           `yaml:"keys"`
+	Interference   InterferenceConfig   `yaml:"interference"`
+	Compound       CompoundConfig       `yaml:"compound"`
+	Vault          VaultConfig          `yaml:"vault"`
+	IsolatedMargin IsolatedMarginConfig `yaml:"isolated_margin"`
+	Transfers      TransfersConfig      `yaml:"transfers"`
+	Dust           DustConfig           `yaml:"dust"`
+	Janitor        JanitorConfig        `yaml:"janitor"`
+	Fees           FeesConfig           `yaml:"fees"`
+	Pricing        PricingConfig        `yaml:"pricing"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+	EventLoop      EventLoopConfig      `yaml:"event_loop"`
+	DecisionLog    DecisionLogConfig    `yaml:"decision_log"`
+	Tracing        TracingConfig        `yaml:"tracing"`
+	Accounts       []AccountConfig      `yaml:"accounts"`
+
+	// ReadOnly runs the bot as a monitor: market data, reconciliation,
+	// metrics, Timescale and Telegram status keep working, but no /exchange
+	// action or transfer is ever sent and no signing key is loaded.
+	ReadOnly bool `yaml:"read_only"`
 }
 
 type LoggingConfig struct {
 	Level string `yaml:"level"`
+	// Format is the log encoding: "json" (default) or "console".
+	Format   string            `yaml:"format"`
+	Sampling LogSamplingConfig `yaml:"sampling"`
+	// File sends the log to a rotating file instead of stderr when its path
+	// is set.
+	File LogFileConfig `yaml:"file"`
+	// Audit copies order and transfer actions to a separate JSON file, never
+	// sampled, when its path is set.
+	Audit LogFileConfig `yaml:"audit"`
+}
+
+// LogFileConfig is a log file rotated once it reaches MaxSizeMB. Rotated
+// files older than MaxAge or beyond the newest MaxBackups are removed; zero
+// keeps them.
+type LogFileConfig struct {
+	Path       string        `yaml:"path"`
+	MaxSizeMB  int           `yaml:"max_size_mb"`
+	MaxAge     time.Duration `yaml:"max_age"`
+	MaxBackups int           `yaml:"max_backups"`
+}
+
+// LogSamplingConfig keeps the first occurrence of each log message and then
+// every Nth repeat, per level; 1 keeps every entry. Entries marked unsampled
+// (orders, entries, exits, tick decision changes) are always kept.
+type LogSamplingConfig struct {
+	Debug int `yaml:"debug"`
+	Info  int `yaml:"info"`
+	Warn  int `yaml:"warn"`
 }
 
 type RESTConfig struct {
-	BaseURL string        `yaml:"base_url"`
-	Timeout time.Duration `yaml:"timeout"`
+	BaseURL         string        `yaml:"base_url"`
+	Timeout         time.Duration `yaml:"timeout"`
+	WeightPerMinute int           `yaml:"weight_per_minute"`
+	// ReserveWeight is a pointer so an explicit 0 (reserve nothing) is kept
+	// rather than replaced by the default.
+	ReserveWeight *int `yaml:"reserve_weight"`
+
+	ExchangeMaxAttempts  int           `yaml:"exchange_max_attempts"`
+	ExchangeRetryBackoff time.Duration `yaml:"exchange_retry_backoff"`
+
+	// InfoMaxAttempts and InfoRetryBackoff retry /info requests answered
+	// with a 429 or 5xx, with jittered exponential backoff.
+	InfoMaxAttempts  int           `yaml:"info_max_attempts"`
+	InfoRetryBackoff time.Duration `yaml:"info_retry_backoff"`
+}
+
+// ReserveWeightValue is rest.reserve_weight, 0 when unset.
+func (r RESTConfig) ReserveWeightValue() int {
+	if r.ReserveWeight == nil {
+		return 0
+	}
+	return *r.ReserveWeight
 }
 
 type WSConfig struct {
@@ -38,8 +112,15 @@ type WSConfig struct {
 	PingInterval   time.Duration `yaml:"ping_interval"`
 }
 
+// StateConfig selects the state store: "sqlite" (a local file) or
+// "postgres" (the timescale.dsn database, scoped to Namespace).
+// ImportMaxAge is how old a bundle imported from another host may be at the
+// first start on it, unless its source was confirmed stopped.
 type StateConfig struct {
-	SQLitePath string `yaml:"sqlite_path"`
+	Backend      string        `yaml:"backend"`
+	SQLitePath   string        `yaml:"sqlite_path"`
+	Namespace    string        `yaml:"namespace"`
+	ImportMaxAge time.Duration `yaml:"import_max_age"`
 }
 
 type MetricsConfig struct {
@@ -48,6 +129,19 @@ type MetricsConfig struct {
 	Path    string `yaml:"path"`
 }
 
+// HealthConfig sets the failure thresholds of /healthz (liveness) and
+// /readyz (readiness) on the metrics listener. Liveness fails when no
+// strategy tick has started for MaxTickAge; readiness also fails when the
+// perp/spot mids or account data are older than MaxMarketAge/MaxAccountAge
+// (default: the risk kill switch thresholds). HeartbeatFile, when set, is
+// rewritten with the time of every completed tick while liveness passes.
+type HealthConfig struct {
+	MaxTickAge    time.Duration `yaml:"max_tick_age"`
+	MaxMarketAge  time.Duration `yaml:"max_market_age"`
+	MaxAccountAge time.Duration `yaml:"max_account_age"`
+	HeartbeatFile string        `yaml:"heartbeat_file"`
+}
+
 type TimescaleConfig struct {
 	Enabled         bool          `yaml:"enabled"`
 	DSN             string        `yaml:"dsn"`
@@ -56,6 +150,20 @@ type TimescaleConfig struct {
 	MaxIdleConns    int           `yaml:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
 	QueueSize       int           `yaml:"queue_size"`
+	// BatchSize rows of a table are written in one INSERT; a partial batch
+	// is written after FlushInterval.
+	BatchSize     int           `yaml:"batch_size"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	// A failed batch is retried MaxRetries times, RetryBackoff apart and
+	// doubling, then kept for the next flush. At most MaxBacklog unwritten
+	// rows per table are kept; older rows are dropped beyond that.
+	MaxRetries   int           `yaml:"max_retries"`
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+	MaxBacklog   int           `yaml:"max_backlog"`
+	// CandleIntervals are the perp candle intervals written to the candles
+	// table, each tracked as its own series; defaults to
+	// strategy.candle_interval.
+	CandleIntervals []string `yaml:"candle_intervals"`
 }
 
 func (m MetricsConfig) EnabledValue() bool {
@@ -66,16 +174,34 @@ func (m MetricsConfig) EnabledValue() bool {
 }
 
 type StrategyConfig struct {
-	Asset                   string        `yaml:"asset"`
-	PerpAsset               string        `yaml:"perp_asset"`
-	SpotAsset               string        `yaml:"spot_asset"`
-	NotionalUSD             float64       `yaml:"notional_usd"`
+	Asset       string  `yaml:"asset"`
+	PerpAsset   string  `yaml:"perp_asset"`
+	SpotAsset   string  `yaml:"spot_asset"`
+	NotionalUSD float64 `yaml:"notional_usd"`
+	// MinOrderBumpPct is how far, in percent of NotionalUSD, an entry may be
+	// sized up so both legs clear the exchange minimum order value after
+	// rounding; an entry that needs more is refused (0 always refuses).
+	MinOrderBumpPct float64 `yaml:"min_order_bump_pct"`
+	// MinFundingAPR is the minimum perp funding, annualized over the asset's
+	// funding interval (0.10 = 10% APR), for an entry.
+	MinFundingAPR float64 `yaml:"min_funding_apr"`
+	// MinFundingRate is the deprecated hourly form of MinFundingAPR; when
+	// MinFundingAPR is unset it is translated at load.
 	MinFundingRate          float64       `yaml:"min_funding_rate"`
 	MaxVolatility           float64       `yaml:"max_volatility"`
 	FeeBps                  float64       `yaml:"fee_bps"`
+	USDCBufferBps           float64       `yaml:"usdc_buffer_bps"`
+	MinTransferUSD          float64       `yaml:"min_transfer_usd"`
+	TransferCooldown        time.Duration `yaml:"transfer_cooldown"`
 	SlippageBps             float64       `yaml:"slippage_bps"`
 	IOCPriceBps             float64       `yaml:"ioc_price_bps"`
+	RollbackAttempts        int           `yaml:"rollback_attempts"`
+	RollbackStepBps         float64       `yaml:"rollback_step_bps"`
+	RollbackMaxBps          float64       `yaml:"rollback_max_bps"`
 	CarryBufferUSD          float64       `yaml:"carry_buffer_usd"`
+	MaxVenueFundingPremium  float64       `yaml:"max_venue_funding_premium"`
+	MinTrailingFunding      float64       `yaml:"min_trailing_funding"`
+	TrailingFundingWindow   time.Duration `yaml:"trailing_funding_window"`
 	FundingConfirmations    int           `yaml:"funding_confirmations"`
 	FundingDipConfirmations int           `yaml:"funding_dip_confirmations"`
 	DeltaBandUSD            float64       `yaml:"delta_band_usd"`
@@ -85,12 +211,138 @@ type StrategyConfig struct {
 	HedgeCooldown           time.Duration `yaml:"hedge_cooldown"`
 	SpotReconcileInterval   time.Duration `yaml:"spot_reconcile_interval"`
 	EntryTimeout            time.Duration `yaml:"entry_timeout"`
-	EntryPollInterval       time.Duration `yaml:"entry_poll_interval"`
-	ExitOnFundingDip        bool          `yaml:"exit_on_funding_dip"`
-	ExitFundingGuard        time.Duration `yaml:"exit_funding_guard"`
-	ExitFundingGuardEnabled *bool         `yaml:"exit_funding_guard_enabled"`
-	CandleInterval          string        `yaml:"candle_interval"`
-	CandleWindow            int           `yaml:"candle_window"`
+	// TickTimeout bounds a whole strategy tick; entries and exits only start
+	// while enough of it remains for their legs. Unset derives it from the
+	// leg timeouts; an explicit 0 disables it.
+	TickTimeout             *time.Duration `yaml:"tick_timeout"`
+	EntryPollInterval       time.Duration  `yaml:"entry_poll_interval"`
+	ExitOnFundingDip        bool           `yaml:"exit_on_funding_dip"`
+	ExitFundingGuard        time.Duration  `yaml:"exit_funding_guard"`
+	ExitFundingGuardEnabled *bool          `yaml:"exit_funding_guard_enabled"`
+	EntryFundingGuard       time.Duration  `yaml:"entry_funding_guard"`
+	CandleInterval          string         `yaml:"candle_interval"`
+	CandleWindow            int            `yaml:"candle_window"`
+	VolatilityEstimator     string         `yaml:"volatility_estimator"`
+	VolatilityEWMALambda    float64        `yaml:"volatility_ewma_lambda"`
+	RealizedVolWindow       time.Duration  `yaml:"realized_vol_window"`
+	TradeFlowWindow         time.Duration  `yaml:"trade_flow_window"`
+	MaxSellImbalance        float64        `yaml:"max_sell_imbalance"`
+	// MaxBookSellImbalance delays entries while the perp l2Book's top
+	// BookImbalanceLevels lean this far toward offers (0 disables).
+	MaxBookSellImbalance float64 `yaml:"max_book_sell_imbalance"`
+	BookImbalanceLevels  int     `yaml:"book_imbalance_levels"`
+	// ExecutionMode is "taker" (IOC legs), "maker_when_thin", or
+	// "maker_first": the spot entry leg first rests as a post-only order for
+	// MakerTimeout, always under maker_first and under maker_when_thin when
+	// net carry is less than MakerMarginUSD above carry_buffer_usd.
+	// MakerPrice places that order at the spot "mid" (default), the best bid
+	// ("touch"), or one tick above it while still below the ask ("inside").
+	ExecutionMode  string        `yaml:"execution_mode"`
+	MakerMarginUSD float64       `yaml:"maker_margin_usd"`
+	MakerTimeout   time.Duration `yaml:"maker_timeout"`
+	MakerPrice     string        `yaml:"maker_price"`
+	// HedgeLeg is the leg delta hedges trade: "perp" (default), "spot", or
+	// "auto", which takes the cheaper leg and moves to spot when the perp
+	// hedge is constrained: margin ratio below HedgeMinMarginRatio for a hedge
+	// that grows the perp, or a positive funding payment within
+	// exit_funding_guard for one that shrinks it.
+	HedgeLeg            string  `yaml:"hedge_leg"`
+	HedgeMinMarginRatio float64 `yaml:"hedge_min_margin_ratio"`
+	// DeltaRecenterPct is the percent of DeltaBandUSD a hedge leaves in
+	// place, on the side the delta drifted to, instead of hedging back to
+	// zero (0 hedges fully).
+	DeltaRecenterPct float64 `yaml:"delta_recenter_pct"`
+	// IOCPriceBpsAuto tunes the IOC offset to twice the realized slippage
+	// of the worse leg over its last SlippageWindow fills, kept within
+	// IOCPriceBpsMin and IOCPriceBpsMax; ioc_price_bps applies until fills
+	// are seen.
+	IOCPriceBpsAuto bool    `yaml:"ioc_price_bps_auto"`
+	IOCPriceBpsMin  float64 `yaml:"ioc_price_bps_min"`
+	IOCPriceBpsMax  float64 `yaml:"ioc_price_bps_max"`
+	SlippageWindow  int     `yaml:"slippage_window"`
+	// ExitBasisBps exits a hedged position once the spot–perp basis has
+	// widened this far above its value at entry (0 disables). BasisWindow is
+	// how much basis history is kept for status and logs.
+	ExitBasisBps float64       `yaml:"exit_basis_bps"`
+	BasisWindow  time.Duration `yaml:"basis_window"`
+	// PerpLeverage is the leverage set on the perp asset at startup and
+	// whenever a reload changes it, in PerpMarginMode "cross" (default) or
+	// "isolated"; 0 keeps whatever the account already has.
+	PerpLeverage   int    `yaml:"perp_leverage"`
+	PerpMarginMode string `yaml:"perp_margin_mode"`
+}
+
+// TickTimeoutValue is strategy.tick_timeout, 0 (no deadline) when unset.
+func (s StrategyConfig) TickTimeoutValue() time.Duration {
+	if s.TickTimeout == nil {
+		return 0
+	}
+	return *s.TickTimeout
+}
+
+// PricingConfig picks the limit pricing policy for each entry/exit leg:
+// aggressive_ioc (strategy.ioc_price_bps through the mid), mid_peg,
+// spread_cross (ioc_price_bps through the opposite touch), or book_aware
+// (ioc_price_bps through the level that covers the order size). The book
+// policies fetch l2Book and fall back to aggressive_ioc when the book is
+// older than BookMaxAge.
+type PricingConfig struct {
+	SpotEntry  string        `yaml:"spot_entry"`
+	PerpEntry  string        `yaml:"perp_entry"`
+	SpotExit   string        `yaml:"spot_exit"`
+	PerpExit   string        `yaml:"perp_exit"`
+	BookMaxAge time.Duration `yaml:"book_max_age"`
+}
+
+// CircuitBreakerConfig stops order placement on an asset after MaxFailures
+// failed or rejected orders within Window, for CoolOff.
+type CircuitBreakerConfig struct {
+	Enabled     *bool         `yaml:"enabled"`
+	MaxFailures int           `yaml:"max_failures"`
+	Window      time.Duration `yaml:"window"`
+	CoolOff     time.Duration `yaml:"cool_off"`
+}
+
+func (c CircuitBreakerConfig) EnabledValue() bool {
+	if c.Enabled == nil {
+		return true
+	}
+	return *c.Enabled
+}
+
+// EventLoopConfig ticks the strategy on fills, perp position changes, margin
+// ratio moves, predicted funding updates, and mid moves instead of every
+// strategy.entry_interval. Ticks stay at least MinSpacing apart; MaxIdle
+// bounds the wait when nothing changes.
+type EventLoopConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	MinSpacing      time.Duration `yaml:"min_spacing"`
+	MaxIdle         time.Duration `yaml:"max_idle"`
+	MidMoveBps      float64       `yaml:"mid_move_bps"`
+	MarginRatioMove float64       `yaml:"margin_ratio_move"`
+	FundingPoll     time.Duration `yaml:"funding_poll"`
+}
+
+// DecisionLogConfig records every strategy tick's decision, the reasons it
+// did not trade and its inputs in the state store, for /decisions. Records
+// older than Retention are pruned; zero keeps them.
+type DecisionLogConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+// TracingConfig exports OpenTelemetry spans for the order lifecycle (tick,
+// decision, order submit, fill wait, reconcile and the REST/WS calls under
+// them) to an OTLP/HTTP collector such as Jaeger or Tempo.
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Endpoint is the collector's OTLP/HTTP host:port or URL.
+	Endpoint string `yaml:"endpoint"`
+	// Insecure sends spans over plain HTTP instead of TLS.
+	Insecure    bool   `yaml:"insecure"`
+	ServiceName string `yaml:"service_name"`
+	// SampleRatio is the fraction of ticks traced, 0 to 1.
+	SampleRatio float64 `yaml:"sample_ratio"`
 }
 
 type RiskConfig struct {
@@ -100,6 +352,318 @@ type RiskConfig struct {
 	MinHealthRatio float64       `yaml:"min_health_ratio"`
 	MaxMarketAge   time.Duration `yaml:"max_market_age"`
 	MaxAccountAge  time.Duration `yaml:"max_account_age"`
+	// ValuationBasis is the price basis ("oracle" or "mark") risk limits
+	// value the position on.
+	ValuationBasis string `yaml:"valuation_basis"`
+	// MaxMarkOracleDivergence alerts when mark and oracle differ by more
+	// than this fraction while a position is held (0 disables).
+	MaxMarkOracleDivergence float64 `yaml:"max_mark_oracle_divergence"`
+	// CrashStopBps rests a reduce-only stop-market order on the perp leg this
+	// far beyond the perp mid once hedged (0 disables). It is the exchange-side
+	// backstop for moves that outrun the connectivity kill switch.
+	CrashStopBps float64 `yaml:"crash_stop_bps"`
+	// CrashStopSlippageBps is how far past the trigger the stop's limit price
+	// sits, bounding the fill when it fires.
+	CrashStopSlippageBps float64 `yaml:"crash_stop_slippage_bps"`
+	// MaxDeltaUSD is the largest spot+perp delta tolerated before the
+	// max_delta rule acts (0 disables).
+	MaxDeltaUSD float64 `yaml:"max_delta_usd"`
+	// MaxDailyLossUSD is the realized+unrealized loss within the PnL day at
+	// which the daily_loss rule acts and trading halts until /resume (0
+	// disables).
+	MaxDailyLossUSD float64 `yaml:"max_daily_loss_usd"`
+	// DailyResetHour is the UTC hour the PnL day starts.
+	DailyResetHour int `yaml:"daily_reset_hour"`
+	// DailyPnLRefresh is how often the day's fills and funding are fetched.
+	DailyPnLRefresh time.Duration `yaml:"daily_pnl_refresh"`
+	// MaxConsecutiveFailures is how many entry, exit, hedge, or compound
+	// attempts may fail in a row before the consecutive_failures rule acts
+	// (0 disables).
+	MaxConsecutiveFailures int `yaml:"max_consecutive_failures"`
+	// FailureCoolOff clears a failure streak this long after its last
+	// failure, so a streak that blocks entries while flat does not outlive
+	// whatever caused it. It is a pointer so an explicit 0 (streaks never
+	// lapse) is kept instead of defaulted.
+	FailureCoolOff *time.Duration `yaml:"failure_cool_off"`
+	// MaxClockDrift is how far the local clock may be from the exchange
+	// clock before the clock_drift rule acts. Nonces follow the exchange
+	// clock regardless; funding timing uses the local one.
+	MaxClockDrift time.Duration `yaml:"max_clock_drift"`
+	// ClockSyncInterval is how often the exchange clock is sampled.
+	ClockSyncInterval time.Duration `yaml:"clock_sync_interval"`
+	// MinLiquidationDistancePct is how close, in percent of the perp mark,
+	// the perp leg's estimated liquidation price may come before the
+	// liquidation_distance rule acts and an alert is sent (0 disables).
+	MinLiquidationDistancePct float64 `yaml:"min_liquidation_distance_pct"`
+	// ReduceToPct is the share of the hedge, in percent, a rule with the
+	// reduce action keeps; the position is reduced once per episode.
+	ReduceToPct float64 `yaml:"reduce_to_pct"`
+	// OverAllocation is what startup does when the configured notional plus
+	// its margin exceeds the account equity: "refuse" to start, or "scale"
+	// the notional down to fit with a warning.
+	OverAllocation string `yaml:"over_allocation"`
+	// AllocationLeverage is the perp leverage the capital check sizes the
+	// perp leg's margin at; 1 treats that leg as fully collateralised.
+	AllocationLeverage float64 `yaml:"allocation_leverage"`
+	// Actions maps each risk rule to what a violation does.
+	Actions RiskActionsConfig `yaml:"actions"`
+}
+
+// FailureCoolOffValue is risk.failure_cool_off, 0 (streaks never lapse) when
+// unset.
+func (r RiskConfig) FailureCoolOffValue() time.Duration {
+	if r.FailureCoolOff == nil {
+		return 0
+	}
+	return *r.FailureCoolOff
+}
+
+// RiskActionsConfig is the action taken per violated risk rule, one of
+// block_entry, hedge_only, reduce, flatten, or halt. The most severe action
+// among the violated rules wins.
+type RiskActionsConfig struct {
+	MaxNotional         string `yaml:"max_notional"`
+	MaxOpenOrders       string `yaml:"max_open_orders"`
+	MinMarginRatio      string `yaml:"min_margin_ratio"`
+	MinHealthRatio      string `yaml:"min_health_ratio"`
+	MaxDelta            string `yaml:"max_delta"`
+	DailyLoss           string `yaml:"daily_loss"`
+	ConsecutiveFailures string `yaml:"consecutive_failures"`
+	ClockDrift          string `yaml:"clock_drift"`
+	LiquidationDistance string `yaml:"liquidation_distance"`
+}
+
+// ScheduleCancelConfig controls the exchange-side dead man's switch
+// (scheduleCancel). Window is how far ahead each refresh pushes the cancel
+// time; it must outlast the refresh cadence (strategy.entry_interval).
+type ScheduleCancelConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Window  time.Duration `yaml:"window"`
+}
+
+// AccountingConfig controls the fill/funding journal. BackfillStart is the
+// date (YYYY-MM-DD or RFC3339) the first sync pages history from; empty
+// means defaultBackfillLookback before startup.
+type AccountingConfig struct {
+	Enabled       *bool         `yaml:"enabled"`
+	BackfillStart string        `yaml:"backfill_start"`
+	SyncInterval  time.Duration `yaml:"sync_interval"`
+}
+
+func (a AccountingConfig) EnabledValue() bool {
+	if a.Enabled == nil {
+		return true
+	}
+	return *a.Enabled
+}
+
+// BackfillStartTime resolves BackfillStart relative to now.
+func (a AccountingConfig) BackfillStartTime(now time.Time) (time.Time, error) {
+	return ParseBackfillStart(a.BackfillStart, now)
+}
+
+// ParseBackfillStart accepts YYYY-MM-DD (UTC midnight) or RFC3339.
+func ParseBackfillStart(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return now.Add(-defaultBackfillLookback), nil
+	}
+	if ts, err := time.Parse("2006-01-02", value); err == nil {
+		return ts, nil
+	}
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("accounting.backfill_start must be YYYY-MM-DD or RFC3339")
+	}
+	return ts, nil
+}
+
+// InterferenceConfig controls detection of orders and fills on the account
+// that this bot instance did not place. Grace is how long an unknown update
+// waits for the bot's own placement response before it is called foreign;
+// Hold is how long entries stay paused after a foreign fill.
+type InterferenceConfig struct {
+	Enabled      *bool         `yaml:"enabled"`
+	PauseEntries bool          `yaml:"pause_entries"`
+	Grace        time.Duration `yaml:"grace"`
+	Hold         time.Duration `yaml:"hold"`
+}
+
+func (i InterferenceConfig) EnabledValue() bool {
+	if i.Enabled == nil {
+		return true
+	}
+	return *i.Enabled
+}
+
+// CompoundConfig rolls received funding back into the hedge. Once funding
+// received since entry reaches IncrementUSD and the entry gates still pass,
+// one IncrementUSD spot+perp add-on is placed.
+type CompoundConfig struct {
+	Enabled      bool    `yaml:"enabled"`
+	IncrementUSD float64 `yaml:"increment_usd"`
+}
+
+// IsolatedMarginConfig tops up the perp position's margin under
+// strategy.perp_margin_mode isolated. Once the position's margin falls below
+// TopUpRatio times its maintenance margin (0 disables), free perp USDC is
+// added to bring it back to TargetRatio, at most MaxTopUpUSD at a time (0
+// caps it only at the free USDC).
+type IsolatedMarginConfig struct {
+	TopUpRatio  float64 `yaml:"top_up_ratio"`
+	TargetRatio float64 `yaml:"target_ratio"`
+	MaxTopUpUSD float64 `yaml:"max_top_up_usd"`
+}
+
+// VaultConfig names the Hyperliquid vault idle USDC is parked in. With
+// AutoPark set, USDC above the next entry's requirement plus ReserveUSD is
+// deposited while the strategy is flat, and parked USDC is withdrawn again
+// before an entry that needs it. Transfers smaller than MinTransferUSD are
+// skipped. Lockup is how long a deposit cannot be withdrawn (four days for
+// HLP); an entry that needs parked USDC back within it is skipped.
+type VaultConfig struct {
+	Address        string        `yaml:"address"`
+	AutoPark       bool          `yaml:"auto_park"`
+	ReserveUSD     float64       `yaml:"reserve_usd"`
+	MinTransferUSD float64       `yaml:"min_transfer_usd"`
+	Lockup         time.Duration `yaml:"lockup"`
+}
+
+// TransfersConfig guards every action that sends funds to another address.
+// The exchange client refuses a destination missing from
+// AllowedDestinations, so an empty list refuses every transfer.
+type TransfersConfig struct {
+	AllowedDestinations []string `yaml:"allowed_destinations"`
+}
+
+// DustConfig sells residual spot balances worth less than
+// strategy.min_exposure_usd back to USDC. Every Interval while flat, and after
+// each exit with SweepOnExit, balances are summed; once the total reaches
+// ThresholdUSD each asset is sold with one IOC order.
+type DustConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Interval     time.Duration `yaml:"interval"`
+	ThresholdUSD float64       `yaml:"threshold_usd"`
+	SweepOnExit  bool          `yaml:"sweep_on_exit"`
+}
+
+// JanitorConfig cancels stray resting orders every Interval: orders whose
+// cloid or oid this instance never placed, and its own orders resting longer
+// than MaxAge (zero disables the age check). The crash stop is left alone.
+type JanitorConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	MaxAge   time.Duration `yaml:"max_age"`
+}
+
+// FeesConfig fetches the account's fee rates (userFees) at startup and every
+// RefreshInterval; while known they replace strategy.fee_bps in the carry
+// estimate.
+type FeesConfig struct {
+	Enabled         *bool         `yaml:"enabled"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+func (f FeesConfig) EnabledValue() bool {
+	if f.Enabled == nil {
+		return true
+	}
+	return *f.Enabled
+}
+
+// AccountConfig is one Hyperliquid (sub-)account traded by a multi-account
+// process. Keys are read from the named environment variables; VaultAddress
+// makes orders and account queries act for that sub-account or vault.
+type AccountConfig struct {
+	Name             string  `yaml:"name"`
+	VaultAddress     string  `yaml:"vault_address"`
+	WalletAddressEnv string  `yaml:"wallet_address_env"`
+	PrivateKeyEnv    string  `yaml:"private_key_env"`
+	SecondaryKeyEnv  string  `yaml:"secondary_key_env"`
+	NotionalUSD      float64 `yaml:"notional_usd"`
+	// KeystorePath is the account's keystore file when keys.source is
+	// keystore; it defaults to keys.keystore_path.
+	KeystorePath string `yaml:"keystore_path"`
+	// SecondaryKeystorePath and SecondaryKeychainAccount locate the
+	// account's standby key under the keystore and keychain sources.
+	SecondaryKeystorePath    string `yaml:"secondary_keystore_path"`
+	SecondaryKeychainAccount string `yaml:"secondary_keychain_account"`
+}
+
+// KeysConfig names where the signing keys are read from. The keys themselves
+// never live in the config file.
+type KeysConfig struct {
+	SecondaryKeyEnv string `yaml:"secondary_key_env"`
+	// Source is where the signing key comes from: "env" (the private key
+	// variable, default), "keystore" (an encrypted geth keystore JSON file)
+	// or "keychain" (the OS keychain, under the wallet address).
+	Source       string `yaml:"source"`
+	KeystorePath string `yaml:"keystore_path"`
+	// PassphraseEnv holds the keystore passphrase; when it is empty the
+	// passphrase is prompted for on the terminal.
+	PassphraseEnv   string `yaml:"passphrase_env"`
+	KeychainService string `yaml:"keychain_service"`
+	// The standby key for /key rotate comes from the same source:
+	// SecondaryKeyEnv for env, SecondaryKeystorePath (unlocked with
+	// PassphraseEnv) for keystore, or the KeychainService entry under
+	// SecondaryKeychainAccount for keychain. Unset stages no standby key.
+	SecondaryKeystorePath    string `yaml:"secondary_keystore_path"`
+	SecondaryKeychainAccount string `yaml:"secondary_keychain_account"`
+}
+
+// ShadowConfig is an alternative strategy parameter set evaluated every tick
+// next to the live one without trading. Unset fields inherit the live
+// strategy value.
+type ShadowConfig struct {
+	Enabled                 bool     `yaml:"enabled"`
+	MinFundingAPR           *float64 `yaml:"min_funding_apr"`
+	MinFundingRate          *float64 `yaml:"min_funding_rate"`
+	MaxVolatility           *float64 `yaml:"max_volatility"`
+	FeeBps                  *float64 `yaml:"fee_bps"`
+	SlippageBps             *float64 `yaml:"slippage_bps"`
+	CarryBufferUSD          *float64 `yaml:"carry_buffer_usd"`
+	MaxVenueFundingPremium  *float64 `yaml:"max_venue_funding_premium"`
+	MinTrailingFunding      *float64 `yaml:"min_trailing_funding"`
+	FundingConfirmations    *int     `yaml:"funding_confirmations"`
+	FundingDipConfirmations *int     `yaml:"funding_dip_confirmations"`
+	ExitOnFundingDip        *bool    `yaml:"exit_on_funding_dip"`
+}
+
+// Apply returns base with the shadow overrides applied.
+func (s ShadowConfig) Apply(base StrategyConfig) StrategyConfig {
+	if s.MinFundingAPR != nil {
+		base.MinFundingAPR = *s.MinFundingAPR
+	} else if s.MinFundingRate != nil {
+		base.MinFundingAPR = *s.MinFundingRate * hoursPerYear
+	}
+	if s.MaxVolatility != nil {
+		base.MaxVolatility = *s.MaxVolatility
+	}
+	if s.FeeBps != nil {
+		base.FeeBps = *s.FeeBps
+	}
+	if s.SlippageBps != nil {
+		base.SlippageBps = *s.SlippageBps
+	}
+	if s.CarryBufferUSD != nil {
+		base.CarryBufferUSD = *s.CarryBufferUSD
+	}
+	if s.MaxVenueFundingPremium != nil {
+		base.MaxVenueFundingPremium = *s.MaxVenueFundingPremium
+	}
+	if s.MinTrailingFunding != nil {
+		base.MinTrailingFunding = *s.MinTrailingFunding
+	}
+	if s.FundingConfirmations != nil {
+		base.FundingConfirmations = *s.FundingConfirmations
+	}
+	if s.FundingDipConfirmations != nil {
+		base.FundingDipConfirmations = *s.FundingDipConfirmations
+	}
+	if s.ExitOnFundingDip != nil {
+		base.ExitOnFundingDip = *s.ExitOnFundingDip
+	}
+	return base
 }
 
 type TelegramConfig struct {
@@ -109,14 +673,47 @@ type TelegramConfig struct {
 	OperatorEnabled        bool          `yaml:"operator_enabled"`
 	OperatorPollInterval   time.Duration `yaml:"operator_poll_interval"`
 	OperatorAllowedUserIDs []int64       `yaml:"operator_allowed_user_ids"`
+	// DedupWindow holds back repeats of an identical alert for this long and
+	// then sends one summary with the repeat count.
+	DedupWindow time.Duration `yaml:"dedup_window"`
+	// MaxPerMinute caps alerts sent per minute; the overflow is dropped and
+	// its count reported with the next message.
+	MaxPerMinute int `yaml:"max_per_minute"`
+	// RequireConfirmation holds risky operator commands (looser /risk
+	// limits, /resume after a daily loss halt) until a /confirm arrives
+	// within ConfirmationTimeout; /flatten always asks for one.
+	RequireConfirmation bool          `yaml:"require_confirmation"`
+	ConfirmationTimeout time.Duration `yaml:"confirmation_timeout"`
+	// ConfirmationDistinctUser makes the confirmation come from an allowed
+	// user other than the one who sent the command.
+	ConfirmationDistinctUser bool `yaml:"confirmation_distinct_user"`
+	// FundingReport sends a summary of the projected next funding payment
+	// and the day's realized funding FundingReportLead before each funding
+	// time while a perp position is held.
+	FundingReport     bool          `yaml:"funding_report"`
+	FundingReportLead time.Duration `yaml:"funding_report_lead"`
 }
 
-const (
-	// Observed Hyperliquid minimum order value on mainnet.
-	minOrderValueUSD = 10.0
+// MinOrderValueUSD is the smallest order value Hyperliquid accepts, as
+// observed on mainnet.
+const MinOrderValueUSD = 10.0
 
+const (
 	minDeltaBandUSD = 2.0
 	deltaBandRatio  = 0.05
+
+	// hoursPerYear translates the hourly min_funding_rate to an APR.
+	hoursPerYear = 24 * 365
+
+	// Hyperliquid rejects scheduleCancel times less than 5s in the future.
+	minScheduleCancelWindow     = 5 * time.Second
+	defaultScheduleCancelWindow = 30 * time.Second
+
+	defaultBackfillLookback = 90 * 24 * time.Hour
+
+	// maxTrailingFundingWindow is the most funding history the trailing gate
+	// fetches.
+	maxTrailingFundingWindow = 7 * 24 * time.Hour
 )
 
 func Load(path string) (*Config, error) {
@@ -140,12 +737,43 @@ func applyDefaults(cfg *Config) {
 	if cfg.Log.Level == "" {
 		cfg.Log.Level = "info"
 	}
+	if cfg.Log.Format == "" {
+		cfg.Log.Format = "json"
+	}
+	if cfg.Log.Sampling.Debug == 0 {
+		cfg.Log.Sampling.Debug = 10
+	}
+	if cfg.Log.File.MaxSizeMB == 0 {
+		cfg.Log.File.MaxSizeMB = 100
+	}
+	if cfg.Log.Audit.MaxSizeMB == 0 {
+		cfg.Log.Audit.MaxSizeMB = 100
+	}
 	if cfg.REST.BaseURL == "" {
 		cfg.REST.BaseURL = "https://api.hyperliquid.xyz"
 	}
 	if cfg.REST.Timeout == 0 {
 		cfg.REST.Timeout = 10 * time.Second
 	}
+	if cfg.REST.WeightPerMinute == 0 {
+		cfg.REST.WeightPerMinute = 1200
+	}
+	if cfg.REST.ReserveWeight == nil {
+		reserve := cfg.REST.WeightPerMinute / 6
+		cfg.REST.ReserveWeight = &reserve
+	}
+	if cfg.REST.ExchangeMaxAttempts == 0 {
+		cfg.REST.ExchangeMaxAttempts = 3
+	}
+	if cfg.REST.ExchangeRetryBackoff == 0 {
+		cfg.REST.ExchangeRetryBackoff = 250 * time.Millisecond
+	}
+	if cfg.REST.InfoMaxAttempts == 0 {
+		cfg.REST.InfoMaxAttempts = 3
+	}
+	if cfg.REST.InfoRetryBackoff == 0 {
+		cfg.REST.InfoRetryBackoff = 250 * time.Millisecond
+	}
 	if cfg.WS.URL == "" {
 		if derived := deriveWSURL(cfg.REST.BaseURL); derived != "" {
 			cfg.WS.URL = derived
@@ -159,9 +787,18 @@ func applyDefaults(cfg *Config) {
 	if cfg.WS.PingInterval == 0 {
 		cfg.WS.PingInterval = 50 * time.Second
 	}
+	if cfg.State.Backend == "" {
+		cfg.State.Backend = "sqlite"
+	}
 	if cfg.State.SQLitePath == "" {
 		cfg.State.SQLitePath = "data/hl-carry-bot.db"
 	}
+	if cfg.State.Namespace == "" {
+		cfg.State.Namespace = "default"
+	}
+	if cfg.State.ImportMaxAge == 0 {
+		cfg.State.ImportMaxAge = 15 * time.Minute
+	}
 	if cfg.Metrics.Enabled == nil {
 		enabled := true
 		cfg.Metrics.Enabled = &enabled
@@ -187,12 +824,42 @@ func applyDefaults(cfg *Config) {
 	if cfg.Timescale.ConnMaxLifetime == 0 {
 		cfg.Timescale.ConnMaxLifetime = 5 * time.Minute
 	}
+	if cfg.Timescale.BatchSize == 0 {
+		cfg.Timescale.BatchSize = 100
+	}
+	if cfg.Timescale.FlushInterval == 0 {
+		cfg.Timescale.FlushInterval = time.Second
+	}
+	if cfg.Timescale.MaxRetries == 0 {
+		cfg.Timescale.MaxRetries = 3
+	}
+	if cfg.Timescale.RetryBackoff == 0 {
+		cfg.Timescale.RetryBackoff = 200 * time.Millisecond
+	}
+	if cfg.Timescale.MaxBacklog == 0 {
+		cfg.Timescale.MaxBacklog = 10000
+	}
 	if cfg.Telegram.OperatorPollInterval == 0 {
 		cfg.Telegram.OperatorPollInterval = 3 * time.Second
 	}
+	if cfg.Telegram.DedupWindow == 0 {
+		cfg.Telegram.DedupWindow = 10 * time.Minute
+	}
+	if cfg.Telegram.MaxPerMinute == 0 {
+		cfg.Telegram.MaxPerMinute = 20
+	}
+	if cfg.Telegram.ConfirmationTimeout == 0 {
+		cfg.Telegram.ConfirmationTimeout = time.Minute
+	}
+	if cfg.Telegram.FundingReportLead == 0 {
+		cfg.Telegram.FundingReportLead = time.Hour
+	}
 	if cfg.Strategy.EntryInterval == 0 {
 		cfg.Strategy.EntryInterval = 30 * time.Second
 	}
+	if cfg.Strategy.MinFundingAPR == 0 && cfg.Strategy.MinFundingRate != 0 {
+		cfg.Strategy.MinFundingAPR = cfg.Strategy.MinFundingRate * hoursPerYear
+	}
 	if cfg.Strategy.EntryCooldown == 0 {
 		if cfg.Strategy.EntryInterval > 0 {
 			cfg.Strategy.EntryCooldown = cfg.Strategy.EntryInterval * 2
@@ -223,12 +890,33 @@ func applyDefaults(cfg *Config) {
 	if cfg.Strategy.EntryTimeout == 0 {
 		cfg.Strategy.EntryTimeout = 5 * time.Second
 	}
+	if cfg.Strategy.USDCBufferBps == 0 {
+		cfg.Strategy.USDCBufferBps = 25
+	}
+	if cfg.Strategy.MinTransferUSD == 0 {
+		cfg.Strategy.MinTransferUSD = 10
+	}
 	if cfg.Strategy.EntryPollInterval == 0 {
 		cfg.Strategy.EntryPollInterval = 250 * time.Millisecond
 	}
 	if cfg.Strategy.ExitFundingGuard == 0 {
 		cfg.Strategy.ExitFundingGuard = 2 * time.Minute
 	}
+	if cfg.Strategy.RollbackAttempts == 0 {
+		cfg.Strategy.RollbackAttempts = 3
+	}
+	if cfg.Strategy.RollbackStepBps == 0 {
+		cfg.Strategy.RollbackStepBps = 25
+	}
+	if cfg.Strategy.RollbackMaxBps == 0 {
+		cfg.Strategy.RollbackMaxBps = 100
+	}
+	if cfg.Strategy.BasisWindow == 0 {
+		cfg.Strategy.BasisWindow = 24 * time.Hour
+	}
+	if cfg.Strategy.TrailingFundingWindow == 0 {
+		cfg.Strategy.TrailingFundingWindow = 24 * time.Hour
+	}
 	if cfg.Strategy.ExitFundingGuardEnabled == nil {
 		enabled := true
 		cfg.Strategy.ExitFundingGuardEnabled = &enabled
@@ -239,6 +927,51 @@ func applyDefaults(cfg *Config) {
 	if cfg.Strategy.CandleWindow == 0 {
 		cfg.Strategy.CandleWindow = 24
 	}
+	if len(cfg.Timescale.CandleIntervals) == 0 {
+		cfg.Timescale.CandleIntervals = []string{cfg.Strategy.CandleInterval}
+	}
+	if cfg.Strategy.VolatilityEstimator == "" {
+		cfg.Strategy.VolatilityEstimator = "stdev"
+	}
+	cfg.Strategy.VolatilityEstimator = strings.ToLower(strings.TrimSpace(cfg.Strategy.VolatilityEstimator))
+	if cfg.Strategy.ExecutionMode == "" {
+		cfg.Strategy.ExecutionMode = "taker"
+	}
+	cfg.Strategy.ExecutionMode = strings.ToLower(strings.TrimSpace(cfg.Strategy.ExecutionMode))
+	if cfg.Strategy.MakerTimeout == 0 {
+		cfg.Strategy.MakerTimeout = 30 * time.Second
+	}
+	if cfg.Strategy.TickTimeout == nil {
+		timeout := deriveTickTimeout(cfg.Strategy.EntryTimeout, cfg.Strategy.MakerTimeout)
+		cfg.Strategy.TickTimeout = &timeout
+	}
+	if cfg.Strategy.SlippageWindow == 0 {
+		cfg.Strategy.SlippageWindow = 20
+	}
+	cfg.Strategy.PerpMarginMode = strings.ToLower(strings.TrimSpace(cfg.Strategy.PerpMarginMode))
+	if cfg.Strategy.PerpMarginMode == "" {
+		cfg.Strategy.PerpMarginMode = "cross"
+	}
+	if cfg.Strategy.MakerPrice == "" {
+		cfg.Strategy.MakerPrice = "mid"
+	}
+	cfg.Strategy.MakerPrice = strings.ToLower(strings.TrimSpace(cfg.Strategy.MakerPrice))
+	if cfg.Strategy.HedgeLeg == "" {
+		cfg.Strategy.HedgeLeg = "perp"
+	}
+	cfg.Strategy.HedgeLeg = strings.ToLower(strings.TrimSpace(cfg.Strategy.HedgeLeg))
+	if cfg.Strategy.VolatilityEWMALambda == 0 {
+		cfg.Strategy.VolatilityEWMALambda = 0.94
+	}
+	if cfg.Strategy.RealizedVolWindow == 0 {
+		cfg.Strategy.RealizedVolWindow = time.Hour
+	}
+	if cfg.Strategy.TradeFlowWindow == 0 {
+		cfg.Strategy.TradeFlowWindow = 5 * time.Minute
+	}
+	if cfg.Strategy.BookImbalanceLevels == 0 {
+		cfg.Strategy.BookImbalanceLevels = 5
+	}
 	if cfg.Strategy.PerpAsset == "" && cfg.Strategy.Asset != "" {
 		cfg.Strategy.PerpAsset = cfg.Strategy.Asset
 	}
@@ -249,12 +982,184 @@ func applyDefaults(cfg *Config) {
 			cfg.Strategy.SpotAsset = cfg.Strategy.PerpAsset
 		}
 	}
+	if cfg.ScheduleCancel.Window == 0 {
+		cfg.ScheduleCancel.Window = deriveScheduleCancelWindow(cfg.Strategy.EntryInterval)
+	}
+	if cfg.Accounting.Enabled == nil {
+		enabled := true
+		cfg.Accounting.Enabled = &enabled
+	}
+	if cfg.Interference.Enabled == nil {
+		enabled := true
+		cfg.Interference.Enabled = &enabled
+	}
+	if cfg.Interference.Grace == 0 {
+		cfg.Interference.Grace = 5 * time.Second
+	}
+	if cfg.Interference.Hold == 0 {
+		cfg.Interference.Hold = 15 * time.Minute
+	}
+	if cfg.Compound.IncrementUSD == 0 {
+		cfg.Compound.IncrementUSD = 25
+	}
+	cfg.Vault.Address = strings.TrimSpace(cfg.Vault.Address)
+	for i, addr := range cfg.Transfers.AllowedDestinations {
+		cfg.Transfers.AllowedDestinations[i] = strings.TrimSpace(addr)
+	}
+	if cfg.Vault.MinTransferUSD == 0 {
+		cfg.Vault.MinTransferUSD = 10
+	}
+	if cfg.Vault.Lockup == 0 {
+		cfg.Vault.Lockup = 96 * time.Hour
+	}
+	if cfg.IsolatedMargin.TopUpRatio > 0 && cfg.IsolatedMargin.TargetRatio == 0 {
+		cfg.IsolatedMargin.TargetRatio = 2 * cfg.IsolatedMargin.TopUpRatio
+	}
+	if cfg.Fees.RefreshInterval == 0 {
+		cfg.Fees.RefreshInterval = 24 * time.Hour
+	}
+	if cfg.Dust.Interval == 0 {
+		cfg.Dust.Interval = time.Hour
+	}
+	if cfg.Janitor.Interval == 0 {
+		cfg.Janitor.Interval = 5 * time.Minute
+	}
+	if cfg.Pricing.SpotEntry == "" {
+		cfg.Pricing.SpotEntry = "aggressive_ioc"
+	}
+	if cfg.Pricing.PerpEntry == "" {
+		cfg.Pricing.PerpEntry = "aggressive_ioc"
+	}
+	if cfg.Pricing.SpotExit == "" {
+		cfg.Pricing.SpotExit = "mid_peg"
+	}
+	if cfg.Pricing.PerpExit == "" {
+		cfg.Pricing.PerpExit = "mid_peg"
+	}
+	if cfg.Pricing.BookMaxAge == 0 {
+		cfg.Pricing.BookMaxAge = 5 * time.Second
+	}
+	if cfg.CircuitBreaker.MaxFailures == 0 {
+		cfg.CircuitBreaker.MaxFailures = 5
+	}
+	if cfg.CircuitBreaker.Window == 0 {
+		cfg.CircuitBreaker.Window = 5 * time.Minute
+	}
+	if cfg.CircuitBreaker.CoolOff == 0 {
+		cfg.CircuitBreaker.CoolOff = 15 * time.Minute
+	}
+	if cfg.EventLoop.MinSpacing == 0 {
+		cfg.EventLoop.MinSpacing = 2 * time.Second
+	}
+	if cfg.EventLoop.MaxIdle == 0 {
+		cfg.EventLoop.MaxIdle = cfg.Strategy.EntryInterval
+	}
+	if cfg.EventLoop.MidMoveBps == 0 {
+		cfg.EventLoop.MidMoveBps = 10
+	}
+	if cfg.EventLoop.MarginRatioMove == 0 {
+		cfg.EventLoop.MarginRatioMove = 0.02
+	}
+	if cfg.EventLoop.FundingPoll == 0 {
+		cfg.EventLoop.FundingPoll = time.Minute
+	}
+	if cfg.DecisionLog.Retention == 0 {
+		cfg.DecisionLog.Retention = 7 * 24 * time.Hour
+	}
+	if cfg.Tracing.Endpoint == "" {
+		cfg.Tracing.Endpoint = "localhost:4318"
+	}
+	if cfg.Tracing.ServiceName == "" {
+		cfg.Tracing.ServiceName = "hl-carry-bot"
+	}
+	if cfg.Tracing.SampleRatio == 0 {
+		cfg.Tracing.SampleRatio = 1
+	}
+	if cfg.Dust.ThresholdUSD == 0 {
+		cfg.Dust.ThresholdUSD = MinOrderValueUSD
+	}
+	if cfg.Keys.SecondaryKeyEnv == "" {
+		cfg.Keys.SecondaryKeyEnv = "HL_SECONDARY_PRIVATE_KEY"
+	}
+	cfg.Keys.Source = strings.ToLower(strings.TrimSpace(cfg.Keys.Source))
+	if cfg.Keys.Source == "" {
+		cfg.Keys.Source = "env"
+	}
+	if cfg.Keys.PassphraseEnv == "" {
+		cfg.Keys.PassphraseEnv = "HL_KEYSTORE_PASSPHRASE"
+	}
+	if cfg.Keys.KeychainService == "" {
+		cfg.Keys.KeychainService = "hl-carry-bot"
+	}
+	for i := range cfg.Accounts {
+		acct := &cfg.Accounts[i]
+		acct.Name = strings.ToLower(strings.TrimSpace(acct.Name))
+		acct.VaultAddress = strings.TrimSpace(acct.VaultAddress)
+		if acct.WalletAddressEnv == "" {
+			acct.WalletAddressEnv = "HL_WALLET_ADDRESS"
+		}
+		if acct.PrivateKeyEnv == "" {
+			acct.PrivateKeyEnv = "HL_PRIVATE_KEY"
+		}
+		if acct.KeystorePath == "" {
+			acct.KeystorePath = cfg.Keys.KeystorePath
+		}
+		if acct.NotionalUSD == 0 {
+			acct.NotionalUSD = cfg.Strategy.NotionalUSD
+		}
+	}
+	if cfg.Accounting.SyncInterval == 0 {
+		cfg.Accounting.SyncInterval = time.Hour
+	}
+	cfg.Risk.ValuationBasis = strings.ToLower(strings.TrimSpace(cfg.Risk.ValuationBasis))
+	if cfg.Risk.ValuationBasis == "" {
+		cfg.Risk.ValuationBasis = "oracle"
+	}
+	if cfg.Risk.CrashStopSlippageBps == 0 {
+		cfg.Risk.CrashStopSlippageBps = 500
+	}
+	applyRiskActionDefaults(&cfg.Risk.Actions)
+	if cfg.Risk.DailyPnLRefresh == 0 {
+		cfg.Risk.DailyPnLRefresh = time.Minute
+	}
+	if cfg.Risk.FailureCoolOff == nil {
+		coolOff := 30 * time.Minute
+		cfg.Risk.FailureCoolOff = &coolOff
+	}
+	if cfg.Risk.MaxClockDrift == 0 {
+		cfg.Risk.MaxClockDrift = 5 * time.Second
+	}
+	if cfg.Risk.ClockSyncInterval == 0 {
+		cfg.Risk.ClockSyncInterval = 5 * time.Minute
+	}
+	if cfg.Risk.ReduceToPct == 0 {
+		cfg.Risk.ReduceToPct = 50
+	}
+	cfg.Risk.OverAllocation = strings.ToLower(strings.TrimSpace(cfg.Risk.OverAllocation))
+	if cfg.Risk.OverAllocation == "" {
+		cfg.Risk.OverAllocation = "refuse"
+	}
+	if cfg.Risk.AllocationLeverage == 0 {
+		cfg.Risk.AllocationLeverage = 1
+		if cfg.Strategy.PerpLeverage > 0 {
+			cfg.Risk.AllocationLeverage = float64(cfg.Strategy.PerpLeverage)
+		}
+	}
 	if cfg.Risk.MaxMarketAge == 0 {
 		cfg.Risk.MaxMarketAge = deriveMaxMarketAge(cfg.Strategy.EntryInterval, cfg.WS.PingInterval)
 	}
 	if cfg.Risk.MaxAccountAge == 0 {
 		cfg.Risk.MaxAccountAge = deriveMaxAccountAge(cfg.Strategy.EntryInterval, cfg.WS.PingInterval, cfg.Strategy.SpotReconcileInterval)
 	}
+	if cfg.Health.MaxTickAge == 0 {
+		cfg.Health.MaxTickAge = deriveMaxTickAge(cfg.Strategy.EntryInterval, cfg.Strategy.TickTimeoutValue(), cfg.EventLoop)
+	}
+	if cfg.Health.MaxMarketAge == 0 {
+		cfg.Health.MaxMarketAge = cfg.Risk.MaxMarketAge
+	}
+	if cfg.Health.MaxAccountAge == 0 {
+		cfg.Health.MaxAccountAge = cfg.Risk.MaxAccountAge
+	}
 }
 
 func applyEnvOverrides(cfg *Config) {
@@ -308,9 +1213,47 @@ func validate(cfg *Config) error {
 	if cfg.Strategy.NotionalUSD <= 0 {
 		return errors.New("strategy.notional_usd must be > 0")
 	}
+	if cfg.Strategy.MinFundingRate != 0 && cfg.Strategy.MinFundingAPR != cfg.Strategy.MinFundingRate*hoursPerYear {
+		return errors.New("strategy.min_funding_rate is deprecated; set only strategy.min_funding_apr")
+	}
 	if cfg.Strategy.EntryTimeout <= 0 {
 		return errors.New("strategy.entry_timeout must be > 0")
 	}
+	if cfg.Log.Sampling.Debug < 0 || cfg.Log.Sampling.Info < 0 || cfg.Log.Sampling.Warn < 0 {
+		return errors.New("log.sampling values must be >= 0")
+	}
+	switch cfg.Log.Format {
+	case "json", "console":
+	default:
+		return errors.New("log.format must be json or console")
+	}
+	if err := validateLogFile("log.file", cfg.Log.File); err != nil {
+		return err
+	}
+	if err := validateLogFile("log.audit", cfg.Log.Audit); err != nil {
+		return err
+	}
+	if cfg.Log.File.Path != "" && filepath.Clean(cfg.Log.File.Path) == filepath.Clean(cfg.Log.Audit.Path) {
+		return errors.New("log.audit.path must differ from log.file.path")
+	}
+	if cfg.REST.WeightPerMinute <= 0 {
+		return errors.New("rest.weight_per_minute must be > 0")
+	}
+	if reserve := cfg.REST.ReserveWeightValue(); reserve < 0 || reserve >= cfg.REST.WeightPerMinute {
+		return errors.New("rest.reserve_weight must be >= 0 and below rest.weight_per_minute")
+	}
+	if cfg.REST.ExchangeMaxAttempts < 1 {
+		return errors.New("rest.exchange_max_attempts must be >= 1")
+	}
+	if cfg.REST.ExchangeRetryBackoff < 0 {
+		return errors.New("rest.exchange_retry_backoff must be >= 0")
+	}
+	if cfg.REST.InfoMaxAttempts < 1 {
+		return errors.New("rest.info_max_attempts must be >= 1")
+	}
+	if cfg.REST.InfoRetryBackoff < 0 {
+		return errors.New("rest.info_retry_backoff must be >= 0")
+	}
 	if cfg.Strategy.EntryPollInterval <= 0 {
 		return errors.New("strategy.entry_poll_interval must be > 0")
 	}
@@ -320,15 +1263,112 @@ func validate(cfg *Config) error {
 	if cfg.Strategy.FeeBps < 0 {
 		return errors.New("strategy.fee_bps must be >= 0")
 	}
+	if cfg.Strategy.USDCBufferBps < 0 {
+		return errors.New("strategy.usdc_buffer_bps must be >= 0")
+	}
+	if cfg.Strategy.MinTransferUSD < 0 {
+		return errors.New("strategy.min_transfer_usd must be >= 0")
+	}
+	if cfg.Strategy.TransferCooldown < 0 {
+		return errors.New("strategy.transfer_cooldown must be >= 0")
+	}
 	if cfg.Strategy.SlippageBps < 0 {
 		return errors.New("strategy.slippage_bps must be >= 0")
 	}
 	if cfg.Strategy.IOCPriceBps < 0 {
 		return errors.New("strategy.ioc_price_bps must be >= 0")
 	}
+	if cfg.Strategy.IOCPriceBpsMin < 0 {
+		return errors.New("strategy.ioc_price_bps_min must be >= 0")
+	}
+	if cfg.Strategy.IOCPriceBpsAuto && cfg.Strategy.IOCPriceBpsMax <= cfg.Strategy.IOCPriceBpsMin {
+		return errors.New("strategy.ioc_price_bps_max must be > strategy.ioc_price_bps_min when ioc_price_bps_auto is set")
+	}
+	if cfg.Strategy.SlippageWindow < 1 {
+		return errors.New("strategy.slippage_window must be >= 1")
+	}
+	if cfg.Strategy.RollbackAttempts < 1 {
+		return errors.New("strategy.rollback_attempts must be >= 1")
+	}
+	if cfg.Strategy.RollbackStepBps < 0 {
+		return errors.New("strategy.rollback_step_bps must be >= 0")
+	}
+	if cfg.Strategy.RollbackMaxBps < cfg.Strategy.IOCPriceBps {
+		return errors.New("strategy.rollback_max_bps must be >= strategy.ioc_price_bps")
+	}
 	if cfg.Strategy.CarryBufferUSD < 0 {
 		return errors.New("strategy.carry_buffer_usd must be >= 0")
 	}
+	if cfg.Strategy.MaxVenueFundingPremium < 0 {
+		return errors.New("strategy.max_venue_funding_premium must be >= 0")
+	}
+	if cfg.Strategy.TrailingFundingWindow < time.Hour || cfg.Strategy.TrailingFundingWindow > maxTrailingFundingWindow {
+		return errors.New("strategy.trailing_funding_window must be between 1h and 168h")
+	}
+	if cfg.Strategy.ExitBasisBps < 0 {
+		return errors.New("strategy.exit_basis_bps must be >= 0")
+	}
+	if cfg.Strategy.BasisWindow < 0 {
+		return errors.New("strategy.basis_window must be >= 0")
+	}
+	switch cfg.Strategy.VolatilityEstimator {
+	case "stdev", "ewma", "parkinson", "realized":
+	default:
+		return errors.New("strategy.volatility_estimator must be stdev, ewma, parkinson, or realized")
+	}
+	switch cfg.Strategy.ExecutionMode {
+	case "taker", "maker_when_thin", "maker_first":
+	default:
+		return errors.New("strategy.execution_mode must be taker, maker_when_thin, or maker_first")
+	}
+	switch cfg.Strategy.MakerPrice {
+	case "mid", "touch", "inside":
+	default:
+		return errors.New("strategy.maker_price must be mid, touch, or inside")
+	}
+	if cfg.Strategy.MakerMarginUSD < 0 {
+		return errors.New("strategy.maker_margin_usd must be >= 0")
+	}
+	switch cfg.Strategy.HedgeLeg {
+	case "perp", "spot", "auto":
+	default:
+		return errors.New("strategy.hedge_leg must be perp, spot, or auto")
+	}
+	if cfg.Strategy.HedgeMinMarginRatio < 0 {
+		return errors.New("strategy.hedge_min_margin_ratio must be >= 0")
+	}
+	if cfg.Strategy.MakerTimeout < 0 {
+		return errors.New("strategy.maker_timeout must be >= 0")
+	}
+	if timeout := cfg.Strategy.TickTimeoutValue(); timeout != 0 && timeout < 2*cfg.Strategy.EntryTimeout+cfg.Strategy.MakerTimeout {
+		return errors.New("strategy.tick_timeout must be 0 (disabled) or at least maker_timeout plus twice entry_timeout")
+	}
+	if cfg.Strategy.PerpLeverage < 0 {
+		return errors.New("strategy.perp_leverage must be >= 0")
+	}
+	switch cfg.Strategy.PerpMarginMode {
+	case "cross", "isolated":
+	default:
+		return errors.New("strategy.perp_margin_mode must be cross or isolated")
+	}
+	if cfg.Strategy.VolatilityEWMALambda <= 0 || cfg.Strategy.VolatilityEWMALambda >= 1 {
+		return errors.New("strategy.volatility_ewma_lambda must be between 0 and 1")
+	}
+	if cfg.Strategy.RealizedVolWindow < time.Minute {
+		return errors.New("strategy.realized_vol_window must be >= 1m")
+	}
+	if cfg.Strategy.TradeFlowWindow < 0 {
+		return errors.New("strategy.trade_flow_window must be >= 0")
+	}
+	if cfg.Strategy.MaxSellImbalance < 0 || cfg.Strategy.MaxSellImbalance > 1 {
+		return errors.New("strategy.max_sell_imbalance must be between 0 and 1")
+	}
+	if cfg.Strategy.MaxBookSellImbalance < 0 || cfg.Strategy.MaxBookSellImbalance > 1 {
+		return errors.New("strategy.max_book_sell_imbalance must be between 0 and 1")
+	}
+	if cfg.Strategy.BookImbalanceLevels < 1 {
+		return errors.New("strategy.book_imbalance_levels must be >= 1")
+	}
 	if cfg.Strategy.FundingConfirmations < 1 {
 		return errors.New("strategy.funding_confirmations must be >= 1")
 	}
@@ -338,6 +1378,12 @@ func validate(cfg *Config) error {
 	if cfg.Strategy.DeltaBandUSD < 0 {
 		return errors.New("strategy.delta_band_usd must be >= 0")
 	}
+	if cfg.Strategy.DeltaRecenterPct < 0 || cfg.Strategy.DeltaRecenterPct >= 100 {
+		return errors.New("strategy.delta_recenter_pct must be >= 0 and < 100")
+	}
+	if cfg.Strategy.MinOrderBumpPct < 0 || cfg.Strategy.MinOrderBumpPct > 100 {
+		return errors.New("strategy.min_order_bump_pct must be >= 0 and <= 100")
+	}
 	if cfg.Strategy.EntryCooldown < 0 {
 		return errors.New("strategy.entry_cooldown must be >= 0")
 	}
@@ -350,9 +1396,178 @@ func validate(cfg *Config) error {
 	if cfg.Strategy.ExitFundingGuard < 0 {
 		return errors.New("strategy.exit_funding_guard must be >= 0")
 	}
+	if cfg.Strategy.EntryFundingGuard < 0 {
+		return errors.New("strategy.entry_funding_guard must be >= 0")
+	}
+	if cfg.ScheduleCancel.Enabled {
+		if cfg.ScheduleCancel.Window < minScheduleCancelWindow {
+			return errors.New("schedule_cancel.window must be >= 5s")
+		}
+		if cfg.ScheduleCancel.Window <= cfg.Strategy.EntryInterval {
+			return errors.New("schedule_cancel.window must exceed strategy.entry_interval")
+		}
+	}
+	if cfg.Accounting.SyncInterval < 0 {
+		return errors.New("accounting.sync_interval must be >= 0")
+	}
+	if start, err := cfg.Accounting.BackfillStartTime(time.Now()); err != nil {
+		return err
+	} else if start.After(time.Now()) {
+		return errors.New("accounting.backfill_start must be in the past")
+	}
+	if cfg.Interference.Grace < 0 {
+		return errors.New("interference.grace must be >= 0")
+	}
+	if cfg.Interference.Hold < 0 {
+		return errors.New("interference.hold must be >= 0")
+	}
+	switch cfg.Keys.Source {
+	case "env", "keychain":
+	case "keystore":
+		if len(cfg.Accounts) == 0 && strings.TrimSpace(cfg.Keys.KeystorePath) == "" {
+			return errors.New("keys.keystore_path is required when keys.source is keystore")
+		}
+		for _, acct := range cfg.Accounts {
+			if strings.TrimSpace(acct.KeystorePath) == "" {
+				return errors.New("accounts.keystore_path (or keys.keystore_path) is required when keys.source is keystore")
+			}
+		}
+	default:
+		return errors.New("keys.source must be env, keystore, or keychain")
+	}
+	if err := validateAccounts(cfg.Accounts); err != nil {
+		return err
+	}
+	if cfg.Compound.Enabled && cfg.Compound.IncrementUSD < MinOrderValueUSD {
+		return errors.New("compound.increment_usd must be >= 10 (exchange minimum order value)")
+	}
+	if cfg.Vault.Address != "" && !validHexAddress(cfg.Vault.Address) {
+		return errors.New("vault.address must be a 0x-prefixed 20-byte hex address")
+	}
+	if cfg.Vault.AutoPark && cfg.Vault.Address == "" {
+		return errors.New("vault.address is required when vault.auto_park is true")
+	}
+	if cfg.Vault.ReserveUSD < 0 {
+		return errors.New("vault.reserve_usd must be >= 0")
+	}
+	if cfg.Vault.MinTransferUSD < 0 {
+		return errors.New("vault.min_transfer_usd must be >= 0")
+	}
+	if cfg.Vault.Lockup < 0 {
+		return errors.New("vault.lockup must be >= 0")
+	}
+	if cfg.IsolatedMargin.TopUpRatio != 0 {
+		if cfg.IsolatedMargin.TopUpRatio <= 1 {
+			return errors.New("isolated_margin.top_up_ratio must be 0 or > 1")
+		}
+		if cfg.Strategy.PerpMarginMode != "isolated" {
+			return errors.New("isolated_margin.top_up_ratio requires strategy.perp_margin_mode isolated")
+		}
+		if cfg.IsolatedMargin.TargetRatio <= cfg.IsolatedMargin.TopUpRatio {
+			return errors.New("isolated_margin.target_ratio must be above top_up_ratio")
+		}
+	}
+	if cfg.IsolatedMargin.MaxTopUpUSD < 0 {
+		return errors.New("isolated_margin.max_top_up_usd must be >= 0")
+	}
+	vaultAllowed := false
+	for _, addr := range cfg.Transfers.AllowedDestinations {
+		if !validHexAddress(addr) {
+			return errors.New("transfers.allowed_destinations entries must be 0x-prefixed 20-byte hex addresses")
+		}
+		if strings.EqualFold(addr, cfg.Vault.Address) {
+			vaultAllowed = true
+		}
+	}
+	if cfg.Vault.Address != "" && !vaultAllowed {
+		return errors.New("vault.address must be listed in transfers.allowed_destinations")
+	}
+	if cfg.Fees.RefreshInterval < time.Minute {
+		return errors.New("fees.refresh_interval must be >= 1m")
+	}
+	if cfg.Dust.Interval < 0 {
+		return errors.New("dust.interval must be >= 0")
+	}
+	if cfg.Dust.ThresholdUSD < 0 {
+		return errors.New("dust.threshold_usd must be >= 0")
+	}
+	if cfg.Dust.Enabled && cfg.Strategy.MinExposureUSD <= MinOrderValueUSD {
+		return errors.New("dust.enabled requires strategy.min_exposure_usd > 10 (the exchange minimum order value), or no balance is both dust and sellable")
+	}
+	if cfg.Janitor.Interval < 0 {
+		return errors.New("janitor.interval must be >= 0")
+	}
+	if cfg.Janitor.MaxAge < 0 {
+		return errors.New("janitor.max_age must be >= 0")
+	}
+	for _, leg := range []struct{ key, policy string }{
+		{"pricing.spot_entry", cfg.Pricing.SpotEntry},
+		{"pricing.perp_entry", cfg.Pricing.PerpEntry},
+		{"pricing.spot_exit", cfg.Pricing.SpotExit},
+		{"pricing.perp_exit", cfg.Pricing.PerpExit},
+	} {
+		switch leg.policy {
+		case "aggressive_ioc", "mid_peg", "spread_cross", "book_aware":
+		default:
+			return errors.New(leg.key + " must be aggressive_ioc, mid_peg, spread_cross, or book_aware")
+		}
+	}
+	if cfg.Pricing.BookMaxAge < 0 {
+		return errors.New("pricing.book_max_age must be >= 0")
+	}
+	if cfg.CircuitBreaker.MaxFailures < 1 {
+		return errors.New("circuit_breaker.max_failures must be >= 1")
+	}
+	if cfg.CircuitBreaker.Window <= 0 {
+		return errors.New("circuit_breaker.window must be > 0")
+	}
+	if cfg.CircuitBreaker.CoolOff <= 0 {
+		return errors.New("circuit_breaker.cool_off must be > 0")
+	}
+	if cfg.EventLoop.MinSpacing <= 0 {
+		return errors.New("event_loop.min_spacing must be > 0")
+	}
+	if cfg.EventLoop.MaxIdle < cfg.EventLoop.MinSpacing {
+		return errors.New("event_loop.max_idle must be >= event_loop.min_spacing")
+	}
+	if cfg.EventLoop.MidMoveBps <= 0 {
+		return errors.New("event_loop.mid_move_bps must be > 0")
+	}
+	if cfg.EventLoop.MarginRatioMove <= 0 {
+		return errors.New("event_loop.margin_ratio_move must be > 0")
+	}
+	if cfg.EventLoop.FundingPoll <= 0 {
+		return errors.New("event_loop.funding_poll must be > 0")
+	}
+	if cfg.DecisionLog.Retention < 0 {
+		return errors.New("decision_log.retention must be >= 0")
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		return errors.New("tracing.sample_ratio must be between 0 and 1")
+	}
+	if cfg.Shadow.Enabled {
+		if err := validateShadow(cfg.Shadow); err != nil {
+			return err
+		}
+	}
 	if cfg.Metrics.Path == "" || !strings.HasPrefix(cfg.Metrics.Path, "/") {
 		return errors.New("metrics.path must start with /")
 	}
+	switch cfg.State.Backend {
+	case "sqlite":
+	case "postgres":
+		if strings.TrimSpace(cfg.Timescale.DSN) == "" {
+			return errors.New("timescale.dsn is required when state.backend is postgres")
+		}
+		if !isValidIdentifier(cfg.Timescale.Schema) {
+			return errors.New("timescale.schema must be alphanumeric/underscore and start with a letter or underscore")
+		}
+	default:
+		return errors.New("state.backend must be sqlite or postgres")
+	}
+	if cfg.State.ImportMaxAge < 0 {
+		return errors.New("state.import_max_age must be >= 0")
+	}
 	if cfg.Timescale.Enabled {
 		if strings.TrimSpace(cfg.Timescale.DSN) == "" {
 			return errors.New("timescale.dsn is required when timescale.enabled is true")
@@ -369,6 +1584,26 @@ func validate(cfg *Config) error {
 		if cfg.Timescale.ConnMaxLifetime < 0 {
 			return errors.New("timescale.conn_max_lifetime must be >= 0")
 		}
+		if cfg.Timescale.BatchSize <= 0 || cfg.Timescale.BatchSize > 1000 {
+			return errors.New("timescale.batch_size must be between 1 and 1000")
+		}
+		if cfg.Timescale.FlushInterval <= 0 {
+			return errors.New("timescale.flush_interval must be > 0")
+		}
+		if cfg.Timescale.MaxRetries < 0 {
+			return errors.New("timescale.max_retries must be >= 0")
+		}
+		if cfg.Timescale.RetryBackoff <= 0 {
+			return errors.New("timescale.retry_backoff must be > 0")
+		}
+		if cfg.Timescale.MaxBacklog < cfg.Timescale.BatchSize {
+			return errors.New("timescale.max_backlog must be >= timescale.batch_size")
+		}
+		for _, interval := range cfg.Timescale.CandleIntervals {
+			if strings.TrimSpace(interval) == "" {
+				return errors.New("timescale.candle_intervals must not contain empty intervals")
+			}
+		}
 		if !isValidIdentifier(cfg.Timescale.Schema) {
 			return errors.New("timescale.schema must be alphanumeric/underscore and start with a letter or underscore")
 		}
@@ -385,14 +1620,105 @@ func validate(cfg *Config) error {
 	if cfg.Risk.MaxAccountAge < 0 {
 		return errors.New("risk.max_account_age must be >= 0")
 	}
+	if cfg.Health.MaxTickAge < 0 || cfg.Health.MaxMarketAge < 0 || cfg.Health.MaxAccountAge < 0 {
+		return errors.New("health thresholds must be >= 0")
+	}
+	if cfg.Health.MaxTickAge > 0 && cfg.Health.MaxTickAge <= cfg.Strategy.EntryInterval {
+		return errors.New("health.max_tick_age must be > strategy.entry_interval")
+	}
+	if cfg.EventLoop.Enabled && cfg.Health.MaxTickAge > 0 && cfg.Health.MaxTickAge <= cfg.EventLoop.MaxIdle {
+		return errors.New("health.max_tick_age must be > event_loop.max_idle")
+	}
+	if cfg.Risk.ValuationBasis != "oracle" && cfg.Risk.ValuationBasis != "mark" {
+		return errors.New("risk.valuation_basis must be oracle or mark")
+	}
+	if cfg.Risk.MaxMarkOracleDivergence < 0 {
+		return errors.New("risk.max_mark_oracle_divergence must be >= 0")
+	}
+	if cfg.Risk.CrashStopBps < 0 || cfg.Risk.CrashStopBps >= 10000 {
+		return errors.New("risk.crash_stop_bps must be >= 0 and < 10000")
+	}
+	if cfg.Risk.CrashStopSlippageBps < 0 || cfg.Risk.CrashStopSlippageBps >= 10000 {
+		return errors.New("risk.crash_stop_slippage_bps must be >= 0 and < 10000")
+	}
+	if cfg.Risk.MaxDeltaUSD < 0 {
+		return errors.New("risk.max_delta_usd must be >= 0")
+	}
+	if cfg.Risk.MaxDeltaUSD > 0 && cfg.Risk.MaxDeltaUSD < cfg.Strategy.DeltaBandUSD {
+		return errors.New("risk.max_delta_usd must be >= strategy.delta_band_usd")
+	}
+	if cfg.Risk.MaxDailyLossUSD < 0 {
+		return errors.New("risk.max_daily_loss_usd must be >= 0")
+	}
+	if cfg.Risk.DailyResetHour < 0 || cfg.Risk.DailyResetHour > 23 {
+		return errors.New("risk.daily_reset_hour must be between 0 and 23")
+	}
+	if cfg.Risk.DailyPnLRefresh < 0 {
+		return errors.New("risk.daily_pnl_refresh must be >= 0")
+	}
+	if cfg.Risk.MaxConsecutiveFailures < 0 {
+		return errors.New("risk.max_consecutive_failures must be >= 0")
+	}
+	if cfg.Risk.FailureCoolOffValue() < 0 {
+		return errors.New("risk.failure_cool_off must be >= 0")
+	}
+	if cfg.Risk.MaxClockDrift <= 0 {
+		return errors.New("risk.max_clock_drift must be > 0")
+	}
+	if cfg.Risk.MinLiquidationDistancePct < 0 || cfg.Risk.MinLiquidationDistancePct >= 100 {
+		return errors.New("risk.min_liquidation_distance_pct must be >= 0 and < 100")
+	}
+	if cfg.Risk.ClockSyncInterval <= 0 {
+		return errors.New("risk.clock_sync_interval must be > 0")
+	}
+	if cfg.Risk.ReduceToPct <= 0 || cfg.Risk.ReduceToPct >= 100 {
+		return errors.New("risk.reduce_to_pct must be > 0 and < 100")
+	}
+	for _, action := range []string{
+		cfg.Risk.Actions.MaxNotional,
+		cfg.Risk.Actions.MaxOpenOrders,
+		cfg.Risk.Actions.MinMarginRatio,
+		cfg.Risk.Actions.MinHealthRatio,
+		cfg.Risk.Actions.MaxDelta,
+		cfg.Risk.Actions.DailyLoss,
+		cfg.Risk.Actions.ConsecutiveFailures,
+		cfg.Risk.Actions.ClockDrift,
+		cfg.Risk.Actions.LiquidationDistance,
+	} {
+		switch action {
+		case "block_entry", "hedge_only", "reduce", "flatten", "halt":
+		default:
+			return errors.New("risk.actions.* must be block_entry, hedge_only, reduce, flatten, or halt")
+		}
+	}
 	if cfg.Risk.MaxNotionalUSD > 0 && cfg.Strategy.NotionalUSD > cfg.Risk.MaxNotionalUSD {
 		return errors.New("strategy.notional_usd exceeds risk.max_notional_usd")
 	}
+	for _, acct := range cfg.Accounts {
+		if cfg.Risk.MaxNotionalUSD > 0 && acct.NotionalUSD > cfg.Risk.MaxNotionalUSD {
+			return errors.New("accounts.notional_usd exceeds risk.max_notional_usd")
+		}
+	}
+	if cfg.Risk.OverAllocation != "refuse" && cfg.Risk.OverAllocation != "scale" {
+		return errors.New("risk.over_allocation must be refuse or scale")
+	}
+	if cfg.Risk.AllocationLeverage < 1 {
+		return errors.New("risk.allocation_leverage must be >= 1")
+	}
+	if cfg.Strategy.PerpLeverage > 0 && cfg.Risk.AllocationLeverage > float64(cfg.Strategy.PerpLeverage) {
+		return errors.New("risk.allocation_leverage must not exceed strategy.perp_leverage")
+	}
 	if cfg.Telegram.Enabled {
 		if strings.TrimSpace(cfg.Telegram.Token) == "" || strings.TrimSpace(cfg.Telegram.ChatID) == "" {
 			return errors.New("telegram token and chat_id are required when telegram.enabled is true (set HL_TELEGRAM_TOKEN and HL_TELEGRAM_CHAT_ID)")
 		}
 	}
+	if cfg.Telegram.DedupWindow < 0 {
+		return errors.New("telegram.dedup_window must be > 0")
+	}
+	if cfg.Telegram.MaxPerMinute < 0 {
+		return errors.New("telegram.max_per_minute must be > 0")
+	}
 	if cfg.Telegram.OperatorEnabled {
 		if !cfg.Telegram.Enabled {
 			return errors.New("telegram.operator_enabled requires telegram.enabled to be true")
@@ -407,6 +1733,15 @@ func validate(cfg *Config) error {
 			return errors.New("telegram.chat_id must be numeric when telegram.operator_enabled is true")
 		}
 	}
+	if cfg.Telegram.ConfirmationTimeout < 0 {
+		return errors.New("telegram.confirmation_timeout must be > 0")
+	}
+	if cfg.Telegram.FundingReportLead < 0 {
+		return errors.New("telegram.funding_report_lead must be > 0")
+	}
+	if cfg.Telegram.ConfirmationDistinctUser && len(cfg.Telegram.OperatorAllowedUserIDs) < 2 {
+		return errors.New("telegram.confirmation_distinct_user requires at least two operator_allowed_user_ids")
+	}
 	return nil
 }
 
@@ -430,7 +1765,7 @@ func isValidIdentifier(value string) bool {
 }
 
 func deriveMinExposureUSD() float64 {
-	return minOrderValueUSD
+	return MinOrderValueUSD
 }
 
 func deriveDeltaBandUSD(notionalUSD float64) float64 {
@@ -444,6 +1779,47 @@ func deriveDeltaBandUSD(notionalUSD float64) float64 {
 	return band
 }
 
+// applyRiskActionDefaults fills unset rule actions: breached account-safety
+// limits flatten, an order pile-up halts, an oversized position stops
+// growing, repeated failures stop new entries, and a drifting clock halts.
+func applyRiskActionDefaults(actions *RiskActionsConfig) {
+	defaults := []struct {
+		action *string
+		value  string
+	}{
+		{&actions.MaxNotional, "hedge_only"},
+		{&actions.MaxOpenOrders, "halt"},
+		{&actions.MinMarginRatio, "flatten"},
+		{&actions.MinHealthRatio, "flatten"},
+		{&actions.MaxDelta, "flatten"},
+		{&actions.DailyLoss, "flatten"},
+		{&actions.ConsecutiveFailures, "block_entry"},
+		{&actions.ClockDrift, "halt"},
+		{&actions.LiquidationDistance, "flatten"},
+	}
+	for _, d := range defaults {
+		*d.action = strings.ToLower(strings.TrimSpace(*d.action))
+		if *d.action == "" {
+			*d.action = d.value
+		}
+	}
+}
+
+// deriveMaxTickAge leaves room for a tick that runs to its timeout before
+// the next one starts, so liveness only fails on a tick that ignores it.
+func deriveMaxTickAge(entryInterval, tickTimeout time.Duration, eventLoop EventLoopConfig) time.Duration {
+	interval := entryInterval
+	if eventLoop.Enabled && eventLoop.MaxIdle > interval {
+		interval = eventLoop.MaxIdle
+	}
+	return maxDuration(scaleDuration(interval, 5), 2*time.Minute, tickTimeout+interval)
+}
+
+// deriveTickTimeout allows a maker wait plus a few IOC legs and a rollback.
+func deriveTickTimeout(entryTimeout, makerTimeout time.Duration) time.Duration {
+	return maxDuration(makerTimeout+scaleDuration(entryTimeout, 6), 90*time.Second)
+}
+
 func deriveMaxMarketAge(entryInterval, pingInterval time.Duration) time.Duration {
 	return maxDuration(
 		scaleDuration(entryInterval, 4),
@@ -459,6 +1835,13 @@ func deriveMaxAccountAge(entryInterval, pingInterval, spotReconcileInterval time
 	)
 }
 
+func deriveScheduleCancelWindow(entryInterval time.Duration) time.Duration {
+	return maxDuration(
+		scaleDuration(entryInterval, 3),
+		defaultScheduleCancelWindow,
+	)
+}
+
 func scaleDuration(value time.Duration, multiplier int) time.Duration {
 	if value <= 0 || multiplier <= 0 {
 		return 0
@@ -475,3 +1858,90 @@ func maxDuration(values ...time.Duration) time.Duration {
 	}
 	return max
 }
+
+func validateAccounts(accounts []AccountConfig) error {
+	names := make(map[string]struct{}, len(accounts))
+	vaults := make(map[string]struct{}, len(accounts))
+	for _, acct := range accounts {
+		if !validAccountName(acct.Name) {
+			return errors.New("accounts.name is required and may only contain a-z, 0-9, '-' and '_'")
+		}
+		if _, ok := names[acct.Name]; ok {
+			return errors.New("accounts.name must be unique")
+		}
+		names[acct.Name] = struct{}{}
+		if acct.VaultAddress != "" {
+			if !validHexAddress(acct.VaultAddress) {
+				return errors.New("accounts.vault_address must be a 0x-prefixed 20-byte hex address")
+			}
+			vault := strings.ToLower(acct.VaultAddress)
+			if _, ok := vaults[vault]; ok {
+				return errors.New("accounts.vault_address must be unique")
+			}
+			vaults[vault] = struct{}{}
+		}
+		if acct.NotionalUSD <= 0 {
+			return errors.New("accounts.notional_usd must be > 0")
+		}
+	}
+	return nil
+}
+
+func validAccountName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func validHexAddress(addr string) bool {
+	if len(addr) != 42 || !strings.HasPrefix(addr, "0x") {
+		return false
+	}
+	for _, r := range addr[2:] {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') && (r < 'A' || r > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func validateShadow(s ShadowConfig) error {
+	if s.FeeBps != nil && *s.FeeBps < 0 {
+		return errors.New("shadow.fee_bps must be >= 0")
+	}
+	if s.SlippageBps != nil && *s.SlippageBps < 0 {
+		return errors.New("shadow.slippage_bps must be >= 0")
+	}
+	if s.CarryBufferUSD != nil && *s.CarryBufferUSD < 0 {
+		return errors.New("shadow.carry_buffer_usd must be >= 0")
+	}
+	if s.MaxVenueFundingPremium != nil && *s.MaxVenueFundingPremium < 0 {
+		return errors.New("shadow.max_venue_funding_premium must be >= 0")
+	}
+	if s.FundingConfirmations != nil && *s.FundingConfirmations < 1 {
+		return errors.New("shadow.funding_confirmations must be >= 1")
+	}
+	if s.FundingDipConfirmations != nil && *s.FundingDipConfirmations < 1 {
+		return errors.New("shadow.funding_dip_confirmations must be >= 1")
+	}
+	return nil
+}
+
+func validateLogFile(section string, f LogFileConfig) error {
+	if f.MaxSizeMB < 0 {
+		return errors.New(section + ".max_size_mb must be >= 0")
+	}
+	if f.MaxAge < 0 {
+		return errors.New(section + ".max_age must be >= 0")
+	}
+	if f.MaxBackups < 0 {
+		return errors.New(section + ".max_backups must be >= 0")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Reload merges next into current for a running bot. Strategy thresholds,
+// sizing and cooldowns, every risk setting, and the telegram alert settings
+// take effect on the next tick; anything wired up at startup (assets, keys,
+// endpoints, stores, loops and their intervals) must stay the same, and a
+// change to it rejects the whole reload. current is not modified.
+func Reload(current, next *Config) (*Config, error) {
+	if current == nil || next == nil {
+		return nil, errors.New("reload requires the current and next config")
+	}
+	merged := *current
+	merged.Strategy = reloadStrategy(current.Strategy, next.Strategy)
+	merged.Risk = next.Risk
+	merged.Telegram = reloadTelegram(current.Telegram, next.Telegram)
+	if changed := ChangedFields(&merged, next); len(changed) > 0 {
+		return nil, fmt.Errorf("config reload changes fields that require a restart: %s", strings.Join(changed, ", "))
+	}
+	return &merged, nil
+}
+
+// reloadStrategy takes next except for the fields the market data feeds,
+// basis tracker and strategy loops were started with.
+func reloadStrategy(current, next StrategyConfig) StrategyConfig {
+	out := next
+	out.Asset = current.Asset
+	out.PerpAsset = current.PerpAsset
+	out.SpotAsset = current.SpotAsset
+	out.EntryInterval = current.EntryInterval
+	out.SpotReconcileInterval = current.SpotReconcileInterval
+	out.CandleInterval = current.CandleInterval
+	out.CandleWindow = current.CandleWindow
+	out.VolatilityEstimator = current.VolatilityEstimator
+	out.VolatilityEWMALambda = current.VolatilityEWMALambda
+	out.RealizedVolWindow = current.RealizedVolWindow
+	out.TradeFlowWindow = current.TradeFlowWindow
+	out.BasisWindow = current.BasisWindow
+	return out
+}
+
+// reloadTelegram takes the alert settings, limits and funding report from
+// next. The operator loop keeps the token, chat and users it was started
+// with, so those stay fixed while operator commands are enabled.
+func reloadTelegram(current, next TelegramConfig) TelegramConfig {
+	out := current
+	out.Enabled = next.Enabled
+	out.DedupWindow = next.DedupWindow
+	out.MaxPerMinute = next.MaxPerMinute
+	out.FundingReport = next.FundingReport
+	out.FundingReportLead = next.FundingReportLead
+	if !current.OperatorEnabled {
+		out.Token = next.Token
+		out.ChatID = next.ChatID
+	}
+	return out
+}
+
+// ChangedFields lists the yaml paths of the settings that differ between a
+// and b, e.g. "strategy.entry_cooldown".
+func ChangedFields(a, b *Config) []string {
+	if a == nil || b == nil {
+		return nil
+	}
+	return changedFields("", reflect.ValueOf(*a), reflect.ValueOf(*b))
+}
+
+// changedFields lists the yaml paths where a and b differ, descending into
+// nested config structs.
+func changedFields(prefix string, a, b reflect.Value) []string {
+	if a.Kind() != reflect.Struct {
+		if reflect.DeepEqual(a.Interface(), b.Interface()) {
+			return nil
+		}
+		return []string{prefix}
+	}
+	var out []string
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		out = append(out, changedFields(name, a.Field(i), b.Field(i))...)
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func reloadTestConfig() *Config {
+	cfg := &Config{
+		Strategy: StrategyConfig{PerpAsset: "ETH", SpotAsset: "UETH", NotionalUSD: 100},
+		Telegram: TelegramConfig{Enabled: true, Token: "a", ChatID: "1"},
+	}
+	applyDefaults(cfg)
+	return cfg
+}
+
+func TestReloadAppliesSafeFields(t *testing.T) {
+	current := reloadTestConfig()
+	next := reloadTestConfig()
+	next.Strategy.MinFundingRate = 0.0002
+	next.Strategy.EntryCooldown = 10 * time.Minute
+	next.Risk.MinMarginRatio = 0.5
+	next.Telegram.Token = "b"
+
+	merged, err := Reload(current, next)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if merged.Strategy.MinFundingRate != 0.0002 || merged.Strategy.EntryCooldown != 10*time.Minute {
+		t.Fatalf("expected strategy thresholds applied, got %+v", merged.Strategy)
+	}
+	if merged.Risk.MinMarginRatio != 0.5 || merged.Telegram.Token != "b" {
+		t.Fatalf("expected risk and telegram applied, got %+v %+v", merged.Risk, merged.Telegram)
+	}
+	if current.Strategy.MinFundingRate == 0.0002 {
+		t.Fatalf("expected current config untouched")
+	}
+	changed := ChangedFields(current, merged)
+	want := []string{"strategy.min_funding_rate", "strategy.entry_cooldown", "risk.min_margin_ratio", "telegram.token"}
+	if strings.Join(changed, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected changed %v, got %v", want, changed)
+	}
+}
+
+func TestReloadRejectsRestartOnlyFields(t *testing.T) {
+	current := reloadTestConfig()
+	next := reloadTestConfig()
+	next.Strategy.PerpAsset = "BTC"
+	next.REST.BaseURL = "https://api.hyperliquid-testnet.xyz"
+	next.Strategy.MinFundingRate = 0.0002
+	_, err := Reload(current, next)
+	if err == nil {
+		t.Fatalf("expected restart-only changes rejected")
+	}
+	for _, field := range []string{"strategy.perp_asset", "rest.base_url"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Fatalf("expected %s named in %v", field, err)
+		}
+	}
+	if strings.Contains(err.Error(), "min_funding_rate") {
+		t.Fatalf("expected safe fields not reported, got %v", err)
+	}
+
+	current.Telegram.OperatorEnabled = true
+	next = reloadTestConfig()
+	next.Telegram.OperatorEnabled = true
+	next.Telegram.ChatID = "2"
+	if _, err := Reload(current, next); err == nil || !strings.Contains(err.Error(), "telegram.chat_id") {
+		t.Fatalf("expected chat_id fixed while operator commands run, got %v", err)
+	}
+}
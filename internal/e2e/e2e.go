@@ -0,0 +1,474 @@
+// Package e2e drives a tiny carry round trip (spot buy + perp short entry, a
+// delta hedge, and the exit) against the Hyperliquid testnet with real
+// signing, nonce persistence, and fill tracking. cmd/e2e runs it directly;
+// `go test -tags e2e ./internal/e2e` runs it as a test.
+package e2e
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/account"
+	"hl-carry-bot/internal/exec"
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/market"
+	"hl-carry-bot/internal/state/sqlite"
+
+	"go.uber.org/zap"
+)
+
+const (
+	TestnetURL = "https://api.hyperliquid-testnet.xyz"
+
+	// defaultNotionalUSD clears the exchange's 10 USDC minimum order value
+	// after rounding and slippage.
+	defaultNotionalUSD = 12.0
+	defaultSlippageBps = 50.0
+	defaultFillTimeout = 20 * time.Second
+	defaultPerpAsset   = "HYPE"
+	defaultSpotAsset   = "HYPE"
+	restTimeout        = 10 * time.Second
+	fillPollInterval   = time.Second
+)
+
+// ErrMissingCredentials is returned by ConfigFromEnv when no testnet key is
+// configured; the test suite skips on it.
+var ErrMissingCredentials = errors.New("HL_E2E_PRIVATE_KEY and HL_E2E_WALLET_ADDRESS are required")
+
+// Config is one e2e run. StorePath is the SQLite file nonces persist to;
+// empty uses a temporary file removed after the run.
+type Config struct {
+	BaseURL     string
+	PrivateKey  string
+	Wallet      string
+	PerpAsset   string
+	SpotAsset   string
+	NotionalUSD float64
+	SlippageBps float64
+	FillTimeout time.Duration
+	StorePath   string
+}
+
+// ConfigFromEnv reads HL_E2E_* variables, defaulting to the testnet URL, the
+// HYPE pair, and a 12 USDC notional.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		BaseURL:     envOr("HL_E2E_BASE_URL", TestnetURL),
+		PrivateKey:  strings.TrimSpace(os.Getenv("HL_E2E_PRIVATE_KEY")),
+		Wallet:      strings.TrimSpace(os.Getenv("HL_E2E_WALLET_ADDRESS")),
+		PerpAsset:   envOr("HL_E2E_PERP_ASSET", defaultPerpAsset),
+		SpotAsset:   envOr("HL_E2E_SPOT_ASSET", defaultSpotAsset),
+		NotionalUSD: defaultNotionalUSD,
+		SlippageBps: defaultSlippageBps,
+		FillTimeout: defaultFillTimeout,
+		StorePath:   strings.TrimSpace(os.Getenv("HL_E2E_STORE")),
+	}
+	if cfg.PrivateKey == "" || cfg.Wallet == "" {
+		return cfg, ErrMissingCredentials
+	}
+	if raw := strings.TrimSpace(os.Getenv("HL_E2E_NOTIONAL_USD")); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid HL_E2E_NOTIONAL_USD: %w", err)
+		}
+		cfg.NotionalUSD = v
+	}
+	if raw := strings.TrimSpace(os.Getenv("HL_E2E_SLIPPAGE_BPS")); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid HL_E2E_SLIPPAGE_BPS: %w", err)
+		}
+		cfg.SlippageBps = v
+	}
+	if raw := strings.TrimSpace(os.Getenv("HL_E2E_FILL_TIMEOUT")); raw != "" {
+		v, err := time.ParseDuration(raw)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid HL_E2E_FILL_TIMEOUT: %w", err)
+		}
+		cfg.FillTimeout = v
+	}
+	return cfg, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// validate refuses anything but the testnet: the run trades real orders.
+func (c Config) validate() error {
+	if !strings.Contains(strings.ToLower(c.BaseURL), "testnet") {
+		return fmt.Errorf("e2e runs only against the testnet, got base URL %s", c.BaseURL)
+	}
+	if c.PrivateKey == "" || c.Wallet == "" {
+		return ErrMissingCredentials
+	}
+	if c.PerpAsset == "" || c.SpotAsset == "" {
+		return errors.New("perp and spot assets are required")
+	}
+	if c.NotionalUSD <= 0 || c.SlippageBps <= 0 || c.FillTimeout <= 0 {
+		return errors.New("notional, slippage, and fill timeout must be > 0")
+	}
+	return nil
+}
+
+// Step is one order the run placed. Tracked reports that userFillsByTime
+// returned fills for the order adding up to Filled.
+type Step struct {
+	Name     string  `json:"name"`
+	OrderID  string  `json:"order_id,omitempty"`
+	Size     float64 `json:"size"`
+	Filled   float64 `json:"filled"`
+	AvgPrice float64 `json:"avg_price"`
+	Tracked  bool    `json:"tracked"`
+	Skipped  string  `json:"skipped,omitempty"`
+}
+
+// Report summarizes a run.
+type Report struct {
+	Wallet        string  `json:"wallet"`
+	Steps         []Step  `json:"steps"`
+	NonceStart    uint64  `json:"nonce_start"`
+	NonceEnd      uint64  `json:"nonce_end"`
+	NonceReseeded uint64  `json:"nonce_reseeded"`
+	FinalSpot     float64 `json:"final_spot"`
+	FinalPerp     float64 `json:"final_perp"`
+}
+
+type runner struct {
+	cfg      Config
+	log      *zap.Logger
+	exchange *exchange.Client
+	market   *market.MarketData
+	account  *account.Account
+	perpCtx  market.PerpContext
+	spotCtx  market.SpotContext
+	perpID   int
+	spotID   int
+}
+
+// Run places the round trip and verifies it. Every order is IOC and the run
+// refuses to start unless the account is flat on both legs; on a failure
+// after entry it still attempts the exit.
+func Run(ctx context.Context, cfg Config, log *zap.Logger) (Report, error) {
+	if err := cfg.validate(); err != nil {
+		return Report{}, err
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	signer, err := exchange.NewSigner(cfg.PrivateKey, false)
+	if err != nil {
+		return Report{}, err
+	}
+	if !strings.EqualFold(cfg.Wallet, signer.Address().Hex()) {
+		return Report{}, fmt.Errorf("wallet address does not match private key: got %s expected %s", cfg.Wallet, signer.Address().Hex())
+	}
+	storePath := cfg.StorePath
+	if storePath == "" {
+		dir, err := os.MkdirTemp("", "hl-e2e-")
+		if err != nil {
+			return Report{}, err
+		}
+		defer os.RemoveAll(dir)
+		storePath = filepath.Join(dir, "e2e.db")
+	}
+	store, err := sqlite.New(storePath)
+	if err != nil {
+		return Report{}, err
+	}
+	defer store.Close()
+
+	exClient, err := exchange.NewClient(cfg.BaseURL, restTimeout, signer, "")
+	if err != nil {
+		return Report{}, err
+	}
+	exClient.SetLogger(log)
+	if err := exClient.InitNonceStore(ctx, store); err != nil {
+		return Report{}, err
+	}
+	report := Report{Wallet: cfg.Wallet}
+	if nonce, ok := exClient.NonceState(); ok {
+		report.NonceStart = nonce.Last
+	}
+
+	restClient := rest.New(cfg.BaseURL, restTimeout, log)
+	r := &runner{
+		cfg:      cfg,
+		log:      log,
+		exchange: exClient,
+		market:   market.New(restClient, nil, log),
+		account:  account.New(restClient, nil, log, cfg.Wallet),
+	}
+	if err := r.resolveAssets(ctx); err != nil {
+		return report, err
+	}
+	spot, perp, err := r.positions(ctx)
+	if err != nil {
+		return report, err
+	}
+	spotMid, err := r.mid(ctx, true)
+	if err != nil {
+		return report, err
+	}
+	if spot*spotMid >= 1 || perp != 0 {
+		return report, fmt.Errorf("account is not flat: spot %.8f perp %.8f", spot, perp)
+	}
+
+	startMS := time.Now().Add(-time.Second).UnixMilli()
+	runErr := r.entryAndHedge(ctx, &report)
+	exitErr := r.exit(ctx, &report)
+	if err := errors.Join(runErr, exitErr); err != nil {
+		return report, err
+	}
+	if err := r.trackFills(ctx, startMS, report.Steps); err != nil {
+		return report, err
+	}
+
+	nonce, ok := exClient.NonceState()
+	if !ok || nonce.Persisted < nonce.Last {
+		return report, fmt.Errorf("nonce not persisted: %+v", nonce)
+	}
+	report.NonceEnd = nonce.Last
+	// A fresh client on the same store must seed past every nonce used.
+	reseeded, err := exchange.NewClient(cfg.BaseURL, restTimeout, signer, "")
+	if err != nil {
+		return report, err
+	}
+	if err := reseeded.InitNonceStore(ctx, store); err != nil {
+		return report, err
+	}
+	if state, ok := reseeded.NonceState(); ok {
+		report.NonceReseeded = state.Last
+	}
+	if report.NonceReseeded < report.NonceEnd {
+		return report, fmt.Errorf("reseeded nonce %d is behind last used %d", report.NonceReseeded, report.NonceEnd)
+	}
+
+	report.FinalSpot, report.FinalPerp, err = r.positions(ctx)
+	if err != nil {
+		return report, err
+	}
+	if lot := r.spotCtx.Increments.LotSize(); report.FinalPerp != 0 || (lot > 0 && report.FinalSpot >= lot) {
+		return report, fmt.Errorf("account not flat after exit: spot %.8f perp %.8f", report.FinalSpot, report.FinalPerp)
+	}
+	return report, nil
+}
+
+func (r *runner) resolveAssets(ctx context.Context) error {
+	if err := r.market.RefreshContexts(ctx); err != nil {
+		return err
+	}
+	perpCtx, ok := r.market.PerpContext(r.cfg.PerpAsset)
+	if !ok {
+		return fmt.Errorf("perp asset not found for %s", r.cfg.PerpAsset)
+	}
+	spotCtx, ok := r.market.ResolveSpot(r.cfg.SpotAsset)
+	if !ok {
+		return fmt.Errorf("spot asset not found for %s", r.cfg.SpotAsset)
+	}
+	spotID, ok := r.market.SpotAssetID(spotCtx.Symbol)
+	if !ok {
+		return fmt.Errorf("spot asset id not found for %s", spotCtx.Symbol)
+	}
+	r.perpCtx, r.spotCtx = perpCtx, spotCtx
+	r.perpID, r.spotID = perpCtx.Index, spotID
+	return nil
+}
+
+func (r *runner) positions(ctx context.Context) (float64, float64, error) {
+	state, err := r.account.Reconcile(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return state.SpotBalances[r.spotCtx.Base], state.PerpPosition[r.cfg.PerpAsset], nil
+}
+
+func (r *runner) mid(ctx context.Context, spot bool) (float64, error) {
+	if !spot {
+		return r.market.Mid(ctx, r.cfg.PerpAsset)
+	}
+	for _, key := range []string{r.spotCtx.MidKey, r.spotCtx.Symbol} {
+		if key == "" {
+			continue
+		}
+		if mid, err := r.market.Mid(ctx, key); err == nil {
+			return mid, nil
+		}
+	}
+	return 0, errors.New("spot mid price not found")
+}
+
+func (r *runner) entryAndHedge(ctx context.Context, report *Report) error {
+	spotMid, err := r.mid(ctx, true)
+	if err != nil {
+		return err
+	}
+	spotStep, err := r.place(ctx, "entry_spot", true, true, r.cfg.NotionalUSD/spotMid, spotMid, false)
+	report.Steps = append(report.Steps, spotStep)
+	if err != nil {
+		return err
+	}
+	if spotStep.Filled <= 0 {
+		return errors.New("spot entry did not fill")
+	}
+	perpMid, err := r.mid(ctx, false)
+	if err != nil {
+		return err
+	}
+	perpStep, err := r.place(ctx, "entry_perp", false, false, spotStep.Filled, perpMid, false)
+	report.Steps = append(report.Steps, perpStep)
+	if err != nil {
+		return err
+	}
+	if perpStep.Filled <= 0 {
+		return errors.New("perp entry did not fill")
+	}
+
+	spot, perp, err := r.positions(ctx)
+	if err != nil {
+		return err
+	}
+	delta := spot + perp
+	size := r.perpCtx.Increments.RoundSize(math.Abs(delta))
+	if size <= 0 {
+		report.Steps = append(report.Steps, Step{Name: "hedge", Skipped: fmt.Sprintf("delta %.8f below lot size", delta)})
+		return nil
+	}
+	hedgeStep, err := r.place(ctx, "hedge", false, delta < 0, size, perpMid, false)
+	report.Steps = append(report.Steps, hedgeStep)
+	return err
+}
+
+func (r *runner) exit(ctx context.Context, report *Report) error {
+	spot, perp, err := r.positions(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	if size := r.spotCtx.Increments.RoundSize(spot); size > 0 {
+		spotMid, err := r.mid(ctx, true)
+		if err != nil {
+			return err
+		}
+		step, err := r.place(ctx, "exit_spot", true, false, size, spotMid, false)
+		report.Steps = append(report.Steps, step)
+		errs = append(errs, err)
+	}
+	if perp != 0 {
+		perpMid, err := r.mid(ctx, false)
+		if err != nil {
+			return err
+		}
+		step, err := r.place(ctx, "exit_perp", false, perp < 0, math.Abs(perp), perpMid, true)
+		report.Steps = append(report.Steps, step)
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// place sends one IOC order priced SlippageBps through mid and reads its
+// fill from the order response.
+func (r *runner) place(ctx context.Context, name string, spot, isBuy bool, size, mid float64, reduceOnly bool) (Step, error) {
+	inc, asset := r.perpCtx.Increments, r.perpID
+	if spot {
+		inc, asset = r.spotCtx.Increments, r.spotID
+	}
+	step := Step{Name: name, Size: inc.RoundSize(size)}
+	if step.Size <= 0 {
+		return step, fmt.Errorf("%s: size rounds to zero", name)
+	}
+	price := inc.RoundPrice(exec.OffsetPrice(mid, isBuy, r.cfg.SlippageBps))
+	cloid, err := newCloid()
+	if err != nil {
+		return step, err
+	}
+	wire, err := exchange.LimitOrderWire(asset, isBuy, step.Size, price, reduceOnly, exchange.TifIoc, cloid)
+	if err != nil {
+		return step, err
+	}
+	resp, err := r.exchange.PlaceOrder(ctx, wire)
+	if err != nil {
+		return step, fmt.Errorf("%s: %w", name, err)
+	}
+	results, err := exchange.OrderResults(resp)
+	if err != nil {
+		return step, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(results) != 1 {
+		return step, fmt.Errorf("%s: expected 1 order status, got %d", name, len(results))
+	}
+	result := results[0]
+	if result.Status == exchange.OrderStatusRejected {
+		return step, fmt.Errorf("%s: %w", name, result.Err)
+	}
+	step.OrderID, step.Filled, step.AvgPrice = result.OrderID, result.FilledSize, result.AvgPrice
+	r.log.Info("e2e order",
+		zap.String("step", name),
+		zap.String("status", string(result.Status)),
+		zap.String("order_id", step.OrderID),
+		zap.Float64("size", step.Size),
+		zap.Float64("limit", price),
+		zap.Float64("filled", step.Filled),
+		zap.Float64("avg_price", step.AvgPrice),
+	)
+	return step, nil
+}
+
+// trackFills polls userFillsByTime until every filled step's fills add up to
+// its filled size.
+func (r *runner) trackFills(ctx context.Context, startMS int64, steps []Step) error {
+	deadline := time.Now().Add(r.cfg.FillTimeout)
+	for {
+		fills, err := r.account.UserFillsByTime(ctx, startMS, 0)
+		if err != nil {
+			return err
+		}
+		byOrder := make(map[string]float64)
+		for _, fill := range fills {
+			byOrder[fill.OrderID] += fill.Size
+		}
+		missing := ""
+		for i := range steps {
+			step := &steps[i]
+			if step.OrderID == "" || step.Filled <= 0 {
+				continue
+			}
+			step.Tracked = math.Abs(byOrder[step.OrderID]-step.Filled) <= 1e-9*math.Max(1, step.Filled)
+			if !step.Tracked && missing == "" {
+				missing = step.Name
+			}
+		}
+		if missing == "" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("fills for %s not tracked within %s", missing, r.cfg.FillTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(fillPollInterval):
+		}
+	}
+}
+
+func newCloid() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,47 @@
+package e2e
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("HL_E2E_PRIVATE_KEY", "")
+	t.Setenv("HL_E2E_WALLET_ADDRESS", "")
+	if _, err := ConfigFromEnv(); !errors.Is(err, ErrMissingCredentials) {
+		t.Fatalf("expected ErrMissingCredentials, got %v", err)
+	}
+
+	t.Setenv("HL_E2E_PRIVATE_KEY", "0xabc")
+	t.Setenv("HL_E2E_WALLET_ADDRESS", "0xdef")
+	t.Setenv("HL_E2E_NOTIONAL_USD", "15")
+	t.Setenv("HL_E2E_FILL_TIMEOUT", "30s")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("config from env: %v", err)
+	}
+	if cfg.BaseURL != TestnetURL || cfg.NotionalUSD != 15 || cfg.FillTimeout != 30*time.Second || cfg.PerpAsset != defaultPerpAsset {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+}
+
+func TestValidateRefusesMainnet(t *testing.T) {
+	cfg := Config{
+		BaseURL:     "https://api.hyperliquid.xyz",
+		PrivateKey:  "0xabc",
+		Wallet:      "0xdef",
+		PerpAsset:   "HYPE",
+		SpotAsset:   "HYPE",
+		NotionalUSD: 12,
+		SlippageBps: 50,
+		FillTimeout: time.Second,
+	}
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "testnet") {
+		t.Fatalf("expected mainnet refusal, got %v", err)
+	}
+}
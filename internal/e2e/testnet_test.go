@@ -0,0 +1,38 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+// TestTestnetRoundTrip trades a tiny entry, hedge, and exit on the testnet.
+// Run with HL_E2E_PRIVATE_KEY and HL_E2E_WALLET_ADDRESS set:
+//
+//	go test -tags e2e -count=1 -v ./internal/e2e
+func TestTestnetRoundTrip(t *testing.T) {
+	cfg, err := ConfigFromEnv()
+	if errors.Is(err, ErrMissingCredentials) {
+		t.Skip("testnet credentials not set")
+	}
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+	report, err := Run(ctx, cfg, zaptest.NewLogger(t))
+	for _, step := range report.Steps {
+		t.Logf("%s: order %s size %.8f filled %.8f @ %.6f tracked=%t %s", step.Name, step.OrderID, step.Size, step.Filled, step.AvgPrice, step.Tracked, step.Skipped)
+	}
+	if err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+	if report.NonceEnd <= report.NonceStart {
+		t.Fatalf("expected nonces to advance, start %d end %d", report.NonceStart, report.NonceEnd)
+	}
+}
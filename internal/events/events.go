@@ -0,0 +1,203 @@
+// Package events is a small in-process pub/sub that carries typed updates
+// between subsystems: market publishes mid and predicted funding changes,
+// account publishes fills, position and margin changes, and external
+// deposits and withdrawals, and the app publishes funding payments and
+// strategy state changes.
+// Publishing never blocks; a subscriber whose buffer is full misses the
+// event and the bus counts the drop.
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindMidUpdated       Kind = "mid_updated"
+	KindFillReceived     Kind = "fill_received"
+	KindPositionChanged  Kind = "position_changed"
+	KindFundingPaid      Kind = "funding_paid"
+	KindMarginChanged    Kind = "margin_changed"
+	KindFundingForecast  Kind = "funding_forecast"
+	KindExternalTransfer Kind = "external_transfer"
+	KindStateChanged     Kind = "state_changed"
+)
+
+// Event is one update on the bus. Subscribers switch on the concrete type.
+type Event interface {
+	Kind() Kind
+}
+
+// MidUpdated reports a changed mid price from the allMids stream.
+type MidUpdated struct {
+	Asset string
+	Mid   float64
+	Time  time.Time
+}
+
+// FillReceived reports a fill that was not part of a stream snapshot.
+type FillReceived struct {
+	OrderID   string
+	Cloid     string
+	TradeID   string
+	Hash      string
+	Asset     string
+	Side      string
+	Size      float64
+	Price     float64
+	Fee       float64
+	FeeToken  string
+	ClosedPnL float64
+	Time      time.Time
+}
+
+// PositionChanged reports a perp position size change; Size is 0 when the
+// position closed.
+type PositionChanged struct {
+	Asset    string
+	Previous float64
+	Size     float64
+	Time     time.Time
+}
+
+// FundingPaid reports a funding payment booked to the account. A positive
+// Amount was received.
+type FundingPaid struct {
+	Asset  string
+	Amount float64
+	Rate   float64
+	Time   time.Time
+}
+
+// MarginChanged reports a new perp margin summary.
+type MarginChanged struct {
+	AccountValue   float64
+	MarginRatio    float64
+	HealthRatio    float64
+	HasMarginRatio bool
+	HasHealthRatio bool
+	Time           time.Time
+}
+
+// FundingForecastUpdated reports a changed predicted funding rate or next
+// funding time for an asset.
+type FundingForecastUpdated struct {
+	Asset       string
+	Rate        float64
+	NextFunding time.Time
+	Time        time.Time
+}
+
+// ExternalTransfer reports money moved into or out of the account by
+// someone other than the bot: a deposit, a withdrawal, or a transfer to or
+// from another address. A positive Amount came in.
+type ExternalTransfer struct {
+	Type     string
+	Asset    string
+	Amount   float64
+	USDValue float64
+	Hash     string
+	Time     time.Time
+}
+
+// StateChanged reports a strategy state transition. Reason is the event
+// that caused it, or "set" when the state was restored.
+type StateChanged struct {
+	From   string
+	To     string
+	Reason string
+	Time   time.Time
+}
+
+func (MidUpdated) Kind() Kind             { return KindMidUpdated }
+func (FillReceived) Kind() Kind           { return KindFillReceived }
+func (PositionChanged) Kind() Kind        { return KindPositionChanged }
+func (FundingPaid) Kind() Kind            { return KindFundingPaid }
+func (MarginChanged) Kind() Kind          { return KindMarginChanged }
+func (FundingForecastUpdated) Kind() Kind { return KindFundingForecast }
+func (ExternalTransfer) Kind() Kind       { return KindExternalTransfer }
+func (StateChanged) Kind() Kind           { return KindStateChanged }
+
+// DefaultBuffer is the subscription buffer used when Subscribe is given a
+// non-positive size.
+const DefaultBuffer = 64
+
+type subscription struct {
+	ch    chan Event
+	kinds map[Kind]bool
+}
+
+// Bus fans events out to subscribers. The zero value is not usable; a nil
+// *Bus accepts and discards publishes so subsystems can run without one.
+type Bus struct {
+	mu      sync.RWMutex
+	subs    map[uint64]*subscription
+	nextID  uint64
+	dropped atomic.Uint64
+}
+
+func New() *Bus {
+	return &Bus{subs: make(map[uint64]*subscription)}
+}
+
+// Subscribe returns a channel receiving events of the given kinds, or all
+// kinds when none are given, and a function that unsubscribes and closes
+// the channel.
+func (b *Bus) Subscribe(buffer int, kinds ...Kind) (<-chan Event, func()) {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	sub := &subscription{ch: make(chan Event, buffer)}
+	if len(kinds) > 0 {
+		sub.kinds = make(map[Kind]bool, len(kinds))
+		for _, kind := range kinds {
+			sub.kinds[kind] = true
+		}
+	}
+	b.mu.Lock()
+	b.nextID++
+	id := b.nextID
+	b.subs[id] = sub
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, id)
+			b.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// Publish delivers ev to every matching subscriber without blocking.
+func (b *Bus) Publish(ev Event) {
+	if b == nil || ev == nil {
+		return
+	}
+	kind := ev.Kind()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, sub := range b.subs {
+		if sub.kinds != nil && !sub.kinds[kind] {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped counts deliveries skipped because a subscriber was full.
+func (b *Bus) Dropped() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.dropped.Load()
+}
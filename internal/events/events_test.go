@@ -0,0 +1,54 @@
+package events
+
+import "testing"
+
+func TestBusFiltersByKind(t *testing.T) {
+	bus := New()
+	fills, unsubFills := bus.Subscribe(4, KindFillReceived)
+	defer unsubFills()
+	all, unsubAll := bus.Subscribe(4)
+	defer unsubAll()
+
+	bus.Publish(MidUpdated{Asset: "ETH", Mid: 3000})
+	bus.Publish(FillReceived{OrderID: "42", Size: 0.1})
+
+	select {
+	case ev := <-fills:
+		if fill, ok := ev.(FillReceived); !ok || fill.OrderID != "42" {
+			t.Fatalf("expected fill 42, got %#v", ev)
+		}
+	default:
+		t.Fatalf("expected a fill event")
+	}
+	if len(fills) != 0 {
+		t.Fatalf("expected the mid update to be filtered out")
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 events for the unfiltered subscriber, got %d", len(all))
+	}
+}
+
+func TestBusDropsWhenFull(t *testing.T) {
+	bus := New()
+	ch, unsubscribe := bus.Subscribe(1)
+	bus.Publish(MidUpdated{Asset: "ETH"})
+	bus.Publish(MidUpdated{Asset: "BTC"})
+	if got := bus.Dropped(); got != 1 {
+		t.Fatalf("expected 1 dropped event, got %d", got)
+	}
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-ch; !ok {
+		t.Fatalf("expected the buffered event before close")
+	}
+	if _, ok := <-ch; ok {
+		t.Fatalf("expected channel closed after unsubscribe")
+	}
+	bus.Publish(MidUpdated{Asset: "ETH"})
+
+	var nilBus *Bus
+	nilBus.Publish(MidUpdated{Asset: "ETH"})
+	if nilBus.Dropped() != 0 {
+		t.Fatalf("expected nil bus to drop nothing")
+	}
+}
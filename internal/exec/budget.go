@@ -0,0 +1,128 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOrderBudgetExceeded is returned (permanent, not sent to the exchange)
+// when an asset already has its maximum of in-flight and resting orders.
+var ErrOrderBudgetExceeded = errors.New("order budget exceeded")
+
+// restingOrder is an order the exchange answered as resting (or waiting for
+// its trigger) that has not been seen filled or cancelled since.
+type restingOrder struct {
+	asset    int
+	placedAt time.Time
+}
+
+// SetOrderBudget caps the orders per asset this executor has in flight
+// (sent, not yet answered) plus resting; zero or less disables the cap.
+// Orders are tracked either way, so a cap set later counts them.
+func (e *Executor) SetOrderBudget(maxPerAsset int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.budget = maxPerAsset
+}
+
+// OpenOrderCount returns the orders on asset that are in flight or resting.
+func (e *Executor) OpenOrderCount(asset int) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.openOrdersLocked(asset)
+}
+
+// CheckOrderBudget returns ErrOrderBudgetExceeded when any of assets has no
+// room for one more order, so a multi-leg action can be skipped before its
+// first leg is sent.
+func (e *Executor) CheckOrderBudget(assets ...int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, asset := range assets {
+		if err := e.budgetErrLocked(asset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncOpenOrders drops tracked resting orders placed before asOf that are
+// missing from open, the order ids the account listed at asOf: they filled,
+// fired or were cancelled outside this executor. Orders placed after asOf
+// may not be listed yet and are kept.
+func (e *Executor) SyncOpenOrders(open []string, asOf time.Time) {
+	listed := make(map[string]struct{}, len(open))
+	for _, orderID := range open {
+		listed[orderID] = struct{}{}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for orderID, order := range e.resting {
+		if _, ok := listed[orderID]; ok || order.placedAt.After(asOf) {
+			continue
+		}
+		delete(e.resting, orderID)
+	}
+}
+
+func (e *Executor) openOrdersLocked(asset int) int {
+	count := e.inFlight[asset]
+	for _, order := range e.resting {
+		if order.asset == asset {
+			count++
+		}
+	}
+	return count
+}
+
+func (e *Executor) budgetErrLocked(asset int) error {
+	if e.budget <= 0 {
+		return nil
+	}
+	if open := e.openOrdersLocked(asset); open >= e.budget {
+		return Permanent(fmt.Errorf("asset %d has %d of %d orders open: %w", asset, open, e.budget, ErrOrderBudgetExceeded))
+	}
+	return nil
+}
+
+// reserveOrder counts an order on asset as in flight, or refuses it when the
+// asset's budget is used up.
+func (e *Executor) reserveOrder(asset int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.budgetErrLocked(asset); err != nil {
+		return err
+	}
+	if e.inFlight == nil {
+		e.inFlight = make(map[int]int)
+	}
+	e.inFlight[asset]++
+	return nil
+}
+
+// releaseOrder ends an in-flight order; one the exchange left on the book
+// (any reported status but filled) stays counted as resting until it is
+// cancelled or synced away.
+func (e *Executor) releaseOrder(asset int, placement Placement, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.inFlight[asset] <= 1 {
+		delete(e.inFlight, asset)
+	} else {
+		e.inFlight[asset]--
+	}
+	if err != nil || placement.OrderID == "" || placement.Status == "" || placement.Filled() {
+		return
+	}
+	if e.resting == nil {
+		e.resting = make(map[string]restingOrder)
+	}
+	e.resting[placement.OrderID] = restingOrder{asset: asset, placedAt: e.now()}
+}
+
+func (e *Executor) forgetOrder(orderID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.resting, orderID)
+}
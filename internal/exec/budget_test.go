@@ -0,0 +1,91 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestOrderBudgetCapsRestingOrdersPerAsset(t *testing.T) {
+	rest := &mockRest{}
+	exec := New(rest, nil, zap.NewNop())
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	exec.now = func() time.Time { return now }
+	exec.SetOrderBudget(2)
+	ctx := context.Background()
+	order := Order{Asset: 7, Size: 1, LimitPrice: 1}
+
+	for _, oid := range []string{"1", "2"} {
+		rest.orderID = oid
+		if _, err := exec.PlaceOrder(ctx, order); err != nil {
+			t.Fatalf("expected order %s within budget, got %v", oid, err)
+		}
+	}
+	if got := exec.OpenOrderCount(7); got != 2 {
+		t.Fatalf("expected 2 resting orders, got %d", got)
+	}
+	calls := rest.calls
+	_, err := exec.PlaceOrder(ctx, order)
+	if !errors.Is(err, ErrOrderBudgetExceeded) || rest.calls != calls {
+		t.Fatalf("expected budget refusal without an exchange call, got %v", err)
+	}
+	if err := exec.CheckOrderBudget(8, 7); !errors.Is(err, ErrOrderBudgetExceeded) {
+		t.Fatalf("expected CheckOrderBudget to refuse asset 7, got %v", err)
+	}
+	rest.orderID = "3"
+	if _, err := exec.PlaceOrder(ctx, Order{Asset: 8, Size: 1, LimitPrice: 1}); err != nil {
+		t.Fatalf("expected other assets unaffected, got %v", err)
+	}
+
+	if err := exec.CancelOrder(ctx, Cancel{Asset: 7, OrderID: "1"}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if got := exec.OpenOrderCount(7); got != 1 {
+		t.Fatalf("expected cancel to free a slot, got %d open", got)
+	}
+
+	// Order 2 filled outside the executor; a snapshot taken after it was
+	// placed no longer lists it.
+	now = now.Add(time.Second)
+	rest.orderID = "4"
+	if _, err := exec.PlaceOrder(ctx, order); err != nil {
+		t.Fatalf("expected order after cancel, got %v", err)
+	}
+	exec.SyncOpenOrders([]string{"3"}, now.Add(-time.Millisecond))
+	if got := exec.OpenOrderCount(7); got != 1 {
+		t.Fatalf("expected sync to drop order 2 but keep the newer order 4, got %d open", got)
+	}
+	if got := exec.OpenOrderCount(8); got != 1 {
+		t.Fatalf("expected listed order 3 kept, got %d open", got)
+	}
+
+	exec.SetOrderBudget(0)
+	if err := exec.CheckOrderBudget(7); err != nil {
+		t.Fatalf("expected a zero budget to disable the cap, got %v", err)
+	}
+}
+
+func TestOrderBudgetIgnoresFailedOrders(t *testing.T) {
+	rest := &mockRest{orderID: "1", err: Permanent(errors.New("insufficient margin"))}
+	exec := New(rest, nil, zap.NewNop())
+	exec.SetOrderBudget(1)
+	ctx := context.Background()
+	order := Order{Asset: 7, Size: 1, LimitPrice: 1}
+
+	if _, err := exec.PlaceOrder(ctx, order); err == nil {
+		t.Fatalf("expected placement error")
+	}
+	if got := exec.OpenOrderCount(7); got != 0 {
+		t.Fatalf("expected a failed order not counted, got %d", got)
+	}
+	rest.err = nil
+	if _, err := exec.PlaceOrder(ctx, order); err != nil {
+		t.Fatalf("expected order within budget, got %v", err)
+	}
+	if _, err := exec.PlaceOrder(ctx, order); !errors.Is(err, ErrOrderBudgetExceeded) {
+		t.Fatalf("expected resting order to use the budget, got %v", err)
+	}
+}
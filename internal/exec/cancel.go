@@ -0,0 +1,80 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"hl-carry-bot/internal/logging"
+	"hl-carry-bot/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// BatchCanceler is implemented by a RestClient that can cancel several
+// orders in one signed action. CancelOrders returns one error per cancel,
+// nil for an order that was cancelled.
+type BatchCanceler interface {
+	CancelOrders(ctx context.Context, cancels []Cancel) ([]error, error)
+}
+
+// CancelAll cancels orderIDs on asset. With a BatchCanceler they go out in a
+// single action, so the orders leave the book together instead of one round
+// trip at a time; otherwise each is cancelled in turn. Orders the exchange
+// no longer has are dropped from the budget along with the cancelled ones.
+// The returned error joins the per-order failures.
+func (e *Executor) CancelAll(ctx context.Context, asset int, orderIDs []string) (err error) {
+	if len(orderIDs) == 0 {
+		return nil
+	}
+	ctx, span := tracer.Start(ctx, "cancel_all", trace.WithAttributes(
+		attribute.Int("asset", asset),
+		attribute.Int("orders", len(orderIDs)),
+	))
+	defer func() { tracing.End(span, err) }()
+	cancels := make([]Cancel, len(orderIDs))
+	for i, orderID := range orderIDs {
+		cancels[i] = Cancel{Asset: asset, OrderID: orderID}
+	}
+	batch, ok := e.rest.(BatchCanceler)
+	if !ok {
+		var errs []error
+		for _, cancel := range cancels {
+			if err := e.CancelOrder(ctx, cancel); err != nil {
+				errs = append(errs, fmt.Errorf("order %s: %w", cancel.OrderID, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	var results []error
+	err = e.retry(ctx, func() error {
+		var err error
+		results, err = batch.CancelOrders(ctx, cancels)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(results) != len(cancels) {
+		return fmt.Errorf("cancel response has %d statuses for %d orders", len(results), len(cancels))
+	}
+	var errs []error
+	for i, cancel := range cancels {
+		if results[i] != nil {
+			e.forgetOrder(cancel.OrderID)
+			errs = append(errs, fmt.Errorf("order %s: %w", cancel.OrderID, results[i]))
+			continue
+		}
+		e.cancelled(cancel)
+	}
+	if e.log != nil {
+		e.log.Info("asset orders cancelled", logging.Audit(),
+			zap.Int("asset", asset),
+			zap.Int("orders", len(cancels)),
+			zap.Int("failed", len(errs)),
+		)
+	}
+	return errors.Join(errs...)
+}
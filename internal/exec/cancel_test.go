@@ -0,0 +1,75 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// batchRest cancels in one call and reports the given order as unknown.
+type batchRest struct {
+	mockRest
+	batches [][]Cancel
+	missing string
+}
+
+func (b *batchRest) CancelOrders(_ context.Context, cancels []Cancel) ([]error, error) {
+	b.batches = append(b.batches, cancels)
+	results := make([]error, len(cancels))
+	for i, cancel := range cancels {
+		if cancel.OrderID == b.missing {
+			results[i] = errors.New("Order was never placed, already canceled, or filled.")
+		}
+	}
+	return results, nil
+}
+
+func TestCancelAllSendsOneAction(t *testing.T) {
+	rest := &batchRest{missing: "2"}
+	exec := New(rest, nil, zap.NewNop())
+	var cancelled []string
+	exec.SetOrderObserver(func(event OrderEvent) {
+		if event.Event == OrderCancelled {
+			cancelled = append(cancelled, event.OrderID)
+		}
+	})
+	ctx := context.Background()
+	for _, oid := range []string{"1", "2", "3"} {
+		rest.orderID = oid
+		if _, err := exec.PlaceOrder(ctx, Order{Asset: 5, Size: 1, LimitPrice: 1}); err != nil {
+			t.Fatalf("place %s: %v", oid, err)
+		}
+	}
+
+	err := exec.CancelAll(ctx, 5, []string{"1", "2", "3"})
+	if err == nil {
+		t.Fatalf("expected the unknown order reported")
+	}
+	if len(rest.batches) != 1 || len(rest.batches[0]) != 3 {
+		t.Fatalf("expected one action with 3 cancels, got %v", rest.batches)
+	}
+	if len(cancelled) != 2 || cancelled[0] != "1" || cancelled[1] != "3" {
+		t.Fatalf("expected orders 1 and 3 observed cancelled, got %v", cancelled)
+	}
+	if got := exec.OpenOrderCount(5); got != 0 {
+		t.Fatalf("expected every order dropped from the budget, got %d open", got)
+	}
+}
+
+func TestCancelAllFallsBackToSingleCancels(t *testing.T) {
+	exec := New(&mockRest{}, nil, zap.NewNop())
+	var cancelled int
+	exec.SetOrderObserver(func(event OrderEvent) {
+		if event.Event == OrderCancelled {
+			cancelled++
+		}
+	})
+	if err := exec.CancelAll(context.Background(), 5, []string{"1", "2"}); err != nil {
+		t.Fatalf("cancel all: %v", err)
+	}
+	if cancelled != 2 {
+		t.Fatalf("expected 2 cancels, got %d", cancelled)
+	}
+}
@@ -0,0 +1,110 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCircuitOpen is returned (permanent, not sent to the exchange) while an
+// asset's circuit is open after repeated order failures.
+var ErrCircuitOpen = errors.New("order circuit open")
+
+// CircuitEvent describes a circuit that just opened.
+type CircuitEvent struct {
+	Asset     int
+	Failures  int
+	OpenUntil time.Time
+	Err       error
+}
+
+// CircuitBreaker opens an asset's circuit after MaxFailures failed orders
+// within Window and rejects orders on it for CoolOff. OnOpen, if set, is
+// called once per opening.
+type CircuitBreaker struct {
+	MaxFailures int
+	Window      time.Duration
+	CoolOff     time.Duration
+	OnOpen      func(ctx context.Context, event CircuitEvent)
+}
+
+type circuitState struct {
+	failures  []time.Time
+	openUntil time.Time
+}
+
+// SetCircuitBreaker enables per-asset circuit breaking; a zero MaxFailures
+// disables it.
+func (e *Executor) SetCircuitBreaker(cb CircuitBreaker) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.breaker = cb
+	e.circuits = make(map[int]*circuitState)
+}
+
+// CircuitOpen reports whether orders on asset are blocked, and until when.
+func (e *Executor) CircuitOpen(asset int) (time.Time, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	state, ok := e.circuits[asset]
+	if !ok || !e.now().Before(state.openUntil) {
+		return time.Time{}, false
+	}
+	return state.openUntil, true
+}
+
+func (e *Executor) checkCircuit(asset int) error {
+	if until, open := e.CircuitOpen(asset); open {
+		return Permanent(fmt.Errorf("asset %d until %s: %w", asset, until.Format(time.RFC3339), ErrCircuitOpen))
+	}
+	return nil
+}
+
+// recordOrderResult counts a failed order against its asset's circuit and
+// clears the count on success. Post-only crosses and cancellations are not
+// exchange failures and are ignored.
+func (e *Executor) recordOrderResult(ctx context.Context, asset int, err error) {
+	if err != nil && (errors.Is(err, ErrWouldCross) || errors.Is(err, ErrCircuitOpen) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return
+	}
+	e.mu.Lock()
+	cb := e.breaker
+	if cb.MaxFailures <= 0 || e.circuits == nil {
+		e.mu.Unlock()
+		return
+	}
+	state, ok := e.circuits[asset]
+	if err == nil {
+		if ok {
+			state.failures = nil
+		}
+		e.mu.Unlock()
+		return
+	}
+	if !ok {
+		state = &circuitState{}
+		e.circuits[asset] = state
+	}
+	now := e.now()
+	cutoff := now.Add(-cb.Window)
+	kept := state.failures[:0]
+	for _, at := range state.failures {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	state.failures = append(kept, now)
+	if len(state.failures) < cb.MaxFailures {
+		e.mu.Unlock()
+		return
+	}
+	event := CircuitEvent{Asset: asset, Failures: len(state.failures), OpenUntil: now.Add(cb.CoolOff), Err: err}
+	state.openUntil = event.OpenUntil
+	state.failures = nil
+	e.mu.Unlock()
+	if cb.OnOpen != nil {
+		cb.OnOpen(ctx, event)
+	}
+}
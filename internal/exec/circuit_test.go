@@ -0,0 +1,84 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestCircuitOpensAfterRepeatedFailures(t *testing.T) {
+	rest := &mockRest{err: Permanent(errors.New("insufficient margin"))}
+	exec := New(rest, nil, zap.NewNop())
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	exec.now = func() time.Time { return now }
+	var events []CircuitEvent
+	exec.SetCircuitBreaker(CircuitBreaker{
+		MaxFailures: 3,
+		Window:      time.Minute,
+		CoolOff:     10 * time.Minute,
+		OnOpen:      func(_ context.Context, event CircuitEvent) { events = append(events, event) },
+	})
+	ctx := context.Background()
+	order := Order{Asset: 7, Size: 1, LimitPrice: 1}
+
+	for i := 0; i < 2; i++ {
+		_, _ = exec.PlaceOrder(ctx, order)
+		now = now.Add(40 * time.Second)
+	}
+	// The first failure has aged out of the window.
+	_, _ = exec.PlaceOrder(ctx, order)
+	if _, open := exec.CircuitOpen(7); open || len(events) != 0 {
+		t.Fatalf("expected circuit closed with failures spread past the window")
+	}
+	_, _ = exec.PlaceOrder(ctx, order)
+	until, open := exec.CircuitOpen(7)
+	if !open || len(events) != 1 || events[0].Failures != 3 || !until.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("expected circuit open for 10m, got open=%t events=%+v", open, events)
+	}
+
+	calls := rest.calls
+	_, err := exec.PlaceOrder(ctx, order)
+	if !errors.Is(err, ErrCircuitOpen) || rest.calls != calls {
+		t.Fatalf("expected open circuit to block without an exchange call, got %v", err)
+	}
+	if _, err := exec.PlaceOrder(ctx, Order{Asset: 8, Size: 1, LimitPrice: 1}); errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected other assets unaffected")
+	}
+
+	now = now.Add(10 * time.Minute)
+	rest.err = nil
+	rest.orderID = "1"
+	if _, err := exec.PlaceOrder(ctx, order); err != nil {
+		t.Fatalf("expected order after cool-off, got %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected a single open event, got %d", len(events))
+	}
+}
+
+func TestCircuitIgnoresPostOnlyCrossesAndResetsOnSuccess(t *testing.T) {
+	rest := &mockRest{err: Permanent(ErrWouldCross)}
+	exec := New(rest, nil, zap.NewNop())
+	exec.SetCircuitBreaker(CircuitBreaker{MaxFailures: 2, Window: time.Minute, CoolOff: time.Minute})
+	ctx := context.Background()
+	order := Order{Asset: 1, Size: 1, LimitPrice: 1}
+	for i := 0; i < 3; i++ {
+		_, _ = exec.PlaceOrder(ctx, order)
+	}
+	if _, open := exec.CircuitOpen(1); open {
+		t.Fatalf("expected post-only crosses not to open the circuit")
+	}
+
+	rest.err = Permanent(errors.New("rejected"))
+	_, _ = exec.PlaceOrder(ctx, order)
+	rest.err, rest.orderID = nil, "2"
+	_, _ = exec.PlaceOrder(ctx, order)
+	rest.err = Permanent(errors.New("rejected"))
+	_, _ = exec.PlaceOrder(ctx, order)
+	if _, open := exec.CircuitOpen(1); open {
+		t.Fatalf("expected a success to reset the failure count")
+	}
+}
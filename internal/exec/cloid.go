@@ -0,0 +1,104 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"hl-carry-bot/internal/logging"
+
+	"go.uber.org/zap"
+)
+
+// ErrOrderUnresolved is returned when a cloid order failed ambiguously (the
+// exchange may have accepted it) and the lookup could not settle whether it
+// did. The order is not resent; its cloid stays pending in the store.
+var ErrOrderUnresolved = errors.New("order outcome unresolved")
+
+// ErrAlreadyProcessed is returned by a RestClient when a resend of an order
+// was refused because the exchange had already processed its nonce, i.e. an
+// earlier attempt most likely landed. A cloid order is looked up instead of
+// being reported as failed.
+var ErrAlreadyProcessed = errors.New("order already processed")
+
+// OrderLookup finds an order on the exchange by the cloid it was sent with.
+// found is false when the exchange shows no trace of it; since is when the
+// order was first sent.
+type OrderLookup interface {
+	LookupCloid(ctx context.Context, cloid string, since time.Time) (placement Placement, found bool, err error)
+}
+
+// SetOrderLookup enables cloid dedupe: a cloid order whose placement failed
+// without a definite answer (a timeout, a dropped connection, a 5xx) is
+// looked up before it is retried, so a retry cannot place it twice.
+func (e *Executor) SetOrderLookup(lookup OrderLookup) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lookup = lookup
+}
+
+func (e *Executor) orderLookup() OrderLookup {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lookup
+}
+
+func pendingKey(cloid string) string {
+	return "cloid-pending:" + cloid
+}
+
+// markPending records in the store that cloid is about to be sent, so a
+// restart mid-send looks it up before placing it again.
+func (e *Executor) markPending(ctx context.Context, cloid string) {
+	if e.store == nil {
+		return
+	}
+	if err := e.store.Set(ctx, pendingKey(cloid), strconv.FormatInt(e.now().UnixMilli(), 10)); err != nil && e.log != nil {
+		e.log.Warn("failed to persist pending cloid", logging.Unsampled(), zap.String("cloid", cloid), zap.Error(err))
+	}
+}
+
+func (e *Executor) clearPending(ctx context.Context, cloid string) {
+	if e.store == nil {
+		return
+	}
+	if err := e.store.Delete(ctx, pendingKey(cloid)); err != nil && e.log != nil {
+		e.log.Warn("failed to clear pending cloid", logging.Unsampled(), zap.String("cloid", cloid), zap.Error(err))
+	}
+}
+
+// pendingSince returns when cloid was last marked pending, if it was.
+func (e *Executor) pendingSince(ctx context.Context, cloid string) (time.Time, bool) {
+	if e.store == nil {
+		return time.Time{}, false
+	}
+	raw, ok, err := e.store.Get(ctx, pendingKey(cloid))
+	if err != nil || !ok {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, true
+	}
+	return time.UnixMilli(ms), true
+}
+
+// resolveCloid looks order up by its cloid. A found order is answered as
+// placed: resting while it is on the book, filled otherwise.
+func (e *Executor) resolveCloid(ctx context.Context, lookup OrderLookup, order Order, since time.Time) (Placement, bool, error) {
+	placement, found, err := lookup.LookupCloid(ctx, order.ClientOrderID, since)
+	if err != nil || !found {
+		return Placement{}, found, err
+	}
+	if e.log != nil {
+		e.log.Warn("order found on the exchange after an ambiguous failure", logging.Audit(),
+			zap.String("cloid", order.ClientOrderID),
+			zap.String("order_id", placement.OrderID),
+			zap.String("status", placement.Status),
+			zap.Float64("filled_size", placement.FilledSize),
+			zap.Int("asset", order.Asset),
+		)
+	}
+	return placement, true, nil
+}
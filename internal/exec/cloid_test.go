@@ -0,0 +1,173 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// flakyRest fails the first failures placements with a timeout after the
+// exchange has already accepted the order.
+type flakyRest struct {
+	mu       sync.Mutex
+	calls    int
+	failures int
+}
+
+func (f *flakyRest) PlaceOrder(context.Context, Order) (Placement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.calls <= f.failures {
+		return Placement{}, context.DeadlineExceeded
+	}
+	return Placement{OrderID: "fresh", Status: PlacementFilled, FilledSize: 1}, nil
+}
+
+func (f *flakyRest) CancelOrder(context.Context, Cancel) error {
+	return nil
+}
+
+type fakeLookup struct {
+	placement Placement
+	found     bool
+	err       error
+	calls     int
+}
+
+func (f *fakeLookup) LookupCloid(context.Context, string, time.Time) (Placement, bool, error) {
+	f.calls++
+	return f.placement, f.found, f.err
+}
+
+func TestAmbiguousFailureResolvedByCloidLookup(t *testing.T) {
+	store := newMemoryStore()
+	rest := &flakyRest{failures: 1}
+	exec := New(rest, store, zap.NewNop())
+	lookup := &fakeLookup{placement: Placement{OrderID: "42", Status: PlacementFilled, FilledSize: 1}, found: true}
+	exec.SetOrderLookup(lookup)
+
+	placement, err := exec.Place(context.Background(), Order{Asset: 1, Size: 1, LimitPrice: 1, ClientOrderID: "0xaa"})
+	if err != nil {
+		t.Fatalf("expected the lookup to resolve the timeout, got %v", err)
+	}
+	if placement.OrderID != "42" || rest.calls != 1 || lookup.calls != 1 {
+		t.Fatalf("expected the found order without a resend, got %+v calls=%d lookups=%d", placement, rest.calls, lookup.calls)
+	}
+	if _, ok := store.data[pendingKey("0xaa")]; ok {
+		t.Fatalf("expected the pending cloid cleared")
+	}
+	if store.data["cloid:0xaa"] != "42" {
+		t.Fatalf("expected the resolved oid cached, got %q", store.data["cloid:0xaa"])
+	}
+}
+
+func TestAmbiguousFailureResentWhenCloidUnknown(t *testing.T) {
+	rest := &flakyRest{failures: 1}
+	exec := New(rest, newMemoryStore(), zap.NewNop())
+	lookup := &fakeLookup{}
+	exec.SetOrderLookup(lookup)
+
+	placement, err := exec.Place(context.Background(), Order{Asset: 1, Size: 1, LimitPrice: 1, ClientOrderID: "0xaa"})
+	if err != nil || placement.OrderID != "fresh" {
+		t.Fatalf("expected the order resent, got %+v err=%v", placement, err)
+	}
+	if rest.calls != 2 || lookup.calls != 1 {
+		t.Fatalf("expected one lookup before the resend, got calls=%d lookups=%d", rest.calls, lookup.calls)
+	}
+}
+
+func TestUnresolvedCloidIsNotResent(t *testing.T) {
+	store := newMemoryStore()
+	rest := &flakyRest{failures: 1}
+	exec := New(rest, store, zap.NewNop())
+	lookup := &fakeLookup{err: errors.New("info unavailable")}
+	exec.SetOrderLookup(lookup)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	_, err := exec.Place(ctx, Order{Asset: 1, Size: 1, LimitPrice: 1, ClientOrderID: "0xaa"})
+	if !errors.Is(err, ErrOrderUnresolved) {
+		t.Fatalf("expected an unresolved outcome, got %v", err)
+	}
+	if rest.calls != 1 {
+		t.Fatalf("expected no resend while the lookup fails, got %d sends", rest.calls)
+	}
+	if _, ok := store.data[pendingKey("0xaa")]; !ok {
+		t.Fatalf("expected the cloid kept pending")
+	}
+
+	// A later run replaying the cloid finds it on the exchange.
+	lookup.err = nil
+	lookup.found = true
+	lookup.placement = Placement{OrderID: "42", Status: PlacementResting}
+	placement, err := New(rest, store, zap.NewNop()).withLookup(lookup).Place(context.Background(), Order{Asset: 1, Size: 1, LimitPrice: 1, ClientOrderID: "0xaa"})
+	if err != nil || placement.OrderID != "42" || rest.calls != 1 {
+		t.Fatalf("expected the pending cloid resolved without a send, got %+v err=%v sends=%d", placement, err, rest.calls)
+	}
+	if _, ok := store.data[pendingKey("0xaa")]; ok {
+		t.Fatalf("expected the pending cloid cleared once resolved")
+	}
+}
+
+func (e *Executor) withLookup(lookup OrderLookup) *Executor {
+	e.SetOrderLookup(lookup)
+	return e
+}
+
+// processedRest answers like a client whose internal resend hit a nonce the
+// exchange had already processed.
+type processedRest struct {
+	calls int
+}
+
+func (p *processedRest) PlaceOrder(context.Context, Order) (Placement, error) {
+	p.calls++
+	return Placement{}, Permanent(ErrAlreadyProcessed)
+}
+
+func (p *processedRest) CancelOrder(context.Context, Cancel) error {
+	return nil
+}
+
+func TestAlreadyProcessedNonceResolvedByCloidLookup(t *testing.T) {
+	store := newMemoryStore()
+	rest := &processedRest{}
+	exec := New(rest, store, zap.NewNop())
+	lookup := &fakeLookup{placement: Placement{OrderID: "42", Status: PlacementFilled, FilledSize: 1}, found: true}
+	exec.SetOrderLookup(lookup)
+
+	placement, err := exec.Place(context.Background(), Order{Asset: 1, Size: 1, LimitPrice: 1, ClientOrderID: "0xaa"})
+	if err != nil {
+		t.Fatalf("expected the lookup to resolve the processed nonce, got %v", err)
+	}
+	if placement.OrderID != "42" || !placement.Filled() || rest.calls != 1 || lookup.calls != 1 {
+		t.Fatalf("expected the filled order without a resend, got %+v calls=%d lookups=%d", placement, rest.calls, lookup.calls)
+	}
+	if store.data["cloid:0xaa"] != "42" {
+		t.Fatalf("expected the resolved oid cached, got %q", store.data["cloid:0xaa"])
+	}
+}
+
+func TestAlreadyProcessedNonceKeepsCloidPendingWhenNotFound(t *testing.T) {
+	store := newMemoryStore()
+	rest := &processedRest{}
+	exec := New(rest, store, zap.NewNop())
+	lookup := &fakeLookup{}
+	exec.SetOrderLookup(lookup)
+
+	_, err := exec.Place(context.Background(), Order{Asset: 1, Size: 1, LimitPrice: 1, ClientOrderID: "0xaa"})
+	if !errors.Is(err, ErrOrderUnresolved) {
+		t.Fatalf("expected an unresolved outcome, got %v", err)
+	}
+	if rest.calls != 1 {
+		t.Fatalf("expected no resend after a processed nonce, got %d sends", rest.calls)
+	}
+	if _, ok := store.data[pendingKey("0xaa")]; !ok {
+		t.Fatalf("expected the cloid kept pending")
+	}
+}
@@ -7,11 +7,19 @@ import (
 	"sync"
 	"time"
 
+	"hl-carry-bot/internal/logging"
 	"hl-carry-bot/internal/state"
+	"hl-carry-bot/internal/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// Order is one limit order. Tif is "Gtc", "Ioc", or "Alo" (post-only);
+// empty uses the client's default. A positive TriggerPrice makes it a
+// stop-market trigger order instead (Tpsl "sl" or "tp"); LimitPrice then
+// bounds slippage once it fires and Tif is ignored.
 type Order struct {
 	Asset         int
 	IsBuy         bool
@@ -20,15 +28,66 @@ type Order struct {
 	ReduceOnly    bool
 	ClientOrderID string
 	Tif           string
+	TriggerPrice  float64
+	Tpsl          string
 }
 
+// ErrWouldCross is returned (permanent, not retried) when a post-only order
+// is rejected because it would have matched on arrival.
+var ErrWouldCross = errors.New("post-only order would cross")
+
+// Cancel is one order to cancel. Asset 0 is a real asset (the BTC perp); a
+// negative Asset is an unresolved one.
 type Cancel struct {
 	Asset   int
 	OrderID string
 }
 
+// Permanent marks err as unsafe to retry, e.g. when the exchange may already
+// have accepted the action.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (p permanentError) Error() string {
+	return p.err.Error()
+}
+
+func (p permanentError) Unwrap() error {
+	return p.err
+}
+
+// Placement is the exchange's immediate answer to an order. Status is
+// PlacementResting or PlacementFilled; a filled order reports its matched
+// size and average price. Filled is final: an IOC order never rests, and a
+// resting remainder would have been reported as resting instead.
+type Placement struct {
+	OrderID    string
+	Status     string
+	FilledSize float64
+	AvgPrice   float64
+}
+
+// Placement statuses. An order answered from the cloid cache has no status.
+const (
+	PlacementResting = "resting"
+	PlacementFilled  = "filled"
+)
+
+// Filled reports whether the order matched on arrival.
+func (p Placement) Filled() bool {
+	return p.Status == PlacementFilled
+}
+
 type RestClient interface {
-	PlaceOrder(ctx context.Context, order Order) (string, error)
+	PlaceOrder(ctx context.Context, order Order) (Placement, error)
 	CancelOrder(ctx context.Context, cancel Cancel) error
 }
 
@@ -37,84 +96,297 @@ type Executor struct {
 	store state.Store
 	log   *zap.Logger
 
-	mu    sync.Mutex
-	cache map[string]string
+	mu         sync.Mutex
+	cache      map[string]string
+	owned      map[string]struct{}
+	ownedOrder []string
+	breaker    CircuitBreaker
+	lookup     OrderLookup
+	circuits   map[int]*circuitState
+	budget     int
+	inFlight   map[int]int
+	resting    map[string]restingOrder
+	observer   func(OrderEvent)
+	now        func() time.Time
+
+	reference        ReferencePrice
+	slippageWindow   int
+	slippageRefs     map[string]slippageRef
+	slippageRefOrder []string
+	slippage         map[int][]slippageFill
 }
 
+// maxOwnedIDs bounds the in-memory set of cloids/oids placed by this
+// instance; older cloids are still recognized through the store.
+const maxOwnedIDs = 4096
+
 func New(rest RestClient, store state.Store, log *zap.Logger) *Executor {
 	return &Executor{
 		rest:  rest,
 		store: store,
 		log:   log,
 		cache: make(map[string]string),
+		owned: make(map[string]struct{}),
+		now:   time.Now,
+	}
+}
+
+// Owns reports whether this executor placed the order, by oid or cloid.
+// Cloids are registered before the order is sent, so an update that races
+// the placement response is still recognized by its cloid.
+func (e *Executor) Owns(ctx context.Context, orderID, cloid string) bool {
+	e.mu.Lock()
+	if orderID != "" {
+		if _, ok := e.owned["oid:"+orderID]; ok {
+			e.mu.Unlock()
+			return true
+		}
+	}
+	if cloid != "" {
+		if _, ok := e.owned["cloid:"+cloid]; ok {
+			e.mu.Unlock()
+			return true
+		}
+	}
+	e.mu.Unlock()
+	if cloid == "" || e.store == nil {
+		return false
+	}
+	_, ok, err := e.store.Get(ctx, "cloid:"+cloid)
+	return err == nil && ok
+}
+
+func (e *Executor) markOwned(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.owned[key]; ok {
+		return
+	}
+	e.owned[key] = struct{}{}
+	e.ownedOrder = append(e.ownedOrder, key)
+	if len(e.ownedOrder) > maxOwnedIDs {
+		for _, old := range e.ownedOrder[:len(e.ownedOrder)-maxOwnedIDs] {
+			delete(e.owned, old)
+		}
+		e.ownedOrder = append([]string(nil), e.ownedOrder[len(e.ownedOrder)-maxOwnedIDs:]...)
 	}
 }
 
 func (e *Executor) PlaceOrder(ctx context.Context, order Order) (string, error) {
+	placement, err := e.Place(ctx, order)
+	return placement.OrderID, err
+}
+
+// Place is PlaceOrder returning the exchange's full answer. A cloid already
+// placed is answered from the cache with its oid alone.
+func (e *Executor) Place(ctx context.Context, order Order) (placement Placement, err error) {
+	ctx, span := tracer.Start(ctx, "order_submit", trace.WithAttributes(orderAttributes(order)...))
+	defer func() {
+		span.SetAttributes(
+			attribute.String("order_id", placement.OrderID),
+			attribute.String("status", placement.Status),
+			attribute.Float64("filled", placement.FilledSize),
+		)
+		tracing.End(span, err)
+	}()
+	return e.place(ctx, order)
+}
+
+func (e *Executor) place(ctx context.Context, order Order) (Placement, error) {
 	if order.ClientOrderID == "" {
 		return e.placeWithRetry(ctx, order)
 	}
 	cacheKey := "cloid:" + order.ClientOrderID
+	e.markOwned(cacheKey)
 	e.mu.Lock()
 	if oid, ok := e.cache[cacheKey]; ok {
 		e.mu.Unlock()
-		return oid, nil
+		return Placement{OrderID: oid}, nil
 	}
 	e.mu.Unlock()
 	if e.store != nil {
 		if oid, ok, err := e.store.Get(ctx, cacheKey); err != nil {
-			return "", err
+			return Placement{}, err
 		} else if ok {
 			e.mu.Lock()
 			e.cache[cacheKey] = oid
 			e.mu.Unlock()
-			return oid, nil
+			e.markOwned("oid:" + oid)
+			return Placement{OrderID: oid}, nil
 		}
 	}
-	orderID, err := e.placeWithRetry(ctx, order)
+	placement, err := e.placeCloid(ctx, order)
 	if err != nil {
-		return "", err
+		return Placement{}, err
 	}
 	if e.store != nil {
-		if err := e.store.Set(ctx, cacheKey, orderID); err != nil {
-			e.log.Warn("failed to persist order id", zap.Error(err))
+		if err := e.store.Set(ctx, cacheKey, placement.OrderID); err != nil {
+			e.log.Warn("failed to persist order id", logging.Unsampled(), zap.Error(err))
 		}
 	}
 	e.mu.Lock()
-	e.cache[cacheKey] = orderID
+	e.cache[cacheKey] = placement.OrderID
 	e.mu.Unlock()
-	return orderID, nil
+	return placement, nil
 }
 
-func (e *Executor) CancelOrder(ctx context.Context, cancel Cancel) error {
-	return e.retry(ctx, func() error {
+func (e *Executor) CancelOrder(ctx context.Context, cancel Cancel) (err error) {
+	ctx, span := tracer.Start(ctx, "order_cancel", trace.WithAttributes(
+		attribute.Int("asset", cancel.Asset),
+		attribute.String("order_id", cancel.OrderID),
+	))
+	defer func() { tracing.End(span, err) }()
+	err = e.retry(ctx, func() error {
 		return e.rest.CancelOrder(ctx, cancel)
 	})
+	if err != nil {
+		return err
+	}
+	e.cancelled(cancel)
+	return nil
 }
 
-func (e *Executor) placeWithRetry(ctx context.Context, order Order) (string, error) {
-	var orderID string
+func (e *Executor) cancelled(cancel Cancel) {
+	e.forgetOrder(cancel.OrderID)
+	if e.log != nil {
+		e.log.Info("order cancelled", logging.Audit(),
+			zap.Int("asset", cancel.Asset),
+			zap.String("order_id", cancel.OrderID),
+		)
+	}
+	e.observe(OrderEvent{Event: OrderCancelled, OrderID: cancel.OrderID, Order: Order{Asset: cancel.Asset}})
+}
+
+// placeCloid places a cloid order, keeping its cloid pending in the store
+// until the outcome is known. A cloid left pending by an earlier run is
+// looked up first and not sent again when the exchange already has it.
+func (e *Executor) placeCloid(ctx context.Context, order Order) (Placement, error) {
+	if lookup := e.orderLookup(); lookup != nil {
+		if since, ok := e.pendingSince(ctx, order.ClientOrderID); ok {
+			placement, found, err := e.resolveCloid(ctx, lookup, order, since)
+			if err != nil {
+				return Placement{}, fmt.Errorf("cloid %s: %w: %v", order.ClientOrderID, ErrOrderUnresolved, err)
+			}
+			if found {
+				e.clearPending(ctx, order.ClientOrderID)
+				e.markOwned("oid:" + placement.OrderID)
+				return placement, nil
+			}
+		}
+	}
+	e.markPending(ctx, order.ClientOrderID)
+	placement, err := e.placeWithRetry(ctx, order)
+	if !errors.Is(err, ErrOrderUnresolved) {
+		e.clearPending(ctx, order.ClientOrderID)
+	}
+	return placement, err
+}
+
+func (e *Executor) placeWithRetry(ctx context.Context, order Order) (Placement, error) {
+	if err := e.checkCircuit(order.Asset); err != nil {
+		return Placement{}, err
+	}
+	if err := e.reserveOrder(order.Asset); err != nil {
+		return Placement{}, err
+	}
+	ref, tracked := e.placementReference(ctx, order)
+	if tracked && order.ClientOrderID != "" {
+		// Fills can arrive before the placement response; they carry the cloid.
+		e.trackReference("cloid:"+order.ClientOrderID, ref)
+	}
+	lookup := e.orderLookup()
+	if order.ClientOrderID == "" {
+		lookup = nil
+	}
+	since := e.now()
+	var placement Placement
+	ambiguous := false
 	err := e.retry(ctx, func() error {
+		if ambiguous {
+			resolved, found, err := e.resolveCloid(ctx, lookup, order, since)
+			if err != nil {
+				return err
+			}
+			if found {
+				placement = resolved
+				return nil
+			}
+		}
 		var err error
-		orderID, err = e.rest.PlaceOrder(ctx, order)
+		placement, err = e.rest.PlaceOrder(ctx, order)
+		var perm permanentError
+		ambiguous = lookup != nil && err != nil && (!errors.As(err, &perm) || errors.Is(err, ErrAlreadyProcessed))
 		return err
 	})
+	if err != nil && ambiguous && errors.Is(err, ErrAlreadyProcessed) {
+		// The nonce landed, so the order is not resent; only a lookup can
+		// settle it.
+		if resolved, found, lookupErr := e.resolveCloid(ctx, lookup, order, since); lookupErr == nil && found {
+			placement, err = resolved, nil
+		}
+	}
+	if err != nil && ambiguous {
+		err = Permanent(fmt.Errorf("cloid %s: %w: %v", order.ClientOrderID, ErrOrderUnresolved, err))
+	}
+	e.releaseOrder(order.Asset, placement, err)
+	orderID := placement.OrderID
+	if err == nil && orderID == "" {
+		err = errors.New("empty order id")
+	}
+	e.recordOrderResult(ctx, order.Asset, err)
+	if reason := RejectReason(err); reason != "" {
+		if e.log != nil {
+			e.log.Warn("order rejected", logging.Audit(),
+				zap.String("reason", reason),
+				zap.Int("asset", order.Asset),
+				zap.Bool("is_buy", order.IsBuy),
+				zap.Float64("size", order.Size),
+				zap.Float64("limit", order.LimitPrice),
+				zap.Error(err),
+			)
+		}
+		e.observe(OrderEvent{Event: OrderRejected, Order: order, Reason: reason})
+	}
 	if err != nil {
-		return "", err
+		return Placement{}, err
 	}
-	if orderID == "" {
-		return "", errors.New("empty order id")
+	if e.log != nil {
+		e.log.Info("order placed", logging.Audit(),
+			zap.String("order_id", orderID),
+			zap.String("cloid", order.ClientOrderID),
+			zap.Int("asset", order.Asset),
+			zap.Bool("is_buy", order.IsBuy),
+			zap.Float64("size", order.Size),
+			zap.Float64("limit", order.LimitPrice),
+			zap.String("tif", order.Tif),
+			zap.Bool("reduce_only", order.ReduceOnly),
+			zap.String("status", placement.Status),
+			zap.Float64("filled_size", placement.FilledSize),
+		)
 	}
-	return orderID, nil
+	e.observe(OrderEvent{Event: OrderPlaced, OrderID: orderID, Order: order})
+	e.markOwned("oid:" + orderID)
+	if tracked {
+		e.trackReference("oid:"+orderID, ref)
+	}
+	return placement, nil
 }
 
 func (e *Executor) retry(ctx context.Context, fn func() error) error {
 	backoff := 200 * time.Millisecond
 	for attempt := 0; attempt < 5; attempt++ {
 		if err := fn(); err != nil {
+			var perm permanentError
+			if errors.As(err, &perm) {
+				return err
+			}
 			if attempt == 4 {
 				return fmt.Errorf("retry failed: %w", err)
 			}
+			trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+				attribute.Int("attempt", attempt+1),
+				attribute.String("error", err.Error()),
+			))
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
@@ -2,6 +2,7 @@ package exec
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 
@@ -47,15 +48,19 @@ type mockRest struct {
 	mu      sync.Mutex
 	calls   int
 	orderID string
+	err     error
 }
 
-func (m *mockRest) PlaceOrder(ctx context.Context, order Order) (string, error) {
+func (m *mockRest) PlaceOrder(ctx context.Context, order Order) (Placement, error) {
 	_ = ctx
 	_ = order
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.calls++
-	return m.orderID, nil
+	if m.err != nil {
+		return Placement{}, m.err
+	}
+	return Placement{OrderID: m.orderID, Status: PlacementResting}, nil
 }
 
 func (m *mockRest) CancelOrder(ctx context.Context, cancel Cancel) error {
@@ -101,3 +106,69 @@ func TestExecutorIdempotentPlacement(t *testing.T) {
 		t.Fatalf("expected no rest calls on restart, got %d", rest2.calls)
 	}
 }
+
+func TestExecutorDoesNotRetryPermanentError(t *testing.T) {
+	cause := errors.New("already processed")
+	rest := &mockRest{err: Permanent(cause)}
+	exec := New(rest, nil, zap.NewNop())
+	_, err := exec.PlaceOrder(context.Background(), Order{Asset: 1, Size: 1, LimitPrice: 1})
+	if !errors.Is(err, cause) {
+		t.Fatalf("expected wrapped cause, got %v", err)
+	}
+	if rest.calls != 1 {
+		t.Fatalf("expected 1 call, got %d", rest.calls)
+	}
+}
+
+func TestExecutorOwnsPlacedOrders(t *testing.T) {
+	store := newMemoryStore()
+	rest := &mockRest{orderID: "42"}
+	exec := New(rest, store, zap.NewNop())
+	ctx := context.Background()
+	if _, err := exec.PlaceOrder(ctx, Order{ClientOrderID: "0xabc"}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if !exec.Owns(ctx, "42", "") || !exec.Owns(ctx, "", "0xabc") {
+		t.Fatalf("expected placed order owned by oid and cloid")
+	}
+	if exec.Owns(ctx, "7", "0xdef") {
+		t.Fatalf("expected unknown order not owned")
+	}
+	restarted := New(rest, store, zap.NewNop())
+	if !restarted.Owns(ctx, "", "0xabc") {
+		t.Fatalf("expected persisted cloid owned after restart")
+	}
+	if restarted.Owns(ctx, "42", "") {
+		t.Fatalf("expected oid-only lookup unknown after restart")
+	}
+}
+
+func TestExecutorReportsOrderEvents(t *testing.T) {
+	rest := &mockRest{orderID: "42"}
+	exec := New(rest, nil, zap.NewNop())
+	var got []OrderEvent
+	exec.SetOrderObserver(func(event OrderEvent) { got = append(got, event) })
+	ctx := context.Background()
+	if _, err := exec.PlaceOrder(ctx, Order{Asset: 4, IsBuy: true, Size: 1, LimitPrice: 10, ClientOrderID: "0xabc"}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if err := exec.CancelOrder(ctx, Cancel{Asset: 4, OrderID: "42"}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	rest.err = Permanent(ErrInsufficientMargin)
+	if _, err := exec.PlaceOrder(ctx, Order{Asset: 4, Size: 1, LimitPrice: 10}); err == nil {
+		t.Fatalf("expected rejection")
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 order events, got %+v", got)
+	}
+	if got[0].Event != OrderPlaced || got[0].OrderID != "42" || got[0].Order.ClientOrderID != "0xabc" || got[0].Time.IsZero() {
+		t.Fatalf("unexpected placed event %+v", got[0])
+	}
+	if got[1].Event != OrderCancelled || got[1].OrderID != "42" || got[1].Order.Asset != 4 {
+		t.Fatalf("unexpected cancel event %+v", got[1])
+	}
+	if got[2].Event != OrderRejected || got[2].Reason == "" {
+		t.Fatalf("unexpected rejected event %+v", got[2])
+	}
+}
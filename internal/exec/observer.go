@@ -0,0 +1,41 @@
+package exec
+
+import "time"
+
+// Order events reported to an OrderObserver.
+const (
+	OrderPlaced    = "placed"
+	OrderRejected  = "rejected"
+	OrderCancelled = "cancelled"
+)
+
+// OrderEvent is one order action the executor completed. Order is the
+// order as submitted (for a cancel, only Asset is set) and Reason the
+// exchange rejection reason.
+type OrderEvent struct {
+	Time    time.Time
+	Event   string
+	OrderID string
+	Order   Order
+	Reason  string
+}
+
+// SetOrderObserver registers fn to receive every placed, rejected and
+// cancelled order, e.g. for an execution history. fn runs on the caller's
+// goroutine and must not block.
+func (e *Executor) SetOrderObserver(fn func(OrderEvent)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.observer = fn
+}
+
+func (e *Executor) observe(event OrderEvent) {
+	e.mu.Lock()
+	fn := e.observer
+	e.mu.Unlock()
+	if fn == nil {
+		return
+	}
+	event.Time = e.now().UTC()
+	fn(event)
+}
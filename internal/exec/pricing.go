@@ -0,0 +1,164 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Pricing policy names selectable per leg in the pricing config.
+const (
+	// PricingAggressiveIOC prices offsetBps through the mid.
+	PricingAggressiveIOC = "aggressive_ioc"
+	// PricingMidPeg prices at the mid.
+	PricingMidPeg = "mid_peg"
+	// PricingSpreadCross prices offsetBps through the opposite touch.
+	PricingSpreadCross = "spread_cross"
+	// PricingBookAware prices offsetBps through the level that fills the
+	// whole order size.
+	PricingBookAware = "book_aware"
+)
+
+// ErrNoReferencePrice is returned when a quote carries no usable price.
+var ErrNoReferencePrice = errors.New("no reference price")
+
+// BookLevel is one aggregated price level of an order book.
+type BookLevel struct {
+	Px float64
+	Sz float64
+}
+
+// Quote is the market view a PricingPolicy prices from. Bids and Asks are
+// best first and may be empty when no book was fetched.
+type Quote struct {
+	Mid  float64
+	Bids []BookLevel
+	Asks []BookLevel
+}
+
+// PricingPolicy turns a quote into an order's limit price. The result is not
+// rounded to the exchange tick; callers normalize it.
+type PricingPolicy interface {
+	Name() string
+	// NeedsBook reports whether the policy reads book levels.
+	NeedsBook() bool
+	LimitPrice(q Quote, isBuy bool, size float64) (float64, error)
+}
+
+// PricingPolicyValid reports whether name is a known policy.
+func PricingPolicyValid(name string) bool {
+	switch name {
+	case PricingAggressiveIOC, PricingMidPeg, PricingSpreadCross, PricingBookAware:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewPricingPolicy returns the named policy. offsetBps is how far through
+// the reference price an aggressive, spread-cross, or book-aware order goes.
+func NewPricingPolicy(name string, offsetBps float64) (PricingPolicy, error) {
+	switch name {
+	case PricingAggressiveIOC:
+		return aggressiveIOC{offsetBps: offsetBps}, nil
+	case PricingMidPeg:
+		return midPeg{}, nil
+	case PricingSpreadCross:
+		return spreadCross{offsetBps: offsetBps}, nil
+	case PricingBookAware:
+		return bookAware{offsetBps: offsetBps}, nil
+	default:
+		return nil, fmt.Errorf("unknown pricing policy %q", name)
+	}
+}
+
+// OffsetPrice moves price bps against the taker: up for a buy, down for a
+// sell.
+func OffsetPrice(price float64, isBuy bool, bps float64) float64 {
+	if price <= 0 || bps <= 0 {
+		return price
+	}
+	scale := bps / 10000
+	if isBuy {
+		return price * (1 + scale)
+	}
+	return price * (1 - scale)
+}
+
+type aggressiveIOC struct {
+	offsetBps float64
+}
+
+func (aggressiveIOC) Name() string    { return PricingAggressiveIOC }
+func (aggressiveIOC) NeedsBook() bool { return false }
+
+func (p aggressiveIOC) LimitPrice(q Quote, isBuy bool, _ float64) (float64, error) {
+	if q.Mid <= 0 {
+		return 0, ErrNoReferencePrice
+	}
+	return OffsetPrice(q.Mid, isBuy, p.offsetBps), nil
+}
+
+type midPeg struct{}
+
+func (midPeg) Name() string    { return PricingMidPeg }
+func (midPeg) NeedsBook() bool { return false }
+
+func (midPeg) LimitPrice(q Quote, _ bool, _ float64) (float64, error) {
+	if q.Mid <= 0 {
+		return 0, ErrNoReferencePrice
+	}
+	return q.Mid, nil
+}
+
+// spreadCross takes the opposite touch; without a book it behaves like
+// aggressiveIOC.
+type spreadCross struct {
+	offsetBps float64
+}
+
+func (spreadCross) Name() string    { return PricingSpreadCross }
+func (spreadCross) NeedsBook() bool { return true }
+
+func (p spreadCross) LimitPrice(q Quote, isBuy bool, size float64) (float64, error) {
+	levels := q.Asks
+	if !isBuy {
+		levels = q.Bids
+	}
+	if len(levels) == 0 || levels[0].Px <= 0 {
+		return aggressiveIOC(p).LimitPrice(q, isBuy, size)
+	}
+	return OffsetPrice(levels[0].Px, isBuy, p.offsetBps), nil
+}
+
+// bookAware walks the opposite side until the cumulative size covers the
+// order. A book too thin for the order prices at its deepest level, so an
+// IOC fills what is there; without a book it behaves like aggressiveIOC.
+type bookAware struct {
+	offsetBps float64
+}
+
+func (bookAware) Name() string    { return PricingBookAware }
+func (bookAware) NeedsBook() bool { return true }
+
+func (p bookAware) LimitPrice(q Quote, isBuy bool, size float64) (float64, error) {
+	levels := q.Asks
+	if !isBuy {
+		levels = q.Bids
+	}
+	price := 0.0
+	filled := 0.0
+	for _, level := range levels {
+		if level.Px <= 0 {
+			continue
+		}
+		price = level.Px
+		filled += level.Sz
+		if filled >= size {
+			break
+		}
+	}
+	if price <= 0 {
+		return aggressiveIOC(p).LimitPrice(q, isBuy, size)
+	}
+	return OffsetPrice(price, isBuy, p.offsetBps), nil
+}
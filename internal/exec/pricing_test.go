@@ -0,0 +1,61 @@
+package exec
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestPricingPolicies(t *testing.T) {
+	book := Quote{
+		Mid:  100,
+		Bids: []BookLevel{{Px: 99.9, Sz: 1}, {Px: 99.5, Sz: 2}},
+		Asks: []BookLevel{{Px: 100.1, Sz: 1}, {Px: 100.4, Sz: 2}, {Px: 101, Sz: 5}},
+	}
+	cases := []struct {
+		policy string
+		quote  Quote
+		isBuy  bool
+		size   float64
+		want   float64
+	}{
+		{policy: PricingAggressiveIOC, quote: book, isBuy: true, size: 1, want: 100.1},
+		{policy: PricingAggressiveIOC, quote: book, isBuy: false, size: 1, want: 99.9},
+		{policy: PricingMidPeg, quote: book, isBuy: true, size: 1, want: 100},
+		{policy: PricingSpreadCross, quote: book, isBuy: true, size: 1, want: 100.1 * 1.001},
+		{policy: PricingSpreadCross, quote: book, isBuy: false, size: 1, want: 99.9 * 0.999},
+		{policy: PricingSpreadCross, quote: Quote{Mid: 100}, isBuy: true, size: 1, want: 100.1},
+		{policy: PricingBookAware, quote: book, isBuy: true, size: 2.5, want: 100.4 * 1.001},
+		{policy: PricingBookAware, quote: book, isBuy: true, size: 50, want: 101 * 1.001},
+		{policy: PricingBookAware, quote: book, isBuy: false, size: 0.5, want: 99.9 * 0.999},
+		{policy: PricingBookAware, quote: Quote{Mid: 100}, isBuy: false, size: 1, want: 99.9},
+	}
+	for _, tc := range cases {
+		policy, err := NewPricingPolicy(tc.policy, 10)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.policy, err)
+		}
+		got, err := policy.LimitPrice(tc.quote, tc.isBuy, tc.size)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tc.policy, err)
+		}
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Fatalf("%s buy=%t size=%f: expected %f, got %f", tc.policy, tc.isBuy, tc.size, tc.want, got)
+		}
+	}
+}
+
+func TestPricingPolicyErrors(t *testing.T) {
+	if _, err := NewPricingPolicy("twap", 10); err == nil {
+		t.Fatalf("expected error for unknown policy")
+	}
+	if PricingPolicyValid("twap") || !PricingPolicyValid(PricingBookAware) {
+		t.Fatalf("unexpected policy validity")
+	}
+	for _, name := range []string{PricingAggressiveIOC, PricingMidPeg, PricingSpreadCross, PricingBookAware} {
+		policy, _ := NewPricingPolicy(name, 10)
+		if _, err := policy.LimitPrice(Quote{}, true, 1); !errors.Is(err, ErrNoReferencePrice) {
+			t.Fatalf("%s: expected ErrNoReferencePrice, got %v", name, err)
+		}
+	}
+}
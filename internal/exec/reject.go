@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRejected wraps an order the exchange refused outright. The RestClient
+// returns rejections permanent: resubmitting the same order would only be
+// refused again and burn a nonce.
+var ErrRejected = errors.New("order rejected")
+
+// Rejection classes. Each wraps ErrRejected.
+var (
+	ErrInsufficientMargin = fmt.Errorf("%w: insufficient margin", ErrRejected)
+	ErrInvalidPrice       = fmt.Errorf("%w: invalid price", ErrRejected)
+	ErrInvalidSize        = fmt.Errorf("%w: invalid size", ErrRejected)
+	ErrMinOrderValue      = fmt.Errorf("%w: below minimum value", ErrRejected)
+	ErrReduceOnly         = fmt.Errorf("%w: reduce-only would increase position", ErrRejected)
+	ErrNoImmediateMatch   = fmt.Errorf("%w: ioc did not match", ErrRejected)
+	ErrPriceBand          = fmt.Errorf("%w: price too far from reference", ErrRejected)
+)
+
+// RejectReason names the rejection class of err for logs, or "" when err is
+// not a rejection.
+func RejectReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInsufficientMargin):
+		return "insufficient_margin"
+	case errors.Is(err, ErrInvalidPrice):
+		return "invalid_price"
+	case errors.Is(err, ErrInvalidSize):
+		return "invalid_size"
+	case errors.Is(err, ErrMinOrderValue):
+		return "min_value"
+	case errors.Is(err, ErrReduceOnly):
+		return "reduce_only"
+	case errors.Is(err, ErrNoImmediateMatch):
+		return "no_match"
+	case errors.Is(err, ErrPriceBand):
+		return "price_band"
+	case errors.Is(err, ErrRejected):
+		return "other"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,137 @@
+package exec
+
+import (
+	"context"
+	"math"
+)
+
+// ReferencePrice returns the mid an order on asset is measured against when
+// it is placed; ok is false when no mid is known.
+type ReferencePrice func(ctx context.Context, asset int) (float64, bool)
+
+// DefaultSlippageWindow is the number of fills per asset the realized
+// slippage averages over when SetSlippageTracking is given none.
+const DefaultSlippageWindow = 20
+
+// slippageRef is the placement-time reference of one order.
+type slippageRef struct {
+	asset int
+	isBuy bool
+	mid   float64
+}
+
+type slippageFill struct {
+	bps  float64
+	size float64
+}
+
+// SlippageSample is one fill measured against its order's reference mid.
+// Bps is positive when the fill was worse than the mid.
+type SlippageSample struct {
+	Asset int
+	IsBuy bool
+	Mid   float64
+	Price float64
+	Size  float64
+	Bps   float64
+}
+
+// SetSlippageTracking records ref's mid for every order placed from now on,
+// so RecordFill can measure its fills. window is the number of fills per
+// asset RealizedSlippageBps averages over.
+func (e *Executor) SetSlippageTracking(ref ReferencePrice, window int) {
+	if window <= 0 {
+		window = DefaultSlippageWindow
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reference = ref
+	e.slippageWindow = window
+	if e.slippageRefs == nil {
+		e.slippageRefs = make(map[string]slippageRef)
+	}
+	if e.slippage == nil {
+		e.slippage = make(map[int][]slippageFill)
+	}
+}
+
+// trackReference records the reference mid of order under key ("cloid:..."
+// before the order is sent, "oid:..." once placed).
+func (e *Executor) trackReference(key string, ref slippageRef) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.slippageRefs == nil {
+		return
+	}
+	if _, ok := e.slippageRefs[key]; !ok {
+		e.slippageRefOrder = append(e.slippageRefOrder, key)
+	}
+	e.slippageRefs[key] = ref
+	if len(e.slippageRefOrder) > maxOwnedIDs {
+		for _, old := range e.slippageRefOrder[:len(e.slippageRefOrder)-maxOwnedIDs] {
+			delete(e.slippageRefs, old)
+		}
+		e.slippageRefOrder = append([]string(nil), e.slippageRefOrder[len(e.slippageRefOrder)-maxOwnedIDs:]...)
+	}
+}
+
+// placementReference looks up the reference mid of an order about to be
+// placed; ok is false when tracking is off or no mid is known.
+func (e *Executor) placementReference(ctx context.Context, order Order) (slippageRef, bool) {
+	e.mu.Lock()
+	ref := e.reference
+	e.mu.Unlock()
+	if ref == nil || order.TriggerPrice > 0 {
+		return slippageRef{}, false
+	}
+	mid, ok := ref(ctx, order.Asset)
+	if !ok || mid <= 0 {
+		return slippageRef{}, false
+	}
+	return slippageRef{asset: order.Asset, isBuy: order.IsBuy, mid: mid}, true
+}
+
+// RecordFill measures a fill of an order this executor placed against the
+// order's reference mid and adds it to the asset's rolling window. ok is
+// false for fills of untracked orders.
+func (e *Executor) RecordFill(orderID, cloid string, price, size float64) (SlippageSample, bool) {
+	if price <= 0 || size == 0 {
+		return SlippageSample{}, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ref, ok := e.slippageRefs["oid:"+orderID]
+	if !ok && cloid != "" {
+		ref, ok = e.slippageRefs["cloid:"+cloid]
+	}
+	if !ok {
+		return SlippageSample{}, false
+	}
+	bps := (price - ref.mid) / ref.mid * 10000
+	if !ref.isBuy {
+		bps = -bps
+	}
+	fills := append(e.slippage[ref.asset], slippageFill{bps: bps, size: math.Abs(size)})
+	if len(fills) > e.slippageWindow {
+		fills = fills[len(fills)-e.slippageWindow:]
+	}
+	e.slippage[ref.asset] = fills
+	return SlippageSample{Asset: ref.asset, IsBuy: ref.isBuy, Mid: ref.mid, Price: price, Size: math.Abs(size), Bps: bps}, true
+}
+
+// RealizedSlippageBps is the size-weighted slippage of the asset's recent
+// fills, in bps against the reference mid (positive is adverse).
+func (e *Executor) RealizedSlippageBps(asset int) (float64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fills := e.slippage[asset]
+	total, weight := 0.0, 0.0
+	for _, fill := range fills {
+		total += fill.bps * fill.size
+		weight += fill.size
+	}
+	if weight <= 0 {
+		return 0, false
+	}
+	return total / weight, true
+}
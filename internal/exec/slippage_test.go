@@ -0,0 +1,53 @@
+package exec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestExecutorMeasuresFillSlippage(t *testing.T) {
+	rest := &mockRest{orderID: "7"}
+	exec := New(rest, nil, zap.NewNop())
+	ctx := context.Background()
+	if _, err := exec.PlaceOrder(ctx, Order{Asset: 1, IsBuy: true, Size: 1, LimitPrice: 101, ClientOrderID: "0xa"}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if _, ok := exec.RecordFill("7", "0xa", 100.5, 1); ok {
+		t.Fatalf("expected no sample before tracking is enabled")
+	}
+
+	mids := map[int]float64{1: 100, 2: 50}
+	exec.SetSlippageTracking(func(_ context.Context, asset int) (float64, bool) {
+		mid, ok := mids[asset]
+		return mid, ok
+	}, 2)
+	if _, err := exec.PlaceOrder(ctx, Order{Asset: 1, IsBuy: true, Size: 1, LimitPrice: 101, ClientOrderID: "0xb"}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	sample, ok := exec.RecordFill("", "0xb", 100.1, 1)
+	if !ok || math.Abs(sample.Bps-10) > 1e-9 {
+		t.Fatalf("expected 10 bps adverse on the buy, got %+v (ok=%t)", sample, ok)
+	}
+	rest.orderID = "8"
+	if _, err := exec.PlaceOrder(ctx, Order{Asset: 1, Size: 3, LimitPrice: 99}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if sample, ok := exec.RecordFill("8", "", 100.1, -3); !ok || math.Abs(sample.Bps+10) > 1e-9 {
+		t.Fatalf("expected a 10 bps improvement on the sell, got %+v (ok=%t)", sample, ok)
+	}
+	if got, ok := exec.RealizedSlippageBps(1); !ok || math.Abs(got+5) > 1e-9 {
+		t.Fatalf("expected size-weighted -5 bps, got %f (ok=%t)", got, ok)
+	}
+
+	// The window keeps the last two fills.
+	exec.RecordFill("8", "", 99.9, 1)
+	if got, _ := exec.RealizedSlippageBps(1); math.Abs(got-(-10*3+10)/4) > 1e-9 {
+		t.Fatalf("expected the oldest fill dropped, got %f", got)
+	}
+	if _, ok := exec.RealizedSlippageBps(2); ok {
+		t.Fatalf("expected no slippage for an asset without fills")
+	}
+}
@@ -0,0 +1,29 @@
+package exec
+
+import (
+	"hl-carry-bot/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = tracing.Tracer("hl-carry-bot/internal/exec")
+
+func orderAttributes(order Order) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.Int("asset", order.Asset),
+		attribute.Bool("is_buy", order.IsBuy),
+		attribute.Float64("size", order.Size),
+		attribute.Float64("limit_price", order.LimitPrice),
+		attribute.Bool("reduce_only", order.ReduceOnly),
+	}
+	if order.ClientOrderID != "" {
+		attrs = append(attrs, attribute.String("cloid", order.ClientOrderID))
+	}
+	if order.Tif != "" {
+		attrs = append(attrs, attribute.String("tif", order.Tif))
+	}
+	if order.TriggerPrice > 0 {
+		attrs = append(attrs, attribute.Float64("trigger_price", order.TriggerPrice))
+	}
+	return attrs
+}
@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+func TestPlaceRecordsSubmitSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(prev)
+
+	exec := New(&mockRest{orderID: "42"}, nil, zap.NewNop())
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "entry")
+	if _, err := exec.Place(ctx, Order{Asset: 3, IsBuy: true, Size: 1, LimitPrice: 10, ClientOrderID: "0xaa"}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	parent.End()
+
+	var submit sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "order_submit" {
+			submit = span
+		}
+	}
+	if submit == nil {
+		t.Fatalf("expected an order_submit span, got %d spans", len(recorder.Ended()))
+	}
+	if submit.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("expected order_submit under the caller's span")
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range submit.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["cloid"].AsString() != "0xaa" || attrs["order_id"].AsString() != "42" || attrs["asset"].AsInt64() != 3 {
+		t.Fatalf("unexpected span attributes %v", attrs)
+	}
+}
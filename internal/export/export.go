@@ -0,0 +1,293 @@
+// Package export writes the accounting journal out as a trade blotter: CSV
+// files of fills, funding payments, transfers and lifecycle events, plus a
+// per-asset summary, for tax and accounting reconciliation.
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	persist "hl-carry-bot/internal/state"
+)
+
+// Source is the journal a blotter is read from; the SQLite store is one.
+type Source interface {
+	Fills(ctx context.Context, startMS, endMS int64) ([]persist.FillRecord, error)
+	FundingPayments(ctx context.Context, startMS, endMS int64) ([]persist.FundingRecord, error)
+	Transfers(ctx context.Context, startMS, endMS int64) ([]persist.TransferRecord, error)
+	Lifecycle(ctx context.Context, startMS, endMS int64) ([]persist.LifecycleRecord, error)
+}
+
+// Options selects the records to export. Both bounds are inclusive; an
+// EndMS of 0 exports through the latest record. ByAsset splits fills and
+// funding into one file per asset.
+type Options struct {
+	StartMS int64
+	EndMS   int64
+	ByAsset bool
+}
+
+// AssetSummary totals one asset's fills and funding over the export range.
+type AssetSummary struct {
+	Asset        string
+	Fills        int
+	VolumeUSD    float64
+	FeesUSD      float64
+	ClosedPnLUSD float64
+	Payments     int
+	FundingUSD   float64
+}
+
+var (
+	fillHeader      = []string{"time", "time_ms", "asset", "side", "size", "price", "notional_usd", "fee", "closed_pnl", "order_id", "hash", "id"}
+	fundingHeader   = []string{"time", "time_ms", "asset", "amount_usd", "rate", "id"}
+	transferHeader  = []string{"time", "time_ms", "kind", "direction", "destination", "amount_usd", "id"}
+	lifecycleHeader = []string{"time", "time_ms", "attempt", "event", "perp_asset", "spot_asset", "notional_usd", "funding_usd", "spot_filled", "perp_filled", "detail", "id"}
+	summaryHeader   = []string{"asset", "fills", "volume_usd", "fees", "closed_pnl", "funding_payments", "funding_usd", "net_usd"}
+)
+
+// Write exports the records in opts to CSV files in dir and returns the
+// paths written. Rows are in time order.
+func Write(ctx context.Context, src Source, dir string, opts Options) ([]string, error) {
+	fills, err := src.Fills(ctx, opts.StartMS, opts.EndMS)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := src.FundingPayments(ctx, opts.StartMS, opts.EndMS)
+	if err != nil {
+		return nil, err
+	}
+	transfers, err := src.Transfers(ctx, opts.StartMS, opts.EndMS)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle, err := src.Lifecycle(ctx, opts.StartMS, opts.EndMS)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	write := func(name string, header []string, rows [][]string) error {
+		path := filepath.Join(dir, name)
+		if err := writeCSV(path, header, rows); err != nil {
+			return err
+		}
+		written = append(written, path)
+		return nil
+	}
+	fillGroups := map[string][][]string{"": nil}
+	for _, fill := range fills {
+		key := groupKey(fill.Asset, opts.ByAsset)
+		fillGroups[key] = append(fillGroups[key], fillRow(fill))
+	}
+	fundingGroups := map[string][][]string{"": nil}
+	for _, payment := range payments {
+		key := groupKey(payment.Asset, opts.ByAsset)
+		fundingGroups[key] = append(fundingGroups[key], fundingRow(payment))
+	}
+	for _, key := range sortedKeys(fillGroups) {
+		if key == "" && opts.ByAsset {
+			continue
+		}
+		if err := write(fileName("fills", key), fillHeader, fillGroups[key]); err != nil {
+			return written, err
+		}
+	}
+	for _, key := range sortedKeys(fundingGroups) {
+		if key == "" && opts.ByAsset {
+			continue
+		}
+		if err := write(fileName("funding", key), fundingHeader, fundingGroups[key]); err != nil {
+			return written, err
+		}
+	}
+	transferRows := make([][]string, 0, len(transfers))
+	for _, rec := range transfers {
+		transferRows = append(transferRows, transferRow(rec))
+	}
+	if err := write("transfers.csv", transferHeader, transferRows); err != nil {
+		return written, err
+	}
+	lifecycleRows := make([][]string, 0, len(lifecycle))
+	for _, rec := range lifecycle {
+		lifecycleRows = append(lifecycleRows, lifecycleRow(rec))
+	}
+	if err := write("lifecycle.csv", lifecycleHeader, lifecycleRows); err != nil {
+		return written, err
+	}
+	var summaryRows [][]string
+	for _, sum := range Summarize(fills, payments) {
+		summaryRows = append(summaryRows, summaryRow(sum))
+	}
+	if err := write("summary.csv", summaryHeader, summaryRows); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// Summarize totals fills and funding per asset, sorted by asset.
+func Summarize(fills []persist.FillRecord, payments []persist.FundingRecord) []AssetSummary {
+	byAsset := make(map[string]*AssetSummary)
+	get := func(asset string) *AssetSummary {
+		sum, ok := byAsset[asset]
+		if !ok {
+			sum = &AssetSummary{Asset: asset}
+			byAsset[asset] = sum
+		}
+		return sum
+	}
+	for _, fill := range fills {
+		sum := get(fill.Asset)
+		sum.Fills++
+		sum.VolumeUSD += fill.Size * fill.Price
+		sum.FeesUSD += fill.Fee
+		sum.ClosedPnLUSD += fill.ClosedPnL
+	}
+	for _, payment := range payments {
+		sum := get(payment.Asset)
+		sum.Payments++
+		sum.FundingUSD += payment.Amount
+	}
+	out := make([]AssetSummary, 0, len(byAsset))
+	for _, sum := range byAsset {
+		out = append(out, *sum)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Asset < out[j].Asset })
+	return out
+}
+
+func fillRow(fill persist.FillRecord) []string {
+	return []string{
+		formatTime(fill.TimeMS),
+		strconv.FormatInt(fill.TimeMS, 10),
+		fill.Asset,
+		fill.Side,
+		formatFloat(fill.Size),
+		formatFloat(fill.Price),
+		formatFloat(fill.Size * fill.Price),
+		formatFloat(fill.Fee),
+		formatFloat(fill.ClosedPnL),
+		fill.OrderID,
+		fill.Hash,
+		fill.ID,
+	}
+}
+
+func fundingRow(payment persist.FundingRecord) []string {
+	return []string{
+		formatTime(payment.TimeMS),
+		strconv.FormatInt(payment.TimeMS, 10),
+		payment.Asset,
+		formatFloat(payment.Amount),
+		formatFloat(payment.Rate),
+		payment.ID,
+	}
+}
+
+func transferRow(rec persist.TransferRecord) []string {
+	return []string{
+		formatTime(rec.TimeMS),
+		strconv.FormatInt(rec.TimeMS, 10),
+		rec.Kind,
+		rec.Direction,
+		rec.Destination,
+		formatFloat(rec.Amount),
+		rec.ID,
+	}
+}
+
+func lifecycleRow(rec persist.LifecycleRecord) []string {
+	return []string{
+		formatTime(rec.TimeMS),
+		strconv.FormatInt(rec.TimeMS, 10),
+		rec.Attempt,
+		rec.Event,
+		rec.PerpAsset,
+		rec.SpotAsset,
+		formatFloat(rec.NotionalUSD),
+		formatFloat(rec.FundingUSD),
+		formatFloat(rec.SpotFilled),
+		formatFloat(rec.PerpFilled),
+		rec.Detail,
+		rec.ID,
+	}
+}
+
+func summaryRow(sum AssetSummary) []string {
+	return []string{
+		sum.Asset,
+		strconv.Itoa(sum.Fills),
+		formatFloat(sum.VolumeUSD),
+		formatFloat(sum.FeesUSD),
+		formatFloat(sum.ClosedPnLUSD),
+		strconv.Itoa(sum.Payments),
+		formatFloat(sum.FundingUSD),
+		formatFloat(sum.ClosedPnLUSD + sum.FundingUSD - sum.FeesUSD),
+	}
+}
+
+func writeCSV(path string, header []string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	if err := w.Write(header); err != nil {
+		file.Close()
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func groupKey(asset string, byAsset bool) string {
+	if !byAsset {
+		return ""
+	}
+	return asset
+}
+
+// fileName is prefix.csv, or prefix_<asset>.csv for a per-asset group with
+// the asset reduced to filename-safe characters ("@107" -> "107").
+func fileName(prefix, asset string) string {
+	if asset == "" {
+		return prefix + ".csv"
+	}
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return -1
+		}
+	}, asset)
+	return prefix + "_" + safe + ".csv"
+}
+
+func sortedKeys(groups map[string][][]string) []string {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatTime(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339Nano)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
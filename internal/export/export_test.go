@@ -0,0 +1,92 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	persist "hl-carry-bot/internal/state"
+	"hl-carry-bot/internal/state/sqlite"
+)
+
+func TestWriteExportsRangeByAsset(t *testing.T) {
+	dir := t.TempDir()
+	store, err := sqlite.New(filepath.Join(dir, "state.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	fills := []persist.FillRecord{
+		{ID: "f1", OrderID: "1", Asset: "ETH", Side: "A", Size: 0.5, Price: 2000, Fee: 0.4, TimeMS: 1000, Hash: "0x1"},
+		{ID: "f2", OrderID: "2", Asset: "@51", Side: "B", Size: 0.5, Price: 1999, Fee: 0.3, TimeMS: 1000, Hash: "0x2"},
+		{ID: "f3", OrderID: "3", Asset: "ETH", Side: "B", Size: 0.5, Price: 1990, Fee: 0.4, ClosedPnL: 5, TimeMS: 3000, Hash: "0x3"},
+		{ID: "f4", OrderID: "4", Asset: "ETH", Side: "B", Size: 1, Price: 1990, TimeMS: 9000, Hash: "0x4"},
+	}
+	for _, fill := range fills {
+		if _, err := store.RecordFill(ctx, fill); err != nil {
+			t.Fatalf("record fill: %v", err)
+		}
+	}
+	if _, err := store.RecordFunding(ctx, persist.FundingRecord{ID: "p1", Asset: "ETH", Amount: 1.25, Rate: 0.0001, TimeMS: 2000}); err != nil {
+		t.Fatalf("record funding: %v", err)
+	}
+	if _, err := store.RecordTransfer(ctx, persist.TransferRecord{ID: "t1", Kind: persist.TransferUSDClass, Direction: "to_perp", Amount: 500, TimeMS: 500}); err != nil {
+		t.Fatalf("record transfer: %v", err)
+	}
+	if _, err := store.RecordLifecycle(ctx, persist.LifecycleRecord{ID: "a1:compound_start", Attempt: "a1", Event: persist.LifecycleCompoundStart, PerpAsset: "ETH", SpotAsset: "UETH", TimeMS: 2500}); err != nil {
+		t.Fatalf("record lifecycle: %v", err)
+	}
+
+	out := filepath.Join(dir, "out")
+	written, err := Write(ctx, store, out, Options{StartMS: 500, EndMS: 5000, ByAsset: true})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(written) != 6 {
+		t.Fatalf("expected 6 files, got %v", written)
+	}
+	eth := readCSV(t, filepath.Join(out, "fills_ETH.csv"))
+	if len(eth) != 3 || eth[1][11] != "f1" || eth[2][11] != "f3" {
+		t.Fatalf("expected the two ETH fills in range, got %v", eth)
+	}
+	if eth[1][0] != "1970-01-01T00:00:01Z" || eth[1][6] != "1000" {
+		t.Fatalf("expected time and notional columns, got %v", eth[1])
+	}
+	if spot := readCSV(t, filepath.Join(out, "fills_51.csv")); len(spot) != 2 {
+		t.Fatalf("expected the spot fill in its own file, got %v", spot)
+	}
+	if funding := readCSV(t, filepath.Join(out, "funding_ETH.csv")); len(funding) != 2 || funding[1][3] != "1.25" {
+		t.Fatalf("unexpected funding export %v", funding)
+	}
+	if transfers := readCSV(t, filepath.Join(out, "transfers.csv")); len(transfers) != 2 || transfers[1][3] != "to_perp" {
+		t.Fatalf("unexpected transfer export %v", transfers)
+	}
+	if lifecycle := readCSV(t, filepath.Join(out, "lifecycle.csv")); len(lifecycle) != 2 {
+		t.Fatalf("unexpected lifecycle export %v", lifecycle)
+	}
+	summary := readCSV(t, filepath.Join(out, "summary.csv"))
+	if len(summary) != 3 || summary[2][0] != "ETH" {
+		t.Fatalf("expected a summary row per asset, got %v", summary)
+	}
+	// ETH: closed 5 + funding 1.25 - fees 0.8.
+	if summary[2][1] != "2" || summary[2][7] != "5.45" {
+		t.Fatalf("unexpected ETH summary %v", summary[2])
+	}
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return rows
+}
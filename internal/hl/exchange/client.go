@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,24 +14,69 @@ import (
 	"sync/atomic"
 	"time"
 
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/logging"
+	"hl-carry-bot/internal/tracing"
+
 	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+var tracer = tracing.Tracer("hl-carry-bot/internal/hl/exchange")
+
 type Client struct {
 	baseURL       string
 	http          *http.Client
+	signMu        sync.RWMutex
 	signer        *Signer
 	vaultAddress  *common.Address
 	lastNonce     atomic.Uint64
 	lastPersisted atomic.Uint64
 	nonceStore    NonceStore
 	nonceKey      string
+	window        *nonceWindow
+	windows       map[common.Address]*nonceWindow
+	nonceOwner    *Client
+	nonceSharers  atomic.Int32
+	clockOffset   atomic.Int64
+	nonceRejected interface{ Inc() }
+	aheadWarned   atomic.Bool
 	log           *zap.Logger
 	persistMu     sync.Mutex
 	persistWarned atomic.Bool
+	limiter       *rest.Limiter
+	maxAttempts   int
+	retryBackoff  time.Duration
+	readOnly      atomic.Bool
+	allowed       map[common.Address]struct{}
 }
 
+const (
+	// An action weighs 1 plus 1 per 40 orders or cancels it batches
+	// (floor(n/40)); see actionWeight.
+	exchangeActionWeight   = 1
+	exchangeBatchPerWeight = 40
+
+	defaultMaxAttempts  = 3
+	defaultRetryBackoff = 250 * time.Millisecond
+	maxRetryBackoff     = 5 * time.Second
+)
+
+// ErrAlreadyProcessed means a retried payload was rejected because its nonce
+// was already consumed: an earlier attempt most likely reached the exchange.
+// Callers must not re-sign and resubmit the action.
+var ErrAlreadyProcessed = errors.New("exchange action already processed")
+
+// ErrReadOnly is returned for every action of a read-only client; nothing is
+// signed and no nonce is drawn.
+var ErrReadOnly = errors.New("exchange client is read-only")
+
+// ErrDestinationNotAllowed is returned for an action that would send funds
+// to an address missing from the allow-list; nothing is signed.
+var ErrDestinationNotAllowed = errors.New("transfer destination is not allowed")
+
 type NonceStore interface {
 	Get(ctx context.Context, key string) (string, bool, error)
 	Set(ctx context.Context, key, value string) error
@@ -41,6 +86,12 @@ type NonceState struct {
 	Key       string
 	Last      uint64
 	Persisted uint64
+	// PersistFailing is set while the last nonce write to the store failed.
+	PersistFailing bool
+	// WindowSize and WindowLowest describe the recent nonces of the current
+	// signer that are still inside the exchange's acceptance window.
+	WindowSize   int
+	WindowLowest uint64
 }
 
 func NewClient(baseURL string, timeout time.Duration, signer *Signer, vaultAddress string) (*Client, error) {
@@ -55,24 +106,79 @@ func NewClient(baseURL string, timeout time.Duration, signer *Signer, vaultAddre
 		addr := common.HexToAddress(vaultAddress)
 		vault = &addr
 	}
-	return &Client{
+	c := &Client{
 		baseURL: baseURL,
 		http: &http.Client{
 			Timeout: timeout,
 		},
 		signer:       signer,
 		vaultAddress: vault,
-	}, nil
+		maxAttempts:  defaultMaxAttempts,
+		retryBackoff: defaultRetryBackoff,
+	}
+	c.useWindowLocked(signer)
+	return c, nil
 }
 
 func (c *Client) SetLogger(log *zap.Logger) {
 	c.log = log
 }
 
+// SetRetry configures how often a signed payload is re-sent (same nonce) on
+// connection errors, 429s, and 5xx responses.
+func (c *Client) SetRetry(maxAttempts int, backoff time.Duration) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	c.maxAttempts = maxAttempts
+	c.retryBackoff = backoff
+}
+
+// SetReadOnly makes every signed action fail with ErrReadOnly.
+func (c *Client) SetReadOnly(readOnly bool) {
+	c.readOnly.Store(readOnly)
+}
+
+// SetAllowedDestinations limits the addresses actions may send funds to.
+// With none, every such action is refused.
+func (c *Client) SetAllowedDestinations(addrs []common.Address) {
+	allowed := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		allowed[addr] = struct{}{}
+	}
+	c.allowed = allowed
+}
+
+// checkDestination refuses a destination missing from the allow-list. The
+// refusal goes to the audit log since it can mean a typo or a compromised
+// operator channel.
+func (c *Client) checkDestination(action string, dest common.Address) error {
+	if _, ok := c.allowed[dest]; ok {
+		return nil
+	}
+	if c.log != nil {
+		c.log.Error("transfer destination refused", logging.Audit(),
+			zap.String("action", action),
+			zap.String("destination", dest.Hex()),
+		)
+	}
+	return fmt.Errorf("%w: %s", ErrDestinationNotAllowed, dest.Hex())
+}
+
+// SetLimiter shares the per-IP REST weight budget with the /info client.
+// Exchange actions are always treated as critical.
+func (c *Client) SetLimiter(l *rest.Limiter) {
+	c.limiter = l
+}
+
 func (c *Client) PlaceOrder(ctx context.Context, order OrderWire) (map[string]any, error) {
 	action := OrderAction{Type: "order", Orders: []OrderWire{order}, Grouping: "na"}
-	nonce := c.nextNonce()
-	sig, err := c.signer.SignOrderAction(action, nonce, c.vaultAddress, nil)
+	sig, nonce, err := c.signAction(func(s *Signer, nonce uint64) (Signature, error) {
+		return s.SignOrderAction(action, nonce, c.vaultAddress, nil)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -80,9 +186,81 @@ func (c *Client) PlaceOrder(ctx context.Context, order OrderWire) (map[string]an
 }
 
 func (c *Client) CancelOrder(ctx context.Context, asset int, orderID int64) (map[string]any, error) {
-	action := CancelAction{Type: "cancel", Cancels: []CancelWire{{Asset: asset, OrderID: orderID}}}
-	nonce := c.nextNonce()
-	sig, err := c.signer.SignCancelAction(action, nonce, c.vaultAddress, nil)
+	return c.CancelOrders(ctx, []CancelWire{{Asset: asset, OrderID: orderID}})
+}
+
+// CancelOrders cancels several orders, on one or more assets, in a single
+// signed action. CancelResults reads the per-order outcome.
+func (c *Client) CancelOrders(ctx context.Context, cancels []CancelWire) (map[string]any, error) {
+	if len(cancels) == 0 {
+		return nil, errors.New("no orders to cancel")
+	}
+	action := CancelAction{Type: "cancel", Cancels: cancels}
+	sig, nonce, err := c.signAction(func(s *Signer, nonce uint64) (Signature, error) {
+		return s.SignCancelAction(action, nonce, c.vaultAddress, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.postAction(ctx, action, sig, nonce, true)
+}
+
+// ScheduleCancel arms the exchange-side dead man's switch so all open orders
+// are cancelled at the given time unless it is refreshed first. A zero time
+// clears the scheduled cancel.
+func (c *Client) ScheduleCancel(ctx context.Context, at time.Time) (map[string]any, error) {
+	action := ScheduleCancelAction{Type: "scheduleCancel"}
+	if !at.IsZero() {
+		ms := uint64(at.UnixMilli())
+		action.Time = &ms
+	}
+	sig, nonce, err := c.signAction(func(s *Signer, nonce uint64) (Signature, error) {
+		return s.SignScheduleCancelAction(action, nonce, c.vaultAddress, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.postAction(ctx, action, sig, nonce, true)
+}
+
+// UpdateLeverage sets the leverage of a perp asset and whether its position
+// uses cross or isolated margin.
+func (c *Client) UpdateLeverage(ctx context.Context, asset, leverage int, isCross bool) (map[string]any, error) {
+	if leverage < 1 {
+		return nil, errors.New("leverage must be >= 1")
+	}
+	action := UpdateLeverageAction{
+		Type:     "updateLeverage",
+		Asset:    asset,
+		IsCross:  isCross,
+		Leverage: leverage,
+	}
+	sig, nonce, err := c.signAction(func(s *Signer, nonce uint64) (Signature, error) {
+		return s.SignUpdateLeverageAction(action, nonce, c.vaultAddress, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.postAction(ctx, action, sig, nonce, true)
+}
+
+// UpdateIsolatedMargin adds usd of margin to an isolated perp position, or
+// removes it when usd is negative. usd is converted to the signed micro-USDC
+// integer the action carries.
+func (c *Client) UpdateIsolatedMargin(ctx context.Context, asset int, usd float64) (map[string]any, error) {
+	micros := math.Round(usd * 1e6)
+	if micros == 0 {
+		return nil, errors.New("usd must be non-zero")
+	}
+	action := UpdateIsolatedMarginAction{
+		Type:  "updateIsolatedMargin",
+		Asset: asset,
+		IsBuy: true,
+		Ntli:  int64(micros),
+	}
+	sig, nonce, err := c.signAction(func(s *Signer, nonce uint64) (Signature, error) {
+		return s.SignUpdateIsolatedMarginAction(action, nonce, c.vaultAddress, nil)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -97,67 +275,172 @@ func (c *Client) USDClassTransfer(ctx context.Context, amount float64, toPerp bo
 	if c.vaultAddress != nil {
 		amountStr += " subaccount:" + c.vaultAddress.Hex()
 	}
-	nonce := c.nextNonce()
 	action := USDClassTransferAction{
 		Type:   "usdClassTransfer",
 		Amount: amountStr,
 		ToPerp: toPerp,
-		Nonce:  nonce,
 	}
-	sig, err := c.signer.SignUSDClassTransfer(&action)
+	sig, _, err := c.signAction(func(s *Signer, nonce uint64) (Signature, error) {
+		action.Nonce = nonce
+		return s.SignUSDClassTransfer(&action)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return c.postAction(ctx, action, sig, action.Nonce, false)
 }
 
+// VaultTransfer moves USDC between the perp account and a vault. usd is
+// converted to the micro-USDC integer the action carries. The action is
+// signed without the sub-account so it always moves the signer's own funds.
+// A deposit is refused unless the vault is an allowed destination.
+func (c *Client) VaultTransfer(ctx context.Context, vault common.Address, usd float64, isDeposit bool) (map[string]any, error) {
+	if vault == (common.Address{}) {
+		return nil, errors.New("vault address is required")
+	}
+	if isDeposit {
+		if err := c.checkDestination("vaultTransfer", vault); err != nil {
+			return nil, err
+		}
+	}
+	micros := math.Round(usd * 1e6)
+	if micros < 1 {
+		return nil, errors.New("usd must be > 0")
+	}
+	action := VaultTransferAction{
+		Type:         "vaultTransfer",
+		VaultAddress: strings.ToLower(vault.Hex()),
+		IsDeposit:    isDeposit,
+		Usd:          uint64(micros),
+	}
+	sig, nonce, err := c.signAction(func(s *Signer, nonce uint64) (Signature, error) {
+		return s.SignVaultTransferAction(action, nonce, nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.postAction(ctx, action, sig, nonce, false)
+}
+
 func (c *Client) InitNonceStore(ctx context.Context, store NonceStore) error {
 	if store == nil {
 		return nil
 	}
+	c.signMu.Lock()
+	defer c.signMu.Unlock()
 	if c.signer == nil {
 		return errors.New("signer is required for nonce store")
 	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	key := nonceStoreKey(c.baseURL, c.signer, c.vaultAddress)
-	now := uint64(time.Now().UnixMilli())
-	seed := now
-	if raw, ok, err := store.Get(ctx, key); err != nil {
+	seed, err := c.nonceSeed(ctx, store, c.signer)
+	if err != nil {
 		return err
-	} else if ok {
-		parsed, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
-		if err != nil {
-			return fmt.Errorf("invalid stored nonce %q: %w", raw, err)
-		}
-		if parsed > seed {
-			seed = parsed
-		}
 	}
-	if current := c.lastNonce.Load(); current > seed {
-		seed = current
+	if c.nonceOwner != nil {
+		// The owner persists the shared nonces; this store only carries
+		// forward what an earlier run drew before they were shared.
+		c.nonceOwner.raiseNonce(seed)
+		return nil
+	}
+	key := nonceStoreKey(c.baseURL, c.signer)
+	if err := store.Set(ctx, key, strconv.FormatUint(seed, 10)); err != nil {
+		return err
 	}
 	c.nonceStore = store
 	c.nonceKey = key
-	c.lastNonce.Store(seed)
+	c.raiseNonce(seed)
 	c.lastPersisted.Store(seed)
 	return nil
 }
 
+// SignerAddress is the address actions are currently signed with.
+func (c *Client) SignerAddress() common.Address {
+	c.signMu.RLock()
+	defer c.signMu.RUnlock()
+	return c.signer.Address()
+}
+
+// RotateSigner switches signing to next. It waits for in-flight actions, so
+// every action is signed entirely by one key, and moves nonce persistence to
+// the store entry and nonce window of the new key, seeded so nonces stay
+// monotonic. Callers
+// must verify next is authorized for the account before rotating.
+func (c *Client) RotateSigner(ctx context.Context, next *Signer) error {
+	if next == nil {
+		return errors.New("signer is required")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c.signMu.Lock()
+	defer c.signMu.Unlock()
+	if c.nonceOwner != nil || c.nonceSharers.Load() > 0 {
+		return errNoncesShared
+	}
+	if c.nonceStore != nil {
+		key := nonceStoreKey(c.baseURL, next)
+		seed, err := c.nonceSeed(ctx, c.nonceStore, next)
+		if err != nil {
+			return err
+		}
+		if err := c.nonceStore.Set(ctx, key, strconv.FormatUint(seed, 10)); err != nil {
+			return err
+		}
+		c.persistMu.Lock()
+		c.nonceKey = key
+		c.lastNonce.Store(seed)
+		c.lastPersisted.Store(seed)
+		c.persistMu.Unlock()
+	}
+	c.signer = next
+	c.useWindowLocked(next)
+	return nil
+}
+
+// signAction draws a nonce and signs with the current signer while holding
+// off RotateSigner.
+func (c *Client) signAction(sign func(*Signer, uint64) (Signature, error)) (Signature, uint64, error) {
+	if c.readOnly.Load() {
+		return Signature{}, 0, ErrReadOnly
+	}
+	c.signMu.RLock()
+	defer c.signMu.RUnlock()
+	nonce := c.nextNonce()
+	sig, err := sign(c.signer, nonce)
+	return sig, nonce, err
+}
+
 func (c *Client) NonceState() (NonceState, bool) {
+	if c.nonceOwner != nil {
+		return c.nonceOwner.NonceState()
+	}
+	c.signMu.RLock()
+	defer c.signMu.RUnlock()
 	if c.nonceStore == nil || c.nonceKey == "" {
 		return NonceState{}, false
 	}
-	return NonceState{
-		Key:       c.nonceKey,
-		Last:      c.lastNonce.Load(),
-		Persisted: c.lastPersisted.Load(),
-	}, true
+	state := NonceState{
+		Key:            c.nonceKey,
+		Last:           c.lastNonce.Load(),
+		Persisted:      c.lastPersisted.Load(),
+		PersistFailing: c.persistWarned.Load(),
+	}
+	if c.window != nil {
+		c.window.prune(c.nonceNow())
+		state.WindowLowest, _, state.WindowSize = c.window.bounds()
+	}
+	return state, true
 }
 
 func (c *Client) nextNonce() uint64 {
-	now := uint64(time.Now().UnixMilli())
+	if owner := c.nonceOwner; owner != nil {
+		owner.signMu.RLock()
+		defer owner.signMu.RUnlock()
+		return owner.nextNonce()
+	}
+	now := c.nonceNow()
 	for {
 		prev := c.lastNonce.Load()
 		next := now
@@ -165,6 +448,7 @@ func (c *Client) nextNonce() uint64 {
 			next = prev + 1
 		}
 		if c.lastNonce.CompareAndSwap(prev, next) {
+			c.recordNonce(next, now)
 			c.persistNonce(next)
 			return next
 		}
@@ -197,19 +481,11 @@ func (c *Client) logPersistError(err error) {
 	}
 }
 
-func nonceStoreKey(baseURL string, signer *Signer, vaultAddress *common.Address) string {
-	addr := "unknown"
-	if signer != nil {
-		addr = strings.ToLower(signer.Address().Hex())
-	}
-	vault := "none"
-	if vaultAddress != nil {
-		vault = strings.ToLower(vaultAddress.Hex())
-	}
-	return fmt.Sprintf("exchange:nonce:%s:%s:%s", strings.ToLower(strings.TrimSpace(baseURL)), addr, vault)
-}
-
-func (c *Client) postAction(ctx context.Context, action any, sig Signature, nonce uint64, includeVault bool) (map[string]any, error) {
+func (c *Client) postAction(ctx context.Context, action any, sig Signature, nonce uint64, includeVault bool) (data map[string]any, err error) {
+	ctx, span := tracer.Start(ctx, "exchange "+actionType(action), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.Int64("nonce", int64(nonce)),
+	))
+	defer func() { tracing.End(span, err) }()
 	var vaultAddress *string
 	if includeVault && c.vaultAddress != nil {
 		addr := c.vaultAddress.Hex()
@@ -222,32 +498,139 @@ func (c *Client) postAction(ctx context.Context, action any, sig Signature, nonc
 		VaultAddress: vaultAddress,
 		ExpiresAfter: nil,
 	}
-	return c.post(ctx, "/exchange", payload)
+	data, err = c.post(ctx, "/exchange", payload, actionWeight(action))
+	if err == nil {
+		c.checkNonceRejection(data, nonce)
+	}
+	return data, err
 }
 
-func (c *Client) post(ctx context.Context, path string, req any) (map[string]any, error) {
+func (c *Client) post(ctx context.Context, path string, req any, weight int) (map[string]any, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
+	attempts := c.maxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := c.retryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		data, retryable, err := c.postOnce(ctx, path, body, weight)
+		if err == nil {
+			if attempt > 1 {
+				if msg, ok := alreadyProcessedMessage(data); ok {
+					return data, fmt.Errorf("%w: %s", ErrAlreadyProcessed, msg)
+				}
+			}
+			return data, nil
+		}
+		lastErr = err
+		if !retryable || attempt == attempts || ctx.Err() != nil {
+			break
+		}
+		trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.String("error", err.Error()),
+		))
+		if c.log != nil {
+			c.log.Warn("exchange post failed, retrying same payload",
+				zap.Error(err),
+				zap.Int("attempt", attempt),
+				zap.Int("max_attempts", attempts),
+				zap.Duration("backoff", backoff),
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+	return nil, lastErr
+}
+
+// postOnce sends body once, charging weight to the limiter, and reports
+// whether a failure is safe to retry with the identical payload.
+func (c *Client) postOnce(ctx context.Context, path string, body []byte, weight int) (map[string]any, bool, error) {
+	if err := c.limiter.Wait(rest.WithPriority(ctx, rest.PriorityCritical), weight); err != nil {
+		return nil, false, err
+	}
 	url := c.baseURL + path
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 	resp, err := c.http.Do(httpReq)
 	if err != nil {
-		return nil, err
+		return nil, ctx.Err() == nil, err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		payload, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-		return nil, fmt.Errorf("http %d: %s", resp.StatusCode, string(payload))
+	if resp.StatusCode == http.StatusTooManyRequests {
+		c.limiter.Drain()
+	}
+	if err := rest.ResponseError(resp); err != nil {
+		return nil, rest.Retryable(err), err
 	}
 	var data map[string]any
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	return data, false, nil
+}
+
+// actionWeight is the rate limit weight of one /exchange action: 1, plus 1 per
+// exchangeBatchPerWeight orders or cancels a batched action carries.
+func actionWeight(action any) int {
+	n := 0
+	switch a := action.(type) {
+	case OrderAction:
+		n = len(a.Orders)
+	case CancelAction:
+		n = len(a.Cancels)
+	}
+	return exchangeActionWeight + n/exchangeBatchPerWeight
+}
+
+// actionType is the action's wire type, naming its span.
+func actionType(action any) string {
+	switch a := action.(type) {
+	case OrderAction:
+		return a.Type
+	case CancelAction:
+		return a.Type
+	case ScheduleCancelAction:
+		return a.Type
+	case VaultTransferAction:
+		return a.Type
+	case UpdateLeverageAction:
+		return a.Type
+	case UpdateIsolatedMarginAction:
+		return a.Type
+	case USDClassTransferAction:
+		return a.Type
+	}
+	return "action"
+}
+
+func alreadyProcessedMessage(resp map[string]any) (string, bool) {
+	err := ResponseError(resp)
+	if err == nil {
+		return "", false
+	}
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "already processed") ||
+		(strings.Contains(msg, "nonce") && (strings.Contains(msg, "duplicate") || strings.Contains(msg, "already used"))) {
+		return err.Error(), true
 	}
-	return data, nil
+	return "", false
 }
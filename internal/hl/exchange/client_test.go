@@ -2,14 +2,21 @@ package exchange
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"hl-carry-bot/internal/state/sqlite"
 
+	"github.com/ethereum/go-ethereum/common"
 	"go.uber.org/zap"
 )
 
@@ -90,7 +97,7 @@ func TestInitNonceStoreSeedsAndPersists(t *testing.T) {
 	}
 	client.SetLogger(zap.NewNop())
 	seed := uint64(time.Now().UnixMilli()) + 10_000
-	key := nonceStoreKey(client.baseURL, client.signer, client.vaultAddress)
+	key := nonceStoreKey(client.baseURL, client.signer)
 	if err := store.Set(ctx, key, strconv.FormatUint(seed, 10)); err != nil {
 		t.Fatalf("store seed: %v", err)
 	}
@@ -126,3 +133,278 @@ func TestInitNonceStoreSeedsAndPersists(t *testing.T) {
 		t.Fatalf("expected stored nonce %d, got %d", nonce, persisted)
 	}
 }
+
+func newRetryTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	signer, err := NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	client, err := NewClient(srv.URL, 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client init: %v", err)
+	}
+	client.SetRetry(3, time.Millisecond)
+	return client
+}
+
+func TestPostRetriesIdenticalPayload(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		n := len(bodies)
+		mu.Unlock()
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"cancel"}}`))
+	})
+	if _, err := client.CancelOrder(context.Background(), 1, 42); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(bodies))
+	}
+	if bodies[0] != bodies[1] {
+		t.Fatalf("expected identical payloads, got %s vs %s", bodies[0], bodies[1])
+	}
+}
+
+func TestPostDetectsAlreadyProcessedOnRetry(t *testing.T) {
+	var calls atomic.Int32
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"err","response":"Invalid nonce: duplicate nonce"}`))
+	})
+	_, err := client.CancelOrder(context.Background(), 1, 42)
+	if !errors.Is(err, ErrAlreadyProcessed) {
+		t.Fatalf("expected ErrAlreadyProcessed, got %v", err)
+	}
+}
+
+func TestPostDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+	if _, err := client.CancelOrder(context.Background(), 1, 42); err == nil {
+		t.Fatalf("expected error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got)
+	}
+}
+
+func TestRotateSignerRekeysNonceStore(t *testing.T) {
+	primary, err := NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	secondary, err := NewSigner("8da4ef21b864d2cc526dbdb2a120bd2874c36c9d0a1fb7f8c63d7f7a8b41de8f", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("store init: %v", err)
+	}
+	ctx := context.Background()
+	client, err := NewClient("https://api.hyperliquid.xyz", 2*time.Second, primary, "")
+	if err != nil {
+		t.Fatalf("client init: %v", err)
+	}
+	if err := client.InitNonceStore(ctx, store); err != nil {
+		t.Fatalf("init nonce store: %v", err)
+	}
+	before := client.nextNonce()
+	oldState, _ := client.NonceState()
+
+	if err := client.RotateSigner(ctx, secondary); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if client.SignerAddress() != secondary.Address() {
+		t.Fatalf("expected signer %s, got %s", secondary.Address().Hex(), client.SignerAddress().Hex())
+	}
+	state, ok := client.NonceState()
+	if !ok {
+		t.Fatalf("expected nonce state")
+	}
+	if state.Key == oldState.Key || state.Key != nonceStoreKey(client.baseURL, secondary) {
+		t.Fatalf("expected nonce key for new signer, got %s", state.Key)
+	}
+	if state.Last < before {
+		t.Fatalf("expected nonce to stay monotonic: %d < %d", state.Last, before)
+	}
+	after := client.nextNonce()
+	raw, ok, err := store.Get(ctx, state.Key)
+	if err != nil || !ok {
+		t.Fatalf("expected stored nonce under new key: %v", err)
+	}
+	if raw != strconv.FormatUint(after, 10) {
+		t.Fatalf("expected stored nonce %d, got %s", after, raw)
+	}
+	if err := client.RotateSigner(ctx, nil); err == nil {
+		t.Fatalf("expected error for nil signer")
+	}
+}
+
+func TestVaultTransferPostsMicroUSDWithoutSubaccount(t *testing.T) {
+	var body map[string]any
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+	})
+	vault := common.HexToAddress("0xdfc24b077bc1425ad1dea75bcb6f8158e10df303")
+	client.SetAllowedDestinations([]common.Address{vault})
+	if _, err := client.VaultTransfer(context.Background(), vault, 125.5, true); err != nil {
+		t.Fatalf("vault transfer: %v", err)
+	}
+	action, ok := body["action"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected action, got %#v", body)
+	}
+	if action["type"] != "vaultTransfer" || action["isDeposit"] != true {
+		t.Fatalf("unexpected action %#v", action)
+	}
+	if action["vaultAddress"] != "0xdfc24b077bc1425ad1dea75bcb6f8158e10df303" {
+		t.Fatalf("unexpected vault %v", action["vaultAddress"])
+	}
+	if got, _ := action["usd"].(float64); got != 125_500_000 {
+		t.Fatalf("expected 125500000 micro usd, got %v", action["usd"])
+	}
+	if body["vaultAddress"] != nil {
+		t.Fatalf("expected no subaccount on vault transfer, got %v", body["vaultAddress"])
+	}
+	if _, err := client.VaultTransfer(context.Background(), vault, 0, false); err == nil {
+		t.Fatalf("expected error for zero amount")
+	}
+	if _, err := client.VaultTransfer(context.Background(), common.Address{}, 10, false); err == nil {
+		t.Fatalf("expected error for missing vault")
+	}
+}
+
+func TestUpdateLeveragePostsAction(t *testing.T) {
+	var body map[string]any
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+	})
+	if _, err := client.UpdateLeverage(context.Background(), 4, 3, false); err != nil {
+		t.Fatalf("update leverage: %v", err)
+	}
+	action, ok := body["action"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected action, got %#v", body)
+	}
+	if action["type"] != "updateLeverage" || action["asset"] != float64(4) || action["isCross"] != false || action["leverage"] != float64(3) {
+		t.Fatalf("unexpected action %#v", action)
+	}
+	if _, err := client.UpdateLeverage(context.Background(), 4, 0, true); err == nil {
+		t.Fatalf("expected error for zero leverage")
+	}
+}
+
+func TestUpdateIsolatedMarginPostsSignedMicroUSD(t *testing.T) {
+	var body map[string]any
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+	})
+	if _, err := client.UpdateIsolatedMargin(context.Background(), 4, -12.25); err != nil {
+		t.Fatalf("update isolated margin: %v", err)
+	}
+	action, ok := body["action"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected action, got %#v", body)
+	}
+	if action["type"] != "updateIsolatedMargin" || action["asset"] != float64(4) || action["isBuy"] != true {
+		t.Fatalf("unexpected action %#v", action)
+	}
+	if got, _ := action["ntli"].(float64); got != -12_250_000 {
+		t.Fatalf("expected -12250000 micro usd, got %v", action["ntli"])
+	}
+	if _, err := client.UpdateIsolatedMargin(context.Background(), 4, 0); err == nil {
+		t.Fatalf("expected error for a zero amount")
+	}
+}
+
+func TestVaultDepositRefusedOutsideAllowList(t *testing.T) {
+	posts := 0
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+	})
+	allowed := common.HexToAddress("0xdfc24b077bc1425ad1dea75bcb6f8158e10df303")
+	other := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	before := client.lastNonce.Load()
+	if _, err := client.VaultTransfer(context.Background(), allowed, 10, true); !errors.Is(err, ErrDestinationNotAllowed) {
+		t.Fatalf("expected every destination refused without an allow-list, got %v", err)
+	}
+	client.SetAllowedDestinations([]common.Address{allowed})
+	if _, err := client.VaultTransfer(context.Background(), other, 10, true); !errors.Is(err, ErrDestinationNotAllowed) {
+		t.Fatalf("expected an unlisted vault refused, got %v", err)
+	}
+	if posts != 0 || client.lastNonce.Load() != before {
+		t.Fatalf("expected nothing posted or signed, got %d posts", posts)
+	}
+	// Withdrawals return funds to the account and need no allow-list entry.
+	if _, err := client.VaultTransfer(context.Background(), other, 10, false); err != nil {
+		t.Fatalf("withdraw: %v", err)
+	}
+}
+
+func TestReadOnlyClientRefusesActions(t *testing.T) {
+	posts := 0
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		_, _ = w.Write([]byte(`{"status":"ok","response":{"type":"default"}}`))
+	})
+	client.SetReadOnly(true)
+	before := client.lastNonce.Load()
+	if _, err := client.CancelOrder(context.Background(), 0, 1); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly for a cancel, got %v", err)
+	}
+	if _, err := client.USDClassTransfer(context.Background(), 10, true); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly for a transfer, got %v", err)
+	}
+	if posts != 0 || client.lastNonce.Load() != before {
+		t.Fatalf("expected nothing posted or signed, got %d posts", posts)
+	}
+	client.SetReadOnly(false)
+	if _, err := client.CancelOrder(context.Background(), 0, 1); err != nil || posts != 1 {
+		t.Fatalf("expected the cancel sent once writable, got %d posts (err=%v)", posts, err)
+	}
+}
+
+func TestActionWeightCountsBatches(t *testing.T) {
+	cases := []struct {
+		action any
+		want   int
+	}{
+		{action: OrderAction{Type: "order", Orders: make([]OrderWire, 1)}, want: 1},
+		{action: CancelAction{Type: "cancel", Cancels: make([]CancelWire, 39)}, want: 1},
+		{action: CancelAction{Type: "cancel", Cancels: make([]CancelWire, 40)}, want: 2},
+		{action: CancelAction{Type: "cancel", Cancels: make([]CancelWire, 85)}, want: 3},
+		{action: UpdateLeverageAction{Type: "updateLeverage"}, want: 1},
+	}
+	for _, tc := range cases {
+		if got := actionWeight(tc.action); got != tc.want {
+			t.Fatalf("%s: expected weight %d, got %d", actionType(tc.action), tc.want, got)
+		}
+	}
+}
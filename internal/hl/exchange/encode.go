@@ -92,6 +92,147 @@ func EncodeCancelAction(action CancelAction) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+func EncodeScheduleCancelAction(action ScheduleCancelAction) ([]byte, error) {
+	if action.Type == "" {
+		return nil, errors.New("action type is required")
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	mapLen := 1
+	if action.Time != nil {
+		mapLen++
+	}
+	if err := enc.EncodeMapLen(mapLen); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("type"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString(action.Type); err != nil {
+		return nil, err
+	}
+	if action.Time != nil {
+		if err := enc.EncodeString("time"); err != nil {
+			return nil, err
+		}
+		if err := enc.EncodeUint(*action.Time); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func EncodeVaultTransferAction(action VaultTransferAction) ([]byte, error) {
+	if action.Type == "" {
+		return nil, errors.New("action type is required")
+	}
+	if action.VaultAddress == "" {
+		return nil, errors.New("vault address is required")
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := enc.EncodeMapLen(4); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("type"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString(action.Type); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("vaultAddress"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString(action.VaultAddress); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("isDeposit"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeBool(action.IsDeposit); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("usd"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeUint(action.Usd); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func EncodeUpdateLeverageAction(action UpdateLeverageAction) ([]byte, error) {
+	if action.Type == "" {
+		return nil, errors.New("action type is required")
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := enc.EncodeMapLen(4); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("type"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString(action.Type); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("asset"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeInt(int64(action.Asset)); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("isCross"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeBool(action.IsCross); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("leverage"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeInt(int64(action.Leverage)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func EncodeUpdateIsolatedMarginAction(action UpdateIsolatedMarginAction) ([]byte, error) {
+	if action.Type == "" {
+		return nil, errors.New("action type is required")
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := enc.EncodeMapLen(4); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("type"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString(action.Type); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("asset"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeInt(int64(action.Asset)); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("isBuy"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeBool(action.IsBuy); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeString("ntli"); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeInt(action.Ntli); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 func encodeOrderWire(enc *msgpack.Encoder, order OrderWire) error {
 	mapLen := 6
 	if order.Cloid != "" {
@@ -164,8 +305,11 @@ func encodeCancelWire(enc *msgpack.Encoder, cancel CancelWire) error {
 }
 
 func encodeOrderTypeWire(enc *msgpack.Encoder, orderType OrderTypeWire) error {
+	if orderType.Trigger != nil {
+		return encodeTriggerOrderType(enc, *orderType.Trigger)
+	}
 	if orderType.Limit == nil {
-		return errors.New("limit order type required")
+		return errors.New("limit or trigger order type required")
 	}
 	if err := enc.EncodeMapLen(1); err != nil {
 		return err
@@ -181,3 +325,31 @@ func encodeOrderTypeWire(enc *msgpack.Encoder, orderType OrderTypeWire) error {
 	}
 	return enc.EncodeString(string(orderType.Limit.Tif))
 }
+
+func encodeTriggerOrderType(enc *msgpack.Encoder, trigger TriggerOrderType) error {
+	if err := enc.EncodeMapLen(1); err != nil {
+		return err
+	}
+	if err := enc.EncodeString("trigger"); err != nil {
+		return err
+	}
+	if err := enc.EncodeMapLen(3); err != nil {
+		return err
+	}
+	if err := enc.EncodeString("isMarket"); err != nil {
+		return err
+	}
+	if err := enc.EncodeBool(trigger.IsMarket); err != nil {
+		return err
+	}
+	if err := enc.EncodeString("triggerPx"); err != nil {
+		return err
+	}
+	if err := enc.EncodeString(trigger.TriggerPx); err != nil {
+		return err
+	}
+	if err := enc.EncodeString("tpsl"); err != nil {
+		return err
+	}
+	return enc.EncodeString(string(trigger.Tpsl))
+}
@@ -111,6 +111,97 @@ func TestEncodeCancelActionDeterministic(t *testing.T) {
 	}
 }
 
+func TestLimitOrderWireTif(t *testing.T) {
+	order, err := LimitOrderWire(1, true, 2.5, 100.0, false, TifAlo, "")
+	if err != nil {
+		t.Fatalf("unexpected order wire error: %v", err)
+	}
+	b, err := EncodeOrderAction(OrderAction{Type: "order", Orders: []OrderWire{order}, Grouping: "na"})
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	var decoded map[string]any
+	if err := msgpack.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	orderMap := decoded["orders"].([]any)[0].(map[string]any)
+	limit := orderMap["t"].(map[string]any)["limit"].(map[string]any)
+	if limit["tif"] != "Alo" {
+		t.Fatalf("expected Alo tif, got %v", limit["tif"])
+	}
+	if _, err := LimitOrderWire(1, true, 2.5, 100.0, false, Tif("Fok"), ""); err == nil {
+		t.Fatalf("expected error for unsupported tif")
+	}
+	if _, err := LimitOrderWire(1, true, 2.5, 100.0, false, "", ""); err == nil {
+		t.Fatalf("expected error for missing tif")
+	}
+}
+
+func TestTriggerOrderWireEncoding(t *testing.T) {
+	order, err := TriggerOrderWire(1, true, 2.5, 3300, 3150, true, true, TpslStopLoss, "")
+	if err != nil {
+		t.Fatalf("unexpected order wire error: %v", err)
+	}
+	b, err := EncodeOrderAction(OrderAction{Type: "order", Orders: []OrderWire{order}, Grouping: "na"})
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	isMarket := bytes.Index(b, []byte("isMarket"))
+	triggerPx := bytes.Index(b, []byte("triggerPx"))
+	tpsl := bytes.Index(b, []byte("tpsl"))
+	if isMarket < 0 || !(isMarket < triggerPx && triggerPx < tpsl) {
+		t.Fatalf("expected isMarket, triggerPx, tpsl key order")
+	}
+	var decoded map[string]any
+	if err := msgpack.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	orderMap := decoded["orders"].([]any)[0].(map[string]any)
+	if orderMap["r"] != true || orderMap["p"] != "3300" {
+		t.Fatalf("unexpected order fields: %#v", orderMap)
+	}
+	trigger := orderMap["t"].(map[string]any)["trigger"].(map[string]any)
+	if trigger["isMarket"] != true || trigger["triggerPx"] != "3150" || trigger["tpsl"] != "sl" {
+		t.Fatalf("unexpected trigger: %#v", trigger)
+	}
+	if _, err := TriggerOrderWire(1, true, 2.5, 3300, 3150, true, true, Tpsl("stop"), ""); err == nil {
+		t.Fatalf("expected error for unsupported tpsl")
+	}
+	if _, err := TriggerOrderWire(1, true, 2.5, 3300, 0, true, true, TpslStopLoss, ""); err == nil {
+		t.Fatalf("expected error for missing trigger price")
+	}
+}
+
+func TestEncodeScheduleCancelAction(t *testing.T) {
+	at := uint64(1_700_000_000_000)
+	b, err := EncodeScheduleCancelAction(ScheduleCancelAction{Type: "scheduleCancel", Time: &at})
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	var decoded map[string]any
+	if err := msgpack.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if decoded["type"] != "scheduleCancel" {
+		t.Fatalf("unexpected action type %v", decoded["type"])
+	}
+	if got, ok := decoded["time"].(uint64); !ok || got != at {
+		t.Fatalf("expected time %d, got %#v", at, decoded["time"])
+	}
+
+	b, err = EncodeScheduleCancelAction(ScheduleCancelAction{Type: "scheduleCancel"})
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	decoded = nil
+	if err := msgpack.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if _, ok := decoded["time"]; ok || len(decoded) != 1 {
+		t.Fatalf("expected clear action without time, got %#v", decoded)
+	}
+}
+
 func TestSignerRecover(t *testing.T) {
 	signer, err := NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
 	if err != nil {
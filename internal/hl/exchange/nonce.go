@@ -0,0 +1,267 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// Hyperliquid keeps the nonceWindowSize highest nonces of each signer and
+// accepts a new one only if it is unused, above the smallest of them, and
+// within (T - nonceMaxAge, T + nonceMaxAhead) of the exchange time T.
+const (
+	nonceWindowSize = 100
+	nonceMaxAge     = 48 * time.Hour
+	nonceMaxAhead   = 24 * time.Hour
+)
+
+// nonceWindow is the sliding set of recent nonces one signer used, mirroring
+// the set the exchange checks new nonces against.
+type nonceWindow struct {
+	mu   sync.Mutex
+	used []uint64 // ascending
+}
+
+func (w *nonceWindow) add(nonce uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := sort.Search(len(w.used), func(i int) bool { return w.used[i] >= nonce })
+	if i < len(w.used) && w.used[i] == nonce {
+		return
+	}
+	w.used = append(w.used, 0)
+	copy(w.used[i+1:], w.used[i:])
+	w.used[i] = nonce
+	if len(w.used) > nonceWindowSize {
+		w.used = w.used[len(w.used)-nonceWindowSize:]
+	}
+}
+
+// prune drops nonces that fell below the exchange's lower bound at now.
+func (w *nonceWindow) prune(now uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	floor := nonceFloor(now)
+	i := sort.Search(len(w.used), func(i int) bool { return w.used[i] > floor })
+	w.used = w.used[i:]
+}
+
+func (w *nonceWindow) bounds() (uint64, uint64, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.used) == 0 {
+		return 0, 0, 0
+	}
+	return w.used[0], w.used[len(w.used)-1], len(w.used)
+}
+
+func nonceFloor(now uint64) uint64 {
+	age := uint64(nonceMaxAge.Milliseconds())
+	if now <= age {
+		return 0
+	}
+	return now - age
+}
+
+func nonceCeiling(now uint64) uint64 {
+	return now + uint64(nonceMaxAhead.Milliseconds())
+}
+
+// SetClockOffset sets how far the exchange clock runs ahead of the local one
+// (negative when behind). Nonces are drawn from the corrected time.
+func (c *Client) SetClockOffset(offset time.Duration) {
+	c.clockOffset.Store(int64(offset))
+}
+
+// ClockOffset is the offset last set with SetClockOffset.
+func (c *Client) ClockOffset() time.Duration {
+	return time.Duration(c.clockOffset.Load())
+}
+
+// nonceNow is the exchange time in milliseconds.
+func (c *Client) nonceNow() uint64 {
+	return uint64(time.Now().Add(c.ClockOffset()).UnixMilli())
+}
+
+// SetNonceMetrics counts actions the exchange rejected for their nonce.
+func (c *Client) SetNonceMetrics(rejected interface{ Inc() }) {
+	c.nonceRejected = rejected
+}
+
+// errNoncesShared refuses a signer rotation on a client whose nonces are
+// shared: the other clients would keep signing with the old key.
+var errNoncesShared = errors.New("signer nonces are shared with another client")
+
+// ShareNonces makes c draw its nonces from owner, which signs with the same
+// key. The exchange tracks nonces per signer, so the clients of accounts
+// signed by one key (sub-accounts of a master wallet) need one counter and one
+// window between them; owner's nonce store persists it. Neither client can
+// rotate its signer afterwards.
+func (c *Client) ShareNonces(owner *Client) error {
+	for owner != nil && owner.nonceOwner != nil {
+		owner = owner.nonceOwner
+	}
+	if owner == nil || owner == c {
+		return errors.New("nonce owner is required")
+	}
+	addr := owner.SignerAddress()
+	c.signMu.Lock()
+	defer c.signMu.Unlock()
+	if c.signer == nil || c.signer.Address() != addr {
+		return fmt.Errorf("nonce owner signs as %s, not as this client's signer", addr.Hex())
+	}
+	owner.signMu.RLock()
+	window := owner.window
+	owner.signMu.RUnlock()
+	owner.nonceSharers.Add(1)
+	c.nonceOwner = owner
+	c.window = window
+	return nil
+}
+
+// raiseNonce lifts the last nonce handed out to at least nonce.
+func (c *Client) raiseNonce(nonce uint64) {
+	for {
+		prev := c.lastNonce.Load()
+		if prev >= nonce || c.lastNonce.CompareAndSwap(prev, nonce) {
+			return
+		}
+	}
+}
+
+// useWindowLocked makes the window of signer current. Callers hold signMu.
+func (c *Client) useWindowLocked(signer *Signer) {
+	if signer == nil {
+		return
+	}
+	if c.windows == nil {
+		c.windows = make(map[common.Address]*nonceWindow)
+	}
+	addr := signer.Address()
+	window, ok := c.windows[addr]
+	if !ok {
+		window = &nonceWindow{}
+		c.windows[addr] = window
+	}
+	c.window = window
+}
+
+// recordNonce adds nonce to the signer's window and warns once if it is past
+// the exchange's upper bound, which happens when the last nonce was seeded
+// from a clock running far ahead.
+func (c *Client) recordNonce(nonce, now uint64) {
+	if c.window != nil {
+		c.window.add(nonce)
+		c.window.prune(now)
+	}
+	if nonce <= nonceCeiling(now) {
+		if c.aheadWarned.CompareAndSwap(true, false) && c.log != nil {
+			c.log.Info("nonce back inside the exchange window")
+		}
+		return
+	}
+	if c.aheadWarned.CompareAndSwap(false, true) && c.log != nil {
+		c.log.Warn("nonce is ahead of the exchange window; actions will be rejected until the clock catches up",
+			zap.Uint64("nonce", nonce),
+			zap.Uint64("exchange_time_ms", now),
+		)
+	}
+}
+
+// nonceSeed is the highest of now, the nonces stored for signer, and the
+// last nonce handed out. The store is read under the per-signer key and the
+// older key that also carried the vault, so the first start after upgrading
+// continues from where it left off. Stored nonces past the exchange's upper
+// bound can never have been accepted and are ignored.
+func (c *Client) nonceSeed(ctx context.Context, store NonceStore, signer *Signer) (uint64, error) {
+	now := c.nonceNow()
+	seed := now
+	keys := []string{nonceStoreKey(c.baseURL, signer), legacyNonceStoreKey(c.baseURL, signer, c.vaultAddress)}
+	for _, key := range keys {
+		raw, ok, err := store.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+		stored, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid stored nonce %q: %w", raw, err)
+		}
+		if stored > nonceCeiling(now) {
+			if c.log != nil {
+				c.log.Warn("ignoring stored nonce beyond the exchange window",
+					zap.String("nonce_key", key),
+					zap.Uint64("stored_nonce", stored),
+					zap.Uint64("exchange_time_ms", now),
+				)
+			}
+			continue
+		}
+		if stored > seed {
+			seed = stored
+		}
+	}
+	if current := c.lastNonce.Load(); current > seed {
+		seed = current
+	}
+	return seed, nil
+}
+
+// checkNonceRejection counts and logs an action refused for its nonce.
+func (c *Client) checkNonceRejection(resp map[string]any, nonce uint64) {
+	err := ResponseError(resp)
+	if err == nil || !strings.Contains(strings.ToLower(err.Error()), "nonce") {
+		return
+	}
+	if c.nonceRejected != nil {
+		c.nonceRejected.Inc()
+	}
+	if c.log == nil {
+		return
+	}
+	now := c.nonceNow()
+	fields := []zap.Field{
+		zap.Uint64("nonce", nonce),
+		zap.Uint64("exchange_time_ms", now),
+		zap.Duration("clock_offset", c.ClockOffset()),
+		zap.Error(err),
+	}
+	c.signMu.RLock()
+	window := c.window
+	c.signMu.RUnlock()
+	if window != nil {
+		lowest, highest, size := window.bounds()
+		fields = append(fields, zap.Uint64("window_lowest", lowest), zap.Uint64("window_highest", highest), zap.Int("window_size", size))
+	}
+	c.log.Warn("exchange rejected nonce", fields...)
+}
+
+func nonceStoreKey(baseURL string, signer *Signer) string {
+	return fmt.Sprintf("exchange:nonce:%s:%s", strings.ToLower(strings.TrimSpace(baseURL)), signerKey(signer))
+}
+
+// legacyNonceStoreKey is the key used before nonces were tracked per signer.
+func legacyNonceStoreKey(baseURL string, signer *Signer, vaultAddress *common.Address) string {
+	vault := "none"
+	if vaultAddress != nil {
+		vault = strings.ToLower(vaultAddress.Hex())
+	}
+	return nonceStoreKey(baseURL, signer) + ":" + vault
+}
+
+func signerKey(signer *Signer) string {
+	if signer == nil {
+		return "unknown"
+	}
+	return strings.ToLower(signer.Address().Hex())
+}
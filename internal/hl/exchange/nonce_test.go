@@ -0,0 +1,185 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/state/sqlite"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNonceWindowKeepsHighestAndPrunes(t *testing.T) {
+	var w nonceWindow
+	now := uint64(time.Now().UnixMilli())
+	for i := uint64(0); i < nonceWindowSize+20; i++ {
+		w.add(now + i)
+	}
+	w.add(now + 5)
+	lowest, highest, size := w.bounds()
+	if size != nonceWindowSize || lowest != now+20 || highest != now+nonceWindowSize+19 {
+		t.Fatalf("unexpected window [%d, %d] size %d", lowest, highest, size)
+	}
+	w.prune(now + uint64(nonceMaxAge.Milliseconds()) + 50)
+	if lowest, _, size := w.bounds(); size != nonceWindowSize-31 || lowest != now+51 {
+		t.Fatalf("expected expired nonces pruned, got lowest %d size %d", lowest, size)
+	}
+}
+
+func TestNextNonceUsesClockOffset(t *testing.T) {
+	c := &Client{}
+	c.SetClockOffset(time.Hour)
+	ahead := uint64(time.Now().Add(time.Hour).UnixMilli())
+	if got := c.nextNonce(); got < ahead {
+		t.Fatalf("expected nonce drawn from exchange time >= %d, got %d", ahead, got)
+	}
+}
+
+func TestInitNonceStoreMigratesLegacyKey(t *testing.T) {
+	signer, err := NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("store init: %v", err)
+	}
+	ctx := context.Background()
+	vault := "0x1111111111111111111111111111111111111111"
+	client, err := NewClient("https://api.hyperliquid.xyz", 2*time.Second, signer, vault)
+	if err != nil {
+		t.Fatalf("client init: %v", err)
+	}
+	vaultAddr := common.HexToAddress(vault)
+	legacy := uint64(time.Now().UnixMilli()) + 10_000
+	if err := store.Set(ctx, legacyNonceStoreKey(client.baseURL, signer, &vaultAddr), strconv.FormatUint(legacy, 10)); err != nil {
+		t.Fatalf("store seed: %v", err)
+	}
+	if err := client.InitNonceStore(ctx, store); err != nil {
+		t.Fatalf("init nonce store: %v", err)
+	}
+	state, ok := client.NonceState()
+	if !ok || state.Key != nonceStoreKey(client.baseURL, signer) || state.Last != legacy {
+		t.Fatalf("expected legacy nonce carried to the per-signer key, got %+v", state)
+	}
+	raw, ok, err := store.Get(ctx, state.Key)
+	if err != nil || !ok || raw != strconv.FormatUint(legacy, 10) {
+		t.Fatalf("expected migrated nonce stored, got %q %v %v", raw, ok, err)
+	}
+	if nonce := client.nextNonce(); nonce != legacy+1 {
+		t.Fatalf("expected nonce %d, got %d", legacy+1, nonce)
+	}
+	if state, _ := client.NonceState(); state.WindowSize != 1 || state.WindowLowest != legacy+1 {
+		t.Fatalf("expected the nonce in the window, got %+v", state)
+	}
+}
+
+func TestInitNonceStoreIgnoresNonceBeyondWindow(t *testing.T) {
+	signer, err := NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("store init: %v", err)
+	}
+	ctx := context.Background()
+	client, err := NewClient("https://api.hyperliquid.xyz", 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client init: %v", err)
+	}
+	skewed := uint64(time.Now().Add(3 * nonceMaxAhead).UnixMilli())
+	if err := store.Set(ctx, nonceStoreKey(client.baseURL, signer), strconv.FormatUint(skewed, 10)); err != nil {
+		t.Fatalf("store seed: %v", err)
+	}
+	if err := client.InitNonceStore(ctx, store); err != nil {
+		t.Fatalf("init nonce store: %v", err)
+	}
+	if state, _ := client.NonceState(); state.Last >= skewed {
+		t.Fatalf("expected a nonce past the exchange window to be ignored, got %d", state.Last)
+	}
+}
+
+func TestPostActionCountsNonceRejections(t *testing.T) {
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"err","response":"Invalid nonce: duplicate nonce"}`))
+	})
+	rejected := &countingMetric{}
+	client.SetNonceMetrics(rejected)
+	if _, err := client.CancelOrder(context.Background(), 1, 42); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if rejected.count != 1 {
+		t.Fatalf("expected 1 nonce rejection, got %d", rejected.count)
+	}
+}
+
+type countingMetric struct{ count int }
+
+func (m *countingMetric) Inc() { m.count++ }
+
+func TestShareNoncesDrawsFromOneCounter(t *testing.T) {
+	signer, err := NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", true)
+	if err != nil {
+		t.Fatalf("signer error: %v", err)
+	}
+	owner, err := NewClient("https://api.hyperliquid.xyz", 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client init: %v", err)
+	}
+	sub, err := NewClient("https://api.hyperliquid.xyz", 2*time.Second, signer, "0x1111111111111111111111111111111111111111")
+	if err != nil {
+		t.Fatalf("client init: %v", err)
+	}
+	if err := sub.ShareNonces(owner); err != nil {
+		t.Fatalf("share nonces: %v", err)
+	}
+	ctx := context.Background()
+	ownerStore, err := sqlite.New(filepath.Join(t.TempDir(), "owner.db"))
+	if err != nil {
+		t.Fatalf("store init: %v", err)
+	}
+	subStore, err := sqlite.New(filepath.Join(t.TempDir(), "sub.db"))
+	if err != nil {
+		t.Fatalf("store init: %v", err)
+	}
+	// A nonce the sub-account drew before its nonces were shared.
+	earlier := uint64(time.Now().UnixMilli()) + 10_000
+	if err := subStore.Set(ctx, nonceStoreKey(sub.baseURL, signer), strconv.FormatUint(earlier, 10)); err != nil {
+		t.Fatalf("store seed: %v", err)
+	}
+	if err := sub.InitNonceStore(ctx, subStore); err != nil {
+		t.Fatalf("init sub nonce store: %v", err)
+	}
+	if err := owner.InitNonceStore(ctx, ownerStore); err != nil {
+		t.Fatalf("init owner nonce store: %v", err)
+	}
+
+	last := earlier
+	for i := 0; i < 4; i++ {
+		client := owner
+		if i%2 == 0 {
+			client = sub
+		}
+		nonce := client.nextNonce()
+		if nonce <= last {
+			t.Fatalf("expected nonces to keep rising across clients, got %d after %d", nonce, last)
+		}
+		last = nonce
+	}
+	raw, ok, err := ownerStore.Get(ctx, nonceStoreKey(owner.baseURL, signer))
+	if err != nil || !ok || raw != strconv.FormatUint(last, 10) {
+		t.Fatalf("expected the owner's store to persist the shared nonce %d, got %q %v %v", last, raw, ok, err)
+	}
+	if state, _ := sub.NonceState(); state.Last != last || state.WindowSize != 4 {
+		t.Fatalf("expected the sub-account to report the shared nonces, got %+v", state)
+	}
+	if err := owner.RotateSigner(ctx, signer); err == nil {
+		t.Fatalf("expected the owner's rotation refused while its nonces are shared")
+	}
+}
@@ -1,7 +1,14 @@
 package exchange
 
-import "strconv"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
 
+// OrderIDFromResponse returns the first oid found anywhere in resp; use
+// OrderResults for the per-order status.
 func OrderIDFromResponse(resp map[string]any) string {
 	if resp == nil {
 		return ""
@@ -9,6 +16,207 @@ func OrderIDFromResponse(resp map[string]any) string {
 	return orderIDFromAny(resp)
 }
 
+// ResponseError returns the exchange error carried by a 200 response with
+// status "err", or nil when the action was accepted.
+func ResponseError(resp map[string]any) error {
+	if resp == nil {
+		return errors.New("empty exchange response")
+	}
+	if !strings.EqualFold(stringFromAny(resp["status"]), "err") {
+		return nil
+	}
+	msg := stringFromAny(resp["response"])
+	if msg == "" {
+		msg = "unknown exchange error"
+	}
+	return errors.New(msg)
+}
+
+// Per-order rejection classes, matched from the error text in
+// response.data.statuses[i].error.
+var (
+	// ErrPostOnlyCross is returned for a post-only (ALO) order the exchange
+	// rejected because it would have matched on arrival.
+	ErrPostOnlyCross = errors.New("post-only order would cross")
+	// ErrInsufficientMargin covers both perp margin and spot balance
+	// shortfalls.
+	ErrInsufficientMargin = errors.New("insufficient margin")
+	ErrInvalidPrice       = errors.New("invalid order price")
+	ErrInvalidSize        = errors.New("invalid order size")
+	ErrMinOrderValue      = errors.New("order below minimum value")
+	ErrReduceOnly         = errors.New("reduce-only order would increase position")
+	ErrNoImmediateMatch   = errors.New("ioc order did not match")
+	ErrPriceBand          = errors.New("order price too far from reference")
+)
+
+// OrderStatus is the per-order outcome in an order response.
+type OrderStatus string
+
+const (
+	OrderStatusResting           OrderStatus = "resting"
+	OrderStatusFilled            OrderStatus = "filled"
+	OrderStatusWaitingForTrigger OrderStatus = "waitingForTrigger"
+	OrderStatusRejected          OrderStatus = "error"
+)
+
+// OrderResult is one entry of response.data.statuses. FilledSize and
+// AvgPrice are set for filled orders; Err carries the classified rejection
+// for rejected (error) statuses.
+type OrderResult struct {
+	Status     OrderStatus
+	OrderID    string
+	Cloid      string
+	FilledSize float64
+	AvgPrice   float64
+	Err        error
+}
+
+// OrderResults parses the per-order statuses of an order response, one per
+// submitted order. A response-level "err" status is returned as the error.
+func OrderResults(resp map[string]any) ([]OrderResult, error) {
+	if err := ResponseError(resp); err != nil {
+		return nil, err
+	}
+	response, _ := resp["response"].(map[string]any)
+	data, _ := response["data"].(map[string]any)
+	statuses, ok := data["statuses"].([]any)
+	if !ok {
+		return nil, errors.New("order response missing statuses")
+	}
+	results := make([]OrderResult, 0, len(statuses))
+	for i, raw := range statuses {
+		result, err := parseOrderStatus(raw)
+		if err != nil {
+			return nil, fmt.Errorf("order status %d: %w", i, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func parseOrderStatus(raw any) (OrderResult, error) {
+	switch status := raw.(type) {
+	case string:
+		if status == string(OrderStatusWaitingForTrigger) {
+			return OrderResult{Status: OrderStatusWaitingForTrigger}, nil
+		}
+	case map[string]any:
+		if msg := stringFromAny(status["error"]); msg != "" {
+			return OrderResult{Status: OrderStatusRejected, Err: classifyOrderError(msg)}, nil
+		}
+		if resting, ok := status["resting"].(map[string]any); ok {
+			return OrderResult{
+				Status:  OrderStatusResting,
+				OrderID: stringFromAny(resting["oid"]),
+				Cloid:   stringFromAny(resting["cloid"]),
+			}, nil
+		}
+		if filled, ok := status["filled"].(map[string]any); ok {
+			return OrderResult{
+				Status:     OrderStatusFilled,
+				OrderID:    stringFromAny(filled["oid"]),
+				Cloid:      stringFromAny(filled["cloid"]),
+				FilledSize: floatFromAny(filled["totalSz"]),
+				AvgPrice:   floatFromAny(filled["avgPx"]),
+			}, nil
+		}
+		if _, ok := status[string(OrderStatusWaitingForTrigger)]; ok {
+			return OrderResult{Status: OrderStatusWaitingForTrigger}, nil
+		}
+	}
+	return OrderResult{}, fmt.Errorf("unrecognized order status %v", raw)
+}
+
+// OrderStatusError returns the first per-order error in an order response
+// (response.data.statuses[i].error), or nil when every status was accepted.
+// The error wraps its rejection class, e.g. ErrPostOnlyCross.
+func OrderStatusError(resp map[string]any) error {
+	response, _ := resp["response"].(map[string]any)
+	data, _ := response["data"].(map[string]any)
+	statuses, _ := data["statuses"].([]any)
+	for _, raw := range statuses {
+		status, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if msg := stringFromAny(status["error"]); msg != "" {
+			return classifyOrderError(msg)
+		}
+	}
+	return nil
+}
+
+// CancelResults parses the per-order statuses of a cancel response, one per
+// submitted cancel: nil for "success", otherwise the exchange's error (e.g.
+// the order was already cancelled or filled). A response-level "err" status
+// is returned as the error.
+func CancelResults(resp map[string]any) ([]error, error) {
+	if err := ResponseError(resp); err != nil {
+		return nil, err
+	}
+	response, _ := resp["response"].(map[string]any)
+	data, _ := response["data"].(map[string]any)
+	statuses, ok := data["statuses"].([]any)
+	if !ok {
+		return nil, errors.New("cancel response missing statuses")
+	}
+	results := make([]error, len(statuses))
+	for i, raw := range statuses {
+		switch status := raw.(type) {
+		case string:
+			if status != "success" {
+				results[i] = fmt.Errorf("unrecognized cancel status %q", status)
+			}
+		case map[string]any:
+			msg := stringFromAny(status["error"])
+			if msg == "" {
+				msg = fmt.Sprintf("unrecognized cancel status %v", raw)
+			}
+			results[i] = errors.New(msg)
+		default:
+			results[i] = fmt.Errorf("unrecognized cancel status %v", raw)
+		}
+	}
+	return results, nil
+}
+
+// orderRejections maps lowercase fragments of Hyperliquid's rejection text
+// to their class; all fragments of an entry must match.
+var orderRejections = []struct {
+	fragments []string
+	class     error
+}{
+	{[]string{"post only", "immediately matched"}, ErrPostOnlyCross},
+	{[]string{"insufficient margin"}, ErrInsufficientMargin},
+	{[]string{"insufficient spot balance"}, ErrInsufficientMargin},
+	{[]string{"tick size"}, ErrInvalidPrice},
+	{[]string{"invalid price"}, ErrInvalidPrice},
+	{[]string{"invalid size"}, ErrInvalidSize},
+	{[]string{"minimum value"}, ErrMinOrderValue},
+	{[]string{"reduce only", "increase position"}, ErrReduceOnly},
+	{[]string{"could not immediately match"}, ErrNoImmediateMatch},
+	{[]string{"away from the reference price"}, ErrPriceBand},
+}
+
+// classifyOrderError wraps msg with its rejection class, or returns it plain
+// when the text is not recognized.
+func classifyOrderError(msg string) error {
+	lower := strings.ToLower(msg)
+	for _, rejection := range orderRejections {
+		matched := true
+		for _, fragment := range rejection.fragments {
+			if !strings.Contains(lower, fragment) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return fmt.Errorf("%w: %s", rejection.class, msg)
+		}
+	}
+	return errors.New(msg)
+}
+
 func stringFromAny(v any) string {
 	switch val := v.(type) {
 	case string:
@@ -24,6 +232,21 @@ func stringFromAny(v any) string {
 	}
 }
 
+func floatFromAny(v any) float64 {
+	switch val := v.(type) {
+	case string:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return 0
+		}
+		return f
+	case float64:
+		return val
+	default:
+		return 0
+	}
+}
+
 func orderIDFromAny(v any) string {
 	switch val := v.(type) {
 	case map[string]any:
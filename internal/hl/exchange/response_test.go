@@ -1,6 +1,9 @@
 package exchange
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestOrderIDFromResponseStatusFilled(t *testing.T) {
 	resp := map[string]any{
@@ -24,3 +27,108 @@ func TestOrderIDFromResponseStatusFilled(t *testing.T) {
 		t.Fatalf("expected order id 292577153770, got %s", got)
 	}
 }
+
+func TestResponseError(t *testing.T) {
+	if err := ResponseError(map[string]any{"status": "ok", "response": map[string]any{"type": "default"}}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	err := ResponseError(map[string]any{"status": "err", "response": "Scheduled cancel time too early"})
+	if err == nil || err.Error() != "Scheduled cancel time too early" {
+		t.Fatalf("expected exchange error, got %v", err)
+	}
+	if err := ResponseError(nil); err == nil {
+		t.Fatalf("expected error for empty response")
+	}
+}
+
+func TestOrderStatusError(t *testing.T) {
+	statusResp := func(status map[string]any) map[string]any {
+		return map[string]any{
+			"status": "ok",
+			"response": map[string]any{
+				"type": "order",
+				"data": map[string]any{"statuses": []any{status}},
+			},
+		}
+	}
+	if err := OrderStatusError(statusResp(map[string]any{"resting": map[string]any{"oid": float64(1)}})); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	err := OrderStatusError(statusResp(map[string]any{"error": "Post only order would have immediately matched, bbo was 2999.9@3000.1. asset=10051"}))
+	if !errors.Is(err, ErrPostOnlyCross) {
+		t.Fatalf("expected ErrPostOnlyCross, got %v", err)
+	}
+	err = OrderStatusError(statusResp(map[string]any{"error": "Insufficient margin to place order."}))
+	if err == nil || errors.Is(err, ErrPostOnlyCross) {
+		t.Fatalf("expected plain order error, got %v", err)
+	}
+}
+
+func TestOrderResults(t *testing.T) {
+	resp := map[string]any{
+		"status": "ok",
+		"response": map[string]any{
+			"type": "order",
+			"data": map[string]any{"statuses": []any{
+				map[string]any{"resting": map[string]any{"oid": float64(77738308), "cloid": "0x01"}},
+				map[string]any{"filled": map[string]any{"totalSz": "0.02", "avgPx": "1891.4", "oid": float64(77747314)}},
+				"waitingForTrigger",
+				map[string]any{"error": "Insufficient margin to place order. asset=1"},
+				map[string]any{"error": "Price must be divisible by tick size. asset=1"},
+				map[string]any{"error": "Order could not immediately match against any resting orders. asset=1"},
+			}},
+		},
+	}
+	results, err := OrderResults(resp)
+	if err != nil {
+		t.Fatalf("order results: %v", err)
+	}
+	if len(results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(results))
+	}
+	if r := results[0]; r.Status != OrderStatusResting || r.OrderID != "77738308" || r.Cloid != "0x01" {
+		t.Fatalf("unexpected resting result %+v", r)
+	}
+	if r := results[1]; r.Status != OrderStatusFilled || r.OrderID != "77747314" || r.FilledSize != 0.02 || r.AvgPrice != 1891.4 {
+		t.Fatalf("unexpected filled result %+v", r)
+	}
+	if r := results[2]; r.Status != OrderStatusWaitingForTrigger {
+		t.Fatalf("unexpected trigger result %+v", r)
+	}
+	for i, want := range []error{ErrInsufficientMargin, ErrInvalidPrice, ErrNoImmediateMatch} {
+		r := results[3+i]
+		if r.Status != OrderStatusRejected || !errors.Is(r.Err, want) {
+			t.Fatalf("expected %v rejection, got %+v", want, r)
+		}
+	}
+
+	if _, err := OrderResults(map[string]any{"status": "err", "response": "User or API Wallet does not exist."}); err == nil {
+		t.Fatalf("expected response-level error")
+	}
+	if _, err := OrderResults(map[string]any{"status": "ok"}); err == nil {
+		t.Fatalf("expected error for missing statuses")
+	}
+}
+
+func TestCancelResults(t *testing.T) {
+	resp := map[string]any{
+		"status": "ok",
+		"response": map[string]any{
+			"type": "cancel",
+			"data": map[string]any{"statuses": []any{
+				"success",
+				map[string]any{"error": "Order was never placed, already canceled, or filled. asset=1"},
+			}},
+		},
+	}
+	results, err := CancelResults(resp)
+	if err != nil {
+		t.Fatalf("cancel results: %v", err)
+	}
+	if len(results) != 2 || results[0] != nil || results[1] == nil {
+		t.Fatalf("unexpected cancel results %v", results)
+	}
+	if _, err := CancelResults(map[string]any{"status": "err", "response": "User or API Wallet does not exist."}); err == nil {
+		t.Fatalf("expected response-level error")
+	}
+}
@@ -32,6 +32,15 @@ func NewSigner(hexKey string, isMainnet bool) (*Signer, error) {
 	if err != nil {
 		return nil, err
 	}
+	return NewSignerFromKey(key, isMainnet)
+}
+
+// NewSignerFromKey signs with an already decrypted key, e.g. one unlocked
+// from a keystore, so the key never passes through a hex string.
+func NewSignerFromKey(key *ecdsa.PrivateKey, isMainnet bool) (*Signer, error) {
+	if key == nil {
+		return nil, errors.New("private key is required")
+	}
 	addr := crypto.PubkeyToAddress(key.PublicKey)
 	return &Signer{privKey: key, address: addr, isMainnet: isMainnet}, nil
 }
@@ -74,6 +83,74 @@ func (s *Signer) SignCancelAction(action CancelAction, nonce uint64, vaultAddres
 	return signatureFromBytes(sig)
 }
 
+func (s *Signer) SignScheduleCancelAction(action ScheduleCancelAction, nonce uint64, vaultAddress *common.Address, expiresAfter *uint64) (Signature, error) {
+	payload, err := EncodeScheduleCancelAction(action)
+	if err != nil {
+		return Signature{}, err
+	}
+	hash := actionHash(payload, nonce, vaultAddress, expiresAfter)
+	digest, err := typedDataHash(hash, s.isMainnet)
+	if err != nil {
+		return Signature{}, err
+	}
+	sig, err := crypto.Sign(digest, s.privKey)
+	if err != nil {
+		return Signature{}, err
+	}
+	return signatureFromBytes(sig)
+}
+
+func (s *Signer) SignVaultTransferAction(action VaultTransferAction, nonce uint64, vaultAddress *common.Address, expiresAfter *uint64) (Signature, error) {
+	payload, err := EncodeVaultTransferAction(action)
+	if err != nil {
+		return Signature{}, err
+	}
+	hash := actionHash(payload, nonce, vaultAddress, expiresAfter)
+	digest, err := typedDataHash(hash, s.isMainnet)
+	if err != nil {
+		return Signature{}, err
+	}
+	sig, err := crypto.Sign(digest, s.privKey)
+	if err != nil {
+		return Signature{}, err
+	}
+	return signatureFromBytes(sig)
+}
+
+func (s *Signer) SignUpdateLeverageAction(action UpdateLeverageAction, nonce uint64, vaultAddress *common.Address, expiresAfter *uint64) (Signature, error) {
+	payload, err := EncodeUpdateLeverageAction(action)
+	if err != nil {
+		return Signature{}, err
+	}
+	hash := actionHash(payload, nonce, vaultAddress, expiresAfter)
+	digest, err := typedDataHash(hash, s.isMainnet)
+	if err != nil {
+		return Signature{}, err
+	}
+	sig, err := crypto.Sign(digest, s.privKey)
+	if err != nil {
+		return Signature{}, err
+	}
+	return signatureFromBytes(sig)
+}
+
+func (s *Signer) SignUpdateIsolatedMarginAction(action UpdateIsolatedMarginAction, nonce uint64, vaultAddress *common.Address, expiresAfter *uint64) (Signature, error) {
+	payload, err := EncodeUpdateIsolatedMarginAction(action)
+	if err != nil {
+		return Signature{}, err
+	}
+	hash := actionHash(payload, nonce, vaultAddress, expiresAfter)
+	digest, err := typedDataHash(hash, s.isMainnet)
+	if err != nil {
+		return Signature{}, err
+	}
+	sig, err := crypto.Sign(digest, s.privKey)
+	if err != nil {
+		return Signature{}, err
+	}
+	return signatureFromBytes(sig)
+}
+
 func (s *Signer) SignUSDClassTransfer(action *USDClassTransferAction) (Signature, error) {
 	if action == nil {
 		return Signature{}, errors.New("usd class transfer action is required")
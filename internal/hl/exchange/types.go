@@ -12,8 +12,25 @@ type LimitOrderType struct {
 	Tif Tif `json:"tif"`
 }
 
+// Tpsl marks a trigger order as a stop loss or take profit.
+type Tpsl string
+
+const (
+	TpslStopLoss   Tpsl = "sl"
+	TpslTakeProfit Tpsl = "tp"
+)
+
+// TriggerOrderType rests off-book until the mark price reaches TriggerPx,
+// then executes as a market order (IsMarket) or a limit at the order price.
+type TriggerOrderType struct {
+	IsMarket  bool   `json:"isMarket"`
+	TriggerPx string `json:"triggerPx"`
+	Tpsl      Tpsl   `json:"tpsl"`
+}
+
 type OrderTypeWire struct {
-	Limit *LimitOrderType `json:"limit,omitempty"`
+	Limit   *LimitOrderType   `json:"limit,omitempty"`
+	Trigger *TriggerOrderType `json:"trigger,omitempty"`
 }
 
 type OrderWire struct {
@@ -43,6 +60,32 @@ type CancelAction struct {
 	Cancels []CancelWire `json:"cancels"`
 }
 
+type ScheduleCancelAction struct {
+	Type string  `json:"type"`
+	Time *uint64 `json:"time,omitempty"`
+}
+
+type VaultTransferAction struct {
+	Type         string `json:"type"`
+	VaultAddress string `json:"vaultAddress"`
+	IsDeposit    bool   `json:"isDeposit"`
+	Usd          uint64 `json:"usd"`
+}
+
+type UpdateLeverageAction struct {
+	Type     string `json:"type"`
+	Asset    int    `json:"asset"`
+	IsCross  bool   `json:"isCross"`
+	Leverage int    `json:"leverage"`
+}
+
+type UpdateIsolatedMarginAction struct {
+	Type  string `json:"type"`
+	Asset int    `json:"asset"`
+	IsBuy bool   `json:"isBuy"`
+	Ntli  int64  `json:"ntli"`
+}
+
 type USDClassTransferAction struct {
 	Type             string `json:"type"`
 	Amount           string `json:"amount"`
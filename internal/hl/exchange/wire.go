@@ -9,8 +9,12 @@ import (
 )
 
 func LimitOrderWire(asset int, isBuy bool, size, limit float64, reduceOnly bool, tif Tif, cloid string) (OrderWire, error) {
-	if tif == "" {
+	switch tif {
+	case TifAlo, TifIoc, TifGtc:
+	case "":
 		return OrderWire{}, errors.New("tif is required")
+	default:
+		return OrderWire{}, fmt.Errorf("unsupported tif %q", tif)
 	}
 	price, err := floatToWire(limit)
 	if err != nil {
@@ -31,6 +35,43 @@ func LimitOrderWire(asset int, isBuy bool, size, limit float64, reduceOnly bool,
 	}, nil
 }
 
+// TriggerOrderWire builds a stop-loss or take-profit order that triggers on
+// the mark price. Trigger orders on Hyperliquid still carry a limit price,
+// which bounds slippage when a market trigger fires.
+func TriggerOrderWire(asset int, isBuy bool, size, limit, triggerPx float64, isMarket, reduceOnly bool, tpsl Tpsl, cloid string) (OrderWire, error) {
+	switch tpsl {
+	case TpslStopLoss, TpslTakeProfit:
+	case "":
+		return OrderWire{}, errors.New("tpsl is required")
+	default:
+		return OrderWire{}, fmt.Errorf("unsupported tpsl %q", tpsl)
+	}
+	if triggerPx <= 0 {
+		return OrderWire{}, errors.New("trigger price must be positive")
+	}
+	price, err := floatToWire(limit)
+	if err != nil {
+		return OrderWire{}, fmt.Errorf("limit price: %w", err)
+	}
+	sizeWire, err := floatToWire(size)
+	if err != nil {
+		return OrderWire{}, fmt.Errorf("size: %w", err)
+	}
+	trigger, err := floatToWire(triggerPx)
+	if err != nil {
+		return OrderWire{}, fmt.Errorf("trigger price: %w", err)
+	}
+	return OrderWire{
+		Asset:      asset,
+		IsBuy:      isBuy,
+		Price:      price,
+		Size:       sizeWire,
+		ReduceOnly: reduceOnly,
+		OrderType:  OrderTypeWire{Trigger: &TriggerOrderType{IsMarket: isMarket, TriggerPx: trigger, Tpsl: tpsl}},
+		Cloid:      cloid,
+	}, nil
+}
+
 func floatToWire(x float64) (string, error) {
 	rounded := fmt.Sprintf("%.8f", x)
 	parsed, err := strconv.ParseFloat(rounded, 64)
@@ -0,0 +1,115 @@
+// Package precision implements Hyperliquid's order price and size increments.
+// Sizes are multiples of 10^-szDecimals; prices carry at most five
+// significant figures and at most MaxPerpDecimals (perps) or MaxSpotDecimals
+// (spot) minus szDecimals decimals, with integer prices always allowed.
+package precision
+
+import (
+	"math"
+	"strconv"
+)
+
+const (
+	MaxPerpDecimals = 6
+	MaxSpotDecimals = 8
+	MaxSigFigs      = 5
+)
+
+// epsilon absorbs binary floating-point error when a value already sits on
+// an increment, e.g. 0.29*100 = 28.999999999999996.
+const epsilon = 1e-9
+
+// Increments are the order increments of one asset. A negative SzDecimals
+// means the asset's metadata is unknown: sizes are left unrounded and prices
+// use the market's maximum decimals.
+type Increments struct {
+	SzDecimals    int
+	PriceDecimals int
+}
+
+// Perp returns the increments of a perp with the given szDecimals.
+func Perp(szDecimals int) Increments {
+	return increments(MaxPerpDecimals, szDecimals)
+}
+
+// Spot returns the increments of a spot pair whose base token has the given
+// szDecimals.
+func Spot(szDecimals int) Increments {
+	return increments(MaxSpotDecimals, szDecimals)
+}
+
+func increments(maxDecimals, szDecimals int) Increments {
+	priceDecimals := maxDecimals
+	if szDecimals >= 0 {
+		priceDecimals = max(maxDecimals-szDecimals, 0)
+	}
+	return Increments{SzDecimals: szDecimals, PriceDecimals: priceDecimals}
+}
+
+// LotSize is the smallest size increment, or 0 when unknown.
+func (i Increments) LotSize() float64 {
+	if i.SzDecimals < 0 {
+		return 0
+	}
+	return math.Pow10(-i.SzDecimals)
+}
+
+// RoundSize rounds size down to the lot size so an order never exceeds the
+// intended amount.
+func (i Increments) RoundSize(size float64) float64 {
+	if i.SzDecimals < 0 {
+		return size
+	}
+	return RoundDown(size, i.SzDecimals)
+}
+
+// RoundSizeUp rounds size up to the lot size, for an order that must reach
+// a minimum.
+func (i Increments) RoundSizeUp(size float64) float64 {
+	if i.SzDecimals < 0 {
+		return size
+	}
+	lot := i.LotSize()
+	return Round(math.Ceil(size/lot-epsilon)*lot, i.SzDecimals)
+}
+
+// RoundPrice rounds price to the nearest valid tick.
+func (i Increments) RoundPrice(price float64) float64 {
+	if price == 0 {
+		return 0
+	}
+	if math.Abs(price) >= math.Pow10(MaxSigFigs) {
+		return math.Round(price)
+	}
+	if sig, err := strconv.ParseFloat(strconv.FormatFloat(price, 'g', MaxSigFigs, 64), 64); err == nil {
+		price = sig
+	}
+	return Round(price, i.PriceDecimals)
+}
+
+// LimitPrice rounds price to the tick of a perp or spot asset with the given
+// szDecimals.
+func LimitPrice(price float64, isSpot bool, szDecimals int) float64 {
+	if isSpot {
+		return Spot(szDecimals).RoundPrice(price)
+	}
+	return Perp(szDecimals).RoundPrice(price)
+}
+
+// RoundDown truncates value to decimals places.
+func RoundDown(value float64, decimals int) float64 {
+	if decimals <= 0 {
+		return math.Floor(value + epsilon)
+	}
+	factor := math.Pow10(decimals)
+	return math.Floor(value*factor+epsilon) / factor
+}
+
+// Round rounds value to decimals places.
+func Round(value float64, decimals int) float64 {
+	if decimals <= 0 {
+		return math.Round(value)
+	}
+	factor := math.Pow10(decimals)
+	return math.Round(value*factor) / factor
+}
@@ -0,0 +1,66 @@
+package precision
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRoundDown(t *testing.T) {
+	if got := RoundDown(1.239, 2); math.Abs(got-1.23) > 1e-9 {
+		t.Fatalf("expected 1.23, got %f", got)
+	}
+	if got := RoundDown(0.29, 2); got != 0.29 {
+		t.Fatalf("expected a value on the increment to be kept, got %v", got)
+	}
+}
+
+func TestLimitPriceDecimals(t *testing.T) {
+	price := LimitPrice(123.456789, true, 2)
+	scaled := price * 1e6
+	if math.Abs(scaled-math.Round(scaled)) > 1e-9 {
+		t.Fatalf("expected spot price rounded to 6 decimals, got %f", price)
+	}
+	perpPrice := LimitPrice(123.456789, false, 1)
+	perpScaled := perpPrice * 1e5
+	if math.Abs(perpScaled-math.Round(perpScaled)) > 1e-9 {
+		t.Fatalf("expected perp price rounded to 5 decimals, got %f", perpPrice)
+	}
+}
+
+func TestIncrements(t *testing.T) {
+	cases := []struct {
+		name  string
+		inc   Increments
+		price float64
+		want  float64
+	}{
+		{"sig figs", Perp(4), 3012.345, 3012.3},
+		{"price decimals", Perp(4), 0.123456, 0.12},
+		{"integer price kept", Perp(0), 123456.7, 123457},
+		{"spot decimals", Spot(0), 0.000123456, 0.00012346},
+		{"unknown sz decimals", Perp(-1), 0.1234567, 0.12346},
+	}
+	for _, tc := range cases {
+		if got := tc.inc.RoundPrice(tc.price); math.Abs(got-tc.want) > 1e-12 {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+	if got := Perp(3).RoundSize(1.23456); got != 1.234 {
+		t.Fatalf("expected size 1.234, got %v", got)
+	}
+	if got := Perp(-1).RoundSize(1.23456); got != 1.23456 {
+		t.Fatalf("expected unknown lot size to leave size unrounded, got %v", got)
+	}
+	if got := Perp(3).RoundSizeUp(1.2341); got != 1.235 {
+		t.Fatalf("expected size rounded up to 1.235, got %v", got)
+	}
+	if got := Perp(2).RoundSizeUp(0.29); got != 0.29 {
+		t.Fatalf("expected a size on the lot kept, got %v", got)
+	}
+	if got := Spot(2).LotSize(); got != 0.01 {
+		t.Fatalf("expected lot size 0.01, got %v", got)
+	}
+	if inc := Spot(10); inc.PriceDecimals != 0 {
+		t.Fatalf("expected price decimals clamped at 0, got %d", inc.PriceDecimals)
+	}
+}
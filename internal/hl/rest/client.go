@@ -4,77 +4,110 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"time"
 
+	"hl-carry-bot/internal/metrics"
+
 	"go.uber.org/zap"
 )
 
 type Client struct {
-	baseURL string
-	http    *http.Client
-	log     *zap.Logger
+	baseURL    string
+	http       *http.Client
+	log        *zap.Logger
+	limiter    *Limiter
+	retry      RetryPolicy
+	latency    metrics.ObserverVec
+	retries    metrics.CounterVec
+	middleware []Middleware
 }
 
+// New returns a client that logs every request at debug and retries 429 and
+// 5xx answers per DefaultRetryPolicy.
 func New(baseURL string, timeout time.Duration, log *zap.Logger) *Client {
 	return &Client{
 		baseURL: baseURL,
 		http: &http.Client{
 			Timeout: timeout,
 		},
-		log: log,
+		log:   log,
+		retry: DefaultRetryPolicy(),
 	}
 }
 
+// SetLimiter shares a weight budget with other clients hitting the same IP
+// limit; nil disables limiting.
+func (c *Client) SetLimiter(l *Limiter) {
+	c.limiter = l
+}
+
+// SetRetry replaces the retry policy; MaxAttempts 1 disables retries.
+func (c *Client) SetRetry(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// SetMetrics reports per-endpoint latency and retries.
+func (c *Client) SetMetrics(latency metrics.ObserverVec, retries metrics.CounterVec) {
+	c.latency = latency
+	c.retries = retries
+}
+
+// Use adds middleware inside the built-in logging, retry, and latency ones,
+// so it sees every attempt. The first added is the outermost.
+func (c *Client) Use(mws ...Middleware) {
+	c.middleware = append(c.middleware, mws...)
+}
+
 type InfoRequest struct {
 	Type string `json:"type"`
 	User string `json:"user,omitempty"`
 }
 
 func (c *Client) Info(ctx context.Context, req interface{}) (map[string]any, error) {
-	return c.post(ctx, "/info", req)
+	var data map[string]any
+	if err := c.post(ctx, "/info", req, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
 }
 
 func (c *Client) InfoAny(ctx context.Context, req interface{}) (any, error) {
-	return c.postAny(ctx, "/info", req)
+	var data any
+	if err := c.post(ctx, "/info", req, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
 }
 
-func (c *Client) post(ctx context.Context, path string, req interface{}) (map[string]any, error) {
+func (c *Client) post(ctx context.Context, path string, req interface{}, out any) error {
 	payload, err := json.Marshal(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	url := c.baseURL + path
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
-	if err != nil {
-		return nil, err
+	call := &Request{Path: path, Endpoint: path, Payload: payload}
+	if path == "/info" {
+		call.Weight = InfoWeight(payload)
+		if kind := infoType(payload); kind != "" {
+			call.Endpoint = kind
+		}
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	resp, err := c.http.Do(httpReq)
+	mws := append([]Middleware{Tracing(), Logging(c.log), Retry(c.retry, c.log, c.retries), Latency(c.latency)}, c.middleware...)
+	body, err := Chain(c.send, mws...)(ctx, call)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-		return nil, fmt.Errorf("http %d: %s", resp.StatusCode, string(body))
+		return err
 	}
-	var data map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, err
-	}
-	return data, nil
+	return json.Unmarshal(body, out)
 }
 
-func (c *Client) postAny(ctx context.Context, path string, req interface{}) (any, error) {
-	payload, err := json.Marshal(req)
-	if err != nil {
+// send is the end of the chain: one weighted POST, with a 429 draining the
+// shared budget.
+func (c *Client) send(ctx context.Context, req *Request) ([]byte, error) {
+	if err := c.limiter.Wait(ctx, req.Weight); err != nil {
 		return nil, err
 	}
-	url := c.baseURL + path
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+req.Path, bytes.NewReader(req.Payload))
 	if err != nil {
 		return nil, err
 	}
@@ -84,13 +117,11 @@ func (c *Client) postAny(ctx context.Context, path string, req interface{}) (any
 		return nil, err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-		return nil, fmt.Errorf("http %d: %s", resp.StatusCode, string(body))
+	if resp.StatusCode == http.StatusTooManyRequests {
+		c.limiter.Drain()
 	}
-	var data any
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+	if err := ResponseError(resp); err != nil {
 		return nil, err
 	}
-	return data, nil
+	return io.ReadAll(resp.Body)
 }
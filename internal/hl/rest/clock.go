@@ -0,0 +1,27 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ClockOffset estimates how far the exchange clock is ahead of the local one
+// from the exchangeStatus timestamp. The local reference is the midpoint of
+// the request, so the estimate is off by at most half the round trip, which
+// is returned alongside.
+func (c *Client) ClockOffset(ctx context.Context) (time.Duration, time.Duration, error) {
+	sent := time.Now()
+	status, err := c.Info(ctx, InfoRequest{Type: "exchangeStatus"})
+	if err != nil {
+		return 0, 0, err
+	}
+	received := time.Now()
+	serverMS, _ := status["time"].(float64)
+	if serverMS <= 0 {
+		return 0, 0, errors.New("exchangeStatus response has no time")
+	}
+	rtt := received.Sub(sent)
+	local := sent.Add(rtt / 2)
+	return time.UnixMilli(int64(serverMS)).Sub(local), rtt, nil
+}
@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestClockOffset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req InfoRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Type != "exchangeStatus" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"time": time.Now().Add(-3 * time.Second).UnixMilli()})
+	}))
+	defer srv.Close()
+
+	offset, rtt, err := New(srv.URL, time.Second, zap.NewNop()).ClockOffset(context.Background())
+	if err != nil {
+		t.Fatalf("clock offset: %v", err)
+	}
+	if diff := offset + 3*time.Second; diff < -rtt-time.Millisecond || diff > rtt+time.Millisecond {
+		t.Fatalf("expected offset near -3s within rtt %s, got %s", rtt, offset)
+	}
+}
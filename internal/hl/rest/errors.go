@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBody bounds how much of a failed response is kept in its error.
+const maxErrorBody = 2048
+
+// RateLimitedError is an HTTP 429 answer. RetryAfter is the server's
+// Retry-After hint, zero when it sent none.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("http %d: %s", http.StatusTooManyRequests, e.Body)
+}
+
+// ServerError is an HTTP 5xx answer.
+type ServerError struct {
+	Status int
+	Body   string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("http %d: %s", e.Status, e.Body)
+}
+
+// StatusError is any other non-2xx answer. The request itself was refused,
+// so it is not retried.
+type StatusError struct {
+	Status int
+	Body   string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http %d: %s", e.Status, e.Body)
+}
+
+// ResponseError returns the typed error for a non-2xx response, or nil for a
+// 2xx one. At most 2 KB of the body is read into the error.
+func ResponseError(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitedError{RetryAfter: retryAfter(resp.Header.Get("Retry-After")), Body: string(body)}
+	case resp.StatusCode >= 500:
+		return &ServerError{Status: resp.StatusCode, Body: string(body)}
+	default:
+		return &StatusError{Status: resp.StatusCode, Body: string(body)}
+	}
+}
+
+// Retryable reports whether err is a 429 or 5xx answer, which are safe to
+// send again.
+func Retryable(err error) bool {
+	var limited *RateLimitedError
+	var server *ServerError
+	return errors.As(err, &limited) || errors.As(err, &server)
+}
+
+// retryAfter parses a Retry-After header given in seconds; the HTTP-date
+// form is not used by the exchange.
+func retryAfter(header string) time.Duration {
+	secs, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
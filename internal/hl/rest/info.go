@@ -0,0 +1,313 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Number is a decimal the exchange sends as a JSON string ("2000.5"), or in
+// places as a plain number. null leaves it zero.
+type Number float64
+
+func (n *Number) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			return nil
+		}
+		data = []byte(s)
+	}
+	f, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("number %s: %w", data, err)
+	}
+	*n = Number(f)
+	return nil
+}
+
+func (n Number) Float() float64 {
+	return float64(n)
+}
+
+// ClearinghouseState is the perp account from clearinghouseState.
+type ClearinghouseState struct {
+	MarginSummary              *MarginSummary  `json:"marginSummary"`
+	CrossMarginSummary         *MarginSummary  `json:"crossMarginSummary"`
+	CrossMaintenanceMarginUsed *Number         `json:"crossMaintenanceMarginUsed"`
+	Withdrawable               *Number         `json:"withdrawable"`
+	AssetPositions             []AssetPosition `json:"assetPositions"`
+	Time                       int64           `json:"time"`
+}
+
+// MarginSummary is one of clearinghouseState's margin totals.
+type MarginSummary struct {
+	AccountValue    Number `json:"accountValue"`
+	TotalNtlPos     Number `json:"totalNtlPos"`
+	TotalRawUSD     Number `json:"totalRawUsd"`
+	TotalMarginUsed Number `json:"totalMarginUsed"`
+}
+
+type AssetPosition struct {
+	Type     string   `json:"type"`
+	Position Position `json:"position"`
+}
+
+type Position struct {
+	Coin          string   `json:"coin"`
+	Szi           Number   `json:"szi"`
+	EntryPx       *Number  `json:"entryPx"`
+	PositionValue Number   `json:"positionValue"`
+	UnrealizedPnl Number   `json:"unrealizedPnl"`
+	LiquidationPx *Number  `json:"liquidationPx"`
+	MarginUsed    Number   `json:"marginUsed"`
+	Leverage      Leverage `json:"leverage"`
+}
+
+type Leverage struct {
+	Type  string `json:"type"`
+	Value int    `json:"value"`
+}
+
+func (s ClearinghouseState) validate() error {
+	for _, pos := range s.AssetPositions {
+		if pos.Position.Coin == "" {
+			return errors.New("asset position without coin")
+		}
+	}
+	return nil
+}
+
+// SpotClearinghouseState is the spot wallet from spotClearinghouseState.
+type SpotClearinghouseState struct {
+	Balances []SpotBalance `json:"balances"`
+}
+
+type SpotBalance struct {
+	Coin     string `json:"coin"`
+	Token    int    `json:"token"`
+	Total    Number `json:"total"`
+	Hold     Number `json:"hold"`
+	EntryNtl Number `json:"entryNtl"`
+}
+
+func (s SpotClearinghouseState) validate() error {
+	for _, bal := range s.Balances {
+		if bal.Coin == "" {
+			return errors.New("balance without coin")
+		}
+	}
+	return nil
+}
+
+// OpenOrder is one resting order from openOrders.
+type OpenOrder struct {
+	Coin      string `json:"coin"`
+	Side      string `json:"side"`
+	LimitPx   Number `json:"limitPx"`
+	Sz        Number `json:"sz"`
+	Oid       int64  `json:"oid"`
+	Timestamp int64  `json:"timestamp"`
+	OrigSz    Number `json:"origSz"`
+	Cloid     string `json:"cloid,omitempty"`
+}
+
+type openOrders []OpenOrder
+
+func (orders openOrders) validate() error {
+	for _, order := range orders {
+		if order.Oid == 0 || order.Coin == "" {
+			return errors.New("open order without oid or coin")
+		}
+	}
+	return nil
+}
+
+// Fill is one account fill from userFills or userFillsByTime.
+type Fill struct {
+	Coin          string `json:"coin"`
+	Px            Number `json:"px"`
+	Sz            Number `json:"sz"`
+	Side          string `json:"side"`
+	Time          int64  `json:"time"`
+	StartPosition Number `json:"startPosition"`
+	Dir           string `json:"dir"`
+	ClosedPnl     Number `json:"closedPnl"`
+	Hash          string `json:"hash"`
+	Oid           int64  `json:"oid"`
+	Crossed       bool   `json:"crossed"`
+	Fee           Number `json:"fee"`
+	Tid           int64  `json:"tid"`
+	FeeToken      string `json:"feeToken"`
+	Cloid         string `json:"cloid,omitempty"`
+}
+
+type fills []Fill
+
+func (list fills) validate() error {
+	for _, fill := range list {
+		if fill.Coin == "" {
+			return errors.New("fill without coin")
+		}
+	}
+	return nil
+}
+
+// PredictedFunding is one coin's entry in predictedFundings, decoded from
+// the [coin, [[venue, {...}], ...]] pair. Venues the exchange reports as
+// null are left out.
+type PredictedFunding struct {
+	Coin   string
+	Venues []VenueFunding
+}
+
+type VenueFunding struct {
+	Venue                string
+	FundingRate          Number `json:"fundingRate"`
+	NextFundingTime      int64  `json:"nextFundingTime"`
+	FundingIntervalHours Number `json:"fundingIntervalHours"`
+}
+
+func (p *PredictedFunding) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("predicted funding: expected [coin, venues], got %d elements", len(pair))
+	}
+	if err := json.Unmarshal(pair[0], &p.Coin); err != nil {
+		return err
+	}
+	var venues [][]json.RawMessage
+	if err := json.Unmarshal(pair[1], &venues); err != nil {
+		return err
+	}
+	p.Venues = p.Venues[:0]
+	for _, venue := range venues {
+		if len(venue) != 2 {
+			return fmt.Errorf("predicted funding %s: expected [venue, funding]", p.Coin)
+		}
+		if bytes.Equal(bytes.TrimSpace(venue[1]), []byte("null")) {
+			continue
+		}
+		var out VenueFunding
+		if err := json.Unmarshal(venue[0], &out.Venue); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(venue[1], &out); err != nil {
+			return err
+		}
+		p.Venues = append(p.Venues, out)
+	}
+	return nil
+}
+
+type predictedFundings []PredictedFunding
+
+func (list predictedFundings) validate() error {
+	for _, entry := range list {
+		if entry.Coin == "" {
+			return errors.New("predicted funding without coin")
+		}
+	}
+	return nil
+}
+
+// DecodeError is an /info answer that did not fit its typed response. Raw
+// keeps the body so callers can fall back to untyped parsing.
+type DecodeError struct {
+	Type string
+	Raw  json.RawMessage
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode %s: %v", e.Type, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// RawFallback returns the undecoded body of a DecodeError as generic JSON
+// (maps, slices, float64s), for the untyped parsers.
+func RawFallback(err error) (any, bool) {
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		return nil, false
+	}
+	var data any
+	if json.Unmarshal(decodeErr.Raw, &data) != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// InfoRaw returns an /info answer undecoded.
+func (c *Client) InfoRaw(ctx context.Context, req interface{}) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := c.post(ctx, "/info", req, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// decode decodes an /info answer of request type typ into T. A body that
+// does not fit T is returned as a *DecodeError carrying the raw body.
+func decode[T interface{ validate() error }](typ string, raw json.RawMessage) (T, error) {
+	var out T
+	if err := json.Unmarshal(raw, &out); err != nil {
+		var zero T
+		return zero, &DecodeError{Type: typ, Raw: raw, Err: err}
+	}
+	if err := out.validate(); err != nil {
+		var zero T
+		return zero, &DecodeError{Type: typ, Raw: raw, Err: err}
+	}
+	return out, nil
+}
+
+func infoTyped[T interface{ validate() error }](ctx context.Context, c *Client, req interface{}, typ string) (T, error) {
+	raw, err := c.InfoRaw(ctx, req)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decode[T](typ, raw)
+}
+
+func (c *Client) ClearinghouseState(ctx context.Context, user string) (ClearinghouseState, error) {
+	return infoTyped[ClearinghouseState](ctx, c, InfoRequest{Type: "clearinghouseState", User: user}, "clearinghouseState")
+}
+
+func (c *Client) SpotClearinghouseState(ctx context.Context, user string) (SpotClearinghouseState, error) {
+	return infoTyped[SpotClearinghouseState](ctx, c, InfoRequest{Type: "spotClearinghouseState", User: user}, "spotClearinghouseState")
+}
+
+func (c *Client) OpenOrders(ctx context.Context, user string) ([]OpenOrder, error) {
+	return infoTyped[openOrders](ctx, c, InfoRequest{Type: "openOrders", User: user}, "openOrders")
+}
+
+// UserFillsByTime returns the fills from startMS on; endMS 0 means now.
+func (c *Client) UserFillsByTime(ctx context.Context, user string, startMS, endMS int64) ([]Fill, error) {
+	req := map[string]any{"type": "userFillsByTime", "user": user, "startTime": startMS}
+	if endMS > 0 {
+		req["endTime"] = endMS
+	}
+	return infoTyped[fills](ctx, c, req, "userFillsByTime")
+}
+
+func (c *Client) PredictedFundings(ctx context.Context) ([]PredictedFunding, error) {
+	return infoTyped[predictedFundings](ctx, c, InfoRequest{Type: "predictedFundings"}, "predictedFundings")
+}
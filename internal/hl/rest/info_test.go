@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newInfoServer(t *testing.T, answers map[string]string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req InfoRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		answer, ok := answers[req.Type]
+		if !ok {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		_, _ = w.Write([]byte(answer))
+	}))
+	t.Cleanup(srv.Close)
+	return New(srv.URL, time.Second, zap.NewNop())
+}
+
+func TestTypedInfoDecodes(t *testing.T) {
+	client := newInfoServer(t, map[string]string{
+		"clearinghouseState": `{"marginSummary":{"accountValue":"1250.5","totalNtlPos":"600.0","totalRawUsd":"1850.5","totalMarginUsed":"120.0"},
+			"crossMaintenanceMarginUsed":"30.0","withdrawable":"1100.25","time":1733961600000,
+			"assetPositions":[{"type":"oneWay","position":{"coin":"ETH","szi":"-0.2","entryPx":"3000.0","liquidationPx":null,"leverage":{"type":"cross","value":5}}}]}`,
+		"spotClearinghouseState": `{"balances":[{"coin":"USDC","token":0,"total":"500.0","hold":"0.0","entryNtl":"0.0"},{"coin":"UETH","token":221,"total":"0.2","hold":"0.0","entryNtl":"600.0"}]}`,
+		"openOrders":             `[{"coin":"ETH","side":"B","limitPx":"2900.0","sz":"0.1","oid":77,"timestamp":1733961600000,"origSz":"0.1","cloid":"0xabc"}]`,
+		"predictedFundings":      `[["ETH",[["BinPerp",{"fundingRate":"0.0001","nextFundingTime":1733990400000,"fundingIntervalHours":8}],["HlPerp",{"fundingRate":"0.0000125","nextFundingTime":1733965200000,"fundingIntervalHours":1}],["BybitPerp",null]]]]`,
+	})
+	ctx := context.Background()
+
+	perp, err := client.ClearinghouseState(ctx, "0xuser")
+	if err != nil {
+		t.Fatalf("clearinghouseState: %v", err)
+	}
+	pos := perp.AssetPositions[0].Position
+	if perp.MarginSummary.AccountValue != 1250.5 || *perp.Withdrawable != 1100.25 || pos.Coin != "ETH" || pos.Szi != -0.2 || pos.LiquidationPx != nil || pos.Leverage.Value != 5 {
+		t.Fatalf("unexpected clearinghouseState %+v", perp)
+	}
+	spot, err := client.SpotClearinghouseState(ctx, "0xuser")
+	if err != nil || len(spot.Balances) != 2 || spot.Balances[1].Coin != "UETH" || spot.Balances[1].Total != 0.2 {
+		t.Fatalf("unexpected spotClearinghouseState %+v (err=%v)", spot, err)
+	}
+	orders, err := client.OpenOrders(ctx, "0xuser")
+	if err != nil || len(orders) != 1 || orders[0].Oid != 77 || orders[0].LimitPx != 2900 || orders[0].Cloid != "0xabc" {
+		t.Fatalf("unexpected openOrders %+v (err=%v)", orders, err)
+	}
+	predicted, err := client.PredictedFundings(ctx)
+	if err != nil || len(predicted) != 1 || len(predicted[0].Venues) != 2 {
+		t.Fatalf("expected the null venue dropped, got %+v (err=%v)", predicted, err)
+	}
+	if hl := predicted[0].Venues[1]; hl.Venue != "HlPerp" || hl.FundingRate != 0.0000125 || hl.FundingIntervalHours != 1 {
+		t.Fatalf("unexpected HlPerp funding %+v", hl)
+	}
+}
+
+func TestTypedInfoFallsBackToRaw(t *testing.T) {
+	client := newInfoServer(t, map[string]string{
+		"openOrders":      `{"orders":[{"orderId":"5","symbol":"ETH"}]}`,
+		"userFillsByTime": `[{"coin":"ETH","px":"oops"}]`,
+	})
+	ctx := context.Background()
+
+	_, err := client.OpenOrders(ctx, "0xuser")
+	raw, ok := RawFallback(err)
+	if !ok {
+		t.Fatalf("expected a DecodeError for an unexpected shape, got %v", err)
+	}
+	if wrapped, _ := raw.(map[string]any); wrapped["orders"] == nil {
+		t.Fatalf("expected the raw body kept, got %#v", raw)
+	}
+	if _, err := client.UserFillsByTime(ctx, "0xuser", 1, 0); err == nil {
+		t.Fatal("expected a malformed number rejected")
+	} else if _, ok := RawFallback(err); !ok {
+		t.Fatalf("expected a DecodeError, got %v", err)
+	}
+	if _, err := client.ClearinghouseState(ctx, "0xuser"); err == nil {
+		t.Fatal("expected an HTTP error")
+	} else if _, ok := RawFallback(err); ok {
+		t.Fatal("expected no raw fallback for a refused request")
+	}
+}
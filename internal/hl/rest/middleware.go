@@ -0,0 +1,181 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"hl-carry-bot/internal/metrics"
+	"hl-carry-bot/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var tracer = tracing.Tracer("hl-carry-bot/internal/hl/rest")
+
+// Request is one REST call on its way through the middleware chain.
+type Request struct {
+	Path string
+	// Endpoint names the call in logs and metrics: the /info request type,
+	// else the path.
+	Endpoint string
+	Payload  []byte
+	// Weight is the request's share of the per-IP weight budget.
+	Weight int
+}
+
+// Handler performs a Request and returns the raw response body.
+type Handler func(ctx context.Context, req *Request) ([]byte, error)
+
+// Middleware wraps a Handler, e.g. to log, measure, or retry it.
+type Middleware func(next Handler) Handler
+
+// Chain wraps h in mws; the first middleware is the outermost.
+func Chain(h Handler, mws ...Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i] != nil {
+			h = mws[i](h)
+		}
+	}
+	return h
+}
+
+// RetryPolicy bounds how often and how fast a 429 or 5xx answer is retried.
+// The n-th retry waits a jittered BaseDelay*2^(n-1), capped at MaxDelay, or
+// the server's Retry-After when that is longer.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy makes 3 attempts starting at 250ms.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, BaseDelay: 250 * time.Millisecond, MaxDelay: 5 * time.Second}
+}
+
+// delay is the wait before retry n (1-based): half the backoff plus up to
+// the other half at random, so clients throttled together spread out.
+func (p RetryPolicy) delay(n int, err error) time.Duration {
+	backoff := p.BaseDelay
+	for i := 1; i < n && backoff < p.MaxDelay; i++ {
+		backoff *= 2
+	}
+	if p.MaxDelay > 0 && backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	wait := backoff / 2
+	if half := int64(backoff - wait); half > 0 {
+		wait += time.Duration(rand.Int64N(half + 1))
+	}
+	var limited *RateLimitedError
+	if errors.As(err, &limited) && limited.RetryAfter > wait {
+		wait = limited.RetryAfter
+		if p.MaxDelay > 0 && wait > p.MaxDelay {
+			wait = p.MaxDelay
+		}
+	}
+	return wait
+}
+
+// Retry sends a request again on a 429 or 5xx answer until policy's attempts
+// run out. Each attempt waits for weight again, and a 429 has drained the
+// limiter, so low-priority requests are shed rather than retried.
+func Retry(policy RetryPolicy, log *zap.Logger, retries metrics.CounterVec) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			attempts := policy.MaxAttempts
+			if attempts < 1 {
+				attempts = 1
+			}
+			for attempt := 1; ; attempt++ {
+				body, err := next(ctx, req)
+				if err == nil || attempt >= attempts || !Retryable(err) {
+					return body, err
+				}
+				wait := policy.delay(attempt, err)
+				if log != nil {
+					log.Warn("rest request retrying",
+						zap.String("endpoint", req.Endpoint),
+						zap.Int("attempt", attempt),
+						zap.Int("max_attempts", attempts),
+						zap.Duration("backoff", wait),
+						zap.Error(err),
+					)
+				}
+				if retries != nil {
+					retries.With(req.Endpoint).Inc()
+				}
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+	}
+}
+
+// Tracing spans each request, retries included, by endpoint under the
+// caller's span.
+func Tracing() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			ctx, span := tracer.Start(ctx, "rest "+req.Endpoint, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+				attribute.String("path", req.Path),
+				attribute.Int("weight", req.Weight),
+			))
+			body, err := next(ctx, req)
+			span.SetAttributes(attribute.Int("bytes", len(body)))
+			tracing.End(span, err)
+			return body, err
+		}
+	}
+}
+
+// Latency observes each attempt's duration in seconds by endpoint.
+func Latency(latency metrics.ObserverVec) Middleware {
+	return func(next Handler) Handler {
+		if latency == nil {
+			return next
+		}
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			start := time.Now()
+			body, err := next(ctx, req)
+			latency.With(req.Endpoint).Observe(time.Since(start).Seconds())
+			return body, err
+		}
+	}
+}
+
+// Logging logs every request at debug with its endpoint, duration, and
+// outcome; callers decide whether a failure deserves more.
+func Logging(log *zap.Logger) Middleware {
+	return func(next Handler) Handler {
+		if log == nil {
+			return next
+		}
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			start := time.Now()
+			body, err := next(ctx, req)
+			if ce := log.Check(zap.DebugLevel, "rest request"); ce != nil {
+				fields := []zap.Field{
+					zap.String("endpoint", req.Endpoint),
+					zap.Int("weight", req.Weight),
+					zap.Duration("duration", time.Since(start)),
+					zap.Int("bytes", len(body)),
+				}
+				if err != nil {
+					fields = append(fields, zap.Error(err))
+				}
+				ce.Write(fields...)
+			}
+			return body, err
+		}
+	}
+}
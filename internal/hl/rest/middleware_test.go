@@ -0,0 +1,128 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+type fakeObserverVec struct{ samples map[string]int }
+
+func (v *fakeObserverVec) With(value string) metrics.Observer {
+	return fakeObserver{v: v, key: value}
+}
+
+type fakeObserver struct {
+	v   *fakeObserverVec
+	key string
+}
+
+func (o fakeObserver) Observe(float64) { o.v.samples[o.key]++ }
+
+type fakeCounterVec struct{ counts map[string]*fakeCounter }
+
+func (v *fakeCounterVec) With(value string) metrics.Counter {
+	if v.counts[value] == nil {
+		v.counts[value] = &fakeCounter{}
+	}
+	return v.counts[value]
+}
+
+func TestClientRetriesServerErrorsAndRecordsMetrics(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			http.Error(w, "overloaded", http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"ETH": "2000"})
+		}
+	}))
+	defer srv.Close()
+	client := New(srv.URL, time.Second, zap.NewNop())
+	client.SetRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond})
+	latency := &fakeObserverVec{samples: map[string]int{}}
+	retries := &fakeCounterVec{counts: map[string]*fakeCounter{}}
+	client.SetMetrics(latency, retries)
+	var attempts int
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			attempts++
+			return next(ctx, req)
+		}
+	})
+
+	mids, err := client.Info(context.Background(), InfoRequest{Type: "allMids"})
+	if err != nil || mids["ETH"] != "2000" {
+		t.Fatalf("expected the third attempt to succeed, got %v (err=%v)", mids, err)
+	}
+	if attempts != 3 || latency.samples["allMids"] != 3 || retries.counts["allMids"].count != 2 {
+		t.Fatalf("expected 3 attempts timed and 2 retries, got %d/%v/%d", attempts, latency.samples, retries.counts["allMids"].count)
+	}
+}
+
+func TestClientTypedErrors(t *testing.T) {
+	var status, calls atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if status.Load() == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "7")
+		}
+		http.Error(w, "nope", int(status.Load()))
+	}))
+	defer srv.Close()
+	client := New(srv.URL, time.Second, zap.NewNop())
+	client.SetRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	ctx := context.Background()
+
+	_, err := client.Info(ctx, InfoRequest{Type: "meta"})
+	var server *ServerError
+	if !errors.As(err, &server) || server.Status != http.StatusServiceUnavailable || calls.Load() != 2 {
+		t.Fatalf("expected a ServerError after 2 attempts, got %v after %d", err, calls.Load())
+	}
+
+	status.Store(http.StatusTooManyRequests)
+	calls.Store(0)
+	_, err = client.Info(ctx, InfoRequest{Type: "meta"})
+	var limited *RateLimitedError
+	if !errors.As(err, &limited) || limited.RetryAfter != 7*time.Second {
+		t.Fatalf("expected a RateLimitedError with its Retry-After, got %v", err)
+	}
+
+	status.Store(http.StatusUnprocessableEntity)
+	calls.Store(0)
+	_, err = client.Info(ctx, InfoRequest{Type: "meta"})
+	var refused *StatusError
+	if !errors.As(err, &refused) || Retryable(err) || calls.Load() != 1 {
+		t.Fatalf("expected a StatusError sent once, got %v after %d", err, calls.Load())
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	for n, bounds := range map[int][2]time.Duration{
+		1: {50 * time.Millisecond, 100 * time.Millisecond},
+		2: {100 * time.Millisecond, 200 * time.Millisecond},
+		4: {150 * time.Millisecond, 300 * time.Millisecond},
+	} {
+		if got := policy.delay(n, &ServerError{Status: 500}); got < bounds[0] || got > bounds[1] {
+			t.Fatalf("retry %d: expected a delay in %v, got %v", n, bounds, got)
+		}
+	}
+	if got := policy.delay(1, &RateLimitedError{RetryAfter: time.Minute}); got != 300*time.Millisecond {
+		t.Fatalf("expected Retry-After capped at MaxDelay, got %v", got)
+	}
+}
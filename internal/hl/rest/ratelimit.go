@@ -0,0 +1,197 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Hyperliquid budgets REST traffic per IP by request weight; /info and
+// /exchange share the same 1200 weight per minute.
+const DefaultWeightPerMinute = 1200
+
+var ErrRateLimited = errors.New("rate limit budget exhausted")
+
+// Priority decides how a request behaves when the weight budget runs low.
+type Priority int
+
+const (
+	// PriorityNormal waits for budget but leaves the reserve untouched.
+	PriorityNormal Priority = iota
+	// PriorityCritical may spend the reserve (order placement, cancels).
+	PriorityCritical
+	// PriorityLow is shed with ErrRateLimited instead of waiting.
+	PriorityLow
+)
+
+type priorityKey struct{}
+
+// WithPriority tags requests made with ctx with the given priority.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// Limiter is a token bucket over request weight. A reserve is held back so
+// critical requests still go through when routine polling has used the rest.
+type Limiter struct {
+	mu       sync.Mutex
+	capacity float64
+	reserve  float64
+	perSec   float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+
+	remaining interface{ Set(float64) }
+	shed      interface{ Inc() }
+}
+
+func NewLimiter(weightPerMinute, reserve int) *Limiter {
+	if weightPerMinute <= 0 {
+		weightPerMinute = DefaultWeightPerMinute
+	}
+	if reserve < 0 {
+		reserve = 0
+	}
+	if reserve >= weightPerMinute {
+		reserve = weightPerMinute - 1
+	}
+	return &Limiter{
+		capacity: float64(weightPerMinute),
+		reserve:  float64(reserve),
+		perSec:   float64(weightPerMinute) / 60,
+		tokens:   float64(weightPerMinute),
+		now:      time.Now,
+	}
+}
+
+// SetMetrics reports the remaining budget and shed requests.
+func (l *Limiter) SetMetrics(remaining interface{ Set(float64) }, shed interface{ Inc() }) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.remaining = remaining
+	l.shed = shed
+}
+
+// Wait blocks until weight is available for the priority carried by ctx.
+func (l *Limiter) Wait(ctx context.Context, weight int) error {
+	if l == nil || weight <= 0 {
+		return nil
+	}
+	priority := priorityFrom(ctx)
+	for {
+		delay, err := l.take(float64(weight), priority)
+		if err != nil || delay == 0 {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Remaining returns the weight currently available.
+func (l *Limiter) Remaining() float64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	return l.tokens
+}
+
+// Drain empties the bucket, e.g. after the server answered 429.
+func (l *Limiter) Drain() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens = 0
+	l.report()
+}
+
+func (l *Limiter) take(weight float64, priority Priority) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	floor := l.reserve
+	if priority == PriorityCritical {
+		floor = 0
+	}
+	if weight > l.capacity-floor {
+		weight = l.capacity - floor
+	}
+	if l.tokens-weight >= floor {
+		l.tokens -= weight
+		l.report()
+		return 0, nil
+	}
+	if priority == PriorityLow {
+		if l.shed != nil {
+			l.shed.Inc()
+		}
+		return 0, ErrRateLimited
+	}
+	deficit := weight + floor - l.tokens
+	delay := time.Duration(deficit / l.perSec * float64(time.Second))
+	if delay < time.Millisecond {
+		delay = time.Millisecond
+	}
+	return delay, nil
+}
+
+func (l *Limiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.perSec
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+	}
+	l.last = now
+}
+
+func (l *Limiter) report() {
+	if l.remaining != nil {
+		l.remaining.Set(l.tokens)
+	}
+}
+
+// InfoWeight returns the documented base weight for an /info request body.
+func InfoWeight(payload []byte) int {
+	switch infoType(payload) {
+	case "l2Book", "allMids", "clearinghouseState", "orderStatus", "spotClearinghouseState", "exchangeStatus":
+		return 2
+	case "userRole":
+		return 60
+	default:
+		return 20
+	}
+}
+
+// infoType returns the type field of an /info request body.
+func infoType(payload []byte) string {
+	var req struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return ""
+	}
+	return req.Type
+}
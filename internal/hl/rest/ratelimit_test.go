@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeGauge struct{ value float64 }
+
+func (g *fakeGauge) Set(v float64) { g.value = v }
+
+type fakeCounter struct{ count int }
+
+func (c *fakeCounter) Inc() { c.count++ }
+
+func newTestLimiter(weightPerMinute, reserve int) (*Limiter, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewLimiter(weightPerMinute, reserve)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiterShedsLowPriorityInsideReserve(t *testing.T) {
+	l, _ := newTestLimiter(60, 20)
+	gauge := &fakeGauge{}
+	shed := &fakeCounter{}
+	l.SetMetrics(gauge, shed)
+	ctx := context.Background()
+	if err := l.Wait(ctx, 40); err != nil {
+		t.Fatalf("normal wait: %v", err)
+	}
+	if gauge.value != 20 {
+		t.Fatalf("expected remaining 20, got %v", gauge.value)
+	}
+	err := l.Wait(WithPriority(ctx, PriorityLow), 2)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if shed.count != 1 {
+		t.Fatalf("expected 1 shed request, got %d", shed.count)
+	}
+	if err := l.Wait(WithPriority(ctx, PriorityCritical), 20); err != nil {
+		t.Fatalf("critical wait: %v", err)
+	}
+	if got := l.Remaining(); got != 0 {
+		t.Fatalf("expected empty bucket, got %v", got)
+	}
+}
+
+func TestLimiterNormalWaitsForRefill(t *testing.T) {
+	l, now := newTestLimiter(60, 0)
+	if err := l.Wait(context.Background(), 60); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	delay, err := l.take(2, PriorityNormal)
+	if err != nil {
+		t.Fatalf("take: %v", err)
+	}
+	if delay != 2*time.Second {
+		t.Fatalf("expected 2s delay at 1 weight/s, got %v", delay)
+	}
+	*now = now.Add(2 * time.Second)
+	if delay, err := l.take(2, PriorityNormal); err != nil || delay != 0 {
+		t.Fatalf("expected immediate take after refill, got %v %v", delay, err)
+	}
+}
+
+func TestLimiterWaitHonorsContext(t *testing.T) {
+	l := NewLimiter(60, 0)
+	l.Drain()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, 30); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestNilLimiterIsNoop(t *testing.T) {
+	var l *Limiter
+	if err := l.Wait(context.Background(), 100); err != nil {
+		t.Fatalf("expected nil limiter to allow requests, got %v", err)
+	}
+	l.Drain()
+}
+
+func TestInfoWeight(t *testing.T) {
+	cases := map[string]int{
+		`{"type":"allMids"}`:                        2,
+		`{"type":"clearinghouseState","user":"0x"}`: 2,
+		`{"type":"userFunding","user":"0x"}`:        20,
+		`{"type":"userRole","user":"0x"}`:           60,
+		`not json`:                                  20,
+	}
+	for payload, want := range cases {
+		if got := InfoWeight([]byte(payload)); got != want {
+			t.Fatalf("InfoWeight(%s) = %d, want %d", payload, got, want)
+		}
+	}
+}
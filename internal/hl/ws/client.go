@@ -7,19 +7,28 @@ import (
 	"sync"
 	"time"
 
+	"hl-carry-bot/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"nhooyr.io/websocket"
 )
 
+var tracer = tracing.Tracer("hl-carry-bot/internal/hl/ws")
+
 type Client struct {
 	url            string
 	reconnectDelay time.Duration
 	pingInterval   time.Duration
 	log            *zap.Logger
 
-	mu   sync.Mutex
-	conn *websocket.Conn
-	subs []interface{}
+	mu          sync.Mutex
+	conn        *websocket.Conn
+	subs        []interface{}
+	subStatus   map[string]*subStatus
+	ackTimeout  time.Duration
+	onReconnect func()
 
 	postMu  sync.Mutex
 	postReq map[uint64]chan json.RawMessage
@@ -43,37 +52,76 @@ func (c *Client) Connect(ctx context.Context) error {
 	return nil
 }
 
+// Connected reports whether the client holds a live connection; it is
+// false while reconnecting.
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
 func (c *Client) Subscribe(ctx context.Context, sub interface{}) error {
 	c.mu.Lock()
 	c.subs = append(c.subs, sub)
+	key := c.trackSubLocked(sub)
 	conn := c.conn
 	c.mu.Unlock()
 	if conn == nil {
 		return errors.New("ws not connected")
 	}
-	return writeJSON(ctx, conn, sub)
+	if err := writeJSON(ctx, conn, sub); err != nil {
+		return err
+	}
+	c.markSent(key)
+	return nil
+}
+
+// SetReconnectHandler registers fn to run after the client reconnects and
+// re-sends its subscriptions, before any message from the new connection is
+// handled. Messages may have been lost while the connection was down.
+func (c *Client) SetReconnectHandler(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onReconnect = fn
 }
 
 func (c *Client) Run(ctx context.Context, handler func(json.RawMessage)) error {
+	reconnected := false
 	for {
 		if err := c.ensureConnected(ctx); err != nil {
 			return err
 		}
+		if reconnected {
+			reconnected = false
+			c.mu.Lock()
+			onReconnect := c.onReconnect
+			c.mu.Unlock()
+			if onReconnect != nil {
+				onReconnect()
+			}
+		}
 		pingCtx, cancel := context.WithCancel(ctx)
 		pingDone := make(chan struct{})
 		go func() {
 			defer close(pingDone)
 			c.pingLoop(pingCtx)
 		}()
+		resubDone := make(chan struct{})
+		go func() {
+			defer close(resubDone)
+			c.resubscribeLoop(pingCtx)
+		}()
 		err := c.readLoop(ctx, handler)
 		cancel()
 		<-pingDone
+		<-resubDone
 		if err != nil {
 			if ctx.Err() != nil {
 				return ctx.Err()
 			}
 			c.logReadLoopError(err)
 			c.resetConn()
+			reconnected = true
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
@@ -96,6 +144,7 @@ func (c *Client) ensureConnected(ctx context.Context) error {
 		if err := writeJSON(ctx, conn, sub); err != nil {
 			return err
 		}
+		c.markSent(subscriptionKey(sub))
 	}
 	return nil
 }
@@ -115,6 +164,9 @@ func (c *Client) readLoop(ctx context.Context, handler func(json.RawMessage)) er
 		if c.handlePostResponse(data) {
 			continue
 		}
+		if c.handleSubscriptionResponse(data) {
+			continue
+		}
 		if handler != nil {
 			handler(json.RawMessage(data))
 		}
@@ -167,12 +219,17 @@ func (c *Client) resetConn() {
 		_ = c.conn.Close(websocket.StatusNormalClosure, "reset")
 		c.conn = nil
 	}
+	c.markAllUnconfirmed()
 }
 
-func (c *Client) Post(ctx context.Context, id uint64, req interface{}) (json.RawMessage, error) {
+func (c *Client) Post(ctx context.Context, id uint64, req interface{}) (resp json.RawMessage, err error) {
 	if id == 0 {
 		return nil, errors.New("post id is required")
 	}
+	ctx, span := tracer.Start(ctx, "ws post", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.Int64("post_id", int64(id)),
+	))
+	defer func() { tracing.End(span, err) }()
 	if err := c.Connect(ctx); err != nil {
 		return nil, err
 	}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -134,3 +135,160 @@ func TestClientPostRequest(t *testing.T) {
 		t.Fatalf("expected post channel, got %v", got["channel"])
 	}
 }
+
+func TestClientTracksSubscriptionAcks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	acked := map[string]int{}
+	ackTrades := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Errorf("accept ws: %v", err)
+			return
+		}
+		defer func() { _ = conn.Close(websocket.StatusNormalClosure, "") }()
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var msg struct {
+				Method       string         `json:"method"`
+				Subscription map[string]any `json:"subscription"`
+			}
+			if err := json.Unmarshal(data, &msg); err != nil || msg.Method != "subscribe" {
+				continue
+			}
+			typ, _ := msg.Subscription["type"].(string)
+			mu.Lock()
+			// trades goes unacknowledged until the test has seen it pending.
+			ack := typ != "trades" || ackTrades
+			if ack {
+				acked[typ]++
+			}
+			mu.Unlock()
+			if !ack {
+				continue
+			}
+			resp, _ := json.Marshal(map[string]any{"channel": "subscriptionResponse", "data": msg})
+			if err := conn.Write(ctx, websocket.MessageText, resp); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	client := New(wsURL, 10*time.Millisecond, 0, zap.NewNop())
+	client.SetAckTimeout(50 * time.Millisecond)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := client.Subscribe(ctx, map[string]any{"method": "subscribe", "subscription": map[string]any{"type": "allMids"}}); err != nil {
+		t.Fatalf("subscribe allMids: %v", err)
+	}
+	if err := client.Subscribe(ctx, map[string]any{"method": "subscribe", "subscription": map[string]any{"type": "trades", "coin": "ETH"}}); err != nil {
+		t.Fatalf("subscribe trades: %v", err)
+	}
+	var handled []string
+	var handledMu sync.Mutex
+	go func() {
+		_ = client.Run(ctx, func(msg json.RawMessage) {
+			handledMu.Lock()
+			handled = append(handled, string(msg))
+			handledMu.Unlock()
+		})
+	}()
+
+	if !client.SubscriptionsHealthy() {
+		t.Fatalf("expected fresh subscriptions to be healthy within the ack timeout")
+	}
+	deadline := time.Now().Add(time.Second)
+	sawPending := false
+	for time.Now().Before(deadline) {
+		if pending := client.PendingSubscriptions(); len(pending) == 1 && strings.Contains(pending[0], `"trades"`) {
+			sawPending = true
+			mu.Lock()
+			ackTrades = true
+			mu.Unlock()
+		}
+		mu.Lock()
+		done := acked["trades"] > 0
+		mu.Unlock()
+		if done && client.SubscriptionsHealthy() {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !sawPending {
+		t.Fatalf("expected the unacknowledged trades subscription to be reported")
+	}
+	if !client.SubscriptionsHealthy() {
+		t.Fatalf("expected the re-issued subscription to be confirmed, pending %v", client.PendingSubscriptions())
+	}
+	handledMu.Lock()
+	defer handledMu.Unlock()
+	for _, msg := range handled {
+		if strings.Contains(msg, "subscriptionResponse") {
+			t.Fatalf("expected acks to be consumed by the client, got %s", msg)
+		}
+	}
+}
+
+func TestClientCallsReconnectHandler(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	connections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Errorf("accept ws: %v", err)
+			return
+		}
+		mu.Lock()
+		connections++
+		first := connections == 1
+		mu.Unlock()
+		if first {
+			// Drop the first connection to force a reconnect.
+			_ = conn.Close(websocket.StatusGoingAway, "restart")
+			return
+		}
+		defer func() { _ = conn.Close(websocket.StatusNormalClosure, "") }()
+		for {
+			if _, _, err := conn.Read(ctx); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	client := New(wsURL, 10*time.Millisecond, 0, zap.NewNop())
+	reconnects := make(chan struct{}, 4)
+	client.SetReconnectHandler(func() { reconnects <- struct{}{} })
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	runCtx, runCancel := context.WithCancel(ctx)
+	defer runCancel()
+	go func() {
+		_ = client.Run(runCtx, nil)
+	}()
+
+	select {
+	case <-reconnects:
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for the reconnect handler")
+	}
+	select {
+	case <-reconnects:
+		t.Fatalf("expected one reconnect handler call")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,225 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultAckTimeout is how long a subscribe may go unacknowledged before it
+// is re-issued and reported unhealthy.
+const DefaultAckTimeout = 10 * time.Second
+
+var subscriptionResponseTag = []byte(`"subscriptionResponse"`)
+
+// subStatus tracks whether the server acknowledged a subscription on the
+// current connection. since is when it last became unconfirmed; sentAt is the
+// last time it was written.
+type subStatus struct {
+	sub       interface{}
+	confirmed bool
+	since     time.Time
+	sentAt    time.Time
+	warned    bool
+}
+
+// SetAckTimeout overrides DefaultAckTimeout.
+func (c *Client) SetAckTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ackTimeout = timeout
+}
+
+func (c *Client) ackTimeoutLocked() time.Duration {
+	if c.ackTimeout > 0 {
+		return c.ackTimeout
+	}
+	return DefaultAckTimeout
+}
+
+// SubscriptionsHealthy reports whether every subscription was acknowledged
+// on the current connection, allowing the ack timeout for fresh ones.
+func (c *Client) SubscriptionsHealthy() bool {
+	return len(c.PendingSubscriptions()) == 0
+}
+
+// PendingSubscriptions lists the subscriptions unacknowledged for longer than
+// the ack timeout, sorted.
+func (c *Client) PendingSubscriptions() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	timeout := c.ackTimeoutLocked()
+	var out []string
+	for key, status := range c.subStatus {
+		if !status.confirmed && now.Sub(status.since) >= timeout {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// trackSubLocked registers sub for ack tracking and returns its key, or ""
+// for messages that are not subscribes.
+func (c *Client) trackSubLocked(sub interface{}) string {
+	key := subscriptionKey(sub)
+	if key == "" {
+		return ""
+	}
+	if c.subStatus == nil {
+		c.subStatus = make(map[string]*subStatus)
+	}
+	if _, ok := c.subStatus[key]; !ok {
+		c.subStatus[key] = &subStatus{sub: sub, since: time.Now()}
+	}
+	return key
+}
+
+func (c *Client) markSent(key string) {
+	if key == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if status, ok := c.subStatus[key]; ok {
+		status.sentAt = time.Now()
+	}
+}
+
+// markAllUnconfirmed drops acks when the connection goes away.
+func (c *Client) markAllUnconfirmed() {
+	now := time.Now()
+	for _, status := range c.subStatus {
+		if status.confirmed {
+			status.confirmed = false
+			status.since = now
+		}
+	}
+}
+
+// handleSubscriptionResponse records a subscriptionResponse ack. It reports
+// whether data was an ack.
+func (c *Client) handleSubscriptionResponse(data []byte) bool {
+	if !bytes.Contains(data, subscriptionResponseTag) {
+		return false
+	}
+	var payload struct {
+		Channel string `json:"channel"`
+		Data    struct {
+			Method       string          `json:"method"`
+			Subscription json.RawMessage `json:"subscription"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil || payload.Channel != "subscriptionResponse" {
+		return false
+	}
+	if payload.Data.Method != "" && payload.Data.Method != "subscribe" {
+		return true
+	}
+	key := canonicalSubscription(payload.Data.Subscription)
+	c.mu.Lock()
+	status, ok := c.subStatus[key]
+	recovered := false
+	if ok && !status.confirmed {
+		status.confirmed = true
+		recovered = status.warned
+		status.warned = false
+	}
+	c.mu.Unlock()
+	if recovered && c.log != nil {
+		c.log.Info("ws subscription confirmed", zap.String("subscription", key))
+	}
+	return true
+}
+
+// resubscribeLoop re-issues subscriptions that were not acknowledged within
+// the ack timeout.
+func (c *Client) resubscribeLoop(ctx context.Context) {
+	c.mu.Lock()
+	interval := c.ackTimeoutLocked() / 2
+	c.mu.Unlock()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.resubscribeUnconfirmed(ctx)
+		}
+	}
+}
+
+func (c *Client) resubscribeUnconfirmed(ctx context.Context) {
+	now := time.Now()
+	c.mu.Lock()
+	conn := c.conn
+	if conn == nil {
+		c.mu.Unlock()
+		return
+	}
+	timeout := c.ackTimeoutLocked()
+	type resend struct {
+		key   string
+		sub   interface{}
+		first bool
+	}
+	var due []resend
+	for key, status := range c.subStatus {
+		if status.confirmed || now.Sub(status.sentAt) < timeout {
+			continue
+		}
+		due = append(due, resend{key: key, sub: status.sub, first: !status.warned})
+		status.warned = true
+	}
+	c.mu.Unlock()
+	for _, r := range due {
+		if c.log != nil {
+			if r.first {
+				c.log.Warn("ws subscription unconfirmed, resubscribing", zap.String("subscription", r.key))
+			} else {
+				c.log.Debug("ws subscription unconfirmed, resubscribing", zap.String("subscription", r.key))
+			}
+		}
+		if err := writeJSON(ctx, conn, r.sub); err != nil {
+			return
+		}
+		c.markSent(r.key)
+	}
+}
+
+// subscriptionKey identifies a subscribe message by its subscription body.
+func subscriptionKey(sub interface{}) string {
+	raw, err := json.Marshal(sub)
+	if err != nil {
+		return ""
+	}
+	var msg struct {
+		Method       string          `json:"method"`
+		Subscription json.RawMessage `json:"subscription"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.Method != "subscribe" {
+		return ""
+	}
+	return canonicalSubscription(msg.Subscription)
+}
+
+// canonicalSubscription re-encodes a subscription with sorted keys and
+// lowercased so an ack matches regardless of field order or address case.
+func canonicalSubscription(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return ""
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(string(out))
+}
@@ -0,0 +1,917 @@
+// Package hltest runs an in-process fake of the Hyperliquid API for tests.
+// One httptest server answers /info from mutable fixtures, accepts /exchange
+// actions without checking signatures, and pushes order and fill updates to
+// /ws subscribers, so exec, account, and app tests can drive a full order
+// lifecycle against the real clients.
+//
+// The fixture universe is one perp (ETH, asset 1, szDecimals 3) and one spot
+// pair (UETH/USDC, index 51, asset 10051, szDecimals 3), both with a mid of
+// 3000. Orders fill at the mid when marketable and otherwise rest.
+package hltest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/exchange"
+
+	"nhooyr.io/websocket"
+)
+
+const (
+	PerpAsset = 1
+	SpotAsset = 10051
+)
+
+// Exchange error messages returned for orders that cannot be placed.
+const (
+	MsgNoImmediateMatch = "Order could not immediately match against any resting orders."
+	MsgPostOnlyCross    = "Post only order would have immediately matched, bbo was 3000."
+)
+
+type instrument struct {
+	name     string
+	fillCoin string
+	base     string
+	quote    string
+	isSpot   bool
+}
+
+var instruments = map[int]instrument{
+	PerpAsset: {name: "ETH", fillCoin: "ETH"},
+	SpotAsset: {name: "UETH/USDC", fillCoin: "@51", base: "UETH", quote: "USDC", isSpot: true},
+}
+
+type restingOrder struct {
+	asset int
+	wire  exchange.OrderWire
+	oid   int64
+	time  int64
+}
+
+// Server is a fake Hyperliquid API. Setters are safe to call while clients
+// are connected.
+type Server struct {
+	t testing.TB
+
+	mu              sync.Mutex
+	counts          map[string]int
+	spotBalances    []any
+	accountValue    float64
+	positions       map[string]float64
+	isolated        map[string]float64
+	mids            map[string]any
+	fundingRate     string
+	nextFundingTime int64
+	fundingVenues   []any
+	fundingHistory  []any
+	candles         []any
+	fills           []any
+	userFunding     []any
+	userFees        map[string]any
+	books           map[string]any
+	clockSkew       time.Duration
+	resting         map[int64]restingOrder
+	orders          []exchange.OrderWire
+	cancels         []exchange.CancelWire
+	leverages       []exchange.UpdateLeverageAction
+	vaultTransfers  []exchange.VaultTransferAction
+	rejectNext      []string
+	rejectLeverage  []string
+	rejectVault     []string
+	fillRatio       float64
+	nextOID         int64
+	nextTID         int64
+	subs            map[*websocket.Conn]map[string]bool
+	server          *httptest.Server
+}
+
+// NewServer starts a fake API that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{
+		t:            t,
+		counts:       make(map[string]int),
+		accountValue: 100,
+		fundingRate:  "0.00001",
+		positions:    make(map[string]float64),
+		isolated:     make(map[string]float64),
+		mids: map[string]any{
+			"ETH":       "3000",
+			"UETH/USDC": "3000",
+		},
+		spotBalances: []any{
+			map[string]any{"coin": "USDC", "total": "100"},
+		},
+		books:     make(map[string]any),
+		resting:   make(map[int64]restingOrder),
+		fillRatio: 1,
+		nextOID:   1000,
+		nextTID:   1,
+		subs:      make(map[*websocket.Conn]map[string]bool),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/exchange", s.handleExchange)
+	mux.HandleFunc("/ws", s.handleWS)
+	mux.HandleFunc("/", s.handleInfo)
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *Server) Close() {
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
+// URL is the REST base URL for both the /info and /exchange clients.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// WSURL is the websocket endpoint.
+func (s *Server) WSURL() string {
+	return strings.Replace(s.server.URL, "http", "ws", 1) + "/ws"
+}
+
+// Count reports how many /info requests of typ were served.
+func (s *Server) Count(typ string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[typ]
+}
+
+func (s *Server) SetSpotBalances(balances []any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spotBalances = balances
+}
+
+func (s *Server) SetAccountValue(value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accountValue = value
+}
+
+// SetPerpPosition sets the signed size of a perp position; 0 removes it.
+func (s *Server) SetPerpPosition(coin string, size float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if size == 0 {
+		delete(s.positions, coin)
+		return
+	}
+	s.positions[coin] = size
+}
+
+// SetIsolatedMargin puts coin's position on isolated margin with marginUsed
+// USDC backing it; updateIsolatedMargin actions move it.
+func (s *Server) SetIsolatedMargin(coin string, marginUsed float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.isolated[coin] = marginUsed
+}
+
+// IsolatedMargin returns the margin backing coin's isolated position.
+func (s *Server) IsolatedMargin(coin string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isolated[coin]
+}
+
+// SetMid overrides the mid of coin; marketability and fill prices follow it.
+func (s *Server) SetMid(coin, mid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mids[coin] = mid
+}
+
+// SetFills replaces the userFillsByTime history.
+func (s *Server) SetFills(fills []any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fills = fills
+}
+
+// SetUserFunding replaces the userFunding history.
+func (s *Server) SetUserFunding(payments []any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userFunding = payments
+}
+
+func (s *Server) SetFundingRate(rate string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fundingRate = rate
+}
+
+func (s *Server) SetNextFundingTime(ms int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextFundingTime = ms
+}
+
+// SetFundingVenues sets the non-Hyperliquid predictedFundings providers.
+func (s *Server) SetFundingVenues(venues []any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fundingVenues = venues
+}
+
+func (s *Server) AppendFundingHistory(entries ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fundingHistory = append(s.fundingHistory, entries...)
+}
+
+// AppendCandles adds candleSnapshot entries.
+func (s *Server) AppendCandles(entries ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.candles = append(s.candles, entries...)
+}
+
+// SetUserFees sets the userFees response; nil makes the request fail.
+func (s *Server) SetUserFees(fees map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userFees = fees
+}
+
+// SetBook sets the l2Book response for coin; coins without a book fail.
+func (s *Server) SetBook(coin string, book any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books[coin] = book
+}
+
+// SetClockSkew moves the exchangeStatus time ahead of the local clock by
+// skew; negative values put it behind.
+func (s *Server) SetClockSkew(skew time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clockSkew = skew
+}
+
+// RejectNextOrder makes the next order return msg as its error status.
+// Calls queue up, one rejection per order.
+func (s *Server) RejectNextOrder(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejectNext = append(s.rejectNext, msg)
+}
+
+// RejectNextLeverage makes the next updateLeverage return msg as an error
+// response. Calls queue up, one rejection per update.
+func (s *Server) RejectNextLeverage(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejectLeverage = append(s.rejectLeverage, msg)
+}
+
+// RejectNextVaultTransfer makes the next vaultTransfer return msg as an
+// error response. Calls queue up, one rejection per transfer.
+func (s *Server) RejectNextVaultTransfer(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejectVault = append(s.rejectVault, msg)
+}
+
+// SetFillRatio scales the filled size of marketable orders, e.g. 0.5 fills
+// half; an IOC remainder is cancelled.
+func (s *Server) SetFillRatio(ratio float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fillRatio = ratio
+}
+
+// Orders returns every order received on /exchange, in order.
+func (s *Server) Orders() []exchange.OrderWire {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]exchange.OrderWire(nil), s.orders...)
+}
+
+// Cancels returns every cancel received on /exchange, in order.
+func (s *Server) Cancels() []exchange.CancelWire {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]exchange.CancelWire(nil), s.cancels...)
+}
+
+// LeverageUpdates returns every updateLeverage action received, in order.
+func (s *Server) LeverageUpdates() []exchange.UpdateLeverageAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]exchange.UpdateLeverageAction(nil), s.leverages...)
+}
+
+// VaultTransfers returns every vaultTransfer action received, rejected ones
+// included.
+func (s *Server) VaultTransfers() []exchange.VaultTransferAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]exchange.VaultTransferAction(nil), s.vaultTransfers...)
+}
+
+// OpenOrderIDs returns the ids of orders resting on the fake book.
+func (s *Server) OpenOrderIDs() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]int64, 0, len(s.resting))
+	for oid := range s.resting {
+		ids = append(ids, oid)
+	}
+	return ids
+}
+
+// PerpPosition returns the signed size of the perp position in coin.
+func (s *Server) PerpPosition(coin string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.positions[coin]
+}
+
+// SpotBalance returns the total spot balance of coin.
+func (s *Server) SpotBalance(coin string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry := s.balanceEntry(coin); entry != nil {
+		return floatOf(entry["total"])
+	}
+	return 0
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	typ, _ := payload["type"].(string)
+	if typ == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.counts[typ]++
+	spotBalances := copyBalances(s.spotBalances)
+	accountValue := s.accountValue
+	positions := s.positionsPayload()
+	mids := make(map[string]any, len(s.mids))
+	for coin, mid := range s.mids {
+		mids[coin] = mid
+	}
+	fundingRate := s.fundingRate
+	fundingVenues := s.fundingVenues
+	fundingHistory := s.fundingHistory
+	candles := s.candles
+	nextFundingTime := s.nextFundingTime
+	fills := append([]any{}, s.fills...)
+	userFunding := s.userFunding
+	openOrders := s.openOrdersPayload()
+	userFees := s.userFees
+	coin, _ := payload["coin"].(string)
+	book, hasBook := s.books[coin]
+	clockSkew := s.clockSkew
+	s.mu.Unlock()
+
+	switch typ {
+	case "metaAndAssetCtxs":
+		writeJSON(w, []any{
+			map[string]any{"universe": []any{
+				map[string]any{"name": "ETH", "szDecimals": 3, "index": 1, "maxLeverage": 25},
+			}},
+			[]any{
+				map[string]any{"funding": fundingRate, "oraclePx": "3000", "markPx": "3000"},
+			},
+		})
+	case "spotMetaAndAssetCtxs", "spotMeta":
+		writeJSON(w, []any{
+			map[string]any{"universe": []any{
+				map[string]any{"name": "UETH/USDC", "index": 51, "base": "UETH", "quote": "USDC", "szDecimals": 3},
+			}},
+		})
+	case "allMids":
+		writeJSON(w, mids)
+	case "predictedFundings":
+		providers := []any{
+			[]any{"HlPerp", map[string]any{
+				"fundingRate":          fundingRate,
+				"nextFundingTime":      nextFundingTime,
+				"fundingIntervalHours": 1,
+			}},
+		}
+		writeJSON(w, []any{
+			[]any{"ETH", append(providers, fundingVenues...)},
+		})
+	case "fundingHistory":
+		writeJSON(w, append([]any{}, fundingHistory...))
+	case "candleSnapshot":
+		writeJSON(w, append([]any{}, candles...))
+	case "spotClearinghouseState":
+		writeJSON(w, map[string]any{"balances": spotBalances})
+	case "clearinghouseState":
+		writeJSON(w, map[string]any{
+			"assetPositions": positions,
+			"marginSummary":  map[string]any{"accountValue": accountValue},
+		})
+	case "openOrders", "frontendOpenOrders":
+		writeJSON(w, openOrders)
+	case "userFillsByTime":
+		writeJSON(w, fills)
+	case "userFunding":
+		writeJSON(w, append([]any{}, userFunding...))
+	case "userFees":
+		if userFees == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, userFees)
+	case "l2Book":
+		if !hasBook {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, book)
+	case "exchangeStatus":
+		writeJSON(w, map[string]any{"time": time.Now().Add(clockSkew).UnixMilli(), "specialStatuses": nil})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (s *Server) handleExchange(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Action json.RawMessage `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(req.Action, &head); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	switch head.Type {
+	case "order":
+		var action exchange.OrderAction
+		if err := json.Unmarshal(req.Action, &action); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		statuses := make([]any, 0, len(action.Orders))
+		for _, order := range action.Orders {
+			statuses = append(statuses, s.placeOrder(order))
+		}
+		writeJSON(w, okResponse("order", statuses))
+	case "cancel":
+		var action exchange.CancelAction
+		if err := json.Unmarshal(req.Action, &action); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		statuses := make([]any, 0, len(action.Cancels))
+		for _, cancel := range action.Cancels {
+			statuses = append(statuses, s.cancelOrder(cancel))
+		}
+		writeJSON(w, okResponse("cancel", statuses))
+	case "updateIsolatedMargin":
+		var action exchange.UpdateIsolatedMarginAction
+		if err := json.Unmarshal(req.Action, &action); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		inst, ok := instruments[action.Asset]
+		s.mu.Lock()
+		if _, isolated := s.isolated[inst.name]; !ok || !isolated {
+			s.mu.Unlock()
+			writeJSON(w, map[string]any{"status": "err", "response": "Cannot update margin on a cross position."})
+			return
+		}
+		s.isolated[inst.name] += float64(action.Ntli) / 1e6
+		s.mu.Unlock()
+		writeJSON(w, map[string]any{"status": "ok", "response": map[string]any{"type": "default"}})
+	case "updateLeverage":
+		var action exchange.UpdateLeverageAction
+		if err := json.Unmarshal(req.Action, &action); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.leverages = append(s.leverages, action)
+		var reject string
+		if len(s.rejectLeverage) > 0 {
+			reject = s.rejectLeverage[0]
+			s.rejectLeverage = s.rejectLeverage[1:]
+		}
+		s.mu.Unlock()
+		if reject != "" {
+			writeJSON(w, map[string]any{"status": "err", "response": reject})
+			return
+		}
+		writeJSON(w, map[string]any{"status": "ok", "response": map[string]any{"type": "default"}})
+	case "vaultTransfer":
+		var action exchange.VaultTransferAction
+		if err := json.Unmarshal(req.Action, &action); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.vaultTransfers = append(s.vaultTransfers, action)
+		var reject string
+		if len(s.rejectVault) > 0 {
+			reject = s.rejectVault[0]
+			s.rejectVault = s.rejectVault[1:]
+		}
+		s.mu.Unlock()
+		if reject != "" {
+			writeJSON(w, map[string]any{"status": "err", "response": reject})
+			return
+		}
+		writeJSON(w, map[string]any{"status": "ok", "response": map[string]any{"type": "default"}})
+	default:
+		writeJSON(w, map[string]any{"status": "ok", "response": map[string]any{"type": "default"}})
+	}
+}
+
+func (s *Server) placeOrder(order exchange.OrderWire) any {
+	s.mu.Lock()
+	s.orders = append(s.orders, order)
+	if len(s.rejectNext) > 0 {
+		msg := s.rejectNext[0]
+		s.rejectNext = s.rejectNext[1:]
+		s.mu.Unlock()
+		return map[string]any{"error": msg}
+	}
+	inst, ok := instruments[order.Asset]
+	if !ok {
+		s.mu.Unlock()
+		return map[string]any{"error": fmt.Sprintf("Asset %d not found.", order.Asset)}
+	}
+	price, errPx := strconv.ParseFloat(order.Price, 64)
+	size, errSz := strconv.ParseFloat(order.Size, 64)
+	if errPx != nil || price <= 0 {
+		s.mu.Unlock()
+		return map[string]any{"error": "Order has invalid price."}
+	}
+	if errSz != nil || size <= 0 {
+		s.mu.Unlock()
+		return map[string]any{"error": "Order has invalid size."}
+	}
+	mid := floatOf(s.mids[inst.name])
+	marketable := mid > 0 && ((order.IsBuy && price >= mid) || (!order.IsBuy && price <= mid))
+	tif := exchange.TifGtc
+	if order.OrderType.Limit != nil {
+		tif = order.OrderType.Limit.Tif
+	}
+	s.nextOID++
+	oid := s.nextOID
+	now := time.Now().UnixMilli()
+
+	if !marketable {
+		if tif == exchange.TifIoc {
+			s.mu.Unlock()
+			return map[string]any{"error": fmt.Sprintf("%s asset=%d", MsgNoImmediateMatch, order.Asset)}
+		}
+		s.resting[oid] = restingOrder{asset: order.Asset, wire: order, oid: oid, time: now}
+		s.mu.Unlock()
+		s.push("orderUpdates", []any{orderUpdate(inst, order, oid, order.Size, "open", now)})
+		return map[string]any{"resting": restingStatus(oid, order.Cloid)}
+	}
+	if tif == exchange.TifAlo {
+		s.mu.Unlock()
+		return map[string]any{"error": fmt.Sprintf("%s asset=%d", MsgPostOnlyCross, order.Asset)}
+	}
+	filled := size * s.fillRatio
+	if filled <= 0 {
+		s.mu.Unlock()
+		return map[string]any{"error": fmt.Sprintf("%s asset=%d", MsgNoImmediateMatch, order.Asset)}
+	}
+	fill := s.applyFill(inst, order, oid, filled, mid, now)
+	s.mu.Unlock()
+
+	status := "filled"
+	if filled < size {
+		status = "canceled"
+	}
+	s.push("orderUpdates", []any{orderUpdate(inst, order, oid, formatFloat(size-filled), status, now)})
+	s.push("userFills", map[string]any{"isSnapshot": false, "fills": []any{fill}})
+	entry := map[string]any{"totalSz": formatFloat(filled), "avgPx": formatFloat(mid), "oid": oid}
+	if order.Cloid != "" {
+		entry["cloid"] = order.Cloid
+	}
+	return map[string]any{"filled": entry}
+}
+
+// applyFill moves positions and balances and records the fill. Callers hold
+// s.mu.
+func (s *Server) applyFill(inst instrument, order exchange.OrderWire, oid int64, size, price float64, now int64) map[string]any {
+	signed := size
+	side := "B"
+	if !order.IsBuy {
+		signed = -size
+		side = "A"
+	}
+	if inst.isSpot {
+		s.adjustBalance(inst.base, signed)
+		s.adjustBalance(inst.quote, -signed*price)
+	} else {
+		s.positions[inst.name] += signed
+		if s.positions[inst.name] == 0 {
+			delete(s.positions, inst.name)
+		}
+	}
+	s.nextTID++
+	fill := map[string]any{
+		"coin":      inst.fillCoin,
+		"side":      side,
+		"px":        formatFloat(price),
+		"sz":        formatFloat(size),
+		"oid":       oid,
+		"tid":       s.nextTID,
+		"time":      now,
+		"fee":       "0",
+		"closedPnl": "0",
+		"hash":      fmt.Sprintf("0x%064x", s.nextTID),
+	}
+	if order.Cloid != "" {
+		fill["cloid"] = order.Cloid
+	}
+	s.fills = append(s.fills, fill)
+	return fill
+}
+
+// FillOrder fills a resting order in full at its limit price, as if the
+// market traded through it. It reports false when oid is not resting.
+func (s *Server) FillOrder(oid int64) bool {
+	s.mu.Lock()
+	order, ok := s.resting[oid]
+	if !ok {
+		s.mu.Unlock()
+		return false
+	}
+	delete(s.resting, oid)
+	price := floatOf(order.wire.Price)
+	size := floatOf(order.wire.Size)
+	now := time.Now().UnixMilli()
+	inst := instruments[order.asset]
+	fill := s.applyFill(inst, order.wire, oid, size, price, now)
+	s.mu.Unlock()
+	s.push("orderUpdates", []any{orderUpdate(inst, order.wire, oid, "0", "filled", now)})
+	s.push("userFills", map[string]any{"isSnapshot": false, "fills": []any{fill}})
+	return true
+}
+
+func (s *Server) cancelOrder(cancel exchange.CancelWire) any {
+	s.mu.Lock()
+	s.cancels = append(s.cancels, cancel)
+	order, ok := s.resting[cancel.OrderID]
+	if ok && order.asset == cancel.Asset {
+		delete(s.resting, cancel.OrderID)
+	}
+	s.mu.Unlock()
+	if !ok || order.asset != cancel.Asset {
+		return map[string]any{"error": "Order was never placed, already canceled, or filled."}
+	}
+	inst := instruments[order.asset]
+	s.push("orderUpdates", []any{orderUpdate(inst, order.wire, order.oid, order.wire.Size, "canceled", time.Now().UnixMilli())})
+	return "success"
+}
+
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "done")
+	s.mu.Lock()
+	s.subs[conn] = make(map[string]bool)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, conn)
+		s.mu.Unlock()
+	}()
+	for {
+		_, data, err := conn.Read(r.Context())
+		if err != nil {
+			return
+		}
+		var msg struct {
+			Method       string         `json:"method"`
+			Subscription map[string]any `json:"subscription"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		switch msg.Method {
+		case "subscribe":
+			typ, _ := msg.Subscription["type"].(string)
+			s.mu.Lock()
+			s.subs[conn][typ] = true
+			s.mu.Unlock()
+			_ = writeWS(r, conn, map[string]any{"channel": "subscriptionResponse", "data": msg})
+		case "ping":
+			_ = writeWS(r, conn, map[string]any{"channel": "pong"})
+		}
+	}
+}
+
+// Subscribers counts connections subscribed to channel, so tests can wait
+// for a client to finish subscribing before triggering pushes.
+func (s *Server) Subscribers(channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, channels := range s.subs {
+		if channels[channel] {
+			n++
+		}
+	}
+	return n
+}
+
+// Push sends data on channel to every connection subscribed to it.
+func (s *Server) Push(channel string, data any) {
+	s.push(channel, data)
+}
+
+func (s *Server) push(channel string, data any) {
+	payload, err := json.Marshal(map[string]any{"channel": channel, "data": data})
+	if err != nil {
+		s.t.Errorf("hltest: encode %s push: %v", channel, err)
+		return
+	}
+	s.mu.Lock()
+	conns := make([]*websocket.Conn, 0, len(s.subs))
+	for conn, channels := range s.subs {
+		if channels[channel] {
+			conns = append(conns, conn)
+		}
+	}
+	s.mu.Unlock()
+	for _, conn := range conns {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_ = conn.Write(ctx, websocket.MessageText, payload)
+		cancel()
+	}
+}
+
+// positionsPayload renders clearinghouseState assetPositions. Callers hold
+// s.mu.
+func (s *Server) positionsPayload() []any {
+	out := make([]any, 0, len(s.positions))
+	for coin, size := range s.positions {
+		pos := map[string]any{
+			"coin":     coin,
+			"szi":      formatFloat(size),
+			"entryPx":  "3000",
+			"leverage": map[string]any{"type": "cross", "value": 1},
+		}
+		if margin, ok := s.isolated[coin]; ok {
+			mid, _ := strconv.ParseFloat(fmt.Sprint(s.mids[coin]), 64)
+			pos["leverage"] = map[string]any{"type": "isolated", "value": 1}
+			pos["marginUsed"] = formatFloat(margin)
+			pos["positionValue"] = formatFloat(math.Abs(size) * mid)
+		}
+		out = append(out, map[string]any{"type": "oneWay", "position": pos})
+	}
+	return out
+}
+
+// openOrdersPayload renders resting orders. Callers hold s.mu.
+func (s *Server) openOrdersPayload() []any {
+	out := make([]any, 0, len(s.resting))
+	for _, order := range s.resting {
+		inst := instruments[order.asset]
+		out = append(out, orderUpdate(inst, order.wire, order.oid, order.wire.Size, "open", order.time)["order"])
+	}
+	return out
+}
+
+// balanceEntry finds the spot balance of coin, creating it when missing.
+// Callers hold s.mu.
+func (s *Server) balanceEntry(coin string) map[string]any {
+	for _, item := range s.spotBalances {
+		if entry, ok := item.(map[string]any); ok && entry["coin"] == coin {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Server) adjustBalance(coin string, delta float64) {
+	entry := s.balanceEntry(coin)
+	if entry == nil {
+		entry = map[string]any{"coin": coin, "total": "0"}
+		s.spotBalances = append(s.spotBalances, entry)
+	}
+	entry["total"] = formatFloat(floatOf(entry["total"]) + delta)
+}
+
+func writeWS(r *http.Request, conn *websocket.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return conn.Write(r.Context(), websocket.MessageText, data)
+}
+
+func orderUpdate(inst instrument, order exchange.OrderWire, oid int64, remaining, status string, now int64) map[string]any {
+	side := "B"
+	if !order.IsBuy {
+		side = "A"
+	}
+	entry := map[string]any{
+		"coin":      inst.fillCoin,
+		"side":      side,
+		"limitPx":   order.Price,
+		"sz":        remaining,
+		"oid":       oid,
+		"origSz":    order.Size,
+		"timestamp": now,
+	}
+	if order.Cloid != "" {
+		entry["cloid"] = order.Cloid
+	}
+	return map[string]any{"order": entry, "status": status, "statusTimestamp": now}
+}
+
+func restingStatus(oid int64, cloid string) map[string]any {
+	entry := map[string]any{"oid": oid}
+	if cloid != "" {
+		entry["cloid"] = cloid
+	}
+	return entry
+}
+
+func okResponse(typ string, statuses []any) map[string]any {
+	return map[string]any{
+		"status": "ok",
+		"response": map[string]any{
+			"type": typ,
+			"data": map[string]any{"statuses": statuses},
+		},
+	}
+}
+
+// copyBalances snapshots balances so fills applied later do not race the
+// JSON encoder.
+func copyBalances(balances []any) []any {
+	out := make([]any, 0, len(balances))
+	for _, item := range balances {
+		if entry, ok := item.(map[string]any); ok {
+			clone := make(map[string]any, len(entry))
+			for k, v := range entry {
+				clone[k] = v
+			}
+			out = append(out, clone)
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+func floatOf(v any) float64 {
+	switch val := v.(type) {
+	case float64:
+		return val
+	case int:
+		return float64(val)
+	case string:
+		f, _ := strconv.ParseFloat(val, 64)
+		return f
+	default:
+		return 0
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func writeJSON(w http.ResponseWriter, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(payload)
+}
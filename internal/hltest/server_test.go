@@ -0,0 +1,165 @@
+package hltest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/exchange"
+	"hl-carry-bot/internal/hl/rest"
+	"hl-carry-bot/internal/hl/ws"
+
+	"go.uber.org/zap"
+)
+
+func newTestClient(t *testing.T, s *Server) *exchange.Client {
+	t.Helper()
+	signer, err := exchange.NewSigner("4f3edf983ac636a65a842ce7c78d9aa706d3b113bce036f81af8f9b72d3d80b2", false)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	client, err := exchange.NewClient(s.URL(), 2*time.Second, signer, "")
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	return client
+}
+
+func iocOrder(asset int, isBuy bool, price, size string) exchange.OrderWire {
+	return exchange.OrderWire{
+		Asset:     asset,
+		IsBuy:     isBuy,
+		Price:     price,
+		Size:      size,
+		OrderType: exchange.OrderTypeWire{Limit: &exchange.LimitOrderType{Tif: exchange.TifIoc}},
+	}
+}
+
+func TestServerOrderLifecycle(t *testing.T) {
+	s := NewServer(t)
+	client := newTestClient(t, s)
+	ctx := context.Background()
+
+	resp, err := client.PlaceOrder(ctx, iocOrder(PerpAsset, false, "2990", "0.1"))
+	if err != nil {
+		t.Fatalf("place perp: %v", err)
+	}
+	results, err := exchange.OrderResults(resp)
+	if err != nil || len(results) != 1 || results[0].Status != exchange.OrderStatusFilled {
+		t.Fatalf("expected a filled perp order, got %+v err=%v", results, err)
+	}
+	if got := s.PerpPosition("ETH"); got != -0.1 {
+		t.Fatalf("expected short 0.1 ETH, got %v", got)
+	}
+
+	if _, err := client.PlaceOrder(ctx, iocOrder(SpotAsset, true, "3010", "0.01")); err != nil {
+		t.Fatalf("place spot: %v", err)
+	}
+	if got := s.SpotBalance("UETH"); got != 0.01 {
+		t.Fatalf("expected 0.01 UETH, got %v", got)
+	}
+	if got := s.SpotBalance("USDC"); got != 70 {
+		t.Fatalf("expected 70 USDC left, got %v", got)
+	}
+
+	fills, err := rest.New(s.URL(), time.Second, zap.NewNop()).InfoAny(ctx, map[string]any{"type": "userFillsByTime", "user": "0xabc", "startTime": 1})
+	if err != nil {
+		t.Fatalf("fills: %v", err)
+	}
+	if list, _ := fills.([]any); len(list) != 2 {
+		t.Fatalf("expected 2 recorded fills, got %v", fills)
+	}
+}
+
+func TestServerRejectsAndRests(t *testing.T) {
+	s := NewServer(t)
+	client := newTestClient(t, s)
+	ctx := context.Background()
+
+	resp, err := client.PlaceOrder(ctx, iocOrder(PerpAsset, false, "3100", "0.1"))
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	results, _ := exchange.OrderResults(resp)
+	if len(results) != 1 || !errors.Is(results[0].Err, exchange.ErrNoImmediateMatch) {
+		t.Fatalf("expected an unmatched IOC, got %+v", results)
+	}
+
+	alo := iocOrder(PerpAsset, true, "3100", "0.1")
+	alo.OrderType.Limit.Tif = exchange.TifAlo
+	resp, _ = client.PlaceOrder(ctx, alo)
+	results, _ = exchange.OrderResults(resp)
+	if len(results) != 1 || !errors.Is(results[0].Err, exchange.ErrPostOnlyCross) {
+		t.Fatalf("expected a post-only cross, got %+v", results)
+	}
+
+	alo.Price = "2900"
+	resp, _ = client.PlaceOrder(ctx, alo)
+	results, _ = exchange.OrderResults(resp)
+	if len(results) != 1 || results[0].Status != exchange.OrderStatusResting {
+		t.Fatalf("expected a resting order, got %+v", results)
+	}
+	oid, _ := strconv.ParseInt(results[0].OrderID, 10, 64)
+	if ids := s.OpenOrderIDs(); len(ids) != 1 || ids[0] != oid {
+		t.Fatalf("expected resting order %d, got %v", oid, ids)
+	}
+	if _, err := client.CancelOrder(ctx, PerpAsset, oid); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if ids := s.OpenOrderIDs(); len(ids) != 0 {
+		t.Fatalf("expected no resting orders after cancel, got %v", ids)
+	}
+
+	s.RejectNextOrder("Insufficient margin to place order. asset=1")
+	resp, _ = client.PlaceOrder(ctx, iocOrder(PerpAsset, false, "2990", "0.1"))
+	results, _ = exchange.OrderResults(resp)
+	if len(results) != 1 || !errors.Is(results[0].Err, exchange.ErrInsufficientMargin) {
+		t.Fatalf("expected an injected margin rejection, got %+v", results)
+	}
+}
+
+func TestServerPushesOrderUpdates(t *testing.T) {
+	s := NewServer(t)
+	client := newTestClient(t, s)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	wsClient := ws.New(s.WSURL(), 10*time.Millisecond, 0, zap.NewNop())
+	if err := wsClient.Connect(ctx); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := wsClient.Subscribe(ctx, map[string]any{"method": "subscribe", "subscription": map[string]any{"type": "orderUpdates", "user": "0xabc"}}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	updates := make(chan string, 4)
+	go func() {
+		_ = wsClient.Run(ctx, func(msg json.RawMessage) {
+			if strings.Contains(string(msg), `"channel":"orderUpdates"`) {
+				updates <- string(msg)
+			}
+		})
+	}()
+	deadline := time.Now().Add(time.Second)
+	for s.Subscribers("orderUpdates") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("subscription never registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := client.PlaceOrder(ctx, iocOrder(PerpAsset, true, "3010", "0.1")); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	select {
+	case msg := <-updates:
+		if !strings.Contains(msg, `"status":"filled"`) {
+			t.Fatalf("expected a filled update, got %s", msg)
+		}
+	case <-ctx.Done():
+		t.Fatalf("no order update pushed")
+	}
+}
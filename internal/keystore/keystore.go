@@ -0,0 +1,158 @@
+// Package keystore loads the signing key from the environment, an encrypted
+// geth keystore file, or the OS keychain. The decrypted key only ever lives
+// in memory.
+package keystore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	gethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// keys.source values.
+const (
+	SourceEnv      = "env"
+	SourceKeystore = "keystore"
+	SourceKeychain = "keychain"
+)
+
+// Source says where one signing key is read from.
+type Source struct {
+	Kind string
+	// Env is the variable holding the hex key for SourceEnv.
+	Env string
+	// Path and PassphraseEnv locate and unlock a SourceKeystore file.
+	Path          string
+	PassphraseEnv string
+	// Service and Account name the SourceKeychain entry.
+	Service string
+	Account string
+}
+
+// Load reads and decrypts the key src points at.
+func Load(ctx context.Context, src Source) (*ecdsa.PrivateKey, error) {
+	switch src.Kind {
+	case "", SourceEnv:
+		hexKey := strings.TrimSpace(os.Getenv(src.Env))
+		if hexKey == "" {
+			return nil, fmt.Errorf("%s is required", src.Env)
+		}
+		key, err := ParseHex(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src.Env, err)
+		}
+		return key, nil
+	case SourceKeystore:
+		passphrase, err := Passphrase(src.PassphraseEnv, src.Path)
+		if err != nil {
+			return nil, err
+		}
+		return DecryptFile(src.Path, passphrase)
+	case SourceKeychain:
+		return Keychain(ctx, src.Service, src.Account)
+	default:
+		return nil, fmt.Errorf("unknown key source %q", src.Kind)
+	}
+}
+
+// ParseHex parses a hex private key, with or without the 0x prefix.
+func ParseHex(hexKey string) (*ecdsa.PrivateKey, error) {
+	clean := strings.TrimPrefix(strings.TrimSpace(hexKey), "0x")
+	if clean == "" {
+		return nil, errors.New("private key is required")
+	}
+	return crypto.HexToECDSA(clean)
+}
+
+// DecryptFile decrypts a geth keystore (Web3 secret storage v3) JSON file,
+// as written by `geth account new` or `cast wallet import`.
+func DecryptFile(path, passphrase string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	key, err := gethkeystore.DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("keystore %s: %w", path, err)
+	}
+	return key.PrivateKey, nil
+}
+
+// Passphrase reads the keystore passphrase from env, or prompts for it on the
+// controlling terminal with echo off when env is unset.
+func Passphrase(env, path string) (string, error) {
+	if env != "" {
+		if passphrase, ok := os.LookupEnv(env); ok {
+			return passphrase, nil
+		}
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("keystore passphrase: set %s or run on a terminal", env)
+	}
+	defer tty.Close()
+	fmt.Fprintf(tty, "Passphrase for %s: ", path)
+	if stty(tty, "-echo") == nil {
+		defer func() {
+			_ = stty(tty, "echo")
+			fmt.Fprintln(tty)
+		}()
+	}
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("keystore passphrase: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func stty(tty *os.File, arg string) error {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = tty
+	return cmd.Run()
+}
+
+// keychainCommand looks up a generic password: the login keychain through
+// security on macOS, the Secret Service (GNOME Keyring, KWallet) through
+// secret-tool elsewhere. Tests replace it.
+var keychainCommand = func(ctx context.Context, service, account string) *exec.Cmd {
+	if runtime.GOOS == "darwin" {
+		return exec.CommandContext(ctx, "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	}
+	return exec.CommandContext(ctx, "secret-tool", "lookup", "service", service, "account", account)
+}
+
+// Keychain reads the hex private key stored in the OS keychain under service
+// and account, e.g. stored with
+//
+//	security add-generic-password -s hl-carry-bot -a 0xWALLET -w
+//	secret-tool store --label hl-carry-bot service hl-carry-bot account 0xWALLET
+func Keychain(ctx context.Context, service, account string) (*ecdsa.PrivateKey, error) {
+	if service == "" || account == "" {
+		return nil, errors.New("keychain service and account are required")
+	}
+	var stderr bytes.Buffer
+	cmd := keychainCommand(ctx, service, account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("keychain %s/%s: %w: %s", service, account, err, msg)
+		}
+		return nil, fmt.Errorf("keychain %s/%s: %w", service, account, err)
+	}
+	key, err := ParseHex(string(out))
+	if err != nil {
+		return nil, fmt.Errorf("keychain %s/%s: %w", service, account, err)
+	}
+	return key, nil
+}
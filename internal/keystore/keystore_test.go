@@ -0,0 +1,92 @@
+package keystore
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	gethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testKeyHex = "4f3edf983ac636a65a842ce7c78d9aa706d3b113bce9c46f30d7d21715b23b1d"
+
+func writeKeystore(t *testing.T, passphrase string) string {
+	t.Helper()
+	key, err := ParseHex(testKeyHex)
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	encrypted, err := gethkeystore.EncryptDataV3(crypto.FromECDSA(key), []byte(passphrase), gethkeystore.LightScryptN, gethkeystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	data, err := json.Marshal(map[string]any{
+		"address": hex.EncodeToString(crypto.PubkeyToAddress(key.PublicKey).Bytes()),
+		"crypto":  encrypted,
+		"id":      "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+		"version": 3,
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestLoadKeystore(t *testing.T) {
+	path := writeKeystore(t, "hunter2")
+	want, _ := ParseHex(testKeyHex)
+	t.Setenv("TEST_KEYSTORE_PASSPHRASE", "hunter2")
+
+	key, err := Load(context.Background(), Source{Kind: SourceKeystore, Path: path, PassphraseEnv: "TEST_KEYSTORE_PASSPHRASE"})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !key.Equal(want) {
+		t.Fatal("expected the decrypted key to match")
+	}
+	if _, err := DecryptFile(path, "wrong"); err == nil {
+		t.Fatal("expected a wrong passphrase rejected")
+	}
+}
+
+func TestLoadEnvAndKeychain(t *testing.T) {
+	want, _ := ParseHex(testKeyHex)
+	t.Setenv("TEST_PRIVATE_KEY", "0x"+testKeyHex)
+	key, err := Load(context.Background(), Source{Kind: SourceEnv, Env: "TEST_PRIVATE_KEY"})
+	if err != nil || !key.Equal(want) {
+		t.Fatalf("env key: %v", err)
+	}
+	if _, err := Load(context.Background(), Source{Kind: SourceEnv, Env: "TEST_UNSET_PRIVATE_KEY"}); err == nil {
+		t.Fatal("expected an unset variable rejected")
+	}
+
+	prev := keychainCommand
+	defer func() { keychainCommand = prev }()
+	var gotService, gotAccount string
+	keychainCommand = func(ctx context.Context, service, account string) *exec.Cmd {
+		gotService, gotAccount = service, account
+		return exec.CommandContext(ctx, "echo", testKeyHex)
+	}
+	key, err = Load(context.Background(), Source{Kind: SourceKeychain, Service: "hl-carry-bot", Account: "0xabc"})
+	if err != nil || !key.Equal(want) {
+		t.Fatalf("keychain key: %v", err)
+	}
+	if gotService != "hl-carry-bot" || gotAccount != "0xabc" {
+		t.Fatalf("unexpected keychain lookup %s/%s", gotService, gotAccount)
+	}
+	keychainCommand = func(ctx context.Context, service, account string) *exec.Cmd {
+		return exec.CommandContext(ctx, "false")
+	}
+	if _, err := Load(context.Background(), Source{Kind: SourceKeychain, Service: "hl-carry-bot", Account: "0xabc"}); err == nil {
+		t.Fatal("expected a failed lookup reported")
+	}
+}
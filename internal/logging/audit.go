@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const auditKey = "log_audit"
+
+// Audit marks an entry (or a logger via With) as an order or transfer action
+// for the audit log. Audit entries are never sampled. Encoders skip the
+// field, so it never appears in output.
+func Audit() zap.Field {
+	return zap.Field{Key: auditKey, Type: zapcore.SkipType}
+}
+
+// auditCore passes only entries marked with Audit to the audit sink.
+type auditCore struct {
+	zapcore.Core
+	audit bool
+}
+
+func newAuditCore(core zapcore.Core) zapcore.Core {
+	return &auditCore{Core: core}
+}
+
+func (c *auditCore) With(fields []zapcore.Field) zapcore.Core {
+	return &auditCore{
+		Core:  c.Core.With(fields),
+		audit: c.audit || hasMarker(fields, auditKey),
+	}
+}
+
+func (c *auditCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *auditCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.audit || hasMarker(fields, auditKey) {
+		return c.Core.Write(ent, fields)
+	}
+	return nil
+}
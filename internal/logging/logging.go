@@ -1,27 +1,86 @@
 package logging
 
 import (
+	"fmt"
+	"os"
+
 	"hl-carry-bot/internal/config"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// New builds the bot logger: entries at cfg.Level in cfg.Format go to
+// stderr, or to a rotating file when log.file.path is set, with per-level
+// sampling. When log.audit.path is set, entries marked Audit are also
+// written, unsampled and as JSON, to the audit file. A file that cannot be
+// opened falls back to stderr so the bot never runs without logs.
 func New(cfg config.LoggingConfig) *zap.Logger {
-	zapCfg := zap.NewProductionConfig()
-	switch cfg.Level {
+	level := zap.NewAtomicLevelAt(parseLevel(cfg.Level))
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoder := zapcore.NewJSONEncoder(encoderCfg)
+	if cfg.Format == "console" {
+		encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderCfg)
+	}
+	var fileErrs []error
+	sink := zapcore.Lock(os.Stderr)
+	if cfg.File.Path != "" {
+		file, err := openRotatingFile(cfg.File)
+		if err != nil {
+			fileErrs = append(fileErrs, fmt.Errorf("log.file: %w", err))
+		} else {
+			sink = zapcore.AddSync(file)
+		}
+	}
+	// zap's default sampler drops by message regardless of priority; sampling
+	// is applied by samplingCore instead so order logs are never dropped.
+	core := newSamplingCore(zapcore.NewCore(encoder, sink, level), samplingEvery(cfg.Sampling))
+	if cfg.Audit.Path != "" {
+		file, err := openRotatingFile(cfg.Audit)
+		if err != nil {
+			fileErrs = append(fileErrs, fmt.Errorf("log.audit: %w", err))
+		} else {
+			audit := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(file), zapcore.DebugLevel)
+			core = zapcore.NewTee(core, newAuditCore(audit))
+		}
+	}
+	logger := zap.New(core,
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	)
+	for _, err := range fileErrs {
+		logger.Error("log file unavailable; logging to stderr", zap.Error(err))
+	}
+	return logger
+}
+
+func parseLevel(level string) zapcore.Level {
+	switch level {
 	case "debug":
-		zapCfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+		return zapcore.DebugLevel
 	case "warn":
-		zapCfg.Level = zap.NewAtomicLevelAt(zapcore.WarnLevel)
+		return zapcore.WarnLevel
 	case "error":
-		zapCfg.Level = zap.NewAtomicLevelAt(zapcore.ErrorLevel)
+		return zapcore.ErrorLevel
 	default:
-		zapCfg.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+		return zapcore.InfoLevel
 	}
-	logger, err := zapCfg.Build()
-	if err != nil {
-		return zap.NewNop()
+}
+
+// WithSampling wraps a logger's core with per-level message sampling.
+func WithSampling(cfg config.LogSamplingConfig) zap.Option {
+	every := samplingEvery(cfg)
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newSamplingCore(core, every)
+	})
+}
+
+func samplingEvery(cfg config.LogSamplingConfig) map[zapcore.Level]int {
+	return map[zapcore.Level]int{
+		zapcore.DebugLevel: cfg.Debug,
+		zapcore.InfoLevel:  cfg.Info,
+		zapcore.WarnLevel:  cfg.Warn,
 	}
-	return logger
 }
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+)
+
+func TestNewWritesJSONFileAndAuditStream(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "bot.log")
+	auditPath := filepath.Join(dir, "audit", "audit.log")
+	log := New(config.LoggingConfig{
+		Level:    "info",
+		Format:   "json",
+		Sampling: config.LogSamplingConfig{Info: 100},
+		File:     config.LogFileConfig{Path: logPath, MaxSizeMB: 1},
+		Audit:    config.LogFileConfig{Path: auditPath, MaxSizeMB: 1},
+	})
+	log.Info("tick")
+	orders := log.With(Audit())
+	for i := 0; i < 3; i++ {
+		log.Info("tick")
+		orders.Info("order placed")
+	}
+	log.Info("transferred USDC to wallet", Audit())
+	log.Debug("order placed", Audit())
+	_ = log.Sync()
+
+	main := readLines(t, logPath)
+	if len(main) != 5 {
+		t.Fatalf("expected 1 sampled tick and 4 audit entries in the main log, got %d: %v", len(main), main)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(main[0]), &entry); err != nil {
+		t.Fatalf("expected JSON log lines, got %q: %v", main[0], err)
+	}
+	audit := readLines(t, auditPath)
+	if len(audit) != 5 {
+		t.Fatalf("expected every audit entry in the audit log, got %d: %v", len(audit), audit)
+	}
+	for _, line := range audit {
+		if strings.Contains(line, `"tick"`) || strings.Contains(line, auditKey) {
+			t.Fatalf("unexpected audit line %q", line)
+		}
+	}
+}
+
+func TestRotatingFileRotatesBySizeAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bot.log")
+	f, err := openRotatingFile(config.LogFileConfig{Path: path, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	f.maxSize = 10
+	now := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Second)
+		if _, err := f.Write([]byte("0123456789")); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups kept, got %v", backups)
+	}
+	if backups[1] != path+".20260102T030005.000" {
+		t.Fatalf("expected newest backups kept, got %v", backups)
+	}
+
+	f.maxBackups = 0
+	f.maxAge = time.Hour
+	now = now.Add(2 * time.Hour)
+	if _, err := f.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	backups, _ = filepath.Glob(path + ".*")
+	if len(backups) != 1 || backups[0] != path+"."+now.Format(backupTimeFormat) {
+		t.Fatalf("expected only the fresh backup after age pruning, got %v", backups)
+	}
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return strings.Split(strings.TrimSpace(string(raw)), "\n")
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const unsampledKey = "log_unsampled"
+
+// Unsampled marks an entry (or a logger via With) as high priority so it
+// bypasses sampling. Use it for order, entry, and exit logs. Encoders skip
+// the field, so it never appears in output.
+func Unsampled() zap.Field {
+	return zap.Field{Key: unsampledKey, Type: zapcore.SkipType}
+}
+
+// samplingCore passes the first occurrence of each message at a level and
+// then every Nth repeat, where N comes from the per-level configuration.
+type samplingCore struct {
+	zapcore.Core
+	every     map[zapcore.Level]int
+	counts    *sampleCounts
+	unsampled bool
+}
+
+type sampleKey struct {
+	level   zapcore.Level
+	message string
+}
+
+type sampleCounts struct {
+	mu     sync.Mutex
+	counts map[sampleKey]uint64
+}
+
+func newSamplingCore(core zapcore.Core, every map[zapcore.Level]int) zapcore.Core {
+	return &samplingCore{
+		Core:   core,
+		every:  every,
+		counts: &sampleCounts{counts: make(map[sampleKey]uint64)},
+	}
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{
+		Core:      c.Core.With(fields),
+		every:     c.every,
+		counts:    c.counts,
+		unsampled: c.unsampled || hasUnsampled(fields),
+	}
+}
+
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+// Write decides on sampling here rather than in Check because the
+// unsampled marker is only visible alongside the entry's fields. Unsampled
+// entries still count, so repeats after one are sampled as usual.
+func (c *samplingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	keep := c.keep(ent)
+	if keep || c.unsampled || hasUnsampled(fields) {
+		return c.Core.Write(ent, fields)
+	}
+	return nil
+}
+
+func (c *samplingCore) keep(ent zapcore.Entry) bool {
+	every := c.every[ent.Level]
+	if every <= 1 {
+		return true
+	}
+	key := sampleKey{level: ent.Level, message: ent.Message}
+	c.counts.mu.Lock()
+	n := c.counts.counts[key]
+	c.counts.counts[key] = n + 1
+	c.counts.mu.Unlock()
+	return n%uint64(every) == 0
+}
+
+// hasUnsampled reports an Unsampled or Audit marker among fields.
+func hasUnsampled(fields []zapcore.Field) bool {
+	return hasMarker(fields, unsampledKey) || hasMarker(fields, auditKey)
+}
+
+func hasMarker(fields []zapcore.Field, key string) bool {
+	for _, field := range fields {
+		if field.Key == key && field.Type == zapcore.SkipType {
+			return true
+		}
+	}
+	return false
+}
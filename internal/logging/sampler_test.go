@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"testing"
+
+	"hl-carry-bot/internal/config"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSamplingKeepsEveryNthRepeat(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(core, WithSampling(config.LogSamplingConfig{Debug: 3}))
+	for i := 0; i < 7; i++ {
+		log.Debug("tick")
+	}
+	for i := 0; i < 2; i++ {
+		log.Info("status")
+	}
+	if got := logs.FilterMessage("tick").Len(); got != 3 {
+		t.Fatalf("expected 3 sampled debug entries, got %d", got)
+	}
+	if got := logs.FilterMessage("status").Len(); got != 2 {
+		t.Fatalf("expected unsampled info entries, got %d", got)
+	}
+}
+
+func TestSamplingSkipsUnsampledEntries(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(core, WithSampling(config.LogSamplingConfig{Debug: 100, Info: 100}))
+	orders := log.With(Unsampled())
+	for i := 0; i < 5; i++ {
+		log.Debug("tick", Unsampled())
+		orders.Info("order placed")
+	}
+	if got := logs.FilterMessage("tick").Len(); got != 5 {
+		t.Fatalf("expected all unsampled ticks, got %d", got)
+	}
+	if got := logs.FilterMessage("order placed").Len(); got != 5 {
+		t.Fatalf("expected all order logs, got %d", got)
+	}
+	for _, entry := range logs.All() {
+		if _, ok := entry.ContextMap()[unsampledKey]; ok {
+			t.Fatalf("expected marker field to be skipped, got %v", entry.ContextMap())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// BookLevel is one aggregated l2Book price level.
+type BookLevel struct {
+	Px float64
+	Sz float64
+}
+
+// L2Book is a REST snapshot of one coin's book, best levels first.
+type L2Book struct {
+	Coin      string
+	Bids      []BookLevel
+	Asks      []BookLevel
+	FetchedAt time.Time
+}
+
+// L2Book fetches the book for coin (a perp name or spot "@index"/pair key)
+// from the l2Book /info endpoint and caches it for CachedL2Book.
+func (m *MarketData) L2Book(ctx context.Context, coin string) (L2Book, error) {
+	if m.rest == nil {
+		return L2Book{}, errors.New("rest client is required")
+	}
+	if coin == "" {
+		return L2Book{}, errors.New("coin is required")
+	}
+	payload, err := m.rest.InfoAny(ctx, map[string]any{"type": "l2Book", "coin": coin})
+	if err != nil {
+		return L2Book{}, err
+	}
+	book, ok := parseL2Book(payload)
+	if !ok {
+		return L2Book{}, errors.New("l2Book response missing levels")
+	}
+	book.Coin = coin
+	book.FetchedAt = time.Now().UTC()
+	m.mu.Lock()
+	m.books[coin] = book
+	m.mu.Unlock()
+	return book, nil
+}
+
+// CachedL2Book returns the last book fetched for coin.
+func (m *MarketData) CachedL2Book(coin string) (L2Book, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	book, ok := m.books[coin]
+	return book, ok
+}
+
+// Imbalance is (bid - ask) / (bid + ask) resting notional over the best
+// levels of each side, in [-1, 1]; negative means offers outweigh bids. ok is
+// false while either side is empty.
+func (b L2Book) Imbalance(levels int) (float64, bool) {
+	bid, ask := bookNotional(b.Bids, levels), bookNotional(b.Asks, levels)
+	if bid <= 0 || ask <= 0 {
+		return 0, false
+	}
+	return (bid - ask) / (bid + ask), true
+}
+
+func bookNotional(levels []BookLevel, depth int) float64 {
+	if depth > 0 && len(levels) > depth {
+		levels = levels[:depth]
+	}
+	total := 0.0
+	for _, level := range levels {
+		total += level.Px * level.Sz
+	}
+	return total
+}
+
+func parseL2Book(payload any) (L2Book, bool) {
+	data, ok := toMap(payload)
+	if !ok {
+		return L2Book{}, false
+	}
+	sides, ok := toSlice(data["levels"])
+	if !ok || len(sides) != 2 {
+		return L2Book{}, false
+	}
+	return L2Book{Bids: parseBookSide(sides[0]), Asks: parseBookSide(sides[1])}, true
+}
+
+func parseBookSide(raw any) []BookLevel {
+	items, _ := toSlice(raw)
+	levels := make([]BookLevel, 0, len(items))
+	for _, item := range items {
+		entry, ok := toMap(item)
+		if !ok {
+			continue
+		}
+		px := floatFromMap(entry, "px")
+		sz := floatFromMap(entry, "sz")
+		if px <= 0 || sz <= 0 {
+			continue
+		}
+		levels = append(levels, BookLevel{Px: px, Sz: sz})
+	}
+	return levels
+}
@@ -0,0 +1,67 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/rest"
+
+	"go.uber.org/zap"
+)
+
+func TestL2BookParsesAndCaches(t *testing.T) {
+	var req map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"coin":"ETH","time":1700000000000,"levels":[
+			[{"px":"2999.5","sz":"1.2","n":3},{"px":"2999.0","sz":"4","n":1}],
+			[{"px":"3000.5","sz":"0.8","n":2},{"px":"bad","sz":"1","n":1}]
+		]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	md := New(rest.New(srv.URL, 2*time.Second, zap.NewNop()), nil, zap.NewNop())
+	book, err := md.L2Book(context.Background(), "ETH")
+	if err != nil {
+		t.Fatalf("l2 book: %v", err)
+	}
+	if req["type"] != "l2Book" || req["coin"] != "ETH" {
+		t.Fatalf("unexpected request: %v", req)
+	}
+	if len(book.Bids) != 2 || book.Bids[0] != (BookLevel{Px: 2999.5, Sz: 1.2}) {
+		t.Fatalf("unexpected bids: %+v", book.Bids)
+	}
+	if len(book.Asks) != 1 || book.Asks[0] != (BookLevel{Px: 3000.5, Sz: 0.8}) {
+		t.Fatalf("expected malformed ask skipped, got %+v", book.Asks)
+	}
+	if cached, ok := md.CachedL2Book("ETH"); !ok || cached.FetchedAt.IsZero() {
+		t.Fatalf("expected cached book, got %+v", cached)
+	}
+}
+
+func TestL2BookImbalance(t *testing.T) {
+	book := L2Book{
+		Bids: []BookLevel{{Px: 99, Sz: 1}, {Px: 98, Sz: 1}},
+		Asks: []BookLevel{{Px: 101, Sz: 3}, {Px: 102, Sz: 100}},
+	}
+	imbalance, ok := book.Imbalance(1)
+	if !ok {
+		t.Fatalf("expected an imbalance")
+	}
+	if want := (99.0 - 303.0) / (99.0 + 303.0); !closeEnough(imbalance, want) {
+		t.Fatalf("expected imbalance over the best level %f, got %f", want, imbalance)
+	}
+	if imbalance, _ := book.Imbalance(5); imbalance >= -0.9 {
+		t.Fatalf("expected deeper offers to weigh in, got %f", imbalance)
+	}
+	if _, ok := (L2Book{Bids: book.Bids}).Imbalance(5); ok {
+		t.Fatalf("expected no imbalance with an empty side")
+	}
+}
@@ -1,6 +1,12 @@
 package market
 
-import "time"
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
 
 type Candle struct {
 	Asset    string
@@ -12,3 +18,282 @@ type Candle struct {
 	Close    float64
 	Volume   float64
 }
+
+// defaultCandleWindow is the window of a series enabled without one.
+const defaultCandleWindow = 20
+
+// candleSeries is one (asset, interval) candle subscription. closes holds
+// every update (the stdev estimator's input); history holds one candle per
+// start.
+type candleSeries struct {
+	asset      string
+	interval   string
+	window     int
+	closes     []float64
+	history    []Candle
+	last       Candle
+	hasLast    bool
+	volatility float64
+	hasVol     bool
+}
+
+// EnableCandle tracks asset's candles of interval (default 1h) over the
+// last window candles. Every (asset, interval) is an independent series with
+// its own window and volatility; enabling one again only updates its
+// window. The first interval enabled for an asset also takes candle updates
+// that carry no interval.
+func (m *MarketData) EnableCandle(asset, interval string, window int) {
+	if asset == "" {
+		return
+	}
+	if interval == "" {
+		interval = "1h"
+	}
+	if window <= 0 {
+		window = defaultCandleWindow
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := candleKey(asset, interval)
+	if series, ok := m.candles[key]; ok {
+		series.window = window
+		return
+	}
+	m.candles[key] = &candleSeries{asset: asset, interval: interval, window: window}
+	m.candleOrder = append(m.candleOrder, key)
+	if _, ok := m.candleAssets[asset]; !ok {
+		m.candleAssets[asset] = interval
+	}
+}
+
+// candleSeriesSpec is a copy of a series' identity, for use without the lock.
+type candleSeriesSpec struct {
+	asset    string
+	interval string
+	window   int
+}
+
+func (m *MarketData) candleSpecs() []candleSeriesSpec {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]candleSeriesSpec, 0, len(m.candleOrder))
+	for _, key := range m.candleOrder {
+		series := m.candles[key]
+		out = append(out, candleSeriesSpec{asset: series.asset, interval: series.interval, window: series.window})
+	}
+	return out
+}
+
+func (m *MarketData) subscribeCandles(ctx context.Context) {
+	for _, spec := range m.candleSpecs() {
+		sub := map[string]any{
+			"method": "subscribe",
+			"subscription": map[string]any{
+				"type":     "candle",
+				"coin":     spec.asset,
+				"interval": spec.interval,
+			},
+		}
+		if err := m.ws.Subscribe(ctx, sub); err != nil {
+			m.log.Warn("candle subscribe failed", zap.String("asset", spec.asset), zap.String("interval", spec.interval), zap.Error(err))
+		}
+	}
+}
+
+// Volatility is the estimate for asset's interval series (per interval).
+func (m *MarketData) Volatility(asset, interval string) (float64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	series, ok := m.candles[candleKey(asset, interval)]
+	if !ok || !series.hasVol {
+		return 0, false
+	}
+	return series.volatility, true
+}
+
+// LatestCandle is the in-progress (or last) candle of asset's interval series.
+func (m *MarketData) LatestCandle(asset, interval string) (Candle, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	series, ok := m.candles[candleKey(asset, interval)]
+	if !ok || !series.hasLast {
+		return Candle{}, false
+	}
+	return series.last, true
+}
+
+func (m *MarketData) updateCandle(payload map[string]any) {
+	candle, ok := parseCandleOHLC(payload)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	m.markFeedLocked(FeedCandles, candle.Asset, now)
+	if candle.Interval == "" {
+		candle.Interval = m.candleAssets[candle.Asset]
+	}
+	series, ok := m.candles[candleKey(candle.Asset, candle.Interval)]
+	if !ok {
+		return
+	}
+	if candle.Open == 0 {
+		candle.Open = candle.Close
+	}
+	if candle.High == 0 {
+		candle.High = candle.Close
+	}
+	if candle.Low == 0 {
+		candle.Low = candle.Close
+	}
+	if candle.Start.IsZero() {
+		candle.Start = now
+	}
+	series.last = candle
+	series.hasLast = true
+	series.append(candle)
+	series.closes = append(series.closes, candle.Close)
+	if len(series.closes) > series.window {
+		series.closes = series.closes[len(series.closes)-series.window:]
+	}
+	m.updateCandleVolatilityLocked(series)
+}
+
+// BackfillCandles seeds every candle series from the candleSnapshot /info
+// endpoint, so volatility is known at startup instead of after a window of
+// WS candles. Candles already received over WS are kept; the snapshot only
+// fills in older ones and gaps. It returns the number of candles added.
+func (m *MarketData) BackfillCandles(ctx context.Context) (int, error) {
+	if m.rest == nil {
+		return 0, nil
+	}
+	added := 0
+	for _, spec := range m.candleSpecs() {
+		n, err := m.backfillSeries(ctx, spec)
+		if err != nil {
+			return added, err
+		}
+		added += n
+	}
+	return added, nil
+}
+
+func (m *MarketData) backfillSeries(ctx context.Context, spec candleSeriesSpec) (int, error) {
+	now := time.Now().UTC()
+	start := now.Add(-time.Duration(spec.window+1) * candleIntervalDuration(spec.interval))
+	payload, err := m.rest.InfoAny(ctx, map[string]any{
+		"type": "candleSnapshot",
+		"req": map[string]any{
+			"coin":      spec.asset,
+			"interval":  spec.interval,
+			"startTime": start.UnixMilli(),
+			"endTime":   now.UnixMilli(),
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	snapshot := parseCandleSnapshot(payload, spec.asset, spec.interval)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series, ok := m.candles[candleKey(spec.asset, spec.interval)]
+	if !ok {
+		return 0, nil
+	}
+	return m.seedCandlesLocked(series, snapshot), nil
+}
+
+// seedCandlesLocked merges the snapshot into the series history: candles
+// already received over WS win, the snapshot fills in older ones and any
+// gap left by a disconnect. When it adds candles, the closes behind the
+// stdev estimator are rebuilt as one close per candle.
+func (m *MarketData) seedCandlesLocked(series *candleSeries, snapshot []Candle) int {
+	byStart := make(map[int64]Candle, len(series.history)+len(snapshot))
+	for _, candle := range series.history {
+		byStart[candle.Start.UnixMilli()] = candle
+	}
+	seeded := make(map[int64]bool, len(snapshot))
+	for _, candle := range snapshot {
+		if _, ok := byStart[candle.Start.UnixMilli()]; ok {
+			continue
+		}
+		byStart[candle.Start.UnixMilli()] = candle
+		seeded[candle.Start.UnixMilli()] = true
+	}
+	merged := make([]Candle, 0, len(byStart))
+	for _, candle := range byStart {
+		merged = append(merged, candle)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Start.Before(merged[j].Start) })
+	if len(merged) > series.window {
+		merged = merged[len(merged)-series.window:]
+	}
+	added := 0
+	for _, candle := range merged {
+		if seeded[candle.Start.UnixMilli()] {
+			added++
+		}
+	}
+	if added == 0 {
+		return 0
+	}
+	series.history = merged
+	series.closes = make([]float64, 0, len(merged))
+	for _, candle := range merged {
+		series.closes = append(series.closes, candle.Close)
+	}
+	if !series.hasLast {
+		series.last = merged[len(merged)-1]
+		series.hasLast = true
+	}
+	m.updateCandleVolatilityLocked(series)
+	return added
+}
+
+// backfillCandlesAfterReconnect refills candles missed while the WS was down.
+func (m *MarketData) backfillCandlesAfterReconnect(ctx context.Context) {
+	if _, err := m.BackfillCandles(ctx); err != nil && m.log != nil {
+		m.log.Warn("candle backfill after reconnect failed", zap.Error(err))
+	}
+}
+
+// parseCandleSnapshot reads a candleSnapshot response, oldest first, one
+// candle per start time.
+func parseCandleSnapshot(payload any, asset, interval string) []Candle {
+	rows, ok := payload.([]any)
+	if !ok {
+		return nil
+	}
+	byStart := make(map[int64]Candle, len(rows))
+	for _, row := range rows {
+		data, ok := row.(map[string]any)
+		if !ok {
+			continue
+		}
+		candle, ok := parseCandleOHLC(map[string]any{"data": data})
+		if !ok || candle.Start.IsZero() {
+			continue
+		}
+		if candle.Asset == "" {
+			candle.Asset = asset
+		}
+		if candle.Interval == "" {
+			candle.Interval = interval
+		}
+		byStart[candle.Start.UnixMilli()] = candle
+	}
+	out := make([]Candle, 0, len(byStart))
+	for _, candle := range byStart {
+		out = append(out, candle)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+func candleKey(asset, interval string) string {
+	if interval == "" {
+		return asset
+	}
+	return asset + "|" + interval
+}
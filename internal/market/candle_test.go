@@ -0,0 +1,121 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/rest"
+
+	"go.uber.org/zap"
+)
+
+func TestBackfillCandlesSeedsWindow(t *testing.T) {
+	end := time.Now().UTC().Truncate(time.Hour)
+	var rows []map[string]any
+	for i := 0; i < 6; i++ {
+		start := end.Add(time.Duration(i-5) * time.Hour)
+		if i == 3 {
+			continue // a gap the WS candles do not cover either
+		}
+		closePx := 3000 + float64(i%2)*30
+		rows = append(rows, map[string]any{
+			"t": start.UnixMilli(), "T": start.Add(time.Hour).UnixMilli() - 1, "s": "ETH", "i": "1h",
+			"o": "3000", "h": "3040", "l": "2990", "c": strconv.FormatFloat(closePx, 'f', -1, 64), "v": "12.5", "n": 40,
+		})
+	}
+	var req map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rows)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	md := New(rest.New(srv.URL, 2*time.Second, zap.NewNop()), nil, zap.NewNop())
+	md.EnableCandle("ETH", "1h", 4)
+	// The in-progress candle has already arrived over WS.
+	md.updateCandle(map[string]any{"channel": "candle", "data": map[string]any{
+		"s": "ETH", "i": "1h", "o": "3000", "h": "3100", "l": "2990", "c": "3090", "t": end.UnixMilli(),
+	}})
+	if vol, _ := md.Volatility("ETH", "1h"); vol != 0 {
+		t.Fatalf("expected no volatility from a single candle, got %v", vol)
+	}
+
+	n, err := md.BackfillCandles(context.Background())
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	body, _ := req["req"].(map[string]any)
+	if req["type"] != "candleSnapshot" || body["coin"] != "ETH" || body["interval"] != "1h" {
+		t.Fatalf("unexpected request: %v", req)
+	}
+	if n != 3 {
+		t.Fatalf("expected the 3 newest older candles to fill the window, got %d", n)
+	}
+	md.mu.RLock()
+	series := md.candles[candleKey("ETH", "1h")]
+	history := append([]Candle{}, series.history...)
+	closes := append([]float64{}, series.closes...)
+	md.mu.RUnlock()
+	if len(history) != 4 || len(closes) != 4 {
+		t.Fatalf("expected the window trimmed to 4 candles, got %d candles %d closes", len(history), len(closes))
+	}
+	if last := history[3]; !last.Start.Equal(end) || last.Close != 3090 {
+		t.Fatalf("expected the WS candle kept as the latest, got %+v", last)
+	}
+	if vol, _ := md.Volatility("ETH", "1h"); vol <= 0 {
+		t.Fatalf("expected volatility from the backfilled window, got %v", vol)
+	}
+	if n, err := md.BackfillCandles(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected a repeat backfill to add nothing, got %d %v", n, err)
+	}
+}
+
+func TestCandleSeriesPerInterval(t *testing.T) {
+	md := New(nil, nil, zap.NewNop())
+	md.EnableCandle("ETH", "5m", 3)
+	md.EnableCandle("ETH", "1h", 2)
+	start := time.UnixMilli(1700000000000).UTC()
+	for i, closePx := range []string{"3000", "3030", "2990", "3060"} {
+		md.updateCandle(map[string]any{"channel": "candle", "data": map[string]any{
+			"s": "ETH", "i": "5m", "c": closePx, "t": start.Add(time.Duration(i) * 5 * time.Minute).UnixMilli(),
+		}})
+	}
+	md.updateCandle(map[string]any{"channel": "candle", "data": map[string]any{
+		"s": "ETH", "i": "1h", "c": "3010", "t": start.UnixMilli(),
+	}})
+	// Updates without an interval belong to the first series enabled.
+	md.updateCandle(map[string]any{"channel": "candle", "data": map[string]any{
+		"s": "ETH", "c": "3070", "t": start.Add(20 * time.Minute).UnixMilli(),
+	}})
+
+	md.mu.RLock()
+	fast := len(md.candles[candleKey("ETH", "5m")].history)
+	slow := len(md.candles[candleKey("ETH", "1h")].history)
+	md.mu.RUnlock()
+	if fast != 3 || slow != 1 {
+		t.Fatalf("expected independent windows, got %d 5m and %d 1h candles", fast, slow)
+	}
+	if vol, ok := md.Volatility("ETH", "5m"); !ok || vol <= 0 {
+		t.Fatalf("expected 5m volatility, got %v %v", vol, ok)
+	}
+	if vol, _ := md.Volatility("ETH", "1h"); vol != 0 {
+		t.Fatalf("expected no 1h volatility from a single candle, got %v", vol)
+	}
+	if candle, ok := md.LatestCandle("ETH", "5m"); !ok || candle.Close != 3070 || candle.Interval != "5m" {
+		t.Fatalf("unexpected latest 5m candle %+v", candle)
+	}
+	if candle, ok := md.LatestCandle("ETH", "1h"); !ok || candle.Close != 3010 {
+		t.Fatalf("unexpected latest 1h candle %+v", candle)
+	}
+	if _, ok := md.Volatility("ETH", "15m"); ok {
+		t.Fatal("expected no volatility for an untracked interval")
+	}
+}
@@ -0,0 +1,72 @@
+package market
+
+import (
+	"sort"
+	"time"
+)
+
+// Feeds tracked per asset for staleness detection.
+const (
+	FeedMids     = "mids"
+	FeedCandles  = "candles"
+	FeedContexts = "contexts"
+)
+
+// FeedUpdate is the last time a feed delivered data for an asset.
+type FeedUpdate struct {
+	Feed      string    `json:"feed"`
+	Asset     string    `json:"asset"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type feedKey struct {
+	feed  string
+	asset string
+}
+
+// LastUpdate returns when feed last delivered data for asset (zero if never).
+func (m *MarketData) LastUpdate(feed, asset string) time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.feedUpdates[feedKey{feed: feed, asset: asset}]
+}
+
+// FeedUpdates lists per-asset update times, optionally limited to assets.
+func (m *MarketData) FeedUpdates(assets ...string) []FeedUpdate {
+	var filter map[string]struct{}
+	if len(assets) > 0 {
+		filter = make(map[string]struct{}, len(assets))
+		for _, asset := range assets {
+			filter[asset] = struct{}{}
+		}
+	}
+	m.mu.RLock()
+	out := make([]FeedUpdate, 0, len(m.feedUpdates))
+	for key, at := range m.feedUpdates {
+		if filter != nil {
+			if _, ok := filter[key.asset]; !ok {
+				continue
+			}
+		}
+		out = append(out, FeedUpdate{Feed: key.feed, Asset: key.asset, UpdatedAt: at})
+	}
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Feed != out[j].Feed {
+			return out[i].Feed < out[j].Feed
+		}
+		return out[i].Asset < out[j].Asset
+	})
+	return out
+}
+
+// markFeedLocked records an update; callers hold m.mu.
+func (m *MarketData) markFeedLocked(feed, asset string, at time.Time) {
+	if asset == "" {
+		return
+	}
+	if m.feedUpdates == nil {
+		m.feedUpdates = make(map[feedKey]time.Time)
+	}
+	m.feedUpdates[feedKey{feed: feed, asset: asset}] = at
+}
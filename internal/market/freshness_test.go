@@ -0,0 +1,51 @@
+package market
+
+import (
+	"testing"
+
+	"hl-carry-bot/internal/events"
+
+	"go.uber.org/zap"
+)
+
+func TestFeedUpdatesTrackedPerAsset(t *testing.T) {
+	m := New(nil, nil, zap.NewNop())
+	m.updateMids(map[string]any{"channel": "allMids", "data": map[string]any{"mids": map[string]any{"BTC": "30000"}}})
+	if m.LastUpdate(FeedMids, "BTC").IsZero() {
+		t.Fatalf("expected BTC mid update")
+	}
+	if !m.LastUpdate(FeedMids, "ETH").IsZero() {
+		t.Fatalf("expected no ETH mid update")
+	}
+	m.updateMids(map[string]any{"channel": "allMids", "data": map[string]any{"mids": map[string]any{"ETH": "2000"}}})
+	m.updateCandle(map[string]any{"channel": "candle", "data": map[string]any{"s": "BTC", "i": "1h", "c": "30100", "o": "30000", "h": "30200", "l": "29900", "t": 1700000000000}})
+
+	feeds := m.FeedUpdates("BTC")
+	if len(feeds) != 2 {
+		t.Fatalf("expected BTC candles and mids feeds, got %+v", feeds)
+	}
+	if feeds[0].Feed != FeedCandles || feeds[1].Feed != FeedMids {
+		t.Fatalf("expected feeds sorted by name, got %+v", feeds)
+	}
+	if got := len(m.FeedUpdates()); got != 3 {
+		t.Fatalf("expected 3 feed entries, got %d", got)
+	}
+}
+
+func TestMidUpdatesPublishChangesOnly(t *testing.T) {
+	bus := events.New()
+	ch, unsubscribe := bus.Subscribe(8, events.KindMidUpdated)
+	defer unsubscribe()
+	m := New(nil, nil, zap.NewNop())
+	m.SetEventBus(bus)
+	m.updateMids(map[string]any{"channel": "allMids", "data": map[string]any{"mids": map[string]any{"ETH": "3000"}}})
+	m.updateMids(map[string]any{"channel": "allMids", "data": map[string]any{"mids": map[string]any{"ETH": "3000"}}})
+	m.updateMids(map[string]any{"channel": "allMids", "data": map[string]any{"mids": map[string]any{"ETH": "3001"}}})
+	if len(ch) != 2 {
+		t.Fatalf("expected 2 mid events, got %d", len(ch))
+	}
+	<-ch
+	if ev := (<-ch).(events.MidUpdated); ev.Asset != "ETH" || ev.Mid != 3001 {
+		t.Fatalf("expected ETH 3001, got %#v", ev)
+	}
+}
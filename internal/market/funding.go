@@ -3,10 +3,14 @@ package market
 import (
 	"context"
 	"errors"
+	"sort"
 	"strings"
 	"time"
 
+	"hl-carry-bot/internal/events"
 	"hl-carry-bot/internal/hl/rest"
+
+	"go.uber.org/zap"
 )
 
 type FundingForecast struct {
@@ -20,6 +24,19 @@ type FundingForecast struct {
 	Source       string
 }
 
+// HyperliquidVenue is the predictedFundings provider name for Hyperliquid.
+const HyperliquidVenue = "HlPerp"
+
+// HourlyRate normalizes Rate to one hour so venues with different funding
+// intervals compare. Without a known interval the rate is taken as hourly,
+// Hyperliquid's own cadence.
+func (f FundingForecast) HourlyRate() float64 {
+	if f.Interval <= 0 {
+		return f.Rate
+	}
+	return f.Rate / f.Interval.Hours()
+}
+
 func (m *MarketData) RefreshFundingForecast(ctx context.Context) (bool, error) {
 	if m.rest == nil {
 		return false, nil
@@ -31,11 +48,20 @@ func (m *MarketData) RefreshFundingForecast(ctx context.Context) (bool, error) {
 	m.mu.Lock()
 	m.lastFundingAttempt = now
 	m.mu.Unlock()
-	payload, err := m.rest.InfoAny(ctx, rest.InfoRequest{Type: "predictedFundings"})
-	if err != nil {
+	var forecasts map[string]FundingForecast
+	var venues map[string][]FundingForecast
+	predicted, err := m.rest.PredictedFundings(ctx)
+	if raw, ok := rest.RawFallback(err); ok {
+		if m.log != nil {
+			m.log.Debug("predictedFundings did not fit its typed response; parsing it untyped", zap.Error(err))
+		}
+		forecasts = parseFundingForecasts(raw)
+		venues = parseFundingVenues(raw)
+	} else if err != nil {
 		return false, err
+	} else {
+		forecasts, venues = forecastsFromPredicted(predicted)
 	}
-	forecasts := parseFundingForecasts(payload)
 	if len(forecasts) == 0 {
 		return false, errors.New("predicted fundings missing")
 	}
@@ -45,10 +71,31 @@ func (m *MarketData) RefreshFundingForecast(ctx context.Context) (bool, error) {
 		forecast = normalizeFundingForecast(forecast, now)
 		forecasts[key] = forecast
 	}
+	for _, list := range venues {
+		for i := range list {
+			list[i].ObservedAt = now
+			list[i] = normalizeFundingForecast(list[i], now)
+		}
+	}
 	m.mu.Lock()
+	var changed []events.FundingForecastUpdated
+	if m.events != nil {
+		for asset, forecast := range forecasts {
+			prev, ok := m.fundingForecasts[asset]
+			if ok && prev.Rate == forecast.Rate && prev.NextFunding.Equal(forecast.NextFunding) {
+				continue
+			}
+			changed = append(changed, events.FundingForecastUpdated{Asset: asset, Rate: forecast.Rate, NextFunding: forecast.NextFunding, Time: now})
+		}
+	}
 	m.fundingForecasts = forecasts
+	m.fundingVenues = venues
 	m.lastFundingFetch = now
+	bus := m.events
 	m.mu.Unlock()
+	for _, ev := range changed {
+		bus.Publish(ev)
+	}
 	return true, nil
 }
 
@@ -59,6 +106,19 @@ func (m *MarketData) FundingForecast(asset string) (FundingForecast, bool) {
 	return forecast, ok
 }
 
+// FundingForecastAll returns the predicted funding of every venue reported
+// for asset (Hyperliquid, Binance, Bybit, ...), sorted by venue name. Payloads
+// without a per-venue breakdown yield nothing.
+func (m *MarketData) FundingForecastAll(asset string) []FundingForecast {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := m.fundingVenues[asset]
+	if len(list) == 0 {
+		return nil
+	}
+	return append([]FundingForecast(nil), list...)
+}
+
 func (m *MarketData) shouldRefreshFundingForecast() bool {
 	m.mu.RLock()
 	last := m.lastFundingAttempt
@@ -73,6 +133,48 @@ func (m *MarketData) shouldRefreshFundingForecast() bool {
 	return time.Since(last) >= window
 }
 
+// forecastsFromPredicted keeps every venue per coin, sorted by venue name,
+// and picks Hyperliquid's own (else the first reported) as the coin's
+// forecast.
+func forecastsFromPredicted(predicted []rest.PredictedFunding) (map[string]FundingForecast, map[string][]FundingForecast) {
+	forecasts := make(map[string]FundingForecast, len(predicted))
+	venues := make(map[string][]FundingForecast, len(predicted))
+	for _, entry := range predicted {
+		var list []FundingForecast
+		for _, venue := range entry.Venues {
+			forecast := FundingForecast{
+				RawAssetName: entry.Coin,
+				Source:       venue.Venue,
+				Rate:         venue.FundingRate.Float(),
+				HasRate:      venue.FundingRate != 0,
+			}
+			if ts, ok := timeFromAny(float64(venue.NextFundingTime)); ok {
+				forecast.NextFunding = ts
+				forecast.HasNext = true
+			}
+			if hours := venue.FundingIntervalHours.Float(); hours > 0 {
+				forecast.Interval = time.Duration(hours * float64(time.Hour))
+			}
+			if !forecast.HasRate && !forecast.HasNext {
+				continue
+			}
+			if _, ok := forecasts[entry.Coin]; !ok || strings.EqualFold(venue.Venue, HyperliquidVenue) {
+				forecasts[entry.Coin] = forecast
+			}
+			list = append(list, forecast)
+		}
+		if len(list) == 0 {
+			continue
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Source < list[j].Source })
+		venues[entry.Coin] = list
+	}
+	if len(forecasts) == 0 {
+		return nil, nil
+	}
+	return forecasts, venues
+}
+
 func parseFundingForecasts(payload any) map[string]FundingForecast {
 	if payload == nil {
 		return nil
@@ -198,6 +300,49 @@ func parseFundingForecastProviders(payload any) (FundingForecast, bool) {
 	return forecastFromProviders(asset, providers)
 }
 
+// parseFundingVenues keeps every provider of the [asset, [[venue, {...}], ...]]
+// payload shape, keyed by asset.
+func parseFundingVenues(payload any) map[string][]FundingForecast {
+	entries, ok := payload.([]any)
+	if !ok {
+		return nil
+	}
+	out := make(map[string][]FundingForecast)
+	for _, item := range entries {
+		entry, ok := item.([]any)
+		if !ok || len(entry) < 2 {
+			continue
+		}
+		asset := stringFromAny(entry[0])
+		providers, ok := entry[1].([]any)
+		if asset == "" || !ok {
+			continue
+		}
+		for _, provider := range providers {
+			pair, ok := provider.([]any)
+			if !ok || len(pair) < 2 {
+				continue
+			}
+			source := stringFromAny(pair[0])
+			if source == "" {
+				continue
+			}
+			forecast, ok := parseProviderForecast(asset, source, pair[1])
+			if !ok {
+				continue
+			}
+			out[asset] = append(out[asset], forecast)
+		}
+		sort.Slice(out[asset], func(i, j int) bool {
+			return out[asset][i].Source < out[asset][j].Source
+		})
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
 func forecastFromProviders(asset string, providers []any) (FundingForecast, bool) {
 	var fallback *FundingForecast
 	for _, provider := range providers {
@@ -210,7 +355,7 @@ func forecastFromProviders(asset string, providers []any) (FundingForecast, bool
 		if !ok {
 			continue
 		}
-		if source != "" && strings.EqualFold(source, "HlPerp") {
+		if source != "" && strings.EqualFold(source, HyperliquidVenue) {
 			return forecast, true
 		}
 		if fallback == nil {
@@ -0,0 +1,150 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Trailing windows reported by FundingHistory.
+const (
+	FundingWindow8h  = 8 * time.Hour
+	FundingWindow24h = 24 * time.Hour
+	FundingWindow7d  = 7 * 24 * time.Hour
+)
+
+// fundingHistoryPageLimit is the most samples fundingHistory returns per call.
+const fundingHistoryPageLimit = 500
+
+type FundingSample struct {
+	Time time.Time
+	Rate float64
+}
+
+// FundingHistory holds settled hourly funding rates for one asset, oldest
+// first, with trailing averages over the standard windows.
+type FundingHistory struct {
+	Asset     string
+	Samples   []FundingSample
+	FetchedAt time.Time
+	Avg8h     float64
+	Avg24h    float64
+	Avg7d     float64
+}
+
+// TrailingAverage is the mean rate of samples within window before now. ok
+// is false when no sample falls in the window, so a history that has not
+// been refreshed for longer than window stops reporting an average.
+func (h FundingHistory) TrailingAverage(now time.Time, window time.Duration) (float64, bool) {
+	if len(h.Samples) == 0 || window <= 0 {
+		return 0, false
+	}
+	cutoff := now.Add(-window)
+	sum := 0.0
+	n := 0
+	for i := len(h.Samples) - 1; i >= 0; i-- {
+		if h.Samples[i].Time.After(now) {
+			continue
+		}
+		if !h.Samples[i].Time.After(cutoff) {
+			break
+		}
+		sum += h.Samples[i].Rate
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+// FundingHistory fetches settled funding for asset over lookback via the
+// fundingHistory /info endpoint and caches the result for
+// CachedFundingHistory.
+func (m *MarketData) FundingHistory(ctx context.Context, asset string, lookback time.Duration) (FundingHistory, error) {
+	if m.rest == nil {
+		return FundingHistory{}, errors.New("rest client is required")
+	}
+	if asset == "" {
+		return FundingHistory{}, errors.New("asset is required")
+	}
+	if lookback <= 0 {
+		lookback = FundingWindow7d
+	}
+	now := time.Now().UTC()
+	start := now.Add(-lookback).UnixMilli()
+	end := now.UnixMilli()
+	var samples []FundingSample
+	for start < end {
+		payload, err := m.rest.InfoAny(ctx, map[string]any{
+			"type":      "fundingHistory",
+			"coin":      asset,
+			"startTime": start,
+			"endTime":   end,
+		})
+		if err != nil {
+			return FundingHistory{}, err
+		}
+		page := parseFundingHistory(payload)
+		samples = append(samples, page...)
+		if len(page) < fundingHistoryPageLimit {
+			break
+		}
+		next := page[len(page)-1].Time.UnixMilli() + 1
+		if next <= start {
+			break
+		}
+		start = next
+	}
+	if len(samples) == 0 {
+		return FundingHistory{}, errors.New("funding history missing")
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	history := FundingHistory{Asset: asset, Samples: samples, FetchedAt: now}
+	history.Avg8h, _ = history.TrailingAverage(now, FundingWindow8h)
+	history.Avg24h, _ = history.TrailingAverage(now, FundingWindow24h)
+	history.Avg7d, _ = history.TrailingAverage(now, FundingWindow7d)
+	m.mu.Lock()
+	m.fundingHistory[asset] = history
+	m.mu.Unlock()
+	return history, nil
+}
+
+// CachedFundingHistory returns the last FundingHistory fetched for asset.
+func (m *MarketData) CachedFundingHistory(asset string) (FundingHistory, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	history, ok := m.fundingHistory[asset]
+	return history, ok
+}
+
+func parseFundingHistory(payload any) []FundingSample {
+	switch data := payload.(type) {
+	case map[string]any:
+		if nested, ok := data["data"]; ok {
+			return parseFundingHistory(nested)
+		}
+		return nil
+	case []any:
+		out := make([]FundingSample, 0, len(data))
+		for _, item := range data {
+			entry, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			ts, ok := timeFromMap(entry, "time")
+			if !ok {
+				continue
+			}
+			rate, ok := floatFromAny(entry["fundingRate"])
+			if !ok {
+				continue
+			}
+			out = append(out, FundingSample{Time: ts, Rate: rate})
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,101 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/hl/rest"
+
+	"go.uber.org/zap"
+)
+
+func TestFundingHistoryTrailingAverages(t *testing.T) {
+	end := time.Now().Truncate(time.Hour)
+	var rows []map[string]any
+	for i := 0; i < 168; i++ {
+		rate := 0.0001
+		if i >= 144 {
+			rate = 0.0002
+		}
+		if i >= 160 {
+			rate = 0.0004
+		}
+		rows = append(rows, map[string]any{
+			"coin":        "ETH",
+			"fundingRate": rate,
+			"premium":     "0",
+			"time":        end.Add(time.Duration(i-167) * time.Hour).UnixMilli(),
+		})
+	}
+	var req map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rows)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	md := New(rest.New(srv.URL, 2*time.Second, zap.NewNop()), nil, zap.NewNop())
+	history, err := md.FundingHistory(context.Background(), "ETH", FundingWindow7d)
+	if err != nil {
+		t.Fatalf("funding history: %v", err)
+	}
+	if req["type"] != "fundingHistory" || req["coin"] != "ETH" {
+		t.Fatalf("unexpected request: %v", req)
+	}
+	if len(history.Samples) != 168 {
+		t.Fatalf("expected 168 samples, got %d", len(history.Samples))
+	}
+	for name, pair := range map[string][2]float64{
+		"8h":  {history.Avg8h, 0.0004},
+		"24h": {history.Avg24h, (16*0.0002 + 8*0.0004) / 24},
+		"7d":  {history.Avg7d, (144*0.0001 + 16*0.0002 + 8*0.0004) / 168},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-12 {
+			t.Fatalf("expected %s average %v, got %v", name, pair[1], pair[0])
+		}
+	}
+	cached, ok := md.CachedFundingHistory("ETH")
+	if !ok || cached.Avg24h != history.Avg24h {
+		t.Fatalf("expected cached history, got %+v", cached)
+	}
+}
+
+func TestTrailingAverageEmptyWindow(t *testing.T) {
+	if _, ok := (FundingHistory{}).TrailingAverage(time.Now(), time.Hour); ok {
+		t.Fatalf("expected no average without samples")
+	}
+}
+
+func TestTrailingAverageAnchorsAtNow(t *testing.T) {
+	newest := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := FundingHistory{Samples: []FundingSample{
+		{Time: newest.Add(-2 * time.Hour), Rate: 0.0001},
+		{Time: newest.Add(-time.Hour), Rate: 0.0002},
+		{Time: newest, Rate: 0.0003},
+	}}
+	cases := []struct {
+		name string
+		now  time.Time
+		want float64
+		ok   bool
+	}{
+		{name: "fresh", now: newest, want: 0.00025, ok: true},
+		{name: "one refresh missed", now: newest.Add(time.Hour), want: 0.0003, ok: true},
+		{name: "stale", now: newest.Add(2 * time.Hour), ok: false},
+		{name: "far stale", now: newest.Add(48 * time.Hour), ok: false},
+	}
+	for _, tc := range cases {
+		got, ok := history.TrailingAverage(tc.now, 2*time.Hour)
+		if ok != tc.ok || math.Abs(got-tc.want) > 1e-12 {
+			t.Fatalf("%s: expected %v/%v, got %v/%v", tc.name, tc.want, tc.ok, got, ok)
+		}
+	}
+}
@@ -178,3 +178,39 @@ func TestRefreshFundingForecastRollsForwardNextFunding(t *testing.T) {
 		t.Fatalf("expected next funding after observed_at, got %s vs %s", forecast.NextFunding, forecast.ObservedAt)
 	}
 }
+
+func TestRefreshFundingForecastKeepsAllVenues(t *testing.T) {
+	payload := `[[ "BTC", [["HlPerp", {"fundingRate":"0.0001","nextFundingTime":1700000000000,"fundingIntervalHours":1}], ["BinPerp", {"fundingRate":"0.0008","nextFundingTime":1700000000000,"fundingIntervalHours":8}], ["BybitPerp", {"fundingRate":"0.0004","nextFundingTime":1700000000000}]] ]]`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(payload))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	md := New(rest.New(srv.URL, 2*time.Second, zap.NewNop()), nil, zap.NewNop())
+	md.fundingWindow = 0
+	if _, err := md.RefreshFundingForecast(context.Background()); err != nil {
+		t.Fatalf("refresh error: %v", err)
+	}
+	if forecast, ok := md.FundingForecast("BTC"); !ok || forecast.Source != HyperliquidVenue {
+		t.Fatalf("expected primary forecast from %s, got %+v", HyperliquidVenue, forecast)
+	}
+	venues := md.FundingForecastAll("BTC")
+	if len(venues) != 3 {
+		t.Fatalf("expected 3 venues, got %d", len(venues))
+	}
+	if venues[0].Source != "BinPerp" || venues[1].Source != "BybitPerp" || venues[2].Source != "HlPerp" {
+		t.Fatalf("expected venues sorted by name, got %s %s %s", venues[0].Source, venues[1].Source, venues[2].Source)
+	}
+	if got := venues[0].HourlyRate(); got != 0.0001 {
+		t.Fatalf("expected binance hourly rate 0.0001, got %v", got)
+	}
+	if venues[1].ObservedAt.IsZero() {
+		t.Fatalf("expected observed_at on every venue")
+	}
+	if md.FundingForecastAll("ETH") != nil {
+		t.Fatalf("expected no venues for unknown asset")
+	}
+}
@@ -5,10 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"math"
-	"strings"
 	"sync"
 	"time"
 
+	"hl-carry-bot/internal/events"
+	"hl-carry-bot/internal/hl/precision"
 	"hl-carry-bot/internal/hl/rest"
 	"hl-carry-bot/internal/hl/ws"
 
@@ -21,6 +22,11 @@ type PerpContext struct {
 	OraclePrice float64
 	MarkPrice   float64
 	SzDecimals  int
+	// MaxLeverage is the asset's leverage cap from meta (0 when not listed);
+	// its maintenance margin is half the initial margin at this leverage.
+	MaxLeverage float64
+	// Increments are the perp's price/size ticks derived from szDecimals.
+	Increments precision.Increments
 }
 
 type SpotContext struct {
@@ -32,22 +38,29 @@ type SpotContext struct {
 	QuoteSzDecimals int
 	RawName         string
 	MidKey          string
+	// Increments are the pair's price/size ticks derived from the base
+	// token's szDecimals.
+	Increments precision.Increments
+	// BaseToken and QuoteToken are the pair's full token metadata.
+	BaseToken  TokenMeta
+	QuoteToken TokenMeta
 }
 
 type MarketData struct {
-	rest *rest.Client
-	ws   *ws.Client
-	log  *zap.Logger
+	rest   *rest.Client
+	ws     *ws.Client
+	log    *zap.Logger
+	events *events.Bus
 
 	mu                 sync.RWMutex
 	midPrices          map[string]float64
 	funding            map[string]float64
 	oraclePrices       map[string]float64
-	volatility         map[string]float64
+	markPrices         map[string]float64
 	perpCtx            map[string]PerpContext
 	spotCtx            map[string]SpotContext
-	candleCloses       map[string][]float64
-	lastCandles        map[string]Candle
+	spotAliases        map[string]string
+	tokens             map[string]TokenMeta
 	lastCtxRefresh     time.Time
 	lastMidUpdate      time.Time
 	lastFundingFetch   time.Time
@@ -55,11 +68,22 @@ type MarketData struct {
 	ctxRefreshWindow   time.Duration
 	fundingWindow      time.Duration
 
-	candleAsset    string
-	candleInterval string
-	candleWindow   int
+	candles      map[string]*candleSeries
+	candleOrder  []string
+	candleAssets map[string]string
+
+	volEstimator   string
+	ewmaLambda     float64
+	realizedWindow time.Duration
+	trades         map[string][]tradePrint
+	flowWindow     time.Duration
+	flow           map[string][]tradePrint
 
 	fundingForecasts map[string]FundingForecast
+	fundingVenues    map[string][]FundingForecast
+	fundingHistory   map[string]FundingHistory
+	books            map[string]L2Book
+	feedUpdates      map[feedKey]time.Time
 }
 
 func New(restClient *rest.Client, wsClient *ws.Client, log *zap.Logger) *MarketData {
@@ -70,29 +94,35 @@ func New(restClient *rest.Client, wsClient *ws.Client, log *zap.Logger) *MarketD
 		midPrices:        make(map[string]float64),
 		funding:          make(map[string]float64),
 		oraclePrices:     make(map[string]float64),
-		volatility:       make(map[string]float64),
+		markPrices:       make(map[string]float64),
 		perpCtx:          make(map[string]PerpContext),
 		spotCtx:          make(map[string]SpotContext),
-		candleCloses:     make(map[string][]float64),
-		lastCandles:      make(map[string]Candle),
+		spotAliases:      make(map[string]string),
+		tokens:           make(map[string]TokenMeta),
 		ctxRefreshWindow: 30 * time.Second,
 		fundingWindow:    60 * time.Second,
-		candleWindow:     20,
-		candleInterval:   "1h",
+		candles:          make(map[string]*candleSeries),
+		candleAssets:     make(map[string]string),
+		volEstimator:     VolEstimatorStdev,
+		ewmaLambda:       DefaultEWMALambda,
+		realizedWindow:   time.Hour,
+		trades:           make(map[string][]tradePrint),
+		flowWindow:       5 * time.Minute,
+		flow:             make(map[string][]tradePrint),
 		fundingForecasts: make(map[string]FundingForecast),
+		fundingVenues:    make(map[string][]FundingForecast),
+		fundingHistory:   make(map[string]FundingHistory),
+		books:            make(map[string]L2Book),
+		feedUpdates:      make(map[feedKey]time.Time),
 	}
 }
 
-func (m *MarketData) EnableCandle(asset, interval string, window int) {
+// SetEventBus publishes MidUpdated for every mid that changes and
+// FundingForecastUpdated when a predicted funding rate or time changes.
+func (m *MarketData) SetEventBus(bus *events.Bus) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.candleAsset = asset
-	if interval != "" {
-		m.candleInterval = interval
-	}
-	if window > 0 {
-		m.candleWindow = window
-	}
+	m.events = bus
 }
 
 func (m *MarketData) Start(ctx context.Context) error {
@@ -106,37 +136,25 @@ func (m *MarketData) Start(ctx context.Context) error {
 	if err := m.ws.Subscribe(ctx, sub); err != nil {
 		return err
 	}
-	m.subscribeCandle(ctx)
+	m.subscribeCandles(ctx)
+	m.subscribeTrades(ctx)
 	if err := m.RefreshContexts(ctx); err != nil {
 		m.log.Warn("context refresh failed", zap.Error(err))
 	}
+	if n, err := m.BackfillCandles(ctx); err != nil {
+		m.log.Warn("candle backfill failed", zap.Error(err))
+	} else if n > 0 {
+		m.log.Info("candle window backfilled", zap.Int("candles", n))
+	}
+	m.ws.SetReconnectHandler(func() {
+		go m.backfillCandlesAfterReconnect(ctx)
+	})
 	go func() {
 		_ = m.ws.Run(ctx, m.handleMessage)
 	}()
 	return nil
 }
 
-func (m *MarketData) subscribeCandle(ctx context.Context) {
-	m.mu.RLock()
-	asset := m.candleAsset
-	interval := m.candleInterval
-	m.mu.RUnlock()
-	if asset == "" {
-		return
-	}
-	sub := map[string]any{
-		"method": "subscribe",
-		"subscription": map[string]any{
-			"type":     "candle",
-			"coin":     asset,
-			"interval": interval,
-		},
-	}
-	if err := m.ws.Subscribe(ctx, sub); err != nil {
-		m.log.Warn("candle subscribe failed", zap.Error(err))
-	}
-}
-
 func (m *MarketData) RefreshContexts(ctx context.Context) error {
 	if m.rest == nil {
 		return nil
@@ -166,12 +184,23 @@ func (m *MarketData) RefreshContexts(ctx context.Context) error {
 	m.mu.Lock()
 	m.perpCtx = perpCtx
 	m.spotCtx = spotCtx
+	m.spotAliases = buildSpotAliases(spotCtx)
+	m.tokens = parseSpotTokens(spotResp)
 	m.lastCtxRefresh = time.Now().UTC()
 	for asset, ctx := range perpCtx {
 		m.funding[asset] = ctx.FundingRate
 		if ctx.OraclePrice > 0 {
 			m.oraclePrices[asset] = ctx.OraclePrice
 		}
+		if ctx.MarkPrice > 0 {
+			m.markPrices[asset] = ctx.MarkPrice
+		}
+		m.markFeedLocked(FeedContexts, asset, m.lastCtxRefresh)
+	}
+	for symbol, ctx := range spotCtx {
+		m.markFeedLocked(FeedContexts, symbol, m.lastCtxRefresh)
+		m.markFeedLocked(FeedContexts, ctx.RawName, m.lastCtxRefresh)
+		m.markFeedLocked(FeedContexts, ctx.Base, m.lastCtxRefresh)
 	}
 	m.mu.Unlock()
 	return nil
@@ -209,6 +238,23 @@ func (m *MarketData) Mid(ctx context.Context, asset string) (float64, error) {
 	return price, nil
 }
 
+// SubscriptionsHealthy reports whether the market WS acknowledged every
+// subscription; see ws.Client.SubscriptionsHealthy.
+func (m *MarketData) SubscriptionsHealthy() bool {
+	if m.ws == nil {
+		return true
+	}
+	return m.ws.SubscriptionsHealthy()
+}
+
+// Connected reports whether the market WS is connected.
+func (m *MarketData) Connected() bool {
+	if m.ws == nil {
+		return true
+	}
+	return m.ws.Connected()
+}
+
 func (m *MarketData) LastMidUpdate() time.Time {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -229,11 +275,11 @@ func (m *MarketData) OraclePrice(asset string) (float64, bool) {
 	return val, ok
 }
 
-func (m *MarketData) SpotContext(asset string) (SpotContext, bool) {
+func (m *MarketData) MarkPrice(asset string) (float64, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	ctx, ok := m.spotCtx[asset]
-	return ctx, ok
+	val, ok := m.markPrices[asset]
+	return val, ok
 }
 
 func (m *MarketData) PerpContext(asset string) (PerpContext, bool) {
@@ -253,36 +299,16 @@ func (m *MarketData) PerpAssetID(asset string) (int, bool) {
 	return ctx.Index, true
 }
 
+// SpotAssetID is the order asset id (10000 + pair index) of a spot name;
+// see ResolveSpot.
 func (m *MarketData) SpotAssetID(asset string) (int, bool) {
-	m.mu.RLock()
-	ctx, ok := m.spotCtx[asset]
-	m.mu.RUnlock()
-	if !ok && !strings.Contains(asset, "/") {
-		m.mu.RLock()
-		ctx, ok = m.spotCtx[asset+"/USDC"]
-		m.mu.RUnlock()
-	}
+	ctx, ok := m.ResolveSpot(asset)
 	if !ok {
 		return 0, false
 	}
 	return 10000 + ctx.Index, true
 }
 
-func (m *MarketData) Volatility(asset string) (float64, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	val, ok := m.volatility[asset]
-	return val, ok
-}
-
-func (m *MarketData) LatestCandle(asset string) (Candle, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	key := candleKey(asset, m.candleInterval)
-	candle, ok := m.lastCandles[key]
-	return candle, ok
-}
-
 func (m *MarketData) handleMessage(msg json.RawMessage) {
 	var payload map[string]any
 	if err := json.Unmarshal(msg, &payload); err != nil {
@@ -291,6 +317,7 @@ func (m *MarketData) handleMessage(msg json.RawMessage) {
 	}
 	m.updateMids(payload)
 	m.updateCandle(payload)
+	m.updateTrades(payload)
 }
 
 func (m *MarketData) updateMids(payload map[string]any) {
@@ -315,59 +342,27 @@ func (m *MarketData) updateMids(payload map[string]any) {
 		return
 	}
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	now := time.Now().UTC()
 	updated := false
+	var changed []events.MidUpdated
 	for asset, v := range mids {
 		if f, ok := floatFromAny(v); ok {
+			if prev, ok := m.midPrices[asset]; (!ok || prev != f) && m.events != nil {
+				changed = append(changed, events.MidUpdated{Asset: asset, Mid: f, Time: now})
+			}
 			m.midPrices[asset] = f
+			m.markFeedLocked(FeedMids, asset, now)
 			updated = true
 		}
 	}
 	if updated {
-		m.lastMidUpdate = time.Now().UTC()
+		m.lastMidUpdate = now
 	}
-}
-
-func (m *MarketData) updateCandle(payload map[string]any) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	candle, ok := parseCandleOHLC(payload)
-	if ok {
-		if candle.Interval == "" {
-			candle.Interval = m.candleInterval
-		}
-		if candle.Open == 0 {
-			candle.Open = candle.Close
-		}
-		if candle.High == 0 {
-			candle.High = candle.Close
-		}
-		if candle.Low == 0 {
-			candle.Low = candle.Close
-		}
-		if candle.Start.IsZero() {
-			candle.Start = time.Now().UTC()
-		}
-		key := candleKey(candle.Asset, candle.Interval)
-		m.lastCandles[key] = candle
-	}
-	asset, close, ok := parseCandle(payload)
-	if !ok {
-		return
-	}
-	closes := append(m.candleCloses[asset], close)
-	if len(closes) > m.candleWindow {
-		closes = closes[len(closes)-m.candleWindow:]
-	}
-	m.candleCloses[asset] = closes
-	m.volatility[asset] = computeVolatility(closes)
-}
-
-func candleKey(asset, interval string) string {
-	if interval == "" {
-		return asset
+	bus := m.events
+	m.mu.Unlock()
+	for _, ev := range changed {
+		bus.Publish(ev)
 	}
-	return asset + "|" + interval
 }
 
 func computeVolatility(closes []float64) float64 {
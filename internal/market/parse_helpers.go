@@ -5,6 +5,8 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+
+	"hl-carry-bot/internal/hl/precision"
 )
 
 func parsePerpContexts(payload any) (map[string]PerpContext, error) {
@@ -26,12 +28,15 @@ func parsePerpContexts(payload any) (map[string]PerpContext, error) {
 		if !ok {
 			continue
 		}
+		szDecimals := intFromAny(meta["szDecimals"], -1)
 		result[name] = PerpContext{
 			Index:       intFromAny(meta["index"], i),
 			FundingRate: floatFromMap(ctx, "funding", "fundingRate"),
 			OraclePrice: floatFromMap(ctx, "oraclePx", "oraclePrice", "oracle"),
 			MarkPrice:   floatFromMap(ctx, "markPx", "markPrice", "mark"),
-			SzDecimals:  intFromAny(meta["szDecimals"], -1),
+			SzDecimals:  szDecimals,
+			MaxLeverage: floatFromMap(meta, "maxLeverage"),
+			Increments:  precision.Perp(szDecimals),
 		}
 	}
 	if len(result) == 0 {
@@ -53,7 +58,9 @@ func parseSpotContexts(payload any) (map[string]SpotContext, error) {
 			continue
 		}
 		rawName := stringFromMap(meta, "name", "symbol", "coin")
-		base, quote, baseDecimals, quoteDecimals := baseQuoteFromTokens(meta, tokenMeta)
+		baseToken, quoteToken := baseQuoteFromTokens(meta, tokenMeta)
+		base, quote := baseToken.Name, quoteToken.Name
+		baseDecimals, quoteDecimals := baseToken.SzDecimals, quoteToken.SzDecimals
 		name := spotSymbol(meta, base, quote)
 		if name == "" {
 			continue
@@ -71,16 +78,11 @@ func parseSpotContexts(payload any) (map[string]SpotContext, error) {
 			QuoteSzDecimals: quoteDecimals,
 			RawName:         rawName,
 			MidKey:          midKey,
+			Increments:      precision.Spot(baseDecimals),
+			BaseToken:       baseToken,
+			QuoteToken:      quoteToken,
 		}
 		result[name] = ctx
-		if rawName != "" && rawName != name {
-			result[rawName] = ctx
-		}
-		if ctx.Base != "" {
-			if _, exists := result[ctx.Base]; !exists {
-				result[ctx.Base] = ctx
-			}
-		}
 	}
 	if len(result) == 0 {
 		return nil, errors.New("no spot contexts parsed")
@@ -194,16 +196,11 @@ func extractSpotUniverseAndTokens(payload any) ([]any, []any) {
 	return nil, nil
 }
 
-type tokenMeta struct {
-	name       string
-	szDecimals int
-}
-
-func tokenMetaByIndex(tokens []any) map[int]tokenMeta {
+func tokenMetaByIndex(tokens []any) map[int]TokenMeta {
 	if len(tokens) == 0 {
 		return nil
 	}
-	names := make(map[int]tokenMeta, len(tokens))
+	out := make(map[int]TokenMeta, len(tokens))
 	for i, item := range tokens {
 		meta, ok := toMap(item)
 		if !ok {
@@ -214,24 +211,45 @@ func tokenMetaByIndex(tokens []any) map[int]tokenMeta {
 			continue
 		}
 		index := intFromAny(meta["index"], i)
-		names[index] = tokenMeta{
-			name:       name,
-			szDecimals: intFromAny(meta["szDecimals"], -1),
+		token := TokenMeta{
+			Name:        name,
+			Index:       index,
+			TokenID:     stringFromMap(meta, "tokenId"),
+			SzDecimals:  intFromAny(meta["szDecimals"], -1),
+			WeiDecimals: intFromAny(meta["weiDecimals"], -1),
+		}
+		token.IsCanonical, _ = meta["isCanonical"].(bool)
+		if evm, ok := toMap(meta["evmContract"]); ok {
+			token.EVMContract = stringFromMap(evm, "address")
+			token.EVMExtraWeiDecimals = intFromAny(evm["evm_extra_wei_decimals"], 0)
 		}
+		out[index] = token
+	}
+	return out
+}
+
+// parseSpotTokens keys spotMeta's tokens by normalized name.
+func parseSpotTokens(payload any) map[string]TokenMeta {
+	_, tokens := extractSpotUniverseAndTokens(payload)
+	byIndex := tokenMetaByIndex(tokens)
+	out := make(map[string]TokenMeta, len(byIndex))
+	for _, token := range byIndex {
+		out[normalizeSpotName(token.Name)] = token
 	}
-	return names
+	return out
 }
 
-func baseQuoteFromTokens(meta map[string]any, tokenNames map[int]tokenMeta) (string, string, int, int) {
-	tokens, ok := toSlice(meta["tokens"])
-	if !ok || len(tokens) < 2 || tokenNames == nil {
-		return stringFromMap(meta, "base", "baseCoin"), stringFromMap(meta, "quote", "quoteCoin"), -1, -1
+// baseQuoteFromTokens resolves a pair's base and quote tokens; pairs without
+// token indices fall back to their base/quote names with unknown decimals.
+func baseQuoteFromTokens(meta map[string]any, tokens map[int]TokenMeta) (TokenMeta, TokenMeta) {
+	indices, ok := toSlice(meta["tokens"])
+	if !ok || len(indices) < 2 || tokens == nil {
+		return TokenMeta{Name: stringFromMap(meta, "base", "baseCoin"), SzDecimals: -1, WeiDecimals: -1},
+			TokenMeta{Name: stringFromMap(meta, "quote", "quoteCoin"), SzDecimals: -1, WeiDecimals: -1}
 	}
-	baseIdx := intFromAny(tokens[0], -1)
-	quoteIdx := intFromAny(tokens[1], -1)
-	base := tokenNames[baseIdx]
-	quote := tokenNames[quoteIdx]
-	return base.name, quote.name, base.szDecimals, quote.szDecimals
+	baseIdx := intFromAny(indices[0], -1)
+	quoteIdx := intFromAny(indices[1], -1)
+	return tokens[baseIdx], tokens[quoteIdx]
 }
 
 func spotSymbol(meta map[string]any, base, quote string) string {
@@ -6,7 +6,7 @@ func TestParsePerpContextsArray(t *testing.T) {
 	payload := []any{
 		map[string]any{
 			"universe": []any{
-				map[string]any{"name": "BTC", "szDecimals": 5},
+				map[string]any{"name": "BTC", "szDecimals": 5, "maxLeverage": 40},
 				map[string]any{"name": "ETH", "szDecimals": 4},
 			},
 		},
@@ -33,7 +33,16 @@ func TestParsePerpContextsArray(t *testing.T) {
 	if btc.SzDecimals != 5 {
 		t.Fatalf("expected BTC sz decimals 5, got %d", btc.SzDecimals)
 	}
+	if btc.Increments.SzDecimals != 5 || btc.Increments.PriceDecimals != 1 {
+		t.Fatalf("expected BTC perp increments 5/1, got %+v", btc.Increments)
+	}
+	if btc.MaxLeverage != 40 {
+		t.Fatalf("expected BTC max leverage 40, got %f", btc.MaxLeverage)
+	}
 	eth := ctxs["ETH"]
+	if eth.MaxLeverage != 0 {
+		t.Fatalf("expected no ETH max leverage, got %f", eth.MaxLeverage)
+	}
 	if !closeEnough(eth.FundingRate, 0.002) {
 		t.Fatalf("expected ETH funding 0.002, got %f", eth.FundingRate)
 	}
@@ -88,6 +97,9 @@ func TestParseSpotContexts(t *testing.T) {
 	if btc.BaseSzDecimals != 5 {
 		t.Fatalf("expected BTC sz decimals 5, got %d", btc.BaseSzDecimals)
 	}
+	if btc.Increments.SzDecimals != 5 || btc.Increments.PriceDecimals != 3 {
+		t.Fatalf("expected BTC spot increments 5/3, got %+v", btc.Increments)
+	}
 	if ctxs["ETH/USDC"].Symbol == "" {
 		t.Fatalf("expected ETH/USDC symbol to be parsed")
 	}
@@ -0,0 +1,94 @@
+package market
+
+import (
+	"sort"
+	"strings"
+)
+
+// Alias ranks, best first. A name claimed by several pairs resolves to the
+// pair with the best rank, then the lowest spot index.
+const (
+	aliasExact = iota
+	aliasBaseUSDC
+	aliasBase
+	aliasUnwrapped
+)
+
+type spotAlias struct {
+	symbol string
+	rank   int
+	index  int
+}
+
+// buildSpotAliases maps every user-facing spot name to a canonical pair
+// symbol: the pair symbol itself, its raw universe name (e.g. "@142"), the
+// base token, and the base without the "U" prefix of wrapped tokens
+// ("BTC" for UBTC). Keys are upper case; see normalizeSpotName.
+func buildSpotAliases(pairs map[string]SpotContext) map[string]string {
+	symbols := make([]string, 0, len(pairs))
+	for symbol := range pairs {
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool { return pairs[symbols[i]].Index < pairs[symbols[j]].Index })
+	best := make(map[string]spotAlias)
+	claim := func(name, symbol string, rank, index int) {
+		key := normalizeSpotName(name)
+		if key == "" {
+			return
+		}
+		if cur, ok := best[key]; ok && (cur.rank < rank || (cur.rank == rank && cur.index <= index)) {
+			return
+		}
+		best[key] = spotAlias{symbol: symbol, rank: rank, index: index}
+	}
+	for _, symbol := range symbols {
+		ctx := pairs[symbol]
+		claim(symbol, symbol, aliasExact, ctx.Index)
+		claim(ctx.RawName, symbol, aliasExact, ctx.Index)
+		if ctx.Base == "" {
+			continue
+		}
+		if ctx.Quote == "USDC" {
+			claim(ctx.Base, symbol, aliasBaseUSDC, ctx.Index)
+			claim(ctx.Base+"/"+ctx.Quote, symbol, aliasExact, ctx.Index)
+			if unwrapped, ok := unwrappedBase(ctx.Base); ok {
+				claim(unwrapped, symbol, aliasUnwrapped, ctx.Index)
+			}
+		} else {
+			claim(ctx.Base, symbol, aliasBase, ctx.Index)
+		}
+	}
+	aliases := make(map[string]string, len(best))
+	for key, alias := range best {
+		aliases[key] = alias.symbol
+	}
+	return aliases
+}
+
+// unwrappedBase strips the "U" of wrapped tokens (UBTC, UETH, USOL).
+// Stablecoins such as USDC/USDT/USDE keep their name.
+func unwrappedBase(base string) (string, bool) {
+	if len(base) < 4 || base[0] != 'U' || strings.HasPrefix(base, "USD") {
+		return "", false
+	}
+	return base[1:], true
+}
+
+func normalizeSpotName(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+// ResolveSpot returns the spot pair for a user-facing name: a pair symbol
+// ("UBTC/USDC"), a raw universe name ("@142"), a base token ("UBTC") or an
+// unwrapped base ("BTC"). Names are matched case-insensitively against the
+// aliases built at the last context refresh.
+func (m *MarketData) ResolveSpot(asset string) (SpotContext, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	symbol, ok := m.spotAliases[normalizeSpotName(asset)]
+	if !ok {
+		return SpotContext{}, false
+	}
+	ctx, ok := m.spotCtx[symbol]
+	return ctx, ok
+}
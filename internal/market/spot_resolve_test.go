@@ -0,0 +1,56 @@
+package market
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestResolveSpot(t *testing.T) {
+	payload := []any{
+		map[string]any{
+			"universe": []any{
+				map[string]any{"name": "PURR/USDC", "index": 0, "tokens": []any{1, 0}},
+				map[string]any{"name": "@142", "index": 142, "tokens": []any{2, 0}},
+				map[string]any{"name": "@150", "index": 150, "tokens": []any{2, 3}},
+				map[string]any{"name": "@151", "index": 151, "tokens": []any{3, 0}},
+			},
+			"tokens": []any{
+				map[string]any{"name": "USDC", "index": 0, "szDecimals": 8},
+				map[string]any{"name": "PURR", "index": 1, "szDecimals": 0},
+				map[string]any{"name": "UBTC", "index": 2, "szDecimals": 5},
+				map[string]any{"name": "USDT0", "index": 3, "szDecimals": 2},
+			},
+		},
+		[]any{},
+	}
+	ctxs, err := parseSpotContexts(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	md := New(nil, nil, zap.NewNop())
+	md.spotCtx = ctxs
+	md.spotAliases = buildSpotAliases(ctxs)
+
+	for _, name := range []string{"UBTC/USDC", "@142", "UBTC", "ubtc", "BTC"} {
+		ctx, ok := md.ResolveSpot(name)
+		if !ok || ctx.Symbol != "UBTC/USDC" || ctx.MidKey != "@142" {
+			t.Fatalf("expected %s to resolve to UBTC/USDC, got %+v %v", name, ctx, ok)
+		}
+	}
+	if ctx, ok := md.ResolveSpot("UBTC/USDT0"); !ok || ctx.Index != 150 {
+		t.Fatalf("expected the USDT0 pair by symbol, got %+v %v", ctx, ok)
+	}
+	if ctx, ok := md.ResolveSpot("USDT0"); !ok || ctx.Index != 151 {
+		t.Fatalf("expected USDT0 to resolve to its USDC pair, got %+v %v", ctx, ok)
+	}
+	if _, ok := md.ResolveSpot("SDT0"); ok {
+		t.Fatal("expected stablecoins to keep their name")
+	}
+	if id, ok := md.SpotAssetID("BTC"); !ok || id != 10142 {
+		t.Fatalf("expected spot asset id 10142, got %d %v", id, ok)
+	}
+	if _, ok := md.ResolveSpot("ETH"); ok {
+		t.Fatal("expected an unknown name not to resolve")
+	}
+}
@@ -0,0 +1,61 @@
+package market
+
+import (
+	"math"
+	"strconv"
+)
+
+// TokenMeta is one spot token from spotMeta. WeiDecimals is the precision
+// balances and spotSend amounts carry on HyperCore; a token linked to
+// HyperEVM has its ERC-20 address in EVMContract, whose decimals exceed
+// WeiDecimals by EVMExtraWeiDecimals. Unknown decimals are -1.
+type TokenMeta struct {
+	Name                string
+	Index               int
+	TokenID             string
+	SzDecimals          int
+	WeiDecimals         int
+	EVMContract         string
+	EVMExtraWeiDecimals int
+	IsCanonical         bool
+}
+
+// HasEVMContract reports whether the token is linked to a HyperEVM contract.
+func (t TokenMeta) HasEVMContract() bool {
+	return t.EVMContract != ""
+}
+
+// RoundAmount truncates amount to the token's wei decimals so a transfer
+// never exceeds the balance it was computed from. Unknown decimals leave
+// amount as is.
+func (t TokenMeta) RoundAmount(amount float64) float64 {
+	if t.WeiDecimals < 0 || amount <= 0 {
+		return amount
+	}
+	scale := math.Pow10(t.WeiDecimals)
+	// The epsilon keeps values like 0.3 (0.29999…) from losing a unit.
+	return math.Floor(amount*scale+1e-9) / scale
+}
+
+// FormatAmount renders amount as the decimal string spotSend expects,
+// truncated to the token's wei decimals without trailing zeros.
+func (t TokenMeta) FormatAmount(amount float64) string {
+	return strconv.FormatFloat(t.RoundAmount(amount), 'f', -1, 64)
+}
+
+// TokenMeta returns the spot token named symbol ("UETH", case-insensitive)
+// as of the last context refresh. A pair name or alias accepted by
+// ResolveSpot ("UETH/USDC", "@151", "ETH") resolves to the pair's base token.
+func (m *MarketData) TokenMeta(symbol string) (TokenMeta, bool) {
+	m.mu.RLock()
+	token, ok := m.tokens[normalizeSpotName(symbol)]
+	m.mu.RUnlock()
+	if ok {
+		return token, true
+	}
+	ctx, ok := m.ResolveSpot(symbol)
+	if !ok || ctx.BaseToken.Name == "" {
+		return TokenMeta{}, false
+	}
+	return ctx.BaseToken, true
+}
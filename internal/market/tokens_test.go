@@ -0,0 +1,58 @@
+package market
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestTokenMetaFromSpotMeta(t *testing.T) {
+	payload := []any{
+		map[string]any{
+			"universe": []any{
+				map[string]any{"name": "@151", "index": 151, "tokens": []any{221, 0}},
+			},
+			"tokens": []any{
+				map[string]any{"name": "USDC", "index": 0, "tokenId": "0x6d1e7cde53ba9467b783cb7c530ce054", "szDecimals": 8, "weiDecimals": 8, "isCanonical": true, "evmContract": nil},
+				map[string]any{"name": "UETH", "index": 221, "tokenId": "0xe1edd30daaf5caac3fe63569e24748da", "szDecimals": 4, "weiDecimals": 9, "isCanonical": false,
+					"evmContract": map[string]any{"address": "0xbe6727b535545c67d5caa73dea54865b92cf7907", "evm_extra_wei_decimals": 9}},
+			},
+		},
+		[]any{},
+	}
+	ctxs, err := parseSpotContexts(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	md := New(nil, nil, zap.NewNop())
+	md.spotCtx = ctxs
+	md.spotAliases = buildSpotAliases(ctxs)
+	md.tokens = parseSpotTokens(payload)
+
+	ueth, ok := md.TokenMeta("ueth")
+	if !ok || ueth.Index != 221 || ueth.SzDecimals != 4 || ueth.WeiDecimals != 9 || ueth.TokenID != "0xe1edd30daaf5caac3fe63569e24748da" {
+		t.Fatalf("unexpected UETH meta %+v %v", ueth, ok)
+	}
+	if !ueth.HasEVMContract() || ueth.EVMContract != "0xbe6727b535545c67d5caa73dea54865b92cf7907" || ueth.EVMExtraWeiDecimals != 9 {
+		t.Fatalf("expected the UETH EVM contract, got %+v", ueth)
+	}
+	if usdc, ok := md.TokenMeta("USDC"); !ok || usdc.HasEVMContract() || !usdc.IsCanonical || usdc.WeiDecimals != 8 {
+		t.Fatalf("unexpected USDC meta %+v %v", usdc, ok)
+	}
+	if byPair, ok := md.TokenMeta("ETH"); !ok || byPair != ueth {
+		t.Fatalf("expected an alias to resolve to the base token, got %+v %v", byPair, ok)
+	}
+	if ctx := ctxs["UETH/USDC"]; ctx.BaseToken != ueth || ctx.QuoteToken.Name != "USDC" {
+		t.Fatalf("expected the pair to carry its token metadata, got %+v", ctx)
+	}
+	if _, ok := md.TokenMeta("PURR"); ok {
+		t.Fatal("expected an unknown token not to resolve")
+	}
+
+	if got := ueth.RoundAmount(0.1234567891234); got != 0.123456789 {
+		t.Fatalf("expected truncation to 9 wei decimals, got %v", got)
+	}
+	if got := ueth.FormatAmount(0.3); got != "0.3" {
+		t.Fatalf("expected 0.3, got %s", got)
+	}
+}
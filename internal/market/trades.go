@@ -0,0 +1,150 @@
+package market
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tradeFlowMinTrades is the fewest trades in the window before TradeFlow
+// reports an imbalance; a handful of prints is noise, not momentum.
+const tradeFlowMinTrades = 10
+
+type tradePrint struct {
+	Time  time.Time
+	Price float64
+	Size  float64
+	// Buy is true when the taker bought (side "B").
+	Buy bool
+}
+
+// TradeFlow summarizes taker flow for an asset over a short window.
+// Imbalance is (buy - sell) / (buy + sell) notional, in [-1, 1]; negative
+// means sellers are hitting bids harder than buyers lift offers.
+type TradeFlow struct {
+	Asset     string        `json:"asset"`
+	Window    time.Duration `json:"window"`
+	Trades    int           `json:"trades"`
+	BuyUSD    float64       `json:"buy_usd"`
+	SellUSD   float64       `json:"sell_usd"`
+	Imbalance float64       `json:"imbalance"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// EnableTradeFlow sets the window TradeFlow aggregates over.
+func (m *MarketData) EnableTradeFlow(window time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if window > 0 {
+		m.flowWindow = window
+	}
+}
+
+// TradeFlow returns the taker buy/sell imbalance for asset over the trade
+// flow window ending now. ok is false until enough trades have been seen.
+func (m *MarketData) TradeFlow(asset string) (TradeFlow, bool) {
+	return m.tradeFlowAt(asset, time.Now().UTC())
+}
+
+func (m *MarketData) tradeFlowAt(asset string, now time.Time) (TradeFlow, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	flow := TradeFlow{Asset: asset, Window: m.flowWindow}
+	cutoff := now.Add(-m.flowWindow)
+	for _, trade := range m.flow[asset] {
+		if trade.Time.Before(cutoff) {
+			continue
+		}
+		notional := trade.Price * trade.Size
+		if trade.Buy {
+			flow.BuyUSD += notional
+		} else {
+			flow.SellUSD += notional
+		}
+		flow.Trades++
+		if trade.Time.After(flow.UpdatedAt) {
+			flow.UpdatedAt = trade.Time
+		}
+	}
+	total := flow.BuyUSD + flow.SellUSD
+	if flow.Trades < tradeFlowMinTrades || total <= 0 {
+		return flow, false
+	}
+	flow.Imbalance = (flow.BuyUSD - flow.SellUSD) / total
+	return flow, true
+}
+
+// subscribeTrades subscribes to the trades of every asset with a candle
+// series.
+func (m *MarketData) subscribeTrades(ctx context.Context) {
+	seen := make(map[string]bool)
+	for _, spec := range m.candleSpecs() {
+		if seen[spec.asset] {
+			continue
+		}
+		seen[spec.asset] = true
+		sub := map[string]any{
+			"method":       "subscribe",
+			"subscription": map[string]any{"type": "trades", "coin": spec.asset},
+		}
+		if err := m.ws.Subscribe(ctx, sub); err != nil {
+			m.log.Warn("trades subscribe failed", zap.Error(err))
+		}
+	}
+}
+
+func (m *MarketData) updateTrades(payload map[string]any) {
+	if channel, _ := payload["channel"].(string); channel != "trades" {
+		return
+	}
+	prints := parseTrades(payload["data"])
+	if len(prints) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	for asset, list := range prints {
+		m.appendFlowLocked(asset, list, now)
+		m.updateRealizedLocked(asset, list)
+	}
+}
+
+// appendFlowLocked keeps trades within the flow window of now for TradeFlow.
+func (m *MarketData) appendFlowLocked(asset string, prints []tradePrint, now time.Time) {
+	flow := append(m.flow[asset], prints...)
+	cutoff := now.Add(-m.flowWindow)
+	start := 0
+	for start < len(flow) && flow[start].Time.Before(cutoff) {
+		start++
+	}
+	m.flow[asset] = flow[start:]
+}
+
+func parseTrades(payload any) map[string][]tradePrint {
+	items, ok := toSlice(payload)
+	if !ok {
+		return nil
+	}
+	out := make(map[string][]tradePrint)
+	for _, item := range items {
+		entry, ok := toMap(item)
+		if !ok {
+			continue
+		}
+		asset := stringFromMap(entry, "coin")
+		price := floatFromMap(entry, "px")
+		ts, ok := timeFromAny(entry["time"])
+		if asset == "" || price <= 0 || !ok {
+			continue
+		}
+		out[asset] = append(out[asset], tradePrint{
+			Time:  ts,
+			Price: price,
+			Size:  floatFromMap(entry, "sz"),
+			Buy:   stringFromMap(entry, "side") == "B",
+		})
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package market
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestTradeFlowImbalance(t *testing.T) {
+	m := New(nil, nil, zap.NewNop())
+	now := time.Now().UTC()
+	var data []any
+	for i := 0; i < tradeFlowMinTrades; i++ {
+		side := "A"
+		if i < 2 {
+			side = "B"
+		}
+		data = append(data, map[string]any{"coin": "ETH", "side": side, "px": "2000", "sz": "1", "time": now.Add(-time.Duration(i) * time.Second).UnixMilli()})
+	}
+	data = append(data, map[string]any{"coin": "ETH", "side": "B", "px": "2000", "sz": "100", "time": now.Add(-time.Hour).UnixMilli()})
+
+	m.updateTrades(map[string]any{"channel": "trades", "data": data[:tradeFlowMinTrades-1]})
+	if _, ok := m.TradeFlow("ETH"); ok {
+		t.Fatalf("expected no imbalance below the minimum trade count")
+	}
+	m.updateTrades(map[string]any{"channel": "trades", "data": data[tradeFlowMinTrades-1:]})
+	flow, ok := m.tradeFlowAt("ETH", now)
+	if !ok {
+		t.Fatalf("expected trade flow")
+	}
+	if flow.Trades != tradeFlowMinTrades {
+		t.Fatalf("expected trades outside the window ignored, got %d", flow.Trades)
+	}
+	if want := (2.0 - 8.0) / 10.0; !closeEnough(flow.Imbalance, want) {
+		t.Fatalf("expected imbalance %f, got %f", want, flow.Imbalance)
+	}
+	if _, ok := m.tradeFlowAt("ETH", now.Add(10*time.Minute)); ok {
+		t.Fatalf("expected stale trades to drop out of the window")
+	}
+}
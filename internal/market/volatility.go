@@ -0,0 +1,189 @@
+package market
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// Volatility estimators selectable with EnableVolatility. All report the
+// stdev of returns per candle interval so they share the MaxVolatility scale.
+const (
+	VolEstimatorStdev     = "stdev"
+	VolEstimatorEWMA      = "ewma"
+	VolEstimatorParkinson = "parkinson"
+	VolEstimatorRealized  = "realized"
+)
+
+// DefaultEWMALambda is the RiskMetrics decay for the EWMA estimator.
+const DefaultEWMALambda = 0.94
+
+// VolEstimatorValid reports whether name is a known estimator.
+func VolEstimatorValid(name string) bool {
+	switch name {
+	case VolEstimatorStdev, VolEstimatorEWMA, VolEstimatorParkinson, VolEstimatorRealized:
+		return true
+	default:
+		return false
+	}
+}
+
+// EnableVolatility selects the estimator behind Volatility. lambda applies to
+// EWMA; window bounds the trades kept for the realized estimator.
+func (m *MarketData) EnableVolatility(estimator string, lambda float64, window time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if VolEstimatorValid(estimator) {
+		m.volEstimator = estimator
+	}
+	if lambda > 0 && lambda < 1 {
+		m.ewmaLambda = lambda
+	}
+	if window > 0 {
+		m.realizedWindow = window
+	}
+}
+
+// updateCandleVolatilityLocked recomputes the candle-based estimate for a
+// series. closes holds every candle update (the legacy stdev input); history
+// holds one entry per candle start.
+func (m *MarketData) updateCandleVolatilityLocked(series *candleSeries) {
+	switch m.volEstimator {
+	case VolEstimatorEWMA:
+		closes := make([]float64, 0, len(series.history))
+		for _, candle := range series.history {
+			closes = append(closes, candle.Close)
+		}
+		series.volatility = ewmaVolatility(closes, m.ewmaLambda)
+	case VolEstimatorParkinson:
+		series.volatility = parkinsonVolatility(series.history)
+	case VolEstimatorRealized:
+		// Driven by the trades feed.
+		return
+	default:
+		series.volatility = computeVolatility(series.closes)
+	}
+	series.hasVol = true
+}
+
+// append keeps one candle per start time, replacing the in-progress candle
+// as updates arrive.
+func (s *candleSeries) append(candle Candle) {
+	if n := len(s.history); n > 0 && s.history[n-1].Start.Equal(candle.Start) {
+		s.history[n-1] = candle
+	} else {
+		s.history = append(s.history, candle)
+	}
+	if len(s.history) > s.window {
+		s.history = s.history[len(s.history)-s.window:]
+	}
+}
+
+// updateRealizedLocked appends trades for the realized estimator, drops those
+// older than realizedWindow, and recomputes the estimate of every series of
+// asset at its own interval.
+func (m *MarketData) updateRealizedLocked(asset string, prints []tradePrint) {
+	if m.volEstimator != VolEstimatorRealized || len(prints) == 0 {
+		return
+	}
+	trades := append(m.trades[asset], prints...)
+	cutoff := trades[len(trades)-1].Time.Add(-m.realizedWindow)
+	start := 0
+	for start < len(trades) && trades[start].Time.Before(cutoff) {
+		start++
+	}
+	m.trades[asset] = trades[start:]
+	for _, series := range m.candles {
+		if series.asset != asset {
+			continue
+		}
+		series.volatility = realizedVolatility(m.trades[asset], candleIntervalDuration(series.interval))
+		series.hasVol = true
+	}
+}
+
+// ewmaVolatility is the RiskMetrics estimate sqrt(var) with
+// var_t = lambda*var_{t-1} + (1-lambda)*r_t^2 over log returns of closes.
+func ewmaVolatility(closes []float64, lambda float64) float64 {
+	if lambda <= 0 || lambda >= 1 {
+		lambda = DefaultEWMALambda
+	}
+	variance := 0.0
+	seeded := false
+	for i := 1; i < len(closes); i++ {
+		if closes[i-1] <= 0 || closes[i] <= 0 {
+			continue
+		}
+		r := math.Log(closes[i] / closes[i-1])
+		if !seeded {
+			variance = r * r
+			seeded = true
+			continue
+		}
+		variance = lambda*variance + (1-lambda)*r*r
+	}
+	return math.Sqrt(variance)
+}
+
+// parkinsonVolatility estimates per-candle volatility from high/low ranges:
+// var = sum(ln(H/L)^2) / (4 ln 2 n).
+func parkinsonVolatility(candles []Candle) float64 {
+	sum := 0.0
+	n := 0
+	for _, candle := range candles {
+		if candle.High <= 0 || candle.Low <= 0 || candle.High < candle.Low {
+			continue
+		}
+		hl := math.Log(candle.High / candle.Low)
+		sum += hl * hl
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Sqrt(sum / (4 * math.Ln2 * float64(n)))
+}
+
+// realizedVolatility sums squared log returns between consecutive trades and
+// scales the variance from the observed span to interval.
+func realizedVolatility(trades []tradePrint, interval time.Duration) float64 {
+	if len(trades) < 2 || interval <= 0 {
+		return 0
+	}
+	span := trades[len(trades)-1].Time.Sub(trades[0].Time)
+	if span <= 0 {
+		return 0
+	}
+	sumSq := 0.0
+	for i := 1; i < len(trades); i++ {
+		r := math.Log(trades[i].Price / trades[i-1].Price)
+		sumSq += r * r
+	}
+	return math.Sqrt(sumSq * float64(interval) / float64(span))
+}
+
+// candleIntervalDuration converts an HL candle interval ("15m", "1h", "1d",
+// "1w", "1M") to a duration; unknown intervals fall back to one hour.
+func candleIntervalDuration(interval string) time.Duration {
+	if len(interval) < 2 {
+		return time.Hour
+	}
+	unit := interval[len(interval)-1:]
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return time.Hour
+	}
+	switch unit {
+	case "m":
+		return time.Duration(n) * time.Minute
+	case "h":
+		return time.Duration(n) * time.Hour
+	case "d":
+		return time.Duration(n) * 24 * time.Hour
+	case "w":
+		return time.Duration(n) * 7 * 24 * time.Hour
+	case "M":
+		return time.Duration(n) * 30 * 24 * time.Hour
+	}
+	return time.Hour
+}
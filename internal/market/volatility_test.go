@@ -0,0 +1,119 @@
+package market
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestEWMAVolatilityWeightsRecentReturns(t *testing.T) {
+	calm := []float64{100, 100.1, 100, 100.1, 100, 100.1, 100}
+	spike := append(append([]float64{}, calm...), 105)
+	if ewmaVolatility(spike, DefaultEWMALambda) <= ewmaVolatility(calm, DefaultEWMALambda) {
+		t.Fatalf("expected a late spike to raise EWMA volatility")
+	}
+	fast := ewmaVolatility(spike, 0.5)
+	slow := ewmaVolatility(spike, 0.97)
+	if fast <= slow {
+		t.Fatalf("expected lower lambda to react faster: fast=%f slow=%f", fast, slow)
+	}
+	if got := ewmaVolatility([]float64{100}, DefaultEWMALambda); got != 0 {
+		t.Fatalf("expected zero volatility for a single close, got %f", got)
+	}
+}
+
+func TestParkinsonVolatility(t *testing.T) {
+	candles := []Candle{{High: 110, Low: 100}, {High: 110, Low: 100}}
+	want := math.Log(1.1) / math.Sqrt(4*math.Ln2)
+	if got := parkinsonVolatility(candles); !closeEnough(got, want) {
+		t.Fatalf("expected %f, got %f", want, got)
+	}
+	if got := parkinsonVolatility([]Candle{{High: 0, Low: 0}}); got != 0 {
+		t.Fatalf("expected zero for invalid candles, got %f", got)
+	}
+}
+
+func TestRealizedVolatilityScalesToInterval(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	trades := []tradePrint{
+		{Time: start, Price: 100},
+		{Time: start.Add(30 * time.Minute), Price: 101},
+		{Time: start.Add(60 * time.Minute), Price: 100},
+	}
+	r := math.Log(101.0 / 100.0)
+	hourly := realizedVolatility(trades, time.Hour)
+	if want := math.Sqrt(2 * r * r); !closeEnough(hourly, want) {
+		t.Fatalf("expected %f, got %f", want, hourly)
+	}
+	if daily := realizedVolatility(trades, 24*time.Hour); !closeEnough(daily, hourly*math.Sqrt(24)) {
+		t.Fatalf("expected sqrt-time scaling, got %f", daily)
+	}
+}
+
+func TestVolatilityEstimatorSelection(t *testing.T) {
+	m := New(nil, nil, zap.NewNop())
+	m.EnableCandle("BTC", "1h", 24)
+	m.EnableVolatility(VolEstimatorParkinson, 0, 0)
+	for i, c := range []map[string]any{
+		{"s": "BTC", "i": "1h", "c": "30100", "o": "30000", "h": "30200", "l": "29900", "t": 1700000000000},
+		{"s": "BTC", "i": "1h", "c": "30150", "o": "30000", "h": "30300", "l": "29800", "t": 1700000000000},
+		{"s": "BTC", "i": "1h", "c": "30200", "o": "30150", "h": "30250", "l": "30100", "t": 1700003600000},
+	} {
+		m.handleMessage(mustJSON(t, map[string]any{"channel": "candle", "data": c}))
+		if i == 1 {
+			if got := len(m.candles[candleKey("BTC", "1h")].history); got != 1 {
+				t.Fatalf("expected in-progress candle replaced, got %d candles", got)
+			}
+		}
+	}
+	want := parkinsonVolatility([]Candle{{High: 30300, Low: 29800}, {High: 30250, Low: 30100}})
+	if got, ok := m.Volatility("BTC", "1h"); !ok || !closeEnough(got, want) {
+		t.Fatalf("expected parkinson volatility %f, got %f", want, got)
+	}
+
+	m.EnableVolatility(VolEstimatorRealized, 0, time.Hour)
+	m.handleMessage(mustJSON(t, map[string]any{"channel": "trades", "data": []any{
+		map[string]any{"coin": "BTC", "px": "30000", "sz": "0.1", "time": 1700000000000},
+		map[string]any{"coin": "BTC", "px": "30300", "sz": "0.1", "time": 1700001800000},
+	}}))
+	want = realizedVolatility([]tradePrint{
+		{Time: time.UnixMilli(1700000000000), Price: 30000},
+		{Time: time.UnixMilli(1700001800000), Price: 30300},
+	}, time.Hour)
+	if got, _ := m.Volatility("BTC", "1h"); !closeEnough(got, want) {
+		t.Fatalf("expected realized volatility %f, got %f", want, got)
+	}
+	m.handleMessage(mustJSON(t, map[string]any{"channel": "trades", "data": []any{
+		map[string]any{"coin": "BTC", "px": "30300", "sz": "0.1", "time": 1700009000000},
+	}}))
+	if got := len(m.trades["BTC"]); got != 1 {
+		t.Fatalf("expected trades outside the window dropped, got %d", got)
+	}
+}
+
+func TestCandleIntervalDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"15m": 15 * time.Minute,
+		"1h":  time.Hour,
+		"1d":  24 * time.Hour,
+		"1w":  7 * 24 * time.Hour,
+		"bad": time.Hour,
+	}
+	for in, want := range cases {
+		if got := candleIntervalDuration(in); got != want {
+			t.Fatalf("%s: expected %s, got %s", in, want, got)
+		}
+	}
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return raw
+}
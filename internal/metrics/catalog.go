@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Definition describes one exported metric. The Prometheus collectors are
+// built from these, so the catalog cannot drift from what /metrics serves.
+type Definition struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Help   string   `json:"help"`
+	Labels []string `json:"labels"`
+	// Buckets are a histogram's upper bounds.
+	Buckets []float64 `json:"buckets,omitempty"`
+}
+
+var (
+	defOrdersPlaced  = Definition{Name: promNamespace + "_orders_placed_total", Type: TypeCounter, Help: "Total number of orders placed."}
+	defOrdersFailed  = Definition{Name: promNamespace + "_orders_failed_total", Type: TypeCounter, Help: "Total number of order placement failures."}
+	defEntryFailed   = Definition{Name: promNamespace + "_entry_failed_total", Type: TypeCounter, Help: "Total number of entry flow failures."}
+	defExitFailed    = Definition{Name: promNamespace + "_exit_failed_total", Type: TypeCounter, Help: "Total number of exit flow failures."}
+	defKillEngaged   = Definition{Name: promNamespace + "_kill_switch_engaged_total", Type: TypeCounter, Help: "Total number of connectivity kill switch engagements."}
+	defKillRestored  = Definition{Name: promNamespace + "_kill_switch_restored_total", Type: TypeCounter, Help: "Total number of connectivity kill switch recoveries."}
+	defRESTShed      = Definition{Name: promNamespace + "_rest_requests_shed_total", Type: TypeCounter, Help: "Total number of low-priority REST requests shed by the rate limiter."}
+	defRESTLeft      = Definition{Name: promNamespace + "_rest_weight_remaining", Type: TypeGauge, Help: "Remaining REST request weight in the per-minute budget."}
+	defRESTLatency   = Definition{Name: promNamespace + "_rest_request_seconds", Type: TypeHistogram, Help: "Duration of REST /info requests in seconds, per attempt, by request type.", Labels: []string{"endpoint"}, Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}}
+	defRESTRetries   = Definition{Name: promNamespace + "_rest_retries_total", Type: TypeCounter, Help: "Total number of REST /info requests retried after a 429 or 5xx answer, by request type.", Labels: []string{"endpoint"}}
+	defRollbacks     = Definition{Name: promNamespace + "_spot_rollbacks_total", Type: TypeCounter, Help: "Total number of spot rollbacks started."}
+	defRollbackFail  = Definition{Name: promNamespace + "_spot_rollbacks_failed_total", Type: TypeCounter, Help: "Total number of spot rollbacks that left residual exposure."}
+	defRollbackRetry = Definition{Name: promNamespace + "_spot_rollback_retries_total", Type: TypeCounter, Help: "Total number of repriced spot rollback retries."}
+	defForeign       = Definition{Name: promNamespace + "_foreign_activity_total", Type: TypeCounter, Help: "Total number of orders and fills on the account not placed by this bot."}
+	defPostOnlyCross = Definition{Name: promNamespace + "_post_only_rejected_total", Type: TypeCounter, Help: "Total number of post-only orders rejected because they would have crossed."}
+	defRiskAction    = Definition{Name: promNamespace + "_risk_action", Type: TypeGauge, Help: "Current risk engine action: 0 none, 1 block_entry, 2 hedge_only, 3 reduce, 4 flatten, 5 halt."}
+	defRiskViolation = Definition{Name: promNamespace + "_risk_violations_total", Type: TypeCounter, Help: "Total number of risk rule violations, counted when a rule starts firing."}
+	defCircuitOpened = Definition{Name: promNamespace + "_order_circuit_opened_total", Type: TypeCounter, Help: "Total number of per-asset order circuits opened after repeated order failures."}
+	defAccountDrift  = Definition{Name: promNamespace + "_account_state_drift_total", Type: TypeCounter, Help: "Total number of REST reconciles that found the WS account state diverged beyond tolerance."}
+	defNonceRejected = Definition{Name: promNamespace + "_nonce_rejected_total", Type: TypeCounter, Help: "Total number of exchange actions rejected for their nonce."}
+	defTSWritten     = Definition{Name: promNamespace + "_timescale_rows_written_total", Type: TypeCounter, Help: "Total number of rows written to Timescale."}
+	defTSDropped     = Definition{Name: promNamespace + "_timescale_rows_dropped_total", Type: TypeCounter, Help: "Total number of Timescale rows dropped on queue or backlog overflow or a rejected insert."}
+	defTSLatency     = Definition{Name: promNamespace + "_timescale_write_seconds", Type: TypeGauge, Help: "Duration of the last successful Timescale batch insert in seconds."}
+	defKillActive    = Definition{Name: promNamespace + "_kill_switch_active", Type: TypeGauge, Help: "1 while the connectivity kill switch is engaged, else 0."}
+	defPaused        = Definition{Name: promNamespace + "_paused", Type: TypeGauge, Help: "1 while trading is paused by an operator, else 0."}
+	defTicksSkipped  = Definition{Name: promNamespace + "_ticks_skipped_total", Type: TypeCounter, Help: "Total number of strategy ticks that skipped trading, by gating reason.", Labels: []string{"reason"}}
+	defFundingAPR    = Definition{Name: promNamespace + "_funding_apr", Type: TypeGauge, Help: "Perp funding rate annualized over its funding interval, as a fraction."}
+	defPerpSlippage  = Definition{Name: promNamespace + "_perp_slippage_bps", Type: TypeGauge, Help: "Size-weighted slippage of recent perp fills against the mid at placement, in bps (positive is adverse)."}
+	defSpotSlippage  = Definition{Name: promNamespace + "_spot_slippage_bps", Type: TypeGauge, Help: "Size-weighted slippage of recent spot fills against the mid at placement, in bps (positive is adverse)."}
+	defIOCPriceBps   = Definition{Name: promNamespace + "_ioc_price_bps", Type: TypeGauge, Help: "IOC limit offset from the mid in use, in bps."}
+	defOrphanOrders  = Definition{Name: promNamespace + "_orphan_orders_total", Type: TypeCounter, Help: "Total number of stray resting orders found by the open order janitor."}
+	defTransitions   = Definition{Name: promNamespace + "_strategy_transitions_total", Type: TypeCounter, Help: "Total number of strategy state changes, by from state, to state, and reason (the event, or set on restore).", Labels: []string{"from", "to", "reason"}}
+	defStateSeconds  = Definition{Name: promNamespace + "_strategy_state_seconds", Type: TypeGauge, Help: "Seconds the strategy has been in its current state, updated every tick."}
+	defUSDCTransfers = Definition{Name: promNamespace + "_usdc_transfers_total", Type: TypeCounter, Help: "Total number of USDC class transfers between the spot and perp wallets, by direction.", Labels: []string{"direction"}}
+	defUSDCMoved     = Definition{Name: promNamespace + "_usdc_transferred_usd_total", Type: TypeCounter, Help: "Total USDC moved by class transfers between the spot and perp wallets, by direction.", Labels: []string{"direction"}}
+)
+
+var definitions = []Definition{
+	defOrdersPlaced,
+	defOrdersFailed,
+	defEntryFailed,
+	defExitFailed,
+	defKillEngaged,
+	defKillRestored,
+	defRESTShed,
+	defRESTLeft,
+	defRESTLatency,
+	defRESTRetries,
+	defRollbacks,
+	defRollbackFail,
+	defRollbackRetry,
+	defForeign,
+	defPostOnlyCross,
+	defRiskAction,
+	defRiskViolation,
+	defCircuitOpened,
+	defAccountDrift,
+	defNonceRejected,
+	defTSWritten,
+	defTSDropped,
+	defTSLatency,
+	defKillActive,
+	defPaused,
+	defTicksSkipped,
+	defFundingAPR,
+	defPerpSlippage,
+	defSpotSlippage,
+	defIOCPriceBps,
+	defOrphanOrders,
+	defTransitions,
+	defStateSeconds,
+	defUSDCTransfers,
+	defUSDCMoved,
+}
+
+// Catalog lists every metric the bot can emit.
+func Catalog() []Definition {
+	out := make([]Definition, len(definitions))
+	for i, def := range definitions {
+		def.Labels = append([]string{}, def.Labels...)
+		def.Buckets = append([]float64(nil), def.Buckets...)
+		out[i] = def
+	}
+	return out
+}
+
+// CatalogHandler serves Catalog as JSON.
+func CatalogHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"metrics": Catalog()})
+	})
+}
@@ -4,6 +4,69 @@ type Counter interface {
 	Inc()
 }
 
+type Gauge interface {
+	Set(float64)
+}
+
+// CounterVec is a counter split by one label.
+type CounterVec interface {
+	With(value string) Counter
+}
+
+// TransitionCounter is a counter split by from state, to state, and reason.
+type TransitionCounter interface {
+	With(from, to, reason string) Counter
+}
+
+// Amount is a counter that grows by arbitrary amounts.
+type Amount interface {
+	Add(float64)
+}
+
+// AmountVec is an Amount split by one label.
+type AmountVec interface {
+	With(value string) Amount
+}
+
+// Observer records samples into a histogram.
+type Observer interface {
+	Observe(float64)
+}
+
+// ObserverVec is a histogram split by one label.
+type ObserverVec interface {
+	With(value string) Observer
+}
+
+// USDC class transfer directions, the label values of USDCTransfers and
+// USDCTransferredUSD.
+const (
+	TransferToSpot = "to_spot"
+	TransferToPerp = "to_perp"
+)
+
+// TransferDirections lists every USDCTransfers label value.
+var TransferDirections = []string{TransferToSpot, TransferToPerp}
+
+// Tick skip reasons, the label values of TicksSkipped.
+const (
+	SkipRisk            = "risk"
+	SkipCooldown        = "cooldown"
+	SkipConnectivity    = "connectivity"
+	SkipPaused          = "paused"
+	SkipCircuit         = "circuit"
+	SkipForeignActivity = "foreign_activity"
+	SkipMinOrder        = "min_order"
+	SkipOrderBudget     = "order_budget"
+	SkipMarginForecast  = "margin_forecast"
+	SkipTickTimeout     = "tick_timeout"
+	SkipTickBudget      = "tick_budget"
+	SkipVaultRecall     = "vault_recall"
+)
+
+// TickSkipReasons lists every TicksSkipped label value.
+var TickSkipReasons = []string{SkipRisk, SkipCooldown, SkipConnectivity, SkipPaused, SkipCircuit, SkipForeignActivity, SkipMinOrder, SkipOrderBudget, SkipMarginForecast, SkipTickTimeout, SkipTickBudget, SkipVaultRecall}
+
 type Metrics struct {
 	OrdersPlaced       Counter
 	OrdersFailed       Counter
@@ -11,12 +74,69 @@ type Metrics struct {
 	ExitFailed         Counter
 	KillSwitchEngaged  Counter
 	KillSwitchRestored Counter
+	RESTWeightShed     Counter
+	RESTWeightLeft     Gauge
+	RESTLatency        ObserverVec
+	RESTRetries        CounterVec
+	Rollbacks          Counter
+	RollbacksFailed    Counter
+	RollbackRetries    Counter
+	ForeignActivity    Counter
+	PostOnlyRejected   Counter
+	RiskAction         Gauge
+	RiskViolations     Counter
+	CircuitOpened      Counter
+	AccountDrift       Counter
+	NonceRejected      Counter
+	TimescaleWritten   Counter
+	TimescaleDropped   Counter
+	TimescaleLatency   Gauge
+	KillSwitchActive   Gauge
+	Paused             Gauge
+	TicksSkipped       CounterVec
+	FundingAPR         Gauge
+	PerpSlippageBps    Gauge
+	SpotSlippageBps    Gauge
+	IOCPriceBps        Gauge
+	OrphanOrders       Counter
+	StateTransitions   TransitionCounter
+	StateSeconds       Gauge
+	USDCTransfers      CounterVec
+	USDCTransferredUSD AmountVec
 }
 
 type noopCounter struct{}
 
 func (noopCounter) Inc() {}
 
+type noopGauge struct{}
+
+func (noopGauge) Set(float64) {}
+
+type noopCounterVec struct{}
+
+func (noopCounterVec) With(string) Counter { return noopCounter{} }
+
+type noopTransitionCounter struct{}
+
+func (noopTransitionCounter) With(string, string, string) Counter { return noopCounter{} }
+
+type noopAmount struct{}
+
+func (noopAmount) Add(float64) {}
+
+type noopAmountVec struct{}
+
+func (noopAmountVec) With(string) Amount { return noopAmount{} }
+
+type noopObserver struct{}
+
+func (noopObserver) Observe(float64) {}
+
+type noopObserverVec struct{}
+
+func (noopObserverVec) With(string) Observer { return noopObserver{} }
+
 func NewNoop() *Metrics {
 	n := noopCounter{}
 	return &Metrics{
@@ -26,5 +146,34 @@ func NewNoop() *Metrics {
 		ExitFailed:         n,
 		KillSwitchEngaged:  n,
 		KillSwitchRestored: n,
+		RESTWeightShed:     n,
+		RESTWeightLeft:     noopGauge{},
+		RESTLatency:        noopObserverVec{},
+		RESTRetries:        noopCounterVec{},
+		Rollbacks:          n,
+		RollbacksFailed:    n,
+		RollbackRetries:    n,
+		ForeignActivity:    n,
+		PostOnlyRejected:   n,
+		RiskAction:         noopGauge{},
+		RiskViolations:     n,
+		CircuitOpened:      n,
+		AccountDrift:       n,
+		NonceRejected:      n,
+		TimescaleWritten:   n,
+		TimescaleDropped:   n,
+		TimescaleLatency:   noopGauge{},
+		KillSwitchActive:   noopGauge{},
+		Paused:             noopGauge{},
+		TicksSkipped:       noopCounterVec{},
+		FundingAPR:         noopGauge{},
+		PerpSlippageBps:    noopGauge{},
+		SpotSlippageBps:    noopGauge{},
+		IOCPriceBps:        noopGauge{},
+		OrphanOrders:       n,
+		StateTransitions:   noopTransitionCounter{},
+		StateSeconds:       noopGauge{},
+		USDCTransfers:      noopCounterVec{},
+		USDCTransferredUSD: noopAmountVec{},
 	}
 }
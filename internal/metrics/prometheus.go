@@ -17,52 +17,161 @@ func (p promCounter) Inc() {
 	p.counter.Inc()
 }
 
+type promCounterVec struct {
+	vec *prometheus.CounterVec
+}
+
+func (p promCounterVec) With(value string) Counter {
+	return p.vec.WithLabelValues(value)
+}
+
+type promTransitionCounter struct {
+	vec *prometheus.CounterVec
+}
+
+func (p promTransitionCounter) With(from, to, reason string) Counter {
+	return p.vec.WithLabelValues(from, to, reason)
+}
+
+type promAmountVec struct {
+	vec *prometheus.CounterVec
+}
+
+func (p promAmountVec) With(value string) Amount {
+	return p.vec.WithLabelValues(value)
+}
+
+type promObserverVec struct {
+	vec *prometheus.HistogramVec
+}
+
+func (p promObserverVec) With(value string) Observer {
+	return p.vec.WithLabelValues(value)
+}
+
 type Prometheus struct {
 	Metrics *Metrics
 
-	registry     *prometheus.Registry
-	ordersPlaced prometheus.Counter
-	ordersFailed prometheus.Counter
-	entryFailed  prometheus.Counter
-	exitFailed   prometheus.Counter
-	killEngaged  prometheus.Counter
-	killRestored prometheus.Counter
+	registry      *prometheus.Registry
+	ordersPlaced  prometheus.Counter
+	ordersFailed  prometheus.Counter
+	entryFailed   prometheus.Counter
+	exitFailed    prometheus.Counter
+	killEngaged   prometheus.Counter
+	killRestored  prometheus.Counter
+	restShed      prometheus.Counter
+	restLeft      prometheus.Gauge
+	restLatency   *prometheus.HistogramVec
+	restRetries   *prometheus.CounterVec
+	rollbacks     prometheus.Counter
+	rollbackFail  prometheus.Counter
+	rollbackRetry prometheus.Counter
+	foreign       prometheus.Counter
+	postOnly      prometheus.Counter
+	riskAction    prometheus.Gauge
+	riskViolation prometheus.Counter
+	circuitOpened prometheus.Counter
+	accountDrift  prometheus.Counter
+	nonceRejected prometheus.Counter
+	tsWritten     prometheus.Counter
+	tsDropped     prometheus.Counter
+	tsLatency     prometheus.Gauge
+	killActive    prometheus.Gauge
+	paused        prometheus.Gauge
+	ticksSkipped  *prometheus.CounterVec
+	fundingAPR    prometheus.Gauge
+	perpSlippage  prometheus.Gauge
+	spotSlippage  prometheus.Gauge
+	iocPriceBps   prometheus.Gauge
+	orphanOrders  prometheus.Counter
+	transitions   *prometheus.CounterVec
+	stateSeconds  prometheus.Gauge
+	transfers     *prometheus.CounterVec
+	transferred   *prometheus.CounterVec
 }
 
 func NewPrometheus() *Prometheus {
+	return newPrometheus(prometheus.NewRegistry(), nil)
+}
+
+// AccountLabel labels every series when one process trades several accounts.
+const AccountLabel = "account"
+
+// PrometheusAccounts serves one metric set per account from a shared
+// registry; each series carries an account label.
+type PrometheusAccounts struct {
+	registry *prometheus.Registry
+	accounts map[string]*Prometheus
+}
+
+func NewPrometheusAccounts(names []string) *PrometheusAccounts {
 	registry := prometheus.NewRegistry()
-	ordersPlaced := prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: promNamespace,
-		Name:      "orders_placed_total",
-		Help:      "Total number of orders placed.",
-	})
-	ordersFailed := prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: promNamespace,
-		Name:      "orders_failed_total",
-		Help:      "Total number of order placement failures.",
-	})
-	entryFailed := prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: promNamespace,
-		Name:      "entry_failed_total",
-		Help:      "Total number of entry flow failures.",
-	})
-	exitFailed := prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: promNamespace,
-		Name:      "exit_failed_total",
-		Help:      "Total number of exit flow failures.",
-	})
-	killEngaged := prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: promNamespace,
-		Name:      "kill_switch_engaged_total",
-		Help:      "Total number of connectivity kill switch engagements.",
-	})
-	killRestored := prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: promNamespace,
-		Name:      "kill_switch_restored_total",
-		Help:      "Total number of connectivity kill switch recoveries.",
-	})
-
-	registry.MustRegister(ordersPlaced, ordersFailed, entryFailed, exitFailed, killEngaged, killRestored)
+	accounts := make(map[string]*Prometheus, len(names))
+	for _, name := range names {
+		accounts[name] = newPrometheus(registry, prometheus.Labels{AccountLabel: name})
+	}
+	return &PrometheusAccounts{registry: registry, accounts: accounts}
+}
+
+// Metrics returns the metric set labeled with account, or nil if the account
+// was not registered.
+func (p *PrometheusAccounts) Metrics(account string) *Metrics {
+	prom, ok := p.accounts[account]
+	if !ok {
+		return nil
+	}
+	return prom.Metrics
+}
+
+func (p *PrometheusAccounts) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+func newPrometheus(registry *prometheus.Registry, labels prometheus.Labels) *Prometheus {
+	ordersPlaced := newPromCounter(defOrdersPlaced, labels)
+	ordersFailed := newPromCounter(defOrdersFailed, labels)
+	entryFailed := newPromCounter(defEntryFailed, labels)
+	exitFailed := newPromCounter(defExitFailed, labels)
+	killEngaged := newPromCounter(defKillEngaged, labels)
+	killRestored := newPromCounter(defKillRestored, labels)
+	restShed := newPromCounter(defRESTShed, labels)
+	restLeft := newPromGauge(defRESTLeft, labels)
+	restLatency := newPromHistogramVec(defRESTLatency, labels)
+	restRetries := newPromCounterVec(defRESTRetries, labels)
+	rollbacks := newPromCounter(defRollbacks, labels)
+	rollbackFail := newPromCounter(defRollbackFail, labels)
+	rollbackRetry := newPromCounter(defRollbackRetry, labels)
+	foreign := newPromCounter(defForeign, labels)
+	postOnly := newPromCounter(defPostOnlyCross, labels)
+	riskAction := newPromGauge(defRiskAction, labels)
+	riskViolation := newPromCounter(defRiskViolation, labels)
+	circuitOpened := newPromCounter(defCircuitOpened, labels)
+	accountDrift := newPromCounter(defAccountDrift, labels)
+	nonceRejected := newPromCounter(defNonceRejected, labels)
+	tsWritten := newPromCounter(defTSWritten, labels)
+	tsDropped := newPromCounter(defTSDropped, labels)
+	tsLatency := newPromGauge(defTSLatency, labels)
+	killActive := newPromGauge(defKillActive, labels)
+	paused := newPromGauge(defPaused, labels)
+	ticksSkipped := newPromCounterVec(defTicksSkipped, labels)
+	fundingAPR := newPromGauge(defFundingAPR, labels)
+	perpSlippage := newPromGauge(defPerpSlippage, labels)
+	spotSlippage := newPromGauge(defSpotSlippage, labels)
+	iocPriceBps := newPromGauge(defIOCPriceBps, labels)
+	orphanOrders := newPromCounter(defOrphanOrders, labels)
+	transitions := newPromCounterVec(defTransitions, labels)
+	stateSeconds := newPromGauge(defStateSeconds, labels)
+	transfers := newPromCounterVec(defUSDCTransfers, labels)
+	transferred := newPromCounterVec(defUSDCMoved, labels)
+	for _, reason := range TickSkipReasons {
+		ticksSkipped.WithLabelValues(reason)
+	}
+	for _, direction := range TransferDirections {
+		transfers.WithLabelValues(direction)
+		transferred.WithLabelValues(direction)
+	}
+
+	registry.MustRegister(ordersPlaced, ordersFailed, entryFailed, exitFailed, killEngaged, killRestored, restShed, restLeft, restLatency, restRetries, rollbacks, rollbackFail, rollbackRetry, foreign, postOnly, riskAction, riskViolation, circuitOpened, accountDrift, nonceRejected, tsWritten, tsDropped, tsLatency, killActive, paused, ticksSkipped, fundingAPR, perpSlippage, spotSlippage, iocPriceBps, orphanOrders, transitions, stateSeconds, transfers, transferred)
 
 	m := &Metrics{
 		OrdersPlaced:       promCounter{ordersPlaced},
@@ -71,20 +180,94 @@ func NewPrometheus() *Prometheus {
 		ExitFailed:         promCounter{exitFailed},
 		KillSwitchEngaged:  promCounter{killEngaged},
 		KillSwitchRestored: promCounter{killRestored},
+		RESTWeightShed:     promCounter{restShed},
+		RESTWeightLeft:     restLeft,
+		RESTLatency:        promObserverVec{restLatency},
+		RESTRetries:        promCounterVec{restRetries},
+		Rollbacks:          promCounter{rollbacks},
+		RollbacksFailed:    promCounter{rollbackFail},
+		RollbackRetries:    promCounter{rollbackRetry},
+		ForeignActivity:    promCounter{foreign},
+		PostOnlyRejected:   promCounter{postOnly},
+		RiskAction:         riskAction,
+		RiskViolations:     promCounter{riskViolation},
+		CircuitOpened:      promCounter{circuitOpened},
+		AccountDrift:       promCounter{accountDrift},
+		NonceRejected:      promCounter{nonceRejected},
+		TimescaleWritten:   promCounter{tsWritten},
+		TimescaleDropped:   promCounter{tsDropped},
+		TimescaleLatency:   tsLatency,
+		KillSwitchActive:   killActive,
+		Paused:             paused,
+		TicksSkipped:       promCounterVec{ticksSkipped},
+		FundingAPR:         fundingAPR,
+		PerpSlippageBps:    perpSlippage,
+		SpotSlippageBps:    spotSlippage,
+		IOCPriceBps:        iocPriceBps,
+		OrphanOrders:       promCounter{orphanOrders},
+		StateTransitions:   promTransitionCounter{transitions},
+		StateSeconds:       stateSeconds,
+		USDCTransfers:      promCounterVec{transfers},
+		USDCTransferredUSD: promAmountVec{transferred},
 	}
 
 	return &Prometheus{
-		Metrics:      m,
-		registry:     registry,
-		ordersPlaced: ordersPlaced,
-		ordersFailed: ordersFailed,
-		entryFailed:  entryFailed,
-		exitFailed:   exitFailed,
-		killEngaged:  killEngaged,
-		killRestored: killRestored,
+		Metrics:       m,
+		registry:      registry,
+		ordersPlaced:  ordersPlaced,
+		ordersFailed:  ordersFailed,
+		entryFailed:   entryFailed,
+		exitFailed:    exitFailed,
+		killEngaged:   killEngaged,
+		killRestored:  killRestored,
+		restShed:      restShed,
+		restLeft:      restLeft,
+		restLatency:   restLatency,
+		restRetries:   restRetries,
+		rollbacks:     rollbacks,
+		rollbackFail:  rollbackFail,
+		rollbackRetry: rollbackRetry,
+		foreign:       foreign,
+		postOnly:      postOnly,
+		riskAction:    riskAction,
+		riskViolation: riskViolation,
+		circuitOpened: circuitOpened,
+		accountDrift:  accountDrift,
+		nonceRejected: nonceRejected,
+		tsWritten:     tsWritten,
+		tsDropped:     tsDropped,
+		tsLatency:     tsLatency,
+		killActive:    killActive,
+		paused:        paused,
+		ticksSkipped:  ticksSkipped,
+		fundingAPR:    fundingAPR,
+		perpSlippage:  perpSlippage,
+		spotSlippage:  spotSlippage,
+		iocPriceBps:   iocPriceBps,
+		orphanOrders:  orphanOrders,
+		transitions:   transitions,
+		stateSeconds:  stateSeconds,
+		transfers:     transfers,
+		transferred:   transferred,
 	}
 }
 
 func (p *Prometheus) Handler() http.Handler {
 	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
 }
+
+func newPromCounter(def Definition, labels prometheus.Labels) prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{Name: def.Name, Help: def.Help, ConstLabels: labels})
+}
+
+func newPromGauge(def Definition, labels prometheus.Labels) prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{Name: def.Name, Help: def.Help, ConstLabels: labels})
+}
+
+func newPromCounterVec(def Definition, labels prometheus.Labels) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: def.Name, Help: def.Help, ConstLabels: labels}, def.Labels)
+}
+
+func newPromHistogramVec(def Definition, labels prometheus.Labels) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: def.Name, Help: def.Help, ConstLabels: labels, Buckets: def.Buckets}, def.Labels)
+}
@@ -1,6 +1,10 @@
 package metrics
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -15,6 +19,36 @@ func TestPrometheusCounters(t *testing.T) {
 	prom.Metrics.ExitFailed.Inc()
 	prom.Metrics.KillSwitchEngaged.Inc()
 	prom.Metrics.KillSwitchRestored.Inc()
+	prom.Metrics.RESTWeightShed.Inc()
+	prom.Metrics.RESTWeightLeft.Set(42)
+	prom.Metrics.Rollbacks.Inc()
+	prom.Metrics.RollbacksFailed.Inc()
+	prom.Metrics.RollbackRetries.Inc()
+	prom.Metrics.ForeignActivity.Inc()
+	prom.Metrics.PostOnlyRejected.Inc()
+	prom.Metrics.RiskAction.Set(3)
+	prom.Metrics.RiskViolations.Inc()
+	prom.Metrics.CircuitOpened.Inc()
+	prom.Metrics.AccountDrift.Inc()
+	prom.Metrics.NonceRejected.Inc()
+	prom.Metrics.TimescaleWritten.Inc()
+	prom.Metrics.TimescaleDropped.Inc()
+	prom.Metrics.TimescaleLatency.Set(0.25)
+	prom.Metrics.KillSwitchActive.Set(1)
+	prom.Metrics.Paused.Set(1)
+	prom.Metrics.TicksSkipped.With(SkipPaused).Inc()
+	prom.Metrics.TicksSkipped.With(SkipPaused).Inc()
+	prom.Metrics.FundingAPR.Set(0.11)
+	prom.Metrics.PerpSlippageBps.Set(1.5)
+	prom.Metrics.SpotSlippageBps.Set(-0.5)
+	prom.Metrics.IOCPriceBps.Set(8)
+	prom.Metrics.OrphanOrders.Inc()
+	prom.Metrics.RESTLatency.With("l2Book").Observe(0.2)
+	prom.Metrics.RESTRetries.With("l2Book").Inc()
+	prom.Metrics.StateTransitions.With("IDLE", "ENTER", "ENTER").Inc()
+	prom.Metrics.StateSeconds.Set(90)
+	prom.Metrics.USDCTransfers.With(TransferToSpot).Inc()
+	prom.Metrics.USDCTransferredUSD.With(TransferToSpot).Add(12.5)
 
 	assertCounter(t, prom.ordersPlaced, 1)
 	assertCounter(t, prom.ordersFailed, 1)
@@ -22,6 +56,59 @@ func TestPrometheusCounters(t *testing.T) {
 	assertCounter(t, prom.exitFailed, 1)
 	assertCounter(t, prom.killEngaged, 1)
 	assertCounter(t, prom.killRestored, 1)
+	assertCounter(t, prom.restShed, 1)
+	assertCounter(t, prom.rollbacks, 1)
+	assertCounter(t, prom.rollbackFail, 1)
+	assertCounter(t, prom.rollbackRetry, 1)
+	assertCounter(t, prom.foreign, 1)
+	assertCounter(t, prom.postOnly, 1)
+	assertCounter(t, prom.riskViolation, 1)
+	assertCounter(t, prom.circuitOpened, 1)
+	assertCounter(t, prom.accountDrift, 1)
+	assertCounter(t, prom.nonceRejected, 1)
+	assertCounter(t, prom.tsWritten, 1)
+	assertCounter(t, prom.tsDropped, 1)
+	assertCounter(t, prom.orphanOrders, 1)
+	if got := testutil.ToFloat64(prom.restLeft); got != 42 {
+		t.Fatalf("expected rest weight remaining 42, got %v", got)
+	}
+	if got := testutil.ToFloat64(prom.riskAction); got != 3 {
+		t.Fatalf("expected risk action 3, got %v", got)
+	}
+	if got := testutil.ToFloat64(prom.tsLatency); got != 0.25 {
+		t.Fatalf("expected timescale write latency 0.25, got %v", got)
+	}
+	if got := testutil.ToFloat64(prom.killActive); got != 1 {
+		t.Fatalf("expected kill switch active 1, got %v", got)
+	}
+	if got := testutil.ToFloat64(prom.paused); got != 1 {
+		t.Fatalf("expected paused 1, got %v", got)
+	}
+	if got := testutil.ToFloat64(prom.fundingAPR); got != 0.11 {
+		t.Fatalf("expected funding apr 0.11, got %v", got)
+	}
+	if got := testutil.ToFloat64(prom.perpSlippage); got != 1.5 {
+		t.Fatalf("expected perp slippage 1.5, got %v", got)
+	}
+	if got := testutil.ToFloat64(prom.spotSlippage); got != -0.5 {
+		t.Fatalf("expected spot slippage -0.5, got %v", got)
+	}
+	if got := testutil.ToFloat64(prom.iocPriceBps); got != 8 {
+		t.Fatalf("expected ioc price bps 8, got %v", got)
+	}
+	assertCounter(t, prom.ticksSkipped.WithLabelValues(SkipPaused), 2)
+	assertCounter(t, prom.ticksSkipped.WithLabelValues(SkipRisk), 0)
+	assertCounter(t, prom.restRetries.WithLabelValues("l2Book"), 1)
+	assertCounter(t, prom.transitions.WithLabelValues("IDLE", "ENTER", "ENTER"), 1)
+	assertCounter(t, prom.transfers.WithLabelValues(TransferToSpot), 1)
+	assertCounter(t, prom.transferred.WithLabelValues(TransferToSpot), 12.5)
+	assertCounter(t, prom.transferred.WithLabelValues(TransferToPerp), 0)
+	if got := testutil.ToFloat64(prom.stateSeconds); got != 90 {
+		t.Fatalf("expected state seconds 90, got %v", got)
+	}
+	if got := testutil.CollectAndCount(prom.restLatency); got != 1 {
+		t.Fatalf("expected one rest latency series, got %d", got)
+	}
 }
 
 func assertCounter(t *testing.T, counter prometheus.Counter, expected float64) {
@@ -30,3 +117,80 @@ func assertCounter(t *testing.T, counter prometheus.Counter, expected float64) {
 		t.Fatalf("expected %v, got %v", expected, got)
 	}
 }
+
+func TestCatalogMatchesRegistry(t *testing.T) {
+	prom := NewPrometheus()
+	// Endpoint-labelled series only exist once a request was made.
+	prom.Metrics.RESTLatency.With("allMids").Observe(0.1)
+	prom.Metrics.RESTRetries.With("allMids").Inc()
+	prom.Metrics.StateTransitions.With("IDLE", "ENTER", "ENTER").Inc()
+	families, err := prom.registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	registered := make(map[string]string, len(families))
+	for _, family := range families {
+		registered[family.GetName()] = strings.ToLower(family.GetType().String())
+	}
+	catalog := Catalog()
+	if len(catalog) != len(registered) {
+		t.Fatalf("expected %d catalog entries, got %d", len(registered), len(catalog))
+	}
+	for _, def := range catalog {
+		typ, ok := registered[def.Name]
+		if !ok {
+			t.Fatalf("catalog metric %s is not registered", def.Name)
+		}
+		if typ != def.Type {
+			t.Fatalf("expected %s to be %s, got %s", def.Name, typ, def.Type)
+		}
+		if def.Help == "" {
+			t.Fatalf("expected help for %s", def.Name)
+		}
+	}
+}
+
+func TestCatalogHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	CatalogHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics-catalog", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var body struct {
+		Metrics []Definition `json:"metrics"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Metrics) != len(definitions) || body.Metrics[0].Name != "hl_carry_bot_orders_placed_total" {
+		t.Fatalf("unexpected catalog: %+v", body.Metrics)
+	}
+
+	rec = httptest.NewRecorder()
+	CatalogHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/metrics-catalog", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestPrometheusAccountsLabelSeries(t *testing.T) {
+	prom := NewPrometheusAccounts([]string{"main", "sub"})
+	prom.Metrics("main").OrdersPlaced.Inc()
+	prom.Metrics("sub").OrdersPlaced.Inc()
+	prom.Metrics("sub").OrdersPlaced.Inc()
+	if prom.Metrics("missing") != nil {
+		t.Fatalf("expected nil metrics for unknown account")
+	}
+
+	rec := httptest.NewRecorder()
+	prom.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`hl_carry_bot_orders_placed_total{account="main"} 1`,
+		`hl_carry_bot_orders_placed_total{account="sub"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in metrics output:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Package backend opens the state store selected by state.backend.
+package backend
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"hl-carry-bot/internal/config"
+	"hl-carry-bot/internal/state"
+	"hl-carry-bot/internal/state/postgres"
+	"hl-carry-bot/internal/state/sqlite"
+)
+
+// Open returns the SQLite store at state.sqlite_path, creating its
+// directory, or the Postgres store on timescale.dsn for state.namespace.
+func Open(cfg *config.Config) (state.Store, error) {
+	if cfg == nil {
+		return nil, errors.New("config is required")
+	}
+	switch cfg.State.Backend {
+	case "postgres":
+		return postgres.New(cfg.Timescale.DSN, cfg.Timescale.Schema, cfg.State.Namespace)
+	case "", "sqlite":
+		if err := os.MkdirAll(filepath.Dir(cfg.State.SQLitePath), 0o755); err != nil {
+			return nil, err
+		}
+		return sqlite.New(cfg.State.SQLitePath)
+	default:
+		return nil, errors.New("unknown state backend " + cfg.State.Backend)
+	}
+}
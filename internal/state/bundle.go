@@ -0,0 +1,199 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BundleVersion is the format version written by ExportBundle.
+const BundleVersion = 1
+
+// ImportMarkerKey holds the ImportMarker left by ImportBundle until the bot
+// has started on the imported state once.
+const ImportMarkerKey = "state:import"
+
+// nonceKeyPrefix is the prefix of the exchange client's nonce keys.
+const nonceKeyPrefix = "exchange:nonce:"
+
+// Bundle is a portable copy of a state store: every key (strategy snapshot,
+// nonces, operator offsets, cursors) and the journals.
+type Bundle struct {
+	Version         int                     `json:"version"`
+	Host            string                  `json:"host"`
+	ExportedAtMS    int64                   `json:"exported_at_ms"`
+	KV              map[string]string       `json:"kv"`
+	Fills           []FillRecord            `json:"fills,omitempty"`
+	Funding         []FundingRecord         `json:"funding,omitempty"`
+	ForeignActivity []ForeignActivityRecord `json:"foreign_activity,omitempty"`
+	Lifecycle       []LifecycleRecord       `json:"lifecycle,omitempty"`
+	Transfers       []TransferRecord        `json:"transfers,omitempty"`
+}
+
+// ImportMarker records where imported state came from. SourceStopped is set
+// when the operator confirmed the source bot was stopped before the export,
+// so no nonce newer than the bundle's can have been signed.
+type ImportMarker struct {
+	Host          string `json:"host"`
+	ExportedAtMS  int64  `json:"exported_at_ms"`
+	ImportedAtMS  int64  `json:"imported_at_ms"`
+	SourceStopped bool   `json:"source_stopped"`
+}
+
+// KVLister is a store that can list every key.
+type KVLister interface {
+	Entries(ctx context.Context) (map[string]string, error)
+}
+
+// ExportBundle reads every key and journal row from store. Journals the
+// store does not implement are left empty.
+func ExportBundle(ctx context.Context, store Store, host string, now time.Time) (Bundle, error) {
+	lister, ok := store.(KVLister)
+	if !ok {
+		return Bundle{}, errors.New("state store cannot list its keys")
+	}
+	kv, err := lister.Entries(ctx)
+	if err != nil {
+		return Bundle{}, err
+	}
+	delete(kv, ImportMarkerKey)
+	bundle := Bundle{Version: BundleVersion, Host: host, ExportedAtMS: now.UnixMilli(), KV: kv}
+	if journal, ok := store.(Journal); ok {
+		if bundle.Fills, err = journal.Fills(ctx, 0, 0); err != nil {
+			return Bundle{}, err
+		}
+		if bundle.Funding, err = journal.FundingPayments(ctx, 0, 0); err != nil {
+			return Bundle{}, err
+		}
+	}
+	if journal, ok := store.(ForeignJournal); ok {
+		if bundle.ForeignActivity, err = journal.ForeignActivity(ctx, 0, 0); err != nil {
+			return Bundle{}, err
+		}
+	}
+	if journal, ok := store.(LifecycleJournal); ok {
+		if bundle.Lifecycle, err = journal.Lifecycle(ctx, 0, 0); err != nil {
+			return Bundle{}, err
+		}
+	}
+	if journal, ok := store.(TransferJournal); ok {
+		if bundle.Transfers, err = journal.Transfers(ctx, 0, 0); err != nil {
+			return Bundle{}, err
+		}
+	}
+	return bundle, nil
+}
+
+// ImportBundle writes bundle into store and leaves an ImportMarker. Keys
+// are overwritten, except that a nonce never moves backwards; journal rows
+// already present are skipped.
+func ImportBundle(ctx context.Context, store Store, bundle Bundle, sourceStopped bool, now time.Time) error {
+	if bundle.Version != BundleVersion {
+		return fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	for key, value := range bundle.KV {
+		if key == ImportMarkerKey {
+			continue
+		}
+		if strings.HasPrefix(key, nonceKeyPrefix) {
+			current, ok, err := store.Get(ctx, key)
+			if err != nil {
+				return err
+			}
+			if ok && parseNonce(current) > parseNonce(value) {
+				continue
+			}
+		}
+		if err := store.Set(ctx, key, value); err != nil {
+			return err
+		}
+	}
+	if err := importJournals(ctx, store, bundle); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(ImportMarker{
+		Host:          bundle.Host,
+		ExportedAtMS:  bundle.ExportedAtMS,
+		ImportedAtMS:  now.UnixMilli(),
+		SourceStopped: sourceStopped,
+	})
+	if err != nil {
+		return err
+	}
+	return store.Set(ctx, ImportMarkerKey, string(payload))
+}
+
+func importJournals(ctx context.Context, store Store, bundle Bundle) error {
+	if len(bundle.Fills)+len(bundle.Funding) > 0 {
+		journal, ok := store.(Journal)
+		if !ok {
+			return errors.New("state store does not support the accounting journal")
+		}
+		for _, rec := range bundle.Fills {
+			if _, err := journal.RecordFill(ctx, rec); err != nil {
+				return err
+			}
+		}
+		for _, rec := range bundle.Funding {
+			if _, err := journal.RecordFunding(ctx, rec); err != nil {
+				return err
+			}
+		}
+	}
+	if len(bundle.ForeignActivity) > 0 {
+		journal, ok := store.(ForeignJournal)
+		if !ok {
+			return errors.New("state store does not support the foreign activity journal")
+		}
+		for _, rec := range bundle.ForeignActivity {
+			if _, err := journal.RecordForeignActivity(ctx, rec); err != nil {
+				return err
+			}
+		}
+	}
+	if len(bundle.Lifecycle) > 0 {
+		journal, ok := store.(LifecycleJournal)
+		if !ok {
+			return errors.New("state store does not support the lifecycle journal")
+		}
+		for _, rec := range bundle.Lifecycle {
+			if _, err := journal.RecordLifecycle(ctx, rec); err != nil {
+				return err
+			}
+		}
+	}
+	if len(bundle.Transfers) > 0 {
+		journal, ok := store.(TransferJournal)
+		if !ok {
+			return errors.New("state store does not support the transfer journal")
+		}
+		for _, rec := range bundle.Transfers {
+			if _, err := journal.RecordTransfer(ctx, rec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadImportMarker returns the marker left by ImportBundle, if any.
+func LoadImportMarker(ctx context.Context, store Store) (ImportMarker, bool, error) {
+	raw, ok, err := store.Get(ctx, ImportMarkerKey)
+	if err != nil || !ok {
+		return ImportMarker{}, false, err
+	}
+	var marker ImportMarker
+	if err := json.Unmarshal([]byte(raw), &marker); err != nil {
+		return ImportMarker{}, false, err
+	}
+	return marker, true, nil
+}
+
+func parseNonce(raw string) uint64 {
+	n, _ := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+	return n
+}
@@ -0,0 +1,140 @@
+package state
+
+import "context"
+
+// FillRecord is a single execution as stored in the accounting journal.
+type FillRecord struct {
+	ID        string
+	OrderID   string
+	Asset     string
+	Side      string
+	Size      float64
+	Price     float64
+	Fee       float64
+	ClosedPnL float64
+	TimeMS    int64
+	Hash      string
+}
+
+// FundingRecord is a single funding payment (USDC, signed) as stored in the
+// accounting journal.
+type FundingRecord struct {
+	ID     string
+	Asset  string
+	Amount float64
+	Rate   float64
+	TimeMS int64
+}
+
+// Journal is the append-only accounting store. Record* calls are idempotent
+// on ID and report whether the row was new.
+type Journal interface {
+	RecordFill(ctx context.Context, fill FillRecord) (bool, error)
+	RecordFunding(ctx context.Context, funding FundingRecord) (bool, error)
+	Fills(ctx context.Context, startMS, endMS int64) ([]FillRecord, error)
+	FundingPayments(ctx context.Context, startMS, endMS int64) ([]FundingRecord, error)
+}
+
+// ForeignActivityRecord is an order update or fill on the account that this
+// bot instance did not place.
+type ForeignActivityRecord struct {
+	ID         string
+	Kind       string
+	OrderID    string
+	Cloid      string
+	Asset      string
+	Side       string
+	Status     string
+	Size       float64
+	Price      float64
+	TimeMS     int64
+	DetectedMS int64
+}
+
+// ForeignJournal records foreign account activity for audit. Records are
+// idempotent on ID.
+type ForeignJournal interface {
+	RecordForeignActivity(ctx context.Context, rec ForeignActivityRecord) (bool, error)
+	ForeignActivity(ctx context.Context, startMS, endMS int64) ([]ForeignActivityRecord, error)
+}
+
+// Lifecycle events recorded for compounding add-ons and partial reduces.
+const (
+	LifecycleCompoundStart  = "compound_start"
+	LifecycleCompoundFilled = "compound_filled"
+	LifecycleCompoundFailed = "compound_failed"
+	LifecycleReduceStart    = "reduce_start"
+	LifecycleReduceFilled   = "reduce_filled"
+	LifecycleReduceFailed   = "reduce_failed"
+)
+
+// LifecycleRecord is one position lifecycle event. Attempt groups the events
+// of a single add-on or reduce; FundingUSD is the accrued funding that
+// triggered an add-on, and Detail says what triggered a reduce.
+type LifecycleRecord struct {
+	ID          string
+	Attempt     string
+	Event       string
+	PerpAsset   string
+	SpotAsset   string
+	NotionalUSD float64
+	FundingUSD  float64
+	SpotFilled  float64
+	PerpFilled  float64
+	Detail      string
+	TimeMS      int64
+}
+
+// LifecycleJournal records position lifecycle events. Records are idempotent
+// on ID.
+type LifecycleJournal interface {
+	RecordLifecycle(ctx context.Context, rec LifecycleRecord) (bool, error)
+	Lifecycle(ctx context.Context, startMS, endMS int64) ([]LifecycleRecord, error)
+}
+
+// Transfer kinds.
+const (
+	TransferUSDClass       = "usd_class"
+	TransferVault          = "vault"
+	TransferIsolatedMargin = "isolated_margin"
+)
+
+// TransferRecord is one USDC movement the bot made: between the spot and
+// perp wallets (Direction "to_perp"/"to_spot"), into and out of a vault
+// ("deposit"/"withdraw", Destination the vault address), or onto an isolated
+// perp position ("top_up", Destination the perp asset).
+type TransferRecord struct {
+	ID          string
+	Kind        string
+	Direction   string
+	Destination string
+	Amount      float64
+	TimeMS      int64
+}
+
+// TransferJournal records the bot's transfers. Records are idempotent on ID.
+type TransferJournal interface {
+	RecordTransfer(ctx context.Context, rec TransferRecord) (bool, error)
+	Transfers(ctx context.Context, startMS, endMS int64) ([]TransferRecord, error)
+}
+
+// DecisionRecord is one strategy tick: the resulting state, decision and
+// action, the reasons that held it back, and the tick inputs as JSON.
+type DecisionRecord struct {
+	ID       string
+	State    string
+	Decision string
+	Action   string
+	Reasons  string
+	Inputs   string
+	TimeMS   int64
+}
+
+// DecisionJournal records strategy tick decisions. Records are idempotent on
+// ID; PruneDecisions deletes records older than beforeMS and reports how
+// many were removed.
+type DecisionJournal interface {
+	RecordDecision(ctx context.Context, rec DecisionRecord) (bool, error)
+	Decisions(ctx context.Context, startMS, endMS int64) ([]DecisionRecord, error)
+	PruneDecisions(ctx context.Context, beforeMS int64) (int64, error)
+}
@@ -0,0 +1,399 @@
+// Package postgres is a PostgreSQL state store for deployments without a
+// persistent local disk. Every row carries a namespace so several accounts
+// can share one database; tables are prefixed state_ so they can live in the
+// Timescale schema next to the telemetry tables.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/state"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+type Store struct {
+	db        *sql.DB
+	schema    string
+	namespace string
+}
+
+// New connects to dsn and creates the state tables in schema (default
+// public). Rows are scoped to namespace (default "default").
+func New(dsn, schema, namespace string) (*Store, error) {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		return nil, errors.New("postgres dsn is required")
+	}
+	schema = strings.TrimSpace(schema)
+	if schema == "" {
+		schema = "public"
+	}
+	namespace = strings.TrimSpace(namespace)
+	if namespace == "" {
+		namespace = "default"
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(4)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	s := &Store{db: db, schema: schema, namespace: namespace}
+	if err := s.initSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS %[1]s.state_kv (
+		namespace TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (namespace, key)
+	)`,
+	`CREATE TABLE IF NOT EXISTS %[1]s.state_fills (
+		namespace TEXT NOT NULL,
+		id TEXT NOT NULL,
+		oid TEXT NOT NULL,
+		coin TEXT NOT NULL,
+		side TEXT NOT NULL,
+		sz DOUBLE PRECISION NOT NULL,
+		px DOUBLE PRECISION NOT NULL,
+		fee DOUBLE PRECISION NOT NULL,
+		closed_pnl DOUBLE PRECISION NOT NULL,
+		time_ms BIGINT NOT NULL,
+		hash TEXT NOT NULL,
+		PRIMARY KEY (namespace, id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS state_fills_time_ms ON %[1]s.state_fills (namespace, time_ms)`,
+	`CREATE TABLE IF NOT EXISTS %[1]s.state_funding_payments (
+		namespace TEXT NOT NULL,
+		id TEXT NOT NULL,
+		coin TEXT NOT NULL,
+		usdc DOUBLE PRECISION NOT NULL,
+		rate DOUBLE PRECISION NOT NULL,
+		time_ms BIGINT NOT NULL,
+		PRIMARY KEY (namespace, id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS state_funding_payments_time_ms ON %[1]s.state_funding_payments (namespace, time_ms)`,
+	`CREATE TABLE IF NOT EXISTS %[1]s.state_foreign_activity (
+		namespace TEXT NOT NULL,
+		id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		oid TEXT NOT NULL,
+		cloid TEXT NOT NULL,
+		coin TEXT NOT NULL,
+		side TEXT NOT NULL,
+		status TEXT NOT NULL,
+		sz DOUBLE PRECISION NOT NULL,
+		px DOUBLE PRECISION NOT NULL,
+		time_ms BIGINT NOT NULL,
+		detected_ms BIGINT NOT NULL,
+		PRIMARY KEY (namespace, id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS state_foreign_activity_time_ms ON %[1]s.state_foreign_activity (namespace, time_ms)`,
+	`CREATE TABLE IF NOT EXISTS %[1]s.state_lifecycle_events (
+		namespace TEXT NOT NULL,
+		id TEXT NOT NULL,
+		attempt TEXT NOT NULL,
+		event TEXT NOT NULL,
+		perp_coin TEXT NOT NULL,
+		spot_coin TEXT NOT NULL,
+		notional_usd DOUBLE PRECISION NOT NULL,
+		funding_usd DOUBLE PRECISION NOT NULL,
+		spot_filled DOUBLE PRECISION NOT NULL,
+		perp_filled DOUBLE PRECISION NOT NULL,
+		detail TEXT NOT NULL,
+		time_ms BIGINT NOT NULL,
+		PRIMARY KEY (namespace, id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS state_lifecycle_events_time_ms ON %[1]s.state_lifecycle_events (namespace, time_ms)`,
+	`CREATE TABLE IF NOT EXISTS %[1]s.state_transfers (
+		namespace TEXT NOT NULL,
+		id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		destination TEXT NOT NULL,
+		amount DOUBLE PRECISION NOT NULL,
+		time_ms BIGINT NOT NULL,
+		PRIMARY KEY (namespace, id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS state_transfers_time_ms ON %[1]s.state_transfers (namespace, time_ms)`,
+	`CREATE TABLE IF NOT EXISTS %[1]s.state_decisions (
+		namespace TEXT NOT NULL,
+		id TEXT NOT NULL,
+		state TEXT NOT NULL,
+		decision TEXT NOT NULL,
+		action TEXT NOT NULL,
+		reasons TEXT NOT NULL,
+		inputs TEXT NOT NULL,
+		time_ms BIGINT NOT NULL,
+		PRIMARY KEY (namespace, id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS state_decisions_time_ms ON %[1]s.state_decisions (namespace, time_ms)`,
+}
+
+func (s *Store) initSchema(ctx context.Context) error {
+	if s.schema != "public" {
+		if _, err := s.db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+s.schema); err != nil {
+			return err
+		}
+	}
+	for _, stmt := range schema {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf(stmt, s.schema)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// q qualifies the state_ tables in query with the schema.
+func (s *Store) q(query string) string {
+	return strings.ReplaceAll(query, "state_", s.schema+".state_")
+}
+
+func (s *Store) Get(ctx context.Context, key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, s.q(`SELECT value FROM state_kv WHERE namespace = $1 AND key = $2`), s.namespace, key).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func (s *Store) Set(ctx context.Context, key, value string) error {
+	_, err := s.db.ExecContext(ctx, s.q(`INSERT INTO state_kv (namespace, key, value) VALUES ($1, $2, $3) ON CONFLICT (namespace, key) DO UPDATE SET value = EXCLUDED.value`), s.namespace, key, value)
+	return err
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.q(`DELETE FROM state_kv WHERE namespace = $1 AND key = $2`), s.namespace, key)
+	return err
+}
+
+func (s *Store) Entries(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, s.q(`SELECT key, value FROM state_kv WHERE namespace = $1`), s.namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		out[key] = value
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordFill(ctx context.Context, fill state.FillRecord) (bool, error) {
+	if fill.ID == "" {
+		return false, errors.New("fill id is required")
+	}
+	res, err := s.db.ExecContext(ctx, s.q(`INSERT INTO state_fills (namespace, id, oid, coin, side, sz, px, fee, closed_pnl, time_ms, hash) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT DO NOTHING`),
+		s.namespace, fill.ID, fill.OrderID, fill.Asset, fill.Side, fill.Size, fill.Price, fill.Fee, fill.ClosedPnL, fill.TimeMS, fill.Hash)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) RecordFunding(ctx context.Context, funding state.FundingRecord) (bool, error) {
+	if funding.ID == "" {
+		return false, errors.New("funding id is required")
+	}
+	res, err := s.db.ExecContext(ctx, s.q(`INSERT INTO state_funding_payments (namespace, id, coin, usdc, rate, time_ms) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`),
+		s.namespace, funding.ID, funding.Asset, funding.Amount, funding.Rate, funding.TimeMS)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) Fills(ctx context.Context, startMS, endMS int64) ([]state.FillRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.q(`SELECT id, oid, coin, side, sz, px, fee, closed_pnl, time_ms, hash FROM state_fills WHERE namespace = $1 AND time_ms >= $2 AND ($3::BIGINT <= 0 OR time_ms <= $3::BIGINT) ORDER BY time_ms, id`), s.namespace, startMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.FillRecord
+	for rows.Next() {
+		var fill state.FillRecord
+		if err := rows.Scan(&fill.ID, &fill.OrderID, &fill.Asset, &fill.Side, &fill.Size, &fill.Price, &fill.Fee, &fill.ClosedPnL, &fill.TimeMS, &fill.Hash); err != nil {
+			return nil, err
+		}
+		out = append(out, fill)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) FundingPayments(ctx context.Context, startMS, endMS int64) ([]state.FundingRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.q(`SELECT id, coin, usdc, rate, time_ms FROM state_funding_payments WHERE namespace = $1 AND time_ms >= $2 AND ($3::BIGINT <= 0 OR time_ms <= $3::BIGINT) ORDER BY time_ms, id`), s.namespace, startMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.FundingRecord
+	for rows.Next() {
+		var funding state.FundingRecord
+		if err := rows.Scan(&funding.ID, &funding.Asset, &funding.Amount, &funding.Rate, &funding.TimeMS); err != nil {
+			return nil, err
+		}
+		out = append(out, funding)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordForeignActivity(ctx context.Context, rec state.ForeignActivityRecord) (bool, error) {
+	if rec.ID == "" {
+		return false, errors.New("foreign activity id is required")
+	}
+	res, err := s.db.ExecContext(ctx, s.q(`INSERT INTO state_foreign_activity (namespace, id, kind, oid, cloid, coin, side, status, sz, px, time_ms, detected_ms) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT DO NOTHING`),
+		s.namespace, rec.ID, rec.Kind, rec.OrderID, rec.Cloid, rec.Asset, rec.Side, rec.Status, rec.Size, rec.Price, rec.TimeMS, rec.DetectedMS)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) ForeignActivity(ctx context.Context, startMS, endMS int64) ([]state.ForeignActivityRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.q(`SELECT id, kind, oid, cloid, coin, side, status, sz, px, time_ms, detected_ms FROM state_foreign_activity WHERE namespace = $1 AND time_ms >= $2 AND ($3::BIGINT <= 0 OR time_ms <= $3::BIGINT) ORDER BY time_ms, id`), s.namespace, startMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.ForeignActivityRecord
+	for rows.Next() {
+		var rec state.ForeignActivityRecord
+		if err := rows.Scan(&rec.ID, &rec.Kind, &rec.OrderID, &rec.Cloid, &rec.Asset, &rec.Side, &rec.Status, &rec.Size, &rec.Price, &rec.TimeMS, &rec.DetectedMS); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordLifecycle(ctx context.Context, rec state.LifecycleRecord) (bool, error) {
+	if rec.ID == "" {
+		return false, errors.New("lifecycle event id is required")
+	}
+	res, err := s.db.ExecContext(ctx, s.q(`INSERT INTO state_lifecycle_events (namespace, id, attempt, event, perp_coin, spot_coin, notional_usd, funding_usd, spot_filled, perp_filled, detail, time_ms) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT DO NOTHING`),
+		s.namespace, rec.ID, rec.Attempt, rec.Event, rec.PerpAsset, rec.SpotAsset, rec.NotionalUSD, rec.FundingUSD, rec.SpotFilled, rec.PerpFilled, rec.Detail, rec.TimeMS)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) Lifecycle(ctx context.Context, startMS, endMS int64) ([]state.LifecycleRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.q(`SELECT id, attempt, event, perp_coin, spot_coin, notional_usd, funding_usd, spot_filled, perp_filled, detail, time_ms FROM state_lifecycle_events WHERE namespace = $1 AND time_ms >= $2 AND ($3::BIGINT <= 0 OR time_ms <= $3::BIGINT) ORDER BY time_ms, id`), s.namespace, startMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.LifecycleRecord
+	for rows.Next() {
+		var rec state.LifecycleRecord
+		if err := rows.Scan(&rec.ID, &rec.Attempt, &rec.Event, &rec.PerpAsset, &rec.SpotAsset, &rec.NotionalUSD, &rec.FundingUSD, &rec.SpotFilled, &rec.PerpFilled, &rec.Detail, &rec.TimeMS); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordTransfer(ctx context.Context, rec state.TransferRecord) (bool, error) {
+	if rec.ID == "" {
+		return false, errors.New("transfer id is required")
+	}
+	res, err := s.db.ExecContext(ctx, s.q(`INSERT INTO state_transfers (namespace, id, kind, direction, destination, amount, time_ms) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT DO NOTHING`),
+		s.namespace, rec.ID, rec.Kind, rec.Direction, rec.Destination, rec.Amount, rec.TimeMS)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) Transfers(ctx context.Context, startMS, endMS int64) ([]state.TransferRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.q(`SELECT id, kind, direction, destination, amount, time_ms FROM state_transfers WHERE namespace = $1 AND time_ms >= $2 AND ($3::BIGINT <= 0 OR time_ms <= $3::BIGINT) ORDER BY time_ms, id`), s.namespace, startMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.TransferRecord
+	for rows.Next() {
+		var rec state.TransferRecord
+		if err := rows.Scan(&rec.ID, &rec.Kind, &rec.Direction, &rec.Destination, &rec.Amount, &rec.TimeMS); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordDecision(ctx context.Context, rec state.DecisionRecord) (bool, error) {
+	if rec.ID == "" {
+		return false, errors.New("decision id is required")
+	}
+	res, err := s.db.ExecContext(ctx, s.q(`INSERT INTO state_decisions (namespace, id, state, decision, action, reasons, inputs, time_ms) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT DO NOTHING`),
+		s.namespace, rec.ID, rec.State, rec.Decision, rec.Action, rec.Reasons, rec.Inputs, rec.TimeMS)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) Decisions(ctx context.Context, startMS, endMS int64) ([]state.DecisionRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.q(`SELECT id, state, decision, action, reasons, inputs, time_ms FROM state_decisions WHERE namespace = $1 AND time_ms >= $2 AND ($3::BIGINT <= 0 OR time_ms <= $3::BIGINT) ORDER BY time_ms, id`), s.namespace, startMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.DecisionRecord
+	for rows.Next() {
+		var rec state.DecisionRecord
+		if err := rows.Scan(&rec.ID, &rec.State, &rec.Decision, &rec.Action, &rec.Reasons, &rec.Inputs, &rec.TimeMS); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) PruneDecisions(ctx context.Context, beforeMS int64) (int64, error) {
+	res, err := s.db.ExecContext(ctx, s.q(`DELETE FROM state_decisions WHERE namespace = $1 AND time_ms < $2`), s.namespace, beforeMS)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func inserted(res sql.Result) (bool, error) {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"hl-carry-bot/internal/state"
+)
+
+var (
+	_ state.Store            = (*Store)(nil)
+	_ state.KVLister         = (*Store)(nil)
+	_ state.Journal          = (*Store)(nil)
+	_ state.ForeignJournal   = (*Store)(nil)
+	_ state.LifecycleJournal = (*Store)(nil)
+	_ state.TransferJournal  = (*Store)(nil)
+	_ state.DecisionJournal  = (*Store)(nil)
+)
+
+// TestStoreRoundTrip runs against a scratch database:
+//
+//	HL_TEST_POSTGRES_DSN=postgres://... go test ./internal/state/postgres
+func TestStoreRoundTrip(t *testing.T) {
+	dsn := os.Getenv("HL_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("HL_TEST_POSTGRES_DSN not set")
+	}
+	store, err := New(dsn, "hl_state_test", "a")
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+	other, err := New(dsn, "hl_state_test", "b")
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer other.Close()
+	ctx := context.Background()
+	defer store.db.ExecContext(ctx, "DROP SCHEMA hl_state_test CASCADE")
+
+	if err := store.Set(ctx, "key", "value"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if val, ok, err := store.Get(ctx, "key"); err != nil || !ok || val != "value" {
+		t.Fatalf("unexpected value: %v (ok=%v err=%v)", val, ok, err)
+	}
+	if _, ok, err := other.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("expected namespaces to be isolated, got ok=%v err=%v", ok, err)
+	}
+	if err := store.Delete(ctx, "key"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, ok, err := store.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("expected key to be deleted, got ok=%v err=%v", ok, err)
+	}
+
+	fill := state.FillRecord{ID: "1", OrderID: "10", Asset: "ETH", Side: "B", Size: 0.5, Price: 3000, Fee: 0.1, TimeMS: 1_700_000_000_000}
+	if ok, err := store.RecordFill(ctx, fill); err != nil || !ok {
+		t.Fatalf("expected first fill insert, got ok=%v err=%v", ok, err)
+	}
+	if ok, err := store.RecordFill(ctx, fill); err != nil || ok {
+		t.Fatalf("expected duplicate fill to be ignored, got ok=%v err=%v", ok, err)
+	}
+	fills, err := store.Fills(ctx, 0, fill.TimeMS)
+	if err != nil || len(fills) != 1 || fills[0] != fill {
+		t.Fatalf("unexpected fills %v (err=%v)", fills, err)
+	}
+	if fills, err := other.Fills(ctx, 0, 0); err != nil || len(fills) != 0 {
+		t.Fatalf("expected no fills in the other namespace, got %v (err=%v)", fills, err)
+	}
+}
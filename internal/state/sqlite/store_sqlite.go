@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 
+	"hl-carry-bot/internal/state"
+
 	_ "modernc.org/sqlite"
 )
 
@@ -24,9 +26,85 @@ func New(path string) (*Store, error) {
 	return &Store{db: db}, nil
 }
 
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS kv (key TEXT PRIMARY KEY, value TEXT NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS fills (
+		id TEXT PRIMARY KEY,
+		oid TEXT NOT NULL,
+		coin TEXT NOT NULL,
+		side TEXT NOT NULL,
+		sz REAL NOT NULL,
+		px REAL NOT NULL,
+		fee REAL NOT NULL,
+		closed_pnl REAL NOT NULL,
+		time_ms INTEGER NOT NULL,
+		hash TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS fills_time_ms ON fills (time_ms)`,
+	`CREATE TABLE IF NOT EXISTS funding_payments (
+		id TEXT PRIMARY KEY,
+		coin TEXT NOT NULL,
+		usdc REAL NOT NULL,
+		rate REAL NOT NULL,
+		time_ms INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS funding_payments_time_ms ON funding_payments (time_ms)`,
+	`CREATE TABLE IF NOT EXISTS foreign_activity (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		oid TEXT NOT NULL,
+		cloid TEXT NOT NULL,
+		coin TEXT NOT NULL,
+		side TEXT NOT NULL,
+		status TEXT NOT NULL,
+		sz REAL NOT NULL,
+		px REAL NOT NULL,
+		time_ms INTEGER NOT NULL,
+		detected_ms INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS foreign_activity_time_ms ON foreign_activity (time_ms)`,
+	`CREATE TABLE IF NOT EXISTS lifecycle_events (
+		id TEXT PRIMARY KEY,
+		attempt TEXT NOT NULL,
+		event TEXT NOT NULL,
+		perp_coin TEXT NOT NULL,
+		spot_coin TEXT NOT NULL,
+		notional_usd REAL NOT NULL,
+		funding_usd REAL NOT NULL,
+		spot_filled REAL NOT NULL,
+		perp_filled REAL NOT NULL,
+		detail TEXT NOT NULL,
+		time_ms INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS lifecycle_events_time_ms ON lifecycle_events (time_ms)`,
+	`CREATE TABLE IF NOT EXISTS transfers (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		destination TEXT NOT NULL,
+		amount REAL NOT NULL,
+		time_ms INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS transfers_time_ms ON transfers (time_ms)`,
+	`CREATE TABLE IF NOT EXISTS decisions (
+		id TEXT PRIMARY KEY,
+		state TEXT NOT NULL,
+		decision TEXT NOT NULL,
+		action TEXT NOT NULL,
+		reasons TEXT NOT NULL,
+		inputs TEXT NOT NULL,
+		time_ms INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS decisions_time_ms ON decisions (time_ms)`,
+}
+
 func initSchema(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS kv (key TEXT PRIMARY KEY, value TEXT NOT NULL)`)
-	return err
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *Store) Get(ctx context.Context, key string) (string, bool, error) {
@@ -51,6 +129,213 @@ func (s *Store) Delete(ctx context.Context, key string) error {
 	return err
 }
 
+func (s *Store) Entries(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT key, value FROM kv`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		out[key] = value
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordFill(ctx context.Context, fill state.FillRecord) (bool, error) {
+	if fill.ID == "" {
+		return false, errors.New("fill id is required")
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO fills (id, oid, coin, side, sz, px, fee, closed_pnl, time_ms, hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		fill.ID, fill.OrderID, fill.Asset, fill.Side, fill.Size, fill.Price, fill.Fee, fill.ClosedPnL, fill.TimeMS, fill.Hash)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) RecordFunding(ctx context.Context, funding state.FundingRecord) (bool, error) {
+	if funding.ID == "" {
+		return false, errors.New("funding id is required")
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO funding_payments (id, coin, usdc, rate, time_ms) VALUES (?, ?, ?, ?, ?)`,
+		funding.ID, funding.Asset, funding.Amount, funding.Rate, funding.TimeMS)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) Fills(ctx context.Context, startMS, endMS int64) ([]state.FillRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, oid, coin, side, sz, px, fee, closed_pnl, time_ms, hash FROM fills WHERE time_ms >= ? AND (? <= 0 OR time_ms <= ?) ORDER BY time_ms, id`, startMS, endMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.FillRecord
+	for rows.Next() {
+		var fill state.FillRecord
+		if err := rows.Scan(&fill.ID, &fill.OrderID, &fill.Asset, &fill.Side, &fill.Size, &fill.Price, &fill.Fee, &fill.ClosedPnL, &fill.TimeMS, &fill.Hash); err != nil {
+			return nil, err
+		}
+		out = append(out, fill)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) FundingPayments(ctx context.Context, startMS, endMS int64) ([]state.FundingRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, coin, usdc, rate, time_ms FROM funding_payments WHERE time_ms >= ? AND (? <= 0 OR time_ms <= ?) ORDER BY time_ms, id`, startMS, endMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.FundingRecord
+	for rows.Next() {
+		var funding state.FundingRecord
+		if err := rows.Scan(&funding.ID, &funding.Asset, &funding.Amount, &funding.Rate, &funding.TimeMS); err != nil {
+			return nil, err
+		}
+		out = append(out, funding)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordForeignActivity(ctx context.Context, rec state.ForeignActivityRecord) (bool, error) {
+	if rec.ID == "" {
+		return false, errors.New("foreign activity id is required")
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO foreign_activity (id, kind, oid, cloid, coin, side, status, sz, px, time_ms, detected_ms) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.ID, rec.Kind, rec.OrderID, rec.Cloid, rec.Asset, rec.Side, rec.Status, rec.Size, rec.Price, rec.TimeMS, rec.DetectedMS)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) ForeignActivity(ctx context.Context, startMS, endMS int64) ([]state.ForeignActivityRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, kind, oid, cloid, coin, side, status, sz, px, time_ms, detected_ms FROM foreign_activity WHERE time_ms >= ? AND (? <= 0 OR time_ms <= ?) ORDER BY time_ms, id`, startMS, endMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.ForeignActivityRecord
+	for rows.Next() {
+		var rec state.ForeignActivityRecord
+		if err := rows.Scan(&rec.ID, &rec.Kind, &rec.OrderID, &rec.Cloid, &rec.Asset, &rec.Side, &rec.Status, &rec.Size, &rec.Price, &rec.TimeMS, &rec.DetectedMS); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordLifecycle(ctx context.Context, rec state.LifecycleRecord) (bool, error) {
+	if rec.ID == "" {
+		return false, errors.New("lifecycle event id is required")
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO lifecycle_events (id, attempt, event, perp_coin, spot_coin, notional_usd, funding_usd, spot_filled, perp_filled, detail, time_ms) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.ID, rec.Attempt, rec.Event, rec.PerpAsset, rec.SpotAsset, rec.NotionalUSD, rec.FundingUSD, rec.SpotFilled, rec.PerpFilled, rec.Detail, rec.TimeMS)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) Lifecycle(ctx context.Context, startMS, endMS int64) ([]state.LifecycleRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, attempt, event, perp_coin, spot_coin, notional_usd, funding_usd, spot_filled, perp_filled, detail, time_ms FROM lifecycle_events WHERE time_ms >= ? AND (? <= 0 OR time_ms <= ?) ORDER BY time_ms, id`, startMS, endMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.LifecycleRecord
+	for rows.Next() {
+		var rec state.LifecycleRecord
+		if err := rows.Scan(&rec.ID, &rec.Attempt, &rec.Event, &rec.PerpAsset, &rec.SpotAsset, &rec.NotionalUSD, &rec.FundingUSD, &rec.SpotFilled, &rec.PerpFilled, &rec.Detail, &rec.TimeMS); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordTransfer(ctx context.Context, rec state.TransferRecord) (bool, error) {
+	if rec.ID == "" {
+		return false, errors.New("transfer id is required")
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO transfers (id, kind, direction, destination, amount, time_ms) VALUES (?, ?, ?, ?, ?, ?)`,
+		rec.ID, rec.Kind, rec.Direction, rec.Destination, rec.Amount, rec.TimeMS)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) Transfers(ctx context.Context, startMS, endMS int64) ([]state.TransferRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, kind, direction, destination, amount, time_ms FROM transfers WHERE time_ms >= ? AND (? <= 0 OR time_ms <= ?) ORDER BY time_ms, id`, startMS, endMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.TransferRecord
+	for rows.Next() {
+		var rec state.TransferRecord
+		if err := rows.Scan(&rec.ID, &rec.Kind, &rec.Direction, &rec.Destination, &rec.Amount, &rec.TimeMS); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) RecordDecision(ctx context.Context, rec state.DecisionRecord) (bool, error) {
+	if rec.ID == "" {
+		return false, errors.New("decision id is required")
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO decisions (id, state, decision, action, reasons, inputs, time_ms) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		rec.ID, rec.State, rec.Decision, rec.Action, rec.Reasons, rec.Inputs, rec.TimeMS)
+	if err != nil {
+		return false, err
+	}
+	return inserted(res)
+}
+
+func (s *Store) Decisions(ctx context.Context, startMS, endMS int64) ([]state.DecisionRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, state, decision, action, reasons, inputs, time_ms FROM decisions WHERE time_ms >= ? AND (? <= 0 OR time_ms <= ?) ORDER BY time_ms, id`, startMS, endMS, endMS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []state.DecisionRecord
+	for rows.Next() {
+		var rec state.DecisionRecord
+		if err := rows.Scan(&rec.ID, &rec.State, &rec.Decision, &rec.Action, &rec.Reasons, &rec.Inputs, &rec.TimeMS); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) PruneDecisions(ctx context.Context, beforeMS int64) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM decisions WHERE time_ms < ?`, beforeMS)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (s *Store) Close() error {
 	return s.db.Close()
 }
+
+func inserted(res sql.Result) (bool, error) {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -2,7 +2,11 @@ package sqlite
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"hl-carry-bot/internal/state"
 )
 
 func TestStoreRoundTrip(t *testing.T) {
@@ -34,3 +38,216 @@ func TestStoreRoundTrip(t *testing.T) {
 		t.Fatalf("expected key to be deleted")
 	}
 }
+
+func TestJournalDeduplicates(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	fill := state.FillRecord{ID: "1", OrderID: "10", Asset: "ETH", Side: "B", Size: 0.5, Price: 3000, Fee: 0.1, TimeMS: 2000}
+	if ok, err := store.RecordFill(ctx, fill); err != nil || !ok {
+		t.Fatalf("expected first fill insert, got ok=%v err=%v", ok, err)
+	}
+	if ok, err := store.RecordFill(ctx, fill); err != nil || ok {
+		t.Fatalf("expected duplicate fill to be ignored, got ok=%v err=%v", ok, err)
+	}
+	funding := state.FundingRecord{ID: "ETH:1000", Asset: "ETH", Amount: 0.25, Rate: 0.0001, TimeMS: 1000}
+	if ok, err := store.RecordFunding(ctx, funding); err != nil || !ok {
+		t.Fatalf("expected first funding insert, got ok=%v err=%v", ok, err)
+	}
+	if ok, err := store.RecordFunding(ctx, funding); err != nil || ok {
+		t.Fatalf("expected duplicate funding to be ignored, got ok=%v err=%v", ok, err)
+	}
+
+	fills, err := store.Fills(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("fills query failed: %v", err)
+	}
+	if len(fills) != 1 || fills[0] != fill {
+		t.Fatalf("unexpected fills: %+v", fills)
+	}
+	if fills, err := store.Fills(ctx, 0, 1500); err != nil || len(fills) != 0 {
+		t.Fatalf("expected no fills before end, got %+v err=%v", fills, err)
+	}
+	payments, err := store.FundingPayments(ctx, 500, 1500)
+	if err != nil {
+		t.Fatalf("funding query failed: %v", err)
+	}
+	if len(payments) != 1 || payments[0] != funding {
+		t.Fatalf("unexpected funding: %+v", payments)
+	}
+}
+
+func TestForeignActivityJournal(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	rec := state.ForeignActivityRecord{ID: "order:7:open", Kind: "order", OrderID: "7", Asset: "ETH", Side: "B", Status: "open", Size: 1, Price: 2000, TimeMS: 1000, DetectedMS: 1005}
+	if ok, err := store.RecordForeignActivity(ctx, rec); err != nil || !ok {
+		t.Fatalf("expected first insert, got ok=%v err=%v", ok, err)
+	}
+	if ok, err := store.RecordForeignActivity(ctx, rec); err != nil || ok {
+		t.Fatalf("expected duplicate to be ignored, got ok=%v err=%v", ok, err)
+	}
+	got, err := store.ForeignActivity(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != rec {
+		t.Fatalf("unexpected foreign activity: %+v", got)
+	}
+}
+
+func TestLifecycleJournal(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	start := state.LifecycleRecord{ID: "a1:compound_start", Attempt: "a1", Event: state.LifecycleCompoundStart, PerpAsset: "ETH", SpotAsset: "UETH", NotionalUSD: 25, FundingUSD: 26, TimeMS: 1000}
+	filled := state.LifecycleRecord{ID: "a1:compound_filled", Attempt: "a1", Event: state.LifecycleCompoundFilled, PerpAsset: "ETH", SpotAsset: "UETH", NotionalUSD: 25, FundingUSD: 26, SpotFilled: 0.01, PerpFilled: 0.01, TimeMS: 2000}
+	for _, rec := range []state.LifecycleRecord{start, filled} {
+		if ok, err := store.RecordLifecycle(ctx, rec); err != nil || !ok {
+			t.Fatalf("expected insert, got ok=%v err=%v", ok, err)
+		}
+	}
+	if ok, err := store.RecordLifecycle(ctx, start); err != nil || ok {
+		t.Fatalf("expected duplicate to be ignored, got ok=%v err=%v", ok, err)
+	}
+	got, err := store.Lifecycle(ctx, 1500, 0)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != filled {
+		t.Fatalf("unexpected lifecycle events: %+v", got)
+	}
+}
+
+func TestTransferJournal(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	toPerp := state.TransferRecord{ID: "usd_class:to_perp:1000", Kind: state.TransferUSDClass, Direction: "to_perp", Amount: 50, TimeMS: 1000}
+	deposit := state.TransferRecord{ID: "vault:deposit:2000", Kind: state.TransferVault, Direction: "deposit", Destination: "0xabc", Amount: 100, TimeMS: 2000}
+	for _, rec := range []state.TransferRecord{toPerp, deposit} {
+		if ok, err := store.RecordTransfer(ctx, rec); err != nil || !ok {
+			t.Fatalf("expected insert, got ok=%v err=%v", ok, err)
+		}
+	}
+	if ok, err := store.RecordTransfer(ctx, toPerp); err != nil || ok {
+		t.Fatalf("expected duplicate to be ignored, got ok=%v err=%v", ok, err)
+	}
+	got, err := store.Transfers(ctx, 0, 1500)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != toPerp {
+		t.Fatalf("unexpected transfers: %+v", got)
+	}
+}
+
+func TestDecisionJournal(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	old := state.DecisionRecord{ID: "1000", State: "IDLE", Decision: "idle", Reasons: "funding_rate_below_min", Inputs: `{"funding_rate":0.00001}`, TimeMS: 1000}
+	enter := state.DecisionRecord{ID: "2000", State: "IDLE", Decision: "enter", Action: "enter", Inputs: `{"funding_rate":0.0002}`, TimeMS: 2000}
+	for _, rec := range []state.DecisionRecord{old, enter} {
+		if ok, err := store.RecordDecision(ctx, rec); err != nil || !ok {
+			t.Fatalf("expected insert, got ok=%v err=%v", ok, err)
+		}
+	}
+	if ok, err := store.RecordDecision(ctx, old); err != nil || ok {
+		t.Fatalf("expected duplicate to be ignored, got ok=%v err=%v", ok, err)
+	}
+	got, err := store.Decisions(ctx, 0, 1500)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != old {
+		t.Fatalf("unexpected decisions: %+v", got)
+	}
+	if n, err := store.PruneDecisions(ctx, 1500); err != nil || n != 1 {
+		t.Fatalf("expected one pruned decision, got n=%d err=%v", n, err)
+	}
+	if got, err := store.Decisions(ctx, 0, 0); err != nil || len(got) != 1 || got[0] != enter {
+		t.Fatalf("unexpected decisions after prune: %+v (err=%v)", got, err)
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src, err := New(filepath.Join(dir, "src.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer src.Close()
+	dst, err := New(filepath.Join(dir, "dst.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer dst.Close()
+
+	ctx := context.Background()
+	nonceKey := "exchange:nonce:https://api.hyperliquid.xyz:0xabc"
+	_ = src.Set(ctx, state.StrategySnapshotKey, `{"action":"HEDGE_OK"}`)
+	_ = src.Set(ctx, nonceKey, "2000")
+	_ = src.Set(ctx, "other", "old")
+	fill := state.FillRecord{ID: "1", OrderID: "10", Asset: "ETH", Side: "B", Size: 0.5, Price: 3000, TimeMS: 1000}
+	_, _ = src.RecordFill(ctx, fill)
+	transfer := state.TransferRecord{ID: "usd_class:to_perp:1000", Kind: state.TransferUSDClass, Direction: "to_perp", Amount: 50, TimeMS: 1000}
+	_, _ = src.RecordTransfer(ctx, transfer)
+	_ = dst.Set(ctx, nonceKey, "3000")
+
+	exportedAt := time.UnixMilli(5000)
+	bundle, err := state.ExportBundle(ctx, src, "old-host", exportedAt)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if len(bundle.KV) != 3 || len(bundle.Fills) != 1 || len(bundle.Transfers) != 1 {
+		t.Fatalf("unexpected bundle %+v", bundle)
+	}
+	if err := state.ImportBundle(ctx, dst, bundle, false, time.UnixMilli(6000)); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if err := state.ImportBundle(ctx, dst, bundle, false, time.UnixMilli(6000)); err != nil {
+		t.Fatalf("expected re-import to be idempotent, got %v", err)
+	}
+	if val, _, _ := dst.Get(ctx, state.StrategySnapshotKey); val != `{"action":"HEDGE_OK"}` {
+		t.Fatalf("expected snapshot imported, got %q", val)
+	}
+	if val, _, _ := dst.Get(ctx, nonceKey); val != "3000" {
+		t.Fatalf("expected the newer local nonce kept, got %q", val)
+	}
+	if fills, err := dst.Fills(ctx, 0, 0); err != nil || len(fills) != 1 || fills[0] != fill {
+		t.Fatalf("unexpected fills %+v (err=%v)", fills, err)
+	}
+	marker, ok, err := state.LoadImportMarker(ctx, dst)
+	if err != nil || !ok || marker.Host != "old-host" || marker.ExportedAtMS != 5000 || marker.SourceStopped {
+		t.Fatalf("unexpected import marker %+v ok=%v err=%v", marker, ok, err)
+	}
+	again, err := state.ExportBundle(ctx, dst, "new-host", exportedAt)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if _, ok := again.KV[state.ImportMarkerKey]; ok {
+		t.Fatalf("expected the import marker left out of exports")
+	}
+}
@@ -0,0 +1,103 @@
+package strategy
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrBasisAdverse is returned when the spot–perp basis has widened against
+// the carry position by more than the configured limit.
+var ErrBasisAdverse = errors.New("basis moved adversely")
+
+// SpotPerpBasis is the perp premium over spot as a fraction of the spot mid:
+// (perp mid - spot mid) / spot mid. A long-spot/short-perp carry loses when it
+// rises after entry, regardless of funding.
+func SpotPerpBasis(snap MarketSnapshot) (float64, bool) {
+	if snap.SpotMidPrice <= 0 || snap.PerpMidPrice <= 0 {
+		return 0, false
+	}
+	return (snap.PerpMidPrice - snap.SpotMidPrice) / snap.SpotMidPrice, true
+}
+
+// BasisAdverseBps is how far the basis has moved against a long-spot/short-
+// perp position since entry, in bps; negative when it moved in its favour.
+func BasisAdverseBps(entry, current float64) float64 {
+	return (current - entry) * 10000
+}
+
+// CheckBasis returns ErrBasisAdverse once the basis has widened more than
+// maxAdverseBps above entry. maxAdverseBps <= 0 disables the check.
+func CheckBasis(maxAdverseBps, entry, current float64) error {
+	if maxAdverseBps <= 0 {
+		return nil
+	}
+	if BasisAdverseBps(entry, current) > maxAdverseBps {
+		return ErrBasisAdverse
+	}
+	return nil
+}
+
+// BasisStats summarizes the basis samples inside the tracker window.
+type BasisStats struct {
+	Last    float64 `json:"last"`
+	Mean    float64 `json:"mean"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Samples int     `json:"samples"`
+}
+
+type basisSample struct {
+	at    time.Time
+	basis float64
+}
+
+// BasisTracker keeps the spot–perp basis observed over a rolling window.
+type BasisTracker struct {
+	window  time.Duration
+	samples []basisSample
+}
+
+// NewBasisTracker tracks basis samples no older than window.
+func NewBasisTracker(window time.Duration) *BasisTracker {
+	return &BasisTracker{window: window}
+}
+
+// Observe records basis at time at and drops samples that fell out of the
+// window.
+func (t *BasisTracker) Observe(at time.Time, basis float64) {
+	if t == nil || math.IsNaN(basis) || math.IsInf(basis, 0) {
+		return
+	}
+	t.samples = append(t.samples, basisSample{at: at, basis: basis})
+	if t.window <= 0 {
+		return
+	}
+	cutoff := at.Add(-t.window)
+	drop := 0
+	for drop < len(t.samples)-1 && t.samples[drop].at.Before(cutoff) {
+		drop++
+	}
+	t.samples = t.samples[drop:]
+}
+
+// Stats reports the window's last, mean, min and max basis.
+func (t *BasisTracker) Stats() (BasisStats, bool) {
+	if t == nil || len(t.samples) == 0 {
+		return BasisStats{}, false
+	}
+	stats := BasisStats{
+		Last:    t.samples[len(t.samples)-1].basis,
+		Min:     math.Inf(1),
+		Max:     math.Inf(-1),
+		Samples: len(t.samples),
+	}
+	sum := 0.0
+	for _, s := range t.samples {
+		sum += s.basis
+		stats.Min = math.Min(stats.Min, s.basis)
+		stats.Max = math.Max(stats.Max, s.basis)
+	}
+	stats.Mean = sum / float64(len(t.samples))
+	return stats, true
+}
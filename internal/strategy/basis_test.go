@@ -0,0 +1,51 @@
+package strategy
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpotPerpBasis(t *testing.T) {
+	basis, ok := SpotPerpBasis(MarketSnapshot{SpotMidPrice: 2000, PerpMidPrice: 2010})
+	if !ok || math.Abs(basis-0.005) > 1e-12 {
+		t.Fatalf("expected 0.005 basis, got %f (%t)", basis, ok)
+	}
+	if _, ok := SpotPerpBasis(MarketSnapshot{PerpMidPrice: 2010}); ok {
+		t.Fatalf("expected no basis without a spot mid")
+	}
+}
+
+func TestCheckBasis(t *testing.T) {
+	if err := CheckBasis(0, 0, 0.05); err != nil {
+		t.Fatalf("expected disabled check to pass, got %v", err)
+	}
+	if err := CheckBasis(50, 0.001, 0.005); err != nil {
+		t.Fatalf("expected 40 bps widening to pass, got %v", err)
+	}
+	if err := CheckBasis(50, 0.001, 0.007); !errors.Is(err, ErrBasisAdverse) {
+		t.Fatalf("expected ErrBasisAdverse, got %v", err)
+	}
+	if err := CheckBasis(50, 0.007, 0.001); err != nil {
+		t.Fatalf("expected narrowing basis to pass, got %v", err)
+	}
+}
+
+func TestBasisTrackerWindow(t *testing.T) {
+	tracker := NewBasisTracker(time.Hour)
+	start := time.Unix(1_700_000_000, 0)
+	tracker.Observe(start, 0.004)
+	tracker.Observe(start.Add(30*time.Minute), 0.001)
+	tracker.Observe(start.Add(90*time.Minute), 0.002)
+	stats, ok := tracker.Stats()
+	if !ok {
+		t.Fatalf("expected stats")
+	}
+	if stats.Samples != 2 || stats.Last != 0.002 || stats.Min != 0.001 || stats.Max != 0.002 || math.Abs(stats.Mean-0.0015) > 1e-12 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if _, ok := NewBasisTracker(time.Hour).Stats(); ok {
+		t.Fatalf("expected no stats without samples")
+	}
+}
@@ -1,7 +1,34 @@
 package strategy
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 const roundTripLegs = 4
 
+// HoursPerYear annualizes hourly funding.
+const HoursPerYear = 24 * 365
+
+// FundingAPR annualizes a funding rate paid every interval, so assets with
+// different funding intervals compare. An unknown interval is taken as
+// hourly, Hyperliquid's own cadence.
+func FundingAPR(rate float64, interval time.Duration) float64 {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return rate * HoursPerYear / interval.Hours()
+}
+
+// FundingRateForAPR is the rate per interval that annualizes to apr.
+func FundingRateForAPR(apr float64, interval time.Duration) float64 {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return apr * interval.Hours() / HoursPerYear
+}
+
 func EstimatedCostsUSD(snap MarketSnapshot, feeBps, slippageBps float64) float64 {
 	notional := fundingNotionalUSD(snap)
 	if notional == 0 {
@@ -21,3 +48,17 @@ func NetExpectedCarryUSD(snap MarketSnapshot, feeBps, slippageBps float64) (floa
 	cost := EstimatedCostsUSD(snap, feeBps, slippageBps)
 	return FundingPaymentEstimateUSD(snap) - cost, cost
 }
+
+var ErrTrailingFunding = errors.New("trailing funding below threshold")
+
+// CheckTrailingFunding requires the trailing average of settled funding to
+// be at least minRate, so a single high snapshot is not enough to enter.
+func CheckTrailingFunding(minRate, trailing float64, hasTrailing bool) error {
+	if !hasTrailing {
+		return fmt.Errorf("trailing funding unavailable: %w", ErrTrailingFunding)
+	}
+	if trailing < minRate {
+		return fmt.Errorf("trailing funding %.8f below %.8f: %w", trailing, minRate, ErrTrailingFunding)
+	}
+	return nil
+}
@@ -1,6 +1,22 @@
 package strategy
 
-import "testing"
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFundingAPRNormalizesIntervals(t *testing.T) {
+	hourly := MarketSnapshot{FundingRate: 0.0001}
+	eightHourly := MarketSnapshot{FundingRate: 0.0008, FundingInterval: 8 * time.Hour}
+	if math.Abs(hourly.FundingAPR()-0.876) > 1e-12 || math.Abs(eightHourly.FundingAPR()-0.876) > 1e-12 {
+		t.Fatalf("expected both to annualize to 0.876, got %f and %f", hourly.FundingAPR(), eightHourly.FundingAPR())
+	}
+	if rate := FundingRateForAPR(0.876, 8*time.Hour); math.Abs(rate-0.0008) > 1e-12 {
+		t.Fatalf("expected 0.0008 per 8h, got %f", rate)
+	}
+}
 
 func TestEstimatedCostsUSDUsesNotional(t *testing.T) {
 	snap := MarketSnapshot{NotionalUSD: 1000}
@@ -35,3 +51,15 @@ func TestNetExpectedCarryUSD(t *testing.T) {
 		t.Fatalf("expected net 0.6, got %f", net)
 	}
 }
+
+func TestCheckTrailingFunding(t *testing.T) {
+	if err := CheckTrailingFunding(0.0001, 0, false); !errors.Is(err, ErrTrailingFunding) {
+		t.Fatalf("expected ErrTrailingFunding without history, got %v", err)
+	}
+	if err := CheckTrailingFunding(0.0001, 0.00005, true); !errors.Is(err, ErrTrailingFunding) {
+		t.Fatalf("expected ErrTrailingFunding below threshold, got %v", err)
+	}
+	if err := CheckTrailingFunding(0.0001, 0.0002, true); err != nil {
+		t.Fatalf("expected pass, got %v", err)
+	}
+}
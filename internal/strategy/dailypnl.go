@@ -0,0 +1,69 @@
+package strategy
+
+import "time"
+
+// DailyPnLBaseline is the position marked to market when a PnL day was first
+// observed. Fills and funding before ObservedAt are not part of the day.
+type DailyPnLBaseline struct {
+	WindowStart  time.Time `json:"window_start"`
+	ObservedAt   time.Time `json:"observed_at"`
+	SpotBalance  float64   `json:"spot_balance"`
+	PerpPosition float64   `json:"perp_position"`
+	SpotMid      float64   `json:"spot_mid"`
+	PerpMid      float64   `json:"perp_mid"`
+}
+
+// PnLFill is one fill on a strategy leg since the baseline. Fee is in USD;
+// FeeInAsset marks a fee taken from the received token, which the held
+// balance already reflects.
+type PnLFill struct {
+	IsBuy      bool
+	Size       float64
+	Price      float64
+	Fee        float64
+	FeeInAsset bool
+	ClosedPnL  float64
+}
+
+// DailyPnL is the PnL since the day's baseline. RealizedUSD is closed PnL and
+// funding net of fees; UnrealizedUSD is the mark-to-market change of what is
+// still held.
+type DailyPnL struct {
+	RealizedUSD   float64
+	UnrealizedUSD float64
+}
+
+func (p DailyPnL) TotalUSD() float64 {
+	return p.RealizedUSD + p.UnrealizedUSD
+}
+
+// DailyWindowStart is the most recent resetHour:00 UTC at or before now.
+func DailyWindowStart(now time.Time, resetHour int) time.Time {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), resetHour, 0, 0, 0, time.UTC)
+	if start.After(now) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start
+}
+
+// ComputeDailyPnL marks both legs at the snapshot mids against the baseline
+// and adds the cash flow of the fills in between, so positions opened or
+// closed during the day are counted at their fill prices.
+func ComputeDailyPnL(base DailyPnLBaseline, snap MarketSnapshot, fills []PnLFill, fundingUSD float64) DailyPnL {
+	total := snap.SpotBalance*snap.SpotMidPrice + snap.PerpPosition*snap.PerpMidPrice -
+		base.SpotBalance*base.SpotMid - base.PerpPosition*base.PerpMid + fundingUSD
+	realized := fundingUSD
+	for _, fill := range fills {
+		cash := fill.Size * fill.Price
+		if fill.IsBuy {
+			cash = -cash
+		}
+		total += cash
+		if !fill.FeeInAsset {
+			total -= fill.Fee
+		}
+		realized += fill.ClosedPnL - fill.Fee
+	}
+	return DailyPnL{RealizedUSD: realized, UnrealizedUSD: total - realized}
+}
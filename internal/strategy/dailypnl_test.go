@@ -0,0 +1,56 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDailyWindowStart(t *testing.T) {
+	now := time.Date(2026, 3, 2, 5, 30, 0, 0, time.UTC)
+	if got := DailyWindowStart(now, 0); !got.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected midnight window start %s", got)
+	}
+	if got := DailyWindowStart(now, 8); !got.Equal(time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the previous day's reset before the hour, got %s", got)
+	}
+	if got := DailyWindowStart(now.Add(3*time.Hour), 8); !got.Equal(time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected today's reset after the hour, got %s", got)
+	}
+}
+
+func TestComputeDailyPnL(t *testing.T) {
+	base := DailyPnLBaseline{SpotBalance: 1, PerpPosition: -1, SpotMid: 3000, PerpMid: 3002}
+	snap := MarketSnapshot{SpotBalance: 1, PerpPosition: -1, SpotMidPrice: 2900, PerpMidPrice: 2905}
+	got := ComputeDailyPnL(base, snap, nil, 1.5)
+	if math.Abs(got.TotalUSD()-(-100+97+1.5)) > 1e-9 || got.RealizedUSD != 1.5 {
+		t.Fatalf("unexpected held-position pnl %+v", got)
+	}
+
+	// Exit both legs during the day at 2950 / 2952.
+	flat := MarketSnapshot{SpotMidPrice: 2900, PerpMidPrice: 2905}
+	fills := []PnLFill{
+		{IsBuy: false, Size: 1, Price: 2950, Fee: 1, ClosedPnL: 40},
+		{IsBuy: true, Size: 1, Price: 2952, Fee: 1, ClosedPnL: -30},
+	}
+	got = ComputeDailyPnL(base, flat, fills, 0)
+	want := (2950.0 - 3000) + (3002.0 - 2952) - 2
+	if math.Abs(got.TotalUSD()-want) > 1e-9 {
+		t.Fatalf("expected total %f, got %f", want, got.TotalUSD())
+	}
+	if math.Abs(got.RealizedUSD-8) > 1e-9 {
+		t.Fatalf("expected realized 8, got %f", got.RealizedUSD)
+	}
+}
+
+func TestComputeDailyPnLFeeInAsset(t *testing.T) {
+	// Buy 1 at 3000 with the fee taken from the received token: the balance
+	// already holds 0.999, so only realized counts the fee.
+	base := DailyPnLBaseline{SpotMid: 3000, PerpMid: 3000}
+	snap := MarketSnapshot{SpotBalance: 0.999, SpotMidPrice: 3000, PerpMidPrice: 3000}
+	fills := []PnLFill{{IsBuy: true, Size: 1, Price: 3000, Fee: 3, FeeInAsset: true}}
+	got := ComputeDailyPnL(base, snap, fills, 0)
+	if math.Abs(got.TotalUSD()+3) > 1e-9 || math.Abs(got.RealizedUSD+3) > 1e-9 {
+		t.Fatalf("expected the 3 USD fee counted once, got %+v", got)
+	}
+}
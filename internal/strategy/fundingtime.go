@@ -0,0 +1,23 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrEntryFundingGuard = errors.New("next funding too close for entry")
+
+// CheckEntryFundingTime holds off an entry that would land within guard of
+// the next funding timestamp, paying both legs' costs just as the accrual
+// window closes; the entry goes ahead once that funding has passed. An
+// unknown funding time does not block.
+func CheckEntryFundingTime(guard, untilFunding time.Duration, hasNext bool) error {
+	if guard <= 0 || !hasNext || untilFunding <= 0 {
+		return nil
+	}
+	if untilFunding <= guard {
+		return fmt.Errorf("next funding in %s within %s: %w", untilFunding.Round(time.Second), guard, ErrEntryFundingGuard)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package strategy
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckEntryFundingTime(t *testing.T) {
+	if err := CheckEntryFundingTime(5*time.Minute, 3*time.Minute, true); !errors.Is(err, ErrEntryFundingGuard) {
+		t.Fatalf("expected ErrEntryFundingGuard, got %v", err)
+	}
+	cases := []struct {
+		guard, until time.Duration
+		hasNext      bool
+	}{
+		{0, time.Minute, true},
+		{5 * time.Minute, 10 * time.Minute, true},
+		{5 * time.Minute, time.Minute, false},
+		{5 * time.Minute, -time.Second, true},
+	}
+	for _, tc := range cases {
+		if err := CheckEntryFundingTime(tc.guard, tc.until, tc.hasNext); err != nil {
+			t.Fatalf("guard %s until %s: expected no block, got %v", tc.guard, tc.until, err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package strategy
+
+import "math"
+
+// LiquidationPrice estimates where a perp position of signed size is
+// liquidated: the price at which margin, marked from mark, only just covers
+// the maintenance margin at maintenanceRate (half the initial margin rate at
+// the asset's max leverage). margin is the collateral backing the position
+// with its unrealized PnL at mark included: the isolated margin, or the
+// cross account value less other positions' maintenance. ok is false for a
+// flat position, and for a long whose margin covers it all the way to zero.
+func LiquidationPrice(size, mark, margin, maintenanceRate float64) (float64, bool) {
+	if size == 0 || mark <= 0 || maintenanceRate <= 0 || maintenanceRate >= 1 {
+		return 0, false
+	}
+	qty := math.Abs(size)
+	side := 1.0
+	if size < 0 {
+		side = -1
+	}
+	price := (qty*mark - side*margin) / (qty * (1 - side*maintenanceRate))
+	if price <= 0 {
+		return 0, false
+	}
+	return price, true
+}
+
+// LiquidationDistancePct is how far mark is from the liquidation price, in
+// percent of mark.
+func LiquidationDistancePct(mark, liquidation float64) float64 {
+	if mark <= 0 {
+		return 0
+	}
+	return math.Abs(liquidation-mark) / mark * 100
+}
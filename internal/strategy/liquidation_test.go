@@ -0,0 +1,29 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLiquidationPrice(t *testing.T) {
+	// A 1 ETH short at 3000 with 300 USD of margin and a 1% maintenance rate
+	// is liquidated once 300 - (P - 3000) = 0.01 P.
+	price, ok := LiquidationPrice(-1, 3000, 300, 0.01)
+	if !ok || math.Abs(price-3300/1.01) > 1e-9 {
+		t.Fatalf("unexpected short liquidation %v %v", price, ok)
+	}
+	if got := LiquidationDistancePct(3000, price); math.Abs(got-(3300/1.01-3000)/30) > 1e-9 {
+		t.Fatalf("unexpected distance %v", got)
+	}
+	// The same long is liquidated once 300 + (P - 3000) = 0.01 P.
+	price, ok = LiquidationPrice(1, 3000, 300, 0.01)
+	if !ok || math.Abs(price-2700/0.99) > 1e-9 {
+		t.Fatalf("unexpected long liquidation %v %v", price, ok)
+	}
+	if _, ok := LiquidationPrice(1, 3000, 3000, 0.01); ok {
+		t.Fatalf("expected a fully collateralised long never to liquidate")
+	}
+	if _, ok := LiquidationPrice(0, 3000, 300, 0.01); ok {
+		t.Fatalf("expected no liquidation price when flat")
+	}
+}
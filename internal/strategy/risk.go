@@ -10,30 +10,39 @@ import (
 )
 
 var (
-	ErrMarketStale  = errors.New("market data stale")
-	ErrAccountStale = errors.New("account data stale")
-	ErrMarginRatio  = errors.New("margin ratio below threshold")
-	ErrHealthRatio  = errors.New("account health below threshold")
+	ErrMarketStale = errors.New("market data stale")
+	// ErrSubscriptionsUnconfirmed marks a WS whose subscribes went
+	// unacknowledged; it is joined with the stale errors of the legs that
+	// connection feeds.
+	ErrSubscriptionsUnconfirmed = errors.New("ws subscriptions unconfirmed")
+	ErrAccountStale             = errors.New("account data stale")
+	ErrMarginRatio              = errors.New("margin ratio below threshold")
+	ErrHealthRatio              = errors.New("account health below threshold")
+
+	// Leg-specific staleness; both match ErrMarketStale.
+	ErrPerpMidStale = fmt.Errorf("perp mid stale: %w", ErrMarketStale)
+	ErrSpotMidStale = fmt.Errorf("spot mid stale: %w", ErrMarketStale)
 )
 
-func CheckRisk(cfg config.RiskConfig, snap MarketSnapshot) error {
-	notional := fundingNotionalUSD(snap)
-	if notional == 0 {
-		notional = snap.NotionalUSD
-	}
-	if cfg.MaxNotionalUSD > 0 && notional > cfg.MaxNotionalUSD {
-		return errors.New("notional exceeds configured maximum")
-	}
-	if cfg.MaxOpenOrders > 0 && snap.OpenOrderCount > cfg.MaxOpenOrders {
-		return errors.New("open orders exceed configured maximum")
-	}
-	if cfg.MinMarginRatio > 0 && snap.HasMarginRatio && snap.MarginRatio < cfg.MinMarginRatio {
-		return fmt.Errorf("margin ratio %.4f below %.4f: %w", snap.MarginRatio, cfg.MinMarginRatio, ErrMarginRatio)
-	}
-	if cfg.MinHealthRatio > 0 && snap.HasHealthRatio && snap.HealthRatio < cfg.MinHealthRatio {
-		return fmt.Errorf("health ratio %.4f below %.4f: %w", snap.HealthRatio, cfg.MinHealthRatio, ErrHealthRatio)
+// DataAges holds how old each input to the kill switch is. Mid ages are per
+// leg so a stale spot feed is not masked by a fresh perp feed.
+type DataAges struct {
+	PerpMid time.Duration
+	SpotMid time.Duration
+	Account time.Duration
+	// MarketSubsPending and AccountSubsPending mark a WS connection with
+	// unacknowledged subscriptions: its data may look fresh while a channel
+	// is silently missing.
+	MarketSubsPending  bool
+	AccountSubsPending bool
+}
+
+// Market is the age of the stalest mid.
+func (d DataAges) Market() time.Duration {
+	if d.SpotMid > d.PerpMid {
+		return d.SpotMid
 	}
-	return nil
+	return d.PerpMid
 }
 
 func CheckConnectivity(cfg config.RiskConfig, marketAge, accountAge time.Duration) error {
@@ -46,6 +55,29 @@ func CheckConnectivity(cfg config.RiskConfig, marketAge, accountAge time.Duratio
 	return nil
 }
 
+// CheckDataAges is CheckConnectivity per leg. Every stale feed is reported;
+// use errors.Is with ErrPerpMidStale, ErrSpotMidStale, or ErrAccountStale to
+// find which legs are blind.
+func CheckDataAges(cfg config.RiskConfig, ages DataAges) error {
+	var errs []error
+	if cfg.MaxMarketAge > 0 && ages.PerpMid > cfg.MaxMarketAge {
+		errs = append(errs, fmt.Errorf("perp mid age %s exceeds %s: %w", ages.PerpMid, cfg.MaxMarketAge, ErrPerpMidStale))
+	}
+	if cfg.MaxMarketAge > 0 && ages.SpotMid > cfg.MaxMarketAge {
+		errs = append(errs, fmt.Errorf("spot mid age %s exceeds %s: %w", ages.SpotMid, cfg.MaxMarketAge, ErrSpotMidStale))
+	}
+	if cfg.MaxAccountAge > 0 && ages.Account > cfg.MaxAccountAge {
+		errs = append(errs, fmt.Errorf("account data age %s exceeds %s: %w", ages.Account, cfg.MaxAccountAge, ErrAccountStale))
+	}
+	if ages.MarketSubsPending {
+		errs = append(errs, fmt.Errorf("market %w: %w, %w", ErrSubscriptionsUnconfirmed, ErrPerpMidStale, ErrSpotMidStale))
+	}
+	if ages.AccountSubsPending {
+		errs = append(errs, fmt.Errorf("account %w: %w", ErrSubscriptionsUnconfirmed, ErrAccountStale))
+	}
+	return errors.Join(errs...)
+}
+
 func fundingNotionalUSD(snap MarketSnapshot) float64 {
 	price := priceForFunding(snap)
 	if price == 0 {
@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -78,6 +79,42 @@ func TestCheckRiskSkipsMissingRatios(t *testing.T) {
 	}
 }
 
+func TestCheckDataAgesNamesStaleLeg(t *testing.T) {
+	cfg := config.RiskConfig{
+		MaxMarketAge:  2 * time.Second,
+		MaxAccountAge: 5 * time.Second,
+	}
+	err := CheckDataAges(cfg, DataAges{PerpMid: time.Second, SpotMid: 3 * time.Second, Account: time.Second})
+	if !errors.Is(err, ErrSpotMidStale) || !errors.Is(err, ErrMarketStale) {
+		t.Fatalf("expected spot mid staleness, got %v", err)
+	}
+	if errors.Is(err, ErrPerpMidStale) {
+		t.Fatalf("expected perp leg to be fresh, got %v", err)
+	}
+	err = CheckDataAges(cfg, DataAges{PerpMid: 3 * time.Second, SpotMid: 3 * time.Second, Account: 6 * time.Second})
+	if !errors.Is(err, ErrPerpMidStale) || !errors.Is(err, ErrSpotMidStale) || !errors.Is(err, ErrAccountStale) {
+		t.Fatalf("expected all legs stale, got %v", err)
+	}
+	if err := CheckDataAges(cfg, DataAges{PerpMid: time.Second, SpotMid: 2 * time.Second, Account: 2 * time.Second}); err != nil {
+		t.Fatalf("expected connectivity ok, got %v", err)
+	}
+}
+
+func TestCheckDataAgesUnconfirmedSubscriptions(t *testing.T) {
+	cfg := config.RiskConfig{MaxMarketAge: 2 * time.Second, MaxAccountAge: 5 * time.Second}
+	err := CheckDataAges(cfg, DataAges{MarketSubsPending: true})
+	if !errors.Is(err, ErrSubscriptionsUnconfirmed) || !errors.Is(err, ErrPerpMidStale) || !errors.Is(err, ErrSpotMidStale) {
+		t.Fatalf("expected unconfirmed market subscriptions to blind both legs, got %v", err)
+	}
+	if errors.Is(err, ErrAccountStale) {
+		t.Fatalf("expected account to be fresh, got %v", err)
+	}
+	err = CheckDataAges(cfg, DataAges{AccountSubsPending: true})
+	if !errors.Is(err, ErrSubscriptionsUnconfirmed) || !errors.Is(err, ErrAccountStale) {
+		t.Fatalf("expected unconfirmed account subscriptions to stale the account, got %v", err)
+	}
+}
+
 func TestCheckConnectivity(t *testing.T) {
 	cfg := config.RiskConfig{
 		MaxMarketAge:  2 * time.Second,
@@ -0,0 +1,211 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"hl-carry-bot/internal/config"
+)
+
+var (
+	ErrMaxNotional         = errors.New("notional exceeds configured maximum")
+	ErrMaxOpenOrders       = errors.New("open orders exceed configured maximum")
+	ErrMaxDelta            = errors.New("delta exceeds configured maximum")
+	ErrDailyLoss           = errors.New("daily loss exceeds configured maximum")
+	ErrConsecutiveFailures = errors.New("consecutive failures exceed configured maximum")
+	ErrClockDrift          = errors.New("local clock drift exceeds configured maximum")
+	ErrLiquidationDistance = errors.New("mark price is too close to the liquidation price")
+)
+
+// RiskAction is what the bot does about a violated risk rule. Actions are
+// ordered by severity; an assessment takes the most severe one.
+type RiskAction int
+
+const (
+	// RiskActionNone trades normally.
+	RiskActionNone RiskAction = iota
+	// RiskActionBlockEntry stops new entries and compounding add-ons.
+	RiskActionBlockEntry
+	// RiskActionHedgeOnly additionally holds the position: only delta hedges
+	// are placed, no exits.
+	RiskActionHedgeOnly
+	// RiskActionReduce holds the position like RiskActionHedgeOnly after
+	// reducing it once to risk.reduce_to_pct of its size.
+	RiskActionReduce
+	// RiskActionFlatten exits the position and blocks new entries.
+	RiskActionFlatten
+	// RiskActionHalt places no orders at all.
+	RiskActionHalt
+)
+
+var riskActionNames = map[RiskAction]string{
+	RiskActionNone:       "none",
+	RiskActionBlockEntry: "block_entry",
+	RiskActionHedgeOnly:  "hedge_only",
+	RiskActionReduce:     "reduce",
+	RiskActionFlatten:    "flatten",
+	RiskActionHalt:       "halt",
+}
+
+func (a RiskAction) String() string {
+	if name, ok := riskActionNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("RiskAction(%d)", int(a))
+}
+
+// ParseRiskAction parses a risk.actions value.
+func ParseRiskAction(name string) (RiskAction, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for action, actionName := range riskActionNames {
+		if name == actionName {
+			return action, nil
+		}
+	}
+	return RiskActionNone, fmt.Errorf("unknown risk action %q", name)
+}
+
+// Risk rule names, as used in risk.actions and reported in violations.
+const (
+	RiskRuleMaxNotional         = "max_notional"
+	RiskRuleMaxOpenOrders       = "max_open_orders"
+	RiskRuleMinMarginRatio      = "min_margin_ratio"
+	RiskRuleMinHealthRatio      = "min_health_ratio"
+	RiskRuleMaxDelta            = "max_delta"
+	RiskRuleDailyLoss           = "daily_loss"
+	RiskRuleConsecutiveFailures = "consecutive_failures"
+	RiskRuleClockDrift          = "clock_drift"
+	RiskRuleLiquidationDistance = "liquidation_distance"
+)
+
+// defaultRiskActions mirrors the config defaults for hand-built configs.
+var defaultRiskActions = map[string]RiskAction{
+	RiskRuleMaxNotional:         RiskActionHedgeOnly,
+	RiskRuleMaxOpenOrders:       RiskActionHalt,
+	RiskRuleMinMarginRatio:      RiskActionFlatten,
+	RiskRuleMinHealthRatio:      RiskActionFlatten,
+	RiskRuleMaxDelta:            RiskActionFlatten,
+	RiskRuleDailyLoss:           RiskActionFlatten,
+	RiskRuleConsecutiveFailures: RiskActionBlockEntry,
+	RiskRuleClockDrift:          RiskActionHalt,
+	RiskRuleLiquidationDistance: RiskActionFlatten,
+}
+
+// RiskInputs is what the risk rules evaluate. The account-level inputs are
+// optional: a rule whose input is missing does not fire.
+type RiskInputs struct {
+	Snap                MarketSnapshot
+	DeltaUSD            float64
+	DailyPnLUSD         float64
+	HasDailyPnL         bool
+	ConsecutiveFailures int
+	// ClockDrift is the exchange clock minus the local clock.
+	ClockDrift    time.Duration
+	HasClockDrift bool
+	// LiquidationDistancePct is how far the perp mark is from the perp
+	// leg's estimated liquidation price, in percent of mark.
+	LiquidationDistancePct float64
+	HasLiquidationDistance bool
+}
+
+// RiskViolation is one rule that fired and the action configured for it.
+type RiskViolation struct {
+	Rule   string
+	Action RiskAction
+	Err    error
+}
+
+// RiskAssessment is the outcome of EvaluateRisk. Action is the most severe
+// action among Violations, or RiskActionNone.
+type RiskAssessment struct {
+	Action     RiskAction
+	Violations []RiskViolation
+}
+
+// Err joins the violation errors, or returns nil when no rule fired.
+func (r RiskAssessment) Err() error {
+	errs := make([]error, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		errs = append(errs, fmt.Errorf("%s: %w", v.Rule, v.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// Rules lists the violated rule names in evaluation order.
+func (r RiskAssessment) Rules() []string {
+	out := make([]string, len(r.Violations))
+	for i, v := range r.Violations {
+		out[i] = v.Rule
+	}
+	return out
+}
+
+// EvaluateRisk runs every configured risk rule. Notional is valued on
+// cfg.ValuationBasis (oracle unless set to mark).
+func EvaluateRisk(cfg config.RiskConfig, in RiskInputs) RiskAssessment {
+	snap := in.Snap
+	var out RiskAssessment
+	add := func(rule, configured string, err error) {
+		action := riskRuleAction(rule, configured)
+		out.Violations = append(out.Violations, RiskViolation{Rule: rule, Action: action, Err: err})
+		if action > out.Action {
+			out.Action = action
+		}
+	}
+	if notional := Value(snap, cfg.ValuationBasis).NotionalUSD; cfg.MaxNotionalUSD > 0 && notional > cfg.MaxNotionalUSD {
+		add(RiskRuleMaxNotional, cfg.Actions.MaxNotional,
+			fmt.Errorf("notional %.2f above %.2f: %w", notional, cfg.MaxNotionalUSD, ErrMaxNotional))
+	}
+	if cfg.MaxOpenOrders > 0 && snap.OpenOrderCount > cfg.MaxOpenOrders {
+		add(RiskRuleMaxOpenOrders, cfg.Actions.MaxOpenOrders,
+			fmt.Errorf("%d open orders above %d: %w", snap.OpenOrderCount, cfg.MaxOpenOrders, ErrMaxOpenOrders))
+	}
+	if cfg.MinMarginRatio > 0 && snap.HasMarginRatio && snap.MarginRatio < cfg.MinMarginRatio {
+		add(RiskRuleMinMarginRatio, cfg.Actions.MinMarginRatio,
+			fmt.Errorf("margin ratio %.4f below %.4f: %w", snap.MarginRatio, cfg.MinMarginRatio, ErrMarginRatio))
+	}
+	if cfg.MinHealthRatio > 0 && snap.HasHealthRatio && snap.HealthRatio < cfg.MinHealthRatio {
+		add(RiskRuleMinHealthRatio, cfg.Actions.MinHealthRatio,
+			fmt.Errorf("health ratio %.4f below %.4f: %w", snap.HealthRatio, cfg.MinHealthRatio, ErrHealthRatio))
+	}
+	if cfg.MaxDeltaUSD > 0 && math.Abs(in.DeltaUSD) > cfg.MaxDeltaUSD {
+		add(RiskRuleMaxDelta, cfg.Actions.MaxDelta,
+			fmt.Errorf("delta %.2f USD above %.2f: %w", in.DeltaUSD, cfg.MaxDeltaUSD, ErrMaxDelta))
+	}
+	if cfg.MaxDailyLossUSD > 0 && in.HasDailyPnL && -in.DailyPnLUSD > cfg.MaxDailyLossUSD {
+		add(RiskRuleDailyLoss, cfg.Actions.DailyLoss,
+			fmt.Errorf("daily pnl %.2f USD below -%.2f: %w", in.DailyPnLUSD, cfg.MaxDailyLossUSD, ErrDailyLoss))
+	}
+	if cfg.MaxConsecutiveFailures > 0 && in.ConsecutiveFailures >= cfg.MaxConsecutiveFailures {
+		add(RiskRuleConsecutiveFailures, cfg.Actions.ConsecutiveFailures,
+			fmt.Errorf("%d consecutive failures reached %d: %w", in.ConsecutiveFailures, cfg.MaxConsecutiveFailures, ErrConsecutiveFailures))
+	}
+	if drift := in.ClockDrift.Abs(); cfg.MaxClockDrift > 0 && in.HasClockDrift && drift > cfg.MaxClockDrift {
+		add(RiskRuleClockDrift, cfg.Actions.ClockDrift,
+			fmt.Errorf("clock drift %s above %s: %w", in.ClockDrift, cfg.MaxClockDrift, ErrClockDrift))
+	}
+	if cfg.MinLiquidationDistancePct > 0 && in.HasLiquidationDistance && in.LiquidationDistancePct < cfg.MinLiquidationDistancePct {
+		add(RiskRuleLiquidationDistance, cfg.Actions.LiquidationDistance,
+			fmt.Errorf("liquidation %.2f%% from mark, within %.2f%%: %w", in.LiquidationDistancePct, cfg.MinLiquidationDistancePct, ErrLiquidationDistance))
+	}
+	return out
+}
+
+// CheckRisk reports every violated risk rule regardless of its action.
+func CheckRisk(cfg config.RiskConfig, snap MarketSnapshot) error {
+	return EvaluateRisk(cfg, RiskInputs{Snap: snap}).Err()
+}
+
+// riskRuleAction resolves the configured action for rule. An unset or
+// unknown value falls back to the rule's default.
+func riskRuleAction(rule, configured string) RiskAction {
+	if configured != "" {
+		if action, err := ParseRiskAction(configured); err == nil && action != RiskActionNone {
+			return action
+		}
+	}
+	return defaultRiskActions[rule]
+}
@@ -0,0 +1,105 @@
+package strategy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+)
+
+func TestEvaluateRiskTakesMostSevereAction(t *testing.T) {
+	cfg := config.RiskConfig{
+		MaxNotionalUSD:         150,
+		MinMarginRatio:         0.25,
+		MaxConsecutiveFailures: 3,
+	}
+	in := RiskInputs{
+		Snap:                MarketSnapshot{OraclePrice: 100, PerpPosition: -2, MarginRatio: 0.2, HasMarginRatio: true},
+		ConsecutiveFailures: 3,
+	}
+	got := EvaluateRisk(cfg, in)
+	if got.Action != RiskActionFlatten {
+		t.Fatalf("expected flatten, got %s", got.Action)
+	}
+	rules := got.Rules()
+	want := []string{RiskRuleMaxNotional, RiskRuleMinMarginRatio, RiskRuleConsecutiveFailures}
+	if len(rules) != len(want) {
+		t.Fatalf("expected rules %v, got %v", want, rules)
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Fatalf("expected rules %v, got %v", want, rules)
+		}
+	}
+	if err := got.Err(); !errors.Is(err, ErrMarginRatio) || !errors.Is(err, ErrMaxNotional) {
+		t.Fatalf("expected joined rule errors, got %v", err)
+	}
+
+	cfg.Actions.MinMarginRatio = "block_entry"
+	if got := EvaluateRisk(cfg, in); got.Action != RiskActionHedgeOnly {
+		t.Fatalf("expected hedge_only once margin only blocks entry, got %s", got.Action)
+	}
+}
+
+func TestEvaluateRiskDeltaAndDailyLoss(t *testing.T) {
+	cfg := config.RiskConfig{
+		MaxDeltaUSD:     50,
+		MaxDailyLossUSD: 100,
+		Actions:         config.RiskActionsConfig{MaxDelta: "halt"},
+	}
+	if got := EvaluateRisk(cfg, RiskInputs{DeltaUSD: -50, DailyPnLUSD: -100, HasDailyPnL: true}); got.Action != RiskActionNone {
+		t.Fatalf("expected no action at the limits, got %s (%v)", got.Action, got.Err())
+	}
+	got := EvaluateRisk(cfg, RiskInputs{DeltaUSD: -51})
+	if got.Action != RiskActionHalt || !errors.Is(got.Err(), ErrMaxDelta) {
+		t.Fatalf("expected configured halt for delta, got %s (%v)", got.Action, got.Err())
+	}
+	got = EvaluateRisk(cfg, RiskInputs{DailyPnLUSD: -101, HasDailyPnL: true})
+	if got.Action != RiskActionFlatten || !errors.Is(got.Err(), ErrDailyLoss) {
+		t.Fatalf("expected flatten for daily loss, got %s (%v)", got.Action, got.Err())
+	}
+	if got := EvaluateRisk(cfg, RiskInputs{DailyPnLUSD: -500}); got.Action != RiskActionNone {
+		t.Fatalf("expected daily loss rule to skip missing pnl, got %s", got.Action)
+	}
+}
+
+func TestEvaluateRiskClockDrift(t *testing.T) {
+	cfg := config.RiskConfig{MaxClockDrift: 5 * time.Second}
+	if got := EvaluateRisk(cfg, RiskInputs{ClockDrift: -5 * time.Second, HasClockDrift: true}); got.Action != RiskActionNone {
+		t.Fatalf("expected no action at the limit, got %s", got.Action)
+	}
+	got := EvaluateRisk(cfg, RiskInputs{ClockDrift: -6 * time.Second, HasClockDrift: true})
+	if got.Action != RiskActionHalt || !errors.Is(got.Err(), ErrClockDrift) {
+		t.Fatalf("expected halt for a clock behind the exchange, got %s (%v)", got.Action, got.Err())
+	}
+	if got := EvaluateRisk(cfg, RiskInputs{ClockDrift: time.Minute}); got.Action != RiskActionNone {
+		t.Fatalf("expected the rule to skip an unsynced clock, got %s", got.Action)
+	}
+}
+
+func TestEvaluateRiskLiquidationDistance(t *testing.T) {
+	cfg := config.RiskConfig{MinLiquidationDistancePct: 20, Actions: config.RiskActionsConfig{LiquidationDistance: "reduce"}}
+	if got := EvaluateRisk(cfg, RiskInputs{LiquidationDistancePct: 25, HasLiquidationDistance: true}); got.Action != RiskActionNone {
+		t.Fatalf("expected no action outside the limit, got %s", got.Action)
+	}
+	got := EvaluateRisk(cfg, RiskInputs{LiquidationDistancePct: 15, HasLiquidationDistance: true})
+	if got.Action != RiskActionReduce || !errors.Is(got.Err(), ErrLiquidationDistance) {
+		t.Fatalf("expected the configured reduce, got %s (%v)", got.Action, got.Err())
+	}
+	if got := EvaluateRisk(cfg, RiskInputs{LiquidationDistancePct: 1}); got.Action != RiskActionNone {
+		t.Fatalf("expected the rule to skip a missing estimate, got %s", got.Action)
+	}
+}
+
+func TestParseRiskAction(t *testing.T) {
+	for _, name := range []string{"none", "block_entry", "hedge_only", "reduce", "flatten", "halt"} {
+		action, err := ParseRiskAction(name)
+		if err != nil || action.String() != name {
+			t.Fatalf("expected %s to round-trip, got %s (%v)", name, action, err)
+		}
+	}
+	if _, err := ParseRiskAction("panic"); err == nil {
+		t.Fatalf("expected error for unknown action")
+	}
+}
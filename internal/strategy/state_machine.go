@@ -1,53 +1,150 @@
 package strategy
 
-import "sync"
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
 
+// ReasonSet is the Transition reason when the state is set directly, as on
+// restore, rather than reached by an event.
+const ReasonSet = "set"
+
+// ErrInvalidTransition is returned by Apply for an event the current state
+// does not accept; the state is left as it was.
+var ErrInvalidTransition = errors.New("invalid state transition")
+
+// transitions lists, per state, the events it accepts and where each leads.
+// EXIT is reachable from IDLE so an operator flatten can close exposure the
+// strategy was not tracking, and from EXIT so a stalled exit can be rerun.
+var transitions = map[State]map[Event]State{
+	StateIdle: {
+		EventEnter: StateEnter,
+		EventExit:  StateExit,
+	},
+	StateEnter: {
+		EventHedgeOK: StateHedgeOK,
+		EventExit:    StateExit,
+		EventAbort:   StateIdle,
+		EventFlat:    StateIdle,
+	},
+	StateHedgeOK: {
+		EventExit: StateExit,
+		EventFlat: StateIdle,
+	},
+	StateExit: {
+		EventExit:    StateExit,
+		EventHedgeOK: StateHedgeOK,
+		EventDone:    StateIdle,
+		EventFlat:    StateIdle,
+	},
+}
+
+// Transition is one change of state. Reason is the event that caused it, or
+// ReasonSet.
+type Transition struct {
+	From   State
+	To     State
+	Reason string
+	At     time.Time
+}
+
+// StateMachine is safe for concurrent use. State is exported for tests that
+// set up a state directly; read it with Current while events may apply.
 type StateMachine struct {
-	mu    sync.Mutex
-	State State
+	mu        sync.Mutex
+	State     State
+	enteredAt time.Time
+	hooks     []func(Transition)
 }
 
 func NewStateMachine() *StateMachine {
-	return &StateMachine{State: StateIdle}
+	return &StateMachine{State: StateIdle, enteredAt: time.Now()}
+}
+
+// OnTransition adds a hook called, in registration order, after every change
+// of state and outside the machine's lock. Hooks are not called for a
+// rejected event.
+func (s *StateMachine) OnTransition(fn func(Transition)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, fn)
+}
+
+// Apply moves the machine by event and returns the new state, or the
+// unchanged state and an ErrInvalidTransition if the current state does not
+// accept the event. An accepted event that leads back to the same state is
+// not a transition and runs no hooks.
+func (s *StateMachine) Apply(event Event) (State, error) {
+	s.mu.Lock()
+	from := s.State
+	to, ok := transitions[from][event]
+	if !ok {
+		s.mu.Unlock()
+		return from, fmt.Errorf("%w: %s in state %s", ErrInvalidTransition, event, from)
+	}
+	if to == from {
+		s.mu.Unlock()
+		return to, nil
+	}
+	s.State = to
+	tr, hooks := s.transitionLocked(from, string(event))
+	s.mu.Unlock()
+	runHooks(hooks, tr)
+	return to, nil
 }
 
-func (s *StateMachine) Apply(event Event) State {
+// Current returns the current state.
+func (s *StateMachine) Current() State {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.State = nextState(s.State, event)
 	return s.State
 }
 
-func (s *StateMachine) SetState(state State) {
+// Accepts reports whether the current state accepts event.
+func (s *StateMachine) Accepts(event Event) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	_, ok := transitions[s.State][event]
+	return ok
+}
+
+// SetState moves the machine to state without a guard, for restoring a
+// persisted state.
+func (s *StateMachine) SetState(state State) {
+	s.mu.Lock()
+	from := s.State
 	s.State = state
+	var tr Transition
+	var hooks []func(Transition)
+	if state != from {
+		tr, hooks = s.transitionLocked(from, ReasonSet)
+	}
+	s.mu.Unlock()
+	runHooks(hooks, tr)
+}
+
+// TimeInState is how long the machine has been in its current state.
+func (s *StateMachine) TimeInState(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.enteredAt.IsZero() {
+		return 0
+	}
+	return now.Sub(s.enteredAt)
+}
+
+// transitionLocked stamps a change from from to the current state and
+// returns the hooks to run once the lock is released.
+func (s *StateMachine) transitionLocked(from State, reason string) (Transition, []func(Transition)) {
+	now := time.Now()
+	s.enteredAt = now
+	return Transition{From: from, To: s.State, Reason: reason, At: now}, append([]func(Transition){}, s.hooks...)
 }
 
-func nextState(current State, event Event) State {
-	switch current {
-	case StateIdle:
-		if event == EventEnter {
-			return StateEnter
-		}
-	case StateEnter:
-		if event == EventHedgeOK {
-			return StateHedgeOK
-		}
-		if event == EventExit {
-			return StateExit
-		}
-	case StateHedgeOK:
-		if event == EventExit {
-			return StateExit
-		}
-	case StateExit:
-		if event == EventHedgeOK {
-			return StateHedgeOK
-		}
-		if event == EventDone {
-			return StateIdle
-		}
+func runHooks(hooks []func(Transition), tr Transition) {
+	for _, hook := range hooks {
+		hook(tr)
 	}
-	return current
 }
@@ -1,36 +1,59 @@
 package strategy
 
-import "testing"
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustApply(t *testing.T, sm *StateMachine, event Event, want State) {
+	t.Helper()
+	got, err := sm.Apply(event)
+	if err != nil {
+		t.Fatalf("apply %s: %v", event, err)
+	}
+	if got != want || sm.State != want {
+		t.Fatalf("apply %s: expected %s, got %s", event, want, got)
+	}
+}
 
 func TestStateMachineTransitions(t *testing.T) {
 	sm := NewStateMachine()
 	if sm.State != StateIdle {
 		t.Fatalf("expected %s, got %s", StateIdle, sm.State)
 	}
-	if sm.Apply(EventEnter) != StateEnter {
-		t.Fatalf("expected %s, got %s", StateEnter, sm.State)
-	}
-	if sm.Apply(EventHedgeOK) != StateHedgeOK {
-		t.Fatalf("expected %s, got %s", StateHedgeOK, sm.State)
-	}
-	if sm.Apply(EventExit) != StateExit {
-		t.Fatalf("expected %s, got %s", StateExit, sm.State)
-	}
-	if sm.Apply(EventHedgeOK) != StateHedgeOK {
-		t.Fatalf("expected %s, got %s", StateHedgeOK, sm.State)
-	}
-	if sm.Apply(EventExit) != StateExit {
-		t.Fatalf("expected %s, got %s", StateExit, sm.State)
-	}
-	if sm.Apply(EventDone) != StateIdle {
-		t.Fatalf("expected %s, got %s", StateIdle, sm.State)
-	}
+	mustApply(t, sm, EventEnter, StateEnter)
+	mustApply(t, sm, EventHedgeOK, StateHedgeOK)
+	mustApply(t, sm, EventExit, StateExit)
+	mustApply(t, sm, EventHedgeOK, StateHedgeOK)
+	mustApply(t, sm, EventExit, StateExit)
+	mustApply(t, sm, EventDone, StateIdle)
+	mustApply(t, sm, EventEnter, StateEnter)
+	mustApply(t, sm, EventAbort, StateIdle)
+	mustApply(t, sm, EventExit, StateExit)
+	mustApply(t, sm, EventExit, StateExit)
+	mustApply(t, sm, EventFlat, StateIdle)
 }
 
 func TestStateMachineInvalidTransition(t *testing.T) {
 	sm := NewStateMachine()
-	if sm.Apply(EventHedgeOK) != StateIdle {
-		t.Fatalf("invalid transition should not change state")
+	calls := 0
+	sm.OnTransition(func(Transition) { calls++ })
+	for _, event := range []Event{EventHedgeOK, EventDone, EventAbort, EventFlat} {
+		if sm.Accepts(event) {
+			t.Fatalf("expected IDLE to refuse %s", event)
+		}
+		state, err := sm.Apply(event)
+		if !errors.Is(err, ErrInvalidTransition) || state != StateIdle {
+			t.Fatalf("expected %s refused in IDLE, got %s (err=%v)", event, state, err)
+		}
+	}
+	mustApply(t, sm, EventEnter, StateEnter)
+	if _, err := sm.Apply(EventEnter); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected a repeated enter refused, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected hooks only for the accepted event, got %d calls", calls)
 	}
 }
 
@@ -41,3 +64,46 @@ func TestStateMachineSetState(t *testing.T) {
 		t.Fatalf("expected %s, got %s", StateHedgeOK, sm.State)
 	}
 }
+
+func TestStateMachineOnTransition(t *testing.T) {
+	sm := NewStateMachine()
+	var got, second []Transition
+	sm.OnTransition(func(tr Transition) { got = append(got, tr) })
+	sm.OnTransition(func(tr Transition) { second = append(second, tr) })
+	mustApply(t, sm, EventEnter, StateEnter)
+	mustApply(t, sm, EventHedgeOK, StateHedgeOK)
+	sm.SetState(StateHedgeOK)
+	sm.SetState(StateIdle)
+	if len(got) != 3 || len(second) != 3 {
+		t.Fatalf("expected 3 transitions for each hook, got %+v and %+v", got, second)
+	}
+	if got[0].From != StateIdle || got[0].To != StateEnter || got[0].Reason != string(EventEnter) {
+		t.Fatalf("unexpected first transition %+v", got[0])
+	}
+	if got[2].From != StateHedgeOK || got[2].To != StateIdle || got[2].Reason != ReasonSet {
+		t.Fatalf("unexpected set transition %+v", got[2])
+	}
+	if age := sm.TimeInState(got[2].At.Add(time.Minute)); age != time.Minute {
+		t.Fatalf("expected a minute in state, got %s", age)
+	}
+}
+
+func TestStateMachineConcurrentCurrent(t *testing.T) {
+	sm := NewStateMachine()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = sm.Current()
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		mustApply(t, sm, EventEnter, StateEnter)
+		mustApply(t, sm, EventHedgeOK, StateHedgeOK)
+		sm.SetState(StateIdle)
+	}
+	<-done
+	if got := sm.Current(); got != StateIdle {
+		t.Fatalf("expected %s, got %s", StateIdle, got)
+	}
+}
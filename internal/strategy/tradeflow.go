@@ -0,0 +1,35 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrTradeFlow = errors.New("taker sell flow against spot leg")
+
+var ErrBookImbalance = errors.New("order book offers outweigh bids against spot leg")
+
+// CheckTradeFlow holds off an entry while takers are selling hard enough that
+// the spot buy would chase a falling price. Missing flow does not block.
+func CheckTradeFlow(maxSellImbalance, imbalance float64, hasImbalance bool) error {
+	if maxSellImbalance <= 0 || !hasImbalance {
+		return nil
+	}
+	if imbalance <= -maxSellImbalance {
+		return fmt.Errorf("trade imbalance %.3f at or below -%.3f: %w", imbalance, maxSellImbalance, ErrTradeFlow)
+	}
+	return nil
+}
+
+// CheckBookImbalance holds off an entry while resting offers outweigh bids by
+// enough that the price is likely to be pushed down into the spot buy.
+// Missing book data does not block.
+func CheckBookImbalance(maxSellImbalance, imbalance float64, hasImbalance bool) error {
+	if maxSellImbalance <= 0 || !hasImbalance {
+		return nil
+	}
+	if imbalance <= -maxSellImbalance {
+		return fmt.Errorf("book imbalance %.3f at or below -%.3f: %w", imbalance, maxSellImbalance, ErrBookImbalance)
+	}
+	return nil
+}
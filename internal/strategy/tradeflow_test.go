@@ -0,0 +1,36 @@
+package strategy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckTradeFlow(t *testing.T) {
+	if err := CheckTradeFlow(0, -1, true); err != nil {
+		t.Fatalf("expected disabled gate to pass, got %v", err)
+	}
+	if err := CheckTradeFlow(0.5, -0.9, false); err != nil {
+		t.Fatalf("expected missing flow to pass, got %v", err)
+	}
+	if err := CheckTradeFlow(0.5, 0.9, true); err != nil {
+		t.Fatalf("expected buy flow to pass, got %v", err)
+	}
+	if err := CheckTradeFlow(0.5, -0.5, true); !errors.Is(err, ErrTradeFlow) {
+		t.Fatalf("expected ErrTradeFlow, got %v", err)
+	}
+}
+
+func TestCheckBookImbalance(t *testing.T) {
+	if err := CheckBookImbalance(0, -1, true); err != nil {
+		t.Fatalf("expected disabled gate to pass, got %v", err)
+	}
+	if err := CheckBookImbalance(0.5, -0.9, false); err != nil {
+		t.Fatalf("expected missing book to pass, got %v", err)
+	}
+	if err := CheckBookImbalance(0.5, -0.4, true); err != nil {
+		t.Fatalf("expected a mild lean to pass, got %v", err)
+	}
+	if err := CheckBookImbalance(0.5, -0.5, true); !errors.Is(err, ErrBookImbalance) {
+		t.Fatalf("expected ErrBookImbalance, got %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package strategy
 
+import "time"
+
 type State string
 
 type Event string
@@ -16,22 +18,43 @@ const (
 	EventHedgeOK Event = "HEDGE_OK"
 	EventExit    Event = "EXIT"
 	EventDone    Event = "DONE"
+	// EventAbort ends a failed entry whose legs were rolled back.
+	EventAbort Event = "ABORT"
+	// EventFlat returns to IDLE when reconciliation finds no exposure and
+	// no open orders, whatever the flow in progress.
+	EventFlat Event = "FLAT"
 )
 
 type MarketSnapshot struct {
-	PerpAsset      string
-	SpotAsset      string
-	SpotMidPrice   float64
-	PerpMidPrice   float64
-	OraclePrice    float64
-	FundingRate    float64
-	Volatility     float64
-	NotionalUSD    float64
-	SpotBalance    float64
-	PerpPosition   float64
-	OpenOrderCount int
-	MarginRatio    float64
-	HealthRatio    float64
-	HasMarginRatio bool
-	HasHealthRatio bool
+	PerpAsset    string
+	SpotAsset    string
+	SpotMidPrice float64
+	PerpMidPrice float64
+	OraclePrice  float64
+	MarkPrice    float64
+	FundingRate  float64
+	// FundingInterval is how often FundingRate is paid; zero means hourly.
+	FundingInterval time.Duration
+	Volatility      float64
+	NotionalUSD     float64
+	SpotBalance     float64
+	PerpPosition    float64
+	OpenOrderCount  int
+	MarginRatio     float64
+	HealthRatio     float64
+	HasMarginRatio  bool
+	HasHealthRatio  bool
+	// TradeImbalance is taker (buy - sell) / (buy + sell) notional on the perp
+	// over strategy.trade_flow_window.
+	TradeImbalance    float64
+	HasTradeImbalance bool
+	// BookImbalance is (bid - ask) / (bid + ask) resting notional over the
+	// top strategy.book_imbalance_levels of the perp l2Book.
+	BookImbalance    float64
+	HasBookImbalance bool
+}
+
+// FundingAPR is FundingRate annualized over FundingInterval.
+func (s MarketSnapshot) FundingAPR() float64 {
+	return FundingAPR(s.FundingRate, s.FundingInterval)
 }
@@ -0,0 +1,68 @@
+package strategy
+
+import "math"
+
+// Valuation bases. Liquidation and margin follow mark; funding accrues on
+// oracle.
+const (
+	ValuationOracle = "oracle"
+	ValuationMark   = "mark"
+)
+
+// Valuation prices the held legs on one basis.
+type Valuation struct {
+	Basis       string  `json:"basis"`
+	Price       float64 `json:"price"`
+	SpotUSD     float64 `json:"spot_usd"`
+	PerpUSD     float64 `json:"perp_usd"`
+	DeltaUSD    float64 `json:"delta_usd"`
+	NotionalUSD float64 `json:"notional_usd"`
+}
+
+// BasisPrice is the reference price for basis, falling back through the
+// other feeds when the preferred one is missing.
+func BasisPrice(snap MarketSnapshot, basis string) float64 {
+	if basis == ValuationMark {
+		for _, price := range []float64{snap.MarkPrice, snap.PerpMidPrice, snap.OraclePrice, snap.SpotMidPrice} {
+			if price > 0 {
+				return price
+			}
+		}
+		return 0
+	}
+	return priceForFunding(snap)
+}
+
+// Value prices the spot balance and perp position on basis. NotionalUSD is
+// the hedged size (perp leg, else spot leg, else the configured notional).
+func Value(snap MarketSnapshot, basis string) Valuation {
+	if basis != ValuationMark {
+		basis = ValuationOracle
+	}
+	price := BasisPrice(snap, basis)
+	v := Valuation{
+		Basis:    basis,
+		Price:    price,
+		SpotUSD:  snap.SpotBalance * price,
+		PerpUSD:  snap.PerpPosition * price,
+		DeltaUSD: (snap.SpotBalance + snap.PerpPosition) * price,
+	}
+	switch {
+	case price > 0 && snap.PerpPosition != 0:
+		v.NotionalUSD = math.Abs(v.PerpUSD)
+	case price > 0 && snap.SpotBalance != 0:
+		v.NotionalUSD = math.Abs(v.SpotUSD)
+	default:
+		v.NotionalUSD = snap.NotionalUSD
+	}
+	return v
+}
+
+// MarkOracleDivergence is |mark - oracle| / oracle. ok is false unless both
+// prices are known.
+func MarkOracleDivergence(snap MarketSnapshot) (float64, bool) {
+	if snap.MarkPrice <= 0 || snap.OraclePrice <= 0 {
+		return 0, false
+	}
+	return math.Abs(snap.MarkPrice-snap.OraclePrice) / snap.OraclePrice, true
+}
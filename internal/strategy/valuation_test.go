@@ -0,0 +1,68 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+
+	"hl-carry-bot/internal/config"
+)
+
+func TestValueBases(t *testing.T) {
+	snap := MarketSnapshot{
+		OraclePrice:  100,
+		MarkPrice:    102,
+		SpotBalance:  2,
+		PerpPosition: -2,
+	}
+	oracle := Value(snap, ValuationOracle)
+	if oracle.Price != 100 || oracle.NotionalUSD != 200 || oracle.SpotUSD != 200 || oracle.PerpUSD != -200 {
+		t.Fatalf("unexpected oracle valuation: %+v", oracle)
+	}
+	mark := Value(snap, ValuationMark)
+	if mark.Price != 102 || mark.NotionalUSD != 204 || mark.DeltaUSD != 0 {
+		t.Fatalf("unexpected mark valuation: %+v", mark)
+	}
+	if got := Value(snap, "").Basis; got != ValuationOracle {
+		t.Fatalf("expected oracle default, got %s", got)
+	}
+}
+
+func TestBasisPriceFallbacks(t *testing.T) {
+	snap := MarketSnapshot{PerpMidPrice: 101, OraclePrice: 100}
+	if got := BasisPrice(snap, ValuationMark); got != 101 {
+		t.Fatalf("expected mark to fall back to perp mid, got %f", got)
+	}
+	if got := BasisPrice(MarketSnapshot{SpotMidPrice: 99}, ValuationMark); got != 99 {
+		t.Fatalf("expected mark to fall back to spot mid, got %f", got)
+	}
+	if got := BasisPrice(MarketSnapshot{MarkPrice: 105, PerpMidPrice: 101}, ValuationOracle); got != 101 {
+		t.Fatalf("expected oracle basis to ignore mark, got %f", got)
+	}
+}
+
+func TestMarkOracleDivergence(t *testing.T) {
+	if _, ok := MarkOracleDivergence(MarketSnapshot{OraclePrice: 100}); ok {
+		t.Fatalf("expected no divergence without mark")
+	}
+	got, ok := MarkOracleDivergence(MarketSnapshot{OraclePrice: 100, MarkPrice: 98})
+	if !ok || math.Abs(got-0.02) > 1e-12 {
+		t.Fatalf("expected 0.02 divergence, got %f (%v)", got, ok)
+	}
+}
+
+func TestCheckRiskUsesValuationBasis(t *testing.T) {
+	snap := MarketSnapshot{
+		OraclePrice:  100,
+		MarkPrice:    110,
+		SpotBalance:  1,
+		PerpPosition: -1,
+	}
+	cfg := config.RiskConfig{MaxNotionalUSD: 105}
+	if err := CheckRisk(cfg, snap); err != nil {
+		t.Fatalf("expected oracle notional within limit, got %v", err)
+	}
+	cfg.ValuationBasis = ValuationMark
+	if err := CheckRisk(cfg, snap); err == nil {
+		t.Fatalf("expected mark notional over limit, got %v", err)
+	}
+}
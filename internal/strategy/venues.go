@@ -0,0 +1,22 @@
+package strategy
+
+import "errors"
+
+var ErrVenuePremium = errors.New("hyperliquid funding premium over other venues too high")
+
+// VenueFundingPremium is how far the Hyperliquid hourly rate sits above the
+// highest hourly rate among other venues. A large premium tends to mean-revert,
+// so entering on it usually collects less carry than the rate suggests. ok is
+// false when there is no other venue to compare against.
+func VenueFundingPremium(hlHourly float64, otherHourly []float64) (float64, bool) {
+	if len(otherHourly) == 0 {
+		return 0, false
+	}
+	best := otherHourly[0]
+	for _, rate := range otherHourly[1:] {
+		if rate > best {
+			best = rate
+		}
+	}
+	return hlHourly - best, true
+}
@@ -0,0 +1,20 @@
+package strategy
+
+import "testing"
+
+func TestVenueFundingPremium(t *testing.T) {
+	if _, ok := VenueFundingPremium(0.001, nil); ok {
+		t.Fatalf("expected no premium without other venues")
+	}
+	premium, ok := VenueFundingPremium(0.001, []float64{0.0002, 0.0004})
+	if !ok {
+		t.Fatalf("expected premium")
+	}
+	if diff := premium - 0.0006; diff > 1e-12 || diff < -1e-12 {
+		t.Fatalf("expected premium 0.0006, got %v", premium)
+	}
+	premium, _ = VenueFundingPremium(0.0001, []float64{0.0003})
+	if premium >= 0 {
+		t.Fatalf("expected negative premium when other venue pays more, got %v", premium)
+	}
+}
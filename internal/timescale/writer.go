@@ -11,6 +11,7 @@ import (
 
 	"hl-carry-bot/internal/config"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"go.uber.org/zap"
 )
@@ -51,15 +52,69 @@ type PositionSnapshot struct {
 	OpenOrders      int
 }
 
+// Order is one order action by the executor: Event is placed, rejected or
+// cancelled. Coin is the fill-stream name of the asset ("ETH", "@107") when
+// known.
+type Order struct {
+	Time       time.Time
+	Event      string
+	OrderID    string
+	Cloid      string
+	AssetID    int
+	Coin       string
+	IsBuy      bool
+	Size       float64
+	LimitPrice float64
+	Tif        string
+	ReduceOnly bool
+	Reason     string
+}
+
+// Fill is one execution from the account fill stream.
+type Fill struct {
+	Time      time.Time
+	OrderID   string
+	Cloid     string
+	TradeID   string
+	Hash      string
+	Coin      string
+	Side      string
+	Size      float64
+	Price     float64
+	Fee       float64
+	ClosedPnL float64
+}
+
 type Writer struct {
-	db         *sql.DB
+	db         execer
+	closer     func() error
 	log        *zap.Logger
 	schema     string
+	cfg        config.TimescaleConfig
 	positions  chan PositionSnapshot
 	candles    chan Candle
+	orders     chan Order
+	fills      chan Fill
 	started    atomic.Bool
+	done       chan struct{}
 	dropPos    atomic.Uint64
 	dropCandle atomic.Uint64
+	dropOrder  atomic.Uint64
+	dropFill   atomic.Uint64
+
+	positionRows *batch
+	candleRows   *batch
+	orderRows    *batch
+	fillRows     *batch
+	failing      bool
+
+	rowsWritten  interface{ Inc() }
+	rowsDropped  interface{ Inc() }
+	writeLatency interface{ Set(float64) }
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
 func New(cfg config.TimescaleConfig, log *zap.Logger) (*Writer, error) {
@@ -70,10 +125,6 @@ func New(cfg config.TimescaleConfig, log *zap.Logger) (*Writer, error) {
 	if dsn == "" {
 		return nil, errors.New("timescale dsn is required")
 	}
-	schema := strings.TrimSpace(cfg.Schema)
-	if schema == "" {
-		schema = "public"
-	}
 	db, err := sql.Open("pgx", dsn)
 	if err != nil {
 		return nil, err
@@ -93,22 +144,92 @@ func New(cfg config.TimescaleConfig, log *zap.Logger) (*Writer, error) {
 		_ = db.Close()
 		return nil, err
 	}
+	writer := newWriter(db, cfg, log)
+	writer.closer = db.Close
+	if err := writer.ensureSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+func newWriter(db execer, cfg config.TimescaleConfig, log *zap.Logger) *Writer {
+	schema := strings.TrimSpace(cfg.Schema)
+	if schema == "" {
+		schema = "public"
+	}
+	cfg.Schema = schema
 	queueSize := cfg.QueueSize
 	if queueSize <= 0 {
 		queueSize = 256
 	}
-	writer := &Writer{
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+	if cfg.MaxBacklog < cfg.BatchSize {
+		cfg.MaxBacklog = cfg.BatchSize
+	}
+	w := &Writer{
 		db:        db,
 		log:       log,
 		schema:    schema,
+		cfg:       cfg,
 		positions: make(chan PositionSnapshot, queueSize),
 		candles:   make(chan Candle, queueSize),
+		orders:    make(chan Order, queueSize),
+		fills:     make(chan Fill, queueSize),
+		done:      make(chan struct{}),
 	}
-	if err := writer.ensureSchema(ctx); err != nil {
-		_ = db.Close()
-		return nil, err
+	w.positionRows = &batch{
+		name: "position_snapshots",
+		columns: []string{
+			"ts", "state", "spot_asset", "perp_asset", "spot_balance", "perp_position", "spot_mid", "perp_mid",
+			"oracle_price", "funding_rate", "volatility", "delta_usd", "spot_exposure_usd", "perp_exposure_usd",
+			"notional_usd", "margin_ratio", "health_ratio", "has_margin_ratio", "has_health_ratio", "open_orders",
+		},
 	}
-	return writer, nil
+	// A candle is re-sent every tick while it is open; only its latest
+	// values are kept, since one upsert cannot touch a row twice.
+	w.candleRows = &batch{
+		name:    "market_ohlc",
+		columns: []string{"ts", "asset", "interval", "open", "high", "low", "close", "volume"},
+		conflict: ` ON CONFLICT (ts, asset, interval) DO UPDATE SET
+		open = EXCLUDED.open,
+		high = EXCLUDED.high,
+		low = EXCLUDED.low,
+		close = EXCLUDED.close,
+		volume = EXCLUDED.volume`,
+		keyColumns: 3,
+	}
+	w.orderRows = &batch{
+		name:    "orders",
+		columns: []string{"ts", "event", "order_id", "cloid", "asset_id", "coin", "is_buy", "size", "limit_px", "tif", "reduce_only", "reason"},
+	}
+	// The fill stream can replay fills after a reconnect; each is inserted
+	// once.
+	w.fillRows = &batch{
+		name:     "fills",
+		columns:  []string{"ts", "fill_id", "order_id", "cloid", "trade_id", "hash", "coin", "side", "size", "px", "fee", "closed_pnl"},
+		conflict: " ON CONFLICT (ts, fill_id) DO NOTHING",
+	}
+	return w
+}
+
+// SetMetrics reports rows written, rows dropped (queue overflow, backlog
+// overflow or a permanent error) and the latency of the last batch insert.
+func (w *Writer) SetMetrics(written, dropped interface{ Inc() }, latency interface{ Set(float64) }) {
+	if w == nil {
+		return
+	}
+	w.rowsWritten = written
+	w.rowsDropped = dropped
+	w.writeLatency = latency
 }
 
 func (w *Writer) Start(ctx context.Context) {
@@ -121,11 +242,28 @@ func (w *Writer) Start(ctx context.Context) {
 	go w.run(ctx)
 }
 
+// Ping checks that the database still answers.
+func (w *Writer) Ping(ctx context.Context) error {
+	if w == nil {
+		return errors.New("timescale is disabled")
+	}
+	_, err := w.db.ExecContext(ctx, "SELECT 1")
+	return err
+}
+
+// Close waits briefly for the final flush after the Start context ends,
+// then closes the database.
 func (w *Writer) Close() error {
-	if w == nil || w.db == nil {
+	if w == nil || w.closer == nil {
 		return nil
 	}
-	return w.db.Close()
+	if w.started.Load() {
+		select {
+		case <-w.done:
+		case <-time.After(2 * writeTimeout):
+		}
+	}
+	return w.closer()
 }
 
 func (w *Writer) EnqueuePosition(snapshot PositionSnapshot) {
@@ -136,6 +274,7 @@ func (w *Writer) EnqueuePosition(snapshot PositionSnapshot) {
 	case w.positions <- snapshot:
 		return
 	default:
+		w.countDropped(1)
 		if w.dropPos.Add(1) == 1 && w.log != nil {
 			w.log.Warn("timescale position queue full")
 		}
@@ -150,25 +289,263 @@ func (w *Writer) EnqueueCandle(candle Candle) {
 	case w.candles <- candle:
 		return
 	default:
+		w.countDropped(1)
 		if w.dropCandle.Add(1) == 1 && w.log != nil {
 			w.log.Warn("timescale candle queue full")
 		}
 	}
 }
 
+func (w *Writer) EnqueueOrder(order Order) {
+	if w == nil {
+		return
+	}
+	select {
+	case w.orders <- order:
+		return
+	default:
+		w.countDropped(1)
+		if w.dropOrder.Add(1) == 1 && w.log != nil {
+			w.log.Warn("timescale order queue full")
+		}
+	}
+}
+
+func (w *Writer) EnqueueFill(fill Fill) {
+	if w == nil {
+		return
+	}
+	select {
+	case w.fills <- fill:
+		return
+	default:
+		w.countDropped(1)
+		if w.dropFill.Add(1) == 1 && w.log != nil {
+			w.log.Warn("timescale fill queue full")
+		}
+	}
+}
+
 func (w *Writer) run(ctx context.Context) {
+	defer close(w.done)
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
+			w.drain()
 			return
 		case snap := <-w.positions:
-			w.writePosition(ctx, snap)
+			w.add(ctx, w.positionRows, positionRow(snap))
+		case candle := <-w.candles:
+			w.add(ctx, w.candleRows, candleRow(candle))
+		case order := <-w.orders:
+			w.add(ctx, w.orderRows, orderRow(order))
+		case fill := <-w.fills:
+			w.add(ctx, w.fillRows, fillRow(fill))
+		case <-ticker.C:
+			w.flushAll(ctx)
+		}
+	}
+}
+
+// drain writes whatever is queued or pending on shutdown, once and without
+// retries.
+func (w *Writer) drain() {
+	for {
+		select {
+		case snap := <-w.positions:
+			w.positionRows.add(positionRow(snap))
 		case candle := <-w.candles:
-			w.writeCandle(ctx, candle)
+			w.candleRows.add(candleRow(candle))
+		case order := <-w.orders:
+			w.orderRows.add(orderRow(order))
+		case fill := <-w.fills:
+			w.fillRows.add(fillRow(fill))
+		default:
+			w.cfg.MaxRetries = 0
+			w.flushAll(context.Background())
+			return
+		}
+	}
+}
+
+func (w *Writer) add(ctx context.Context, b *batch, row []any) {
+	b.add(row)
+	w.countDropped(b.trim(w.cfg.MaxBacklog))
+	if len(b.pending) >= w.cfg.BatchSize && !w.failing {
+		w.flush(ctx, b)
+	}
+}
+
+func (w *Writer) flushAll(ctx context.Context) {
+	for _, b := range []*batch{w.candleRows, w.positionRows, w.orderRows, w.fillRows} {
+		if !w.flush(ctx, b) {
+			return
+		}
+	}
+}
+
+// flush writes b's pending rows in batches. A batch that still fails after
+// the retries stays pending for the next flush; one rejected by the database
+// itself is dropped. It reports whether the database accepted everything.
+func (w *Writer) flush(ctx context.Context, b *batch) bool {
+	for len(b.pending) > 0 {
+		n := min(len(b.pending), w.cfg.BatchSize)
+		query, args := b.insert(w.table(b.name), b.pending[:n])
+		err := w.execWithRetry(ctx, query, args)
+		if err != nil && isTransient(err) {
+			if !w.failing && w.log != nil {
+				w.log.Warn("timescale write failed; keeping rows for retry",
+					zap.String("table", b.name),
+					zap.Int("pending", len(b.pending)),
+					zap.Error(err),
+				)
+			}
+			w.failing = true
+			return false
+		}
+		b.pending = b.pending[n:]
+		if err != nil {
+			w.countDropped(n)
+			if w.log != nil {
+				w.log.Warn("timescale insert rejected; dropping rows", zap.String("table", b.name), zap.Int("rows", n), zap.Error(err))
+			}
+			continue
+		}
+		if w.failing && w.log != nil {
+			w.log.Info("timescale writes recovered")
+		}
+		w.failing = false
+		if w.rowsWritten != nil {
+			for i := 0; i < n; i++ {
+				w.rowsWritten.Inc()
+			}
+		}
+	}
+	b.pending = nil
+	return true
+}
+
+func (w *Writer) execWithRetry(ctx context.Context, query string, args []any) error {
+	backoff := w.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		execCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+		_, err := w.db.ExecContext(execCtx, query, args...)
+		cancel()
+		if err == nil {
+			if w.writeLatency != nil {
+				w.writeLatency.Set(time.Since(start).Seconds())
+			}
+			return nil
+		}
+		if attempt >= w.cfg.MaxRetries || !isTransient(err) || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
 		}
+		backoff *= 2
+	}
+}
+
+func (w *Writer) countDropped(n int) {
+	if w.rowsDropped == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		w.rowsDropped.Inc()
 	}
 }
 
+// isTransient reports whether a failed insert may succeed later: connection
+// loss, timeouts and server-side resource or availability errors. Errors
+// about the rows themselves (bad data, constraint or schema errors) are not.
+func isTransient(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && len(pgErr.Code) >= 2 {
+		switch pgErr.Code[:2] {
+		case "08", "40", "53", "57", "58":
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// batch holds one table's rows waiting to be written.
+type batch struct {
+	name     string
+	columns  []string
+	conflict string
+	// keyColumns > 0 keeps only the latest row per key (the leading columns).
+	keyColumns int
+	pending    [][]any
+}
+
+func (b *batch) add(row []any) {
+	if b.keyColumns > 0 {
+		for i := len(b.pending) - 1; i >= 0; i-- {
+			if sameKey(b.pending[i], row, b.keyColumns) {
+				b.pending[i] = row
+				return
+			}
+		}
+	}
+	b.pending = append(b.pending, row)
+}
+
+// trim drops the oldest rows beyond max and returns how many were dropped.
+func (b *batch) trim(max int) int {
+	over := len(b.pending) - max
+	if over <= 0 {
+		return 0
+	}
+	b.pending = append(b.pending[:0:0], b.pending[over:]...)
+	return over
+}
+
+// insert builds one multi-row INSERT for rows.
+func (b *batch) insert(table string, rows [][]any) (string, []any) {
+	var query strings.Builder
+	fmt.Fprintf(&query, "INSERT INTO %s (%s) VALUES ", table, strings.Join(b.columns, ", "))
+	args := make([]any, 0, len(rows)*len(b.columns))
+	for i, row := range rows {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteByte('(')
+		for j := range row {
+			if j > 0 {
+				query.WriteByte(',')
+			}
+			fmt.Fprintf(&query, "$%d", len(args)+j+1)
+		}
+		query.WriteByte(')')
+		args = append(args, row...)
+	}
+	query.WriteString(b.conflict)
+	return query.String(), args
+}
+
+func sameKey(a, b []any, n int) bool {
+	for i := 0; i < n; i++ {
+		if at, ok := a[i].(time.Time); ok {
+			if bt, ok := b[i].(time.Time); !ok || !at.Equal(bt) {
+				return false
+			}
+			continue
+		}
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (w *Writer) ensureSchema(ctx context.Context) error {
 	if w.db == nil {
 		return errors.New("timescale db not initialized")
@@ -215,35 +592,55 @@ func (w *Writer) ensureSchema(ctx context.Context) error {
 	)`, w.table("position_snapshots"))); err != nil {
 		return err
 	}
+	if err := w.exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		ts TIMESTAMPTZ NOT NULL,
+		event TEXT NOT NULL,
+		order_id TEXT NOT NULL,
+		cloid TEXT NOT NULL,
+		asset_id INTEGER NOT NULL,
+		coin TEXT NOT NULL,
+		is_buy BOOLEAN NOT NULL,
+		size DOUBLE PRECISION NOT NULL,
+		limit_px DOUBLE PRECISION NOT NULL,
+		tif TEXT NOT NULL,
+		reduce_only BOOLEAN NOT NULL,
+		reason TEXT NOT NULL
+	)`, w.table("orders"))); err != nil {
+		return err
+	}
+	if err := w.exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		ts TIMESTAMPTZ NOT NULL,
+		fill_id TEXT NOT NULL,
+		order_id TEXT NOT NULL,
+		cloid TEXT NOT NULL,
+		trade_id TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		coin TEXT NOT NULL,
+		side TEXT NOT NULL,
+		size DOUBLE PRECISION NOT NULL,
+		px DOUBLE PRECISION NOT NULL,
+		fee DOUBLE PRECISION NOT NULL,
+		closed_pnl DOUBLE PRECISION NOT NULL,
+		PRIMARY KEY (ts, fill_id)
+	)`, w.table("fills"))); err != nil {
+		return err
+	}
 	if err := w.exec(ctx, "CREATE EXTENSION IF NOT EXISTS timescaledb"); err != nil {
 		if w.log != nil {
 			w.log.Warn("timescale extension ensure failed", zap.Error(err))
 		}
 		return nil
 	}
-	if err := w.exec(ctx, fmt.Sprintf("SELECT create_hypertable('%s', 'ts', if_not_exists => TRUE)", w.table("market_ohlc"))); err != nil && w.log != nil {
-		w.log.Warn("timescale market_ohlc hypertable create failed", zap.Error(err))
-	}
-	if err := w.exec(ctx, fmt.Sprintf("SELECT create_hypertable('%s', 'ts', if_not_exists => TRUE)", w.table("position_snapshots"))); err != nil && w.log != nil {
-		w.log.Warn("timescale position_snapshots hypertable create failed", zap.Error(err))
+	for _, name := range []string{"market_ohlc", "position_snapshots", "orders", "fills"} {
+		if err := w.exec(ctx, fmt.Sprintf("SELECT create_hypertable('%s', 'ts', if_not_exists => TRUE)", w.table(name))); err != nil && w.log != nil {
+			w.log.Warn("timescale "+name+" hypertable create failed", zap.Error(err))
+		}
 	}
 	return nil
 }
 
-func (w *Writer) writePosition(ctx context.Context, snap PositionSnapshot) {
-	if w.db == nil {
-		return
-	}
-	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
-	defer cancel()
-	query := fmt.Sprintf(`INSERT INTO %s (
-		ts, state, spot_asset, perp_asset, spot_balance, perp_position, spot_mid, perp_mid,
-		oracle_price, funding_rate, volatility, delta_usd, spot_exposure_usd, perp_exposure_usd,
-		notional_usd, margin_ratio, health_ratio, has_margin_ratio, has_health_ratio, open_orders
-	) VALUES (
-		$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20
-	)`, w.table("position_snapshots"))
-	if _, err := w.db.ExecContext(ctx, query,
+func positionRow(snap PositionSnapshot) []any {
+	return []any{
 		snap.Time,
 		snap.State,
 		snap.SpotAsset,
@@ -264,29 +661,11 @@ func (w *Writer) writePosition(ctx context.Context, snap PositionSnapshot) {
 		snap.HasMarginRatio,
 		snap.HasHealthRatio,
 		snap.OpenOrders,
-	); err != nil && w.log != nil {
-		w.log.Warn("timescale position insert failed", zap.Error(err))
 	}
 }
 
-func (w *Writer) writeCandle(ctx context.Context, candle Candle) {
-	if w.db == nil {
-		return
-	}
-	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
-	defer cancel()
-	query := fmt.Sprintf(`INSERT INTO %s (
-		ts, asset, interval, open, high, low, close, volume
-	) VALUES (
-		$1,$2,$3,$4,$5,$6,$7,$8
-	)
-	ON CONFLICT (ts, asset, interval) DO UPDATE SET
-		open = EXCLUDED.open,
-		high = EXCLUDED.high,
-		low = EXCLUDED.low,
-		close = EXCLUDED.close,
-		volume = EXCLUDED.volume`, w.table("market_ohlc"))
-	if _, err := w.db.ExecContext(ctx, query,
+func candleRow(candle Candle) []any {
+	return []any{
 		candle.Start,
 		candle.Asset,
 		candle.Interval,
@@ -295,11 +674,52 @@ func (w *Writer) writeCandle(ctx context.Context, candle Candle) {
 		candle.Low,
 		candle.Close,
 		candle.Volume,
-	); err != nil && w.log != nil {
-		w.log.Warn("timescale candle upsert failed", zap.Error(err))
 	}
 }
 
+func orderRow(order Order) []any {
+	return []any{
+		order.Time,
+		order.Event,
+		order.OrderID,
+		order.Cloid,
+		order.AssetID,
+		order.Coin,
+		order.IsBuy,
+		order.Size,
+		order.LimitPrice,
+		order.Tif,
+		order.ReduceOnly,
+		order.Reason,
+	}
+}
+
+func fillRow(fill Fill) []any {
+	return []any{
+		fill.Time,
+		fillID(fill),
+		fill.OrderID,
+		fill.Cloid,
+		fill.TradeID,
+		fill.Hash,
+		fill.Coin,
+		fill.Side,
+		fill.Size,
+		fill.Price,
+		fill.Fee,
+		fill.ClosedPnL,
+	}
+}
+
+// fillID matches the accounting journal's fill ids: the trade id, or
+// hash/oid for older payloads without one (ts is already in the key).
+func fillID(fill Fill) string {
+	if fill.TradeID != "" {
+		return fill.TradeID
+	}
+	return fill.Hash + ":" + fill.OrderID
+}
+
 func (w *Writer) exec(ctx context.Context, query string) error {
 	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
 	defer cancel()
@@ -0,0 +1,109 @@
+package timescale
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"hl-carry-bot/internal/config"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type fakeDB struct {
+	queries []string
+	rows    []int
+	errs    []error
+}
+
+func (f *fakeDB) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	f.queries = append(f.queries, query)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.rows = append(f.rows, strings.Count(query, "($"))
+	return nil, nil
+}
+
+type count struct{ n int }
+
+func (c *count) Inc() { c.n++ }
+
+func newTestWriter(db *fakeDB) (*Writer, *count, *count) {
+	w := newWriter(db, config.TimescaleConfig{
+		BatchSize:    2,
+		MaxRetries:   1,
+		RetryBackoff: time.Millisecond,
+		MaxBacklog:   3,
+	}, nil)
+	written, dropped := &count{}, &count{}
+	w.SetMetrics(written, dropped, nil)
+	return w, written, dropped
+}
+
+func TestWriterBatchesRowsAndKeepsLatestCandle(t *testing.T) {
+	db := &fakeDB{}
+	w, written, _ := newTestWriter(db)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		w.add(ctx, w.orderRows, orderRow(Order{OrderID: "1"}))
+	}
+	if len(db.queries) != 1 || db.rows[0] != 2 || !strings.Contains(db.queries[0], "$24)") {
+		t.Fatalf("expected one 2-row insert at batch size, got %v", db.queries)
+	}
+	start := time.Unix(60, 0)
+	w.add(ctx, w.candleRows, candleRow(Candle{Asset: "ETH", Interval: "1m", Start: start, Close: 1}))
+	w.add(ctx, w.candleRows, candleRow(Candle{Asset: "ETH", Interval: "1m", Start: start.UTC(), Close: 2}))
+	if len(w.candleRows.pending) != 1 || w.candleRows.pending[0][6] != 2.0 {
+		t.Fatalf("expected only the latest candle pending, got %v", w.candleRows.pending)
+	}
+	w.flushAll(ctx)
+	if written.n != 4 || len(w.orderRows.pending) != 0 || len(w.candleRows.pending) != 0 {
+		t.Fatalf("expected all rows written, got %d", written.n)
+	}
+	if !strings.Contains(db.queries[1], "ON CONFLICT (ts, asset, interval) DO UPDATE") {
+		t.Fatalf("expected candle upsert, got %s", db.queries[1])
+	}
+}
+
+func TestWriterRetriesThenKeepsBacklog(t *testing.T) {
+	down := errors.New("connection refused")
+	db := &fakeDB{errs: []error{down, down}}
+	w, written, dropped := newTestWriter(db)
+	ctx := context.Background()
+	w.add(ctx, w.fillRows, fillRow(Fill{TradeID: "1"}))
+	w.add(ctx, w.fillRows, fillRow(Fill{TradeID: "2"}))
+	if len(db.queries) != 2 || !w.failing || len(w.fillRows.pending) != 2 {
+		t.Fatalf("expected a retried insert kept pending, got %d queries, pending %d", len(db.queries), len(w.fillRows.pending))
+	}
+	w.add(ctx, w.fillRows, fillRow(Fill{TradeID: "3"}))
+	w.add(ctx, w.fillRows, fillRow(Fill{TradeID: "4"}))
+	if len(db.queries) != 2 {
+		t.Fatalf("expected no batch writes while failing, got %d queries", len(db.queries))
+	}
+	if dropped.n != 1 || w.fillRows.pending[0][1] != "2" {
+		t.Fatalf("expected the oldest row dropped past the backlog, got %d dropped", dropped.n)
+	}
+	w.flushAll(ctx)
+	if w.failing || written.n != 3 || len(w.fillRows.pending) != 0 {
+		t.Fatalf("expected the backlog written on recovery, got %d written", written.n)
+	}
+}
+
+func TestWriterDropsRejectedBatch(t *testing.T) {
+	db := &fakeDB{errs: []error{&pgconn.PgError{Code: "22P02"}}}
+	w, written, dropped := newTestWriter(db)
+	ctx := context.Background()
+	w.add(ctx, w.positionRows, positionRow(PositionSnapshot{State: "IDLE"}))
+	w.add(ctx, w.positionRows, positionRow(PositionSnapshot{State: "IDLE"}))
+	if len(db.queries) != 1 || dropped.n != 2 || written.n != 0 || w.failing {
+		t.Fatalf("expected the rejected batch dropped without retry, got %d queries, %d dropped", len(db.queries), dropped.n)
+	}
+}